@@ -0,0 +1,171 @@
+// Package client is a Go client for the expense tracker API, so other services don't have to write the HTTP calls.
+//
+//	c := client.New("https://expenses.example.com", client.WithToken(token))
+//	created, err := c.Create(ctx, &client.ExpenseInput{OccuredAt: time.Now(), Description: "lunch", Amount: 1250})
+//
+// Reads, updates, and deletes are retried on network errors, 429, 502, 503, and 504.
+// Creates are only retried on 429, which the rate limiter sends before the request is handled,
+// since a create that timed out may still have been recorded.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaults for the options below
+const (
+	defaultRetries = 2
+	defaultBackoff = 250 * time.Millisecond
+	defaultTimeout = 30 * time.Second
+	maxRetryAfter  = time.Minute
+)
+
+// Client calls the API at one base URL, it is safe for concurrent use
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+	retries int
+	backoff time.Duration
+}
+
+// Option configures optional parts of the Client
+type Option func(*Client)
+
+// WithToken authenticates every request with an "Authorization: Bearer" token,
+// either a login token or an API token from POST /users/me/tokens
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithHTTPClient replaces the default http.Client, which times out after 30 seconds
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.http = httpClient }
+}
+
+// WithRetries sets how many times a failed request is retried, and the wait before the first retry.
+// The wait doubles with each retry, unless the response says how long to wait with Retry-After
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) { c.retries, c.backoff = retries, backoff }
+}
+
+// New is a client for the API at baseURL, i.e. "http://localhost:8080"
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: defaultTimeout},
+		retries: defaultRetries,
+		backoff: defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// retryable reports whether a request can be sent again after this response or error
+func retryable(method string, resp *http.Response, err error) bool {
+	if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if method == http.MethodPost {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryWait is how long to wait before the attempt after attempt, Retry-After when the response has one
+func (c *Client) retryWait(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, maxRetryAfter)
+		}
+	}
+	return c.backoff << attempt
+}
+
+// send makes the request, retrying as described in the package docs.
+// A 2xx response is returned for the caller to read and close, anything else is turned into an *Error
+func (c *Client) send(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	var encoded []byte
+	if body != nil {
+		var err error
+		if encoded, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(encoded))
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+
+		resp, err := c.http.Do(req)
+		if err == nil && resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+			return resp, nil
+		}
+
+		if attempt >= c.retries || !retryable(method, resp, err) {
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+			return nil, readError(resp)
+		}
+
+		wait := c.retryWait(attempt, resp)
+		if resp != nil {
+			// drained so the connection can be reused
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// do sends the request and decodes the json response into out, when out is not nil
+func (c *Client) do(ctx context.Context, method, path string, body, out any) (http.Header, error) {
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.Header, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return nil, fmt.Errorf("unable to decode %s %s response: %w", method, path, err)
+	}
+	return resp.Header, nil
+}
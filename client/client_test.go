@@ -0,0 +1,197 @@
+package client_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/client"
+)
+
+func TestRetries(t *testing.T) {
+	testTable := []struct {
+		name         string
+		inputMethod  string
+		inputStatus  []int // of each attempt, the last repeats
+		expectError  bool
+		wantError    error
+		wantAttempts int32
+	}{
+		{
+			name:         "valid-get-retried-until-ok",
+			inputMethod:  http.MethodGet,
+			inputStatus:  []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			wantAttempts: 3,
+		},
+		{
+			name:         "valid-create-retried-when-rate-limited",
+			inputMethod:  http.MethodPost,
+			inputStatus:  []int{http.StatusTooManyRequests, http.StatusCreated},
+			wantAttempts: 2,
+		},
+		{
+			name:         "invalid-get-gives-up-after-retries",
+			inputMethod:  http.MethodGet,
+			inputStatus:  []int{http.StatusServiceUnavailable},
+			expectError:  true,
+			wantError:    client.ErrUnavailable,
+			wantAttempts: 3,
+		},
+		{
+			name:         "invalid-create-not-retried-on-server-error",
+			inputMethod:  http.MethodPost,
+			inputStatus:  []int{http.StatusServiceUnavailable, http.StatusCreated},
+			expectError:  true,
+			wantError:    client.ErrServer,
+			wantAttempts: 1,
+		},
+		{
+			name:         "invalid-not-found-not-retried",
+			inputMethod:  http.MethodGet,
+			inputStatus:  []int{http.StatusNotFound, http.StatusOK},
+			expectError:  true,
+			wantError:    client.ErrNotFound,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempt := int(attempts.Add(1)) - 1
+				status := testCase.inputStatus[min(attempt, len(testCase.inputStatus)-1)]
+				if status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "0")
+				}
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(`{"id":1,"description":"lunch","amount":1250}`))
+			}))
+			defer srv.Close()
+
+			c := client.New(srv.URL, client.WithRetries(2, time.Millisecond))
+			var gotErr error
+			if testCase.inputMethod == http.MethodPost {
+				_, gotErr = c.Create(t.Context(), &client.ExpenseInput{Description: "lunch", Amount: 1250})
+			} else {
+				_, gotErr = c.Get(t.Context(), 1)
+			}
+
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+			if testCase.expectError && !errors.Is(gotErr, testCase.wantError) {
+				t.Errorf("got error: '%v', want error: '%v'", gotErr, testCase.wantError)
+			}
+			if got := attempts.Load(); got != testCase.wantAttempts {
+				t.Errorf("got %d attempts, want %d", got, testCase.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestErrorBody(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputStatus int
+		inputBody   string
+		wantError   error
+		wantMessage string
+	}{
+		{
+			name:        "valid-message",
+			inputStatus: http.StatusUnauthorized,
+			inputBody:   `{"error":"Unauthorized"}`,
+			wantError:   client.ErrUnauthorized,
+			wantMessage: "expense api responded 401: Unauthorized",
+		},
+		{
+			name:        "valid-issues",
+			inputStatus: http.StatusBadRequest,
+			inputBody:   `{"code":400,"issues":["amount must be positive","description is required"]}`,
+			wantError:   client.ErrBadRequest,
+			wantMessage: "expense api responded 400: amount must be positive; description is required",
+		},
+		{
+			name:        "valid-not-json",
+			inputStatus: http.StatusForbidden,
+			inputBody:   `<html>forbidden</html>`,
+			wantError:   client.ErrForbidden,
+			wantMessage: "expense api responded 403",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testCase.inputStatus)
+				_, _ = w.Write([]byte(testCase.inputBody))
+			}))
+			defer srv.Close()
+
+			gotErr := client.New(srv.URL).Delete(t.Context(), 1)
+			if !errors.Is(gotErr, testCase.wantError) {
+				t.Errorf("got error: '%v', want error: '%v'", gotErr, testCase.wantError)
+			}
+			if gotErr == nil || gotErr.Error() != testCase.wantMessage {
+				t.Errorf("got message: '%v', want message: '%s'", gotErr, testCase.wantMessage)
+			}
+		})
+	}
+}
+
+func TestListAndUpdate(t *testing.T) {
+	var gotQuery, gotPath string
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			gotQuery = r.URL.RawQuery
+			w.Header().Set("Link", `</expenses?cursor=abc123&limit=1>; rel="next"`)
+			_, _ = w.Write([]byte(`[{"id":2,"occured_at":"2025-10-02T00:00:00Z","description":"two","amount":200}]`))
+		case http.MethodPut:
+			gotPath = r.URL.Path
+			_ = json.NewDecoder(r.Body).Decode(&gotBody)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL, client.WithToken("secret"))
+
+	page, err := c.List(t.Context(), client.ListOptions{
+		Limit:     1,
+		From:      time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC),
+		MinAmount: 100,
+	})
+	if err != nil {
+		t.Fatalf("List() got error: '%v'", err)
+	}
+	if want := "from=2025-10-01T00%3A00%3A00Z&limit=1&min_amount=100"; gotQuery != want {
+		t.Errorf("got query: %s, want: %s", gotQuery, want)
+	}
+	if len(page.Expenses) != 1 || page.Expenses[0].ID != 2 || page.NextCursor != "abc123" {
+		t.Errorf("got page: %+v", page)
+	}
+
+	err = c.Update(t.Context(), 2, &client.ExpenseInput{
+		OccuredAt:   time.Date(2025, 10, 2, 0, 0, 0, 0, time.UTC),
+		Description: "two, corrected",
+		Amount:      250,
+	})
+	if err != nil {
+		t.Fatalf("Update() got error: '%v'", err)
+	}
+	if gotPath != "/expenses/2" || gotBody["description"] != "two, corrected" || gotBody["amount"] != float64(250) {
+		t.Errorf("got update of %s with body: %v", gotPath, gotBody)
+	}
+}
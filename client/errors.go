@@ -0,0 +1,76 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Each *Error matches the one of these for its status code with errors.Is, i.e.
+//
+//	if errors.Is(err, client.ErrNotFound) { ... }
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrGone         = errors.New("gone") // i.e. a sync cursor older than deletions are kept, see Client.Changes
+	ErrRateLimited  = errors.New("rate limited")
+	ErrUnavailable  = errors.New("service unavailable")
+	ErrServer       = errors.New("server error") // every 5xx
+)
+
+// statusErrors maps status codes to the errors above, 5xx are all ErrServer as well
+var statusErrors = map[int]error{
+	http.StatusBadRequest:         ErrBadRequest,
+	http.StatusUnauthorized:       ErrUnauthorized,
+	http.StatusForbidden:          ErrForbidden,
+	http.StatusNotFound:           ErrNotFound,
+	http.StatusConflict:           ErrConflict,
+	http.StatusGone:               ErrGone,
+	http.StatusTooManyRequests:    ErrRateLimited,
+	http.StatusServiceUnavailable: ErrUnavailable,
+}
+
+// Error is a response outside of 2xx. The API sends either a message, {"error": "Not Found"},
+// or the issues with a request body, {"code": 400, "issues": [...]}
+type Error struct {
+	StatusCode int
+	Message    string
+	Issues     []string
+}
+
+func (e *Error) Error() string {
+	switch {
+	case len(e.Issues) > 0:
+		return fmt.Sprintf("expense api responded %d: %s", e.StatusCode, strings.Join(e.Issues, "; "))
+	case e.Message != "":
+		return fmt.Sprintf("expense api responded %d: %s", e.StatusCode, e.Message)
+	default:
+		return fmt.Sprintf("expense api responded %d", e.StatusCode)
+	}
+}
+
+// Is matches the error of the status code, see ErrNotFound
+func (e *Error) Is(target error) bool {
+	if target == ErrServer {
+		return e.StatusCode >= 500
+	}
+	return statusErrors[e.StatusCode] == target
+}
+
+// readError reads the body of a response outside of 2xx
+func readError(resp *http.Response) *Error {
+	var body struct {
+		Error  string   `json:"error"`
+		Issues []string `json:"issues"`
+	}
+	// the status alone is still worth reporting when the body isn't json
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body)
+
+	return &Error{StatusCode: resp.StatusCode, Message: body.Error, Issues: body.Issues}
+}
@@ -0,0 +1,284 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expense is an expense as the API sends it, amounts are in minor units of Currency, i.e. cents
+type Expense struct {
+	ID          int       `json:"id"`
+	OwnerID     int       `json:"owner_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	OccuredAt   time.Time `json:"occured_at"`
+	Description string    `json:"description"`
+	Amount      int64     `json:"amount"`
+	Currency    string    `json:"currency"`
+	TimeZone    string    `json:"timezone"` // OccuredAt is in it, an IANA name or an offset
+	CategoryID  int       `json:"category_id,omitempty"`
+	Category    *Category `json:"category,omitempty"` // only with ListOptions.IncludeCategory
+	URL         string    `json:"url,omitempty"`
+	Version     int       `json:"version"`             // goes up by one on every update
+	ClientID    string    `json:"client_id,omitempty"` // the UUID an offline client created it with
+}
+
+// Category is the category an expense is in, embedded when asked for
+type Category struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExpenseInput is what is sent to create or update an expense, the amount is in cents
+type ExpenseInput struct {
+	OccuredAt   time.Time `json:"occured_at"`
+	Description string    `json:"description"`
+	Amount      int64     `json:"amount"`
+	TimeZone    string    `json:"timezone,omitempty"`    // IANA zone to keep OccuredAt in, its offset's when empty
+	CategoryID  int       `json:"category_id,omitempty"` // uncategorized when empty
+}
+
+// ListOptions filter and page GET /expenses, the zero value of each is left out
+type ListOptions struct {
+	Limit  int
+	Cursor string // Page.NextCursor of the previous page
+	From   time.Time
+	To     time.Time
+
+	// in cents
+	MinAmount int64
+	MaxAmount int64
+
+	Search string // found in the description, ignoring case and accents
+
+	UpdatedSince time.Time // only expenses created or updated at or after, for syncing changes
+
+	IncludeCategory bool // embeds each expense's category, so it isn't looked up separately
+}
+
+// query is the options as GET /expenses query parameters
+func (o ListOptions) query() url.Values {
+	query := url.Values{}
+	if o.Limit != 0 {
+		query.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Cursor != "" {
+		query.Set("cursor", o.Cursor)
+	}
+	if !o.From.IsZero() {
+		query.Set("from", o.From.Format(time.RFC3339))
+	}
+	if !o.To.IsZero() {
+		query.Set("to", o.To.Format(time.RFC3339))
+	}
+	if o.MinAmount != 0 {
+		query.Set("min_amount", strconv.FormatInt(o.MinAmount, 10))
+	}
+	if o.MaxAmount != 0 {
+		query.Set("max_amount", strconv.FormatInt(o.MaxAmount, 10))
+	}
+	if o.Search != "" {
+		query.Set("q", o.Search)
+	}
+	if !o.UpdatedSince.IsZero() {
+		query.Set("updated_since", o.UpdatedSince.Format(time.RFC3339))
+	}
+	if o.IncludeCategory {
+		query.Set("include", "category")
+	}
+	return query
+}
+
+// Page is one page of expenses, newest first. NextCursor is empty on the last page
+type Page struct {
+	Expenses   []*Expense
+	NextCursor string
+}
+
+// PeriodTotal is the total of one day, month, or year of a summary
+type PeriodTotal struct {
+	Start  time.Time `json:"start"`
+	Amount int64     `json:"amount"`
+	Count  int       `json:"count"`
+}
+
+// Conversion is how a summary's amounts were converted with SummaryOptions.Currency
+type Conversion struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Rate      float64   `json:"rate"`
+	RatesAsOf time.Time `json:"rates_as_of"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Stale     bool      `json:"stale"`
+}
+
+// Summary is the total of a range, and of each period in it. The bounds are nil when unbounded
+type Summary struct {
+	From       *time.Time     `json:"from,omitempty"`
+	To         *time.Time     `json:"to,omitempty"`
+	Amount     int64          `json:"amount"`
+	Count      int            `json:"count"`
+	GroupBy    string         `json:"group_by"`
+	Periods    []*PeriodTotal `json:"periods"`
+	Conversion *Conversion    `json:"conversion,omitempty"`
+}
+
+// SummaryOptions pick the range of a summary, the zero value summarizes every expense
+type SummaryOptions struct {
+	Range    string // all, this-month, month, this-year, year, months, or period
+	Period   string // for month, year, months, and period, i.e. 2025-03, 2025, 2025-01:2025-06, or 2025-Q3
+	GroupBy  string // day, month, or year, instead of what suits the range
+	Currency string // converts the amounts, i.e. USD
+	TimeZone string // whose days, months, and years are totaled, i.e. Europe/Berlin, UTC when empty
+
+	// narrow down the expenses totaled, amounts in cents
+	CategoryID int
+	MinAmount  int64
+	MaxAmount  int64
+}
+
+// Change is an expense created or updated since a sync, or the id of one deleted. Expense is nil when Deleted
+type Change struct {
+	ID        int       `json:"id"`
+	Deleted   bool      `json:"deleted"`
+	ChangedAt time.Time `json:"changed_at"`
+	Expense   *Expense  `json:"expense,omitempty"`
+}
+
+// ChangeSet is one batch of changes, oldest first. The next sync starts from Cursor, straight away while HasMore
+type ChangeSet struct {
+	Changes []*Change `json:"changes"`
+	Cursor  string    `json:"cursor"`
+	HasMore bool      `json:"has_more"`
+}
+
+// Create records an expense, it is not retried unless rate limited
+func (c *Client) Create(ctx context.Context, exp *ExpenseInput) (*Expense, error) {
+	var created Expense
+	if _, err := c.do(ctx, http.MethodPost, "/expenses", exp, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Get is one expense, ErrNotFound when there is none with id
+func (c *Client) Get(ctx context.Context, id int) (*Expense, error) {
+	var got Expense
+	if _, err := c.do(ctx, http.MethodGet, "/expenses/"+strconv.Itoa(id), nil, &got); err != nil {
+		return nil, err
+	}
+	return &got, nil
+}
+
+// List is one page of expenses. For the page after it, pass its NextCursor with the other options unchanged
+func (c *Client) List(ctx context.Context, opts ListOptions) (*Page, error) {
+	page := &Page{}
+	header, err := c.do(ctx, http.MethodGet, "/expenses?"+opts.query().Encode(), nil, &page.Expenses)
+	if err != nil {
+		return nil, err
+	}
+	page.NextCursor = nextCursor(header.Get("Link"))
+	return page, nil
+}
+
+// Update replaces the expense with id, ErrNotFound when there is none
+func (c *Client) Update(ctx context.Context, id int, exp *ExpenseInput) error {
+	_, err := c.do(ctx, http.MethodPut, "/expenses/"+strconv.Itoa(id), exp, nil)
+	return err
+}
+
+// Delete deletes the expense with id, ErrNotFound when there is none
+func (c *Client) Delete(ctx context.Context, id int) error {
+	_, err := c.do(ctx, http.MethodDelete, "/expenses/"+strconv.Itoa(id), nil, nil)
+	return err
+}
+
+// Changes are the expenses created, updated, or deleted since the sync that returned cursor.
+// An empty cursor is every expense, for the first sync, and a limit of 0 is the server's default.
+// ErrGone means the cursor is older than the server keeps deletions, sync again from an empty cursor
+func (c *Client) Changes(ctx context.Context, cursor string, limit int) (*ChangeSet, error) {
+	query := url.Values{}
+	if cursor != "" {
+		query.Set("since", cursor)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var got ChangeSet
+	if _, err := c.do(ctx, http.MethodGet, "/expenses/changes?"+query.Encode(), nil, &got); err != nil {
+		return nil, err
+	}
+	return &got, nil
+}
+
+// Summarize totals the expenses in a range, see SummaryOptions
+func (c *Client) Summarize(ctx context.Context, opts SummaryOptions) (*Summary, error) {
+	query := url.Values{}
+	if opts.Range != "" {
+		query.Set("range", opts.Range)
+	}
+	if opts.Period != "" {
+		query.Set("period", opts.Period)
+	}
+	if opts.GroupBy != "" {
+		query.Set("group_by", opts.GroupBy)
+	}
+	if opts.Currency != "" {
+		query.Set("currency", opts.Currency)
+	}
+	if opts.TimeZone != "" {
+		query.Set("timezone", opts.TimeZone)
+	}
+	if opts.CategoryID != 0 {
+		query.Set("category_id", strconv.Itoa(opts.CategoryID))
+	}
+	if opts.MinAmount != 0 {
+		query.Set("min_amount", strconv.FormatInt(opts.MinAmount, 10))
+	}
+	if opts.MaxAmount != 0 {
+		query.Set("max_amount", strconv.FormatInt(opts.MaxAmount, 10))
+	}
+
+	var got Summary
+	if _, err := c.do(ctx, http.MethodGet, "/expenses/summary?"+query.Encode(), nil, &got); err != nil {
+		return nil, err
+	}
+	return &got, nil
+}
+
+// ExportCSV copies the csv export of every expense to w as it streams in.
+// Failures before the export starts are retried, one part way through is returned
+func (c *Client) ExportCSV(ctx context.Context, w io.Writer) error {
+	resp, err := c.send(ctx, http.MethodGet, "/exports/expenses.csv", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// nextCursor is the cursor in the rel="next" link of a Link header, i.e. </expenses?cursor=abc>; rel="next"
+func nextCursor(header string) string {
+	for link := range strings.SplitSeq(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+
+		next, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+		if err != nil {
+			return ""
+		}
+		return next.Query().Get("cursor")
+	}
+	return ""
+}
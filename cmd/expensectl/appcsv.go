@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/nicholasss/expense-tracker-api/client"
+)
+
+// appExport is how one personal finance app lays out its csv export. Columns are found by their header,
+// so apps that add or reorder columns between versions are still read
+type appExport struct {
+	comma       rune
+	columns     []string // the headers read, lowercase
+	dateColumn  string
+	dateLayouts []string // tried in order, dates without a zone are read in the local time zone
+
+	// expense reads the spending in a row, nil for rows that aren't spending, like income or transfers
+	expense func(row appRow) (*appExpense, error)
+}
+
+// appExpense is a row of spending before its date is read
+type appExpense struct {
+	category string
+	note     string
+	amount   int64
+}
+
+// appExports are the apps import can read with -app
+var appExports = map[string]*appExport{
+	// YNAB's register export,
+	// "Account","Flag","Date","Payee","Category Group/Category","Category Group","Category","Memo","Outflow","Inflow","Cleared"
+	"ynab": {
+		comma:       ',',
+		columns:     []string{"date", "payee", "category", "memo", "outflow"},
+		dateColumn:  "date",
+		dateLayouts: []string{"01/02/2006", "2006-01-02", "02.01.2006"},
+		expense: func(row appRow) (*appExpense, error) {
+			// moving money between budget accounts shows up as a payee
+			if strings.HasPrefix(row.get("payee"), "Transfer : ") {
+				return nil, nil
+			}
+			amount, err := parseAppAmount(row.get("outflow"))
+			if err != nil || amount <= 0 {
+				return nil, err
+			}
+			return &appExpense{category: row.get("category"), note: joinNonEmpty(" - ", row.get("payee"), row.get("memo")), amount: amount}, nil
+		},
+	},
+	// Money Manager by Realbyte, "Period","Accounts","Category","Subcategory","Note","Amount","Income/Expense","Description",...
+	"money-manager": {
+		comma:       ',',
+		columns:     []string{"period", "category", "subcategory", "note", "amount", "income/expense"},
+		dateColumn:  "period",
+		dateLayouts: []string{"2006-01-02 15:04:05", "01/02/2006 15:04:05", "2006-01-02", "01/02/2006"},
+		expense: func(row appRow) (*appExpense, error) {
+			// "Exp." in recent versions, "Expense" in older ones
+			if !strings.HasPrefix(strings.ToLower(row.get("income/expense")), "exp") {
+				return nil, nil
+			}
+			amount, err := parseAppAmount(row.get("amount"))
+			if err != nil {
+				return nil, err
+			}
+			category := joinNonEmpty(" / ", normalizeCategory(row.get("category")), normalizeCategory(row.get("subcategory")))
+			return &appExpense{category: category, note: row.get("note"), amount: max(amount, -amount)}, nil
+		},
+	},
+	// Wallet by BudgetBakers, account;category;currency;amount;ref_currency_amount;type;payment_type;...;note;date;...;transfer;payee;labels
+	"wallet": {
+		comma:       ';',
+		columns:     []string{"category", "amount", "type", "note", "date", "transfer", "payee"},
+		dateColumn:  "date",
+		dateLayouts: []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05"},
+		expense: func(row appRow) (*appExpense, error) {
+			if row.get("type") != "Expenses" || strings.EqualFold(row.get("transfer"), "true") {
+				return nil, nil
+			}
+			// spending is negative
+			amount, err := parseAppAmount(row.get("amount"))
+			if err != nil {
+				return nil, err
+			}
+			return &appExpense{category: row.get("category"), note: joinNonEmpty(" - ", row.get("payee"), row.get("note")), amount: -amount}, nil
+		},
+	},
+}
+
+// appNames are the names of the apps import reads, in alphabetical order
+func appNames() []string {
+	names := make([]string, 0, len(appExports))
+	for name := range appExports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// appRow is one row of an app's export, with its columns looked up by header
+type appRow struct {
+	fields    []string
+	positions map[string]int
+}
+
+// get is the trimmed value of column, empty when the row is too short to have it
+func (r appRow) get(column string) string {
+	position := r.positions[column]
+	if position >= len(r.fields) {
+		return ""
+	}
+	return strings.TrimSpace(r.fields[position])
+}
+
+// readAppCSV reads the spending in an app's csv export. The category is put in front of the description,
+// as in "Groceries: Tesco", since expenses here have none of their own. dateLayout replaces the app's
+// layouts when set, for exports written in a date format they don't try.
+// skipped counts the rows of income and transfers, which aren't expenses
+func readAppCSV(r io.Reader, app *appExport, dateLayout string) (records []*client.ExpenseInput, skipped int, err error) {
+	reader := csv.NewReader(r)
+	reader.Comma = app.comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, 0, errors.New("csv is empty, it needs a header row")
+		}
+		return nil, 0, err
+	}
+	positions := make(map[string]int, len(header))
+	for i, column := range header {
+		// spreadsheet apps like to start files with a byte order mark
+		column = strings.TrimPrefix(column, "\ufeff")
+		positions[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, column := range app.columns {
+		if _, ok := positions[column]; !ok {
+			return nil, 0, fmt.Errorf("csv header is missing the %q column, is it the right app?", column)
+		}
+	}
+
+	layouts := app.dateLayouts
+	if dateLayout != "" {
+		layouts = []string{dateLayout}
+	}
+
+	records = make([]*client.ExpenseInput, 0)
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, skipped, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		line, _ := reader.FieldPos(0)
+		row := appRow{fields: fields, positions: positions}
+
+		spent, err := app.expense(row)
+		if err != nil {
+			return nil, 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		if spent == nil {
+			skipped++
+			continue
+		}
+
+		occuredAt, err := parseAppDate(row.get(app.dateColumn), layouts)
+		if err != nil {
+			return nil, 0, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		description := spent.note
+		if category := normalizeCategory(spent.category); category != "" {
+			description = joinNonEmpty(": ", category, spent.note)
+		}
+		if description == "" {
+			description = "imported expense"
+		}
+
+		records = append(records, &client.ExpenseInput{OccuredAt: occuredAt, Description: description, Amount: spent.amount})
+	}
+}
+
+// parseAppDate reads a date in the first of layouts it matches
+func parseAppDate(raw string, layouts []string) (time.Time, error) {
+	for _, layout := range layouts {
+		if occuredAt, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return occuredAt, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("date %q must be like %s, or pass -date-format", raw, strings.Join(layouts, " or "))
+}
+
+// parseAppAmount reads an amount in either decimal style, "1,234.50" or "1.234,50". A comma followed by
+// one or two digits at the end is taken for a decimal comma
+func parseAppAmount(raw string) (int64, error) {
+	if raw == "" {
+		return 0, nil
+	}
+
+	decimalComma := false
+	digits := strings.TrimRightFunc(raw, func(r rune) bool { return !unicode.IsDigit(r) })
+	if i := strings.LastIndexAny(digits, ".,"); i != -1 && digits[i] == ',' && len(digits)-i-1 <= 2 {
+		decimalComma = true
+	}
+	return parseBankAmount(raw, decimalComma)
+}
+
+// normalizeCategory tidies a category the way apps let users write them, dropping the emoji they
+// are often prefixed with and YNAB's "Group: Category" group, i.e. "🛒  groceries" is "Groceries"
+func normalizeCategory(raw string) string {
+	if _, category, ok := strings.Cut(raw, ": "); ok {
+		raw = category
+	}
+	raw = strings.TrimLeftFunc(raw, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	category := strings.Join(strings.Fields(raw), " ")
+	if category == "" {
+		return ""
+	}
+
+	first := []rune(category)
+	first[0] = unicode.ToUpper(first[0])
+	return string(first)
+}
+
+// joinNonEmpty joins the parts that aren't empty
+func joinNonEmpty(sep string, parts ...string) string {
+	kept := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, sep)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nicholasss/expense-tracker-api/client"
+)
+
+// bankProfile is how one bank lays out its csv export. Columns are counted from 1, 0 is unused.
+// Custom profiles go under "profiles" in the config file, with the same json fields
+type bankProfile struct {
+	Comma             string `json:"comma,omitempty"` // "," when empty
+	HeaderRows        int    `json:"header_rows"`     // rows before the first transaction, blank lines aside
+	DateFormat        string `json:"date_format"`     // a Go time layout, dates are read in the local time zone
+	DateColumn        int    `json:"date_column"`
+	DescriptionColumn int    `json:"description_column"`
+	// AmountColumn has money in and out with opposite signs, DebitColumn only money out,
+	// one of the two is set. Money in, like refunds and salary, is skipped
+	AmountColumn     int  `json:"amount_column,omitempty"`
+	DebitColumn      int  `json:"debit_column,omitempty"`
+	SpendingNegative bool `json:"spending_negative,omitempty"` // spending is -12.50 in AmountColumn
+	DecimalComma     bool `json:"decimal_comma,omitempty"`     // 1.234,56 rather than 1,234.56
+}
+
+// bankProfiles are the profiles known without any config
+var bankProfiles = map[string]*bankProfile{
+	// American Express, Date,Description,Amount
+	"amex": {
+		HeaderRows: 1, DateFormat: "01/02/2006",
+		DateColumn: 1, DescriptionColumn: 2, AmountColumn: 3,
+	},
+	// Capital One, Transaction Date,Posted Date,Card No.,Description,Category,Debit,Credit
+	"capital-one": {
+		HeaderRows: 1, DateFormat: "2006-01-02",
+		DateColumn: 1, DescriptionColumn: 4, DebitColumn: 6,
+	},
+	// Chase, Transaction Date,Post Date,Description,Category,Type,Amount,Memo
+	"chase": {
+		HeaderRows: 1, DateFormat: "01/02/2006",
+		DateColumn: 1, DescriptionColumn: 3, AmountColumn: 6, SpendingNegative: true,
+	},
+	// Monzo, Transaction ID,Date,Time,Type,Name,Emoji,Category,Amount,...
+	"monzo": {
+		HeaderRows: 1, DateFormat: "02/01/2006",
+		DateColumn: 2, DescriptionColumn: 5, AmountColumn: 8, SpendingNegative: true,
+	},
+	// Revolut, Type,Product,Started Date,Completed Date,Description,Amount,...
+	"revolut": {
+		HeaderRows: 1, DateFormat: "2006-01-02 15:04:05",
+		DateColumn: 3, DescriptionColumn: 5, AmountColumn: 6, SpendingNegative: true,
+	},
+}
+
+// validate checks a profile can be read with, custom ones in particular
+func (p *bankProfile) validate() error {
+	switch {
+	case p.DateFormat == "":
+		return errors.New("date_format is missing")
+	case p.DateColumn < 1 || p.DescriptionColumn < 1:
+		return errors.New("date_column and description_column must be set, counting from 1")
+	case (p.AmountColumn < 1) == (p.DebitColumn < 1):
+		return errors.New("one of amount_column or debit_column must be set")
+	case p.Comma != "" && utf8.RuneCountInString(p.Comma) != 1:
+		return fmt.Errorf("comma must be one character, not %q", p.Comma)
+	case p.HeaderRows < 0:
+		return errors.New("header_rows can't be negative")
+	}
+	return nil
+}
+
+// profileNames are the names of profiles, in alphabetical order
+func profileNames(profiles map[string]*bankProfile) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseBankAmount reads an amount the way banks write them, i.e. "$1,234.50", "-12.50", or "(12.50)",
+// into cents. Currency symbols and thousands separators are dropped
+func parseBankAmount(s string, decimalComma bool) (int64, error) {
+	raw := strings.TrimSpace(s)
+	negative := strings.HasPrefix(raw, "(") && strings.HasSuffix(raw, ")")
+
+	decimal := '.'
+	if decimalComma {
+		decimal = ','
+	}
+	var cleaned strings.Builder
+	for _, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			cleaned.WriteRune(r)
+		case r == decimal:
+			cleaned.WriteRune('.')
+		case r == '-' || r == '−':
+			negative = !negative
+		}
+	}
+
+	cents, err := parseAmount(cleaned.String())
+	if err != nil {
+		return 0, fmt.Errorf("amount %q is not a number", s)
+	}
+	if negative {
+		cents = -cents
+	}
+	return cents, nil
+}
+
+// readBankCSV reads the spending in a bank's csv export, as laid out by profile.
+// skipped counts the rows of money coming in, which aren't expenses
+func readBankCSV(r io.Reader, profile *bankProfile) (records []*client.ExpenseInput, skipped int, err error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // banks put notes above the header and leave trailing columns off
+	reader.LazyQuotes = true
+	if profile.Comma != "" {
+		reader.Comma, _ = utf8.DecodeRuneInString(profile.Comma)
+	}
+
+	// the last column read decides how short a row can be
+	needed := max(profile.DateColumn, profile.DescriptionColumn, profile.AmountColumn, profile.DebitColumn)
+
+	records = make([]*client.ExpenseInput, 0)
+	for row := 0; ; row++ {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, skipped, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if row < profile.HeaderRows {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		if len(fields) < needed {
+			return nil, 0, fmt.Errorf("line %d: has %d columns, the profile reads up to column %d", line, len(fields), needed)
+		}
+
+		occuredAt, err := time.ParseInLocation(profile.DateFormat, strings.TrimSpace(fields[profile.DateColumn-1]), time.Local)
+		if err != nil {
+			return nil, 0, fmt.Errorf("line %d: date must be like %s: %w", line, profile.DateFormat, err)
+		}
+
+		var spent int64
+		if profile.DebitColumn > 0 {
+			debit := strings.TrimSpace(fields[profile.DebitColumn-1])
+			if debit != "" {
+				spent, err = parseBankAmount(debit, profile.DecimalComma)
+				// some banks write debits as negatives too
+				spent = max(spent, -spent)
+			}
+		} else {
+			spent, err = parseBankAmount(fields[profile.AmountColumn-1], profile.DecimalComma)
+			if profile.SpendingNegative {
+				spent = -spent
+			}
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		if spent <= 0 {
+			skipped++
+			continue
+		}
+
+		records = append(records, &client.ExpenseInput{
+			OccuredAt:   occuredAt,
+			Description: strings.TrimSpace(fields[profile.DescriptionColumn-1]),
+			Amount:      spent,
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/client"
+)
+
+// newFlagSet is the flag set of one command, its usage line shows the arguments after the flags
+func newFlagSet(c *cli, name, args string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: expensectl %s [flags] %s\n\n%s\n", name, args, commands[name].summary)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseTime reads an RFC 3339 time, or a YYYY-MM-DD date at midnight local time
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("time %q must be an RFC 3339 time or YYYY-MM-DD date", s)
+}
+
+// runAdd records one expense: expensectl add 12.50 lunch with the team
+func runAdd(ctx context.Context, c *cli, args []string) error {
+	flags := newFlagSet(c, "add", "AMOUNT DESCRIPTION...")
+	at := flags.String("at", "", "when it occured, an RFC 3339 time or YYYY-MM-DD date (default now)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 2 {
+		flags.Usage()
+		return errors.New("add needs an amount and a description")
+	}
+
+	amount, err := parseAmount(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	occuredAt := time.Now()
+	if *at != "" {
+		if occuredAt, err = parseTime(*at); err != nil {
+			return err
+		}
+	}
+
+	created, err := c.api.Create(ctx, &client.ExpenseInput{
+		OccuredAt:   occuredAt,
+		Description: strings.Join(flags.Args()[1:], " "),
+		Amount:      amount,
+	})
+	if err != nil {
+		return err
+	}
+	return writeExpenses(c.stdout, c.format, []*client.Expense{created})
+}
+
+// runList lists expenses newest first, following the next links until -limit are listed
+func runList(ctx context.Context, c *cli, args []string) error {
+	flags := newFlagSet(c, "list", "")
+	limit := flags.Int("limit", 20, "most expenses to list, 0 lists every one")
+	from := flags.String("from", "", "only expenses occured at or after, an RFC 3339 time or YYYY-MM-DD date")
+	to := flags.String("to", "", "only expenses occured before, an RFC 3339 time or YYYY-MM-DD date")
+	minAmount := flags.String("min", "", "only expenses of at least this amount, i.e. 10.00")
+	maxAmount := flags.String("max", "", "only expenses of at most this amount")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *limit < 0 {
+		return errors.New("-limit can't be negative")
+	}
+
+	opts := client.ListOptions{}
+	var err error
+	if *from != "" {
+		if opts.From, err = parseTime(*from); err != nil {
+			return err
+		}
+	}
+	if *to != "" {
+		if opts.To, err = parseTime(*to); err != nil {
+			return err
+		}
+	}
+	if *minAmount != "" {
+		if opts.MinAmount, err = parseAmount(*minAmount); err != nil {
+			return err
+		}
+	}
+	if *maxAmount != "" {
+		if opts.MaxAmount, err = parseAmount(*maxAmount); err != nil {
+			return err
+		}
+	}
+
+	listed := make([]*client.Expense, 0)
+	for {
+		page, err := c.api.List(ctx, opts)
+		if err != nil {
+			return err
+		}
+		listed = append(listed, page.Expenses...)
+
+		if page.NextCursor == "" || (*limit != 0 && len(listed) >= *limit) {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+	if *limit != 0 && len(listed) > *limit {
+		listed = listed[:*limit]
+	}
+
+	return writeExpenses(c.stdout, c.format, listed)
+}
+
+// runSummary totals expenses over a range, see GET /expenses/summary
+func runSummary(ctx context.Context, c *cli, args []string) error {
+	flags := newFlagSet(c, "summary", "")
+	rangeName := flags.String("range", "all", "all, this-month, month, this-year, year, months, or period")
+	period := flags.String("period", "", "the month, year, months, or period of the range, i.e. 2025-03, 2025, 2025-01:2025-06, 2025-W11, or 2025-Q3")
+	groupBy := flags.String("group-by", "", "day, month, or year, instead of what suits the range")
+	currency := flags.String("currency", "", "convert the amounts to this currency, i.e. USD")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	got, err := c.api.Summarize(ctx, client.SummaryOptions{Range: *rangeName, Period: *period, GroupBy: *groupBy, Currency: *currency})
+	if err != nil {
+		return err
+	}
+	return writeSummary(c.stdout, c.format, got)
+}
+
+// runDelete deletes each id in turn, stopping at the first that fails
+func runDelete(ctx context.Context, c *cli, args []string) error {
+	flags := newFlagSet(c, "delete", "ID...")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("delete needs at least one id")
+	}
+
+	ids := make([]int, 0, flags.NArg())
+	for _, arg := range flags.Args() {
+		id, err := strconv.Atoi(arg)
+		if err != nil || id < 1 {
+			return fmt.Errorf("id %q must be a positive number", arg)
+		}
+		ids = append(ids, id)
+	}
+
+	for _, id := range ids {
+		if err := c.api.Delete(ctx, id); err != nil {
+			return fmt.Errorf("deleting %d: %w", id, err)
+		}
+		fmt.Fprintf(c.stdout, "deleted %d\n", id)
+	}
+	return nil
+}
+
+// runImport records every expense in a file, stopping at the first the server refuses.
+// The file is read whole first, so a malformed row records nothing. -profile reads a bank's
+// csv export instead, -app a personal finance app's, and -dry-run shows what would be recorded
+// without recording it
+func runImport(ctx context.Context, c *cli, args []string) error {
+	flags := newFlagSet(c, "import", "FILE")
+	format := flags.String("format", "", "csv or json, by default taken from the file extension, - is read as csv")
+	profileName := flags.String("profile", "", "read a bank's csv export, one of "+strings.Join(profileNames(c.profiles), ", "))
+	appName := flags.String("app", "", "read a personal finance app's csv export, one of "+strings.Join(appNames(), ", "))
+	dateFormat := flags.String("date-format", "", "a Go time layout for -app exports in a date format it doesn't expect, i.e. 02/01/2006")
+	dryRun := flags.Bool("dry-run", false, "show the expenses that would be imported, without importing them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("import needs one file, or - for stdin")
+	}
+
+	var profile *bankProfile
+	if *profileName != "" {
+		var ok bool
+		if profile, ok = c.profiles[*profileName]; !ok {
+			return fmt.Errorf("unknown profile %q, known profiles are %s", *profileName, strings.Join(profileNames(c.profiles), ", "))
+		}
+		if *format != "" && *format != "csv" {
+			return errors.New("-profile only reads csv")
+		}
+	}
+
+	var app *appExport
+	if *appName != "" {
+		var ok bool
+		if app, ok = appExports[*appName]; !ok {
+			return fmt.Errorf("unknown app %q, known apps are %s", *appName, strings.Join(appNames(), ", "))
+		}
+		if profile != nil {
+			return errors.New("-app and -profile can't be used together")
+		}
+		if *format != "" && *format != "csv" {
+			return errors.New("-app only reads csv")
+		}
+	} else if *dateFormat != "" {
+		return errors.New("-date-format is only used with -app")
+	}
+
+	path := flags.Arg(0)
+	var in io.Reader = c.stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	if *format == "" {
+		*format = "csv"
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			*format = "json"
+		}
+	}
+
+	var records []*client.ExpenseInput
+	var skipped int
+	var err error
+	switch {
+	case profile != nil:
+		records, skipped, err = readBankCSV(in, profile)
+	case app != nil:
+		records, skipped, err = readAppCSV(in, app, *dateFormat)
+	case *format == "csv":
+		records, err = readImportCSV(in)
+	case *format == "json":
+		records, err = readImportJSON(in)
+	default:
+		return fmt.Errorf("-format must be csv or json, not %q", *format)
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	if *dryRun {
+		if err := writeImportPreview(c.stdout, c.format, records); err != nil {
+			return err
+		}
+		if c.format != outputJSON {
+			fmt.Fprintf(c.stdout, "would import %d expenses, skipping %d rows of income and transfers\n", len(records), skipped)
+		}
+		return nil
+	}
+
+	imported := make([]*client.Expense, 0, len(records))
+	for i, record := range records {
+		created, err := c.api.Create(ctx, record)
+		if err != nil {
+			return fmt.Errorf("expense %d of %d, %d were imported before it: %w", i+1, len(records), i, err)
+		}
+		imported = append(imported, created)
+	}
+
+	if c.format == outputJSON {
+		return writeJSON(c.stdout, imported)
+	}
+	fmt.Fprintf(c.stdout, "imported %d expenses", len(imported))
+	if skipped > 0 {
+		fmt.Fprintf(c.stdout, ", skipped %d rows of income and transfers", skipped)
+	}
+	fmt.Fprintln(c.stdout)
+	return nil
+}
+
+// runExport downloads the streamed csv export, to stdout unless -out is set
+func runExport(ctx context.Context, c *cli, args []string) (err error) {
+	flags := newFlagSet(c, "export", "")
+	out := flags.String("out", "", "file to write the csv to (default stdout)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *out == "" {
+		return c.api.ExportCSV(ctx, c.stdout)
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := file.Close()
+		if err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+
+	return c.api.ExportCSV(ctx, file)
+}
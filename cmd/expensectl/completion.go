@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// completionScripts complete the command names, and the shells after completion.
+// %[1]s is the space separated command names
+var completionScripts = map[string]string{
+	"bash": `# eval "$(expensectl completion bash)"
+_expensectl() {
+  local cur prev
+  cur="${COMP_WORDS[COMP_CWORD]}"
+  prev="${COMP_WORDS[COMP_CWORD-1]}"
+  if [ "$prev" = "completion" ]; then
+    COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur"))
+  elif [ "$prev" = "-o" ]; then
+    COMPREPLY=($(compgen -W "table json" -- "$cur"))
+  elif [ "$COMP_CWORD" -eq 1 ]; then
+    COMPREPLY=($(compgen -W "%[1]s" -- "$cur"))
+  else
+    COMPREPLY=($(compgen -f -- "$cur"))
+  fi
+}
+complete -F _expensectl expensectl
+`,
+	"zsh": `#compdef expensectl
+# source <(expensectl completion zsh)
+_expensectl() {
+  if (( CURRENT == 2 )); then
+    compadd %[1]s
+  elif [[ "${words[2]}" == completion ]]; then
+    compadd bash zsh fish
+  else
+    _files
+  fi
+}
+compdef _expensectl expensectl
+`,
+	"fish": `# expensectl completion fish | source
+complete -c expensectl -n "__fish_use_subcommand" -a "%[1]s"
+complete -c expensectl -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+`,
+}
+
+// runCompletion prints the completion script of a shell
+func runCompletion(ctx context.Context, c *cli, args []string) error {
+	flags := newFlagSet(c, "completion", "bash|zsh|fish")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("completion needs a shell, bash, zsh, or fish")
+	}
+
+	script, ok := completionScripts[flags.Arg(0)]
+	if !ok {
+		return fmt.Errorf("no completion for %q, only bash, zsh, and fish", flags.Arg(0))
+	}
+
+	_, err := fmt.Fprintf(c.stdout, script, strings.Join(commandNames(), " "))
+	return err
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ctlConfig is where the server is and how to authenticate with it,
+// along with any bank csv profiles beyond the built in ones
+type ctlConfig struct {
+	Server   string                  `json:"server"`
+	Token    string                  `json:"token"`
+	Profiles map[string]*bankProfile `json:"profiles,omitempty"`
+}
+
+// defaultConfigPath is expensectl/config.json in the user's config directory, i.e. ~/.config on linux
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "expensectl", "config.json")
+}
+
+// loadConfig reads the config file, then lets EXPENSECTL_SERVER and EXPENSECTL_TOKEN override it.
+// A missing file is fine, everything can come from the environment or flags
+func loadConfig(path string) (*ctlConfig, error) {
+	cfg := &ctlConfig{}
+
+	if path != "" {
+		contents, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return nil, err
+		default:
+			if err := json.Unmarshal(contents, cfg); err != nil {
+				return nil, fmt.Errorf("config file %s: %w", path, err)
+			}
+			for name, profile := range cfg.Profiles {
+				if err := profile.validate(); err != nil {
+					return nil, fmt.Errorf("config file %s: profile %q: %w", path, name, err)
+				}
+			}
+		}
+	}
+
+	if server, ok := os.LookupEnv("EXPENSECTL_SERVER"); ok {
+		cfg.Server = server
+	}
+	if token, ok := os.LookupEnv("EXPENSECTL_TOKEN"); ok {
+		cfg.Token = token
+	}
+
+	return cfg, nil
+}
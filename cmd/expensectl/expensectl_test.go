@@ -0,0 +1,450 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/client"
+)
+
+func TestParseAmount(t *testing.T) {
+	testTable := []struct {
+		name        string
+		input       string
+		expectError bool
+		want        int64
+	}{
+		{name: "valid-whole", input: "12", want: 1200},
+		{name: "valid-one-decimal", input: "12.5", want: 1250},
+		{name: "valid-two-decimals", input: "0.99", want: 99},
+		{name: "invalid-three-decimals", input: "1.999", expectError: true},
+		{name: "invalid-no-whole", input: ".50", expectError: true},
+		{name: "invalid-not-a-number", input: "twelve", expectError: true},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, gotErr := parseAmount(testCase.input)
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+			if got != testCase.want {
+				t.Errorf("got: %d, want: %d", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestReadImportCSV(t *testing.T) {
+	testTable := []struct {
+		name        string
+		input       string
+		expectError bool
+		want        []*client.ExpenseInput
+	}{
+		{
+			name: "valid-export",
+			input: "id,created_at,occured_at,description,amount\n" +
+				"4,2025-10-02T09:00:00Z,2025-10-01T12:30:00Z,\"lunch, with team\",2450\n",
+			want: []*client.ExpenseInput{
+				{OccuredAt: time.Date(2025, 10, 1, 12, 30, 0, 0, time.UTC), Description: "lunch, with team", Amount: 2450},
+			},
+		},
+		{
+			name:  "valid-reordered-columns",
+			input: "amount,description,occured_at\n999,cab,2025-10-01T00:00:00Z\n",
+			want: []*client.ExpenseInput{
+				{OccuredAt: time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC), Description: "cab", Amount: 999},
+			},
+		},
+		{
+			name:        "invalid-missing-column",
+			input:       "occured_at,description\n2025-10-01T00:00:00Z,cab\n",
+			expectError: true,
+		},
+		{
+			name:        "invalid-decimal-amount",
+			input:       "occured_at,description,amount\n2025-10-01T00:00:00Z,cab,9.99\n",
+			expectError: true,
+		},
+		{
+			name:        "invalid-empty",
+			input:       "",
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, gotErr := readImportCSV(strings.NewReader(testCase.input))
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+			if testCase.expectError {
+				return
+			}
+
+			if len(got) != len(testCase.want) {
+				t.Fatalf("got %d expenses, want %d", len(got), len(testCase.want))
+			}
+			for i := range got {
+				if !got[i].OccuredAt.Equal(testCase.want[i].OccuredAt) || got[i].Description != testCase.want[i].Description || got[i].Amount != testCase.want[i].Amount {
+					t.Errorf("got expense %d: %+v, want: %+v", i, got[i], testCase.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadBankCSV(t *testing.T) {
+	testTable := []struct {
+		name         string
+		inputProfile *bankProfile
+		input        string
+		expectError  bool
+		want         []*client.ExpenseInput
+		wantSkipped  int
+	}{
+		{
+			name:         "valid-chase-skips-payments",
+			inputProfile: bankProfiles["chase"],
+			input: "Transaction Date,Post Date,Description,Category,Type,Amount,Memo\n" +
+				"10/01/2025,10/02/2025,\"COFFEE, INC\",Food & Drink,Sale,-4.75,\n" +
+				"10/03/2025,10/03/2025,Payment Thank You,,Payment,250.00,\n",
+			want: []*client.ExpenseInput{
+				{OccuredAt: time.Date(2025, 10, 1, 0, 0, 0, 0, time.Local), Description: "COFFEE, INC", Amount: 475},
+			},
+			wantSkipped: 1,
+		},
+		{
+			name:         "valid-capital-one-debit-column",
+			inputProfile: bankProfiles["capital-one"],
+			input: "Transaction Date,Posted Date,Card No.,Description,Category,Debit,Credit\n" +
+				"2025-10-01,2025-10-02,1234,GROCER,Merchandise,\"1,032.10\",\n" +
+				"2025-10-04,2025-10-04,1234,REFUND,Merchandise,,12.00\n",
+			want: []*client.ExpenseInput{
+				{OccuredAt: time.Date(2025, 10, 1, 0, 0, 0, 0, time.Local), Description: "GROCER", Amount: 103210},
+			},
+			wantSkipped: 1,
+		},
+		{
+			name: "valid-decimal-comma-and-notes-above-header",
+			inputProfile: &bankProfile{
+				Comma: ";", HeaderRows: 2, DateFormat: "02.01.2006",
+				DateColumn: 1, DescriptionColumn: 2, AmountColumn: 3, SpendingNegative: true, DecimalComma: true,
+			},
+			input: "Konto;DE00 1234\n\nBuchung;Empfänger;Betrag\n" +
+				"01.10.2025;Bäckerei;-3,20 €\n02.10.2025;Miete;-1.150,00\n",
+			want: []*client.ExpenseInput{
+				{OccuredAt: time.Date(2025, 10, 1, 0, 0, 0, 0, time.Local), Description: "Bäckerei", Amount: 320},
+				{OccuredAt: time.Date(2025, 10, 2, 0, 0, 0, 0, time.Local), Description: "Miete", Amount: 115000},
+			},
+		},
+		{
+			name:         "valid-amex-parentheses-are-credits",
+			inputProfile: bankProfiles["amex"],
+			input:        "Date,Description,Amount\n10/05/2025,AIRLINE,$412.00\n10/06/2025,AIRLINE CREDIT,($50.00)\n",
+			want: []*client.ExpenseInput{
+				{OccuredAt: time.Date(2025, 10, 5, 0, 0, 0, 0, time.Local), Description: "AIRLINE", Amount: 41200},
+			},
+			wantSkipped: 1,
+		},
+		{
+			name:         "invalid-date-format",
+			inputProfile: bankProfiles["chase"],
+			input:        "Transaction Date,Post Date,Description,Category,Type,Amount,Memo\n2025-10-01,,cab,,Sale,-9.99,\n",
+			expectError:  true,
+		},
+		{
+			name:         "invalid-short-row",
+			inputProfile: bankProfiles["monzo"],
+			input:        "Transaction ID,Date,Time,Type,Name\ntx_1,01/10/2025,12:00:00,Card payment,cab\n",
+			expectError:  true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			if err := testCase.inputProfile.validate(); err != nil {
+				t.Fatalf("got invalid profile: %v", err)
+			}
+
+			got, gotSkipped, gotErr := readBankCSV(strings.NewReader(testCase.input), testCase.inputProfile)
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+			if testCase.expectError {
+				return
+			}
+
+			if len(got) != len(testCase.want) || gotSkipped != testCase.wantSkipped {
+				t.Fatalf("got %d expenses and %d skipped, want %d and %d", len(got), gotSkipped, len(testCase.want), testCase.wantSkipped)
+			}
+			for i := range got {
+				if !got[i].OccuredAt.Equal(testCase.want[i].OccuredAt) || got[i].Description != testCase.want[i].Description || got[i].Amount != testCase.want[i].Amount {
+					t.Errorf("got expense %d: %+v, want: %+v", i, got[i], testCase.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadAppCSV(t *testing.T) {
+	testTable := []struct {
+		name            string
+		inputApp        string
+		inputDateLayout string
+		input           string
+		expectError     bool
+		want            []*client.ExpenseInput
+		wantSkipped     int
+	}{
+		{
+			name:     "valid-ynab-skips-inflows-and-transfers",
+			inputApp: "ynab",
+			input: "\ufeff\"Account\",\"Flag\",\"Date\",\"Payee\",\"Category Group/Category\",\"Category Group\",\"Category\",\"Memo\",\"Outflow\",\"Inflow\",\"Cleared\"\n" +
+				"\"Checking\",\"\",\"10/01/2025\",\"Tesco\",\"Everyday: 🛒 Groceries\",\"Everyday\",\"🛒 Groceries\",\"weekly shop\",\"$1,032.10\",\"$0.00\",\"Cleared\"\n" +
+				"\"Checking\",\"\",\"10/02/2025\",\"Employer\",\"Inflow: Ready to Assign\",\"Inflow\",\"Ready to Assign\",\"\",\"$0.00\",\"$2,000.00\",\"Cleared\"\n" +
+				"\"Checking\",\"\",\"10/03/2025\",\"Transfer : Savings\",\"\",\"\",\"\",\"\",\"$100.00\",\"$0.00\",\"Cleared\"\n",
+			want: []*client.ExpenseInput{
+				{OccuredAt: time.Date(2025, 10, 1, 0, 0, 0, 0, time.Local), Description: "Groceries: Tesco - weekly shop", Amount: 103210},
+			},
+			wantSkipped: 2,
+		},
+		{
+			name:            "valid-ynab-day-first-decimal-comma",
+			inputApp:        "ynab",
+			inputDateLayout: "02/01/2006",
+			input: "Account,Flag,Date,Payee,Category Group/Category,Category Group,Category,Memo,Outflow,Inflow,Cleared\n" +
+				"Girokonto,,13/10/2025,Bäckerei,Alltag: Essen,Alltag,Essen,,\"3,20€\",\"0,00€\",Cleared\n",
+			want: []*client.ExpenseInput{
+				{OccuredAt: time.Date(2025, 10, 13, 0, 0, 0, 0, time.Local), Description: "Essen: Bäckerei", Amount: 320},
+			},
+		},
+		{
+			name:     "valid-money-manager-subcategories",
+			inputApp: "money-manager",
+			input: "Period,Accounts,Category,Subcategory,Note,Amount,Income/Expense,Description\n" +
+				"2025-10-01 12:30:00,Cash,🍜 food,lunch,noodles,12.5,Exp.,\n" +
+				"2025-10-02 09:00:00,Bank,Salary,,October,2000,Income,\n",
+			want: []*client.ExpenseInput{
+				{OccuredAt: time.Date(2025, 10, 1, 12, 30, 0, 0, time.Local), Description: "Food / Lunch: noodles", Amount: 1250},
+			},
+			wantSkipped: 1,
+		},
+		{
+			name:     "valid-wallet-negative-spending",
+			inputApp: "wallet",
+			input: "account;category;currency;amount;ref_currency_amount;type;payment_type;payment_type_local;note;date;gps_latitude;gps_longitude;gps_accuracy_in_meters;warranty_in_month;transfer;payee;labels\n" +
+				"Cash;Restaurant, fast-food;EUR;-14.99;-14.99;Expenses;CASH;Cash;pizza;2025-10-17T19:00:00.000Z;;;;0;false;Luigi's;\n" +
+				"Bank;Transfer;EUR;-50.00;-50.00;Expenses;TRANSFER;Transfer;;2025-10-18T10:00:00.000Z;;;;0;true;;\n" +
+				"Bank;Wage, invoices;EUR;1500.00;1500.00;Income;TRANSFER;Transfer;;2025-10-19T10:00:00.000Z;;;;0;false;;\n",
+			want: []*client.ExpenseInput{
+				{OccuredAt: time.Date(2025, 10, 17, 19, 0, 0, 0, time.UTC), Description: "Restaurant, fast-food: Luigi's - pizza", Amount: 1499},
+			},
+			wantSkipped: 2,
+		},
+		{
+			name:        "invalid-wrong-app",
+			inputApp:    "wallet",
+			input:       "Period,Accounts,Category,Subcategory,Note,Amount,Income/Expense,Description\n",
+			expectError: true,
+		},
+		{
+			name:     "invalid-date",
+			inputApp: "ynab",
+			input: "Account,Flag,Date,Payee,Category Group/Category,Category Group,Category,Memo,Outflow,Inflow,Cleared\n" +
+				"Checking,,13/10/2025,cab,,,,,9.99,0.00,Cleared\n",
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, gotSkipped, gotErr := readAppCSV(strings.NewReader(testCase.input), appExports[testCase.inputApp], testCase.inputDateLayout)
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+			if testCase.expectError {
+				return
+			}
+
+			if len(got) != len(testCase.want) || gotSkipped != testCase.wantSkipped {
+				t.Fatalf("got %d expenses and %d skipped, want %d and %d", len(got), gotSkipped, len(testCase.want), testCase.wantSkipped)
+			}
+			for i := range got {
+				if !got[i].OccuredAt.Equal(testCase.want[i].OccuredAt) || got[i].Description != testCase.want[i].Description || got[i].Amount != testCase.want[i].Amount {
+					t.Errorf("got expense %d: %+v, want: %+v", i, got[i], testCase.want[i])
+				}
+			}
+		})
+	}
+}
+
+// fakeAPI serves two pages of expenses, records created and deleted expenses, and refuses descriptions of "refused"
+type fakeAPI struct {
+	mux     sync.Mutex
+	created []*client.ExpenseInput
+	deleted []int
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"Unauthorized"}`))
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/expenses" && r.URL.Query().Get("cursor") == "":
+		w.Header().Set("Link", `</expenses?cursor=page2&limit=2>; rel="next"`)
+		_, _ = w.Write([]byte(`[{"id":3,"occured_at":"2025-10-03T00:00:00Z","description":"three","amount":300},` +
+			`{"id":2,"occured_at":"2025-10-02T00:00:00Z","description":"two","amount":200}]`))
+	case r.Method == http.MethodGet && r.URL.Path == "/expenses":
+		_, _ = w.Write([]byte(`[{"id":1,"occured_at":"2025-10-01T00:00:00Z","description":"one","amount":100}]`))
+	case r.Method == http.MethodPost && r.URL.Path == "/expenses":
+		var exp client.ExpenseInput
+		if err := json.NewDecoder(r.Body).Decode(&exp); err != nil || exp.Description == "refused" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":400,"issues":["description is refused"]}`))
+			return
+		}
+		f.mux.Lock()
+		f.created = append(f.created, &exp)
+		f.mux.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(&client.Expense{ID: 10, OccuredAt: exp.OccuredAt, Description: exp.Description, Amount: exp.Amount})
+	case r.Method == http.MethodDelete && r.URL.Path == "/expenses/3":
+		f.mux.Lock()
+		f.deleted = append(f.deleted, 3)
+		f.mux.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestRun(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputArgs   []string
+		inputStdin  string
+		expectError bool
+		wantOutput  []string // each must be somewhere in stdout, or the error
+		wantMissing []string // none may be
+		wantCreated int
+	}{
+		{
+			name:       "valid-list-follows-next-link",
+			inputArgs:  []string{"list", "-limit", "0"},
+			wantOutput: []string{"three", "two", "one", "3.00"},
+		},
+		{
+			name:        "valid-list-stops-at-limit",
+			inputArgs:   []string{"-o", "json", "list", "-limit", "2"},
+			wantOutput:  []string{`"description": "two"`},
+			wantMissing: []string{`"description": "one"`},
+		},
+		{
+			name:        "valid-add",
+			inputArgs:   []string{"add", "-at", "2025-10-01", "12.50", "lunch", "out"},
+			wantOutput:  []string{"12.50", "lunch out"},
+			wantCreated: 1,
+		},
+		{
+			name:        "valid-import-stdin",
+			inputArgs:   []string{"import", "-"},
+			inputStdin:  "occured_at,description,amount\n2025-10-01T00:00:00Z,cab,999\n2025-10-02T00:00:00Z,train,450\n",
+			wantOutput:  []string{"imported 2 expenses"},
+			wantCreated: 2,
+		},
+		{
+			name:        "invalid-import-stops-at-refused",
+			inputArgs:   []string{"import", "-format", "json", "-"},
+			inputStdin:  `[{"occured_at":"2025-10-01T00:00:00Z","description":"cab","amount":999},{"occured_at":"2025-10-01T00:00:00Z","description":"refused","amount":1}]`,
+			expectError: true,
+			wantOutput:  []string{"expense 2 of 2", "description is refused"},
+			wantCreated: 1,
+		},
+		{
+			name:        "valid-import-profile-dry-run",
+			inputArgs:   []string{"import", "-profile", "chase", "-dry-run", "-"},
+			inputStdin:  "Transaction Date,Post Date,Description,Category,Type,Amount,Memo\n10/01/2025,10/02/2025,cab,Travel,Sale,-9.99,\n",
+			wantOutput:  []string{"9.99", "cab", "would import 1 expenses"},
+			wantCreated: 0,
+		},
+		{
+			name:        "valid-import-app-dry-run",
+			inputArgs:   []string{"import", "-app", "money-manager", "-dry-run", "-"},
+			inputStdin:  "Period,Accounts,Category,Subcategory,Note,Amount,Income/Expense,Description\n2025-10-01,Cash,Transport,,cab,9.99,Exp.,\n",
+			wantOutput:  []string{"9.99", "Transport: cab", "would import 1 expenses"},
+			wantCreated: 0,
+		},
+		{
+			name:        "invalid-import-app-and-profile",
+			inputArgs:   []string{"import", "-app", "ynab", "-profile", "chase", "-"},
+			expectError: true,
+			wantOutput:  []string{"can't be used together"},
+		},
+		{
+			name:        "invalid-import-unknown-profile",
+			inputArgs:   []string{"import", "-profile", "mattress", "-"},
+			expectError: true,
+			wantOutput:  []string{`unknown profile "mattress"`, "chase"},
+		},
+		{
+			name:        "invalid-bad-token",
+			inputArgs:   []string{"-token", "wrong", "list"},
+			expectError: true,
+			wantOutput:  []string{"401", "Unauthorized"},
+		},
+		{
+			name:        "invalid-unknown-command",
+			inputArgs:   []string{"frobnicate"},
+			expectError: true,
+			wantOutput:  []string{`unknown command "frobnicate"`},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			api := &fakeAPI{}
+			srv := httptest.NewServer(api)
+			defer srv.Close()
+
+			t.Setenv("EXPENSECTL_SERVER", srv.URL)
+			t.Setenv("EXPENSECTL_TOKEN", "secret")
+
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"-config", ""}, testCase.inputArgs...)
+			gotErr := run(t.Context(), args, strings.NewReader(testCase.inputStdin), &stdout, &stderr)
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+
+			output := stdout.String()
+			if gotErr != nil {
+				output += gotErr.Error()
+			}
+			for _, want := range testCase.wantOutput {
+				if !strings.Contains(output, want) {
+					t.Errorf("output is missing %q, got:\n%s", want, output)
+				}
+			}
+			for _, missing := range testCase.wantMissing {
+				if strings.Contains(output, missing) {
+					t.Errorf("output has %q, got:\n%s", missing, output)
+				}
+			}
+			if len(api.created) != testCase.wantCreated {
+				t.Errorf("got %d created, want %d", len(api.created), testCase.wantCreated)
+			}
+		})
+	}
+}
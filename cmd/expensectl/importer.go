@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/client"
+)
+
+// importColumns are the csv columns read by import, in any order.
+// Other columns, like the id and created_at of an export, are ignored
+var importColumns = []string{"occured_at", "description", "amount"}
+
+// readImportCSV reads expenses from csv with a header row, amounts are in cents as in an export
+func readImportCSV(r io.Reader) ([]*client.ExpenseInput, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("csv is empty, it needs a header row")
+		}
+		return nil, err
+	}
+
+	positions := make(map[string]int, len(importColumns))
+	for _, column := range importColumns {
+		position := slices.Index(header, column)
+		if position == -1 {
+			return nil, fmt.Errorf("csv header is missing the %q column", column)
+		}
+		positions[column] = position
+	}
+
+	records := make([]*client.ExpenseInput, 0)
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		occuredAt, err := time.Parse(time.RFC3339, row[positions["occured_at"]])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: occured_at must be an RFC 3339 time: %w", line, err)
+		}
+		amount, err := strconv.ParseInt(row[positions["amount"]], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: amount must be a whole number of cents: %w", line, err)
+		}
+
+		records = append(records, &client.ExpenseInput{
+			OccuredAt:   occuredAt,
+			Description: row[positions["description"]],
+			Amount:      amount,
+		})
+	}
+}
+
+// readImportJSON reads a json array of expenses, shaped like the body of POST /expenses
+func readImportJSON(r io.Reader) ([]*client.ExpenseInput, error) {
+	var records []*client.ExpenseInput
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, fmt.Errorf("json must be an array of expenses: %w", err)
+	}
+	return records, nil
+}
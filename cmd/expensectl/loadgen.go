@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/client"
+)
+
+// loadOps are the requests loadgen can send, in the order they are reported
+var loadOps = []string{"read", "write", "summary"}
+
+// loadMix is how often each op is picked, relative to the others
+type loadMix map[string]int
+
+// parseLoadMix reads weights like "read=70,write=20,summary=10", ops left out are never sent
+func parseLoadMix(s string) (loadMix, error) {
+	mix := make(loadMix)
+	for part := range strings.SplitSeq(s, ",") {
+		op, raw, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || !slices.Contains(loadOps, op) {
+			return nil, fmt.Errorf("-mix %q must be op=weight pairs, with ops from %s", s, strings.Join(loadOps, ", "))
+		}
+		weight, err := strconv.Atoi(raw)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("-mix weight %q of %s must be a whole number", raw, op)
+		}
+		mix[op] = weight
+	}
+
+	total := 0
+	for _, weight := range mix {
+		total += weight
+	}
+	if total == 0 {
+		return nil, errors.New("-mix needs at least one op with a weight above 0")
+	}
+	return mix, nil
+}
+
+// pick chooses an op at random, by weight
+func (m loadMix) pick(rng *rand.Rand) string {
+	total := 0
+	for _, op := range loadOps {
+		total += m[op]
+	}
+	n := rng.IntN(total)
+	for _, op := range loadOps {
+		if n < m[op] {
+			return op
+		}
+		n -= m[op]
+	}
+	return loadOps[0]
+}
+
+// percentile is the nearest rank p percentile of sorted latencies, i.e. 0.99 for p99
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(float64(len(sorted))*p+0.999999) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+// loadResult is what one op saw over the run
+type loadResult struct {
+	Op          string        `json:"op"`
+	Requests    int           `json:"requests"`
+	Errors      int           `json:"errors"`
+	SampleError string        `json:"sample_error,omitempty"`
+	P50         time.Duration `json:"p50_ns"`
+	P90         time.Duration `json:"p90_ns"`
+	P99         time.Duration `json:"p99_ns"`
+	Max         time.Duration `json:"max_ns"`
+
+	latencies []time.Duration
+}
+
+// loadRecorder collects latencies from every worker, and the expenses written so they can be deleted after
+type loadRecorder struct {
+	mux     sync.Mutex
+	results map[string]*loadResult
+	created []int
+}
+
+func (r *loadRecorder) record(op string, latency time.Duration, err error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	result, ok := r.results[op]
+	if !ok {
+		result = &loadResult{Op: op}
+		r.results[op] = result
+	}
+	result.Requests++
+	result.latencies = append(result.latencies, latency)
+	if err != nil {
+		result.Errors++
+		result.SampleError = err.Error()
+	}
+}
+
+// send runs one op, timing only the request itself
+func (r *loadRecorder) send(ctx context.Context, api *client.Client, op string, rng *rand.Rand) {
+	start := time.Now()
+	var err error
+	switch op {
+	case "read":
+		_, err = api.List(ctx, client.ListOptions{Limit: 50})
+	case "write":
+		var created *client.Expense
+		created, err = api.Create(ctx, &client.ExpenseInput{
+			OccuredAt:   time.Now().Add(-time.Duration(rng.IntN(90*24)) * time.Hour),
+			Description: "loadgen",
+			Amount:      100 + rng.Int64N(10000),
+		})
+		if err == nil {
+			r.mux.Lock()
+			r.created = append(r.created, created.ID)
+			r.mux.Unlock()
+		}
+	case "summary":
+		ranges := []client.SummaryOptions{{Range: "this-month"}, {Range: "this-year"}, {Range: "all"}}
+		_, err = api.Summarize(ctx, ranges[rng.IntN(len(ranges))])
+	}
+
+	// requests cut off by the end of the run aren't the server's fault
+	if ctx.Err() != nil {
+		return
+	}
+	r.record(op, time.Since(start), err)
+}
+
+// runLoadgen sends a mix of requests from -concurrency workers for -duration, then reports the latencies
+// of each kind. Written expenses are deleted afterwards unless -keep is set
+func runLoadgen(ctx context.Context, c *cli, args []string) error {
+	flags := newFlagSet(c, "loadgen", "")
+	duration := flags.Duration("duration", 30*time.Second, "how long to send requests for")
+	concurrency := flags.Int("concurrency", 8, "requests in flight at once")
+	rate := flags.Float64("rate", 0, "requests per second across every worker, 0 sends as fast as the server answers")
+	mixFlag := flags.String("mix", "read=70,write=20,summary=10", "relative weights of the read, write, and summary requests")
+	keep := flags.Bool("keep", false, "keep the written expenses instead of deleting them afterwards")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *concurrency < 1 || *duration <= 0 {
+		return errors.New("-concurrency and -duration must be above 0")
+	}
+	mix, err := parseLoadMix(*mixFlag)
+	if err != nil {
+		return err
+	}
+
+	// every failure is measured rather than retried, over enough connections for every worker
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = *concurrency
+	api := c.newAPI(client.WithRetries(0, 0), client.WithHTTPClient(&http.Client{Transport: transport, Timeout: 30 * time.Second}))
+
+	runCtx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+
+	// with a rate, workers wait on a shared ticker instead of going as fast as they can
+	var ticks <-chan time.Time
+	if *rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / *rate))
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	recorder := &loadRecorder{results: make(map[string]*loadResult)}
+	seed := rand.Uint64()
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for worker := range *concurrency {
+		wg.Go(func() {
+			rng := rand.New(rand.NewPCG(seed, uint64(worker)))
+			for runCtx.Err() == nil {
+				if ticks != nil {
+					select {
+					case <-ticks:
+					case <-runCtx.Done():
+						return
+					}
+				}
+				recorder.send(runCtx, api, mix.pick(rng), rng)
+			}
+		})
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	results := make([]*loadResult, 0, len(recorder.results))
+	total := 0
+	for _, op := range loadOps {
+		result, ok := recorder.results[op]
+		if !ok {
+			continue
+		}
+		slices.Sort(result.latencies)
+		result.P50 = percentile(result.latencies, 0.50)
+		result.P90 = percentile(result.latencies, 0.90)
+		result.P99 = percentile(result.latencies, 0.99)
+		result.Max = result.latencies[len(result.latencies)-1]
+		results = append(results, result)
+		total += result.Requests
+	}
+
+	if err := writeLoadResults(c, results, float64(total)/elapsed.Seconds()); err != nil {
+		return err
+	}
+
+	if *keep || len(recorder.created) == 0 {
+		return nil
+	}
+	// cleaned up even after an interrupt, which would otherwise leave every written expense behind
+	cleanupCtx, cancelCleanup := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+	defer cancelCleanup()
+	for i, id := range recorder.created {
+		if err := c.api.Delete(cleanupCtx, id); err != nil {
+			return fmt.Errorf("deleting written expense %d, %d of %d are left: %w", id, len(recorder.created)-i, len(recorder.created), err)
+		}
+	}
+	fmt.Fprintf(c.stderr, "deleted the %d written expenses\n", len(recorder.created))
+	return nil
+}
+
+func writeLoadResults(c *cli, results []*loadResult, throughput float64) error {
+	if c.format == outputJSON {
+		return writeJSON(c.stdout, map[string]any{"requests_per_second": throughput, "ops": results})
+	}
+
+	ms := func(d time.Duration) string {
+		return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 1, 64)
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "OP\tREQUESTS\tERRORS\tP50 MS\tP90 MS\tP99 MS\tMAX MS\t")
+	for _, result := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t\n",
+			result.Op, result.Requests, result.Errors, ms(result.P50), ms(result.P90), ms(result.P99), ms(result.Max))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "\n%.1f requests per second\n", throughput)
+	for _, result := range results {
+		if result.SampleError != "" {
+			fmt.Fprintf(c.stdout, "%s errors, i.e. %s\n", result.Op, result.SampleError)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLoadMix(t *testing.T) {
+	testTable := []struct {
+		name        string
+		input       string
+		expectError bool
+		want        loadMix
+	}{
+		{name: "valid-all-ops", input: "read=70, write=20, summary=10", want: loadMix{"read": 70, "write": 20, "summary": 10}},
+		{name: "valid-only-writes", input: "write=1", want: loadMix{"write": 1}},
+		{name: "invalid-unknown-op", input: "read=1,export=1", expectError: true},
+		{name: "invalid-negative-weight", input: "read=-1", expectError: true},
+		{name: "invalid-all-zero", input: "read=0,write=0", expectError: true},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, gotErr := parseLoadMix(testCase.input)
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+			if len(got) != len(testCase.want) {
+				t.Fatalf("got: %v, want: %v", got, testCase.want)
+			}
+			for op, weight := range testCase.want {
+				if got[op] != weight {
+					t.Errorf("got: %v, want: %v", got, testCase.want)
+				}
+			}
+
+			// ops without weight are never picked
+			if !testCase.expectError {
+				rng := rand.New(rand.NewPCG(1, 2))
+				for range 100 {
+					if op := got.pick(rng); got[op] == 0 {
+						t.Fatalf("picked %s, which has no weight", op)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 0, 100)
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+
+	testTable := []struct {
+		name       string
+		inputP     float64
+		inputTimes []time.Duration
+		want       time.Duration
+	}{
+		{name: "valid-p50", inputP: 0.5, inputTimes: sorted, want: 50 * time.Millisecond},
+		{name: "valid-p99", inputP: 0.99, inputTimes: sorted, want: 99 * time.Millisecond},
+		{name: "valid-p99-of-one", inputP: 0.99, inputTimes: sorted[:1], want: time.Millisecond},
+		{name: "valid-empty", inputP: 0.5, inputTimes: nil, want: 0},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := percentile(testCase.inputTimes, testCase.inputP); got != testCase.want {
+				t.Errorf("got: %v, want: %v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestLoadgen(t *testing.T) {
+	api := &fakeAPI{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	t.Setenv("EXPENSECTL_SERVER", srv.URL)
+	t.Setenv("EXPENSECTL_TOKEN", "secret")
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"-config", "", "loadgen", "-duration", "100ms", "-concurrency", "2", "-mix", "read=1,write=1", "-keep"}
+	if err := run(t.Context(), args, strings.NewReader(""), &stdout, &stderr); err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+
+	output := stdout.String()
+	for _, want := range []string{"read", "write", "P99 MS", "requests per second"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output is missing %q, got:\n%s", want, output)
+		}
+	}
+
+	// waits on requests the end of the run cut off, which the server may still be answering
+	srv.Close()
+	api.mux.Lock()
+	defer api.mux.Unlock()
+	if strings.Contains(output, "summary") || len(api.created) == 0 {
+		t.Errorf("got %d created and output:\n%s", len(api.created), output)
+	}
+}
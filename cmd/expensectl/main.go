@@ -0,0 +1,141 @@
+// expensectl is a command line client for the expense tracker API.
+//
+//	expensectl [-server URL] [-token TOKEN] [-config FILE] [-o table|json] <command> [flags] [args]
+//
+// The server and token are read from the config file, ~/.config/expensectl/config.json on linux,
+// then EXPENSECTL_SERVER and EXPENSECTL_TOKEN, then the flags, each overriding the last.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+
+	"github.com/nicholasss/expense-tracker-api/client"
+)
+
+// cli is what every command runs with
+type cli struct {
+	api      *client.Client
+	newAPI   func(opts ...client.Option) *client.Client // the same server and token, with other options
+	format   string
+	profiles map[string]*bankProfile // built in and configured bank csv profiles
+	stdin    io.Reader
+	stdout   io.Writer
+	stderr   io.Writer
+}
+
+// command is one subcommand, i.e. expensectl list
+type command struct {
+	summary string
+	run     func(ctx context.Context, c *cli, args []string) error
+	// offline commands don't need a server
+	offline bool
+}
+
+// commands is filled in by init, since completion lists the commands themselves
+var commands map[string]*command
+
+func init() {
+	commands = map[string]*command{
+		"add":        {summary: "record an expense", run: runAdd},
+		"list":       {summary: "list expenses, newest first", run: runList},
+		"summary":    {summary: "total expenses per day, month, or year", run: runSummary},
+		"delete":     {summary: "delete expenses by id", run: runDelete},
+		"import":     {summary: "record every expense in a csv or json file", run: runImport},
+		"loadgen":    {summary: "send a mix of requests for a while and report their latencies", run: runLoadgen},
+		"export":     {summary: "download every expense as csv", run: runExport},
+		"tui":        {summary: "browse, search, add, and edit expenses interactively", run: runTUI},
+		"completion": {summary: "print a bash, zsh, or fish completion script", run: runCompletion, offline: true},
+	}
+}
+
+// commandNames are the commands in alphabetical order
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "expensectl:", err)
+		os.Exit(1)
+	}
+}
+
+// run parses the global flags and runs the command named after them
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("expensectl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", defaultConfigPath(), "config file with the server and token")
+	server := flags.String("server", "", "server URL, i.e. http://localhost:8080")
+	token := flags.String("token", "", "API token, see POST /users/me/tokens")
+	format := flags.String("o", outputTable, "output format, table or json")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: expensectl [flags] <command> [command flags] [args]\n\ncommands:\n")
+		for _, name := range commandNames() {
+			fmt.Fprintf(stderr, "  %-11s %s\n", name, commands[name].summary)
+		}
+		fmt.Fprintf(stderr, "\nflags:\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !slices.Contains([]string{outputTable, outputJSON}, *format) {
+		return fmt.Errorf("-o must be %s or %s", outputTable, outputJSON)
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+
+	name := flags.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		flags.Usage()
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if *server != "" {
+		cfg.Server = *server
+	}
+	if *token != "" {
+		cfg.Token = *token
+	}
+	if cfg.Server == "" && !cmd.offline {
+		return errors.New("no server set, use -server, EXPENSECTL_SERVER, or the config file")
+	}
+
+	// configured profiles replace built in ones of the same name
+	profiles := maps.Clone(bankProfiles)
+	maps.Copy(profiles, cfg.Profiles)
+
+	newAPI := func(opts ...client.Option) *client.Client {
+		return client.New(cfg.Server, append([]client.Option{client.WithToken(cfg.Token)}, opts...)...)
+	}
+	c := &cli{api: newAPI(), newAPI: newAPI, format: *format, profiles: profiles, stdin: stdin, stdout: stdout, stderr: stderr}
+	return cmd.run(ctx, c, flags.Args()[1:])
+}
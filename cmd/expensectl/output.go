@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/client"
+)
+
+// output formats, set with -o
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// formatAmount shows cents as a decimal, i.e. 1250 as 12.50
+func formatAmount(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// parseAmount reads a decimal amount into cents, i.e. 12.5 as 1250. More than two decimals is an error
+func parseAmount(s string) (int64, error) {
+	whole, fraction, _ := strings.Cut(strings.TrimSpace(s), ".")
+	if len(fraction) > 2 {
+		return 0, fmt.Errorf("amount %q has more than two decimals", s)
+	}
+
+	fraction = (fraction + "00")[:2]
+	cents, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil || whole == "" {
+		return 0, fmt.Errorf("amount %q is not a number like 12.50", s)
+	}
+	return cents, nil
+}
+
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func writeExpenses(w io.Writer, format string, records []*client.Expense) error {
+	if format == outputJSON {
+		return writeJSON(w, records)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "ID\tOCCURED\tAMOUNT\t DESCRIPTION")
+	for _, record := range records {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t %s\n",
+			record.ID, record.OccuredAt.Local().Format("2006-01-02 15:04"), formatAmount(record.Amount), record.Description)
+	}
+	return tw.Flush()
+}
+
+// writeImportPreview shows expenses read for import, which have no id until they are recorded
+func writeImportPreview(w io.Writer, format string, records []*client.ExpenseInput) error {
+	if format == outputJSON {
+		return writeJSON(w, records)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "OCCURED\tAMOUNT\t DESCRIPTION")
+	for _, record := range records {
+		fmt.Fprintf(tw, "%s\t%s\t %s\n", record.OccuredAt.Local().Format("2006-01-02 15:04"), formatAmount(record.Amount), record.Description)
+	}
+	return tw.Flush()
+}
+
+func writeSummary(w io.Writer, format string, got *client.Summary) error {
+	if format == outputJSON {
+		return writeJSON(w, got)
+	}
+
+	layouts := map[string]string{"day": "2006-01-02", "month": "2006-01", "year": "2006"}
+	layout, ok := layouts[got.GroupBy]
+	if !ok {
+		layout = time.DateOnly
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "%s\tCOUNT\tAMOUNT\t\n", strings.ToUpper(got.GroupBy))
+	for _, period := range got.Periods {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t\n", period.Start.UTC().Format(layout), period.Count, formatAmount(period.Amount))
+	}
+	fmt.Fprintf(tw, "total\t%d\t%s\t\n", got.Count, formatAmount(got.Amount))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if got.Conversion != nil {
+		stale := ""
+		if got.Conversion.Stale {
+			stale = ", rates are stale"
+		}
+		fmt.Fprintf(w, "converted to %s at %v%s\n", got.Conversion.To, got.Conversion.Rate, stale)
+	}
+	return nil
+}
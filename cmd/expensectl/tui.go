@@ -0,0 +1,364 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nicholasss/expense-tracker-api/client"
+)
+
+// tuiPageSize is how many expenses each load asks for, n loads the next page
+const tuiPageSize = 100
+
+// tuiMode is what keys do, browsing the list or typing into one of the inputs
+type tuiMode int
+
+const (
+	modeBrowse tuiMode = iota
+	modeSearch
+	modeForm
+	modeConfirmDelete
+)
+
+// the inputs of the add and edit form, in tab order
+const (
+	fieldAmount = iota
+	fieldDescription
+	fieldOccuredAt
+	fieldCount
+)
+
+// messages sent back by the commands that call the API
+type (
+	pageMsg struct {
+		page  *client.Page
+		reset bool // the first page, replacing what was loaded
+	}
+	savedMsg struct{ status string }
+	errMsg   struct{ err error }
+)
+
+// tuiModel browses the loaded expenses, searching their descriptions, and adds, edits, and deletes them
+type tuiModel struct {
+	ctx context.Context
+	api *client.Client
+
+	expenses   []*client.Expense // newest first, as loaded
+	nextCursor string
+	visible    []*client.Expense // the expenses matching the search
+	selected   int               // index into visible
+	height     int
+
+	mode    tuiMode
+	search  textinput.Model
+	form    [fieldCount]textinput.Model
+	focus   int
+	editing *client.Expense // nil while adding
+	status  string
+}
+
+func newTUIModel(ctx context.Context, api *client.Client) *tuiModel {
+	m := &tuiModel{ctx: ctx, api: api, height: 24, search: textinput.New()}
+	m.search.Prompt = "/"
+	m.search.Placeholder = "search descriptions"
+
+	for i, placeholder := range [fieldCount]string{"12.50", "lunch with the team", "2025-10-01 or an RFC 3339 time, empty for now"} {
+		m.form[i] = textinput.New()
+		m.form[i].Placeholder = placeholder
+	}
+	m.form[fieldAmount].Prompt = "amount:      "
+	m.form[fieldDescription].Prompt = "description: "
+	m.form[fieldOccuredAt].Prompt = "occured at:  "
+	return m
+}
+
+// load gets the first page when cursor is empty, or the page after it
+func (m *tuiModel) load(cursor string) tea.Cmd {
+	return func() tea.Msg {
+		page, err := m.api.List(m.ctx, client.ListOptions{Limit: tuiPageSize, Cursor: cursor})
+		if err != nil {
+			return errMsg{err}
+		}
+		return pageMsg{page: page, reset: cursor == ""}
+	}
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return m.load("")
+}
+
+// filter fills visible with the expenses whose description contains the search, ignoring case
+func (m *tuiModel) filter() {
+	term := strings.ToLower(strings.TrimSpace(m.search.Value()))
+	m.visible = m.visible[:0]
+	for _, exp := range m.expenses {
+		if term == "" || strings.Contains(strings.ToLower(exp.Description), term) {
+			m.visible = append(m.visible, exp)
+		}
+	}
+	m.selected = min(m.selected, max(len(m.visible)-1, 0))
+}
+
+// current is the selected expense, nil when none are visible
+func (m *tuiModel) current() *client.Expense {
+	if m.selected >= len(m.visible) {
+		return nil
+	}
+	return m.visible[m.selected]
+}
+
+// openForm starts adding an expense, or editing exp when it is not nil
+func (m *tuiModel) openForm(exp *client.Expense) tea.Cmd {
+	m.mode, m.editing, m.focus = modeForm, exp, fieldAmount
+	for i := range m.form {
+		m.form[i].Reset()
+		m.form[i].Blur()
+	}
+	if exp != nil {
+		m.form[fieldAmount].SetValue(formatAmount(exp.Amount))
+		m.form[fieldDescription].SetValue(exp.Description)
+		m.form[fieldOccuredAt].SetValue(exp.OccuredAt.Local().Format(time.RFC3339))
+	}
+	return m.form[fieldAmount].Focus()
+}
+
+// submit saves the form, the list is reloaded once the server has it
+func (m *tuiModel) submit() tea.Cmd {
+	amount, err := parseAmount(m.form[fieldAmount].Value())
+	if err != nil {
+		m.status = err.Error()
+		return nil
+	}
+	occuredAt := time.Now()
+	if raw := strings.TrimSpace(m.form[fieldOccuredAt].Value()); raw != "" {
+		if occuredAt, err = parseTime(raw); err != nil {
+			m.status = err.Error()
+			return nil
+		}
+	}
+	input := &client.ExpenseInput{
+		OccuredAt:   occuredAt,
+		Description: strings.TrimSpace(m.form[fieldDescription].Value()),
+		Amount:      amount,
+	}
+
+	m.mode, m.status = modeBrowse, "saving..."
+	editing := m.editing
+	return func() tea.Msg {
+		if editing != nil {
+			if err := m.api.Update(m.ctx, editing.ID, input); err != nil {
+				return errMsg{err}
+			}
+			return savedMsg{fmt.Sprintf("updated %d", editing.ID)}
+		}
+
+		created, err := m.api.Create(m.ctx, input)
+		if err != nil {
+			return errMsg{err}
+		}
+		return savedMsg{fmt.Sprintf("added %d", created.ID)}
+	}
+}
+
+func (m *tuiModel) remove(exp *client.Expense) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.api.Delete(m.ctx, exp.ID); err != nil {
+			return errMsg{err}
+		}
+		return savedMsg{fmt.Sprintf("deleted %d", exp.ID)}
+	}
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		return m, nil
+	case pageMsg:
+		if msg.reset {
+			m.expenses = m.expenses[:0]
+		}
+		m.expenses = append(m.expenses, msg.page.Expenses...)
+		m.nextCursor = msg.page.NextCursor
+		m.filter()
+		return m, nil
+	case savedMsg:
+		m.status = msg.status
+		return m, m.load("")
+	case errMsg:
+		m.status = "error: " + msg.err.Error()
+		return m, nil
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		switch m.mode {
+		case modeSearch:
+			return m.updateSearch(msg)
+		case modeForm:
+			return m.updateForm(msg)
+		case modeConfirmDelete:
+			return m.updateConfirmDelete(msg)
+		default:
+			return m.updateBrowse(msg)
+		}
+	}
+	return m, nil
+}
+
+func (m *tuiModel) updateBrowse(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc":
+		return m, tea.Quit
+	case "up", "k":
+		m.selected = max(m.selected-1, 0)
+	case "down", "j":
+		m.selected = min(m.selected+1, max(len(m.visible)-1, 0))
+	case "/":
+		m.mode = modeSearch
+		return m, m.search.Focus()
+	case "a":
+		return m, m.openForm(nil)
+	case "e", "enter":
+		if exp := m.current(); exp != nil {
+			return m, m.openForm(exp)
+		}
+	case "d":
+		if m.current() != nil {
+			m.mode = modeConfirmDelete
+		}
+	case "n":
+		if m.nextCursor != "" {
+			m.status = "loading..."
+			return m, m.load(m.nextCursor)
+		}
+		m.status = "every expense is loaded"
+	case "r":
+		m.status = "reloading..."
+		return m, m.load("")
+	}
+	return m, nil
+}
+
+func (m *tuiModel) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.search.Reset()
+		fallthrough
+	case "enter":
+		m.search.Blur()
+		m.mode = modeBrowse
+		m.filter()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	m.filter()
+	return m, cmd
+}
+
+func (m *tuiModel) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode, m.status = modeBrowse, ""
+		return m, nil
+	case "tab", "down", "shift+tab", "up":
+		step := 1
+		if msg.String() == "shift+tab" || msg.String() == "up" {
+			step = fieldCount - 1
+		}
+		m.form[m.focus].Blur()
+		m.focus = (m.focus + step) % fieldCount
+		return m, m.form[m.focus].Focus()
+	case "enter":
+		if m.focus < fieldCount-1 {
+			m.form[m.focus].Blur()
+			m.focus++
+			return m, m.form[m.focus].Focus()
+		}
+		return m, m.submit()
+	}
+
+	var cmd tea.Cmd
+	m.form[m.focus], cmd = m.form[m.focus].Update(msg)
+	return m, cmd
+}
+
+func (m *tuiModel) updateConfirmDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.mode = modeBrowse
+	if msg.String() != "y" {
+		m.status = ""
+		return m, nil
+	}
+	m.status = "deleting..."
+	return m, m.remove(m.current())
+}
+
+func (m *tuiModel) View() string {
+	var b strings.Builder
+
+	if m.mode == modeForm {
+		title := "add an expense"
+		if m.editing != nil {
+			title = fmt.Sprintf("edit expense %d", m.editing.ID)
+		}
+		fmt.Fprintf(&b, "%s\n\n", title)
+		for i := range m.form {
+			fmt.Fprintf(&b, "%s\n", m.form[i].View())
+		}
+		fmt.Fprintf(&b, "\n%s\n\ntab next field • enter save on the last field • esc cancel\n", m.status)
+		return b.String()
+	}
+
+	more := ""
+	if m.nextCursor != "" {
+		more = ", n loads more"
+	}
+	fmt.Fprintf(&b, "%d of %d loaded expenses%s\n", len(m.visible), len(m.expenses), more)
+	if m.mode == modeSearch || m.search.Value() != "" {
+		fmt.Fprintf(&b, "%s\n", m.search.View())
+	}
+	b.WriteString("\n")
+
+	// the rows that fit, scrolled to keep the selected one in view
+	rows := max(m.height-7, 1)
+	start := max(min(m.selected-rows/2, len(m.visible)-rows), 0)
+	for i := start; i < min(start+rows, len(m.visible)); i++ {
+		exp := m.visible[i]
+		marker := "  "
+		if i == m.selected {
+			marker = "> "
+		}
+		fmt.Fprintf(&b, "%s%6d  %s  %10s  %s\n",
+			marker, exp.ID, exp.OccuredAt.Local().Format("2006-01-02 15:04"), formatAmount(exp.Amount), exp.Description)
+	}
+
+	status := m.status
+	if m.mode == modeConfirmDelete {
+		status = fmt.Sprintf("delete expense %d? y to confirm, anything else cancels", m.current().ID)
+	}
+	fmt.Fprintf(&b, "\n%s\n↑/↓ move • / search • a add • e edit • d delete • r reload • q quit\n", status)
+	return b.String()
+}
+
+// runTUI browses expenses interactively until q or ctrl+c
+func runTUI(ctx context.Context, c *cli, args []string) error {
+	flags := newFlagSet(c, "tui", "")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	program := tea.NewProgram(newTUIModel(ctx, c.api),
+		tea.WithContext(ctx), tea.WithInput(c.stdin), tea.WithOutput(c.stdout), tea.WithAltScreen())
+	if _, err := program.Run(); err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nicholasss/expense-tracker-api/client"
+)
+
+// typeKeys sends each key to the model, named keys like "enter" or runes to type
+func typeKeys(m *tuiModel, keys ...string) tea.Cmd {
+	named := map[string]tea.KeyType{"enter": tea.KeyEnter, "esc": tea.KeyEsc, "tab": tea.KeyTab}
+
+	var cmd tea.Cmd
+	for _, key := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		if keyType, ok := named[key]; ok {
+			msg = tea.KeyMsg{Type: keyType}
+		}
+		_, cmd = m.Update(msg)
+	}
+	return cmd
+}
+
+// runCmd runs an API command and hands its message back to the model, returning the command that follows
+func runCmd(t *testing.T, m *tuiModel, cmd tea.Cmd) tea.Cmd {
+	t.Helper()
+	if cmd == nil {
+		t.Fatalf("got no command, want one calling the API")
+	}
+	_, next := m.Update(cmd())
+	return next
+}
+
+func TestTUI(t *testing.T) {
+	api := &fakeAPI{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	m := newTUIModel(t.Context(), client.New(srv.URL, client.WithToken("secret")))
+	runCmd(t, m, m.Init())
+	if len(m.expenses) != 2 || m.nextCursor == "" {
+		t.Fatalf("got %d expenses after the first page, want 2 and more to load", len(m.expenses))
+	}
+
+	// n loads the next page onto the end
+	runCmd(t, m, typeKeys(m, "n"))
+	if len(m.expenses) != 3 || m.nextCursor != "" {
+		t.Fatalf("got %d expenses after the second page, want 3 and no more", len(m.expenses))
+	}
+
+	// searching narrows the list down as it is typed
+	typeKeys(m, "/", "t", "h", "r", "enter")
+	if len(m.visible) != 1 || m.current().Description != "three" {
+		t.Fatalf("got %d visible after searching, want only three", len(m.visible))
+	}
+	if view := m.View(); !strings.Contains(view, "1 of 3") || strings.Contains(view, "two") {
+		t.Errorf("got view after searching:\n%s", view)
+	}
+
+	// deleting asks first
+	typeKeys(m, "d", "x")
+	if len(api.deleted) != 0 || m.mode != modeBrowse {
+		t.Fatalf("got %v deleted after cancelling, want none", api.deleted)
+	}
+	runCmd(t, m, typeKeys(m, "d", "y"))
+	if len(api.deleted) != 1 || api.deleted[0] != 3 {
+		t.Fatalf("got %v deleted, want [3]", api.deleted)
+	}
+
+	// a bad amount keeps the form open
+	typeKeys(m, "a", "1", "2", ".", "5", "0", "0", "enter", "enter", "enter")
+	if m.mode != modeForm || !strings.Contains(m.status, "more than two decimals") {
+		t.Fatalf("got mode %d and status %q after a bad amount", m.mode, m.status)
+	}
+	typeKeys(m, "esc")
+
+	// adding saves once enter is pressed on the last field, then reloads
+	typeKeys(m, "a", "1", "2", ".", "5", "enter")
+	typeKeys(m, strings.Split("lunch", "")...)
+	typeKeys(m, "enter")
+	typeKeys(m, strings.Split("2025-10-01", "")...)
+	next := runCmd(t, m, typeKeys(m, "enter"))
+	if len(api.created) != 1 || api.created[0].Amount != 1250 || api.created[0].Description != "lunch" {
+		t.Fatalf("got created: %+v", api.created)
+	}
+	if m.status != "added 10" || next == nil {
+		t.Errorf("got status %q after adding, want added 10 and a reload", m.status)
+	}
+}
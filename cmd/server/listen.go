@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// listenFDsStart is the first file descriptor systemd passes, after stdin, stdout, and stderr
+const listenFDsStart = 3
+
+// activatedListeners are the sockets passed in by systemd socket activation, in the order of the
+// ListenStream= lines, or nil when the process was not socket activated. systemd keeps the sockets
+// open across restarts, so connections queue up instead of being refused while the binary is replaced.
+func activatedListeners() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+
+	// the sockets are ours alone, so they are not passed on to anything started from here
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]net.Listener, 0, count)
+	for fd := listenFDsStart; fd < listenFDsStart+count; fd++ {
+		file := os.NewFile(uintptr(fd), "listen-fd-"+strconv.Itoa(fd))
+		listener, err := net.FileListener(file)
+		file.Close() // FileListener holds its own copy
+		if err != nil {
+			return nil, fmt.Errorf("socket activation fd %d: %w", fd, err)
+		}
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// listen binds addr, with SO_REUSEPORT when reusePort is set so that another process can bind it too.
+// During a deploy the new process starts listening, then the old one is sent SIGTERM and drains.
+func listen(addr string, reusePort bool) (net.Listener, error) {
+	lc := net.ListenConfig{}
+	if reusePort {
+		lc.Control = reusePortControl
+	}
+	return lc.Listen(context.Background(), "tcp", addr)
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package main
+
+import (
+	"errors"
+	"syscall"
+)
+
+// reusePortControl fails, SO_REUSEPORT is not available on this platform
+func reusePortControl(network, address string, conn syscall.RawConn) error {
+	return errors.New("LISTEN_REUSE_PORT is not supported on this platform")
+}
@@ -1,41 +1,431 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	// expenses keep the IANA zone they occured in, which has to load on hosts without a zone database
+	_ "time/tzdata"
 
 	"github.com/nicholasss/expense-tracker-api/config"
+	"github.com/nicholasss/expense-tracker-api/internal/audit"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/banksync"
+	"github.com/nicholasss/expense-tracker-api/internal/categories"
+	"github.com/nicholasss/expense-tracker-api/internal/errreport"
 	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/fieldcrypt"
+	"github.com/nicholasss/expense-tracker-api/internal/fxrates"
+	"github.com/nicholasss/expense-tracker-api/internal/households"
+	"github.com/nicholasss/expense-tracker-api/internal/i18n"
+	"github.com/nicholasss/expense-tracker-api/internal/jobs"
+	"github.com/nicholasss/expense-tracker-api/internal/mail"
+	"github.com/nicholasss/expense-tracker-api/internal/oidc"
+	"github.com/nicholasss/expense-tracker-api/internal/opstats"
+	"github.com/nicholasss/expense-tracker-api/internal/outbox"
+	"github.com/nicholasss/expense-tracker-api/internal/projections"
+	"github.com/nicholasss/expense-tracker-api/internal/repometrics"
+	"github.com/nicholasss/expense-tracker-api/internal/respcache"
+	"github.com/nicholasss/expense-tracker-api/internal/retention"
+	"github.com/nicholasss/expense-tracker-api/internal/search"
+	"github.com/nicholasss/expense-tracker-api/internal/selfcheck"
+	"github.com/nicholasss/expense-tracker-api/internal/slack"
 	"github.com/nicholasss/expense-tracker-api/internal/sqlite"
+	"github.com/nicholasss/expense-tracker-api/internal/users"
 	"github.com/nicholasss/expense-tracker-api/routes"
 )
 
-const ConfigPath = ".env"
+// readHeaderTimeout stops slow clients from holding connections open before sending a request
+const readHeaderTimeout = 10 * time.Second
+
+// jobQueueSize is how many async jobs can wait for a worker before new ones are refused
+const jobQueueSize = 32
+
+// statsWindow is how far back the admin dashboard's request rates reach
+const statsWindow = 15 * time.Minute
+
+// buildRevision is the commit the binary was built from, for tagging error reports
+func buildRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return ""
+}
+
+// fieldKeyring is the keyring of FIELD_ENCRYPTION_KEYS, nil when no keys are configured
+func fieldKeyring(cfg *config.Config) (*fieldcrypt.Keyring, error) {
+	if cfg.FieldEncryptionKeys == "" {
+		return nil, nil
+	}
+
+	keys, err := fieldcrypt.ParseKeys(cfg.FieldEncryptionKeys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse FIELD_ENCRYPTION_KEYS: %w", err)
+	}
+	return fieldcrypt.NewKeyring(keys)
+}
+
+// rateProvider is the FX_PROVIDER source of exchange rates, falling back to FX_RATES_FILE when one is set
+func rateProvider(cfg *config.Config) fxrates.RateProvider {
+	var provider fxrates.RateProvider
+	switch cfg.FXProvider {
+	case "static":
+		return fxrates.NewStaticProvider(cfg.FXRatesFile)
+	case "json":
+		provider = fxrates.NewJSONProvider(cfg.FXRatesURL)
+	default:
+		provider = fxrates.NewECBProvider(cfg.FXRatesURL)
+	}
+
+	if cfg.FXRatesFile != "" {
+		provider = &fxrates.FallbackProvider{Primary: provider, Fallback: fxrates.NewStaticProvider(cfg.FXRatesFile)}
+	}
+	return provider
+}
 
 func main() {
-	cfg, err := config.LoadConfig(ConfigPath)
+	// cancelled on SIGINT or SIGTERM, which starts a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// "seed" fills the database with demo expenses instead of serving
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		if err := runSeed(ctx, os.Args[2:]); err != nil && !errors.Is(err, flag.ErrHelp) {
+			log.Fatalf("Failed to seed demo expenses: %v", err)
+		}
+		return
+	}
+
+	// flags, then the environment, then the config file
+	watcher, err := config.NewWatcher(os.Args[1:])
 	if err != nil {
-		if errors.Is(err, &config.MissingVariableError{}) {
-			log.Fatal("missing variable in .env")
+		if errors.Is(err, flag.ErrHelp) {
+			return
 		}
 
+		// names every missing or invalid variable
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	cfg := watcher.Config()
+	slog.SetLogLoggerLevel(cfg.LogLevel)
 
 	repository, err := sqlite.NewSqliteRepository(cfg.DBDriver, cfg.DBString)
 	if err != nil {
 		log.Fatalf("Failed to load SQLite3 database: %v", err)
 	}
+	repository.Currency = cfg.FXBaseCurrency
+
+	// webhook events are written along with every expense change, and only while there is a webhook to post them to
+	repository.Outbox = cfg.WebhookURL != ""
+
+	// nobody runs goose against dev mode's in-memory database, so it is migrated here
+	if cfg.DevMode {
+		applied, err := sqlite.Migrate(ctx, repository.Writer)
+		if err != nil {
+			log.Fatalf("Failed to migrate the dev mode database: %v", err)
+		}
+		log.Printf("Dev mode: applied %d migrations to %s, nothing is kept after exit when in memory", len(applied), cfg.DBString)
+	}
+
+	checks, err := startupChecks(cfg, repository.DB)
+	if err != nil {
+		log.Fatalf("Failed to setup self-checks: %v", err)
+	}
+	err = selfcheck.Run(ctx, checks, func(name string) {
+		log.Printf("Self-check %s: ok", name)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// range and owner queries table-scan without their indexes
+	createdIndexes, err := repository.EnsureIndexes(ctx)
+	if err != nil {
+		log.Fatalf("Failed to verify database indexes: %v", err)
+	}
+	for _, name := range createdIndexes {
+		log.Printf("Created missing database index %s", name)
+	}
+
+	// description search matches the folded key, which older expenses are stored without
+	folded, err := repository.FoldDescriptions(ctx)
+	if err != nil {
+		log.Fatalf("Failed to fold expense descriptions: %v", err)
+	}
+	if folded > 0 {
+		log.Printf("Folded the descriptions of %d expenses for search", folded)
+	}
+
+	// expenses are recorded in the base currency and checked for dates in the future,
+	// summaries over a bounded range are split across the report workers,
+	// unpaged reads are refused past the result cap, and syncs from before the tombstones kept must resync
+	expenseOpts := []expenses.Option{
+		expenses.WithCurrency(cfg.FXBaseCurrency),
+		expenses.WithFuturePolicy(expenses.FuturePolicies[cfg.FutureExpenses], cfg.FutureExpenseSkew),
+		expenses.WithReportWorkers(cfg.ReportWorkers),
+		expenses.WithMaxResults(cfg.MaxResultRows),
+		expenses.WithDuplicateWindow(cfg.DuplicateWindow),
+		expenses.WithTombstoneRetention(cfg.RetentionRules[retention.TargetTombstones]),
+	}
+
+	// every change to an expense is published here, for whatever reacts to it
+	events := expenses.NewBus()
+	expenseOpts = append(expenseOpts, expenses.WithEvents(events))
+
+	// dropped by the services whenever expenses, household members, or categories change
+	var cache *respcache.Cache
+	var householdOpts []households.Option
+	var categoryOpts []categories.Option
+	if cfg.ResponseCacheTTL > 0 {
+		cache = respcache.New("expenses", cfg.ResponseCacheTTL, cfg.ResponseCacheMaxEntries)
+		expenseOpts = append(expenseOpts, expenses.WithInvalidator(cache))
+		householdOpts = append(householdOpts, households.WithInvalidator(cache))
+		categoryOpts = append(categoryOpts, categories.WithInvalidator(cache))
+	}
+
+	// household members share their expenses with each other
+	userRepository := sqlite.NewUserRepository(repository.DB, repository.Writer)
+	householdService := households.NewService(sqlite.NewHouseholdRepository(repository.DB, repository.Writer), userRepository, householdOpts...)
+
+	// and the categories they put them in
+	categoryService := categories.NewService(sqlite.NewCategoryRepository(repository.DB, repository.Writer),
+		append(categoryOpts, categories.WithHouseholds(householdService))...)
+
+	// query durations, errors, and connections are published under /debug/vars
+	var expenseRepository expenses.Repository = expenses.NewInstrumentedRepository(repository, repometrics.New("sqlite", repository.DB))
+
+	// descriptions are encrypted before they reach the database when keys are configured
+	keyring, err := fieldKeyring(cfg)
+	if err != nil {
+		log.Fatalf("Failed to setup field encryption: %v", err)
+	}
+	if keyring != nil {
+		encrypted := expenses.NewEncryptedRepository(expenseRepository, keyring)
+
+		if cfg.FieldEncryptionRotate {
+			rotated, err := encrypted.Rotate(context.Background())
+			if err != nil {
+				log.Fatalf("Failed to rotate field encryption: %v", err)
+			}
+			log.Printf("Re-encrypted %d expense descriptions with the primary key", rotated)
+		}
+		expenseRepository = encrypted
+	}
 
-	service := expenses.NewService(repository)
+	// large expenses are posted to a Slack channel, however they were created. Only the Slack users' own
+	// are posted once auth is enabled, without it every expense belongs to the one user
+	var notifier *slack.Notifier
+	if cfg.SlackWebhookURL != "" {
+		ownerIDs := []int{0}
+		if cfg.AuthEnabled {
+			ownerIDs = slices.Collect(maps.Values(cfg.SlackUsers))
+		}
+		notifier = slack.NewNotifier(cfg.SlackWebhookURL, int64(cfg.SlackNotifyMinAmount), ownerIDs)
+		expenseOpts = append(expenseOpts, expenses.WithNotifier(notifier))
+	}
+
+	// summaries are converted to other currencies with the exchange rates, refreshed further down
+	var rates *fxrates.Cache
+	if cfg.FXProvider != "" {
+		rates = fxrates.NewCache(rateProvider(cfg),
+			sqlite.NewFXRateRepository(repository.DB, repository.Writer), cfg.FXBaseCurrency, cfg.FXMaxAge)
+		expenseOpts = append(expenseOpts, expenses.WithConverter(rates))
+	}
+
+	service := expenses.NewService(expenseRepository, append(expenseOpts, expenses.WithHouseholds(householdService), expenses.WithCategories(categoryService))...)
+	// the dashboard is projected from the events rather than summed for every request
+	dashboards := projections.NewProjector(expenseRepository, householdService)
+	dashboards.Subscribe(events)
+
+	// descriptions are indexed for fuzzy search as they change, the index is built first when it is new
+	var searchIndex *search.Index
+	if cfg.SearchIndexPath != "" {
+		path := cfg.SearchIndexPath
+		if path == config.MemorySearchIndex {
+			path = ""
+		}
+		searchIndex, err = search.Open(ctx, path, expenseRepository, householdService)
+		if err != nil {
+			log.Fatalf("Failed to open search index: %v", err)
+		}
+		searchIndex.Subscribe(events)
+	}
+
+	jobManager := jobs.NewManager(cfg.JobWorkers, jobQueueSize, cfg.JobRetention)
+
+	// without a mailer the email change token has nowhere to go, so changing email is refused
+	var userOpts []users.Option
+	if cfg.SMTPHost != "" {
+		mailer := mail.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+		userOpts = append(userOpts, users.WithMailer(mailer))
+	}
+	userService := users.NewService(userRepository, userOpts...)
+
+	// logins and denied requests are kept in their own table
+	auditRepository := sqlite.NewAuditRepository(repository.DB, repository.Writer)
+	auditService := audit.NewService(auditRepository)
+
+	// record counts, database size, request rates, and the webhook outbox for GET /admin/stats
+	statsRepository := sqlite.NewStatsRepository(repository.DB)
+	var statsOpts []opstats.Option
+	if cfg.WebhookURL != "" {
+		statsOpts = append(statsOpts, opstats.WithOutbox(sqlite.NewOutboxRepository(repository.DB, repository.Writer)))
+	}
+	statsService := opstats.NewService(statsRepository, statsWindow, statsOpts...)
+
+	if cfg.DevMode {
+		if err := seedDev(ctx, service, statsRepository); err != nil {
+			log.Fatalf("Failed to seed the dev mode database: %v", err)
+		}
+	}
+
+	// summary labels and error messages follow Accept-Language, clients asking for none of ours get the default
+	locales, err := i18n.NewMatcher(cfg.DefaultLocale)
+	if err != nil {
+		log.Fatalf("Failed to setup localization: %v", err)
+	}
+
+	services := routes.Services{
+		Expenses:   service,
+		Users:      userService,
+		Jobs:       jobManager,
+		Households: householdService,
+		Categories: categoryService,
+		Audit:      auditService,
+		Cache:      cache,
+		Stats:      statsService,
+		Dashboards: dashboards,
+		Search:     searchIndex,
+		Locales:    locales,
+	}
+
+	// 5xx responses and panics go to the error tracker when one is configured
+	if cfg.ErrorReportingDSN != "" {
+		reporter, err := errreport.NewReporter(cfg.ErrorReportingDSN, cfg.ErrorReportingEnvironment, buildRevision())
+		if err != nil {
+			log.Fatalf("Failed to setup error reporting: %v", err)
+		}
+		services.Errors = reporter
+	}
+
+	// tokens are issued whenever a secret is set, and required when auth is enabled
+	if cfg.JWTSecret != "" {
+		tokens, err := auth.NewTokenIssuer([]byte(cfg.JWTSecret), cfg.JWTTTL)
+		if err != nil {
+			log.Fatalf("Failed to setup token issuer: %v", err)
+		}
+		services.Tokens = tokens
+	}
+
+	// oidc discovery happens once at startup, so a bad issuer fails fast
+	if cfg.OIDCIssuerURL != "" {
+		provider, err := oidc.NewProvider(context.Background(), cfg.OIDCIssuerURL,
+			cfg.OIDCClientID, cfg.OIDCClientSecret, cfg.OIDCRedirectURL)
+		if err != nil {
+			log.Fatalf("Failed to setup OIDC provider: %v", err)
+		}
+		services.OIDC = provider
+	}
+
+	// background loops stop with ctx, and are waited on before the database closes
+	var background sync.WaitGroup
+
+	// bank sync runs in the background, drafts wait for confirmation
+	if cfg.BankProvider != "" {
+		provider := banksync.NewGoCardlessProvider(cfg.BankSecretID, cfg.BankSecretKey, cfg.BankAccountID)
+		draftRepository := sqlite.NewDraftRepository(repository.DB, repository.Writer)
+		draftRepository.Currency = cfg.FXBaseCurrency
+		bankSync := banksync.NewService(provider, draftRepository, service)
+		background.Go(func() { bankSync.Run(ctx, cfg.BankSyncInterval) })
 
-	ginEngine := routes.SetupRoutes(service)
-	log.Printf("Starting server at %s...\n", cfg.Address)
+		services.BankSync = bankSync
+	}
+
+	// exchange rates are refreshed in the background, starting from the last saved ones
+	if rates != nil {
+		if err := rates.Load(ctx); err != nil {
+			log.Printf("Unable to load saved exchange rates: %v", err)
+		}
+		background.Go(func() { rates.Run(ctx, cfg.FXRefreshInterval) })
+	}
+
+	// expense changes are posted from the outbox, retrying until they are delivered or given up on
+	if cfg.WebhookURL != "" {
+		dispatcher := outbox.NewDispatcher(sqlite.NewOutboxRepository(repository.DB, repository.Writer),
+			outbox.NewWebhook(cfg.WebhookURL, []byte(cfg.WebhookSecret)), cfg.WebhookMaxAttempts)
+		background.Go(func() { dispatcher.Run(ctx, cfg.WebhookPollInterval) })
+
+		services.Outbox = dispatcher
+	}
+
+	// old records are removed on a schedule, and every removal is put in the audit log
+	if len(cfg.RetentionRules) > 0 {
+		purgers := map[string]retention.Purger{
+			retention.TargetAuditEvents: auditRepository,
+			retention.TargetOutbox:      sqlite.NewOutboxRepository(repository.DB, repository.Writer),
+			retention.TargetTombstones:  sqlite.NewTombstoneRepository(repository.DB, repository.Writer),
+		}
+		retentionService := retention.NewService(purgers, cfg.RetentionRules, auditService)
+		background.Go(func() { retentionService.Run(ctx, cfg.RetentionInterval) })
+	}
+
+	ginEngine, reloadable := routes.SetupRoutes(cfg, services)
+
+	// changes to the config file are picked up without a restart, for the settings that allow it
+	if cfg.ConfigReloadInterval > 0 {
+		background.Go(func() {
+			watcher.Watch(ctx, cfg.ConfigReloadInterval, func(next *config.Config) {
+				slog.SetLogLoggerLevel(next.LogLevel)
+				reloadable.Apply(next)
+				log.Println("Config reloaded")
+			})
+		})
+	}
+	srv := &http.Server{Addr: cfg.Address, Handler: ginEngine, ReadHeaderTimeout: readHeaderTimeout}
+
+	err = serve(ctx, cfg, srv)
+
+	// requests have drained, so stop the background work before closing the database under it
+	stop()
+	background.Wait()
+	jobManager.Close()
+	if services.Errors != nil {
+		services.Errors.Close()
+	}
+	if notifier != nil {
+		notifier.Close()
+	}
+	if searchIndex != nil {
+		if closeErr := searchIndex.Close(); closeErr != nil {
+			log.Printf("Failed to close search index: %v", closeErr)
+		}
+	}
+	if closeErr := repository.Close(); closeErr != nil {
+		log.Printf("Failed to close SQLite3 database: %v", closeErr)
+	}
 
-	err = ginEngine.Run(cfg.Address)
 	if err != nil {
 		log.Fatal(err)
 	}
+	log.Println("Server stopped")
 }
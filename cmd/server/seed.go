@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/config"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/households"
+	"github.com/nicholasss/expense-tracker-api/internal/seed"
+	"github.com/nicholasss/expense-tracker-api/internal/sqlite"
+	"github.com/nicholasss/expense-tracker-api/internal/users"
+)
+
+// runSeed fills the configured database with demo expenses:
+//
+//	server seed [-months 6] [-owner EMAIL] [-seed N] [-- config flags]
+//
+// The database is found the same way the server finds it, config flags go after --
+func runSeed(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	months := flags.Int("months", 6, "months of expenses up to today, the current month included")
+	owner := flags.String("owner", "", "email of the user the expenses belong to, unowned when empty")
+	seedNumber := flags.Uint64("seed", 0, "random seed, the same seed makes the same expenses (default random)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *months < 1 {
+		return errors.New("-months must be at least 1")
+	}
+	if *seedNumber == 0 {
+		*seedNumber = rand.Uint64()
+	}
+
+	cfg, err := config.Load(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	repository, err := sqlite.NewSqliteRepository(cfg.DBDriver, cfg.DBString)
+	if err != nil {
+		return err
+	}
+	defer repository.Close()
+	repository.Currency = cfg.FXBaseCurrency
+
+	// through the service, so the expenses are validated, encrypted, and put in households like any other
+	userRepository := sqlite.NewUserRepository(repository.DB, repository.Writer)
+	householdService := households.NewService(sqlite.NewHouseholdRepository(repository.DB, repository.Writer), userRepository)
+
+	var expenseRepository expenses.Repository = repository
+	keyring, err := fieldKeyring(cfg)
+	if err != nil {
+		return err
+	}
+	if keyring != nil {
+		expenseRepository = expenses.NewEncryptedRepository(expenseRepository, keyring)
+	}
+	service := expenses.NewService(expenseRepository, expenses.WithCurrency(cfg.FXBaseCurrency), expenses.WithHouseholds(householdService))
+
+	if *owner != "" {
+		// stored lowercased, see users.Service
+		user, err := userRepository.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(*owner)))
+		if err != nil {
+			if errors.Is(err, users.ErrUserNotFound) {
+				return fmt.Errorf("no user with the email %s, register them first", *owner)
+			}
+			return err
+		}
+		ctx = auth.WithUserID(ctx, user.ID)
+	}
+
+	generated := seed.Generate(rand.New(rand.NewPCG(*seedNumber, *seedNumber)), time.Now(), *months)
+	seeded, err := seed.Seed(ctx, service, generated)
+	if err != nil {
+		return fmt.Errorf("seeded %d of %d expenses: %w", seeded, len(generated), err)
+	}
+
+	log.Printf("Seeded %d demo expenses over %d months with seed %d", seeded, *months, *seedNumber)
+	return nil
+}
+
+// devSeedMonths is how far back the demo expenses of dev mode reach
+const devSeedMonths = 6
+
+// seedDev gives dev mode demo expenses to work with. They are unowned, and a database that
+// already has expenses is left alone, in case DB_PATH points dev mode at a file
+func seedDev(ctx context.Context, creator seed.Creator, stats *sqlite.StatsRepository) error {
+	counts, err := stats.RecordCounts(ctx)
+	if err != nil {
+		return err
+	}
+	if counts["expenses"] > 0 {
+		return nil
+	}
+
+	generated := seed.Generate(rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())), time.Now(), devSeedMonths)
+	seeded, err := seed.Seed(ctx, creator, generated)
+	if err != nil {
+		return fmt.Errorf("seeded %d of %d expenses: %w", seeded, len(generated), err)
+	}
+
+	log.Printf("Dev mode: seeded %d demo expenses over %d months", seeded, devSeedMonths)
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/config"
+	"github.com/nicholasss/expense-tracker-api/internal/selfcheck"
+	"github.com/nicholasss/expense-tracker-api/internal/sqlite"
+	migrations "github.com/nicholasss/expense-tracker-api/sql"
+)
+
+// minClock is the earliest believable time for builds without vcs info
+var minClock = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// startupChecks are run before the listener is bound, so a broken environment
+// fails with a diagnostic instead of on the first request
+func startupChecks(cfg *config.Config, db *sql.DB) ([]selfcheck.Check, error) {
+	wantVersion, err := migrations.LatestVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	checks := []selfcheck.Check{
+		{
+			Name: "clock",
+			Hint: "sync the system clock, i.e. with NTP",
+			Run:  selfcheck.Clock(time.Now, selfcheck.BuildTime(minClock)),
+		},
+		{
+			Name: "database connection",
+			Hint: "check DB_PATH and GOOSE_DRIVER",
+			Run:  selfcheck.Database(db),
+		},
+		{
+			Name: "database schema",
+			Hint: "run goose up with the migrations in sql/schema",
+			Run: selfcheck.SchemaVersion(func(ctx context.Context) (int64, error) {
+				return sqlite.SchemaVersion(ctx, db)
+			}, wantVersion),
+		},
+	}
+
+	// sqlite writes its journal next to the database file
+	if cfg.DBString != ":memory:" && !strings.HasPrefix(cfg.DBString, "file:") {
+		checks = append(checks, selfcheck.Check{
+			Name: "database directory writable",
+			Hint: "check the permissions of the directory holding DB_PATH",
+			Run:  selfcheck.Writable(filepath.Dir(cfg.DBString)),
+		})
+	}
+
+	if len(cfg.TLSAutocertHosts) > 0 {
+		checks = append(checks, selfcheck.Check{
+			Name: "autocert cache writable",
+			Hint: "check the permissions of TLS_AUTOCERT_CACHE_DIR",
+			Run:  selfcheck.Writable(cfg.TLSAutocertCacheDir),
+		})
+	}
+
+	return checks, nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/nicholasss/expense-tracker-api/config"
+)
+
+// redirectToHTTPS sends plain http requests to the same host and path over https
+func redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+}
+
+// shutdownTimeout is how long in-flight requests get to finish once a shutdown signal arrives.
+// Dev mode waits less, so a file watcher restarting the server on every change isn't held up
+const (
+	shutdownTimeout    = 30 * time.Second
+	devShutdownTimeout = time.Second
+)
+
+// serveRedirect serves on ln in the background, for acme challenges and redirects.
+// The returned server is shut down alongside the main one
+func serveRedirect(ln net.Listener, handler http.Handler) *http.Server {
+	redirect := &http.Server{Addr: ln.Addr().String(), Handler: handler, ReadHeaderTimeout: readHeaderTimeout}
+
+	go func() {
+		log.Printf("Redirecting http at %s to https...\n", redirect.Addr)
+		if err := redirect.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("http redirect listener stopped: %v", err)
+		}
+	}()
+
+	return redirect
+}
+
+// serverListeners opens the main listener, and the http redirect listener when cfg needs one.
+// Sockets passed in by systemd are used when there are any, the first for the server and the second
+// for redirects, otherwise the configured addresses are bound.
+func serverListeners(cfg *config.Config) (net.Listener, net.Listener, error) {
+	activated, err := activatedListeners()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(activated) > 0 {
+		log.Printf("Using %d socket activated listener(s)\n", len(activated))
+		if len(activated) > 1 {
+			return activated[0], activated[1], nil
+		}
+		return activated[0], nil, nil
+	}
+
+	ln, err := listen(cfg.Address, cfg.ListenReusePort)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if cfg.TLSHTTPRedirectAddr == "" || (cfg.TLSCertFile == "" && len(cfg.TLSAutocertHosts) == 0) {
+		return ln, nil, nil
+	}
+	redirectLn, err := listen(cfg.TLSHTTPRedirectAddr, cfg.ListenReusePort)
+	if err != nil {
+		ln.Close()
+		return nil, nil, err
+	}
+	return ln, redirectLn, nil
+}
+
+// serve runs srv until ctx is cancelled, then stops accepting connections and
+// waits up to shutdownTimeout, or devShutdownTimeout, for in-flight requests to finish
+func serve(ctx context.Context, cfg *config.Config, srv *http.Server) error {
+	ln, redirectLn, err := serverListeners(cfg)
+	if err != nil {
+		return err
+	}
+	listen, redirect := setupListener(cfg, srv, ln, redirectLn)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- listen()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	timeout := shutdownTimeout
+	if cfg.DevMode {
+		timeout = devShutdownTimeout
+	}
+	log.Printf("Shutting down, waiting up to %s for in-flight requests...\n", timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if redirect != nil {
+		if err := redirect.Shutdown(shutdownCtx); err != nil {
+			log.Printf("http redirect listener did not shut down cleanly: %v", err)
+		}
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("server did not shut down cleanly: %w", err)
+	}
+
+	// Serve returns ErrServerClosed as soon as Shutdown is called
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// setupListener prepares srv with tls from files, from let's encrypt, or as plain http, depending on cfg.
+// It returns the blocking serve call on ln, and the http redirect server when redirectLn is set
+func setupListener(cfg *config.Config, srv *http.Server, ln, redirectLn net.Listener) (func() error, *http.Server) {
+	var redirect *http.Server
+
+	switch {
+	case cfg.TLSCertFile != "":
+		srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		if redirectLn != nil {
+			redirect = serveRedirect(redirectLn, http.HandlerFunc(redirectToHTTPS))
+		}
+
+		return func() error {
+			log.Printf("Starting server with TLS at %s...\n", ln.Addr())
+			return srv.ServeTLS(ln, cfg.TLSCertFile, cfg.TLSKeyFile)
+		}, redirect
+
+	case len(cfg.TLSAutocertHosts) > 0:
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.TLSAutocertHosts...),
+			Cache:      autocert.DirCache(cfg.TLSAutocertCacheDir),
+			Email:      cfg.TLSAutocertEmail,
+		}
+
+		// tls-alpn-01 challenges are answered on the tls listener itself,
+		// the http listener adds http-01 challenges
+		srv.TLSConfig = manager.TLSConfig()
+		srv.TLSConfig.MinVersion = tls.VersionTLS12
+		if redirectLn != nil {
+			redirect = serveRedirect(redirectLn, manager.HTTPHandler(http.HandlerFunc(redirectToHTTPS)))
+		}
+
+		return func() error {
+			log.Printf("Starting server with Let's Encrypt TLS for %v at %s...\n", cfg.TLSAutocertHosts, ln.Addr())
+			return srv.ServeTLS(ln, "", "")
+		}, redirect
+
+	default:
+		// a redirect listener passed in by systemd has nothing to redirect to
+		if redirectLn != nil {
+			redirectLn.Close()
+		}
+
+		return func() error {
+			log.Printf("Starting server at %s...\n", ln.Addr())
+			return srv.Serve(ln)
+		}, nil
+	}
+}
@@ -2,15 +2,54 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
 	"os"
-
-	"github.com/joho/godotenv"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
-type MissingVariableError struct{}
+// MissingVariableError is returned when required variables are unset, naming every one of them
+type MissingVariableError struct {
+	Keys []string
+}
 
 func (e *MissingVariableError) Error() string {
-	return "missing required environmental variable(s)"
+	if len(e.Keys) == 0 {
+		return "missing required environmental variable(s)"
+	}
+	return "missing required environmental variable(s): " + strings.Join(e.Keys, ", ")
+}
+
+// Is implementing for errors.Is(), any MissingVariableError matches regardless of keys
+func (e *MissingVariableError) Is(target error) bool {
+	_, ok := target.(*MissingVariableError)
+	return ok
+}
+
+// InvalidVariableError is returned when a variable is set to a value that can't be used
+type InvalidVariableError struct {
+	Key    string
+	Value  string
+	Reason string
+}
+
+func (e *InvalidVariableError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("invalid %s: %s", e.Key, e.Reason)
+	}
+	return fmt.Sprintf("invalid %s %q: %s", e.Key, e.Value, e.Reason)
+}
+
+// Is implementing for errors.Is(), any InvalidVariableError matches regardless of key
+func (e *InvalidVariableError) Is(target error) bool {
+	_, ok := target.(*InvalidVariableError)
+	return ok
 }
 
 type Config struct {
@@ -19,6 +58,22 @@ type Config struct {
 	LocalPort    string
 	// Hosting address, i.e. 10.0.0.1:8080
 	Address string
+	// ListenReusePort binds with SO_REUSEPORT, so a new process can listen while the old one drains
+	ListenReusePort bool
+
+	// Request timeout config, the request context is cancelled and a 504 returned once it runs out.
+	// RouteTimeouts are keyed by method and route, i.e. "GET /expenses/:id", and 0 turns the timeout off
+	RequestTimeout time.Duration
+	RouteTimeouts  map[string]time.Duration
+
+	// StrictJSON refuses request bodies with fields the endpoint doesn't take, so typos like
+	// "occurred_at" fail with a 400 rather than the field being left out
+	StrictJSON bool
+
+	// Response cache config, hot reads are served from memory for ResponseCacheTTL, 0 turns the cache off.
+	// Every change to expenses drops the whole cache
+	ResponseCacheTTL        time.Duration
+	ResponseCacheMaxEntries int
 
 	// Database config
 	// sqlite
@@ -26,23 +81,678 @@ type Config struct {
 	DBDriver string
 	// mongodb
 	MongoDBURI string
+
+	// Bank sync config, disabled when BankProvider is empty
+	BankProvider     string
+	BankSecretID     string
+	BankSecretKey    string
+	BankAccountID    string
+	BankSyncInterval time.Duration
+
+	// Exchange rate config, disabled when FXProvider is empty. Rates come from the ECB, a JSON API at FXRatesURL,
+	// or the static FXRatesFile, which also stands in while the other providers are failing.
+	// Expenses are recorded in FXBaseCurrency, and rates refreshed longer than FXMaxAge ago are marked stale
+	FXProvider        string
+	FXRatesURL        string
+	FXRatesFile       string
+	FXBaseCurrency    string
+	FXRefreshInterval time.Duration
+	FXMaxAge          time.Duration
+
+	// Slack config. The /expense slash command is served at /slack/commands when SlackSigningSecret is set,
+	// SlackUsers maps Slack user ids to ours. Expenses of at least SlackNotifyMinAmount cents are posted
+	// to SlackWebhookURL when it is set, with auth enabled only those of the users in SlackUsers
+	SlackSigningSecret   string
+	SlackUsers           map[string]int
+	SlackWebhookURL      string
+	SlackNotifyMinAmount int
+
+	// SMTP config, account emails such as email change confirmations are sent through SMTPHost when it is set.
+	// Without it users can't change their email address
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// Webhook config. Every expense change is posted to WebhookURL when it is set, from an outbox written
+	// with the change and signed with WebhookSecret. Failed posts are tried again with backoff, up to WebhookMaxAttempts times
+	WebhookURL          string
+	WebhookSecret       string
+	WebhookMaxAttempts  int
+	WebhookPollInterval time.Duration
+
+	// Rate limit config, disabled when RateLimitRequests is 0
+	RateLimitRequests int
+	RateLimitWindow   time.Duration
+
+	// TrustedProxies are the IPs and CIDRs allowed to set X-Forwarded-For, none by default,
+	// so clients cannot pick their own address for the rate limits
+	TrustedProxies []string
+
+	// Async job config
+	JobWorkers   int
+	JobRetention time.Duration
+
+	// RetentionRules are the longest each kind of record is kept, i.e. "audit_events", see retention.TargetAuditEvents.
+	// They are enforced every RetentionInterval, kinds without a rule are kept forever
+	RetentionRules    map[string]time.Duration
+	RetentionInterval time.Duration
+
+	// Result guardrails. Lists are paged up to MaxPageSize, and unpaged reads such as
+	// GET /admin/expenses refuse more than MaxResultRows expenses, 0 for no cap
+	MaxPageSize   int
+	MaxResultRows int
+
+	// Report config, summaries split their range into this many queries run side by side. 1 runs them as one
+	ReportWorkers int
+
+	// Future dated expense config, "allow", "flag", or "reject" expenses dated more than FutureExpenseSkew from now
+	FutureExpenses    string
+	FutureExpenseSkew time.Duration
+
+	// Duplicate guard config, an identical create within DuplicateWindow is refused, 0 to accept them all
+	DuplicateWindow time.Duration
+
+	// Auth config, JWTSecret is required once AuthEnabled is set
+	AuthEnabled bool
+	JWTSecret   string
+	JWTTTL      time.Duration
+
+	// OIDC login config, disabled when OIDCIssuerURL is empty
+	OIDCIssuerURL    string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+
+	// CORS config, disabled when CORSAllowedOrigins is empty
+	CORSAllowedOrigins []string
+	CORSAllowedMethods []string
+	CORSAllowedHeaders []string
+	CORSMaxAge         time.Duration
+
+	// TLS config, either a cert and key pair or autocert hosts. Plain http when neither is set
+	TLSCertFile         string
+	TLSKeyFile          string
+	TLSAutocertHosts    []string
+	TLSAutocertEmail    string
+	TLSAutocertCacheDir string
+	TLSHTTPRedirectAddr string
+
+	// At-rest encryption config, disabled when FieldEncryptionKeys is empty.
+	// Comma separated id:base64 keys, the first encrypts and the rest only decrypt
+	FieldEncryptionKeys   string
+	FieldEncryptionRotate bool
+
+	// Search index config, GET /expenses/search is only served when SearchIndexPath is set.
+	// ":memory:" keeps the index in memory, indexing every expense again on each start
+	SearchIndexPath string
+
+	// Access log config. Format is "text" or "json", fields add "user_agent", "bytes", and "user_id",
+	// and requests to the skipped paths are not logged at all, i.e. health checks
+	AccessLogFormat    string
+	AccessLogFields    []string
+	AccessLogSkipPaths []string
+
+	// Profiling config, /debug/pprof and /debug/vars are only served when PprofToken is set
+	PprofToken string
+
+	// Web UI config, the embedded single page app is served at /ui unless UIEnabled is turned off
+	UIEnabled bool
+
+	// Localization config, responses are translated to the Accept-Language locale
+	// and DefaultLocale is used when it asks for none of the supported ones
+	DefaultLocale string
+
+	// Logging config, for the log/slog messages
+	LogLevel slog.Level
+
+	// Debug body logging, requests and responses are logged at debug level when DebugLogBodies is set.
+	// The redacted fields are added to the passwords, tokens, and secrets that are always redacted
+	DebugLogBodies       bool
+	DebugLogRedactFields []string
+
+	// Error reporting config, disabled when ErrorReportingDSN is empty.
+	// The DSN is a Sentry style https://key@host/project_id, any Sentry compatible tracker works
+	ErrorReportingDSN         string
+	ErrorReportingEnvironment string
+
+	// Reload config, the config file is checked for changes every interval. Disabled when 0
+	ConfigReloadInterval time.Duration
+
+	// DevMode runs against a seeded in-memory database with verbose logging, see devDefaults
+	DevMode bool
+}
+
+// dbBackends are the supported GOOSE_DRIVER values, and the variables each of them needs
+var dbBackends = map[string][]string{
+	"sqlite3": {"DB_PATH"},
+}
+
+// Defaults for optional variables
+const (
+	defaultRequestTimeout    = 30 * time.Second
+	defaultResponseCacheSize = 10000
+	defaultBankSyncInterval  = 6 * time.Hour
+	defaultFXBaseCurrency    = "USD" // money.DefaultCurrency, the currency amounts were stored in before they had one
+	defaultFXRefreshInterval = 12 * time.Hour
+	defaultFXMaxAge          = 48 * time.Hour
+	defaultSlackNotifyAmount = 10000
+	defaultSMTPPort          = 587
+	defaultWebhookAttempts   = 10
+	defaultWebhookPoll       = 5 * time.Second
+	defaultRateLimitWindow   = time.Minute
+	defaultJobWorkers        = 2
+	defaultJobRetention      = time.Hour
+	defaultRetentionInterval = 24 * time.Hour
+	defaultReportWorkers     = 4
+	defaultFutureExpenses    = "flag"
+	defaultFutureExpenseSkew = 10 * time.Minute
+	defaultMaxPageSize       = 500
+	defaultMaxResultRows     = 10000
+	defaultJWTTTL            = 24 * time.Hour
+	defaultCORSMaxAge        = 10 * time.Minute
+	defaultAutocertCacheDir  = "./autocert-cache"
+	defaultAccessLogFormat   = "text"
+	defaultErrorReportingEnv = "production"
+	defaultLocale            = "en"
+	minPprofTokenLength      = 32
+)
+
+// Defaults for optional list variables
+var (
+	defaultCORSAllowedMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	defaultCORSAllowedHeaders = []string{"Authorization", "Content-Type"}
+)
+
+// futureExpensePolicies are the accepted values for FUTURE_EXPENSES, see expenses.FuturePolicies
+var futureExpensePolicies = []string{"allow", "flag", "reject"}
+
+// MemorySearchIndex is the SEARCH_INDEX_PATH of an index kept in memory
+const MemorySearchIndex = ":memory:"
+
+// supportedLocales are the accepted values for DEFAULT_LOCALE, the locales internal/i18n has catalogs for
+var supportedLocales = []string{"en", "de", "fr", "es"}
+
+// retentionTargets are the kinds of records RETENTION_RULES can be set for, see retention.TargetAuditEvents
+var retentionTargets = []string{"audit_events", "outbox", "expense_tombstones"}
+
+// Accepted values for the access log variables
+var (
+	accessLogFormats = []string{"text", "json"}
+	accessLogFields  = []string{"user_agent", "bytes", "user_id"}
+)
+
+// envVars reads variables while collecting every problem with them,
+// so a bad config is reported all at once instead of one variable per restart
+type envVars struct {
+	missing []string
+	invalid []error
+}
+
+// require reads a variable that has to be set
+func (v *envVars) require(key string) string {
+	v.requireAll(key)
+	return os.Getenv(key)
+}
+
+// requireAll marks each of keys that is unset as missing
+func (v *envVars) requireAll(keys ...string) {
+	for _, key := range keys {
+		if os.Getenv(key) == "" && !slices.Contains(v.missing, key) {
+			v.missing = append(v.missing, key)
+		}
+	}
+}
+
+// reject records key as set to a value that can't be used
+func (v *envVars) reject(key, value, reason string) {
+	v.invalid = append(v.invalid, &InvalidVariableError{Key: key, Value: value, Reason: reason})
 }
 
-// LoadConfig will load given file path and setup the config
-func LoadConfig(filePath string) (*Config, error) {
-	err := godotenv.Load(filePath)
+// duration reads an optional duration variable, i.e. "30m", using def when unset
+func (v *envVars) duration(key string, def time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+
+	val, err := time.ParseDuration(raw)
+	if err != nil {
+		v.reject(key, raw, "must be a duration, i.e. 30m")
+		return def
+	}
+	return val
+}
+
+// integer reads an optional non-negative integer variable, using def when unset
+func (v *envVars) integer(key string, def int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+
+	val, err := strconv.Atoi(raw)
+	if err != nil || val < 0 {
+		v.reject(key, raw, "must be a non-negative integer")
+		return def
+	}
+	return val
+}
+
+// boolean reads an optional boolean variable, i.e. "true" or "0", using def when unset
+func (v *envVars) boolean(key string, def bool) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+
+	val, err := strconv.ParseBool(raw)
+	if err != nil {
+		v.reject(key, raw, "must be true or false")
+		return def
+	}
+	return val
+}
+
+// oneOf reads an optional variable limited to allowed, using def when unset
+func (v *envVars) oneOf(key, def string, allowed []string) string {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+
+	if !slices.Contains(allowed, raw) {
+		v.reject(key, raw, "must be one of "+strings.Join(allowed, ", "))
+		return def
+	}
+	return raw
+}
+
+// logLevel reads an optional log level variable, i.e. "debug" or "warn", using def when unset
+func (v *envVars) logLevel(key string, def slog.Level) slog.Level {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(raw)); err != nil {
+		v.reject(key, raw, "must be one of debug, info, warn, error")
+		return def
+	}
+	return level
+}
+
+// routeDurations reads an optional comma separated list of route=duration pairs,
+// i.e. "GET /expenses=5s, POST /exports=1m", keyed by the method and route
+func (v *envVars) routeDurations(key string) map[string]time.Duration {
+	durations := make(map[string]time.Duration)
+	for item := range strings.SplitSeq(os.Getenv(key), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		route, raw, ok := strings.Cut(item, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !hasPath || !strings.HasPrefix(strings.TrimSpace(path), "/") {
+			v.reject(key, item, "must be METHOD /route=duration, i.e. GET /expenses=5s")
+			continue
+		}
+
+		val, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil || val < 0 {
+			v.reject(key, item, "must be METHOD /route=duration, i.e. GET /expenses=5s")
+			continue
+		}
+		durations[strings.ToUpper(method)+" "+strings.TrimSpace(path)] = val
+	}
+	return durations
+}
+
+// targetDurations reads an optional comma separated list of target=duration pairs, i.e. "outbox=720h",
+// keyed by the target. Targets are limited to allowed, and durations need to be more than 0
+func (v *envVars) targetDurations(key string, allowed []string) map[string]time.Duration {
+	reason := "must be target=duration, i.e. " + allowed[0] + "=720h, with a target of " + strings.Join(allowed, ", ")
+
+	durations := make(map[string]time.Duration)
+	for item := range strings.SplitSeq(os.Getenv(key), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		target, raw, ok := strings.Cut(item, "=")
+		target = strings.TrimSpace(target)
+		val, err := time.ParseDuration(strings.TrimSpace(raw))
+		if !ok || !slices.Contains(allowed, target) || err != nil || val <= 0 {
+			v.reject(key, item, reason)
+			continue
+		}
+		durations[target] = val
+	}
+	return durations
+}
+
+// userIDs reads an optional comma separated list of external=user id pairs, i.e. "U012AB3CD=1, U045EF6GH=2",
+// keyed by the external id
+func (v *envVars) userIDs(key string) map[string]int {
+	ids := make(map[string]int)
+	for item := range strings.SplitSeq(os.Getenv(key), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		external, raw, ok := strings.Cut(item, "=")
+		id, err := strconv.Atoi(strings.TrimSpace(raw))
+		if !ok || strings.TrimSpace(external) == "" || err != nil || id < 1 {
+			v.reject(key, item, "must be external_id=user_id, i.e. U012AB3CD=1")
+			continue
+		}
+		ids[strings.TrimSpace(external)] = id
+	}
+	return ids
+}
+
+// err joins everything that was missing or invalid, or is nil for a usable config
+func (v *envVars) err() error {
+	var errs []error
+	if len(v.missing) > 0 {
+		errs = append(errs, &MissingVariableError{Keys: v.missing})
+	}
+	return errors.Join(append(errs, v.invalid...)...)
+}
+
+// envList reads an optional comma separated variable, i.e. "GET, POST", using def when unset
+func envList(key string, def []string) []string {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+
+	list := make([]string, 0)
+	for item := range strings.SplitSeq(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// isCurrencyCode checks for a three letter ISO 4217 style code, i.e. EUR
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadConfig will load given file path and setup the config.
+// Secrets missing from the file are looked up in the sources configured there, then in sources.
+func LoadConfig(filePath string, sources ...SecretSource) (*Config, error) {
+	err := loadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
 
-	localAddress := os.Getenv("LOCAL_ADDRESS")
-	localPort := os.Getenv("LOCAL_PORT")
+	return loadFromEnv(sources...)
+}
+
+// loadFromEnv sets up the config from environmental variables, once any file has been loaded.
+// Every missing or invalid variable is named in the returned error.
+func loadFromEnv(sources ...SecretSource) (*Config, error) {
+	err := resolveSecrets(context.Background(), append(secretSourcesFromEnv(), sources...))
+	if err != nil {
+		return nil, err
+	}
+
+	v := &envVars{}
+
+	localAddress := v.require("LOCAL_ADDRESS")
+	localPort := v.require("LOCAL_PORT")
+	listenReusePort := v.boolean("LISTEN_REUSE_PORT", false)
+
+	// request timeouts, a route can have its own or none at all
+	requestTimeout := v.duration("REQUEST_TIMEOUT", defaultRequestTimeout)
+	routeTimeouts := v.routeDurations("ROUTE_TIMEOUTS")
+
+	// request bodies
+	strictJSON := v.boolean("STRICT_JSON", false)
+
+	// optional response cache
+	responseCacheTTL := v.duration("RESPONSE_CACHE_TTL", 0)
+	responseCacheMaxEntries := v.integer("RESPONSE_CACHE_MAX_ENTRIES", defaultResponseCacheSize)
+	dbDriver := v.require("GOOSE_DRIVER")
 	dbPath := os.Getenv("DB_PATH") // aka, database string
-	dbDriver := os.Getenv("GOOSE_DRIVER")
 	mongoDBURI := os.Getenv("MONGODB_URI")
 
-	if localAddress == "" || localPort == "" || dbPath == "" || dbDriver == "" || mongoDBURI == "" {
-		return nil, &MissingVariableError{}
+	// only the variables of the chosen database backend are required
+	if backendKeys, ok := dbBackends[dbDriver]; ok {
+		v.requireAll(backendKeys...)
+	} else if dbDriver != "" {
+		v.reject("GOOSE_DRIVER", dbDriver, "unsupported database driver")
+	}
+
+	// optional bank sync, requires credentials once a provider is chosen
+	bankProvider := os.Getenv("BANK_PROVIDER")
+	bankSecretID := os.Getenv("BANK_SECRET_ID")
+	bankSecretKey := os.Getenv("BANK_SECRET_KEY")
+	bankAccountID := os.Getenv("BANK_ACCOUNT_ID")
+
+	if bankProvider != "" {
+		if bankProvider != "gocardless" {
+			v.reject("BANK_PROVIDER", bankProvider, "unsupported bank provider")
+		}
+		v.requireAll("BANK_SECRET_ID", "BANK_SECRET_KEY", "BANK_ACCOUNT_ID")
+	}
+
+	bankSyncInterval := v.duration("BANK_SYNC_INTERVAL", defaultBankSyncInterval)
+
+	// optional exchange rates, a rates url without a provider is the json provider from before there was a choice
+	fxRatesURL := os.Getenv("FX_RATES_URL")
+	fxRatesFile := os.Getenv("FX_RATES_FILE")
+	fxProvider := v.oneOf("FX_PROVIDER", "", []string{"ecb", "json", "static"})
+	if fxProvider == "" && fxRatesURL != "" {
+		fxProvider = "json"
+	}
+	switch fxProvider {
+	case "json":
+		v.requireAll("FX_RATES_URL")
+	case "static":
+		v.requireAll("FX_RATES_FILE")
+	}
+	fxBaseCurrency := os.Getenv("FX_BASE_CURRENCY")
+	if fxBaseCurrency == "" {
+		fxBaseCurrency = defaultFXBaseCurrency
+	}
+	if !isCurrencyCode(fxBaseCurrency) {
+		v.reject("FX_BASE_CURRENCY", fxBaseCurrency, "must be a three letter currency code, i.e. EUR")
+	}
+	fxRefreshInterval := v.duration("FX_REFRESH_INTERVAL", defaultFXRefreshInterval)
+	fxMaxAge := v.duration("FX_MAX_AGE", defaultFXMaxAge)
+
+	// optional slack, the slash command and notifications are set up separately
+	slackSigningSecret := os.Getenv("SLACK_SIGNING_SECRET")
+	slackUsers := v.userIDs("SLACK_USERS")
+	slackWebhookURL := os.Getenv("SLACK_WEBHOOK_URL")
+	slackNotifyMinAmount := v.integer("SLACK_NOTIFY_MIN_AMOUNT", defaultSlackNotifyAmount)
+
+	// optional smtp for account emails
+	smtpHost := os.Getenv("SMTP_HOST")
+	smtpPort := v.integer("SMTP_PORT", defaultSMTPPort)
+	smtpUsername := os.Getenv("SMTP_USERNAME")
+	smtpPassword := os.Getenv("SMTP_PASSWORD")
+	smtpFrom := os.Getenv("SMTP_FROM")
+
+	if smtpHost != "" {
+		v.requireAll("SMTP_FROM")
+	}
+	if smtpUsername != "" {
+		v.requireAll("SMTP_PASSWORD")
+	}
+
+	// optional webhook
+	webhookURL := os.Getenv("WEBHOOK_URL")
+	webhookSecret := os.Getenv("WEBHOOK_SECRET")
+	if webhookURL != "" {
+		v.requireAll("WEBHOOK_SECRET")
+	}
+	webhookMaxAttempts := v.integer("WEBHOOK_MAX_ATTEMPTS", defaultWebhookAttempts)
+	if webhookMaxAttempts == 0 {
+		v.reject("WEBHOOK_MAX_ATTEMPTS", "0", "must be at least 1")
+	}
+	webhookPollInterval := v.duration("WEBHOOK_POLL_INTERVAL", defaultWebhookPoll)
+	if webhookPollInterval <= 0 {
+		v.reject("WEBHOOK_POLL_INTERVAL", webhookPollInterval.String(), "must be more than 0")
+	}
+
+	// optional rate limiting
+	rateLimitRequests := v.integer("RATE_LIMIT_REQUESTS", 0)
+	rateLimitWindow := v.duration("RATE_LIMIT_WINDOW", defaultRateLimitWindow)
+	trustedProxies := envList("TRUSTED_PROXIES", nil)
+	for _, proxy := range trustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				v.reject("TRUSTED_PROXIES", proxy, "must be an IP or CIDR")
+			}
+		}
+	}
+
+	// async jobs
+	jobWorkers := v.integer("JOB_WORKERS", defaultJobWorkers)
+	jobRetention := v.duration("JOB_RETENTION", defaultJobRetention)
+
+	// data retention
+	retentionRules := v.targetDurations("RETENTION_RULES", retentionTargets)
+	retentionInterval := v.duration("RETENTION_INTERVAL", defaultRetentionInterval)
+	if retentionInterval <= 0 {
+		v.reject("RETENTION_INTERVAL", retentionInterval.String(), "must be more than 0")
+	}
+
+	// result guardrails
+	maxPageSize := v.integer("MAX_PAGE_SIZE", defaultMaxPageSize)
+	if maxPageSize == 0 {
+		v.reject("MAX_PAGE_SIZE", "0", "must be at least 1")
+	}
+	maxResultRows := v.integer("MAX_RESULT_ROWS", defaultMaxResultRows)
+
+	// reports
+	reportWorkers := v.integer("REPORT_WORKERS", defaultReportWorkers)
+
+	// future dated expenses
+	futureExpenses := v.oneOf("FUTURE_EXPENSES", defaultFutureExpenses, futureExpensePolicies)
+	futureExpenseSkew := v.duration("FUTURE_EXPENSE_SKEW", defaultFutureExpenseSkew)
+
+	// duplicate creates
+	duplicateWindow := v.duration("DUPLICATE_WINDOW", 0)
+
+	// auth
+	authEnabled := v.boolean("AUTH_ENABLED", false)
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if authEnabled {
+		v.requireAll("JWT_SECRET")
+	}
+	jwtTTL := v.duration("JWT_TTL", defaultJWTTTL)
+
+	// optional oidc login, our own tokens are still issued after the callback
+	oidcIssuerURL := os.Getenv("OIDC_ISSUER_URL")
+	oidcClientID := os.Getenv("OIDC_CLIENT_ID")
+	oidcClientSecret := os.Getenv("OIDC_CLIENT_SECRET")
+	oidcRedirectURL := os.Getenv("OIDC_REDIRECT_URL")
+
+	if oidcIssuerURL != "" {
+		v.requireAll("OIDC_CLIENT_ID", "OIDC_CLIENT_SECRET", "OIDC_REDIRECT_URL", "JWT_SECRET")
+	}
+
+	// optional cors for browser clients
+	corsAllowedOrigins := envList("CORS_ALLOWED_ORIGINS", nil)
+	corsAllowedMethods := envList("CORS_ALLOWED_METHODS", defaultCORSAllowedMethods)
+	corsAllowedHeaders := envList("CORS_ALLOWED_HEADERS", defaultCORSAllowedHeaders)
+	corsMaxAge := v.duration("CORS_MAX_AGE", defaultCORSMaxAge)
+
+	// optional native tls, from files or from let's encrypt
+	tlsCertFile := os.Getenv("TLS_CERT_FILE")
+	tlsKeyFile := os.Getenv("TLS_KEY_FILE")
+	tlsAutocertHosts := envList("TLS_AUTOCERT_HOSTS", nil)
+	tlsAutocertEmail := os.Getenv("TLS_AUTOCERT_EMAIL")
+	tlsAutocertCacheDir := os.Getenv("TLS_AUTOCERT_CACHE_DIR")
+	if tlsAutocertCacheDir == "" {
+		tlsAutocertCacheDir = defaultAutocertCacheDir
+	}
+	tlsHTTPRedirectAddr := os.Getenv("TLS_HTTP_REDIRECT_ADDRESS")
+
+	if tlsCertFile != "" || tlsKeyFile != "" {
+		v.requireAll("TLS_CERT_FILE", "TLS_KEY_FILE")
+	}
+	if tlsCertFile != "" && len(tlsAutocertHosts) > 0 {
+		v.reject("TLS_AUTOCERT_HOSTS", os.Getenv("TLS_AUTOCERT_HOSTS"), "cannot be set together with TLS_CERT_FILE")
+	}
+
+	// optional at-rest encryption, rotating needs keys to rotate to
+	fieldEncryptionKeys := os.Getenv("FIELD_ENCRYPTION_KEYS")
+	fieldEncryptionRotate := v.boolean("FIELD_ENCRYPTION_ROTATE", false)
+	if fieldEncryptionRotate {
+		v.requireAll("FIELD_ENCRYPTION_KEYS")
+	}
+
+	// optional search index, on disk it would keep the descriptions encryption keeps out of the database
+	searchIndexPath := os.Getenv("SEARCH_INDEX_PATH")
+	if searchIndexPath != "" && searchIndexPath != MemorySearchIndex && fieldEncryptionKeys != "" {
+		v.reject("SEARCH_INDEX_PATH", searchIndexPath, "must be "+MemorySearchIndex+" when FIELD_ENCRYPTION_KEYS is set")
+	}
+
+	// access log, the optional fields are off unless asked for
+	accessLogFormat := v.oneOf("ACCESS_LOG_FORMAT", defaultAccessLogFormat, accessLogFormats)
+	accessLogFieldList := envList("ACCESS_LOG_FIELDS", nil)
+	for _, field := range accessLogFieldList {
+		if !slices.Contains(accessLogFields, field) {
+			v.reject("ACCESS_LOG_FIELDS", field, "must be one of "+strings.Join(accessLogFields, ", "))
+		}
+	}
+	accessLogSkipPaths := envList("ACCESS_LOG_SKIP_PATHS", nil)
+
+	// optional profiling, the token is all that guards it so it has to be long.
+	// the value is left out of the error so it never reaches the logs
+	pprofToken := os.Getenv("PPROF_TOKEN")
+	if pprofToken != "" && len(pprofToken) < minPprofTokenLength {
+		v.reject("PPROF_TOKEN", "", fmt.Sprintf("needs to be at least %d characters", minPprofTokenLength))
+	}
+
+	// the web UI only calls the API, so it is safe to leave on
+	uiEnabled := v.boolean("UI_ENABLED", true)
+
+	// localization, clients without a supported Accept-Language get the default locale
+	defaultLocale := v.oneOf("DEFAULT_LOCALE", defaultLocale, supportedLocales)
+
+	// logging
+	logLevel := v.logLevel("LOG_LEVEL", slog.LevelInfo)
+
+	// opt-in body logging, only written once LOG_LEVEL is debug
+	debugLogBodies := v.boolean("DEBUG_LOG_BODIES", false)
+	debugLogRedactFields := envList("DEBUG_LOG_REDACT_FIELDS", nil)
+
+	// optional error reporting to a sentry compatible tracker
+	errorReportingDSN := os.Getenv("ERROR_REPORTING_DSN")
+	errorReportingEnvironment := os.Getenv("ERROR_REPORTING_ENVIRONMENT")
+	if errorReportingEnvironment == "" {
+		errorReportingEnvironment = defaultErrorReportingEnv
+	}
+
+	// config reload, only some settings take effect without a restart
+	configReloadInterval := v.duration("CONFIG_RELOAD_INTERVAL", 0)
+
+	// dev mode, its defaults are filled in before the file is read
+	devMode := v.boolean("DEV_MODE", false)
+
+	if err := v.err(); err != nil {
+		return nil, err
 	}
 
 	conf := Config{
@@ -51,10 +761,145 @@ func LoadConfig(filePath string) (*Config, error) {
 		LocalPort:    localPort,
 		Address:      localAddress + ":" + localPort,
 
+		ListenReusePort: listenReusePort,
+
+		// request timeouts
+		RequestTimeout: requestTimeout,
+		RouteTimeouts:  routeTimeouts,
+
+		// request bodies
+		StrictJSON: strictJSON,
+
+		// response cache
+		ResponseCacheTTL:        responseCacheTTL,
+		ResponseCacheMaxEntries: responseCacheMaxEntries,
+
 		// database
 		DBString:   dbPath,
 		DBDriver:   dbDriver,
 		MongoDBURI: mongoDBURI,
+
+		// bank sync
+		BankProvider:     bankProvider,
+		BankSecretID:     bankSecretID,
+		BankSecretKey:    bankSecretKey,
+		BankAccountID:    bankAccountID,
+		BankSyncInterval: bankSyncInterval,
+
+		// exchange rates
+		FXProvider:        fxProvider,
+		FXRatesURL:        fxRatesURL,
+		FXRatesFile:       fxRatesFile,
+		FXBaseCurrency:    fxBaseCurrency,
+		FXRefreshInterval: fxRefreshInterval,
+		FXMaxAge:          fxMaxAge,
+
+		// slack
+		SlackSigningSecret:   slackSigningSecret,
+		SlackUsers:           slackUsers,
+		SlackWebhookURL:      slackWebhookURL,
+		SlackNotifyMinAmount: slackNotifyMinAmount,
+
+		// smtp
+		SMTPHost:     smtpHost,
+		SMTPPort:     smtpPort,
+		SMTPUsername: smtpUsername,
+		SMTPPassword: smtpPassword,
+		SMTPFrom:     smtpFrom,
+
+		// webhook
+		WebhookURL:          webhookURL,
+		WebhookSecret:       webhookSecret,
+		WebhookMaxAttempts:  webhookMaxAttempts,
+		WebhookPollInterval: webhookPollInterval,
+
+		// rate limit
+		RateLimitRequests: rateLimitRequests,
+		RateLimitWindow:   rateLimitWindow,
+		TrustedProxies:    trustedProxies,
+
+		// async jobs
+		JobWorkers:   jobWorkers,
+		JobRetention: jobRetention,
+
+		// data retention
+		RetentionRules:    retentionRules,
+		RetentionInterval: retentionInterval,
+
+		// result guardrails
+		MaxPageSize:   maxPageSize,
+		MaxResultRows: maxResultRows,
+
+		// reports
+		ReportWorkers: reportWorkers,
+
+		// future dated expenses
+		FutureExpenses:    futureExpenses,
+		FutureExpenseSkew: futureExpenseSkew,
+
+		// duplicate creates
+		DuplicateWindow: duplicateWindow,
+
+		// auth
+		AuthEnabled: authEnabled,
+		JWTSecret:   jwtSecret,
+		JWTTTL:      jwtTTL,
+
+		// oidc
+		OIDCIssuerURL:    oidcIssuerURL,
+		OIDCClientID:     oidcClientID,
+		OIDCClientSecret: oidcClientSecret,
+		OIDCRedirectURL:  oidcRedirectURL,
+
+		// cors
+		CORSAllowedOrigins: corsAllowedOrigins,
+		CORSAllowedMethods: corsAllowedMethods,
+		CORSAllowedHeaders: corsAllowedHeaders,
+		CORSMaxAge:         corsMaxAge,
+
+		// tls
+		TLSCertFile:         tlsCertFile,
+		TLSKeyFile:          tlsKeyFile,
+		TLSAutocertHosts:    tlsAutocertHosts,
+		TLSAutocertEmail:    tlsAutocertEmail,
+		TLSAutocertCacheDir: tlsAutocertCacheDir,
+		TLSHTTPRedirectAddr: tlsHTTPRedirectAddr,
+
+		// at-rest encryption
+		FieldEncryptionKeys:   fieldEncryptionKeys,
+		FieldEncryptionRotate: fieldEncryptionRotate,
+
+		// search index
+		SearchIndexPath: searchIndexPath,
+
+		// access log
+		AccessLogFormat:    accessLogFormat,
+		AccessLogFields:    accessLogFieldList,
+		AccessLogSkipPaths: accessLogSkipPaths,
+
+		// profiling
+		PprofToken: pprofToken,
+
+		// web ui
+		UIEnabled: uiEnabled,
+
+		// localization
+		DefaultLocale: defaultLocale,
+
+		// logging
+		LogLevel:             logLevel,
+		DebugLogBodies:       debugLogBodies,
+		DebugLogRedactFields: debugLogRedactFields,
+
+		// error reporting
+		ErrorReportingDSN:         errorReportingDSN,
+		ErrorReportingEnvironment: errorReportingEnvironment,
+
+		// config reload
+		ConfigReloadInterval: configReloadInterval,
+
+		// dev mode
+		DevMode: devMode,
 	}
 
 	return &conf, nil
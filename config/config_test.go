@@ -2,8 +2,13 @@ package config_test
 
 import (
 	"errors"
+	"log/slog"
+	"maps"
 	"os"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/nicholasss/expense-tracker-api/config"
@@ -23,6 +28,28 @@ func checkConfigEquality(t *testing.T, got, want *config.Config) {
 	if got.Address != want.Address {
 		t.Errorf("conf.Address does not match. got: '%v', want: '%v'", got.Address, want.Address)
 	}
+	if got.ListenReusePort != want.ListenReusePort {
+		t.Errorf("conf.ListenReusePort does not match. got: '%v', want: '%v'", got.ListenReusePort, want.ListenReusePort)
+	}
+
+	// request timeouts, no route timeouts is the same as an empty map
+	if got.RequestTimeout != want.RequestTimeout {
+		t.Errorf("conf.RequestTimeout does not match. got: '%v', want: '%v'", got.RequestTimeout, want.RequestTimeout)
+	}
+	if !maps.Equal(got.RouteTimeouts, want.RouteTimeouts) {
+		t.Errorf("conf.RouteTimeouts does not match. got: '%v', want: '%v'", got.RouteTimeouts, want.RouteTimeouts)
+	}
+	if got.StrictJSON != want.StrictJSON {
+		t.Errorf("conf.StrictJSON does not match. got: '%v', want: '%v'", got.StrictJSON, want.StrictJSON)
+	}
+
+	// response cache
+	if got.ResponseCacheTTL != want.ResponseCacheTTL {
+		t.Errorf("conf.ResponseCacheTTL does not match. got: '%v', want: '%v'", got.ResponseCacheTTL, want.ResponseCacheTTL)
+	}
+	if got.ResponseCacheMaxEntries != want.ResponseCacheMaxEntries {
+		t.Errorf("conf.ResponseCacheMaxEntries does not match. got: '%v', want: '%v'", got.ResponseCacheMaxEntries, want.ResponseCacheMaxEntries)
+	}
 
 	// database
 	if got.DBString != want.DBString {
@@ -31,39 +58,964 @@ func checkConfigEquality(t *testing.T, got, want *config.Config) {
 	if got.DBDriver != want.DBDriver {
 		t.Errorf("conf.DBDriver does not match. got: '%v', want: '%v'", got.DBDriver, want.DBDriver)
 	}
+
+	// bank sync
+	if got.BankProvider != want.BankProvider {
+		t.Errorf("conf.BankProvider does not match. got: '%v', want: '%v'", got.BankProvider, want.BankProvider)
+	}
+	if got.BankSyncInterval != want.BankSyncInterval {
+		t.Errorf("conf.BankSyncInterval does not match. got: '%v', want: '%v'", got.BankSyncInterval, want.BankSyncInterval)
+	}
+
+	// exchange rates
+	if got.FXProvider != want.FXProvider {
+		t.Errorf("conf.FXProvider does not match. got: '%v', want: '%v'", got.FXProvider, want.FXProvider)
+	}
+	if got.FXRatesURL != want.FXRatesURL {
+		t.Errorf("conf.FXRatesURL does not match. got: '%v', want: '%v'", got.FXRatesURL, want.FXRatesURL)
+	}
+	if got.FXRatesFile != want.FXRatesFile {
+		t.Errorf("conf.FXRatesFile does not match. got: '%v', want: '%v'", got.FXRatesFile, want.FXRatesFile)
+	}
+	if got.FXBaseCurrency != want.FXBaseCurrency {
+		t.Errorf("conf.FXBaseCurrency does not match. got: '%v', want: '%v'", got.FXBaseCurrency, want.FXBaseCurrency)
+	}
+	if got.FXRefreshInterval != want.FXRefreshInterval {
+		t.Errorf("conf.FXRefreshInterval does not match. got: '%v', want: '%v'", got.FXRefreshInterval, want.FXRefreshInterval)
+	}
+	if got.FXMaxAge != want.FXMaxAge {
+		t.Errorf("conf.FXMaxAge does not match. got: '%v', want: '%v'", got.FXMaxAge, want.FXMaxAge)
+	}
+
+	// slack
+	if got.SlackSigningSecret != want.SlackSigningSecret {
+		t.Errorf("conf.SlackSigningSecret does not match. got: '%v', want: '%v'", got.SlackSigningSecret, want.SlackSigningSecret)
+	}
+	if !maps.Equal(got.SlackUsers, want.SlackUsers) {
+		t.Errorf("conf.SlackUsers does not match. got: '%v', want: '%v'", got.SlackUsers, want.SlackUsers)
+	}
+	if got.SlackWebhookURL != want.SlackWebhookURL {
+		t.Errorf("conf.SlackWebhookURL does not match. got: '%v', want: '%v'", got.SlackWebhookURL, want.SlackWebhookURL)
+	}
+	if got.SlackNotifyMinAmount != want.SlackNotifyMinAmount {
+		t.Errorf("conf.SlackNotifyMinAmount does not match. got: '%v', want: '%v'", got.SlackNotifyMinAmount, want.SlackNotifyMinAmount)
+	}
+
+	// smtp
+	if got.SMTPHost != want.SMTPHost {
+		t.Errorf("conf.SMTPHost does not match. got: '%v', want: '%v'", got.SMTPHost, want.SMTPHost)
+	}
+	if got.SMTPPort != want.SMTPPort {
+		t.Errorf("conf.SMTPPort does not match. got: '%v', want: '%v'", got.SMTPPort, want.SMTPPort)
+	}
+	if got.SMTPUsername != want.SMTPUsername {
+		t.Errorf("conf.SMTPUsername does not match. got: '%v', want: '%v'", got.SMTPUsername, want.SMTPUsername)
+	}
+	if got.SMTPPassword != want.SMTPPassword {
+		t.Errorf("conf.SMTPPassword does not match. got: '%v', want: '%v'", got.SMTPPassword, want.SMTPPassword)
+	}
+	if got.SMTPFrom != want.SMTPFrom {
+		t.Errorf("conf.SMTPFrom does not match. got: '%v', want: '%v'", got.SMTPFrom, want.SMTPFrom)
+	}
+	if got.WebhookURL != want.WebhookURL {
+		t.Errorf("conf.WebhookURL does not match. got: '%v', want: '%v'", got.WebhookURL, want.WebhookURL)
+	}
+	if got.WebhookSecret != want.WebhookSecret {
+		t.Errorf("conf.WebhookSecret does not match. got: '%v', want: '%v'", got.WebhookSecret, want.WebhookSecret)
+	}
+	if got.WebhookMaxAttempts != want.WebhookMaxAttempts {
+		t.Errorf("conf.WebhookMaxAttempts does not match. got: '%v', want: '%v'", got.WebhookMaxAttempts, want.WebhookMaxAttempts)
+	}
+	if got.WebhookPollInterval != want.WebhookPollInterval {
+		t.Errorf("conf.WebhookPollInterval does not match. got: '%v', want: '%v'", got.WebhookPollInterval, want.WebhookPollInterval)
+	}
+
+	// rate limit
+	if got.RateLimitRequests != want.RateLimitRequests {
+		t.Errorf("conf.RateLimitRequests does not match. got: '%v', want: '%v'", got.RateLimitRequests, want.RateLimitRequests)
+	}
+	if got.RateLimitWindow != want.RateLimitWindow {
+		t.Errorf("conf.RateLimitWindow does not match. got: '%v', want: '%v'", got.RateLimitWindow, want.RateLimitWindow)
+	}
+	if !slices.Equal(got.TrustedProxies, want.TrustedProxies) {
+		t.Errorf("conf.TrustedProxies does not match. got: '%v', want: '%v'", got.TrustedProxies, want.TrustedProxies)
+	}
+
+	// async jobs
+	if got.JobWorkers != want.JobWorkers {
+		t.Errorf("conf.JobWorkers does not match. got: '%v', want: '%v'", got.JobWorkers, want.JobWorkers)
+	}
+	if got.JobRetention != want.JobRetention {
+		t.Errorf("conf.JobRetention does not match. got: '%v', want: '%v'", got.JobRetention, want.JobRetention)
+	}
+	if !maps.Equal(got.RetentionRules, want.RetentionRules) {
+		t.Errorf("conf.RetentionRules does not match. got: '%v', want: '%v'", got.RetentionRules, want.RetentionRules)
+	}
+	if got.RetentionInterval != want.RetentionInterval {
+		t.Errorf("conf.RetentionInterval does not match. got: '%v', want: '%v'", got.RetentionInterval, want.RetentionInterval)
+	}
+
+	// result guardrails
+	if got.MaxPageSize != want.MaxPageSize {
+		t.Errorf("conf.MaxPageSize does not match. got: '%v', want: '%v'", got.MaxPageSize, want.MaxPageSize)
+	}
+	if got.MaxResultRows != want.MaxResultRows {
+		t.Errorf("conf.MaxResultRows does not match. got: '%v', want: '%v'", got.MaxResultRows, want.MaxResultRows)
+	}
+
+	// reports
+	if got.ReportWorkers != want.ReportWorkers {
+		t.Errorf("conf.ReportWorkers does not match. got: '%v', want: '%v'", got.ReportWorkers, want.ReportWorkers)
+	}
+
+	// future dated expenses
+	if got.FutureExpenses != want.FutureExpenses {
+		t.Errorf("conf.FutureExpenses does not match. got: '%v', want: '%v'", got.FutureExpenses, want.FutureExpenses)
+	}
+	if got.FutureExpenseSkew != want.FutureExpenseSkew {
+		t.Errorf("conf.FutureExpenseSkew does not match. got: '%v', want: '%v'", got.FutureExpenseSkew, want.FutureExpenseSkew)
+	}
+	if got.DuplicateWindow != want.DuplicateWindow {
+		t.Errorf("conf.DuplicateWindow does not match. got: '%v', want: '%v'", got.DuplicateWindow, want.DuplicateWindow)
+	}
+
+	// auth
+	if got.AuthEnabled != want.AuthEnabled {
+		t.Errorf("conf.AuthEnabled does not match. got: '%v', want: '%v'", got.AuthEnabled, want.AuthEnabled)
+	}
+	if got.JWTSecret != want.JWTSecret {
+		t.Errorf("conf.JWTSecret does not match. got: '%v', want: '%v'", got.JWTSecret, want.JWTSecret)
+	}
+	if got.JWTTTL != want.JWTTTL {
+		t.Errorf("conf.JWTTTL does not match. got: '%v', want: '%v'", got.JWTTTL, want.JWTTTL)
+	}
+
+	// oidc
+	if got.OIDCIssuerURL != want.OIDCIssuerURL {
+		t.Errorf("conf.OIDCIssuerURL does not match. got: '%v', want: '%v'", got.OIDCIssuerURL, want.OIDCIssuerURL)
+	}
+	if got.OIDCClientID != want.OIDCClientID {
+		t.Errorf("conf.OIDCClientID does not match. got: '%v', want: '%v'", got.OIDCClientID, want.OIDCClientID)
+	}
+	if got.OIDCRedirectURL != want.OIDCRedirectURL {
+		t.Errorf("conf.OIDCRedirectURL does not match. got: '%v', want: '%v'", got.OIDCRedirectURL, want.OIDCRedirectURL)
+	}
+
+	// cors
+	if !slices.Equal(got.CORSAllowedOrigins, want.CORSAllowedOrigins) {
+		t.Errorf("conf.CORSAllowedOrigins does not match. got: '%v', want: '%v'", got.CORSAllowedOrigins, want.CORSAllowedOrigins)
+	}
+	if !slices.Equal(got.CORSAllowedMethods, want.CORSAllowedMethods) {
+		t.Errorf("conf.CORSAllowedMethods does not match. got: '%v', want: '%v'", got.CORSAllowedMethods, want.CORSAllowedMethods)
+	}
+	if !slices.Equal(got.CORSAllowedHeaders, want.CORSAllowedHeaders) {
+		t.Errorf("conf.CORSAllowedHeaders does not match. got: '%v', want: '%v'", got.CORSAllowedHeaders, want.CORSAllowedHeaders)
+	}
+	if got.CORSMaxAge != want.CORSMaxAge {
+		t.Errorf("conf.CORSMaxAge does not match. got: '%v', want: '%v'", got.CORSMaxAge, want.CORSMaxAge)
+	}
+
+	// tls
+	if got.TLSCertFile != want.TLSCertFile {
+		t.Errorf("conf.TLSCertFile does not match. got: '%v', want: '%v'", got.TLSCertFile, want.TLSCertFile)
+	}
+	if got.TLSKeyFile != want.TLSKeyFile {
+		t.Errorf("conf.TLSKeyFile does not match. got: '%v', want: '%v'", got.TLSKeyFile, want.TLSKeyFile)
+	}
+	if !slices.Equal(got.TLSAutocertHosts, want.TLSAutocertHosts) {
+		t.Errorf("conf.TLSAutocertHosts does not match. got: '%v', want: '%v'", got.TLSAutocertHosts, want.TLSAutocertHosts)
+	}
+	if got.TLSAutocertCacheDir != want.TLSAutocertCacheDir {
+		t.Errorf("conf.TLSAutocertCacheDir does not match. got: '%v', want: '%v'", got.TLSAutocertCacheDir, want.TLSAutocertCacheDir)
+	}
+	if got.TLSHTTPRedirectAddr != want.TLSHTTPRedirectAddr {
+		t.Errorf("conf.TLSHTTPRedirectAddr does not match. got: '%v', want: '%v'", got.TLSHTTPRedirectAddr, want.TLSHTTPRedirectAddr)
+	}
+
+	// at-rest encryption
+	if got.FieldEncryptionKeys != want.FieldEncryptionKeys {
+		t.Errorf("conf.FieldEncryptionKeys does not match. got: '%v', want: '%v'", got.FieldEncryptionKeys, want.FieldEncryptionKeys)
+	}
+	if got.FieldEncryptionRotate != want.FieldEncryptionRotate {
+		t.Errorf("conf.FieldEncryptionRotate does not match. got: '%v', want: '%v'", got.FieldEncryptionRotate, want.FieldEncryptionRotate)
+	}
+	if got.SearchIndexPath != want.SearchIndexPath {
+		t.Errorf("conf.SearchIndexPath does not match. got: '%v', want: '%v'", got.SearchIndexPath, want.SearchIndexPath)
+	}
+
+	// access log
+	if got.AccessLogFormat != want.AccessLogFormat {
+		t.Errorf("conf.AccessLogFormat does not match. got: '%v', want: '%v'", got.AccessLogFormat, want.AccessLogFormat)
+	}
+	if !slices.Equal(got.AccessLogFields, want.AccessLogFields) {
+		t.Errorf("conf.AccessLogFields does not match. got: '%v', want: '%v'", got.AccessLogFields, want.AccessLogFields)
+	}
+	if !slices.Equal(got.AccessLogSkipPaths, want.AccessLogSkipPaths) {
+		t.Errorf("conf.AccessLogSkipPaths does not match. got: '%v', want: '%v'", got.AccessLogSkipPaths, want.AccessLogSkipPaths)
+	}
+
+	// profiling
+	if got.PprofToken != want.PprofToken {
+		t.Errorf("conf.PprofToken does not match. got: '%v', want: '%v'", got.PprofToken, want.PprofToken)
+	}
+
+	// web ui
+	if got.UIEnabled != want.UIEnabled {
+		t.Errorf("conf.UIEnabled does not match. got: '%v', want: '%v'", got.UIEnabled, want.UIEnabled)
+	}
+
+	// localization
+	if got.DefaultLocale != want.DefaultLocale {
+		t.Errorf("conf.DefaultLocale does not match. got: '%v', want: '%v'", got.DefaultLocale, want.DefaultLocale)
+	}
+
+	// logging, the zero value is info
+	if got.LogLevel != want.LogLevel {
+		t.Errorf("conf.LogLevel does not match. got: '%v', want: '%v'", got.LogLevel, want.LogLevel)
+	}
+	if got.DebugLogBodies != want.DebugLogBodies {
+		t.Errorf("conf.DebugLogBodies does not match. got: '%v', want: '%v'", got.DebugLogBodies, want.DebugLogBodies)
+	}
+	if !slices.Equal(got.DebugLogRedactFields, want.DebugLogRedactFields) {
+		t.Errorf("conf.DebugLogRedactFields does not match. got: '%v', want: '%v'", got.DebugLogRedactFields, want.DebugLogRedactFields)
+	}
+
+	// error reporting
+	if got.ErrorReportingDSN != want.ErrorReportingDSN {
+		t.Errorf("conf.ErrorReportingDSN does not match. got: '%v', want: '%v'", got.ErrorReportingDSN, want.ErrorReportingDSN)
+	}
+	if got.ErrorReportingEnvironment != want.ErrorReportingEnvironment {
+		t.Errorf("conf.ErrorReportingEnvironment does not match. got: '%v', want: '%v'", got.ErrorReportingEnvironment, want.ErrorReportingEnvironment)
+	}
+
+	// config reload
+	if got.ConfigReloadInterval != want.ConfigReloadInterval {
+		t.Errorf("conf.ConfigReloadInterval does not match. got: '%v', want: '%v'", got.ConfigReloadInterval, want.ConfigReloadInterval)
+	}
+
+	// dev mode
+	if got.DevMode != want.DevMode {
+		t.Errorf("conf.DevMode does not match. got: '%v', want: '%v'", got.DevMode, want.DevMode)
+	}
 }
 
-func unsetEnvVars(t *testing.T, keyList []string) {
-	t.Helper()
+func unsetEnvVars(t *testing.T, keyList []string) {
+	t.Helper()
+
+	for _, key := range keyList {
+		err := os.Unsetenv(key)
+		if err != nil {
+			t.Errorf("unable to unset key %q", key)
+		}
+	}
+}
+
+// NOTE: any newly supported vars need to be added here for testing
+func TestLoadConfig(t *testing.T) {
+	envVarKeys := []string{
+		"LOCAL_ADDRESS",
+		"LOCAL_PORT",
+		"LISTEN_REUSE_PORT",
+		"REQUEST_TIMEOUT",
+		"ROUTE_TIMEOUTS",
+		"STRICT_JSON",
+		"RESPONSE_CACHE_TTL",
+		"RESPONSE_CACHE_MAX_ENTRIES",
+		"DB_PATH",
+		"GOOSE_DRIVER",
+		"GOOSE_DBSTRING",
+		"MONGODB_URI",
+		"BANK_PROVIDER",
+		"BANK_SECRET_ID",
+		"BANK_SECRET_KEY",
+		"BANK_ACCOUNT_ID",
+		"BANK_SYNC_INTERVAL",
+		"FX_PROVIDER",
+		"FX_RATES_URL",
+		"FX_RATES_FILE",
+		"FX_BASE_CURRENCY",
+		"FX_REFRESH_INTERVAL",
+		"FX_MAX_AGE",
+		"SLACK_SIGNING_SECRET",
+		"SLACK_USERS",
+		"SLACK_WEBHOOK_URL",
+		"SLACK_NOTIFY_MIN_AMOUNT",
+		"SMTP_HOST",
+		"SMTP_PORT",
+		"SMTP_USERNAME",
+		"SMTP_PASSWORD",
+		"SMTP_FROM",
+		"WEBHOOK_URL",
+		"WEBHOOK_SECRET",
+		"WEBHOOK_MAX_ATTEMPTS",
+		"WEBHOOK_POLL_INTERVAL",
+		"RATE_LIMIT_REQUESTS",
+		"RATE_LIMIT_WINDOW",
+		"TRUSTED_PROXIES",
+		"JOB_WORKERS",
+		"JOB_RETENTION",
+		"RETENTION_RULES",
+		"RETENTION_INTERVAL",
+		"MAX_PAGE_SIZE",
+		"MAX_RESULT_ROWS",
+		"REPORT_WORKERS",
+		"FUTURE_EXPENSES",
+		"FUTURE_EXPENSE_SKEW",
+		"DUPLICATE_WINDOW",
+		"AUTH_ENABLED",
+		"JWT_SECRET",
+		"JWT_TTL",
+		"OIDC_ISSUER_URL",
+		"OIDC_CLIENT_ID",
+		"OIDC_CLIENT_SECRET",
+		"OIDC_REDIRECT_URL",
+		"CORS_ALLOWED_ORIGINS",
+		"CORS_ALLOWED_METHODS",
+		"CORS_ALLOWED_HEADERS",
+		"CORS_MAX_AGE",
+		"TLS_CERT_FILE",
+		"TLS_KEY_FILE",
+		"TLS_AUTOCERT_HOSTS",
+		"TLS_AUTOCERT_EMAIL",
+		"TLS_AUTOCERT_CACHE_DIR",
+		"TLS_HTTP_REDIRECT_ADDRESS",
+		"SECRETS_DIR",
+		"VAULT_ADDR",
+		"VAULT_TOKEN",
+		"VAULT_SECRET_PATH",
+		"AWS_SECRET_NAME",
+		"AWS_REGION",
+		"DB_PATH_FILE",
+		"JWT_SECRET_FILE",
+		"FIELD_ENCRYPTION_KEYS",
+		"FIELD_ENCRYPTION_ROTATE",
+		"SEARCH_INDEX_PATH",
+		"ACCESS_LOG_FORMAT",
+		"ACCESS_LOG_FIELDS",
+		"ACCESS_LOG_SKIP_PATHS",
+		"PPROF_TOKEN",
+		"UI_ENABLED",
+		"DEFAULT_LOCALE",
+		"LOG_LEVEL",
+		"DEBUG_LOG_BODIES",
+		"DEBUG_LOG_REDACT_FIELDS",
+		"ERROR_REPORTING_DSN",
+		"ERROR_REPORTING_ENVIRONMENT",
+		"CONFIG_RELOAD_INTERVAL",
+		"DEV_MODE",
+	}
+
+	testTable := []struct {
+		name        string
+		inputConfig string
+		expectError bool
+		wantError   error
+		wantConfig  *config.Config
+	}{
+		{
+			name: "valid-config-load-a",
+			inputConfig: `# server vars
+      export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+
+      # Goose vars
+      export GOOSE_DRIVER="sqlite3"
+      export GOOSE_DBSTRING="./../../expense-tracker.db"
+
+      # MongoDB Vars
+      export MONGODB_URI="mongodb://localhost:27017"`,
+			expectError: false,
+			wantError:   nil,
+			wantConfig: &config.Config{
+				LocalAddress: "localhost",
+				LocalPort:    "8080",
+				Address:      "localhost:8080",
+				DBString:     "./expense-tracker.db",
+				DBDriver:     "sqlite3",
+
+				RequestTimeout: 30 * time.Second,
+
+				ResponseCacheMaxEntries: 10000,
+
+				BankSyncInterval: 6 * time.Hour,
+				RateLimitWindow:  time.Minute,
+
+				FXBaseCurrency:    "USD",
+				FXRefreshInterval: 12 * time.Hour,
+				FXMaxAge:          48 * time.Hour,
+
+				SlackNotifyMinAmount: 10000,
+
+				SMTPPort: 587,
+
+				WebhookMaxAttempts:  10,
+				WebhookPollInterval: 5 * time.Second,
+
+				JobWorkers:   2,
+				JobRetention: time.Hour,
+
+				RetentionInterval: 24 * time.Hour,
+				MaxPageSize:       500,
+				MaxResultRows:     10000,
+				ReportWorkers:     4,
+				JWTTTL:            24 * time.Hour,
+
+				FutureExpenses:    "flag",
+				FutureExpenseSkew: 10 * time.Minute,
+
+				CORSAllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+				CORSAllowedHeaders: []string{"Authorization", "Content-Type"},
+				CORSMaxAge:         10 * time.Minute,
+
+				TLSAutocertCacheDir: "./autocert-cache",
+
+				AccessLogFormat: "text",
+
+				UIEnabled: true,
+
+				DefaultLocale: "en",
+
+				ErrorReportingEnvironment: "production",
+			},
+		},
+		{
+			name: "valid-config-load-b",
+			inputConfig: `# server vars
+      export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+
+      # Goose vars
+      export GOOSE_DRIVER="sqlite3"
+
+      # MongoDB Vars
+      export MONGODB_URI="mongodb://localhost:27017"`,
+			expectError: false,
+			wantError:   nil,
+			wantConfig: &config.Config{
+				LocalAddress: "localhost",
+				LocalPort:    "8080",
+				Address:      "localhost:8080",
+				DBString:     "./expense-tracker.db",
+				DBDriver:     "sqlite3",
+
+				RequestTimeout: 30 * time.Second,
+
+				ResponseCacheMaxEntries: 10000,
+
+				BankSyncInterval: 6 * time.Hour,
+				RateLimitWindow:  time.Minute,
+
+				FXBaseCurrency:    "USD",
+				FXRefreshInterval: 12 * time.Hour,
+				FXMaxAge:          48 * time.Hour,
+
+				SlackNotifyMinAmount: 10000,
+
+				SMTPPort: 587,
+
+				WebhookMaxAttempts:  10,
+				WebhookPollInterval: 5 * time.Second,
+
+				JobWorkers:   2,
+				JobRetention: time.Hour,
+
+				RetentionInterval: 24 * time.Hour,
+				MaxPageSize:       500,
+				MaxResultRows:     10000,
+				ReportWorkers:     4,
+				JWTTTL:            24 * time.Hour,
+
+				FutureExpenses:    "flag",
+				FutureExpenseSkew: 10 * time.Minute,
+
+				CORSAllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+				CORSAllowedHeaders: []string{"Authorization", "Content-Type"},
+				CORSMaxAge:         10 * time.Minute,
+
+				TLSAutocertCacheDir: "./autocert-cache",
+
+				AccessLogFormat: "text",
+
+				UIEnabled: true,
+
+				DefaultLocale: "en",
+
+				ErrorReportingEnvironment: "production",
+			},
+		},
+		{
+			name: "valid-config-load-bank-sync",
+			inputConfig: `# server vars
+      export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export LISTEN_REUSE_PORT="true"
+      export DB_PATH="./expense-tracker.db"
+
+      # Request timeout vars
+      export REQUEST_TIMEOUT="15s"
+      export ROUTE_TIMEOUTS="GET /expenses=5s, post /exports=0"
+
+      # Request body vars
+      export STRICT_JSON="true"
+
+      # Response cache vars
+      export RESPONSE_CACHE_TTL="10s"
+      export RESPONSE_CACHE_MAX_ENTRIES="500"
+
+      # Goose vars
+      export GOOSE_DRIVER="sqlite3"
+
+      # MongoDB Vars
+      export MONGODB_URI="mongodb://localhost:27017"
+
+      # Bank sync vars
+      export BANK_PROVIDER="gocardless"
+      export BANK_SECRET_ID="secret-id"
+      export BANK_SECRET_KEY="secret-key"
+      export BANK_ACCOUNT_ID="account-id"
+      export BANK_SYNC_INTERVAL="30m"
+
+      # Exchange rate vars
+      export FX_RATES_URL="https://api.frankfurter.app/latest"
+      export FX_BASE_CURRENCY="USD"
+      export FX_REFRESH_INTERVAL="1h"
+      export FX_MAX_AGE="6h"
+
+      # Slack vars
+      export SLACK_SIGNING_SECRET="slack-signing-secret"
+      export SLACK_USERS="U012AB3CD=1, U045EF6GH=2"
+      export SLACK_WEBHOOK_URL="https://hooks.slack.com/services/T000/B000/XXXX"
+      export SLACK_NOTIFY_MIN_AMOUNT="50000"
+
+      # SMTP vars
+      export SMTP_HOST="smtp.example.com"
+      export SMTP_PORT="465"
+      export SMTP_USERNAME="mailer"
+      export SMTP_PASSWORD="smtp-password"
+      export SMTP_FROM="noreply@example.com"
+
+      # Webhook vars
+      export WEBHOOK_URL="https://example.com/hooks/expenses"
+      export WEBHOOK_SECRET="webhook-secret"
+      export WEBHOOK_MAX_ATTEMPTS="5"
+      export WEBHOOK_POLL_INTERVAL="30s"
+
+      # Rate limit vars
+      export RATE_LIMIT_REQUESTS="120"
+      export RATE_LIMIT_WINDOW="1m"
+      export TRUSTED_PROXIES="10.0.0.1, 192.168.0.0/16"
+
+      # Async job vars
+      export JOB_WORKERS="4"
+      export JOB_RETENTION="24h"
+
+      # Data retention vars
+      export RETENTION_RULES="audit_events=8760h, outbox=720h, expense_tombstones=2160h"
+      export RETENTION_INTERVAL="1h"
+
+      # Result guardrail vars
+      export MAX_PAGE_SIZE="100"
+      export MAX_RESULT_ROWS="0"
+
+      # Report vars
+      export REPORT_WORKERS="8"
+
+      # Future dated expense vars
+      export FUTURE_EXPENSES="reject"
+      export FUTURE_EXPENSE_SKEW="1h"
+
+      # Duplicate guard vars
+      export DUPLICATE_WINDOW="1m"
+
+      # Auth vars
+      export AUTH_ENABLED="true"
+      export JWT_SECRET="0123456789abcdef0123456789abcdef"
+      export JWT_TTL="1h"
+
+      # OIDC vars
+      export OIDC_ISSUER_URL="https://accounts.example.com"
+      export OIDC_CLIENT_ID="expense-tracker"
+      export OIDC_CLIENT_SECRET="client-secret"
+      export OIDC_REDIRECT_URL="http://localhost:8080/auth/oidc/callback"
+
+      # CORS vars
+      export CORS_ALLOWED_ORIGINS="https://app.example.com, http://localhost:5173"
+      export CORS_ALLOWED_METHODS="GET,POST"
+      export CORS_MAX_AGE="1h"
+
+      # TLS vars
+      export TLS_AUTOCERT_HOSTS="expenses.example.com"
+      export TLS_AUTOCERT_CACHE_DIR="/var/lib/expense-tracker/autocert"
+      export TLS_HTTP_REDIRECT_ADDRESS=":80"
+
+      # At-rest encryption vars
+      export FIELD_ENCRYPTION_KEYS="2025:AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE="
+      export FIELD_ENCRYPTION_ROTATE="true"
+
+      # Search index vars
+      export SEARCH_INDEX_PATH=":memory:"
+
+      # Access log vars
+      export ACCESS_LOG_FORMAT="json"
+      export ACCESS_LOG_FIELDS="user_id, bytes"
+      export ACCESS_LOG_SKIP_PATHS="/healthz"
+
+      # Profiling vars
+      export PPROF_TOKEN="fedcba9876543210fedcba9876543210"
+
+      # Web UI vars
+      export UI_ENABLED="false"
+
+      # Localization vars
+      export DEFAULT_LOCALE="de"
+
+      # Logging vars
+      export LOG_LEVEL="debug"
+      export DEBUG_LOG_BODIES="true"
+      export DEBUG_LOG_REDACT_FIELDS="email, description"
+
+      # Error reporting vars
+      export ERROR_REPORTING_DSN="https://public@errors.example.com/42"
+      export ERROR_REPORTING_ENVIRONMENT="staging"
+
+      # Config reload vars
+      export CONFIG_RELOAD_INTERVAL="30s"`,
+			expectError: false,
+			wantError:   nil,
+			wantConfig: &config.Config{
+				LocalAddress: "localhost",
+				LocalPort:    "8080",
+				Address:      "localhost:8080",
+				DBString:     "./expense-tracker.db",
+				DBDriver:     "sqlite3",
+
+				ListenReusePort: true,
+
+				RequestTimeout: 15 * time.Second,
+				RouteTimeouts:  map[string]time.Duration{"GET /expenses": 5 * time.Second, "POST /exports": 0},
+
+				StrictJSON: true,
+
+				ResponseCacheTTL:        10 * time.Second,
+				ResponseCacheMaxEntries: 500,
+
+				BankProvider:     "gocardless",
+				BankSyncInterval: 30 * time.Minute,
+
+				FXProvider:        "json",
+				FXRatesURL:        "https://api.frankfurter.app/latest",
+				FXBaseCurrency:    "USD",
+				FXRefreshInterval: time.Hour,
+				FXMaxAge:          6 * time.Hour,
+
+				SlackSigningSecret:   "slack-signing-secret",
+				SlackUsers:           map[string]int{"U012AB3CD": 1, "U045EF6GH": 2},
+				SlackWebhookURL:      "https://hooks.slack.com/services/T000/B000/XXXX",
+				SlackNotifyMinAmount: 50000,
+
+				SMTPHost:     "smtp.example.com",
+				SMTPPort:     465,
+				SMTPUsername: "mailer",
+				SMTPPassword: "smtp-password",
+				SMTPFrom:     "noreply@example.com",
+
+				WebhookURL:          "https://example.com/hooks/expenses",
+				WebhookSecret:       "webhook-secret",
+				WebhookMaxAttempts:  5,
+				WebhookPollInterval: 30 * time.Second,
+
+				RateLimitRequests: 120,
+				RateLimitWindow:   time.Minute,
+				TrustedProxies:    []string{"10.0.0.1", "192.168.0.0/16"},
+
+				JobWorkers:   4,
+				JobRetention: 24 * time.Hour,
+
+				RetentionRules:    map[string]time.Duration{"audit_events": 8760 * time.Hour, "outbox": 720 * time.Hour, "expense_tombstones": 2160 * time.Hour},
+				RetentionInterval: time.Hour,
+
+				MaxPageSize:   100,
+				MaxResultRows: 0,
+
+				ReportWorkers: 8,
+
+				FutureExpenses:    "reject",
+				FutureExpenseSkew: time.Hour,
+
+				DuplicateWindow: time.Minute,
+
+				AuthEnabled: true,
+				JWTSecret:   "0123456789abcdef0123456789abcdef",
+				JWTTTL:      time.Hour,
+
+				OIDCIssuerURL:    "https://accounts.example.com",
+				OIDCClientID:     "expense-tracker",
+				OIDCClientSecret: "client-secret",
+				OIDCRedirectURL:  "http://localhost:8080/auth/oidc/callback",
+
+				CORSAllowedOrigins: []string{"https://app.example.com", "http://localhost:5173"},
+				CORSAllowedMethods: []string{"GET", "POST"},
+				CORSAllowedHeaders: []string{"Authorization", "Content-Type"},
+				CORSMaxAge:         time.Hour,
+
+				TLSAutocertHosts:    []string{"expenses.example.com"},
+				TLSAutocertCacheDir: "/var/lib/expense-tracker/autocert",
+				TLSHTTPRedirectAddr: ":80",
+
+				FieldEncryptionKeys:   "2025:AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE=",
+				FieldEncryptionRotate: true,
+
+				SearchIndexPath: ":memory:",
+
+				AccessLogFormat:    "json",
+				AccessLogFields:    []string{"user_id", "bytes"},
+				AccessLogSkipPaths: []string{"/healthz"},
+
+				PprofToken: "fedcba9876543210fedcba9876543210",
+
+				DefaultLocale: "de",
+
+				LogLevel:             slog.LevelDebug,
+				DebugLogBodies:       true,
+				DebugLogRedactFields: []string{"email", "description"},
+
+				ErrorReportingDSN:         "https://public@errors.example.com/42",
+				ErrorReportingEnvironment: "staging",
+
+				ConfigReloadInterval: 30 * time.Second,
+			},
+		},
+		{
+			name: "invalid-bank-sync-missing-credentials",
+			inputConfig: `# server vars
+      export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+
+      # Goose vars
+      export GOOSE_DRIVER="sqlite3"
+
+      # MongoDB Vars
+      export MONGODB_URI="mongodb://localhost:27017"
+
+      # Bank sync vars
+      export BANK_PROVIDER="gocardless"`,
+			expectError: true,
+			wantError:   &config.MissingVariableError{},
+			wantConfig:  nil,
+		},
+		{
+			name: "invalid-auth-enabled-without-secret",
+			inputConfig: `# server vars
+      export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+
+      # Goose vars
+      export GOOSE_DRIVER="sqlite3"
+
+      # MongoDB Vars
+      export MONGODB_URI="mongodb://localhost:27017"
+
+      # Auth vars
+      export AUTH_ENABLED="true"`,
+			expectError: true,
+			wantError:   &config.MissingVariableError{},
+			wantConfig:  nil,
+		},
+		{
+			name: "invalid-webhook-without-secret",
+			inputConfig: `# server vars
+      export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+
+      # Goose vars
+      export GOOSE_DRIVER="sqlite3"
+
+      # MongoDB Vars
+      export MONGODB_URI="mongodb://localhost:27017"
+
+      # Webhook vars
+      export WEBHOOK_URL="https://example.com/hooks/expenses"`,
+			expectError: true,
+			wantError:   &config.MissingVariableError{},
+			wantConfig:  nil,
+		},
+		{
+			name: "invalid-oidc-missing-client",
+			inputConfig: `# server vars
+      export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+
+      # Goose vars
+      export GOOSE_DRIVER="sqlite3"
+
+      # MongoDB Vars
+      export MONGODB_URI="mongodb://localhost:27017"
+
+      # Auth vars
+      export JWT_SECRET="0123456789abcdef0123456789abcdef"
+
+      # OIDC vars
+      export OIDC_ISSUER_URL="https://accounts.example.com"`,
+			expectError: true,
+			wantError:   &config.MissingVariableError{},
+			wantConfig:  nil,
+		},
+		{
+			name: "invalid-tls-cert-without-key",
+			inputConfig: `# server vars
+      export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+
+      # Goose vars
+      export GOOSE_DRIVER="sqlite3"
+
+      # MongoDB Vars
+      export MONGODB_URI="mongodb://localhost:27017"
+
+      # TLS vars
+      export TLS_CERT_FILE="./cert.pem"`,
+			expectError: true,
+			wantError:   &config.MissingVariableError{},
+			wantConfig:  nil,
+		},
+		{
+			name: "invalid-encryption-rotate-without-keys",
+			inputConfig: `# server vars
+      export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+
+      # Goose vars
+      export GOOSE_DRIVER="sqlite3"
+
+      # MongoDB Vars
+      export MONGODB_URI="mongodb://localhost:27017"
+
+      # At-rest encryption vars
+      export FIELD_ENCRYPTION_ROTATE="true"
+
+      # Access log vars
+      export ACCESS_LOG_FORMAT="json"
+      export ACCESS_LOG_FIELDS="user_id, bytes"
+      export ACCESS_LOG_SKIP_PATHS="/healthz"
+
+      # Profiling vars
+      export PPROF_TOKEN="fedcba9876543210fedcba9876543210"`,
+			expectError: true,
+			wantError:   &config.MissingVariableError{},
+			wantConfig:  nil,
+		},
+		{
+			name: "invalid-access-log-field",
+			inputConfig: `# server vars
+      export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+
+      # Goose vars
+      export GOOSE_DRIVER="sqlite3"
+
+      # MongoDB Vars
+      export MONGODB_URI="mongodb://localhost:27017"
+
+      # Access log vars
+      export ACCESS_LOG_FIELDS="user_id,password"`,
+			expectError: true,
+			wantError:   nil,
+			wantConfig:  nil,
+		},
+		{
+			name: "invalid-short-pprof-token",
+			inputConfig: `# server vars
+      export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+
+      # Goose vars
+      export GOOSE_DRIVER="sqlite3"
+
+      # MongoDB Vars
+      export MONGODB_URI="mongodb://localhost:27017"
+
+      # Profiling vars
+      export PPROF_TOKEN="letmein"`,
+			expectError: true,
+			wantError:   nil,
+			wantConfig:  nil,
+		},
+		{
+			name: "invalid-log-level",
+			inputConfig: `# server vars
+      export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+
+      # Goose vars
+      export GOOSE_DRIVER="sqlite3"
+
+      # Logging vars
+      export LOG_LEVEL="verbose"`,
+			expectError: true,
+			wantError:   &config.InvalidVariableError{},
+			wantConfig:  nil,
+		},
+		{
+			name: "invalid-zero-page-size",
+			inputConfig: `# server vars
+      export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+
+      # Goose vars
+      export GOOSE_DRIVER="sqlite3"
+
+      # Result guardrail vars
+      export MAX_PAGE_SIZE="0"`,
+			expectError: true,
+			wantError:   &config.InvalidVariableError{},
+			wantConfig:  nil,
+		},
+		{
+			name: "invalid-fx-base-currency",
+			inputConfig: `# server vars
+      export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+
+      # Goose vars
+      export GOOSE_DRIVER="sqlite3"
 
-	for _, key := range keyList {
-		err := os.Unsetenv(key)
-		if err != nil {
-			t.Errorf("unable to unset key %q", key)
-		}
-	}
-}
+      # Exchange rate vars
+      export FX_BASE_CURRENCY="euro"`,
+			expectError: true,
+			wantError:   &config.InvalidVariableError{},
+			wantConfig:  nil,
+		},
+		{
+			name: "invalid-fx-static-without-file",
+			inputConfig: `# server vars
+      export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
 
-// NOTE: any newly supported vars need to be added here for testing
-func TestLoadConfig(t *testing.T) {
-	envVarKeys := []string{
-		"LOCAL_ADDRESS",
-		"LOCAL_PORT",
-		"DB_PATH",
-		"GOOSE_DRIVER",
-		"GOOSE_DBSTRING",
-		"MONGODB_URI",
-	}
+      # Goose vars
+      export GOOSE_DRIVER="sqlite3"
 
-	testTable := []struct {
-		name        string
-		inputConfig string
-		expectError bool
-		wantError   error
-		wantConfig  *config.Config
-	}{
+      # Exchange rate vars
+      export FX_PROVIDER="static"`,
+			expectError: true,
+			wantError:   &config.MissingVariableError{},
+			wantConfig:  nil,
+		},
 		{
-			name: "valid-config-load-a",
+			name: "invalid-fx-provider",
 			inputConfig: `# server vars
       export LOCAL_ADDRESS="localhost"
       export LOCAL_PORT="8080"
@@ -71,22 +1023,15 @@ func TestLoadConfig(t *testing.T) {
 
       # Goose vars
       export GOOSE_DRIVER="sqlite3"
-      export GOOSE_DBSTRING="./../../expense-tracker.db"
 
-      # MongoDB Vars
-      export MONGODB_URI="mongodb://localhost:27017"`,
-			expectError: false,
-			wantError:   nil,
-			wantConfig: &config.Config{
-				LocalAddress: "localhost",
-				LocalPort:    "8080",
-				Address:      "localhost:8080",
-				DBString:     "./expense-tracker.db",
-				DBDriver:     "sqlite3",
-			},
+      # Exchange rate vars
+      export FX_PROVIDER="openexchangerates"`,
+			expectError: true,
+			wantError:   &config.InvalidVariableError{},
+			wantConfig:  nil,
 		},
 		{
-			name: "valid-config-load-b",
+			name: "invalid-future-expenses",
 			inputConfig: `# server vars
       export LOCAL_ADDRESS="localhost"
       export LOCAL_PORT="8080"
@@ -95,17 +1040,27 @@ func TestLoadConfig(t *testing.T) {
       # Goose vars
       export GOOSE_DRIVER="sqlite3"
 
-      # MongoDB Vars
-      export MONGODB_URI="mongodb://localhost:27017"`,
-			expectError: false,
-			wantError:   nil,
-			wantConfig: &config.Config{
-				LocalAddress: "localhost",
-				LocalPort:    "8080",
-				Address:      "localhost:8080",
-				DBString:     "./expense-tracker.db",
-				DBDriver:     "sqlite3",
-			},
+      # Future dated expense vars
+      export FUTURE_EXPENSES="warn"`,
+			expectError: true,
+			wantError:   &config.InvalidVariableError{},
+			wantConfig:  nil,
+		},
+		{
+			name: "invalid-slack-users",
+			inputConfig: `# server vars
+      export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+
+      # Goose vars
+      export GOOSE_DRIVER="sqlite3"
+
+      # Slack vars
+      export SLACK_USERS="U012AB3CD=alice"`,
+			expectError: true,
+			wantError:   &config.InvalidVariableError{},
+			wantConfig:  nil,
 		},
 		{
 			name:        "invalid-empty-config-load",
@@ -207,9 +1162,9 @@ func TestLoadConfig(t *testing.T) {
 				t.Errorf("LoadConfig(%q) with config: %v, got error: '%v', expected error: '%v'", tmpFile.Name(), testCase.inputConfig, gotErr, testCase.wantError)
 			}
 
-			// checking error type if its not nil
+			// checking error type if its not nil, invalid values have no error type to check
 			if gotErr != nil {
-				if !errors.Is(gotErr, testCase.wantError) {
+				if testCase.wantError != nil && !errors.Is(gotErr, testCase.wantError) {
 					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
 				}
 			}
@@ -223,12 +1178,367 @@ func TestLoadConfig(t *testing.T) {
 }
 
 func TestMissingVariableError(t *testing.T) {
-	err := &config.MissingVariableError{}
-	errStr := err.Error()
+	testTable := []struct {
+		name      string
+		inputKeys []string
+		want      string
+	}{
+		{
+			name:      "test-missing-variable-error",
+			inputKeys: nil,
+			want:      "missing required environmental variable(s)",
+		},
+		{
+			name:      "test-missing-variable-error-keys",
+			inputKeys: []string{"LOCAL_PORT", "DB_PATH"},
+			want:      "missing required environmental variable(s): LOCAL_PORT, DB_PATH",
+		},
+	}
 
-	t.Run("test-missing-variable-error", func(t *testing.T) {
-		if errStr != "missing required environmental variable(s)" {
-			t.Errorf("error does not match what was expected")
-		}
-	})
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := &config.MissingVariableError{Keys: testCase.inputKeys}
+			if got := err.Error(); got != testCase.want {
+				t.Errorf("got error: %q, want: %q", got, testCase.want)
+			}
+			if !errors.Is(err, &config.MissingVariableError{}) {
+				t.Errorf("errors.Is() did not match a MissingVariableError without keys")
+			}
+		})
+	}
+}
+
+func TestLoadConfigNamesVariables(t *testing.T) {
+	envVarKeys := []string{
+		"LOCAL_ADDRESS",
+		"LOCAL_PORT",
+		"DB_PATH",
+		"GOOSE_DRIVER",
+		"MONGODB_URI",
+		"AUTH_ENABLED",
+		"JWT_SECRET",
+		"JWT_TTL",
+		"PPROF_TOKEN",
+		"ROUTE_TIMEOUTS",
+		"RETENTION_RULES",
+		"TRUSTED_PROXIES",
+		"SMTP_HOST",
+		"SMTP_FROM",
+		"FIELD_ENCRYPTION_KEYS",
+		"SEARCH_INDEX_PATH",
+		"DEFAULT_LOCALE",
+	}
+
+	testTable := []struct {
+		name        string
+		inputConfig string
+		wantMissing []string
+		wantInvalid []string
+	}{
+		{
+			name: "valid-sqlite-without-mongodb",
+			inputConfig: `export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+      export GOOSE_DRIVER="sqlite3"`,
+		},
+		{
+			name: "invalid-sqlite-without-db-path",
+			inputConfig: `export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export GOOSE_DRIVER="sqlite3"`,
+			wantMissing: []string{"DB_PATH"},
+		},
+		{
+			name: "invalid-unsupported-driver",
+			inputConfig: `export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export GOOSE_DRIVER="postgres"`,
+			wantInvalid: []string{"GOOSE_DRIVER"},
+		},
+		{
+			name: "invalid-route-timeout",
+			inputConfig: `export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+      export GOOSE_DRIVER="sqlite3"
+      export ROUTE_TIMEOUTS="/expenses=5s"`,
+			wantInvalid: []string{"ROUTE_TIMEOUTS"},
+		},
+		{
+			name: "invalid-retention-target",
+			inputConfig: `export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+      export GOOSE_DRIVER="sqlite3"
+      export RETENTION_RULES="outbox=720h, expenses=8760h"`,
+			wantInvalid: []string{"RETENTION_RULES"},
+		},
+		{
+			name: "invalid-search-index-on-disk-with-encryption",
+			inputConfig: `export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+      export GOOSE_DRIVER="sqlite3"
+      export FIELD_ENCRYPTION_KEYS="2025:AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE="
+      export SEARCH_INDEX_PATH="./search.bleve"`,
+			wantInvalid: []string{"SEARCH_INDEX_PATH"},
+		},
+		{
+			name: "invalid-unsupported-locale",
+			inputConfig: `export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+      export GOOSE_DRIVER="sqlite3"
+      export DEFAULT_LOCALE="tlh"`,
+			wantInvalid: []string{"DEFAULT_LOCALE"},
+		},
+		{
+			name: "invalid-smtp-without-from",
+			inputConfig: `export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+      export GOOSE_DRIVER="sqlite3"
+      export SMTP_HOST="smtp.example.com"`,
+			wantMissing: []string{"SMTP_FROM"},
+		},
+		{
+			name: "invalid-trusted-proxy",
+			inputConfig: `export LOCAL_ADDRESS="localhost"
+      export LOCAL_PORT="8080"
+      export DB_PATH="./expense-tracker.db"
+      export GOOSE_DRIVER="sqlite3"
+      export TRUSTED_PROXIES="10.0.0.1, proxy.internal"`,
+			wantInvalid: []string{"TRUSTED_PROXIES"},
+		},
+		{
+			name: "invalid-every-problem-listed",
+			inputConfig: `export LOCAL_ADDRESS="localhost"
+      export GOOSE_DRIVER="sqlite3"
+      export AUTH_ENABLED="yes please"
+      export JWT_TTL="forever"
+      export PPROF_TOKEN="letmein"`,
+			wantMissing: []string{"LOCAL_PORT", "DB_PATH"},
+			wantInvalid: []string{"AUTH_ENABLED", "JWT_TTL", "PPROF_TOKEN"},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			unsetEnvVars(t, envVarKeys)
+			t.Cleanup(func() { unsetEnvVars(t, envVarKeys) })
+
+			path := t.TempDir() + "/test.env"
+			if err := os.WriteFile(path, []byte(testCase.inputConfig), 0o644); err != nil {
+				t.Fatalf("failed to write to temporary file: %v", err)
+			}
+
+			_, gotErr := config.LoadConfig(path)
+
+			var gotMissing []string
+			var missingErr *config.MissingVariableError
+			if errors.As(gotErr, &missingErr) {
+				gotMissing = missingErr.Keys
+			}
+			if !slices.Equal(gotMissing, testCase.wantMissing) {
+				t.Errorf("got missing: %v, want missing: %v, error: '%v'", gotMissing, testCase.wantMissing, gotErr)
+			}
+
+			for _, key := range testCase.wantInvalid {
+				if gotErr == nil || !strings.Contains(gotErr.Error(), "invalid "+key) {
+					t.Errorf("got error: '%v', want it to name %s", gotErr, key)
+				}
+			}
+			if len(testCase.wantInvalid) > 0 && !errors.Is(gotErr, &config.InvalidVariableError{}) {
+				t.Errorf("got error: '%v', want an InvalidVariableError", gotErr)
+			}
+			if gotErr != nil && strings.Contains(gotErr.Error(), "letmein") {
+				t.Errorf("got error: '%v', the pprof token must not be included", gotErr)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	envVarKeys := []string{
+		"LOCAL_ADDRESS",
+		"LOCAL_PORT",
+		"DB_PATH",
+		"GOOSE_DRIVER",
+		"MONGODB_URI",
+		"RATE_LIMIT_REQUESTS",
+		"TRUSTED_PROXIES",
+		"CORS_ALLOWED_ORIGINS",
+		"ACCESS_LOG_FORMAT",
+		"CONFIG_FILE",
+		"DEV_MODE",
+		"LOG_LEVEL",
+		"DEBUG_LOG_BODIES",
+	}
+
+	yamlConfig := `LOCAL_ADDRESS: localhost
+LOCAL_PORT: 8080
+DB_PATH: ./expense-tracker.db
+GOOSE_DRIVER: sqlite3
+MONGODB_URI: mongodb://localhost:27017
+CORS_ALLOWED_ORIGINS:
+  - https://app.example.com
+  - http://localhost:5173
+`
+
+	testTable := []struct {
+		name        string
+		inputFile   string // written to config.yaml when set
+		inputEnv    map[string]string
+		inputArgs   []string
+		expectError bool
+		wantError   error
+		wantAddress string
+		wantOrigins []string
+		wantDBPath  string // checked when set
+		wantDevMode bool
+	}{
+		{
+			name: "valid-env-only-without-file",
+			inputEnv: map[string]string{
+				"LOCAL_ADDRESS": "0.0.0.0",
+				"LOCAL_PORT":    "9000",
+				"DB_PATH":       "./expense-tracker.db",
+				"GOOSE_DRIVER":  "sqlite3",
+				"MONGODB_URI":   "mongodb://localhost:27017",
+			},
+			expectError: false,
+			wantAddress: "0.0.0.0:9000",
+		},
+		{
+			name:        "valid-yaml-file",
+			inputFile:   yamlConfig,
+			inputArgs:   []string{"-config", "config.yaml"},
+			expectError: false,
+			wantAddress: "localhost:8080",
+			wantOrigins: []string{"https://app.example.com", "http://localhost:5173"},
+		},
+		{
+			name:        "valid-yaml-file-from-env",
+			inputFile:   yamlConfig,
+			inputEnv:    map[string]string{"CONFIG_FILE": "config.yaml"},
+			expectError: false,
+			wantAddress: "localhost:8080",
+			wantOrigins: []string{"https://app.example.com", "http://localhost:5173"},
+		},
+		{
+			name:        "valid-env-over-file",
+			inputFile:   yamlConfig,
+			inputEnv:    map[string]string{"LOCAL_PORT": "9000"},
+			inputArgs:   []string{"-config", "config.yaml"},
+			expectError: false,
+			wantAddress: "localhost:9000",
+			wantOrigins: []string{"https://app.example.com", "http://localhost:5173"},
+		},
+		{
+			name:        "valid-flag-over-env",
+			inputFile:   yamlConfig,
+			inputEnv:    map[string]string{"LOCAL_PORT": "9000"},
+			inputArgs:   []string{"-config", "config.yaml", "-port", "9100", "-address", "127.0.0.1"},
+			expectError: false,
+			wantAddress: "127.0.0.1:9100",
+			wantOrigins: []string{"https://app.example.com", "http://localhost:5173"},
+		},
+		{
+			name:        "valid-dev-without-file-or-env",
+			inputArgs:   []string{"-dev"},
+			expectError: false,
+			wantAddress: "localhost:8080",
+			wantDBPath:  ":memory:",
+			wantDevMode: true,
+		},
+		{
+			name:        "valid-dev-over-file",
+			inputFile:   yamlConfig,
+			inputArgs:   []string{"-config", "config.yaml", "-dev"},
+			expectError: false,
+			wantAddress: "localhost:8080",
+			wantOrigins: []string{"https://app.example.com", "http://localhost:5173"},
+			wantDBPath:  ":memory:",
+			wantDevMode: true,
+		},
+		{
+			name:        "valid-env-over-dev",
+			inputEnv:    map[string]string{"DEV_MODE": "true", "LOCAL_PORT": "9000", "DB_PATH": "./dev.db"},
+			expectError: false,
+			wantAddress: "localhost:9000",
+			wantDBPath:  "./dev.db",
+			wantDevMode: true,
+		},
+		{
+			name:        "invalid-missing-named-file",
+			inputArgs:   []string{"-config", "missing.yaml"},
+			expectError: true,
+			wantError:   os.ErrNotExist,
+		},
+		{
+			name:        "invalid-yaml-file",
+			inputFile:   "LOCAL_PORT: [8080",
+			inputArgs:   []string{"-config", "config.yaml"},
+			expectError: true,
+			wantError:   nil,
+		},
+		{
+			name:        "invalid-unknown-flag",
+			inputArgs:   []string{"-verbose"},
+			expectError: true,
+			wantError:   nil,
+		},
+		{
+			name:        "invalid-no-file-or-env",
+			expectError: true,
+			wantError:   &config.MissingVariableError{},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			unsetEnvVars(t, envVarKeys)
+			t.Cleanup(func() { unsetEnvVars(t, envVarKeys) })
+
+			// nothing is read from a stray .env in the package directory
+			t.Chdir(t.TempDir())
+
+			if testCase.inputFile != "" {
+				if err := os.WriteFile("config.yaml", []byte(testCase.inputFile), 0o644); err != nil {
+					t.Fatalf("failed to write config file: %v", err)
+				}
+			}
+			for key, value := range testCase.inputEnv {
+				if err := os.Setenv(key, value); err != nil {
+					t.Fatalf("unable to set %q: %v", key, err)
+				}
+			}
+
+			gotConfig, gotErr := config.Load(testCase.inputArgs)
+
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("Load(%q) got error: '%v', expected error: '%v'", testCase.inputArgs, gotErr, testCase.expectError)
+			}
+			if gotErr != nil {
+				if testCase.wantError != nil && !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+				return
+			}
+
+			if gotConfig.Address != testCase.wantAddress {
+				t.Errorf("conf.Address does not match. got: '%v', want: '%v'", gotConfig.Address, testCase.wantAddress)
+			}
+			if !slices.Equal(gotConfig.CORSAllowedOrigins, testCase.wantOrigins) {
+				t.Errorf("conf.CORSAllowedOrigins does not match. got: '%v', want: '%v'", gotConfig.CORSAllowedOrigins, testCase.wantOrigins)
+			}
+			if testCase.wantDBPath != "" && gotConfig.DBString != testCase.wantDBPath {
+				t.Errorf("conf.DBString does not match. got: '%v', want: '%v'", gotConfig.DBString, testCase.wantDBPath)
+			}
+			if gotConfig.DevMode != testCase.wantDevMode {
+				t.Errorf("conf.DevMode does not match. got: '%v', want: '%v'", gotConfig.DevMode, testCase.wantDevMode)
+			}
+		})
+	}
 }
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/joho/godotenv"
+)
+
+// defaultConfigFile is read when no file is given, and skipped when it does not exist
+const defaultConfigFile = ".env"
+
+// flagVars are the command line flags, each overriding the variable it is named after
+var flagVars = []struct {
+	name  string
+	key   string
+	usage string
+}{
+	{"address", "LOCAL_ADDRESS", "address to listen on, i.e. localhost"},
+	{"port", "LOCAL_PORT", "port to listen on, i.e. 8080"},
+	{"db-path", "DB_PATH", "path of the sqlite database"},
+	{"db-driver", "GOOSE_DRIVER", "database driver, i.e. sqlite3"},
+	{"auth-enabled", "AUTH_ENABLED", "require a token for the expense routes"},
+	{"rate-limit-requests", "RATE_LIMIT_REQUESTS", "requests allowed per client and window, 0 to disable"},
+	{"access-log-format", "ACCESS_LOG_FORMAT", "access log format, text or json"},
+}
+
+// devDefaults are set by -dev or DEV_MODE for the variables that are still unset, so the server runs
+// without any setup. They win over the config file, which would otherwise point dev mode at a real database
+var devDefaults = map[string]string{
+	"LOCAL_ADDRESS":    "localhost",
+	"LOCAL_PORT":       "8080",
+	"GOOSE_DRIVER":     "sqlite3",
+	"DB_PATH":          ":memory:",
+	"LOG_LEVEL":        "debug",
+	"DEBUG_LOG_BODIES": "true",
+}
+
+// setDevDefaults sets devDefaults when dev mode is on, leaving alone anything already set
+func setDevDefaults() error {
+	devMode, _ := strconv.ParseBool(os.Getenv("DEV_MODE"))
+	if !devMode {
+		return nil
+	}
+
+	for key, value := range devDefaults {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load sets up the config from command line flags, environmental variables, and a config file.
+// Flags win over variables, and variables win over the file, so a container can be configured with
+// variables alone. The file is named by -config or CONFIG_FILE, and otherwise .env is used if it exists.
+func Load(args []string, sources ...SecretSource) (*Config, error) {
+	w, err := NewWatcher(args, sources...)
+	if err != nil {
+		return nil, err
+	}
+	return w.Config(), nil
+}
+
+// NewWatcher loads the config as Load does, and keeps track of the file so it can be reloaded
+func NewWatcher(args []string, sources ...SecretSource) (*Watcher, error) {
+	flags := flag.NewFlagSet("expense-tracker-api", flag.ContinueOnError)
+	configFile := flags.String("config", "", "env or YAML config file, defaults to CONFIG_FILE or .env")
+	dev := flags.Bool("dev", false, "run against a seeded in-memory database with verbose logging (sets DEV_MODE)")
+	for _, fv := range flagVars {
+		flags.String(fv.name, "", fv.usage+" (overrides "+fv.key+")")
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+
+	// flags are set first, so neither the file nor the secret sources replace them
+	var setErr error
+	flags.Visit(func(f *flag.Flag) {
+		for _, fv := range flagVars {
+			if fv.name == f.Name && setErr == nil {
+				setErr = os.Setenv(fv.key, f.Value.String())
+			}
+		}
+	})
+	if setErr != nil {
+		return nil, setErr
+	}
+	if *dev {
+		if err := os.Setenv("DEV_MODE", "true"); err != nil {
+			return nil, err
+		}
+	}
+	if err := setDevDefaults(); err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		path:     *configFile,
+		required: true,
+		sources:  sources,
+		fileKeys: make(map[string]bool),
+	}
+	if w.path == "" {
+		w.path = os.Getenv("CONFIG_FILE")
+	}
+	if w.path == "" {
+		w.path, w.required = defaultConfigFile, false
+	}
+
+	// the first check only records the modification time
+	w.changed()
+
+	values, err := w.readFile()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.setFileValues(values); err != nil {
+		return nil, err
+	}
+
+	cfg, err := loadFromEnv(sources...)
+	if err != nil {
+		return nil, err
+	}
+	w.current = cfg
+
+	return w, nil
+}
+
+// loadFile sets the variables in a .env or YAML file that are not already set
+func loadFile(path string) error {
+	values, err := readFile(path)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range values {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readFile reads the variables in a .env or YAML file, picked by its extension
+func readFile(path string) (map[string]string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return readYAML(path)
+	default:
+		return godotenv.Read(path)
+	}
+}
+
+// readYAML reads a flat YAML file keyed by variable name, i.e. "LOCAL_PORT: 8080".
+// Lists are joined with commas, the same as the comma separated variables.
+func readYAML(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]any)
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch v := value.(type) {
+		case nil:
+			continue
+		case []any:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[key] = strings.Join(items, ",")
+		case map[string]any:
+			return nil, fmt.Errorf("invalid config file %s: %s must be a value or a list", path, key)
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+)
+
+// hotFields are the Config fields a reload applies, changes to any other field need a restart
+var hotFields = []string{
+	"LogLevel",
+	"RateLimitRequests",
+	"RateLimitWindow",
+	"CORSAllowedOrigins",
+	"CORSAllowedMethods",
+	"CORSAllowedHeaders",
+	"CORSMaxAge",
+}
+
+// Watcher reloads the config when its file changes.
+// Only variables that came from the file are reloaded, flags and the environment still win over it.
+type Watcher struct {
+	path     string
+	required bool
+	sources  []SecretSource
+
+	// fileKeys are the variables that were set from the file
+	fileKeys map[string]bool
+	modTime  time.Time
+
+	mux     sync.Mutex
+	current *Config
+}
+
+// Config returns the config as of the last successful reload
+func (w *Watcher) Config() *Config {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	return w.current
+}
+
+// readFile reads the config file, which is empty when an optional file does not exist
+func (w *Watcher) readFile() (map[string]string, error) {
+	values, err := readFile(w.path)
+	if err != nil {
+		if !w.required && errors.Is(err, fs.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	return values, nil
+}
+
+// setFileValues sets the variables from the file that are not set some other way,
+// and unsets the ones the file set before that it no longer has
+func (w *Watcher) setFileValues(values map[string]string) error {
+	for key := range w.fileKeys {
+		if _, ok := values[key]; ok {
+			continue
+		}
+		if err := os.Unsetenv(key); err != nil {
+			return err
+		}
+		delete(w.fileKeys, key)
+	}
+
+	for key, value := range values {
+		if _, ok := os.LookupEnv(key); ok && !w.fileKeys[key] {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+		w.fileKeys[key] = true
+	}
+
+	return nil
+}
+
+// changed reports whether the file has been modified, created, or removed since the last check
+func (w *Watcher) changed() bool {
+	var modTime time.Time
+	if info, err := os.Stat(w.path); err == nil {
+		modTime = info.ModTime()
+	}
+
+	if modTime.Equal(w.modTime) {
+		return false
+	}
+	w.modTime = modTime
+	return true
+}
+
+// Reload reads the config file again and applies the fields in hotFields.
+// It returns the new config and the names of the fields that changed but need a restart,
+// which keep their current values. An invalid file leaves the current config in place.
+func (w *Watcher) Reload() (*Config, []string, error) {
+	values, err := w.readFile()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := w.setFileValues(values); err != nil {
+		return nil, nil, err
+	}
+
+	next, err := loadFromEnv(w.sources...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	merged, refused := mergeHotFields(w.current, next)
+	w.current = merged
+	return merged, refused, nil
+}
+
+// Watch checks the config file every interval until ctx is done, calling apply after every reload.
+// Failed reloads and changes that need a restart are logged and otherwise ignored.
+func (w *Watcher) Watch(ctx context.Context, interval time.Duration, apply func(*Config)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !w.changed() {
+			continue
+		}
+
+		cfg, refused, err := w.Reload()
+		if err != nil {
+			log.Printf("Config reload failed, keeping the current config: %v", err)
+			continue
+		}
+		for _, field := range refused {
+			log.Printf("Config reload: %s changed but needs a restart, keeping the current value", field)
+		}
+
+		apply(cfg)
+	}
+}
+
+// mergeHotFields copies the hotFields of next over current, returning the fields that differ otherwise
+func mergeHotFields(current, next *Config) (*Config, []string) {
+	merged := *current
+	refused := make([]string, 0)
+
+	mergedValue := reflect.ValueOf(&merged).Elem()
+	currentValue := reflect.ValueOf(current).Elem()
+	nextValue := reflect.ValueOf(next).Elem()
+
+	for i := range currentValue.NumField() {
+		if reflect.DeepEqual(currentValue.Field(i).Interface(), nextValue.Field(i).Interface()) {
+			continue
+		}
+
+		name := currentValue.Type().Field(i).Name
+		if slices.Contains(hotFields, name) {
+			mergedValue.Field(i).Set(nextValue.Field(i))
+		} else {
+			refused = append(refused, name)
+		}
+	}
+
+	return &merged, refused
+}
@@ -0,0 +1,123 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/nicholasss/expense-tracker-api/config"
+)
+
+func TestWatcherReload(t *testing.T) {
+	envVarKeys := []string{
+		"LOCAL_ADDRESS",
+		"LOCAL_PORT",
+		"DB_PATH",
+		"GOOSE_DRIVER",
+		"RATE_LIMIT_REQUESTS",
+		"CORS_ALLOWED_ORIGINS",
+		"JOB_WORKERS",
+		"CONFIG_FILE",
+	}
+	unsetEnvVars(t, envVarKeys)
+	t.Cleanup(func() { unsetEnvVars(t, envVarKeys) })
+
+	base := `LOCAL_ADDRESS="localhost"
+DB_PATH="./expense-tracker.db"
+GOOSE_DRIVER="sqlite3"
+`
+	path := filepath.Join(t.TempDir(), "config.env")
+	if err := os.WriteFile(path, []byte(base+`LOCAL_PORT="8080"
+RATE_LIMIT_REQUESTS="10"
+JOB_WORKERS="2"`), 0o644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	// set outside of the file, so reloads never change it
+	if err := os.Setenv("JOB_WORKERS", "4"); err != nil {
+		t.Fatalf("unable to set JOB_WORKERS: %v", err)
+	}
+
+	watcher, err := config.NewWatcher([]string{"-config", path})
+	if err != nil {
+		t.Fatalf("NewWatcher() got error: '%v'", err)
+	}
+
+	testTable := []struct {
+		name        string
+		inputFile   string
+		expectError bool
+		wantRate    int
+		wantOrigins []string
+		wantPort    string
+		wantWorkers int
+		wantRefused []string
+	}{
+		{
+			name: "valid-hot-fields-applied",
+			inputFile: base + `LOCAL_PORT="8080"
+RATE_LIMIT_REQUESTS="20"
+CORS_ALLOWED_ORIGINS="https://app.example.com"
+JOB_WORKERS="8"`,
+			expectError: false,
+			wantRate:    20,
+			wantOrigins: []string{"https://app.example.com"},
+			wantPort:    "8080",
+			wantWorkers: 4,
+			wantRefused: []string{},
+		},
+		{
+			name: "valid-restart-fields-refused",
+			inputFile: base + `LOCAL_PORT="9090"
+RATE_LIMIT_REQUESTS="30"`,
+			expectError: false,
+			wantRate:    30,
+			wantOrigins: nil,
+			wantPort:    "8080",
+			wantWorkers: 4,
+			wantRefused: []string{"LocalPort", "Address"},
+		},
+		{
+			name: "invalid-value-keeps-config",
+			inputFile: base + `LOCAL_PORT="8080"
+RATE_LIMIT_REQUESTS="lots"`,
+			expectError: true,
+			wantRate:    30,
+			wantOrigins: nil,
+			wantPort:    "8080",
+			wantWorkers: 4,
+		},
+	}
+
+	// runs in order, each reload starts from the previous config
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			if err := os.WriteFile(path, []byte(testCase.inputFile), 0o644); err != nil {
+				t.Fatalf("failed to write config file: %v", err)
+			}
+
+			_, gotRefused, gotErr := watcher.Reload()
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("Reload() got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+			if gotErr == nil && !slices.Equal(gotRefused, testCase.wantRefused) {
+				t.Errorf("got refused: %v, want refused: %v", gotRefused, testCase.wantRefused)
+			}
+
+			got := watcher.Config()
+			if got.RateLimitRequests != testCase.wantRate {
+				t.Errorf("conf.RateLimitRequests does not match. got: '%v', want: '%v'", got.RateLimitRequests, testCase.wantRate)
+			}
+			if !slices.Equal(got.CORSAllowedOrigins, testCase.wantOrigins) {
+				t.Errorf("conf.CORSAllowedOrigins does not match. got: '%v', want: '%v'", got.CORSAllowedOrigins, testCase.wantOrigins)
+			}
+			if got.LocalPort != testCase.wantPort {
+				t.Errorf("conf.LocalPort does not match. got: '%v', want: '%v'", got.LocalPort, testCase.wantPort)
+			}
+			if got.JobWorkers != testCase.wantWorkers {
+				t.Errorf("conf.JobWorkers does not match. got: '%v', want: '%v'", got.JobWorkers, testCase.wantWorkers)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// secretKeys are the variables that can come from a secret source instead of the .env file
+var secretKeys = []string{
+	"DB_PATH",
+	"MONGODB_URI",
+	"JWT_SECRET",
+	"BANK_SECRET_ID",
+	"BANK_SECRET_KEY",
+	"OIDC_CLIENT_SECRET",
+	"SLACK_SIGNING_SECRET",
+	"SLACK_WEBHOOK_URL",
+	"SMTP_PASSWORD",
+	"WEBHOOK_SECRET",
+	"FIELD_ENCRYPTION_KEYS",
+	"PPROF_TOKEN",
+	"ERROR_REPORTING_DSN",
+}
+
+// secretLookupTimeout bounds how long startup waits on remote secret managers
+const secretLookupTimeout = 15 * time.Second
+
+// SecretSource provides values for secret variables, such as a secret manager.
+// ok is false when the source does not have key.
+type SecretSource interface {
+	Name() string
+	Lookup(ctx context.Context, key string) (value string, ok bool, err error)
+}
+
+// SecretSourceError is returned when a secret source can't be read
+type SecretSourceError struct {
+	Source string
+	Err    error
+}
+
+func (e *SecretSourceError) Error() string {
+	return fmt.Sprintf("unable to load secrets from %s: %v", e.Source, e.Err)
+}
+
+// Unwrap implementing for errors.Is()
+func (e *SecretSourceError) Unwrap() error { return e.Err }
+
+// secretSourcesFromEnv creates the sources configured with environmental variables.
+// The KEY_FILE convention is always checked first.
+func secretSourcesFromEnv() []SecretSource {
+	sources := []SecretSource{&FileRefSecrets{}}
+
+	if dir := os.Getenv("SECRETS_DIR"); dir != "" {
+		sources = append(sources, &DirSecrets{Dir: dir})
+	}
+	if addr := os.Getenv("VAULT_ADDR"); addr != "" {
+		sources = append(sources, NewVaultSecrets(addr, os.Getenv("VAULT_TOKEN"), os.Getenv("VAULT_SECRET_PATH")))
+	}
+	if secretID := os.Getenv("AWS_SECRET_NAME"); secretID != "" {
+		sources = append(sources, NewAWSSecrets(os.Getenv("AWS_REGION"), secretID,
+			os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN")))
+	}
+
+	return sources
+}
+
+// resolveSecrets fills in unset secret variables from the first source that has them.
+// Variables that are already set always win, so the .env file can override a source.
+func resolveSecrets(ctx context.Context, sources []SecretSource) error {
+	ctx, cancel := context.WithTimeout(ctx, secretLookupTimeout)
+	defer cancel()
+
+	for _, key := range secretKeys {
+		if os.Getenv(key) != "" {
+			continue
+		}
+
+		for _, source := range sources {
+			value, ok, err := source.Lookup(ctx, key)
+			if err != nil {
+				return &SecretSourceError{Source: source.Name(), Err: err}
+			}
+			if !ok {
+				continue
+			}
+
+			if err := os.Setenv(key, value); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
+// readSecretFile reads a secret, without the trailing newline most editors and tools add
+func readSecretFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// FileRefSecrets reads KEY from the file named by KEY_FILE, i.e. JWT_SECRET_FILE=/run/secrets/jwt
+type FileRefSecrets struct{}
+
+func (s *FileRefSecrets) Name() string { return "_FILE variables" }
+
+func (s *FileRefSecrets) Lookup(ctx context.Context, key string) (string, bool, error) {
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return "", false, nil
+	}
+
+	value, err := readSecretFile(path)
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// DirSecrets reads KEY from a file in Dir named key or KEY, i.e. Docker secrets in /run/secrets
+type DirSecrets struct {
+	Dir string
+}
+
+func (s *DirSecrets) Name() string { return "secrets directory " + s.Dir }
+
+func (s *DirSecrets) Lookup(ctx context.Context, key string) (string, bool, error) {
+	for _, name := range []string{strings.ToLower(key), key} {
+		value, err := readSecretFile(filepath.Join(s.Dir, name))
+		if err == nil {
+			return value, true, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", false, err
+		}
+	}
+	return "", false, nil
+}
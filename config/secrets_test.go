@@ -0,0 +1,193 @@
+package config_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nicholasss/expense-tracker-api/config"
+)
+
+// baseConfig has every required variable except the secrets
+const baseConfig = `export LOCAL_ADDRESS="localhost"
+export LOCAL_PORT="8080"
+export GOOSE_DRIVER="sqlite3"
+export MONGODB_URI="mongodb://localhost:27017"
+`
+
+var secretEnvKeys = []string{
+	"LOCAL_ADDRESS", "LOCAL_PORT", "GOOSE_DRIVER", "MONGODB_URI",
+	"DB_PATH", "DB_PATH_FILE", "JWT_SECRET", "JWT_SECRET_FILE", "AUTH_ENABLED",
+	"SECRETS_DIR", "VAULT_ADDR", "VAULT_TOKEN", "VAULT_SECRET_PATH", "AWS_SECRET_NAME",
+}
+
+// writeEnvFile writes contents to a .env file in a temporary directory
+func writeEnvFile(t *testing.T, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("failed to write env file: %v", err)
+	}
+	return path
+}
+
+func TestSecretSources(t *testing.T) {
+	secretsDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(secretsDir, "db_path"), []byte("./from-dir.db\n"), 0o600); err != nil {
+		t.Fatalf("failed to write secret: %v", err)
+	}
+	jwtFile := filepath.Join(t.TempDir(), "jwt")
+	if err := os.WriteFile(jwtFile, []byte("a-jwt-secret-from-a-file-32-bytes\n"), 0o600); err != nil {
+		t.Fatalf("failed to write secret: %v", err)
+	}
+
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/expense-tracker" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+			"data":     map[string]string{"DB_PATH": "./from-vault.db", "JWT_SECRET": "a-jwt-secret-from-vault-32-bytes!"},
+			"metadata": map[string]any{"version": 1},
+		}})
+	}))
+	defer vault.Close()
+
+	testTable := []struct {
+		name        string
+		inputConfig string
+		expectError bool
+		wantError   error
+		wantSource  bool // the error comes from a secret source
+		wantDB      string
+		wantJWT     string
+	}{
+		{
+			name:        "valid-secrets-dir-and-file",
+			inputConfig: baseConfig + `export SECRETS_DIR="` + secretsDir + `"` + "\n" + `export JWT_SECRET_FILE="` + jwtFile + `"`,
+			expectError: false,
+			wantError:   nil,
+			wantDB:      "./from-dir.db",
+			wantJWT:     "a-jwt-secret-from-a-file-32-bytes",
+		},
+		{
+			name:        "valid-env-overrides-source",
+			inputConfig: baseConfig + `export DB_PATH="./from-env.db"` + "\n" + `export SECRETS_DIR="` + secretsDir + `"`,
+			expectError: false,
+			wantError:   nil,
+			wantDB:      "./from-env.db",
+		},
+		{
+			name: "valid-vault",
+			inputConfig: baseConfig + `export VAULT_ADDR="` + vault.URL + `"
+export VAULT_TOKEN="vault-token"
+export VAULT_SECRET_PATH="secret/data/expense-tracker"`,
+			expectError: false,
+			wantError:   nil,
+			wantDB:      "./from-vault.db",
+			wantJWT:     "a-jwt-secret-from-vault-32-bytes!",
+		},
+		{
+			name: "invalid-vault-token",
+			inputConfig: baseConfig + `export VAULT_ADDR="` + vault.URL + `"
+export VAULT_TOKEN="wrong-token"
+export VAULT_SECRET_PATH="secret/data/expense-tracker"`,
+			expectError: true,
+			wantError:   nil,
+			wantSource:  true,
+		},
+		{
+			name:        "invalid-missing-file",
+			inputConfig: baseConfig + `export DB_PATH_FILE="` + filepath.Join(secretsDir, "missing") + `"`,
+			expectError: true,
+			wantError:   nil,
+			wantSource:  true,
+		},
+		{
+			name:        "invalid-no-source-has-secret",
+			inputConfig: baseConfig,
+			expectError: true,
+			wantError:   &config.MissingVariableError{},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			unsetEnvVars(t, secretEnvKeys)
+			defer unsetEnvVars(t, secretEnvKeys)
+
+			gotConfig, gotErr := config.LoadConfig(writeEnvFile(t, testCase.inputConfig))
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("LoadConfig() got error: '%v', expected error: '%v'", gotErr, testCase.wantError)
+			}
+
+			// checking error type if its not nil
+			if gotErr != nil {
+				var sourceErr *config.SecretSourceError
+				if errors.As(gotErr, &sourceErr) != testCase.wantSource {
+					t.Errorf("got error: %v, want secret source error: %v", gotErr, testCase.wantSource)
+				}
+				if testCase.wantError != nil && !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+				return
+			}
+
+			if gotConfig.DBString != testCase.wantDB {
+				t.Errorf("DBString does not match. got: %q, want: %q", gotConfig.DBString, testCase.wantDB)
+			}
+			if gotConfig.JWTSecret != testCase.wantJWT {
+				t.Errorf("JWTSecret does not match. got: %q, want: %q", gotConfig.JWTSecret, testCase.wantJWT)
+			}
+		})
+	}
+}
+
+func TestAWSSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+			!strings.Contains(auth, "/us-east-1/secretsmanager/aws4_request") ||
+			!strings.Contains(auth, "SignedHeaders=content-type;host;x-amz-date;x-amz-target") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var in struct{ SecretId string }
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.SecretId != "expense-tracker" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"JWT_SECRET":"from-aws"}`})
+	}))
+	defer server.Close()
+
+	source := config.NewAWSSecrets("us-east-1", "expense-tracker", "AKIDEXAMPLE", "secret-key", "")
+	source.Endpoint = server.URL
+
+	got, ok, err := source.Lookup(t.Context(), "JWT_SECRET")
+	if err != nil || !ok || got != "from-aws" {
+		t.Errorf("Lookup(JWT_SECRET) got: %q, %v, %v, want: %q", got, ok, err, "from-aws")
+	}
+
+	_, ok, err = source.Lookup(t.Context(), "DB_PATH")
+	if err != nil || ok {
+		t.Errorf("Lookup(DB_PATH) got: %v, %v, want not found", ok, err)
+	}
+}
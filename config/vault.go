@@ -0,0 +1,91 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VaultSecrets reads secrets from a HashiCorp Vault KV secret, one field per variable.
+// Path is the API path after /v1/, i.e. "secret/data/expense-tracker" for KV version 2.
+type VaultSecrets struct {
+	Addr   string
+	Token  string
+	Path   string
+	Client *http.Client
+
+	once   sync.Once
+	values map[string]string
+	err    error
+}
+
+// NewVaultSecrets creates a source using a token, i.e. from VAULT_TOKEN
+func NewVaultSecrets(addr, token, path string) *VaultSecrets {
+	return &VaultSecrets{
+		Addr:   strings.TrimSuffix(addr, "/"),
+		Token:  token,
+		Path:   strings.Trim(path, "/"),
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *VaultSecrets) Name() string { return "vault " + s.Path }
+
+// Lookup reads the whole secret once, then serves every key from it
+func (s *VaultSecrets) Lookup(ctx context.Context, key string) (string, bool, error) {
+	s.once.Do(func() { s.values, s.err = s.fetch(ctx) })
+	if s.err != nil {
+		return "", false, s.err
+	}
+
+	value, ok := s.values[key]
+	return value, ok, nil
+}
+
+func (s *VaultSecrets) fetch(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.Addr+"/v1/"+s.Path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", s.Token)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	// KV version 2 nests the fields under data.data, version 1 has them straight under data
+	fields := body.Data
+	if nested, ok := body.Data["data"]; ok {
+		fields = nil
+		if err := json.Unmarshal(nested, &fields); err != nil {
+			return nil, err
+		}
+	}
+
+	values := make(map[string]string, len(fields))
+	for k, raw := range fields {
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("field %q is not a string", k)
+		}
+		values[k] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,53 @@
+// Package audit records security relevant events, such as logins and denied requests.
+// It is kept apart from expense data so the trail survives changes to the records themselves.
+package audit
+
+import "time"
+
+// Type names what happened
+type Type string
+
+const (
+	TypeLoginSucceeded Type = "login.succeeded"
+	TypeLoginFailed    Type = "login.failed"
+	TypeUnauthorized   Type = "access.unauthorized" // missing or invalid credentials
+	TypeForbidden      Type = "access.forbidden"    // valid credentials, not allowed
+
+	TypeImpersonationStarted Type = "impersonation.started" // an admin was issued a token for another user
+	TypeImpersonatedRequest  Type = "impersonation.request" // any request made with that token
+
+	TypeRetentionPurged Type = "retention.purged" // records older than a retention rule allows were removed
+)
+
+// RecordedKey is set on the gin context by handlers that record their own event,
+// so the middleware does not record the same request twice
+const RecordedKey = "audit.recorded"
+
+// Event is a single audit record. UserID is 0 when the user is not known,
+// ActorID is the admin acting on behalf of UserID and 0 otherwise
+type Event struct {
+	ID        int
+	Type      Type
+	UserID    int
+	ActorID   int
+	Email     string // the attempted email for failed logins
+	IP        string // the client, as resolved through the trusted proxies
+	RemoteIP  string // the connection's address, which no header can change
+	UserAgent string
+	Method    string
+	Path      string
+	Status    int
+	Detail    string
+	CreatedAt time.Time
+}
+
+// Filter narrows a listing, zero values match everything
+type Filter struct {
+	Type    Type
+	UserID  int
+	ActorID int
+	From    time.Time // inclusive
+	To      time.Time // exclusive
+	Limit   int
+	Offset  int
+}
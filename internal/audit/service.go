@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"context"
+	"log"
+)
+
+type Repository interface {
+	// store the event, setting its id and created time
+	Insert(ctx context.Context, event *Event) error
+
+	// list events newest first
+	List(ctx context.Context, filter Filter) ([]*Event, error)
+}
+
+// Service defines an interface for the audit business layer.
+//
+// This is primarily implemented for easier mocking for testing.
+type Service interface {
+	// Record never fails the request it is called from, errors are logged
+	Record(ctx context.Context, event Event)
+
+	List(ctx context.Context, filter Filter) ([]*Event, error)
+}
+
+type AuditService struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// Record stores the event even if the request that caused it was cancelled
+func (s *AuditService) Record(ctx context.Context, event Event) {
+	err := s.repo.Insert(context.WithoutCancel(ctx), &event)
+	if err != nil {
+		log.Printf("unable to record audit event %q: %v", event.Type, err)
+	}
+}
+
+func (s *AuditService) List(ctx context.Context, filter Filter) ([]*Event, error) {
+	return s.repo.List(ctx, filter)
+}
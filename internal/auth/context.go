@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// contextKey is unexported so only this package can set the values
+type contextKey int
+
+const (
+	userIDKey contextKey = iota
+	scopesKey
+	actorIDKey
+)
+
+// WithUserID returns a copy of ctx carrying the authenticated user's id
+func WithUserID(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext returns the authenticated user's id, ok is false for anonymous requests
+func UserIDFromContext(ctx context.Context) (int, bool) {
+	userID, ok := ctx.Value(userIDKey).(int)
+	return userID, ok
+}
+
+// WithScopes returns a copy of ctx limited to scopes, for requests made with a scoped token
+func WithScopes(ctx context.Context, scopes []Scope) context.Context {
+	return context.WithValue(ctx, scopesKey, scopes)
+}
+
+// HasScope reports whether the request may use scope.
+// Requests without scopes, from unscoped tokens or with auth disabled, may use every scope.
+func HasScope(ctx context.Context, scope Scope) bool {
+	scopes, ok := ctx.Value(scopesKey).([]Scope)
+	if !ok {
+		return true
+	}
+	return slices.Contains(scopes, scope)
+}
+
+// WithActorID returns a copy of ctx recording that actorID is acting on behalf of the user
+func WithActorID(ctx context.Context, actorID int) context.Context {
+	return context.WithValue(ctx, actorIDKey, actorID)
+}
+
+// ActorIDFromContext returns the admin impersonating the user, ok is false for the user's own requests
+func ActorIDFromContext(ctx context.Context) (int, bool) {
+	actorID, ok := ctx.Value(actorIDKey).(int)
+	return actorID, ok
+}
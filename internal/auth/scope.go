@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Scope limits what a token can be used for.
+// Tokens from logging in have no scopes and can do everything, scoped tokens only what they list.
+type Scope string
+
+const (
+	ScopeExpensesRead   Scope = "expenses:read"   // list and fetch expenses, exports, and bank drafts
+	ScopeExpensesCreate Scope = "expenses:create" // record new expenses, i.e. for an ingestion script
+	ScopeExpensesWrite  Scope = "expenses:write"  // change or delete expenses, and handle bank drafts
+	ScopeSummariesRead  Scope = "summaries:read"  // totals only, i.e. for a dashboard
+
+	// ScopeAccount guards account, household, and admin routes.
+	// It can't be issued, so only unscoped tokens ever have it.
+	ScopeAccount Scope = "account"
+)
+
+// IssuableScopes are the scopes a user can put on a token they issue
+var IssuableScopes = []Scope{ScopeExpensesRead, ScopeExpensesCreate, ScopeExpensesWrite, ScopeSummariesRead}
+
+// ErrInvalidScope is returned by ParseScopes() for unknown scopes, or when there are none
+var ErrInvalidScope = errors.New("scopes need to be one or more of: expenses:read, expenses:create, expenses:write, summaries:read")
+
+// ParseScopes validates requested scopes, returning them sorted without duplicates
+func ParseScopes(raw []string) ([]Scope, error) {
+	scopes := make([]Scope, 0, len(raw))
+	for _, item := range raw {
+		scope := Scope(strings.TrimSpace(item))
+		if !slices.Contains(IssuableScopes, scope) {
+			return nil, ErrInvalidScope
+		}
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 0 {
+		return nil, ErrInvalidScope
+	}
+
+	slices.Sort(scopes)
+	return slices.Compact(scopes), nil
+}
+
+// joinScopes is the space separated form used in the scope claim
+func joinScopes(scopes []Scope) string {
+	parts := make([]string, len(scopes))
+	for i, scope := range scopes {
+		parts[i] = string(scope)
+	}
+	return strings.Join(parts, " ")
+}
+
+// splitScopes parses the scope claim, an empty claim is an unscoped token
+func splitScopes(claim string) []Scope {
+	fields := strings.Fields(claim)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	scopes := make([]Scope, len(fields))
+	for i, field := range fields {
+		scopes[i] = Scope(field)
+	}
+	return scopes
+}
@@ -0,0 +1,191 @@
+// Package auth issues and verifies the HS256 JWTs used to authenticate requests,
+// and carries the authenticated user through the request context
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Issuer is set as the iss claim, and required when verifying
+const Issuer = "expense-tracker-api"
+
+// MinSecretLength is the shortest signing secret accepted, 32 bytes for HS256
+const MinSecretLength = 32
+
+// ErrInvalidToken is returned for malformed tokens, bad signatures, or unexpected claims
+var ErrInvalidToken = errors.New("token is invalid")
+
+// ErrExpiredToken is returned for tokens past their exp claim
+var ErrExpiredToken = errors.New("token has expired")
+
+// ErrRevokedToken is returned for tokens from before the user's last "log out everywhere"
+var ErrRevokedToken = errors.New("token has been revoked")
+
+// ErrShortSecret is returned when the signing secret is under MinSecretLength
+var ErrShortSecret = errors.New("token signing secret needs to be at least 32 bytes")
+
+// Claims is the JWT payload
+type Claims struct {
+	Subject   string `json:"sub"`
+	Issuer    string `json:"iss"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+
+	// Generation is the user's token generation when issued, tokens from an older one are revoked
+	Generation int `json:"gen,omitempty"`
+
+	// Scope is space separated, tokens without it are unscoped and can do everything
+	Scope string `json:"scope,omitempty"`
+
+	// Actor is the admin impersonating Subject, nil for the user's own tokens
+	Actor *Actor `json:"act,omitempty"`
+}
+
+// Actor is the act claim from RFC 8693, naming who is really behind the token
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// ActorID parses the actor claim back into a user id, ok is false when there is no actor
+func (c *Claims) ActorID() (int, bool, error) {
+	if c.Actor == nil {
+		return 0, false, nil
+	}
+	id, err := strconv.Atoi(c.Actor.Subject)
+	if err != nil || id <= 0 {
+		return 0, false, ErrInvalidToken
+	}
+	return id, true, nil
+}
+
+// Scopes is what the token is limited to, nil for an unscoped token
+func (c *Claims) Scopes() []Scope {
+	return splitScopes(c.Scope)
+}
+
+// UserID parses the subject claim back into a user id
+func (c *Claims) UserID() (int, error) {
+	id, err := strconv.Atoi(c.Subject)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidToken
+	}
+	return id, nil
+}
+
+// jwtHeader is the only header we issue or accept
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// TokenIssuer signs and verifies tokens with a shared secret
+type TokenIssuer struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewTokenIssuer creates an issuer whose tokens are valid for ttl
+func NewTokenIssuer(secret []byte, ttl time.Duration) (*TokenIssuer, error) {
+	if len(secret) < MinSecretLength {
+		return nil, ErrShortSecret
+	}
+	return &TokenIssuer{secret: secret, ttl: ttl, now: time.Now}, nil
+}
+
+// sign returns the base64url HMAC-SHA256 of the signing input
+func (t *TokenIssuer) sign(signingInput string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Issue creates a signed token for the user at their current token generation,
+// returning it with when it expires
+func (t *TokenIssuer) Issue(userID, generation int) (string, time.Time, error) {
+	return t.IssueScoped(userID, generation, nil, t.ttl)
+}
+
+// IssueScoped creates a token limited to scopes, valid for ttl instead of the issuer's default.
+// No scopes is an unscoped token, so callers check scopes with ParseScopes() first.
+func (t *TokenIssuer) IssueScoped(userID, generation int, scopes []Scope, ttl time.Duration) (string, time.Time, error) {
+	return t.issue(Claims{
+		Subject:    strconv.Itoa(userID),
+		Generation: generation,
+		Scope:      joinScopes(scopes),
+	}, ttl)
+}
+
+// IssueImpersonation creates a scoped token for userID that records actorID as the admin behind it
+func (t *TokenIssuer) IssueImpersonation(actorID, userID, generation int, scopes []Scope, ttl time.Duration) (string, time.Time, error) {
+	return t.issue(Claims{
+		Subject:    strconv.Itoa(userID),
+		Generation: generation,
+		Scope:      joinScopes(scopes),
+		Actor:      &Actor{Subject: strconv.Itoa(actorID)},
+	}, ttl)
+}
+
+// issue fills in the issuer and times, then signs the claims
+func (t *TokenIssuer) issue(claims Claims, ttl time.Duration) (string, time.Time, error) {
+	now := t.now()
+	expiresAt := now.Add(ttl)
+
+	claims.Issuer = Issuer
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = expiresAt.Unix()
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	signingInput := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + t.sign(signingInput), expiresAt, nil
+}
+
+// TTL is how long tokens from Issue() are valid for
+func (t *TokenIssuer) TTL() time.Duration {
+	return t.ttl
+}
+
+// Verify checks the signature, issuer, and expiry of a token, returning its claims
+func (t *TokenIssuer) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	// only our own header is accepted, which rules out alg=none and friends
+	if parts[0] != jwtHeader {
+		return nil, ErrInvalidToken
+	}
+
+	signingInput := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(t.sign(signingInput))) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.Issuer != Issuer {
+		return nil, ErrInvalidToken
+	}
+	if t.now().Unix() >= claims.ExpiresAt {
+		return nil, ErrExpiredToken
+	}
+
+	return &claims, nil
+}
@@ -0,0 +1,207 @@
+package auth_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func TestNewTokenIssuer(t *testing.T) {
+	_, err := auth.NewTokenIssuer([]byte("too-short"), time.Hour)
+	if !errors.Is(err, auth.ErrShortSecret) {
+		t.Errorf("got error: '%v', want error: '%v'", err, auth.ErrShortSecret)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	issuer, err := auth.NewTokenIssuer([]byte(testSecret), time.Hour)
+	if err != nil {
+		t.Fatalf("unable to create issuer: %v", err)
+	}
+	expiredIssuer, err := auth.NewTokenIssuer([]byte(testSecret), -time.Minute)
+	if err != nil {
+		t.Fatalf("unable to create issuer: %v", err)
+	}
+	otherIssuer, err := auth.NewTokenIssuer([]byte("fedcba9876543210fedcba9876543210"), time.Hour)
+	if err != nil {
+		t.Fatalf("unable to create issuer: %v", err)
+	}
+
+	validToken, _, err := issuer.Issue(42, 0)
+	if err != nil {
+		t.Fatalf("unable to issue token: %v", err)
+	}
+	expiredToken, _, _ := expiredIssuer.Issue(42, 0)
+	otherToken, _, _ := otherIssuer.Issue(42, 0)
+
+	// swap the payload for one claiming to be another user
+	parts := strings.Split(validToken, ".")
+	forgedToken, _, _ := issuer.Issue(7, 0)
+	forgedParts := strings.Split(forgedToken, ".")
+	tamperedToken := parts[0] + "." + forgedParts[1] + "." + parts[2]
+
+	testTable := []struct {
+		name        string
+		inputToken  string
+		expectError bool
+		wantError   error
+		wantUserID  int
+	}{
+		{
+			name:        "valid-token",
+			inputToken:  validToken,
+			expectError: false,
+			wantError:   nil,
+			wantUserID:  42,
+		},
+		{
+			name:        "invalid-expired",
+			inputToken:  expiredToken,
+			expectError: true,
+			wantError:   auth.ErrExpiredToken,
+		},
+		{
+			name:        "invalid-other-secret",
+			inputToken:  otherToken,
+			expectError: true,
+			wantError:   auth.ErrInvalidToken,
+		},
+		{
+			name:        "invalid-tampered-payload",
+			inputToken:  tamperedToken,
+			expectError: true,
+			wantError:   auth.ErrInvalidToken,
+		},
+		{
+			name:        "invalid-alg-none",
+			inputToken:  "eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0." + parts[1] + ".",
+			expectError: true,
+			wantError:   auth.ErrInvalidToken,
+		},
+		{
+			name:        "invalid-garbage",
+			inputToken:  "not-a-token",
+			expectError: true,
+			wantError:   auth.ErrInvalidToken,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			gotClaims, gotErr := issuer.Verify(testCase.inputToken)
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("Verify() got error: '%v', expected error: '%v'", gotErr, testCase.wantError)
+			}
+
+			// checking error type if its not nil
+			if gotErr != nil {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+				return
+			}
+
+			gotUserID, err := gotClaims.UserID()
+			if err != nil || gotUserID != testCase.wantUserID {
+				t.Errorf("got user id: %d, error: '%v', want user id: %d", gotUserID, err, testCase.wantUserID)
+			}
+		})
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputScopes []string
+		expectError bool
+		wantError   error
+		wantScopes  []auth.Scope
+	}{
+		{
+			name:        "valid-sorted-and-deduplicated",
+			inputScopes: []string{"summaries:read", " expenses:read", "summaries:read"},
+			expectError: false,
+			wantError:   nil,
+			wantScopes:  []auth.Scope{auth.ScopeExpensesRead, auth.ScopeSummariesRead},
+		},
+		{
+			name:        "invalid-empty",
+			inputScopes: []string{},
+			expectError: true,
+			wantError:   auth.ErrInvalidScope,
+		},
+		{
+			name:        "invalid-unknown-scope",
+			inputScopes: []string{"expenses:read", "expenses:everything"},
+			expectError: true,
+			wantError:   auth.ErrInvalidScope,
+		},
+		{
+			name:        "invalid-account-not-issuable",
+			inputScopes: []string{"account"},
+			expectError: true,
+			wantError:   auth.ErrInvalidScope,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			gotScopes, gotErr := auth.ParseScopes(testCase.inputScopes)
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("ParseScopes() got error: '%v', expected error: '%v'", gotErr, testCase.wantError)
+			}
+
+			// checking error type if its not nil
+			if gotErr != nil {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+				return
+			}
+
+			if !slices.Equal(gotScopes, testCase.wantScopes) {
+				t.Errorf("ParseScopes() got: %v, want: %v", gotScopes, testCase.wantScopes)
+			}
+		})
+	}
+}
+
+func TestIssueScoped(t *testing.T) {
+	issuer, err := auth.NewTokenIssuer([]byte(testSecret), time.Hour)
+	if err != nil {
+		t.Fatalf("unable to create issuer: %v", err)
+	}
+
+	scopes := []auth.Scope{auth.ScopeExpensesCreate}
+	token, expiresAt, err := issuer.IssueScoped(42, 3, scopes, 48*time.Hour)
+	if err != nil {
+		t.Fatalf("IssueScoped() got error: '%v'", err)
+	}
+	if time.Until(expiresAt) < 47*time.Hour {
+		t.Errorf("IssueScoped() expires at: %v, want about 48h from now", expiresAt)
+	}
+
+	claims, err := issuer.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify() got error: '%v'", err)
+	}
+	if !slices.Equal(claims.Scopes(), scopes) || claims.Generation != 3 {
+		t.Errorf("Verify() got claims: %+v", claims)
+	}
+
+	// login tokens stay unscoped
+	token, _, _ = issuer.Issue(42, 0)
+	if claims, _ := issuer.Verify(token); claims.Scopes() != nil {
+		t.Errorf("Issue() got scopes: %v, want none", claims.Scopes())
+	}
+}
@@ -0,0 +1,175 @@
+package banksync_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/nicholasss/expense-tracker-api/internal/banksync"
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+	"github.com/nicholasss/expense-tracker-api/internal/sqlite"
+)
+
+// fakeProvider returns the same transactions on every sync
+type fakeProvider struct {
+	txns []banksync.Transaction
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) Transactions(ctx context.Context, since time.Time) ([]banksync.Transaction, error) {
+	return p.txns, nil
+}
+
+// setupTestService creates a sync service backed by an in-memory sqlite database
+func setupTestService(t *testing.T, provider banksync.Provider) *banksync.SyncService {
+	t.Helper()
+
+	repo, err := sqlite.NewSqliteRepository("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+
+	t.Cleanup(func() {
+		if err := repo.DB.Close(); err != nil {
+			t.Errorf("unable to close connection to in-memory sqlite database: %v", err)
+		}
+	})
+
+	createQuery := `
+  CREATE TABLE
+    expenses (
+      id INTEGER PRIMARY KEY,
+      owner_id INTEGER,
+      household_id INTEGER,
+      created_at INTEGER,
+      updated_at INTEGER NOT NULL DEFAULT 0,
+      occured_at INTEGER,
+      occured_zone TEXT NOT NULL DEFAULT '',
+      description TEXT,
+      description_key TEXT NOT NULL DEFAULT '',
+      amount INTEGER,
+      category_id INTEGER,
+      version INTEGER NOT NULL DEFAULT 1,
+      client_id TEXT
+    );
+  CREATE TABLE
+    expense_tombstones (
+      expense_id INTEGER PRIMARY KEY,
+      owner_id INTEGER,
+      household_id INTEGER,
+      deleted_at INTEGER NOT NULL
+    );
+  CREATE TABLE
+    bank_drafts (
+      id INTEGER PRIMARY KEY,
+      provider TEXT NOT NULL,
+      external_id TEXT NOT NULL,
+      created_at INTEGER,
+      occured_at INTEGER,
+      description TEXT,
+      amount INTEGER,
+      currency TEXT NOT NULL DEFAULT '',
+      status TEXT NOT NULL DEFAULT 'pending',
+      expense_id INTEGER,
+      UNIQUE (provider, external_id)
+    );`
+	if _, err := repo.DB.Exec(createQuery); err != nil {
+		t.Fatalf("unable to create tables: %v", err)
+	}
+
+	expenseService := expenses.NewService(repo)
+	return banksync.NewService(provider, sqlite.NewDraftRepository(repo.DB, repo.Writer), expenseService)
+}
+
+func TestSyncAndResolveDrafts(t *testing.T) {
+	provider := &fakeProvider{txns: []banksync.Transaction{
+		{ExternalID: "txn-1", BookedAt: time.Unix(1761231600, 0), Description: "corner bakery", Amount: money.New(450, "USD")},
+		{ExternalID: "txn-2", BookedAt: time.Unix(1761318000, 0), Description: "city parking", Amount: money.New(1200, "USD")},
+	}}
+	serv := setupTestService(t, provider)
+
+	// first sync creates both, second sync creates none
+	created, err := serv.Sync(t.Context())
+	if err != nil || created != 2 {
+		t.Fatalf("first Sync() got created: %d, error: '%v', want created: 2", created, err)
+	}
+	created, err = serv.Sync(t.Context())
+	if err != nil || created != 0 {
+		t.Fatalf("second Sync() got created: %d, error: '%v', want created: 0", created, err)
+	}
+
+	// confirm the first, dismiss the second
+	exp, err := serv.ConfirmDraft(t.Context(), 1)
+	if err != nil {
+		t.Fatalf("ConfirmDraft(1) got error: '%v'", err)
+	}
+	if exp.Amount != money.New(450, "USD") || exp.Description != "corner bakery" {
+		t.Errorf("confirmed expense does not match draft. got: %+v", exp)
+	}
+	if err := serv.DismissDraft(t.Context(), 2); err != nil {
+		t.Fatalf("DismissDraft(2) got error: '%v'", err)
+	}
+
+	pending, err := serv.PendingDrafts(t.Context())
+	if err != nil || len(pending) != 0 {
+		t.Errorf("PendingDrafts() got %d drafts, error: '%v', want 0 drafts", len(pending), err)
+	}
+
+	// resolving twice or resolving nothing
+	if _, err := serv.ConfirmDraft(t.Context(), 1); !errors.Is(err, banksync.ErrDraftNotPending) {
+		t.Errorf("ConfirmDraft(1) again got error: '%v', want error: '%v'", err, banksync.ErrDraftNotPending)
+	}
+	if err := serv.DismissDraft(t.Context(), 9); !errors.Is(err, banksync.ErrDraftNotFound) {
+		t.Errorf("DismissDraft(9) got error: '%v', want error: '%v'", err, banksync.ErrDraftNotFound)
+	}
+}
+
+func TestGoCardlessTransactions(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token/new/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"access": "test-token"}`))
+	})
+	mux.HandleFunc("GET /accounts/acc-1/transactions/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"transactions": {"booked": [
+      {"transactionId": "a", "bookingDate": "2025-10-20", "creditorName": "Grocer", "transactionAmount": {"amount": "-23.5", "currency": "USD"}},
+      {"transactionId": "b", "bookingDate": "2025-10-21", "remittanceInformationUnstructured": "salary", "transactionAmount": {"amount": "1500.00", "currency": "USD"}},
+      {"transactionId": "c", "bookingDate": "2025-10-22", "remittanceInformationUnstructured": "card 1234 cinema", "transactionAmount": {"amount": "-12.00", "currency": "USD"}}
+    ]}}`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	provider := banksync.NewGoCardlessProvider("id", "key", "acc-1")
+	provider.BaseURL = server.URL
+
+	txns, err := provider.Transactions(t.Context(), time.Unix(1760000000, 0))
+	if err != nil {
+		t.Fatalf("Transactions() got error: '%v'", err)
+	}
+
+	want := []banksync.Transaction{
+		{ExternalID: "a", BookedAt: time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC), Description: "Grocer", Amount: money.New(2350, "USD")},
+		{ExternalID: "c", BookedAt: time.Date(2025, 10, 22, 0, 0, 0, 0, time.UTC), Description: "card 1234 cinema", Amount: money.New(1200, "USD")},
+	}
+	if len(txns) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(txns), len(want))
+	}
+	for i := range want {
+		if txns[i] != want[i] {
+			t.Errorf("transaction %d does not match. got: %+v, want: %+v", i, txns[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Package banksync pulls transactions from a bank data provider and turns them into draft expenses,
+// which only become real expenses once a user confirms them
+package banksync
+
+import (
+	"errors"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+)
+
+// DraftStatus is where a draft is in the confirmation flow
+type DraftStatus string
+
+const (
+	StatusPending   DraftStatus = "pending"
+	StatusConfirmed DraftStatus = "confirmed"
+	StatusDismissed DraftStatus = "dismissed"
+)
+
+// ErrDraftNotFound is returned when a draft id does not have a record
+var ErrDraftNotFound = errors.New("provided id does not have a draft")
+
+// ErrDraftNotPending is returned when confirming or dismissing a draft that was already handled
+var ErrDraftNotPending = errors.New("draft has already been confirmed or dismissed")
+
+// Transaction is a single outgoing payment as reported by a Provider
+type Transaction struct {
+	ExternalID  string      // id from the provider, used to avoid importing twice
+	BookedAt    time.Time   // when the bank booked it
+	Description string      // creditor or remittance text
+	Amount      money.Money // positive for money spent, in the account's currency
+}
+
+// Draft is a pulled Transaction waiting on the user to confirm it as an expense
+type Draft struct {
+	ID          int
+	Provider    string
+	ExternalID  string
+	CreatedAt   time.Time
+	OccuredAt   time.Time
+	Description string
+	Amount      money.Money
+	Status      DraftStatus
+	ExpenseID   int // set once confirmed
+}
@@ -0,0 +1,42 @@
+package banksync
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Provider is implemented by each bank data integration
+type Provider interface {
+	// Name is stored alongside each draft, i.e. "gocardless"
+	Name() string
+
+	// Transactions returns outgoing transactions booked on or after since
+	Transactions(ctx context.Context, since time.Time) ([]Transaction, error)
+}
+
+// parseAmountCents converts a decimal amount string such as "-12.34" into cents
+func parseAmountCents(amount string) (int64, error) {
+	whole, frac, _ := strings.Cut(strings.TrimSpace(amount), ".")
+
+	negative := strings.HasPrefix(whole, "-")
+	whole = strings.TrimLeft(whole, "+-")
+
+	// pad or reject fractional part so that it is exactly cents
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("amount %q has more than two decimal places", amount)
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+
+	cents, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", amount, err)
+	}
+
+	if negative {
+		cents = -cents
+	}
+	return cents, nil
+}
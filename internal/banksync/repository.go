@@ -0,0 +1,24 @@
+package banksync
+
+import (
+	"context"
+	"time"
+)
+
+// Repository stores drafts between a sync and the user confirming them
+type Repository interface {
+	// save new drafts, skipping any already stored for the provider + external id
+	SaveDrafts(ctx context.Context, provider string, txns []Transaction) (int, error)
+
+	// latest occured at time of any draft for the provider, zero if there are none
+	LatestOccuredAt(ctx context.Context, provider string) (time.Time, error)
+
+	// get one draft by ID
+	GetDraftByID(ctx context.Context, id int) (*Draft, error)
+
+	// get all drafts with the given status
+	GetDraftsByStatus(ctx context.Context, status DraftStatus) ([]*Draft, error)
+
+	// set the status of a pending draft, and the expense it became if confirmed
+	ResolveDraft(ctx context.Context, id int, status DraftStatus, expenseID int) error
+}
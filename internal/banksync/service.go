@@ -0,0 +1,120 @@
+package banksync
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+)
+
+// initialLookback is how far back the first sync reaches when there are no drafts yet
+const initialLookback = 90 * 24 * time.Hour
+
+// syncOverlap re-requests a few days before the latest draft, since banks can book late
+const syncOverlap = 7 * 24 * time.Hour
+
+// Service defines an interface for the bank sync business layer.
+//
+// This is primarily implemented for easier mocking for testing.
+type Service interface {
+	Sync(ctx context.Context) (int, error)
+
+	PendingDrafts(ctx context.Context) ([]*Draft, error)
+
+	ConfirmDraft(ctx context.Context, id int) (*expenses.Expense, error)
+
+	DismissDraft(ctx context.Context, id int) error
+}
+
+// SyncService pulls from a single Provider into drafts, and turns confirmed drafts into expenses
+type SyncService struct {
+	provider Provider
+	repo     Repository
+	expenses expenses.Service
+}
+
+// NewService creates a SyncService, confirmed drafts are created through expenseService
+func NewService(provider Provider, repo Repository, expenseService expenses.Service) *SyncService {
+	return &SyncService{provider: provider, repo: repo, expenses: expenseService}
+}
+
+// Sync pulls new transactions from the provider and stores them as pending drafts.
+// It returns how many new drafts were created.
+func (s *SyncService) Sync(ctx context.Context) (int, error) {
+	latest, err := s.repo.LatestOccuredAt(ctx, s.provider.Name())
+	if err != nil {
+		return 0, err
+	}
+
+	since := time.Now().Add(-initialLookback)
+	if !latest.IsZero() {
+		since = latest.Add(-syncOverlap)
+	}
+
+	txns, err := s.provider.Transactions(ctx, since)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.repo.SaveDrafts(ctx, s.provider.Name(), txns)
+}
+
+// Run calls Sync every interval until ctx is done, logging rather than stopping on failures
+func (s *SyncService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		created, err := s.Sync(ctx)
+		if err != nil {
+			log.Printf("bank sync with %s failed: %v", s.provider.Name(), err)
+		} else if created > 0 {
+			log.Printf("bank sync with %s created %d draft(s)", s.provider.Name(), created)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *SyncService) PendingDrafts(ctx context.Context) ([]*Draft, error) {
+	return s.repo.GetDraftsByStatus(ctx, StatusPending)
+}
+
+// ConfirmDraft creates an expense from a pending draft, validated like any other new expense
+func (s *SyncService) ConfirmDraft(ctx context.Context, id int) (*expenses.Expense, error) {
+	draft, err := s.repo.GetDraftByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if draft.Status != StatusPending {
+		return nil, ErrDraftNotPending
+	}
+
+	exp, err := s.expenses.NewExpense(ctx, draft.OccuredAt, draft.Description, draft.Amount, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ResolveDraft(ctx, id, StatusConfirmed, exp.ID); err != nil {
+		return nil, err
+	}
+
+	return exp, nil
+}
+
+func (s *SyncService) DismissDraft(ctx context.Context, id int) error {
+	draft, err := s.repo.GetDraftByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if draft.Status != StatusPending {
+		return ErrDraftNotPending
+	}
+
+	return s.repo.ResolveDraft(ctx, id, StatusDismissed, 0)
+}
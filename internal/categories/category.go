@@ -0,0 +1,55 @@
+// Package categories keeps the taxonomy expenses are sorted into, one per book so every household
+// and user can make it their own
+package categories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+)
+
+// Category is one of a book's categories.
+//
+// ID & CreatedAt is set in the repository layer
+type Category struct {
+	ID        int
+	Name      string
+	CreatedAt time.Time
+}
+
+// Book is whose categories they are. It is the household's shared book when there is one,
+// otherwise the user's own, and with neither the single book kept when auth is disabled
+type Book struct {
+	OwnerID     int
+	HouseholdID int
+}
+
+// BookOf is the book expenses written in scope go into, see expenses.ExpenseService.NewExpense
+func BookOf(scope expenses.Scope) Book {
+	if scope.HouseholdID != 0 {
+		return Book{HouseholdID: scope.HouseholdID}
+	}
+	return Book{OwnerID: scope.OwnerID}
+}
+
+// Defaults are the categories every book starts with, they can be renamed and archived like any other
+var Defaults = []string{
+	"Food",
+	"Groceries",
+	"Transport",
+	"Housing",
+	"Utilities",
+	"Health",
+	"Entertainment",
+	"Shopping",
+	"Travel",
+	"Other",
+}
+
+// These errors are used in the validation step of the Service
+var (
+	ErrEmptyName = errors.New("category name cannot be empty")
+	ErrNameTaken = errors.New("the book already has a category by that name")
+	ErrNotFound  = errors.New("the book has no category with that id")
+)
@@ -0,0 +1,173 @@
+package categories_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/categories"
+)
+
+// mockRepository implements the Repository interface to test the service layer
+type mockRepository struct {
+	lastID int
+	books  map[categories.Book][]*categories.Category
+}
+
+func (r *mockRepository) List(ctx context.Context, book categories.Book) ([]*categories.Category, error) {
+	return r.books[book], nil
+}
+
+func (r *mockRepository) Seed(ctx context.Context, book categories.Book, names []string) error {
+	if _, ok := r.books[book]; ok {
+		return nil
+	}
+	for _, name := range names {
+		if _, err := r.Create(ctx, book, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *mockRepository) Create(ctx context.Context, book categories.Book, name string) (*categories.Category, error) {
+	for _, category := range r.books[book] {
+		if category.Name == name {
+			return nil, categories.ErrNameTaken
+		}
+	}
+	r.lastID += 1
+	category := &categories.Category{ID: r.lastID, Name: name}
+	r.books[book] = append(r.books[book], category)
+	return category, nil
+}
+
+func (r *mockRepository) Rename(ctx context.Context, book categories.Book, id int, name string) (*categories.Category, error) {
+	for _, category := range r.books[book] {
+		if category.ID == id {
+			category.Name = name
+			return category, nil
+		}
+	}
+	return nil, categories.ErrNotFound
+}
+
+func (r *mockRepository) Archive(ctx context.Context, book categories.Book, id int) error {
+	for i, category := range r.books[book] {
+		if category.ID == id {
+			r.books[book] = append(r.books[book][:i], r.books[book][i+1:]...)
+			return nil
+		}
+	}
+	return categories.ErrNotFound
+}
+
+func (r *mockRepository) Exists(ctx context.Context, book categories.Book, id int) (bool, error) {
+	for _, category := range r.books[book] {
+		if category.ID == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// mockHouseholds puts users 1 and 2 in household 7
+type mockHouseholds struct{}
+
+func (mockHouseholds) HouseholdIDForUser(ctx context.Context, userID int) (int, error) {
+	if userID == 1 || userID == 2 {
+		return 7, nil
+	}
+	return 0, nil
+}
+
+func setupTestService() *categories.CategoryService {
+	repo := &mockRepository{books: make(map[categories.Book][]*categories.Category)}
+	return categories.NewService(repo, categories.WithHouseholds(mockHouseholds{}))
+}
+
+func TestList(t *testing.T) {
+	serv := setupTestService()
+	ada, grace, linus := auth.WithUserID(t.Context(), 1), auth.WithUserID(t.Context(), 2), auth.WithUserID(t.Context(), 3)
+
+	got, err := serv.List(ada)
+	if err != nil {
+		t.Fatalf("List() got error: '%v'", err)
+	}
+	if len(got) != len(categories.Defaults) {
+		t.Fatalf("List() got %d categories, want the %d defaults", len(got), len(categories.Defaults))
+	}
+
+	// household members share a book, so what one adds the other sees
+	if _, err := serv.Create(grace, "Pets"); err != nil {
+		t.Fatalf("Create() got error: '%v'", err)
+	}
+	if got, err := serv.List(ada); err != nil || len(got) != len(categories.Defaults)+1 {
+		t.Errorf("List() got %d categories, error: '%v', want the defaults and Pets", len(got), err)
+	}
+
+	// users outside of a household have a book of their own
+	if got, err := serv.List(linus); err != nil || len(got) != len(categories.Defaults) {
+		t.Errorf("List() got %d categories, error: '%v', want the defaults", len(got), err)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputName   string
+		want        string
+		expectError bool
+		wantError   error
+	}{
+		{
+			name:      "valid",
+			inputName: "Pets",
+			want:      "Pets",
+		},
+		{
+			name:      "valid-whitespace-collapsed",
+			inputName: "  Eating \t out ",
+			want:      "Eating out",
+		},
+		{
+			name:        "invalid-empty",
+			inputName:   " \n ",
+			expectError: true,
+			wantError:   categories.ErrEmptyName,
+		},
+		{
+			name:        "invalid-default-taken",
+			inputName:   categories.Defaults[0],
+			expectError: true,
+			wantError:   categories.ErrNameTaken,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			serv := setupTestService()
+			ctx := auth.WithUserID(t.Context(), 3)
+
+			got, err := serv.Create(ctx, testCase.inputName)
+			if testCase.expectError {
+				if !errors.Is(err, testCase.wantError) {
+					t.Errorf("Create() got error: '%v', want error: '%v'", err, testCase.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Create() got error: '%v'", err)
+			}
+			if got.Name != testCase.want {
+				t.Errorf("Create() got name: %q, want: %q", got.Name, testCase.want)
+			}
+
+			// a book that starts with its own category still gets the defaults
+			if all, err := serv.List(ctx); err != nil || len(all) != len(categories.Defaults)+1 {
+				t.Errorf("List() got %d categories, error: '%v', want the defaults and %q", len(all), err, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package categories
+
+import "context"
+
+type Repository interface {
+	// list the categories in use, by name
+	List(ctx context.Context, book Book) ([]*Category, error)
+
+	// add names to a book that has never had any categories, archived ones included
+	Seed(ctx context.Context, book Book, names []string) error
+
+	// add a category, ErrNameTaken when one in use has the name
+	Create(ctx context.Context, book Book, name string) (*Category, error)
+
+	// rename a category in use, ErrNotFound when there is none with id and ErrNameTaken as for Create
+	Rename(ctx context.Context, book Book, id int, name string) (*Category, error)
+
+	// archive a category in use, ErrNotFound when there is none with id
+	Archive(ctx context.Context, book Book, id int) error
+
+	// report whether a category in use has id
+	Exists(ctx context.Context, book Book, id int) (bool, error)
+}
@@ -0,0 +1,157 @@
+package categories
+
+import (
+	"context"
+
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+)
+
+// Service defines an interface for the categories business layer.
+// Every method acts on the book of the authenticated user, see BookOf.
+//
+// This is primarily implemented for easier mocking for testing.
+type Service interface {
+	List(ctx context.Context) ([]*Category, error)
+
+	Create(ctx context.Context, name string) (*Category, error)
+
+	Rename(ctx context.Context, id int, name string) (*Category, error)
+
+	Archive(ctx context.Context, id int) error
+}
+
+// CategoryService seeds each book with the Defaults, and keeps its names tidy
+type CategoryService struct {
+	repo       Repository
+	households expenses.HouseholdLookup
+	cache      Invalidator
+}
+
+// Invalidator drops cached reads once categories change, since expenses are sent with theirs
+// under ?include=category. It is implemented by respcache.Cache
+type Invalidator interface {
+	Invalidate()
+}
+
+// Option configures optional parts of the CategoryService
+type Option func(*CategoryService)
+
+// WithHouseholds gives the members of a household one shared set of categories, like their expenses
+func WithHouseholds(lookup expenses.HouseholdLookup) Option {
+	return func(s *CategoryService) { s.households = lookup }
+}
+
+// WithInvalidator invalidates cache whenever a category is renamed or archived
+func WithInvalidator(cache Invalidator) Option {
+	return func(s *CategoryService) { s.cache = cache }
+}
+
+func NewService(repo Repository, opts ...Option) *CategoryService {
+	s := &CategoryService{repo: repo}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// invalidate tells the cache, if there is one, that categories changed
+func (s *CategoryService) invalidate() {
+	if s.cache != nil {
+		s.cache.Invalidate()
+	}
+}
+
+// book is the authenticated user's
+func (s *CategoryService) book(ctx context.Context) (Book, error) {
+	scope, err := expenses.ScopeOf(ctx, s.households)
+	if err != nil {
+		return Book{}, err
+	}
+	return BookOf(scope), nil
+}
+
+// checkName trims name and collapses the whitespace inside it, refusing it when nothing is left
+func checkName(name string) (string, error) {
+	name = expenses.NormalizeDescription(name)
+	if name == "" {
+		return "", ErrEmptyName
+	}
+	return name, nil
+}
+
+// List returns the book's categories, seeding the Defaults the first time a book is looked at
+func (s *CategoryService) List(ctx context.Context) ([]*Category, error) {
+	book, err := s.book(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.repo.List(ctx, book)
+	if err != nil || len(categories) > 0 {
+		return categories, err
+	}
+
+	// a book that archived every category stays empty, Seed only fills books that never had any
+	if err := s.repo.Seed(ctx, book, Defaults); err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, book)
+}
+
+// Create adds a category to the book. The Defaults are seeded first, so a book
+// that starts with its own categories gets them too
+func (s *CategoryService) Create(ctx context.Context, name string) (*Category, error) {
+	name, err := checkName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := s.book(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Seed(ctx, book, Defaults); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, book, name)
+}
+
+// Rename renames one of the book's categories, the expenses in it stay in it
+func (s *CategoryService) Rename(ctx context.Context, id int, name string) (*Category, error) {
+	name, err := checkName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := s.book(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	category, err := s.repo.Rename(ctx, book, id, name)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return category, nil
+}
+
+// Archive takes a category out of use. The expenses in it keep it, but no more can be put in it
+func (s *CategoryService) Archive(ctx context.Context, id int) error {
+	book, err := s.book(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Archive(ctx, book, id); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// CategoryExists implements expenses.CategoryLookup
+func (s *CategoryService) CategoryExists(ctx context.Context, scope expenses.Scope, id int) (bool, error) {
+	return s.repo.Exists(ctx, BookOf(scope), id)
+}
@@ -0,0 +1,38 @@
+package expenses
+
+import "context"
+
+// ErrUnknownCategory is used in the validation step of NewExpense() and UpdateExpense()
+// for a category that isn't one of the book's, or has been archived
+var ErrUnknownCategory = invalid("category_id", "expense category needs to be one of the book's categories, see GET /categories")
+
+// CategoryLookup checks the categories expenses are put in, it is implemented by categories.CategoryService
+type CategoryLookup interface {
+	// reports whether id is a category of the book scope writes to, and isn't archived
+	CategoryExists(ctx context.Context, scope Scope, id int) (bool, error)
+}
+
+// WithCategories lets expenses be put in the categories of their book. Without it only
+// uncategorized expenses are accepted
+func WithCategories(lookup CategoryLookup) Option {
+	return func(s *ExpenseService) { s.categories = lookup }
+}
+
+// checkCategory refuses categoryID when it isn't one of the book's, 0 is uncategorized and always fine
+func (s *ExpenseService) checkCategory(ctx context.Context, scope Scope, categoryID int) error {
+	if categoryID == 0 {
+		return nil
+	}
+	if s.categories == nil {
+		return ErrUnknownCategory
+	}
+
+	exists, err := s.categories.CategoryExists(ctx, scope, categoryID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrUnknownCategory
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package expenses
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSyncCursor is returned for a sync point that was not made by SyncCursor.Encode
+var ErrInvalidSyncCursor = invalid("since", "invalid sync cursor")
+
+// ErrResyncRequired is returned for a sync point older than tombstones are kept, the deletions since
+// may have been pruned, so the client has to sync from the start again
+var ErrResyncRequired = &Error{Kind: KindGone, Field: "since", Err: errors.New("sync cursor is older than deletions are kept, resync from the start")}
+
+// WithTombstoneRetention refuses to sync from points older than maxAge with ErrResyncRequired,
+// it is the retention rule for tombstones. 0 or less keeps them forever, so every sync point is good
+func WithTombstoneRetention(maxAge time.Duration) Option {
+	return func(s *ExpenseService) { s.tombstoneAge = maxAge }
+}
+
+// Change is an expense created or updated, or the id of one deleted, since a sync point
+type Change struct {
+	ID        int
+	ChangedAt time.Time
+	Expense   *Expense // nil once deleted
+}
+
+// Deleted reports whether the change deleted the expense
+func (c *Change) Deleted() bool {
+	return c.Expense == nil
+}
+
+// SyncCursor is a sync point, a client syncing from it gets the changes made after it.
+// Changes are ordered oldest first, by when they were made and then by expense id. The zero cursor is before every change
+type SyncCursor struct {
+	ChangedAt time.Time
+	ID        int
+}
+
+// Encode is the opaque form of the cursor handed to clients
+func (c SyncCursor) Encode() string {
+	raw := strconv.FormatInt(c.ChangedAt.Unix(), 10) + ":" + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeSyncCursor reads a cursor made by SyncCursor.Encode
+func DecodeSyncCursor(encoded string) (SyncCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return SyncCursor{}, ErrInvalidSyncCursor
+	}
+
+	rawChanged, rawID, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return SyncCursor{}, ErrInvalidSyncCursor
+	}
+	changed, err := strconv.ParseInt(rawChanged, 10, 64)
+	if err != nil {
+		return SyncCursor{}, ErrInvalidSyncCursor
+	}
+	id, err := strconv.Atoi(rawID)
+	if err != nil || id < 0 {
+		return SyncCursor{}, ErrInvalidSyncCursor
+	}
+
+	return SyncCursor{ChangedAt: time.Unix(changed, 0), ID: id}, nil
+}
+
+// ChangeSet is one batch of changes, and the sync point to carry on from
+type ChangeSet struct {
+	Changes []*Change
+	Next    SyncCursor
+	More    bool // more changes are waiting after Next, rather than the client being up to date
+}
+
+// Changes reads up to limit of the changes to the user's book made after since, 0 for no limit.
+// Changes made in the current second are left for the next sync, as a change committed later in the second
+// could be ordered before one already read. Clients sync again from Next, straight away while More is set.
+// Sync points older than the tombstone retention are refused with ErrResyncRequired
+func (s *ExpenseService) Changes(ctx context.Context, since SyncCursor, limit int) (*ChangeSet, error) {
+	if limit < 0 {
+		return nil, ErrInvalidFilter
+	}
+	if s.tombstoneAge > 0 && !since.ChangedAt.IsZero() && since.ChangedAt.Before(s.now().Add(-s.tombstoneAge)) {
+		return nil, ErrResyncRequired
+	}
+
+	scope, err := s.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// one more than asked for, to know whether there are more
+	until := s.now().Truncate(time.Second)
+	read := 0
+	if limit > 0 {
+		read = limit + 1
+	}
+
+	changes, err := s.repo.Changes(ctx, scope, since, until, read)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		if !change.Deleted() {
+			s.flagFuture(change.Expense)
+		}
+	}
+
+	set := &ChangeSet{Changes: changes, Next: since}
+	if limit > 0 && len(changes) > limit {
+		last := changes[limit-1]
+		set.Changes = changes[:limit]
+		set.Next = SyncCursor{ChangedAt: last.ChangedAt, ID: last.ID}
+		set.More = true
+	} else if until.After(since.ChangedAt) {
+		// everything before until has been read, whatever its id
+		set.Next = SyncCursor{ChangedAt: until}
+	}
+	return set, nil
+}
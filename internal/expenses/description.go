@@ -0,0 +1,42 @@
+package expenses
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrInvalidDescription is used in the validation step of NewExpense() and UpdateExpense()
+// for descriptions that are nothing but whitespace
+var ErrInvalidDescription = invalid("description", "expense description needs to have more than whitespace")
+
+// NormalizeDescription trims description and collapses the whitespace inside it to single spaces,
+// so "  Coffee \t at  work\n" is stored as "Coffee at work"
+func NormalizeDescription(description string) string {
+	return strings.Join(strings.Fields(description), " ")
+}
+
+// FoldDescription is description as it is matched by search, normalized, lowercase and without accents,
+// so "Café  Müller" and "cafe muller" fold to the same thing. Imports from banks and apps
+// rarely agree on either
+func FoldDescription(description string) string {
+	var folded strings.Builder
+	for _, r := range norm.NFD.String(NormalizeDescription(description)) {
+		// accents are split off into combining marks by NFD
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		folded.WriteRune(unicode.ToLower(r))
+	}
+	return folded.String()
+}
+
+// checkDescription normalizes description, refusing it when nothing is left
+func checkDescription(description string) (string, error) {
+	normalized := NormalizeDescription(description)
+	if normalized == "" {
+		return "", ErrInvalidDescription
+	}
+	return normalized, nil
+}
@@ -0,0 +1,98 @@
+package expenses
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+)
+
+// ErrDuplicateExpense is returned by NewExpense for an expense identical to one created within the
+// duplicate window, which is nearly always a client sending the same request twice
+var ErrDuplicateExpense = NewError(KindConflict, errors.New("an identical expense was just created"))
+
+// WithDuplicateWindow refuses to create an expense identical to one created within window by the same user,
+// same amount, description, and time it occured. 0 or less never refuses one.
+// Only this service's own creates are remembered, and only in memory
+func WithDuplicateWindow(window time.Duration) Option {
+	return func(s *ExpenseService) {
+		s.recent = nil
+		if window > 0 {
+			s.recent = &recentCreates{window: window, created: make(map[createKey]recentCreate)}
+		}
+	}
+}
+
+// createKey is what makes two creates the same. Descriptions are folded, so whitespace and case don't tell them apart
+type createKey struct {
+	scope       Scope
+	occuredAt   int64
+	description string
+	amount      money.Money
+}
+
+// recentCreate is when a create was claimed, and the expense it created once it has
+type recentCreate struct {
+	at time.Time
+	id int
+}
+
+// recentCreates remembers the creates within the window, a nil one remembers nothing
+type recentCreates struct {
+	window time.Duration
+
+	mux     sync.Mutex
+	created map[createKey]recentCreate
+}
+
+// claim takes key for the window, failing with ErrDuplicateExpense while it is taken.
+// Claims that have run out are dropped on the way
+func (r *recentCreates) claim(key createKey, now time.Time) error {
+	if r == nil {
+		return nil
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for other, create := range r.created {
+		if now.Sub(create.at) >= r.window {
+			delete(r.created, other)
+		}
+	}
+
+	if create, ok := r.created[key]; ok {
+		if create.id == 0 {
+			return ErrDuplicateExpense
+		}
+		return fmt.Errorf("%w, as expense %d", ErrDuplicateExpense, create.id)
+	}
+	r.created[key] = recentCreate{at: now}
+	return nil
+}
+
+// done records the expense a claim created, for the error a duplicate of it gets
+func (r *recentCreates) done(key createKey, id int) {
+	if r == nil {
+		return
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if create, ok := r.created[key]; ok {
+		create.id = id
+		r.created[key] = create
+	}
+}
+
+// release gives up a claim whose create failed, so retrying it isn't refused
+func (r *recentCreates) release(key createKey) {
+	if r == nil {
+		return
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	delete(r.created, key)
+}
@@ -0,0 +1,231 @@
+package expenses
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// descriptionField is authenticated with every encrypted description
+const descriptionField = "expenses.description"
+
+// FieldCipher encrypts single values, it is implemented by fieldcrypt.Keyring
+type FieldCipher interface {
+	Encrypt(field, plaintext string) (string, error)
+	Decrypt(field, value string) (string, error)
+	NeedsRotation(value string) bool
+}
+
+// EncryptedRepository encrypts descriptions before they reach the wrapped repository,
+// and decrypts them on the way out. Everything else passes straight through.
+type EncryptedRepository struct {
+	repo   Repository
+	cipher FieldCipher
+}
+
+func NewEncryptedRepository(repo Repository, cipher FieldCipher) *EncryptedRepository {
+	return &EncryptedRepository{repo: repo, cipher: cipher}
+}
+
+// sealed returns a copy of exp with its description encrypted
+func (r *EncryptedRepository) sealed(exp *Expense) (*Expense, error) {
+	if exp == nil {
+		return nil, ErrNilPointer
+	}
+
+	description, err := r.cipher.Encrypt(descriptionField, exp.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	copied := *exp
+	copied.Description = description
+	return &copied, nil
+}
+
+// opened returns a copy of record with its description decrypted,
+// copied so that a repository handing out shared records never sees plaintext
+func (r *EncryptedRepository) opened(record *Expense) (*Expense, error) {
+	description, err := r.cipher.Decrypt(descriptionField, record.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	copied := *record
+	copied.Description = description
+	return &copied, nil
+}
+
+// openedAll is opened for every record
+func (r *EncryptedRepository) openedAll(records []*Expense) ([]*Expense, error) {
+	opened := make([]*Expense, 0, len(records))
+	for _, record := range records {
+		record, err := r.opened(record)
+		if err != nil {
+			return nil, err
+		}
+		opened = append(opened, record)
+	}
+	return opened, nil
+}
+
+func (r *EncryptedRepository) GetByID(ctx context.Context, scope Scope, id int) (*Expense, error) {
+	record, err := r.repo.GetByID(ctx, scope, id)
+	if err != nil {
+		return nil, err
+	}
+	return r.opened(record)
+}
+
+func (r *EncryptedRepository) GetByIDs(ctx context.Context, scope Scope, ids []int) ([]*Expense, error) {
+	records, err := r.repo.GetByIDs(ctx, scope, ids)
+	if err != nil {
+		return nil, err
+	}
+	return r.openedAll(records)
+}
+
+func (r *EncryptedRepository) GetAll(ctx context.Context, scope Scope) ([]*Expense, error) {
+	records, err := r.repo.GetAll(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	return r.openedAll(records)
+}
+
+func (r *EncryptedRepository) Each(ctx context.Context, scope Scope, fn func(*Expense) error) error {
+	return r.repo.Each(ctx, scope, func(record *Expense) error {
+		record, err := r.opened(record)
+		if err != nil {
+			return err
+		}
+		return fn(record)
+	})
+}
+
+// List can't search descriptions, the database only ever sees them encrypted
+func (r *EncryptedRepository) List(ctx context.Context, scope Scope, filter ListFilter) ([]*Expense, error) {
+	if filter.Search != "" {
+		return nil, fmt.Errorf("%w: descriptions are encrypted, so they can't be searched", ErrInvalidFilter)
+	}
+	records, err := r.repo.List(ctx, scope, filter)
+	if err != nil {
+		return nil, err
+	}
+	return r.openedAll(records)
+}
+
+// Count can't search descriptions either, see List
+func (r *EncryptedRepository) Count(ctx context.Context, scope Scope, filter ListFilter) (int, error) {
+	if filter.Search != "" {
+		return 0, fmt.Errorf("%w: descriptions are encrypted, so they can't be searched", ErrInvalidFilter)
+	}
+	return r.repo.Count(ctx, scope, filter)
+}
+
+func (r *EncryptedRepository) Create(ctx context.Context, exp *Expense) (*Expense, error) {
+	sealed, err := r.sealed(exp)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := r.repo.Create(ctx, sealed)
+	if err != nil {
+		return nil, err
+	}
+	return r.opened(record)
+}
+
+func (r *EncryptedRepository) Update(ctx context.Context, scope Scope, exp *Expense) error {
+	sealed, err := r.sealed(exp)
+	if err != nil {
+		return err
+	}
+	return r.repo.Update(ctx, scope, sealed)
+}
+
+func (r *EncryptedRepository) Delete(ctx context.Context, scope Scope, id int) error {
+	return r.repo.Delete(ctx, scope, id)
+}
+
+// Apply seals the expenses created and updated, and opens the stored ones in the results
+func (r *EncryptedRepository) Apply(ctx context.Context, scope Scope, mutations []*Mutation) ([]*MutationResult, error) {
+	sealed := make([]*Mutation, 0, len(mutations))
+	for _, m := range mutations {
+		copied := *m
+		if m.Expense != nil {
+			var err error
+			if copied.Expense, err = r.sealed(m.Expense); err != nil {
+				return nil, err
+			}
+		}
+		sealed = append(sealed, &copied)
+	}
+
+	results, err := r.repo.Apply(ctx, scope, sealed)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		if result.Expense == nil {
+			continue
+		}
+		if result.Expense, err = r.opened(result.Expense); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// Changes opens the changed expenses, deletes only carry the id
+func (r *EncryptedRepository) Changes(ctx context.Context, scope Scope, after SyncCursor, until time.Time, limit int) ([]*Change, error) {
+	changes, err := r.repo.Changes(ctx, scope, after, until, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, change := range changes {
+		if change.Deleted() {
+			continue
+		}
+		if change.Expense, err = r.opened(change.Expense); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+// SumInRange and GroupedSum only read amounts, which are not encrypted
+func (r *EncryptedRepository) SumInRange(ctx context.Context, scope Scope, from, to time.Time, filter SummaryFilter) (*Total, error) {
+	return r.repo.SumInRange(ctx, scope, from, to, filter)
+}
+
+func (r *EncryptedRepository) GroupedSum(ctx context.Context, scope Scope, from, to time.Time, grouping Grouping, loc *time.Location, filter SummaryFilter) ([]*PeriodTotal, error) {
+	return r.repo.GroupedSum(ctx, scope, from, to, grouping, loc, filter)
+}
+
+// Rotate re-encrypts every description that is plaintext or sealed with an old key,
+// returning how many were rewritten. Once it finishes, old keys can be removed.
+func (r *EncryptedRepository) Rotate(ctx context.Context) (int, error) {
+	records, err := r.repo.GetAll(ctx, Unscoped)
+	if err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	for _, record := range records {
+		if !r.cipher.NeedsRotation(record.Description) {
+			continue
+		}
+		record, err := r.opened(record)
+		if err != nil {
+			return rotated, err
+		}
+		if err := r.Update(ctx, Unscoped, record); err != nil {
+			return rotated, err
+		}
+		rotated += 1
+	}
+
+	return rotated, nil
+}
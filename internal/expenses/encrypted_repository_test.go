@@ -0,0 +1,108 @@
+package expenses_test
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/fieldcrypt"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+)
+
+// newTestKeyring creates a keyring from key ids, each key is its id repeated to 32 bytes
+func newTestKeyring(t *testing.T, ids ...string) *fieldcrypt.Keyring {
+	t.Helper()
+
+	keys := make([]fieldcrypt.Key, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, fieldcrypt.Key{ID: id, Secret: bytes.Repeat([]byte(id[:1]), fieldcrypt.KeySize)})
+	}
+
+	keyring, err := fieldcrypt.NewKeyring(keys)
+	if err != nil {
+		t.Fatalf("unable to create keyring: %v", err)
+	}
+	return keyring
+}
+
+func TestEncryptedRepository(t *testing.T) {
+	inner := setupTestRepo(t)
+	repo := expenses.NewEncryptedRepository(inner, newTestKeyring(t, "a"))
+
+	created, err := repo.Create(t.Context(), &expenses.Expense{
+		Amount:           money.New(1250, "EUR"),
+		ExpenseOccuredAt: time.Unix(1761670800, 0),
+		Description:      "therapy session",
+	})
+	if err != nil {
+		t.Fatalf("Create() got error: '%v'", err)
+	}
+	if created.Description != "therapy session" {
+		t.Errorf("Create() got description: %q, want plaintext", created.Description)
+	}
+
+	// the wrapped repository only ever sees ciphertext
+	raw, err := inner.GetByID(t.Context(), expenses.Unscoped, created.ID)
+	if err != nil {
+		t.Fatalf("inner GetByID() got error: '%v'", err)
+	}
+	if !strings.HasPrefix(raw.Description, "enc:v1:a:") || strings.Contains(raw.Description, "therapy") {
+		t.Errorf("stored description is not encrypted: %q", raw.Description)
+	}
+
+	got, err := repo.GetByID(t.Context(), expenses.Unscoped, created.ID)
+	if err != nil || got.Description != "therapy session" {
+		t.Errorf("GetByID() got: %+v, error: '%v'", got, err)
+	}
+
+	// the wrapped repository could only search the ciphertext
+	if _, err := repo.List(t.Context(), expenses.Unscoped, expenses.ListFilter{Search: "therapy"}); !errors.Is(err, expenses.ErrInvalidFilter) {
+		t.Errorf("List() with a search got error: '%v', want error: '%v'", err, expenses.ErrInvalidFilter)
+	}
+
+	// records from before encryption was enabled still read as plaintext
+	legacy, err := repo.GetByID(t.Context(), expenses.Unscoped, 1)
+	if err != nil || legacy.Description != "dinner out with friends" {
+		t.Errorf("GetByID(1) got: %+v, error: '%v'", legacy, err)
+	}
+
+	// a new primary key, with the old one kept to read existing records
+	rotating := expenses.NewEncryptedRepository(inner, newTestKeyring(t, "b", "a"))
+	rotated, err := rotating.Rotate(t.Context())
+	if err != nil {
+		t.Fatalf("Rotate() got error: '%v'", err)
+	}
+	if rotated != 7 {
+		t.Errorf("Rotate() got: %d, want: 7", rotated)
+	}
+	if again, _ := rotating.Rotate(t.Context()); again != 0 {
+		t.Errorf("Rotate() again got: %d, want: 0", again)
+	}
+
+	// once rotated, the old key is no longer needed
+	newOnly := expenses.NewEncryptedRepository(inner, newTestKeyring(t, "b"))
+	all, err := newOnly.GetAll(t.Context(), expenses.Unscoped)
+	if err != nil {
+		t.Fatalf("GetAll() with only the new key got error: '%v'", err)
+	}
+	if len(all) != 7 || all[0].Description != "dinner out with friends" || all[6].Description != "therapy session" {
+		t.Errorf("GetAll() got unexpected records after rotation")
+	}
+
+	// streamed records are decrypted one at a time too
+	var streamed []string
+	err = newOnly.Each(t.Context(), expenses.Unscoped, func(record *expenses.Expense) error {
+		streamed = append(streamed, record.Description)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Each() got error: '%v'", err)
+	}
+	if len(streamed) != 7 || !slices.Contains(streamed, "therapy session") || !slices.Contains(streamed, "dinner out with friends") {
+		t.Errorf("Each() got descriptions: %q", streamed)
+	}
+}
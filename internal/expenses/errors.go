@@ -0,0 +1,77 @@
+package expenses
+
+import "errors"
+
+// Kind is what went wrong, in terms every caller can act on without knowing the cause
+type Kind int
+
+const (
+	KindInternal Kind = iota // anything unexpected, the caller can't fix it
+	KindInvalid              // the input can never succeed as it is
+	KindNotFound             // what the input refers to does not exist, or is out of scope
+	KindConflict             // the input clashes with what is already stored
+	KindGone                 // what the input refers to is no longer kept, i.e. pruned by a retention rule
+)
+
+// Error is an error with a Kind, and the Field of the input at fault when there is one.
+// Handlers map the kind to a status, so repositories return their own errors wrapped in an Error
+// rather than the service checking for each backend's, i.e. sql.ErrNoRows
+type Error struct {
+	Kind  Kind
+	Field string // i.e. "amount", empty when no single field is at fault
+	Err   error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// NewError wraps err as kind
+func NewError(kind Kind, err error) *Error {
+	return &Error{Kind: kind, Err: err}
+}
+
+// invalid is a sentinel for input at fault in field
+func invalid(field, message string) *Error {
+	return &Error{Kind: KindInvalid, Field: field, Err: errors.New(message)}
+}
+
+// KindOf is the kind of the first Error in err's chain. Summary ranges that can't be read are invalid,
+// and any other error is internal
+func KindOf(err error) Kind {
+	var kindErr *Error
+	if errors.As(err, &kindErr) {
+		return kindErr.Kind
+	}
+	var timeErr *ErrInvalidTime
+	if errors.As(err, &timeErr) {
+		return KindInvalid
+	}
+	return KindInternal
+}
+
+// FieldOf is the field at fault in err, empty when there is none
+func FieldOf(err error) string {
+	var kindErr *Error
+	if errors.As(err, &kindErr) {
+		return kindErr.Field
+	}
+	return ""
+}
+
+// Problems are the errors joined in err, as validation joins every check that fails, or just err when it joins nothing
+func Problems(err error) []error {
+	if err == nil {
+		return nil
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+
+	problems := make([]error, 0)
+	for _, inner := range joined.Unwrap() {
+		problems = append(problems, Problems(inner)...)
+	}
+	return problems
+}
@@ -0,0 +1,84 @@
+package expenses
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// EventType names what happened to an expense
+type EventType string
+
+const (
+	ExpenseCreated EventType = "expense.created"
+	ExpenseUpdated EventType = "expense.updated"
+	ExpenseDeleted EventType = "expense.deleted"
+)
+
+// Event is published once a change to an expense has been written.
+// Expense is what was written, for deletes only its ID is set
+type Event struct {
+	Type    EventType
+	Scope   Scope // the book that changed
+	Expense *Expense
+	At      time.Time
+}
+
+// Subscriber is called with every event published to the bus it subscribed to. It is called
+// before the service method that published the event returns, so it shouldn't block
+type Subscriber func(ctx context.Context, event Event)
+
+// Bus hands the events published to it to every subscriber, in the order they subscribed.
+// The cache invalidator and notifier subscribe to it, so the service doesn't know about either
+type Bus struct {
+	mux         sync.RWMutex
+	subscribers []Subscriber
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe calls fn with every event published from now on
+func (b *Bus) Subscribe(fn Subscriber) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.subscribers = append(b.subscribers, fn)
+}
+
+// Publish calls every subscriber with event
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	b.mux.RLock()
+	subscribers := b.subscribers
+	b.mux.RUnlock()
+
+	for _, fn := range subscribers {
+		fn(ctx, event)
+	}
+}
+
+// WithEvents publishes the service's events on bus, so more subscribers can be added to it.
+// The service has a bus of its own otherwise
+func WithEvents(bus *Bus) Option {
+	return func(s *ExpenseService) { s.events = bus }
+}
+
+// publish tells the subscribers that exp changed in scope
+func (s *ExpenseService) publish(ctx context.Context, eventType EventType, scope Scope, exp *Expense) {
+	s.events.Publish(ctx, Event{Type: eventType, Scope: scope, Expense: exp, At: s.now()})
+}
+
+// subscribeOptions subscribes the invalidator and notifier passed as options to the service's bus
+func (s *ExpenseService) subscribeOptions() {
+	if cache := s.cache; cache != nil {
+		s.events.Subscribe(func(ctx context.Context, event Event) { cache.Invalidate() })
+	}
+	if notifier := s.notifier; notifier != nil {
+		s.events.Subscribe(func(ctx context.Context, event Event) {
+			if event.Type == ExpenseCreated {
+				notifier.ExpenseCreated(event.Expense)
+			}
+		})
+	}
+}
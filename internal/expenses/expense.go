@@ -1,14 +1,25 @@
 package expenses
 
-import "time"
+import (
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+)
 
 // Expense is used for all expense types, except summaries
 //
-// ID & RecordCreatedAt is set in the repository layer
+// ID, RecordCreatedAt, RecordUpdatedAt & Version are set in the repository layer
 type Expense struct {
-	ID               int       // id of the expense for db
-	Amount           int64     // cents total
-	ExpenseOccuredAt time.Time // when it happened
-	RecordCreatedAt  time.Time // when the record was created
-	Description      string    // what the transaction is
+	ID               int         // id of the expense for db
+	OwnerID          int         // user the expense belongs to, 0 when created without auth
+	HouseholdID      int         // household the expense is shared with, 0 for none
+	CategoryID       int         // one of the book's categories, 0 for uncategorized
+	Amount           money.Money // in the currency expenses are recorded in
+	ExpenseOccuredAt time.Time   // when it happened
+	RecordCreatedAt  time.Time   // when the record was created
+	RecordUpdatedAt  time.Time   // when the record was last created or updated
+	Description      string      // what the transaction is
+	Version          int         // counts the writes to the record, 1 once created, see Mutation
+	ClientID         string      // the UUID an offline client created it with, empty otherwise
+	FutureDated      bool        // occurs in the future, only set when future expenses are flagged, see WithFuturePolicy
 }
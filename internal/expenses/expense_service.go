@@ -2,10 +2,12 @@ package expenses
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/fxrates"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
 )
 
 type SummaryTimeRange int
@@ -17,20 +19,26 @@ const (
 	ThisYear
 	CustomYear
 	CustomYearMonthRange
+	CustomPeriod
 )
 
 // These errors are used in the validation step of NewExpense() and UpdateExpense()
 var (
-	ErrInvalidAmount        = fmt.Errorf("expense amount needs to be greater than 0")
-	ErrInvalidOccuredAtTime = fmt.Errorf("expense date needs to be after 1970")
+	ErrInvalidAmount        = invalid("amount", "expense amount needs to be greater than 0")
+	ErrInvalidCurrency      = invalid("currency", "expense amount needs to be in the currency expenses are recorded in")
+	ErrInvalidOccuredAtTime = invalid("occured_at", "expense date needs to be after 1970")
 )
 
 // ErrInvalidID is used with validation step of GetExpenseByID()
-var ErrInvalidID = fmt.Errorf("id needs to be greater than 0")
+var ErrInvalidID = invalid("id", "id needs to be greater than 0")
 
 // ErrUnusedID is used in the validation step of GetExpenseByID(),
 // for record ID's that structurally valid (above 0) but do not have a valid record
-var ErrUnusedID = fmt.Errorf("provided id does not have a record")
+var ErrUnusedID = &Error{Kind: KindNotFound, Err: errors.New("provided id does not have a record")}
+
+// ErrTooManyResults is used by GetAllExpenses() and GetAllOwnersExpenses() when more expenses match
+// than WithMaxResults allows, they are left for ListExpenses() to page through or EachExpense() to stream
+var ErrTooManyResults = invalid("", "too many expenses to read at once")
 
 // ErrInvalidTime is used for SummarizeExpenses() when an invalid range is provided
 type ErrInvalidTime struct {
@@ -45,14 +53,49 @@ func (e *ErrInvalidTime) Error() string {
 	return fmt.Sprintf("invalid time range of '%s'", e.ProvidedTime)
 }
 
-// checkAmount is ensure that amount is not zero or negative.
-func checkAmount(amount int64) error {
-	if amount <= 0 {
+// checkAmount is ensure that amount is not zero or negative, and is in currency.
+func checkAmount(amount money.Money, currency string) error {
+	if amount.Currency != currency {
+		return fmt.Errorf("%w, %s rather than %s", ErrInvalidCurrency, currency, amount.Currency)
+	}
+	if !amount.IsPositive() {
 		return ErrInvalidAmount
 	}
 	return nil
 }
 
+// checkExpense runs every check on an expense being written to the book of scope, and joins what fails so
+// a client sees all the problems with it at once, see Problems. The description comes back normalized.
+// Failing to look up the category is returned on its own, since it isn't a problem with the expense
+func (s *ExpenseService) checkExpense(ctx context.Context, scope Scope, occuredAt time.Time, description string, amount money.Money, categoryID int) (string, error) {
+	var problems []error
+	if err := checkAmount(amount, s.currency); err != nil {
+		problems = append(problems, err)
+	}
+
+	if err := s.checkCategory(ctx, scope, categoryID); err != nil {
+		if KindOf(err) != KindInvalid {
+			return "", err
+		}
+		problems = append(problems, err)
+	}
+
+	// stored trimmed and with its whitespace collapsed
+	description, err := checkDescription(description)
+	if err != nil {
+		problems = append(problems, err)
+	}
+
+	// able to be unix time, and only then worth checking for being in the future
+	if err := checkOccuredAt(occuredAt); err != nil {
+		problems = append(problems, err)
+	} else if err := s.checkFuture(occuredAt); err != nil {
+		problems = append(problems, err)
+	}
+
+	return description, errors.Join(problems...)
+}
+
 // checkOccuredAt is to ensure that the time is able to be stored as a valid unix time
 func checkOccuredAt(occ time.Time) error {
 	unixEpoch := time.Unix(0, 0)
@@ -65,97 +108,312 @@ func checkOccuredAt(occ time.Time) error {
 // ExpenseService implements all of the underlying business logic.
 // Things such as expenses being positive and not zero, etc.
 type ExpenseService struct {
-	repo Repository
+	repo       Repository
+	currency   string
+	households HouseholdLookup
+	categories CategoryLookup
+	recent     *recentCreates
+	now        func() time.Time
+	cache      Invalidator
+	notifier   Notifier
+	events     *Bus
+	converter  Converter
+
+	reportWorkers int
+	maxResults    int
+	futurePolicy  FuturePolicy
+	futureSkew    time.Duration
+	tombstoneAge  time.Duration
+}
+
+// Invalidator drops cached reads once expenses change, it is implemented by respcache.Cache
+type Invalidator interface {
+	Invalidate()
+}
+
+// Notifier is told about every expense once it is created, it is implemented by slack.Notifier.
+// It is called before NewExpense returns, so it shouldn't block
+type Notifier interface {
+	ExpenseCreated(e *Expense)
+}
+
+// Converter converts amounts from the currency expenses are recorded in, it is implemented by fxrates.Cache
+type Converter interface {
+	Convert(currency string) (*fxrates.Conversion, error)
+}
+
+// Option configures optional parts of the ExpenseService
+type Option func(*ExpenseService)
+
+// WithCurrency records expenses in currency, money.DefaultCurrency otherwise
+func WithCurrency(currency string) Option {
+	return func(s *ExpenseService) { s.currency = currency }
+}
+
+// WithHouseholds shares expenses between the members of a household
+func WithHouseholds(lookup HouseholdLookup) Option {
+	return func(s *ExpenseService) { s.households = lookup }
+}
+
+// WithClock replaces time.Now, for the ranges relative to the current month and year
+func WithClock(now func() time.Time) Option {
+	return func(s *ExpenseService) { s.now = now }
+}
+
+// WithInvalidator invalidates cache whenever an expense is created, updated, or deleted, see Bus
+func WithInvalidator(cache Invalidator) Option {
+	return func(s *ExpenseService) { s.cache = cache }
+}
+
+// WithNotifier tells notifier about every expense created, see Bus
+func WithNotifier(notifier Notifier) Option {
+	return func(s *ExpenseService) { s.notifier = notifier }
+}
+
+// WithConverter converts summaries to the currency they ask for, they can't be converted otherwise
+func WithConverter(converter Converter) Option {
+	return func(s *ExpenseService) { s.converter = converter }
+}
+
+// WithReportWorkers splits bounded summaries into up to n queries run side by side, 1 or less runs them as one
+func WithReportWorkers(n int) Option {
+	return func(s *ExpenseService) { s.reportWorkers = n }
+}
+
+// WithMaxResults caps the unpaged reads at n expenses, 0 or less for no cap
+func WithMaxResults(n int) Option {
+	return func(s *ExpenseService) { s.maxResults = n }
 }
 
 // NewService utilizes the Repository interface defined in internal/repository.go
 // This way, we never need to worry about the underlying database
-func NewService(repo Repository) *ExpenseService {
-	return &ExpenseService{repo: repo}
+func NewService(repo Repository, opts ...Option) *ExpenseService {
+	s := &ExpenseService{repo: repo, currency: money.DefaultCurrency, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	// the cache and notifier hear about changes like any other subscriber
+	if s.events == nil {
+		s.events = NewBus()
+	}
+	s.subscribeOptions()
+	return s
+}
+
+// Currency is what expenses are recorded in
+func (s *ExpenseService) Currency() string {
+	return s.currency
 }
 
-func (s *ExpenseService) NewExpense(ctx context.Context, occuredAt time.Time, description string, amount int64) (*Expense, error) {
-	// check amount
-	if err := checkAmount(amount); err != nil {
+func (s *ExpenseService) NewExpense(ctx context.Context, occuredAt time.Time, description string, amount money.Money, categoryID int) (*Expense, error) {
+	// new expenses go into the household book when there is one
+	scope, err := s.scope(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	// able to be unix time
-	if err := checkOccuredAt(occuredAt); err != nil {
+	description, err = s.checkExpense(ctx, scope, occuredAt, description, amount, categoryID)
+	if err != nil {
 		return nil, err
 	}
 
 	exp := &Expense{
+		OwnerID:          scope.OwnerID,
+		HouseholdID:      scope.HouseholdID,
+		CategoryID:       categoryID,
 		Amount:           amount,
 		ExpenseOccuredAt: occuredAt,
 		Description:      description,
 	}
 
-	exp, err := s.repo.Create(ctx, exp)
+	// the same create sent twice gets a conflict rather than a second expense
+	key := createKey{scope: scope, occuredAt: occuredAt.Unix(), description: FoldDescription(description), amount: amount}
+	if err := s.recent.claim(key, s.now()); err != nil {
+		return nil, err
+	}
+
+	exp, err = s.repo.Create(ctx, exp)
 	if err != nil {
+		s.recent.release(key)
 		return nil, err
 	}
+	s.recent.done(key, exp.ID)
+	s.flagFuture(exp)
+	s.publish(ctx, ExpenseCreated, scope, exp)
 
 	return exp, nil
 }
 
+// checkResultCount counts the expenses in scope before an unpaged read loads them,
+// so a read over the cap fails without ever holding them all
+func (s *ExpenseService) checkResultCount(ctx context.Context, scope Scope) error {
+	if s.maxResults <= 0 {
+		return nil
+	}
+
+	total, err := s.repo.SumInRange(ctx, scope, time.Time{}, time.Time{}, SummaryFilter{})
+	if err != nil {
+		return err
+	}
+	if total.Count > s.maxResults {
+		return ErrTooManyResults
+	}
+	return nil
+}
+
 func (s *ExpenseService) GetAllExpenses(ctx context.Context) ([]*Expense, error) {
-	exps, err := s.repo.GetAll(ctx)
+	scope, err := s.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkResultCount(ctx, scope); err != nil {
+		return nil, err
+	}
+
+	exps, err := s.repo.GetAll(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	s.flagFuture(exps...)
+
+	return exps, nil
+}
+
+// EachExpense calls fn with every expense, oldest first, without loading them all at once.
+// It is for streaming exports, and stops at the first error from fn
+func (s *ExpenseService) EachExpense(ctx context.Context, fn func(*Expense) error) error {
+	scope, err := s.scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Each(ctx, scope, func(exp *Expense) error {
+		s.flagFuture(exp)
+		return fn(exp)
+	})
+}
+
+// GetAllOwnersExpenses lists every tenant's expenses.
+// It is for admins only, so the caller needs to have checked that first.
+func (s *ExpenseService) GetAllOwnersExpenses(ctx context.Context) ([]*Expense, error) {
+	if err := s.checkResultCount(ctx, Unscoped); err != nil {
+		return nil, err
+	}
+
+	exps, err := s.repo.GetAll(ctx, Unscoped)
 	if err != nil {
 		return nil, err
 	}
+	s.flagFuture(exps...)
 
 	return exps, nil
 }
 
 func (s *ExpenseService) GetExpenseByID(ctx context.Context, id int) (*Expense, error) {
-	exp, err := s.repo.GetByID(ctx, id)
+	scope, err := s.scope(ctx)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	exp, err := s.repo.GetByID(ctx, scope, id)
+	if err != nil {
+		if KindOf(err) == KindNotFound {
 			return nil, ErrUnusedID
 		}
 		return nil, err
 	}
+	s.flagFuture(exp)
 
 	return exp, nil
 }
 
-func (s *ExpenseService) UpdateExpense(ctx context.Context, id int, occuredAt time.Time, description string, amount int64) error {
-	// validate for above 0
-	if err := checkAmount(amount); err != nil {
+// GetExpensesByIDs returns the records for ids in the order they were requested.
+// Any ids without a record are returned in missing rather than as an error.
+func (s *ExpenseService) GetExpensesByIDs(ctx context.Context, ids []int) ([]*Expense, []int, error) {
+	for _, id := range ids {
+		if id <= 0 {
+			return nil, nil, ErrInvalidID
+		}
+	}
+
+	scope, err := s.scope(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	exps, err := s.repo.GetByIDs(ctx, scope, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.flagFuture(exps...)
+
+	// index so that the requested order can be kept
+	byID := make(map[int]*Expense, len(exps))
+	for _, exp := range exps {
+		byID[exp.ID] = exp
+	}
+
+	found := make([]*Expense, 0, len(ids))
+	missing := make([]int, 0)
+	for _, id := range ids {
+		exp, ok := byID[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		found = append(found, exp)
+	}
+
+	return found, missing, nil
+}
+
+func (s *ExpenseService) UpdateExpense(ctx context.Context, id int, occuredAt time.Time, description string, amount money.Money, categoryID int) error {
+	scope, err := s.scope(ctx)
+	if err != nil {
 		return err
 	}
-	// validate for unix time
-	if err := checkOccuredAt(occuredAt); err != nil {
+
+	description, err = s.checkExpense(ctx, scope, occuredAt, description, amount, categoryID)
+	if err != nil {
 		return err
 	}
 
 	exp := &Expense{
 		ID:               id,
+		CategoryID:       categoryID,
 		Amount:           amount,
 		ExpenseOccuredAt: occuredAt,
 		Description:      description,
 	}
 
-	if err := s.repo.Update(ctx, exp); err != nil {
-		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, ErrNoRowsUpdated) {
+	if err := s.repo.Update(ctx, scope, exp); err != nil {
+		if KindOf(err) == KindNotFound {
 			return ErrUnusedID
 		}
 		return err
 	}
+	s.publish(ctx, ExpenseUpdated, scope, exp)
 
 	return nil
 }
 
 func (s *ExpenseService) DeleteExpense(ctx context.Context, id int) error {
-	if err := s.repo.Delete(ctx, id); err != nil {
-		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, ErrNoRowsDeleted) {
+	scope, err := s.scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, scope, id); err != nil {
+		if KindOf(err) == KindNotFound {
 			return ErrUnusedID
 		}
 
 		// otherwise other error
 		return err
 	}
+	s.publish(ctx, ExpenseDeleted, scope, &Expense{ID: id})
 
 	return nil
 }
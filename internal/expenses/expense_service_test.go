@@ -4,13 +4,23 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
 	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/fxrates"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
 	"github.com/nicholasss/expense-tracker-api/internal/sqlite"
+	migrations "github.com/nicholasss/expense-tracker-api/sql"
 )
 
 // mockRepository implements the Respository interface to test the service layer
@@ -24,22 +34,43 @@ type mockRepository struct {
 	mux *sync.RWMutex
 }
 
+// visible reports whether a record is inside scope
+func visible(scope expenses.Scope, record *expenses.Expense) bool {
+	return scope.AllOwners || record.OwnerID == scope.OwnerID ||
+		(scope.HouseholdID != 0 && record.HouseholdID == scope.HouseholdID)
+}
+
 // get one expense record by ID
-func (r *mockRepository) GetByID(ctx context.Context, id int) (*expenses.Expense, error) {
+func (r *mockRepository) GetByID(ctx context.Context, scope expenses.Scope, id int) (*expenses.Expense, error) {
 	r.mux.RLock()
 	defer r.mux.RUnlock()
 
 	// get from map of records
 	record, ok := r.db[id]
-	if !ok {
-		return nil, &sqlite.QueryError{Query: "mocked for test", Err: sql.ErrNoRows}
+	if !ok || !visible(scope, record) {
+		return nil, expenses.NewError(expenses.KindNotFound, &sqlite.QueryError{Query: "mocked for test", Err: sql.ErrNoRows})
 	}
 
 	return record, nil
 }
 
+// get every expense with an id in ids
+func (r *mockRepository) GetByIDs(ctx context.Context, scope expenses.Scope, ids []int) ([]*expenses.Expense, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	records := make([]*expenses.Expense, 0)
+	for _, id := range ids {
+		if record, ok := r.db[id]; ok && visible(scope, record) {
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
+
 // get all expenses
-func (r *mockRepository) GetAll(ctx context.Context) ([]*expenses.Expense, error) {
+func (r *mockRepository) GetAll(ctx context.Context, scope expenses.Scope) ([]*expenses.Expense, error) {
 	// check if no records in repository
 	if r.lastID == 0 {
 		return nil, &sqlite.QueryError{Query: "mocked for test", Err: sql.ErrNoRows}
@@ -51,11 +82,11 @@ func (r *mockRepository) GetAll(ctx context.Context) ([]*expenses.Expense, error
 
 	// read all records into slice
 	records := make([]*expenses.Expense, 0)
-	for i := range r.lastID {
+	for i := 1; i <= r.lastID; i++ {
 		record, ok := r.db[i]
 
 		// only append if not deleted
-		if ok {
+		if ok && visible(scope, record) {
 			records = append(records, record)
 		}
 	}
@@ -64,6 +95,79 @@ func (r *mockRepository) GetAll(ctx context.Context) ([]*expenses.Expense, error
 	return records, nil
 }
 
+// call fn with every visible expense, oldest first
+func (r *mockRepository) Each(ctx context.Context, scope expenses.Scope, fn func(*expenses.Expense) error) error {
+	r.mux.RLock()
+	records := make([]*expenses.Expense, 0, len(r.db))
+	for _, record := range r.db {
+		if visible(scope, record) {
+			records = append(records, record)
+		}
+	}
+	r.mux.RUnlock()
+
+	slices.SortFunc(records, func(a, b *expenses.Expense) int {
+		if c := a.ExpenseOccuredAt.Compare(b.ExpenseOccuredAt); c != 0 {
+			return c
+		}
+		return a.ID - b.ID
+	})
+
+	for _, record := range records {
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// list expenses matching filter, newest first
+func (r *mockRepository) List(ctx context.Context, scope expenses.Scope, filter expenses.ListFilter) ([]*expenses.Expense, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	records := make([]*expenses.Expense, 0)
+	for _, record := range r.db {
+		if !visible(scope, record) || !inRange(record, filter.From, filter.To) {
+			continue
+		}
+		if (filter.MinAmount != 0 && record.Amount.Minor < filter.MinAmount) || (filter.MaxAmount != 0 && record.Amount.Minor > filter.MaxAmount) {
+			continue
+		}
+		if !strings.Contains(expenses.FoldDescription(record.Description), filter.Search) {
+			continue
+		}
+		if !filter.UpdatedSince.IsZero() && record.RecordUpdatedAt.Before(filter.UpdatedSince) {
+			continue
+		}
+		if after := filter.After; after != nil && !record.ExpenseOccuredAt.Before(after.OccuredAt) &&
+			!(record.ExpenseOccuredAt.Equal(after.OccuredAt) && record.ID < after.ID) {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	slices.SortFunc(records, func(a, b *expenses.Expense) int {
+		if c := b.ExpenseOccuredAt.Compare(a.ExpenseOccuredAt); c != 0 {
+			return c
+		}
+		return b.ID - a.ID
+	})
+
+	records = records[min(filter.Offset, len(records)):]
+	if filter.Limit > 0 {
+		records = records[:min(filter.Limit, len(records))]
+	}
+	return records, nil
+}
+
+// count expenses matching filter, on every page
+func (r *mockRepository) Count(ctx context.Context, scope expenses.Scope, filter expenses.ListFilter) (int, error) {
+	filter.After, filter.Limit, filter.Offset = nil, 0, 0
+	records, err := r.List(ctx, scope, filter)
+	return len(records), err
+}
+
 // create a new expense
 func (r *mockRepository) Create(ctx context.Context, exp *expenses.Expense) (*expenses.Expense, error) {
 	// check for nil exp pointer
@@ -81,6 +185,7 @@ func (r *mockRepository) Create(ctx context.Context, exp *expenses.Expense) (*ex
 	// prepare record
 	exp.ID = newID
 	exp.RecordCreatedAt = time.Now()
+	exp.RecordUpdatedAt = exp.RecordCreatedAt
 
 	// insert record into map
 	r.db[newID] = exp
@@ -89,7 +194,7 @@ func (r *mockRepository) Create(ctx context.Context, exp *expenses.Expense) (*ex
 }
 
 // update an existing expense
-func (r *mockRepository) Update(ctx context.Context, exp *expenses.Expense) error {
+func (r *mockRepository) Update(ctx context.Context, scope expenses.Scope, exp *expenses.Expense) error {
 	// check for nil exp pointer
 	if exp == nil {
 		return expenses.ErrNilPointer
@@ -99,26 +204,27 @@ func (r *mockRepository) Update(ctx context.Context, exp *expenses.Expense) erro
 	defer r.mux.Unlock()
 
 	// make sure id exists
-	_, exists := r.db[exp.ID]
-	if !exists {
+	existing, exists := r.db[exp.ID]
+	if !exists || !visible(scope, existing) {
 		return expenses.ErrNoRowsUpdated
 	}
 
 	// perform update
+	exp.RecordCreatedAt, exp.RecordUpdatedAt = existing.RecordCreatedAt, time.Now()
 	r.db[exp.ID] = exp
 
 	return nil
 }
 
 // delete an exisiting expense
-func (r *mockRepository) Delete(ctx context.Context, id int) error {
+func (r *mockRepository) Delete(ctx context.Context, scope expenses.Scope, id int) error {
 	// lock mux
 	r.mux.Lock()
 	defer r.mux.Unlock()
 
 	// test if empty, and return ErrNoRowsDeleted
-	_, exists := r.db[id]
-	if r.lastID == 0 || !exists {
+	existing, exists := r.db[id]
+	if r.lastID == 0 || !exists || !visible(scope, existing) {
 		return expenses.ErrNoRowsDeleted
 	}
 
@@ -127,6 +233,96 @@ func (r *mockRepository) Delete(ctx context.Context, id int) error {
 	return nil
 }
 
+// changes are the records updated after the cursor and before until, in whole seconds. Deletes aren't kept
+func (r *mockRepository) Changes(ctx context.Context, scope expenses.Scope, after expenses.SyncCursor, until time.Time, limit int) ([]*expenses.Change, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	changes := make([]*expenses.Change, 0)
+	for _, record := range r.db {
+		changed := record.RecordUpdatedAt.Unix()
+		afterCursor := changed > after.ChangedAt.Unix() || (changed == after.ChangedAt.Unix() && record.ID > after.ID)
+		if visible(scope, record) && afterCursor && changed < until.Unix() {
+			changes = append(changes, &expenses.Change{ID: record.ID, ChangedAt: time.Unix(changed, 0), Expense: record})
+		}
+	}
+
+	slices.SortFunc(changes, func(a, b *expenses.Change) int {
+		if c := a.ChangedAt.Compare(b.ChangedAt); c != 0 {
+			return c
+		}
+		return a.ID - b.ID
+	})
+	if limit > 0 && len(changes) > limit {
+		changes = changes[:limit]
+	}
+	return changes, nil
+}
+
+// batches are only applied by the sqlite repository, see TestApplyMutations
+func (r *mockRepository) Apply(ctx context.Context, scope expenses.Scope, mutations []*expenses.Mutation) ([]*expenses.MutationResult, error) {
+	return nil, errors.New("mock repository can't apply mutations")
+}
+
+// inRange reports whether a record occured in [from, to), zero times are unbounded
+func inRange(record *expenses.Expense, from, to time.Time) bool {
+	occured := record.ExpenseOccuredAt
+	return (from.IsZero() || !occured.Before(from)) && (to.IsZero() || occured.Before(to))
+}
+
+// matches reports whether a record passes a summary filter, zero fields match everything
+func matches(record *expenses.Expense, filter expenses.SummaryFilter) bool {
+	amount := record.Amount.Minor
+	return (filter.CategoryID == 0 || record.CategoryID == filter.CategoryID) &&
+		(filter.MinAmount == 0 || amount >= filter.MinAmount) &&
+		(filter.MaxAmount == 0 || amount <= filter.MaxAmount)
+}
+
+// sum the expenses in range
+func (r *mockRepository) SumInRange(ctx context.Context, scope expenses.Scope, from, to time.Time, filter expenses.SummaryFilter) (*expenses.Total, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	total := &expenses.Total{Amount: money.Zero("USD")}
+	for _, record := range r.db {
+		if visible(scope, record) && inRange(record, from, to) && matches(record, filter) {
+			total.Amount.Minor += record.Amount.Minor
+			total.Count += 1
+		}
+	}
+	return total, nil
+}
+
+// sum the expenses in range per period
+func (r *mockRepository) GroupedSum(ctx context.Context, scope expenses.Scope, from, to time.Time, grouping expenses.Grouping, loc *time.Location, filter expenses.SummaryFilter) ([]*expenses.PeriodTotal, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	byStart := make(map[time.Time]*expenses.PeriodTotal)
+	for _, record := range r.db {
+		if !visible(scope, record) || !inRange(record, from, to) || !matches(record, filter) {
+			continue
+		}
+
+		if loc == nil {
+			loc = time.UTC
+		}
+		start := expenses.PeriodStart(record.ExpenseOccuredAt.In(loc), grouping)
+
+		period, ok := byStart[start]
+		if !ok {
+			period = &expenses.PeriodTotal{Start: start, Total: expenses.Total{Amount: money.Zero("USD")}}
+			byStart[start] = period
+		}
+		period.Amount.Minor += record.Amount.Minor
+		period.Count += 1
+	}
+
+	periods := slices.Collect(maps.Values(byStart))
+	slices.SortFunc(periods, func(a, b *expenses.PeriodTotal) int { return a.Start.Compare(b.Start) })
+	return periods, nil
+}
+
 // setupTestRepo sets up a mock repository layer in order to test the service layer
 func setupTestRepo(t *testing.T) expenses.Repository {
 	t.Helper()
@@ -141,32 +337,32 @@ func setupTestRepo(t *testing.T) expenses.Repository {
 	// list out records to load
 	recordsToLoad := []*expenses.Expense{
 		{
-			Amount:           8929,
+			Amount:           money.New(8929, "USD"),
 			ExpenseOccuredAt: time.Unix(1760574600, 0),
 			Description:      "dinner out with friends",
 		},
 		{
-			Amount:           7800,
+			Amount:           money.New(7800, "USD"),
 			ExpenseOccuredAt: time.Unix(1760792400, 0),
 			Description:      "coffee for office breakfast",
 		},
 		{
-			Amount:           4810,
+			Amount:           money.New(4810, "USD"),
 			ExpenseOccuredAt: time.Unix(1760877900, 0),
 			Description:      "bagels for office breakfast",
 		},
 		{
-			Amount:           31800,
+			Amount:           money.New(31800, "USD"),
 			ExpenseOccuredAt: time.Unix(1761160500, 0),
 			Description:      "new CAT5 cabling for office",
 		},
 		{
-			Amount:           74100,
+			Amount:           money.New(74100, "USD"),
 			ExpenseOccuredAt: time.Unix(1761404400, 0),
 			Description:      "replacing breaks on company prius",
 		},
 		{
-			Amount:           289,
+			Amount:           money.New(289, "USD"),
 			ExpenseOccuredAt: time.Unix(1761670800, 0),
 			Description:      "soda from maplefields",
 		},
@@ -209,7 +405,7 @@ func TestNewExpense(t *testing.T) {
 		name             string
 		inputOccuredAt   time.Time
 		inputDescription string
-		inputAmount      int64
+		inputAmount      money.Money
 		wantRecord       *expenses.Expense
 		expectError      bool
 		wantError        error
@@ -218,10 +414,10 @@ func TestNewExpense(t *testing.T) {
 			name:             "valid-expense-creation-b",
 			inputOccuredAt:   time.Unix(1761677891, 0),
 			inputDescription: "new coffee beans for espresso",
-			inputAmount:      2149,
+			inputAmount:      money.New(2149, "USD"),
 			wantRecord: &expenses.Expense{
 				ID:               7,
-				Amount:           2149,
+				Amount:           money.New(2149, "USD"),
 				ExpenseOccuredAt: time.Unix(1761677891, 0),
 				Description:      "new coffee beans for espresso",
 			},
@@ -232,10 +428,10 @@ func TestNewExpense(t *testing.T) {
 			name:             "valid-expense-creation-a",
 			inputOccuredAt:   time.Unix(1761721091, 0),
 			inputDescription: "new cat food for bodega cat",
-			inputAmount:      3499,
+			inputAmount:      money.New(3499, "USD"),
 			wantRecord: &expenses.Expense{
 				ID:               7,
-				Amount:           3499,
+				Amount:           money.New(3499, "USD"),
 				ExpenseOccuredAt: time.Unix(1761721091, 0),
 				Description:      "new cat food for bodega cat",
 			},
@@ -246,7 +442,7 @@ func TestNewExpense(t *testing.T) {
 			name:             "invalid-occured-at-time",
 			inputOccuredAt:   time.Unix(0, 0),
 			inputDescription: "new cat food for bodega cat",
-			inputAmount:      3499,
+			inputAmount:      money.New(3499, "USD"),
 			wantRecord:       nil,
 			expectError:      true,
 			wantError:        expenses.ErrInvalidOccuredAtTime,
@@ -255,7 +451,7 @@ func TestNewExpense(t *testing.T) {
 			name:             "invalid-amount-zero",
 			inputOccuredAt:   time.Unix(1761721091, 0),
 			inputDescription: "new cat food for bodega cat",
-			inputAmount:      0,
+			inputAmount:      money.New(0, "USD"),
 			wantRecord:       nil,
 			expectError:      true,
 			wantError:        expenses.ErrInvalidAmount,
@@ -264,11 +460,41 @@ func TestNewExpense(t *testing.T) {
 			name:             "invalid-amount-negative",
 			inputOccuredAt:   time.Unix(1761721091, 0),
 			inputDescription: "new cat food for bodega cat",
-			inputAmount:      -2,
+			inputAmount:      money.New(-2, "USD"),
 			wantRecord:       nil,
 			expectError:      true,
 			wantError:        expenses.ErrInvalidAmount,
 		},
+		{
+			name:             "invalid-currency",
+			inputOccuredAt:   time.Unix(1761721091, 0),
+			inputDescription: "new cat food for bodega cat",
+			inputAmount:      money.New(2149, "EUR"),
+			wantRecord:       nil,
+			expectError:      true,
+			wantError:        expenses.ErrInvalidCurrency,
+		},
+		{
+			name:             "valid-whitespace-collapsed",
+			inputOccuredAt:   time.Unix(1761721091, 0),
+			inputDescription: "  new cat food \t for\nbodega  cat ",
+			inputAmount:      money.New(3499, "USD"),
+			wantRecord: &expenses.Expense{
+				ID:               7,
+				Amount:           money.New(3499, "USD"),
+				ExpenseOccuredAt: time.Unix(1761721091, 0),
+				Description:      "new cat food for bodega cat",
+			},
+		},
+		{
+			name:             "invalid-description-whitespace",
+			inputOccuredAt:   time.Unix(1761721091, 0),
+			inputDescription: " \t\n ",
+			inputAmount:      money.New(3499, "USD"),
+			wantRecord:       nil,
+			expectError:      true,
+			wantError:        expenses.ErrInvalidDescription,
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -278,7 +504,7 @@ func TestNewExpense(t *testing.T) {
 
 			// call function
 			gotRecord, gotErr := serv.NewExpense(t.Context(),
-				testCase.inputOccuredAt, testCase.inputDescription, testCase.inputAmount,
+				testCase.inputOccuredAt, testCase.inputDescription, testCase.inputAmount, 0,
 			)
 
 			// test for expecting error
@@ -315,37 +541,37 @@ func TestGetAllExpenses(t *testing.T) {
 			wantRecords: []*expenses.Expense{
 				{
 					ID:               1,
-					Amount:           8929,
+					Amount:           money.New(8929, "USD"),
 					ExpenseOccuredAt: time.Unix(1760574600, 0),
 					Description:      "dinner out with friends",
 				},
 				{
 					ID:               2,
-					Amount:           7800,
+					Amount:           money.New(7800, "USD"),
 					ExpenseOccuredAt: time.Unix(1760792400, 0),
 					Description:      "coffee for office breakfast",
 				},
 				{
 					ID:               3,
-					Amount:           4810,
+					Amount:           money.New(4810, "USD"),
 					ExpenseOccuredAt: time.Unix(1760877900, 0),
 					Description:      "bagels for office breakfast",
 				},
 				{
 					ID:               4,
-					Amount:           31800,
+					Amount:           money.New(31800, "USD"),
 					ExpenseOccuredAt: time.Unix(1761160500, 0),
 					Description:      "new CAT5 cabling for office",
 				},
 				{
 					ID:               5,
-					Amount:           74100,
+					Amount:           money.New(74100, "USD"),
 					ExpenseOccuredAt: time.Unix(1761404400, 0),
 					Description:      "replacing breaks on company prius",
 				},
 				{
 					ID:               6,
-					Amount:           289,
+					Amount:           money.New(289, "USD"),
 					ExpenseOccuredAt: time.Unix(1761670800, 0),
 					Description:      "soda from maplefields",
 				},
@@ -400,7 +626,7 @@ func TestGetExpenseByID(t *testing.T) {
 			wantError:   nil,
 			wantRecord: &expenses.Expense{
 				ID:               1,
-				Amount:           8929,
+				Amount:           money.New(8929, "USD"),
 				ExpenseOccuredAt: time.Unix(1760574600, 0),
 				Description:      "dinner out with friends",
 			},
@@ -412,7 +638,7 @@ func TestGetExpenseByID(t *testing.T) {
 			wantError:   nil,
 			wantRecord: &expenses.Expense{
 				ID:               3,
-				Amount:           4810,
+				Amount:           money.New(4810, "USD"),
 				ExpenseOccuredAt: time.Unix(1760877900, 0),
 				Description:      "bagels for office breakfast",
 			},
@@ -461,13 +687,82 @@ func TestGetExpenseByID(t *testing.T) {
 	}
 }
 
+func TestGetExpensesByIDs(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputIDs    []int
+		expectError bool
+		wantError   error
+		wantIDs     []int
+		wantMissing []int
+	}{
+		{
+			name:        "valid-keeps-requested-order",
+			inputIDs:    []int{3, 1, 6},
+			expectError: false,
+			wantError:   nil,
+			wantIDs:     []int{3, 1, 6},
+			wantMissing: []int{},
+		},
+		{
+			name:        "valid-reports-missing",
+			inputIDs:    []int{2, 19, 4, 77},
+			expectError: false,
+			wantError:   nil,
+			wantIDs:     []int{2, 4},
+			wantMissing: []int{19, 77},
+		},
+		{
+			name:        "invalid-zero-id",
+			inputIDs:    []int{2, 0},
+			expectError: true,
+			wantError:   expenses.ErrInvalidID,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := setupTestRepo(t)
+			serv := expenses.NewService(repo)
+
+			// call function
+			gotRecords, gotMissing, gotErr := serv.GetExpensesByIDs(t.Context(), testCase.inputIDs)
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("GetExpensesByIDs(%v) got error: '%v', expected error: '%v'", testCase.inputIDs, gotErr, testCase.wantError)
+			}
+
+			// checking error type if its not nil
+			if gotErr != nil {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+				return
+			}
+
+			// checking result
+			gotIDs := make([]int, 0, len(gotRecords))
+			for _, record := range gotRecords {
+				gotIDs = append(gotIDs, record.ID)
+			}
+			if !slices.Equal(gotIDs, testCase.wantIDs) {
+				t.Errorf("got ids: %v, want ids: %v", gotIDs, testCase.wantIDs)
+			}
+			if !slices.Equal(gotMissing, testCase.wantMissing) {
+				t.Errorf("got missing: %v, want missing: %v", gotMissing, testCase.wantMissing)
+			}
+		})
+	}
+}
+
 func TestUpdateExpense(t *testing.T) {
 	testTable := []struct {
 		name             string
 		inputID          int
 		inputOccuredAt   time.Time
 		inputDescription string
-		inputAmount      int64
+		inputAmount      money.Money
 		expectError      bool
 		wantError        error
 	}{
@@ -476,7 +771,7 @@ func TestUpdateExpense(t *testing.T) {
 			inputID:          1,
 			inputOccuredAt:   time.Unix(1760574600, 0),
 			inputDescription: "dinner out with friends, not family",
-			inputAmount:      8929,
+			inputAmount:      money.New(8929, "USD"),
 			expectError:      false,
 			wantError:        nil,
 		},
@@ -485,7 +780,7 @@ func TestUpdateExpense(t *testing.T) {
 			inputID:          6,
 			inputOccuredAt:   time.Unix(1761670800, 0),
 			inputDescription: "soda from maplefields",
-			inputAmount:      189,
+			inputAmount:      money.New(189, "USD"),
 			expectError:      false,
 			wantError:        nil,
 		},
@@ -494,7 +789,7 @@ func TestUpdateExpense(t *testing.T) {
 			inputID:          3,
 			inputOccuredAt:   time.Unix(1760877820, 0),
 			inputDescription: "bagels for office breakfast",
-			inputAmount:      4810,
+			inputAmount:      money.New(4810, "USD"),
 			expectError:      false,
 			wantError:        nil,
 		},
@@ -503,7 +798,7 @@ func TestUpdateExpense(t *testing.T) {
 			inputID:          3,
 			inputOccuredAt:   time.Unix(0, 0),
 			inputDescription: "bagels for office breakfast",
-			inputAmount:      4810,
+			inputAmount:      money.New(4810, "USD"),
 			expectError:      true,
 			wantError:        expenses.ErrInvalidOccuredAtTime,
 		},
@@ -512,7 +807,7 @@ func TestUpdateExpense(t *testing.T) {
 			inputID:          3,
 			inputOccuredAt:   time.Unix(1760877900, 0),
 			inputDescription: "bagels for office breakfast",
-			inputAmount:      0,
+			inputAmount:      money.New(0, "USD"),
 			expectError:      true,
 			wantError:        expenses.ErrInvalidAmount,
 		},
@@ -521,7 +816,7 @@ func TestUpdateExpense(t *testing.T) {
 			inputID:          3,
 			inputOccuredAt:   time.Unix(1760877900, 0),
 			inputDescription: "bagels for office breakfast",
-			inputAmount:      -2,
+			inputAmount:      money.New(-2, "USD"),
 			expectError:      true,
 			wantError:        expenses.ErrInvalidAmount,
 		},
@@ -530,7 +825,7 @@ func TestUpdateExpense(t *testing.T) {
 			inputID:          1235,
 			inputOccuredAt:   time.Unix(1760574600, 0),
 			inputDescription: "dinner out with friends, not family",
-			inputAmount:      8929,
+			inputAmount:      money.New(8929, "USD"),
 			expectError:      true,
 			wantError:        expenses.ErrUnusedID,
 		},
@@ -539,7 +834,7 @@ func TestUpdateExpense(t *testing.T) {
 			inputID:          33,
 			inputOccuredAt:   time.Unix(1760574600, 0),
 			inputDescription: "dinner out with friends, not family",
-			inputAmount:      8929,
+			inputAmount:      money.New(8929, "USD"),
 			expectError:      true,
 			wantError:        expenses.ErrUnusedID,
 		},
@@ -552,7 +847,7 @@ func TestUpdateExpense(t *testing.T) {
 
 			// call function
 			gotErr := serv.UpdateExpense(t.Context(),
-				testCase.inputID, testCase.inputOccuredAt, testCase.inputDescription, testCase.inputAmount)
+				testCase.inputID, testCase.inputOccuredAt, testCase.inputDescription, testCase.inputAmount, 0)
 
 			// checking if we expect an error
 			if (gotErr != nil) != testCase.expectError {
@@ -624,3 +919,1679 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestTenantIsolation(t *testing.T) {
+	repo := setupTestRepo(t)
+	serv := expenses.NewService(repo)
+
+	ada := auth.WithUserID(t.Context(), 1)
+	grace := auth.WithUserID(t.Context(), 2)
+
+	exp, err := serv.NewExpense(ada, time.Unix(1761721091, 0), "ada's lunch", money.New(1500, "USD"), 0)
+	if err != nil {
+		t.Fatalf("NewExpense() got error: '%v'", err)
+	}
+	if exp.OwnerID != 1 {
+		t.Errorf("got owner id: %d, want owner id: 1", exp.OwnerID)
+	}
+
+	// the owner sees only their own records
+	records, err := serv.GetAllExpenses(ada)
+	if err != nil || len(records) != 1 {
+		t.Errorf("GetAllExpenses(ada) got %d records, error: '%v', want 1 record", len(records), err)
+	}
+
+	// everyone else is told it does not exist
+	if _, err := serv.GetExpenseByID(grace, exp.ID); !errors.Is(err, expenses.ErrUnusedID) {
+		t.Errorf("GetExpenseByID(grace) got error: '%v', want error: '%v'", err, expenses.ErrUnusedID)
+	}
+	if err := serv.UpdateExpense(grace, exp.ID, time.Unix(1761721091, 0), "mine now", money.New(1, "USD"), 0); !errors.Is(err, expenses.ErrUnusedID) {
+		t.Errorf("UpdateExpense(grace) got error: '%v', want error: '%v'", err, expenses.ErrUnusedID)
+	}
+	if err := serv.DeleteExpense(grace, exp.ID); !errors.Is(err, expenses.ErrUnusedID) {
+		t.Errorf("DeleteExpense(grace) got error: '%v', want error: '%v'", err, expenses.ErrUnusedID)
+	}
+	_, missing, err := serv.GetExpensesByIDs(grace, []int{exp.ID})
+	if err != nil || !slices.Equal(missing, []int{exp.ID}) {
+		t.Errorf("GetExpensesByIDs(grace) got missing: %v, error: '%v'", missing, err)
+	}
+
+	// admins see every tenant
+	records, err = serv.GetAllOwnersExpenses(grace)
+	if err != nil || len(records) != 7 {
+		t.Errorf("GetAllOwnersExpenses() got %d records, error: '%v', want 7 records", len(records), err)
+	}
+}
+
+// householdLookup puts users into households by id
+type householdLookup map[int]int
+
+func (h householdLookup) HouseholdIDForUser(ctx context.Context, userID int) (int, error) {
+	return h[userID], nil
+}
+
+func TestHouseholdSharing(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	// users 1 and 2 share household 7, user 3 is on their own
+	serv := expenses.NewService(repo, expenses.WithHouseholds(householdLookup{1: 7, 2: 7}))
+
+	ada := auth.WithUserID(t.Context(), 1)
+	grace := auth.WithUserID(t.Context(), 2)
+	linus := auth.WithUserID(t.Context(), 3)
+
+	exp, err := serv.NewExpense(ada, time.Unix(1761721091, 0), "groceries", money.New(6400, "USD"), 0)
+	if err != nil {
+		t.Fatalf("NewExpense() got error: '%v'", err)
+	}
+	if exp.HouseholdID != 7 {
+		t.Errorf("got household id: %d, want household id: 7", exp.HouseholdID)
+	}
+
+	// members of the household share the book
+	if _, err := serv.GetExpenseByID(grace, exp.ID); err != nil {
+		t.Errorf("GetExpenseByID(grace) got error: '%v'", err)
+	}
+	if _, err := serv.GetExpenseByID(linus, exp.ID); !errors.Is(err, expenses.ErrUnusedID) {
+		t.Errorf("GetExpenseByID(linus) got error: '%v', want error: '%v'", err, expenses.ErrUnusedID)
+	}
+}
+
+func TestMaxResults(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputMax    int
+		inputAdmin  bool
+		expectError bool
+		wantError   error
+		wantLen     int
+	}{
+		{
+			name:     "valid-no-cap",
+			inputMax: 0,
+			wantLen:  6,
+		},
+		{
+			name:     "valid-at-cap",
+			inputMax: 6,
+			wantLen:  6,
+		},
+		{
+			name:        "invalid-over-cap",
+			inputMax:    5,
+			expectError: true,
+			wantError:   expenses.ErrTooManyResults,
+		},
+		{
+			name:        "invalid-all-owners-over-cap",
+			inputMax:    5,
+			inputAdmin:  true,
+			expectError: true,
+			wantError:   expenses.ErrTooManyResults,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			service := expenses.NewService(setupTestRepo(t), expenses.WithMaxResults(testCase.inputMax))
+
+			read := service.GetAllExpenses
+			if testCase.inputAdmin {
+				read = service.GetAllOwnersExpenses
+			}
+			got, gotErr := read(t.Context())
+
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+			if testCase.expectError {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: '%v', want error: '%v'", gotErr, testCase.wantError)
+				}
+				return
+			}
+			if len(got) != testCase.wantLen {
+				t.Errorf("got %d records, want %d", len(got), testCase.wantLen)
+			}
+		})
+	}
+}
+
+func TestSummarizeExpenses(t *testing.T) {
+	testTable := []struct {
+		name          string
+		inputKind     expenses.SummaryTimeRange
+		inputModifier string
+		inputGroupBy  expenses.Grouping
+		expectError   bool
+		wantError     error
+		wantErrorText string
+		wantFrom      time.Time
+		wantAmount    int64
+		wantCount     int
+		wantPeriods   int
+	}{
+		{
+			name:        "valid-all-expenses-by-year",
+			inputKind:   expenses.AllExpenses,
+			wantAmount:  127728,
+			wantCount:   6,
+			wantPeriods: 1,
+		},
+		{
+			name:        "valid-this-month-by-day",
+			inputKind:   expenses.ThisMonth,
+			wantFrom:    time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC),
+			wantAmount:  127728,
+			wantCount:   6,
+			wantPeriods: 6,
+		},
+		{
+			name:         "valid-all-expenses-by-day",
+			inputKind:    expenses.AllExpenses,
+			inputGroupBy: expenses.GroupByDay,
+			wantAmount:   127728,
+			wantCount:    6,
+			wantPeriods:  6,
+		},
+		{
+			name:          "valid-custom-month-without-expenses",
+			inputKind:     expenses.CustomMonth,
+			inputModifier: "2025-09",
+			wantFrom:      time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "valid-custom-year",
+			inputKind:     expenses.CustomYear,
+			inputModifier: "2025",
+			wantFrom:      time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantAmount:    127728,
+			wantCount:     6,
+			wantPeriods:   1,
+		},
+		{
+			name:          "valid-month-range",
+			inputKind:     expenses.CustomYearMonthRange,
+			inputModifier: "2025-08:2025-10",
+			wantFrom:      time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC),
+			wantAmount:    127728,
+			wantCount:     6,
+			wantPeriods:   1,
+		},
+		{
+			name:          "valid-quarter-to-month-range",
+			inputKind:     expenses.CustomYearMonthRange,
+			inputModifier: "2025-Q3:2025-10",
+			wantFrom:      time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+			wantAmount:    127728,
+			wantCount:     6,
+			wantPeriods:   1,
+		},
+		{
+			name:          "valid-iso-week-by-day",
+			inputKind:     expenses.CustomPeriod,
+			inputModifier: "2025-W42",
+			wantFrom:      time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC),
+			wantAmount:    21539,
+			wantCount:     3,
+			wantPeriods:   3,
+		},
+		{
+			name:          "valid-iso-week-one-starts-in-last-year",
+			inputKind:     expenses.CustomPeriod,
+			inputModifier: "2026-W01",
+			wantFrom:      time.Date(2025, 12, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "valid-quarter-by-month",
+			inputKind:     expenses.CustomPeriod,
+			inputModifier: "2025-Q4",
+			wantFrom:      time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC),
+			wantAmount:    127728,
+			wantCount:     6,
+			wantPeriods:   1,
+		},
+		{
+			name:          "valid-day",
+			inputKind:     expenses.CustomPeriod,
+			inputModifier: "2025-10-22",
+			wantFrom:      time.Date(2025, 10, 22, 0, 0, 0, 0, time.UTC),
+			wantAmount:    31800,
+			wantCount:     1,
+			wantPeriods:   1,
+		},
+		{
+			name:        "invalid-missing-modifier",
+			inputKind:   expenses.CustomMonth,
+			expectError: true,
+			wantError:   expenses.ErrMissingModifier,
+		},
+		{
+			name:          "invalid-month",
+			inputKind:     expenses.CustomMonth,
+			inputModifier: "2025-13",
+			expectError:   true,
+		},
+		{
+			name:          "invalid-year-before-1970",
+			inputKind:     expenses.CustomYear,
+			inputModifier: "1969",
+			expectError:   true,
+		},
+		{
+			name:          "invalid-backwards-range",
+			inputKind:     expenses.CustomYearMonthRange,
+			inputModifier: "2025-10:2025-08",
+			expectError:   true,
+			wantErrorText: "first month needs to be before the last month",
+		},
+		{
+			name:          "invalid-quarter-as-month",
+			inputKind:     expenses.CustomMonth,
+			inputModifier: "2025-Q3",
+			expectError:   true,
+			wantErrorText: "needs to be a month, not a quarter",
+		},
+		{
+			name:          "invalid-week-past-last-of-year",
+			inputKind:     expenses.CustomPeriod,
+			inputModifier: "2025-W53",
+			expectError:   true,
+			wantErrorText: "week 53 needs to be 01 to 52, 2025 has 52 ISO weeks",
+		},
+		{
+			name:          "invalid-day-past-end-of-month",
+			inputKind:     expenses.CustomPeriod,
+			inputModifier: "2025-02-29",
+			expectError:   true,
+			wantErrorText: "2025-02 has 28 days",
+		},
+		{
+			name:          "invalid-quarter",
+			inputKind:     expenses.CustomPeriod,
+			inputModifier: "2025-Q5",
+			expectError:   true,
+			wantErrorText: "quarter 5 needs to be 1 to 4",
+		},
+		{
+			name:          "invalid-one-digit-month",
+			inputKind:     expenses.CustomPeriod,
+			inputModifier: "2025-3",
+			expectError:   true,
+			wantErrorText: `month "3" needs to be 2 digits`,
+		},
+		{
+			name:          "invalid-range-end",
+			inputKind:     expenses.CustomYearMonthRange,
+			inputModifier: "2025-01:2025-W60",
+			expectError:   true,
+			wantErrorText: "end of range: week 60",
+		},
+		{
+			name:          "invalid-notation",
+			inputKind:     expenses.CustomPeriod,
+			inputModifier: "2025-01-02-03",
+			expectError:   true,
+			wantErrorText: "period needs to be YYYY, YYYY-MM, YYYY-MM-DD, YYYY-Www, or YYYY-Qn",
+		},
+	}
+
+	now := func() time.Time { return time.Date(2025, 10, 30, 12, 0, 0, 0, time.UTC) }
+	service := expenses.NewService(setupTestRepo(t), expenses.WithClock(now))
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, gotErr := service.SummarizeExpenses(t.Context(), expenses.SummaryQuery{Range: testCase.inputKind, Period: testCase.inputModifier, GroupBy: testCase.inputGroupBy})
+			if testCase.expectError {
+				var timeErr *expenses.ErrInvalidTime
+				if !errors.As(gotErr, &timeErr) {
+					t.Fatalf("got error: '%v', want an ErrInvalidTime", gotErr)
+				}
+				if testCase.wantError != nil && !errors.Is(timeErr.WrappedError, testCase.wantError) {
+					t.Errorf("got error: '%v', want error: '%v'", gotErr, testCase.wantError)
+				}
+				if !strings.Contains(gotErr.Error(), testCase.wantErrorText) {
+					t.Errorf("got error: '%v', want it to mention: '%s'", gotErr, testCase.wantErrorText)
+				}
+				return
+			}
+			if gotErr != nil {
+				t.Fatalf("SummarizeExpenses() got error: '%v'", gotErr)
+			}
+
+			if !got.From.Equal(testCase.wantFrom) {
+				t.Errorf("got from: %v, want: %v", got.From, testCase.wantFrom)
+			}
+			if got.Amount.Minor != testCase.wantAmount || got.Count != testCase.wantCount {
+				t.Errorf("got total: %+v, want amount: %d, count: %d", got.Total, testCase.wantAmount, testCase.wantCount)
+			}
+			if len(got.Periods) != testCase.wantPeriods {
+				t.Errorf("got %d periods, want %d", len(got.Periods), testCase.wantPeriods)
+			}
+		})
+	}
+}
+
+// countingGroupedSum counts the GroupedSum calls made to the wrapped repository
+type countingGroupedSum struct {
+	expenses.Repository
+	calls atomic.Int32
+}
+
+func (r *countingGroupedSum) GroupedSum(ctx context.Context, scope expenses.Scope, from, to time.Time, grouping expenses.Grouping, loc *time.Location, filter expenses.SummaryFilter) ([]*expenses.PeriodTotal, error) {
+	r.calls.Add(1)
+	return r.Repository.GroupedSum(ctx, scope, from, to, grouping, loc, filter)
+}
+
+func TestSummarizeExpensesSharded(t *testing.T) {
+	testTable := []struct {
+		name          string
+		inputKind     expenses.SummaryTimeRange
+		inputModifier string
+		inputWorkers  int
+		wantQueries   int32
+	}{
+		{
+			name:         "valid-this-month-by-day",
+			inputKind:    expenses.ThisMonth,
+			inputWorkers: 4,
+			wantQueries:  4,
+		},
+		{
+			name:          "valid-year-by-month",
+			inputKind:     expenses.CustomYear,
+			inputModifier: "2025",
+			inputWorkers:  5,
+			wantQueries:   5,
+		},
+		{
+			name:          "valid-more-workers-than-months",
+			inputKind:     expenses.CustomYearMonthRange,
+			inputModifier: "2024-11:2025-10",
+			inputWorkers:  40,
+			wantQueries:   12,
+		},
+		{
+			name:         "valid-unbounded-is-not-split",
+			inputKind:    expenses.AllExpenses,
+			inputWorkers: 4,
+			wantQueries:  1,
+		},
+	}
+
+	now := func() time.Time { return time.Date(2025, 10, 30, 12, 0, 0, 0, time.UTC) }
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := setupTestRepo(t)
+			service := expenses.NewService(repo, expenses.WithClock(now))
+
+			// spread a few more expenses over the past year, so shards have something to merge
+			for month := range 12 {
+				occured := time.Date(2024, time.Month(11+month), 1+2*month, 9, 0, 0, 0, time.UTC)
+				if _, err := service.NewExpense(t.Context(), occured, "rent", money.New(95000, "USD"), 0); err != nil {
+					t.Fatalf("unable to add expense: %v", err)
+				}
+			}
+
+			want, err := service.SummarizeExpenses(t.Context(), expenses.SummaryQuery{Range: testCase.inputKind, Period: testCase.inputModifier})
+			if err != nil {
+				t.Fatalf("SummarizeExpenses() got error: '%v'", err)
+			}
+
+			counting := &countingGroupedSum{Repository: repo}
+			sharded := expenses.NewService(counting, expenses.WithClock(now), expenses.WithReportWorkers(testCase.inputWorkers))
+			got, err := sharded.SummarizeExpenses(t.Context(), expenses.SummaryQuery{Range: testCase.inputKind, Period: testCase.inputModifier})
+			if err != nil {
+				t.Fatalf("SummarizeExpenses() sharded got error: '%v'", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got summary: %+v, want summary: %+v", got, want)
+			}
+			if gotQueries := counting.calls.Load(); gotQueries != testCase.wantQueries {
+				t.Errorf("got %d grouped queries, want %d", gotQueries, testCase.wantQueries)
+			}
+		})
+	}
+}
+
+// stubConverter converts to EUR at 1.5, and knows no other currency
+type stubConverter struct{}
+
+func (stubConverter) Convert(currency string) (*fxrates.Conversion, error) {
+	if currency != "EUR" {
+		return nil, fxrates.ErrUnknownCurrency
+	}
+	return &fxrates.Conversion{From: "USD", To: "EUR", Rate: 1.5}, nil
+}
+
+func TestSummarizeExpensesQuery(t *testing.T) {
+	newYork, err := expenses.LoadZone("America/New_York")
+	if err != nil {
+		t.Fatalf("LoadZone() got error: '%v'", err)
+	}
+
+	testTable := []struct {
+		name           string
+		inputQuery     expenses.SummaryQuery
+		inputConverter bool
+		expectError    bool
+		wantError      error
+		wantFrom       time.Time
+		wantAmount     int64
+		wantCurrency   string
+		wantCount      int
+	}{
+		{
+			name:         "valid-this-month-in-zone",
+			inputQuery:   expenses.SummaryQuery{Range: expenses.ThisMonth, Location: newYork},
+			wantFrom:     time.Date(2025, 10, 1, 0, 0, 0, 0, newYork),
+			wantAmount:   127728,
+			wantCurrency: "USD",
+			wantCount:    6,
+		},
+		{
+			name:         "valid-amount-filter",
+			inputQuery:   expenses.SummaryQuery{Range: expenses.ThisMonth, Filter: expenses.SummaryFilter{MinAmount: 7800, MaxAmount: 31800}},
+			wantFrom:     time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC),
+			wantAmount:   48529,
+			wantCurrency: "USD",
+			wantCount:    3,
+		},
+		{
+			name:         "valid-category-filter",
+			inputQuery:   expenses.SummaryQuery{Range: expenses.AllExpenses, Filter: expenses.SummaryFilter{CategoryID: 3}},
+			wantCurrency: "USD",
+		},
+		{
+			name:           "valid-converted",
+			inputQuery:     expenses.SummaryQuery{Range: expenses.AllExpenses, Currency: "eur"},
+			inputConverter: true,
+			wantAmount:     191592,
+			wantCurrency:   "EUR",
+			wantCount:      6,
+		},
+		{
+			name:         "valid-own-currency-without-converter",
+			inputQuery:   expenses.SummaryQuery{Range: expenses.AllExpenses, Currency: "USD"},
+			wantAmount:   127728,
+			wantCurrency: "USD",
+			wantCount:    6,
+		},
+		{
+			name:        "invalid-min-above-max",
+			inputQuery:  expenses.SummaryQuery{Filter: expenses.SummaryFilter{MinAmount: 500, MaxAmount: 100}},
+			expectError: true,
+			wantError:   expenses.ErrInvalidFilter,
+		},
+		{
+			name:           "invalid-unknown-currency",
+			inputQuery:     expenses.SummaryQuery{Currency: "XYZ"},
+			inputConverter: true,
+			expectError:    true,
+			wantError:      expenses.ErrUnknownCurrency,
+		},
+		{
+			name:        "invalid-conversion-disabled",
+			inputQuery:  expenses.SummaryQuery{Currency: "EUR"},
+			expectError: true,
+			wantError:   expenses.ErrConversionDisabled,
+		},
+	}
+
+	now := func() time.Time { return time.Date(2025, 10, 30, 12, 0, 0, 0, time.UTC) }
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			opts := []expenses.Option{expenses.WithClock(now)}
+			if testCase.inputConverter {
+				opts = append(opts, expenses.WithConverter(stubConverter{}))
+			}
+			service := expenses.NewService(setupTestRepo(t), opts...)
+
+			got, gotErr := service.SummarizeExpenses(t.Context(), testCase.inputQuery)
+			if testCase.expectError {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Fatalf("got error: '%v', want error: '%v'", gotErr, testCase.wantError)
+				}
+				return
+			}
+			if gotErr != nil {
+				t.Fatalf("SummarizeExpenses() got error: '%v'", gotErr)
+			}
+
+			if !got.From.Equal(testCase.wantFrom) {
+				t.Errorf("got from: %v, want: %v", got.From, testCase.wantFrom)
+			}
+			if got.Amount.Minor != testCase.wantAmount || got.Amount.Currency != testCase.wantCurrency || got.Count != testCase.wantCount {
+				t.Errorf("got total: %+v, want amount: %d %s, count: %d", got.Total, testCase.wantAmount, testCase.wantCurrency, testCase.wantCount)
+			}
+			if (got.Conversion != nil) != (testCase.wantCurrency == "EUR") {
+				t.Errorf("got conversion: %+v", got.Conversion)
+			}
+		})
+	}
+}
+
+func TestListExpenses(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputFilter expenses.ListFilter
+		expectError bool
+		wantError   error
+		wantIDs     []int
+		wantNextID  int // 0 for no next page
+	}{
+		{
+			name:        "valid-first-page",
+			inputFilter: expenses.ListFilter{Limit: 2},
+			wantIDs:     []int{6, 5},
+			wantNextID:  5,
+		},
+		{
+			name: "valid-page-after-cursor",
+			inputFilter: expenses.ListFilter{
+				Limit: 2,
+				After: &expenses.Cursor{OccuredAt: time.Unix(1761404400, 0), ID: 5},
+			},
+			wantIDs:    []int{4, 3},
+			wantNextID: 3,
+		},
+		{
+			name:        "valid-last-page",
+			inputFilter: expenses.ListFilter{Limit: 10},
+			wantIDs:     []int{6, 5, 4, 3, 2, 1},
+		},
+		{
+			name:        "valid-amount-range",
+			inputFilter: expenses.ListFilter{MinAmount: 5000, MaxAmount: 40000},
+			wantIDs:     []int{4, 2, 1},
+		},
+		{
+			name:        "valid-search-ignores-case-and-accents",
+			inputFilter: expenses.ListFilter{Search: "  OFFICE  Bréakfast"},
+			wantIDs:     []int{3, 2},
+		},
+		{
+			name:        "invalid-min-above-max",
+			inputFilter: expenses.ListFilter{MinAmount: 5000, MaxAmount: 4000},
+			expectError: true,
+			wantError:   expenses.ErrInvalidFilter,
+		},
+		{
+			name:        "invalid-negative-limit",
+			inputFilter: expenses.ListFilter{Limit: -1},
+			expectError: true,
+			wantError:   expenses.ErrInvalidFilter,
+		},
+	}
+
+	service := expenses.NewService(setupTestRepo(t))
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, gotNext, gotErr := service.ListExpenses(t.Context(), testCase.inputFilter)
+			if testCase.expectError {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: '%v', want error: '%v'", gotErr, testCase.wantError)
+				}
+				return
+			}
+			if gotErr != nil {
+				t.Fatalf("ListExpenses() got error: '%v'", gotErr)
+			}
+
+			gotIDs := make([]int, 0, len(got))
+			for _, record := range got {
+				gotIDs = append(gotIDs, record.ID)
+			}
+			if !slices.Equal(gotIDs, testCase.wantIDs) {
+				t.Errorf("got ids: %v, want ids: %v", gotIDs, testCase.wantIDs)
+			}
+
+			switch {
+			case testCase.wantNextID == 0 && gotNext != nil:
+				t.Errorf("got next cursor: %+v, want none", gotNext)
+			case testCase.wantNextID != 0 && (gotNext == nil || gotNext.ID != testCase.wantNextID):
+				t.Errorf("got next cursor: %+v, want id: %d", gotNext, testCase.wantNextID)
+			}
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	testTable := []struct {
+		name        string
+		input       string
+		expectError bool
+		want        expenses.Cursor
+	}{
+		{
+			name:  "valid-round-trip",
+			input: expenses.Cursor{OccuredAt: time.Unix(1761404400, 0), ID: 5}.Encode(),
+			want:  expenses.Cursor{OccuredAt: time.Unix(1761404400, 0), ID: 5},
+		},
+		{
+			name:        "invalid-not-base64",
+			input:       "not a cursor!",
+			expectError: true,
+		},
+		{
+			name:        "invalid-missing-id",
+			input:       "MTc2MTQwNDQwMA",
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, gotErr := expenses.DecodeCursor(testCase.input)
+			if testCase.expectError {
+				if !errors.Is(gotErr, expenses.ErrInvalidCursor) {
+					t.Errorf("got error: '%v', want error: '%v'", gotErr, expenses.ErrInvalidCursor)
+				}
+				return
+			}
+			if gotErr != nil {
+				t.Fatalf("DecodeCursor() got error: '%v'", gotErr)
+			}
+			if !got.OccuredAt.Equal(testCase.want.OccuredAt) || got.ID != testCase.want.ID {
+				t.Errorf("got: %+v, want: %+v", *got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestChanges(t *testing.T) {
+	// a clock ahead of the seeded records, so none of them are in the current second
+	until := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	testTable := []struct {
+		name        string
+		inputSince  expenses.SyncCursor
+		inputLimit  int
+		expectError bool
+		wantError   error
+		wantIDs     []int
+		wantMore    bool
+		wantNextID  int
+		wantNextAt  time.Time // zero to not check it
+	}{
+		{
+			name:       "valid-first-sync",
+			wantIDs:    []int{1, 2, 3, 4, 5, 6},
+			wantNextAt: until,
+		},
+		{
+			name:       "valid-limited",
+			inputLimit: 4,
+			wantIDs:    []int{1, 2, 3, 4},
+			wantMore:   true,
+			wantNextID: 4,
+		},
+		{
+			name:       "valid-exactly-limit",
+			inputLimit: 6,
+			wantIDs:    []int{1, 2, 3, 4, 5, 6},
+			wantNextAt: until,
+		},
+		{
+			name:       "valid-up-to-date",
+			inputSince: expenses.SyncCursor{ChangedAt: until},
+			wantIDs:    []int{},
+			wantNextAt: until,
+		},
+		{
+			name:       "valid-within-tombstone-retention",
+			inputSince: expenses.SyncCursor{ChangedAt: until.Add(-24 * time.Hour)},
+			wantIDs:    []int{1, 2, 3, 4, 5, 6},
+			wantNextAt: until,
+		},
+		{
+			name:        "invalid-negative-limit",
+			inputLimit:  -1,
+			expectError: true,
+			wantError:   expenses.ErrInvalidFilter,
+		},
+		{
+			name:        "invalid-older-than-tombstones",
+			inputSince:  expenses.SyncCursor{ChangedAt: until.Add(-31 * 24 * time.Hour), ID: 3},
+			expectError: true,
+			wantError:   expenses.ErrResyncRequired,
+		},
+	}
+
+	service := expenses.NewService(setupTestRepo(t),
+		expenses.WithClock(func() time.Time { return until.Add(300 * time.Millisecond) }),
+		expenses.WithTombstoneRetention(30*24*time.Hour))
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, gotErr := service.Changes(t.Context(), testCase.inputSince, testCase.inputLimit)
+			if testCase.expectError {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: '%v', want error: '%v'", gotErr, testCase.wantError)
+				}
+				return
+			}
+			if gotErr != nil {
+				t.Fatalf("Changes() got error: '%v'", gotErr)
+			}
+
+			gotIDs := make([]int, 0, len(got.Changes))
+			for _, change := range got.Changes {
+				gotIDs = append(gotIDs, change.ID)
+			}
+			if !slices.Equal(gotIDs, testCase.wantIDs) {
+				t.Errorf("got ids: %v, want ids: %v", gotIDs, testCase.wantIDs)
+			}
+
+			if got.More != testCase.wantMore || got.Next.ID != testCase.wantNextID {
+				t.Errorf("got more: %v, next: %+v, want more: %v, next id: %d", got.More, got.Next, testCase.wantMore, testCase.wantNextID)
+			}
+			if !testCase.wantNextAt.IsZero() && !got.Next.ChangedAt.Equal(testCase.wantNextAt) {
+				t.Errorf("got next at: %v, want next at: %v", got.Next.ChangedAt, testCase.wantNextAt)
+			}
+		})
+	}
+}
+
+func TestSyncCursor(t *testing.T) {
+	testTable := []struct {
+		name        string
+		input       string
+		expectError bool
+		want        expenses.SyncCursor
+	}{
+		{
+			name:  "valid-round-trip",
+			input: expenses.SyncCursor{ChangedAt: time.Unix(1761404400, 0), ID: 5}.Encode(),
+			want:  expenses.SyncCursor{ChangedAt: time.Unix(1761404400, 0), ID: 5},
+		},
+		{
+			name:  "valid-whole-second",
+			input: expenses.SyncCursor{ChangedAt: time.Unix(1761404400, 0)}.Encode(),
+			want:  expenses.SyncCursor{ChangedAt: time.Unix(1761404400, 0)},
+		},
+		{
+			name:        "invalid-not-base64",
+			input:       "not a cursor!",
+			expectError: true,
+		},
+		{
+			name:        "invalid-missing-id",
+			input:       "MTc2MTQwNDQwMA",
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, gotErr := expenses.DecodeSyncCursor(testCase.input)
+			if testCase.expectError {
+				if !errors.Is(gotErr, expenses.ErrInvalidSyncCursor) {
+					t.Errorf("got error: '%v', want error: '%v'", gotErr, expenses.ErrInvalidSyncCursor)
+				}
+				return
+			}
+			if gotErr != nil {
+				t.Fatalf("DecodeSyncCursor() got error: '%v'", gotErr)
+			}
+			if !got.ChangedAt.Equal(testCase.want.ChangedAt) || got.ID != testCase.want.ID {
+				t.Errorf("got: %+v, want: %+v", got, testCase.want)
+			}
+		})
+	}
+}
+
+// countingInvalidator counts how often the cache would have been dropped
+type countingInvalidator struct {
+	count int
+}
+
+func (i *countingInvalidator) Invalidate() { i.count += 1 }
+
+func TestInvalidator(t *testing.T) {
+	testTable := []struct {
+		name      string
+		inputCall func(service *expenses.ExpenseService) error
+		wantCount int
+	}{
+		{
+			name: "valid-create",
+			inputCall: func(service *expenses.ExpenseService) error {
+				_, err := service.NewExpense(t.Context(), time.Unix(1761670800, 0), "soda", money.New(289, "USD"), 0)
+				return err
+			},
+			wantCount: 1,
+		},
+		{
+			name: "valid-update",
+			inputCall: func(service *expenses.ExpenseService) error {
+				return service.UpdateExpense(t.Context(), 1, time.Unix(1761670800, 0), "soda", money.New(289, "USD"), 0)
+			},
+			wantCount: 1,
+		},
+		{
+			name: "valid-delete",
+			inputCall: func(service *expenses.ExpenseService) error {
+				return service.DeleteExpense(t.Context(), 1)
+			},
+			wantCount: 1,
+		},
+		{
+			name: "valid-read-keeps-cache",
+			inputCall: func(service *expenses.ExpenseService) error {
+				_, err := service.GetExpenseByID(t.Context(), 1)
+				return err
+			},
+			wantCount: 0,
+		},
+		{
+			name: "invalid-failed-update-keeps-cache",
+			inputCall: func(service *expenses.ExpenseService) error {
+				err := service.UpdateExpense(t.Context(), 100, time.Unix(1761670800, 0), "soda", money.New(289, "USD"), 0)
+				if !errors.Is(err, expenses.ErrUnusedID) {
+					return err
+				}
+				return nil
+			},
+			wantCount: 0,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			invalidator := &countingInvalidator{}
+			service := expenses.NewService(setupTestRepo(t), expenses.WithInvalidator(invalidator))
+
+			if err := testCase.inputCall(service); err != nil {
+				t.Fatalf("got error: '%v'", err)
+			}
+			if invalidator.count != testCase.wantCount {
+				t.Errorf("got %d invalidations, want %d", invalidator.count, testCase.wantCount)
+			}
+		})
+	}
+}
+
+func BenchmarkSummarizeExpenses(b *testing.B) {
+	// summaries are summed by the database, so they are measured against sqlite
+	repo, err := sqlite.NewSqliteRepository("sqlite3", ":memory:")
+	if err != nil {
+		b.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+	b.Cleanup(func() { repo.Close() })
+
+	_, err = repo.DB.Exec(`
+  CREATE TABLE
+    expenses (
+      id INTEGER PRIMARY KEY,
+      owner_id INTEGER,
+      household_id INTEGER,
+      created_at INTEGER,
+      updated_at INTEGER NOT NULL DEFAULT 0,
+      occured_at INTEGER,
+      occured_zone TEXT NOT NULL DEFAULT '',
+      description TEXT,
+      description_key TEXT NOT NULL DEFAULT '',
+      amount INTEGER,
+      category_id INTEGER,
+      version INTEGER NOT NULL DEFAULT 1,
+      client_id TEXT
+    );`)
+	if err != nil {
+		b.Fatalf("unable to create table: %v", err)
+	}
+	rollups, err := migrations.Up("00014_expense_rollups.sql")
+	if err != nil {
+		b.Fatalf("unable to read rollups migration: %v", err)
+	}
+	if _, err := repo.DB.Exec(rollups); err != nil {
+		b.Fatalf("unable to create rollups: %v", err)
+	}
+	tombstones, err := migrations.Up("00023_expense_tombstones.sql")
+	if err != nil {
+		b.Fatalf("unable to read tombstones migration: %v", err)
+	}
+	if _, err := repo.DB.Exec(tombstones); err != nil {
+		b.Fatalf("unable to create tombstones: %v", err)
+	}
+	if _, err := repo.EnsureIndexes(b.Context()); err != nil {
+		b.Fatalf("unable to create indexes: %v", err)
+	}
+
+	// a year of expenses, one every two hours
+	now := time.Date(2025, 10, 30, 12, 0, 0, 0, time.UTC)
+	for i := range 365 * 12 {
+		exp := &expenses.Expense{ExpenseOccuredAt: now.Add(-time.Duration(i) * 2 * time.Hour), Description: "bench", Amount: money.New(1250, "USD")}
+		if _, err := repo.Create(b.Context(), exp); err != nil {
+			b.Fatalf("unable to insert expense: %v", err)
+		}
+	}
+
+	service := expenses.NewService(repo, expenses.WithClock(func() time.Time { return now }))
+
+	benchTable := []struct {
+		name          string
+		inputKind     expenses.SummaryTimeRange
+		inputModifier string
+	}{
+		{name: "this-month-by-day", inputKind: expenses.ThisMonth},
+		{name: "this-year-by-month", inputKind: expenses.ThisYear},
+		{name: "all-by-year", inputKind: expenses.AllExpenses},
+	}
+
+	for _, benchCase := range benchTable {
+		b.Run(benchCase.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := service.SummarizeExpenses(b.Context(), expenses.SummaryQuery{Range: benchCase.inputKind, Period: benchCase.inputModifier}); err != nil {
+					b.Fatalf("SummarizeExpenses() got error: '%v'", err)
+				}
+			}
+		})
+	}
+}
+
+func TestEvents(t *testing.T) {
+	bus := expenses.NewBus()
+	var got []expenses.Event
+	bus.Subscribe(func(ctx context.Context, event expenses.Event) { got = append(got, event) })
+
+	service := expenses.NewService(setupTestRepo(t), expenses.WithEvents(bus))
+
+	created, err := service.NewExpense(t.Context(), time.Unix(1761670800, 0), "soda", money.New(289, "USD"), 0)
+	if err != nil {
+		t.Fatalf("NewExpense() got error: '%v'", err)
+	}
+	if err := service.UpdateExpense(t.Context(), created.ID, time.Unix(1761670800, 0), "soda and chips", money.New(489, "USD"), 0); err != nil {
+		t.Fatalf("UpdateExpense() got error: '%v'", err)
+	}
+	if err := service.DeleteExpense(t.Context(), created.ID); err != nil {
+		t.Fatalf("DeleteExpense() got error: '%v'", err)
+	}
+
+	// nothing changed, so nothing is published
+	if err := service.DeleteExpense(t.Context(), created.ID); !errors.Is(err, expenses.ErrUnusedID) {
+		t.Fatalf("DeleteExpense() again got error: '%v', want: '%v'", err, expenses.ErrUnusedID)
+	}
+	if _, err := service.NewExpense(t.Context(), time.Unix(1761670800, 0), "", money.New(289, "USD"), 0); err == nil {
+		t.Fatal("NewExpense() expected error, got none")
+	}
+
+	wantTypes := []expenses.EventType{expenses.ExpenseCreated, expenses.ExpenseUpdated, expenses.ExpenseDeleted}
+	if len(got) != len(wantTypes) {
+		t.Fatalf("got %d events, want %d", len(got), len(wantTypes))
+	}
+	for i, event := range got {
+		if event.Type != wantTypes[i] {
+			t.Errorf("event %d got type: %q, want type: %q", i, event.Type, wantTypes[i])
+		}
+		if event.Expense.ID != created.ID {
+			t.Errorf("event %d got expense: %d, want expense: %d", i, event.Expense.ID, created.ID)
+		}
+	}
+	if description := got[1].Expense.Description; description != "soda and chips" {
+		t.Errorf("update event got description: %q, want: %q", description, "soda and chips")
+	}
+}
+
+func TestZoneName(t *testing.T) {
+	berlin, err := expenses.LoadZone("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadZone() got error: '%v'", err)
+	}
+	instant := time.Unix(1761249149, 0)
+
+	testTable := []struct {
+		name      string
+		inputTime time.Time
+		want      string
+	}{
+		{
+			name:      "utc",
+			inputTime: instant.UTC(),
+			want:      "UTC",
+		},
+		{
+			name:      "iana-zone",
+			inputTime: instant.In(berlin),
+			want:      "Europe/Berlin",
+		},
+		{
+			name:      "offset-without-name",
+			inputTime: instant.In(time.FixedZone("", -4*60*60)),
+			want:      "-04:00",
+		},
+		{
+			name:      "abbreviation-is-not-a-zone",
+			inputTime: instant.In(time.FixedZone("CEST", 2*60*60)),
+			want:      "+02:00",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got := expenses.ZoneName(testCase.inputTime)
+			if got != testCase.want {
+				t.Fatalf("ZoneName() got: %q, want: %q", got, testCase.want)
+			}
+
+			// read back, it is the same wall clock time
+			zone, err := expenses.LoadZone(got)
+			if err != nil {
+				t.Fatalf("LoadZone(%q) got error: '%v'", got, err)
+			}
+			if back := instant.In(zone); back.Format(time.RFC3339) != testCase.inputTime.Format(time.RFC3339) {
+				t.Errorf("LoadZone(%q) got: %v, want: %v", got, back, testCase.inputTime)
+			}
+		})
+	}
+
+	for _, name := range []string{"", "Local", "Mars/Olympus_Mons"} {
+		if _, err := expenses.LoadZone(name); !errors.Is(err, expenses.ErrInvalidZone) {
+			t.Errorf("LoadZone(%q) got error: '%v', want error: '%v'", name, err, expenses.ErrInvalidZone)
+		}
+	}
+}
+
+func TestNewExpenseProblems(t *testing.T) {
+	serv := expenses.NewService(setupTestRepo(t))
+
+	// every check fails, and each is reported rather than only the first
+	_, err := serv.NewExpense(t.Context(), time.Unix(0, 0), " \t ", money.New(0, "USD"), 0)
+	for _, want := range []error{expenses.ErrInvalidAmount, expenses.ErrInvalidDescription, expenses.ErrInvalidOccuredAtTime} {
+		if !errors.Is(err, want) {
+			t.Errorf("NewExpense() got error: '%v', want it to include: '%v'", err, want)
+		}
+	}
+	if expenses.KindOf(err) != expenses.KindInvalid {
+		t.Errorf("KindOf() got: %v, want: %v", expenses.KindOf(err), expenses.KindInvalid)
+	}
+
+	gotFields := make([]string, 0)
+	for _, problem := range expenses.Problems(err) {
+		gotFields = append(gotFields, expenses.FieldOf(problem))
+	}
+	if wantFields := []string{"amount", "description", "occured_at"}; !slices.Equal(gotFields, wantFields) {
+		t.Errorf("Problems() got fields: %v, want fields: %v", gotFields, wantFields)
+	}
+
+	// a single problem is a problem of its own
+	if got := expenses.Problems(expenses.ErrInvalidID); len(got) != 1 || got[0] != expenses.ErrInvalidID {
+		t.Errorf("Problems() got: %v, want: [%v]", got, expenses.ErrInvalidID)
+	}
+}
+
+// staticCategories has categories 1 and 2 in every book, and fails to look up 99
+type staticCategories struct{}
+
+var errCategoryLookup = errors.New("category lookup failed")
+
+func (staticCategories) CategoryExists(ctx context.Context, scope expenses.Scope, id int) (bool, error) {
+	if id == 99 {
+		return false, errCategoryLookup
+	}
+	return id == 1 || id == 2, nil
+}
+
+func TestNewExpenseCategory(t *testing.T) {
+	testTable := []struct {
+		name          string
+		inputLookup   expenses.CategoryLookup
+		inputCategory int
+		expectError   bool
+		wantError     error
+	}{
+		{
+			name:          "valid-uncategorized",
+			inputLookup:   staticCategories{},
+			inputCategory: 0,
+		},
+		{
+			name:          "valid-category",
+			inputLookup:   staticCategories{},
+			inputCategory: 2,
+		},
+		{
+			name:          "valid-uncategorized-without-categories",
+			inputCategory: 0,
+		},
+		{
+			name:          "invalid-unknown-category",
+			inputLookup:   staticCategories{},
+			inputCategory: 3,
+			expectError:   true,
+			wantError:     expenses.ErrUnknownCategory,
+		},
+		{
+			name:          "invalid-without-categories",
+			inputCategory: 1,
+			expectError:   true,
+			wantError:     expenses.ErrUnknownCategory,
+		},
+		{
+			name:          "invalid-lookup-failed",
+			inputLookup:   staticCategories{},
+			inputCategory: 99,
+			expectError:   true,
+			wantError:     errCategoryLookup,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			var opts []expenses.Option
+			if testCase.inputLookup != nil {
+				opts = append(opts, expenses.WithCategories(testCase.inputLookup))
+			}
+			serv := expenses.NewService(setupTestRepo(t), opts...)
+
+			got, err := serv.NewExpense(t.Context(), time.Unix(1761231600, 0), "train ticket", money.New(1250, "USD"), testCase.inputCategory)
+			if testCase.expectError {
+				if !errors.Is(err, testCase.wantError) {
+					t.Errorf("NewExpense() got error: '%v', want error: '%v'", err, testCase.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewExpense() got error: '%v'", err)
+			}
+			if got.CategoryID != testCase.inputCategory {
+				t.Errorf("NewExpense() got category: %d, want: %d", got.CategoryID, testCase.inputCategory)
+			}
+
+			// the category is checked again on update
+			if err := serv.UpdateExpense(t.Context(), got.ID, got.ExpenseOccuredAt, got.Description, got.Amount, 3); !errors.Is(err, expenses.ErrUnknownCategory) {
+				t.Errorf("UpdateExpense() got error: '%v', want error: '%v'", err, expenses.ErrUnknownCategory)
+			}
+		})
+	}
+
+	// a failed lookup isn't a problem with the expense, so it isn't joined with the others
+	serv := expenses.NewService(setupTestRepo(t), expenses.WithCategories(staticCategories{}))
+	_, err := serv.NewExpense(t.Context(), time.Unix(1761231600, 0), "train ticket", money.New(0, "USD"), 99)
+	if expenses.KindOf(err) != expenses.KindInternal {
+		t.Errorf("KindOf() got: %v, want: %v", expenses.KindOf(err), expenses.KindInternal)
+	}
+}
+
+func TestDuplicateWindow(t *testing.T) {
+	occuredAt := time.Unix(1761231600, 0)
+
+	testTable := []struct {
+		name             string
+		inputWindow      time.Duration
+		inputUser        int
+		inputAfter       time.Duration
+		inputDescription string
+		inputAmount      money.Money
+		expectError      bool
+		wantError        error
+	}{
+		{
+			name:             "invalid-same-create",
+			inputWindow:      time.Minute,
+			inputUser:        1,
+			inputAfter:       time.Second,
+			inputDescription: "train ticket",
+			inputAmount:      money.New(1250, "USD"),
+			expectError:      true,
+			wantError:        expenses.ErrDuplicateExpense,
+		},
+		{
+			name:             "invalid-same-create-folded",
+			inputWindow:      time.Minute,
+			inputUser:        1,
+			inputAfter:       time.Second,
+			inputDescription: " Train  TICKET",
+			inputAmount:      money.New(1250, "USD"),
+			expectError:      true,
+			wantError:        expenses.ErrDuplicateExpense,
+		},
+		{
+			name:             "valid-other-amount",
+			inputWindow:      time.Minute,
+			inputUser:        1,
+			inputAfter:       time.Second,
+			inputDescription: "train ticket",
+			inputAmount:      money.New(1350, "USD"),
+		},
+		{
+			name:             "valid-other-user",
+			inputWindow:      time.Minute,
+			inputUser:        2,
+			inputAfter:       time.Second,
+			inputDescription: "train ticket",
+			inputAmount:      money.New(1250, "USD"),
+		},
+		{
+			name:             "valid-after-window",
+			inputWindow:      time.Minute,
+			inputUser:        1,
+			inputAfter:       time.Minute,
+			inputDescription: "train ticket",
+			inputAmount:      money.New(1250, "USD"),
+		},
+		{
+			name:             "valid-without-window",
+			inputUser:        1,
+			inputAfter:       time.Second,
+			inputDescription: "train ticket",
+			inputAmount:      money.New(1250, "USD"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			now := time.Date(2025, 10, 23, 15, 0, 0, 0, time.UTC)
+			clock := func() time.Time { return now }
+			serv := expenses.NewService(setupTestRepo(t), expenses.WithClock(clock), expenses.WithDuplicateWindow(testCase.inputWindow))
+
+			first, err := serv.NewExpense(auth.WithUserID(t.Context(), 1), occuredAt, "train ticket", money.New(1250, "USD"), 0)
+			if err != nil {
+				t.Fatalf("NewExpense() got error: '%v'", err)
+			}
+
+			now = now.Add(testCase.inputAfter)
+			_, err = serv.NewExpense(auth.WithUserID(t.Context(), testCase.inputUser), occuredAt, testCase.inputDescription, testCase.inputAmount, 0)
+			if testCase.expectError {
+				if !errors.Is(err, testCase.wantError) || expenses.KindOf(err) != expenses.KindConflict {
+					t.Errorf("NewExpense() got error: '%v', want error: '%v'", err, testCase.wantError)
+				}
+				if want := fmt.Sprintf("expense %d", first.ID); !strings.Contains(fmt.Sprint(err), want) {
+					t.Errorf("NewExpense() got error: '%v', want it to mention: '%s'", err, want)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("NewExpense() got error: '%v'", err)
+			}
+		})
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	testTable := []struct {
+		name      string
+		input     error
+		wantKind  expenses.Kind
+		wantField string
+	}{
+		{
+			name:      "invalid-sentinel",
+			input:     expenses.ErrInvalidAmount,
+			wantKind:  expenses.KindInvalid,
+			wantField: "amount",
+		},
+		{
+			name:      "invalid-wrapped",
+			input:     fmt.Errorf("%w, EUR rather than USD", expenses.ErrInvalidCurrency),
+			wantKind:  expenses.KindInvalid,
+			wantField: "currency",
+		},
+		{
+			name:     "invalid-summary-range",
+			input:    &expenses.ErrInvalidTime{ProvidedTime: "2025-13"},
+			wantKind: expenses.KindInvalid,
+		},
+		{
+			name:     "not-found-from-backend",
+			input:    expenses.NewError(expenses.KindNotFound, &sqlite.QueryError{Query: "mocked for test", Err: sql.ErrNoRows}),
+			wantKind: expenses.KindNotFound,
+		},
+		{
+			name:     "internal-unknown",
+			input:    errors.New("disk is full"),
+			wantKind: expenses.KindInternal,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := expenses.KindOf(testCase.input); got != testCase.wantKind {
+				t.Errorf("KindOf() got: %v, want: %v", got, testCase.wantKind)
+			}
+			if got := expenses.FieldOf(testCase.input); got != testCase.wantField {
+				t.Errorf("FieldOf() got: %q, want: %q", got, testCase.wantField)
+			}
+		})
+	}
+}
+
+func TestFoldDescription(t *testing.T) {
+	testTable := []struct {
+		name          string
+		input         string
+		wantNormalize string
+		wantFold      string
+	}{
+		{
+			name:          "already-normal",
+			input:         "coffee at work",
+			wantNormalize: "coffee at work",
+			wantFold:      "coffee at work",
+		},
+		{
+			name:          "whitespace",
+			input:         "\t Coffee   at\nWork  ",
+			wantNormalize: "Coffee at Work",
+			wantFold:      "coffee at work",
+		},
+		{
+			name:          "accents",
+			input:         "Café Müller, Señor Ñoño",
+			wantNormalize: "Café Müller, Señor Ñoño",
+			wantFold:      "cafe muller, senor nono",
+		},
+		{
+			name:          "decomposed-accents",
+			input:         "Cafe\u0301",
+			wantNormalize: "Cafe\u0301",
+			wantFold:      "cafe",
+		},
+		{
+			name:          "only-whitespace",
+			input:         " \t ",
+			wantNormalize: "",
+			wantFold:      "",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := expenses.NormalizeDescription(testCase.input); got != testCase.wantNormalize {
+				t.Errorf("NormalizeDescription() got: %q, want: %q", got, testCase.wantNormalize)
+			}
+			if got := expenses.FoldDescription(testCase.input); got != testCase.wantFold {
+				t.Errorf("FoldDescription() got: %q, want: %q", got, testCase.wantFold)
+			}
+		})
+	}
+}
+
+func TestFuturePolicy(t *testing.T) {
+	now := func() time.Time { return time.Date(2025, 10, 23, 12, 0, 0, 0, time.UTC) }
+	skew := 10 * time.Minute
+
+	testTable := []struct {
+		name            string
+		inputPolicy     expenses.FuturePolicy
+		inputOccuredAt  time.Time
+		expectError     bool
+		wantError       error
+		wantFutureDated bool
+	}{
+		{
+			name:           "valid-allow-future",
+			inputPolicy:    expenses.FutureAllow,
+			inputOccuredAt: time.Date(2026, 10, 23, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:            "valid-flag-future",
+			inputPolicy:     expenses.FutureFlag,
+			inputOccuredAt:  time.Date(2026, 10, 23, 12, 0, 0, 0, time.UTC),
+			wantFutureDated: true,
+		},
+		{
+			name:           "valid-flag-within-skew",
+			inputPolicy:    expenses.FutureFlag,
+			inputOccuredAt: now().Add(5 * time.Minute),
+		},
+		{
+			name:           "valid-reject-within-skew",
+			inputPolicy:    expenses.FutureReject,
+			inputOccuredAt: now().Add(5 * time.Minute),
+		},
+		{
+			name:           "invalid-reject-future",
+			inputPolicy:    expenses.FutureReject,
+			inputOccuredAt: time.Date(2026, 10, 23, 12, 0, 0, 0, time.UTC),
+			expectError:    true,
+			wantError:      expenses.ErrFutureOccuredAt,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			serv := expenses.NewService(setupTestRepo(t), expenses.WithClock(now), expenses.WithFuturePolicy(testCase.inputPolicy, skew))
+
+			created, err := serv.NewExpense(t.Context(), testCase.inputOccuredAt, "typo in the year", money.New(1250, "USD"), 0)
+			if testCase.expectError {
+				if !errors.Is(err, testCase.wantError) {
+					t.Errorf("NewExpense() got error: '%v', want error: '%v'", err, testCase.wantError)
+				}
+				// updates are checked the same way
+				if err := serv.UpdateExpense(t.Context(), 1, testCase.inputOccuredAt, "typo in the year", money.New(1250, "USD"), 0); !errors.Is(err, testCase.wantError) {
+					t.Errorf("UpdateExpense() got error: '%v', want error: '%v'", err, testCase.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewExpense() got error: '%v'", err)
+			}
+			if created.FutureDated != testCase.wantFutureDated {
+				t.Errorf("NewExpense() got future dated: %v, want: %v", created.FutureDated, testCase.wantFutureDated)
+			}
+
+			// flagged on reads as well
+			read, err := serv.GetExpenseByID(t.Context(), created.ID)
+			if err != nil {
+				t.Fatalf("GetExpenseByID() got error: '%v'", err)
+			}
+			if read.FutureDated != testCase.wantFutureDated {
+				t.Errorf("GetExpenseByID() got future dated: %v, want: %v", read.FutureDated, testCase.wantFutureDated)
+			}
+		})
+	}
+}
+
+// setupMutationService creates a service backed by a migrated in-memory sqlite database, as batches rely on its
+// transactions. User 1 has expense 1 at version 2, it was updated once, and expense 2 at version 1
+func setupMutationService(t *testing.T, opts ...expenses.Option) *expenses.ExpenseService {
+	t.Helper()
+
+	repo, err := sqlite.NewSqliteRepository("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+	t.Cleanup(func() { repo.Close() })
+
+	if _, err := sqlite.Migrate(t.Context(), repo.DB); err != nil {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+
+	service := expenses.NewService(repo, opts...)
+	ctx := auth.WithUserID(t.Context(), 1)
+	occuredAt := time.Unix(1761670800, 0)
+	for _, description := range []string{"soda", "chips"} {
+		if _, err := service.NewExpense(ctx, occuredAt, description, money.New(289, money.DefaultCurrency), 0); err != nil {
+			t.Fatalf("NewExpense() got error: '%v'", err)
+		}
+	}
+	if err := service.UpdateExpense(ctx, 1, occuredAt, "soda and ice", money.New(389, money.DefaultCurrency), 0); err != nil {
+		t.Fatalf("UpdateExpense() got error: '%v'", err)
+	}
+	return service
+}
+
+func TestApplyMutations(t *testing.T) {
+	const clientID = "0b6c1c6e-4a8e-4c3e-9d1a-2f4b5c6d7e8f"
+	occuredAt := time.Unix(1761670800, 0)
+	edit := func(description string) *expenses.Expense {
+		return &expenses.Expense{ExpenseOccuredAt: occuredAt, Description: description, Amount: money.New(450, money.DefaultCurrency)}
+	}
+
+	testTable := []struct {
+		name           string
+		inputMutations []*expenses.Mutation
+		expectError    bool
+		wantError      error
+		wantErrors     []error // one per result, nil once applied
+		wantVersions   []int   // of the result's expense, 0 when it has none
+		wantIDs        []int   // the expenses left afterwards
+	}{
+		{
+			name: "valid-create",
+			inputMutations: []*expenses.Mutation{
+				{Op: expenses.MutationCreate, ClientID: strings.ToUpper(clientID), Expense: edit("bagel")},
+			},
+			wantErrors:   []error{nil},
+			wantVersions: []int{1},
+			wantIDs:      []int{1, 2, 3},
+		},
+		{
+			name: "valid-create-then-edit-by-client-id",
+			inputMutations: []*expenses.Mutation{
+				{Op: expenses.MutationCreate, ClientID: clientID, Expense: edit("bagel")},
+				{Op: expenses.MutationUpdate, ClientID: clientID, BaseVersion: 1, Expense: edit("bagel and coffee")},
+			},
+			wantErrors:   []error{nil, nil},
+			wantVersions: []int{1, 2},
+			wantIDs:      []int{1, 2, 3},
+		},
+		{
+			name: "valid-update-and-delete",
+			inputMutations: []*expenses.Mutation{
+				{Op: expenses.MutationUpdate, ID: 1, BaseVersion: 2, Expense: edit("soda")},
+				{Op: expenses.MutationDelete, ID: 2, BaseVersion: 1},
+			},
+			wantErrors:   []error{nil, nil},
+			wantVersions: []int{3, 0},
+			wantIDs:      []int{1},
+		},
+		{
+			name: "conflict-stale-version-rolls-back-batch",
+			inputMutations: []*expenses.Mutation{
+				{Op: expenses.MutationDelete, ID: 2, BaseVersion: 1},
+				{Op: expenses.MutationUpdate, ID: 1, BaseVersion: 1, Expense: edit("soda")},
+			},
+			wantErrors:   []error{expenses.ErrNotApplied, expenses.ErrVersionConflict},
+			wantVersions: []int{0, 2},
+			wantIDs:      []int{1, 2},
+		},
+		{
+			name: "conflict-deleted",
+			inputMutations: []*expenses.Mutation{
+				{Op: expenses.MutationUpdate, ID: 9, BaseVersion: 1, Expense: edit("soda")},
+			},
+			wantErrors:   []error{expenses.ErrExpenseGone},
+			wantVersions: []int{0},
+			wantIDs:      []int{1, 2},
+		},
+		{
+			name: "invalid-mutations",
+			inputMutations: []*expenses.Mutation{
+				{Op: expenses.MutationCreate, ClientID: "not-a-uuid", Expense: edit("bagel")},
+				{Op: expenses.MutationDelete, ID: 2},
+				{Op: expenses.MutationUpdate, ID: 1, BaseVersion: 2},
+				{Op: expenses.MutationDelete, ID: 1, BaseVersion: 2},
+			},
+			wantErrors: []error{
+				expenses.ErrInvalidClientID, expenses.ErrInvalidBaseVersion, expenses.ErrMissingExpense, expenses.ErrNotApplied,
+			},
+			wantVersions: []int{0, 0, 0, 0},
+			wantIDs:      []int{1, 2},
+		},
+		{
+			name:           "invalid-empty-batch",
+			inputMutations: []*expenses.Mutation{},
+			expectError:    true,
+			wantError:      expenses.ErrInvalidBatch,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			service := setupMutationService(t)
+			ctx := auth.WithUserID(t.Context(), 1)
+
+			results, gotErr := service.ApplyMutations(ctx, testCase.inputMutations)
+			if testCase.expectError {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: '%v', want error: '%v'", gotErr, testCase.wantError)
+				}
+				return
+			}
+			if gotErr != nil {
+				t.Fatalf("ApplyMutations() got error: '%v'", gotErr)
+			}
+
+			if len(results) != len(testCase.wantErrors) {
+				t.Fatalf("got %d results, want %d", len(results), len(testCase.wantErrors))
+			}
+			for i, result := range results {
+				if !errors.Is(result.Err, testCase.wantErrors[i]) || (testCase.wantErrors[i] == nil && result.Err != nil) {
+					t.Errorf("result %d got error: '%v', want error: '%v'", i, result.Err, testCase.wantErrors[i])
+				}
+				gotVersion := 0
+				if result.Expense != nil {
+					gotVersion = result.Expense.Version
+				}
+				if gotVersion != testCase.wantVersions[i] {
+					t.Errorf("result %d got version: %d, want version: %d", i, gotVersion, testCase.wantVersions[i])
+				}
+			}
+
+			stored, err := service.GetAllExpenses(ctx)
+			if err != nil {
+				t.Fatalf("GetAllExpenses() got error: '%v'", err)
+			}
+			gotIDs := make([]int, 0, len(stored))
+			for _, exp := range stored {
+				gotIDs = append(gotIDs, exp.ID)
+			}
+			slices.Sort(gotIDs)
+			if !slices.Equal(gotIDs, testCase.wantIDs) {
+				t.Errorf("got ids: %v, want ids: %v", gotIDs, testCase.wantIDs)
+			}
+		})
+	}
+}
+
+func TestApplyMutationsReplayed(t *testing.T) {
+	bus := expenses.NewBus()
+	var got []expenses.Event
+	bus.Subscribe(func(ctx context.Context, event expenses.Event) { got = append(got, event) })
+
+	service := setupMutationService(t, expenses.WithEvents(bus))
+	ctx := auth.WithUserID(t.Context(), 1)
+	got = nil
+	batch := []*expenses.Mutation{{
+		Op:       expenses.MutationCreate,
+		ClientID: "0b6c1c6e-4a8e-4c3e-9d1a-2f4b5c6d7e8f",
+		Expense:  &expenses.Expense{ExpenseOccuredAt: time.Unix(1761670800, 0), Description: "bagel", Amount: money.New(450, money.DefaultCurrency)},
+	}}
+
+	first, err := service.ApplyMutations(ctx, batch)
+	if err != nil || first[0].Err != nil {
+		t.Fatalf("ApplyMutations() got error: '%v', result error: '%v'", err, first[0].Err)
+	}
+	// the response was lost, so the client sends the batch again
+	again, err := service.ApplyMutations(ctx, batch)
+	if err != nil || again[0].Err != nil {
+		t.Fatalf("ApplyMutations() again got error: '%v', result error: '%v'", err, again[0].Err)
+	}
+
+	if !again[0].Replayed || again[0].ID != first[0].ID {
+		t.Errorf("got replayed: %v for id %d, want replayed for id %d", again[0].Replayed, again[0].ID, first[0].ID)
+	}
+	if len(got) != 1 || got[0].Type != expenses.ExpenseCreated {
+		t.Errorf("got events: %v, want one %q", got, expenses.ExpenseCreated)
+	}
+
+	// another user can't see the expense, nor create one with its client id
+	other, err := service.ApplyMutations(auth.WithUserID(t.Context(), 2), batch)
+	if err != nil {
+		t.Fatalf("ApplyMutations() for another user got error: '%v'", err)
+	}
+	if !errors.Is(other[0].Err, expenses.ErrClientIDInUse) {
+		t.Errorf("got error: '%v', want error: '%v'", other[0].Err, expenses.ErrClientIDInUse)
+	}
+}
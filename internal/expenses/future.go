@@ -0,0 +1,52 @@
+package expenses
+
+import "time"
+
+// FuturePolicy is what happens to expenses dated in the future, which are nearly always a typo in the year
+type FuturePolicy int
+
+const (
+	FutureAllow  FuturePolicy = iota // treated like any other expense
+	FutureFlag                       // kept, but read back with FutureDated set
+	FutureReject                     // refused with ErrFutureOccuredAt
+)
+
+// FuturePolicies are the policies by the names FUTURE_EXPENSES takes
+var FuturePolicies = map[string]FuturePolicy{
+	"allow":  FutureAllow,
+	"flag":   FutureFlag,
+	"reject": FutureReject,
+}
+
+// ErrFutureOccuredAt is used in the validation step of NewExpense() and UpdateExpense() when future expenses are rejected
+var ErrFutureOccuredAt = invalid("occured_at", "expense date is in the future, check the year")
+
+// WithFuturePolicy flags or rejects expenses dated more than skew after now, the skew
+// leaves room for clocks that are slightly ahead. Future expenses are allowed otherwise
+func WithFuturePolicy(policy FuturePolicy, skew time.Duration) Option {
+	return func(s *ExpenseService) { s.futurePolicy, s.futureSkew = policy, skew }
+}
+
+// isFuture reports whether occuredAt is too far ahead of now to be a clock being off
+func (s *ExpenseService) isFuture(occuredAt time.Time) bool {
+	return occuredAt.After(s.now().Add(s.futureSkew))
+}
+
+// checkFuture refuses occuredAt when it is in the future and future expenses are rejected
+func (s *ExpenseService) checkFuture(occuredAt time.Time) error {
+	if s.futurePolicy == FutureReject && s.isFuture(occuredAt) {
+		return ErrFutureOccuredAt
+	}
+	return nil
+}
+
+// flagFuture sets FutureDated on exps when future expenses are flagged.
+// It is worked out on every read, so an expense stops being flagged once its date comes
+func (s *ExpenseService) flagFuture(exps ...*Expense) {
+	if s.futurePolicy != FutureFlag {
+		return
+	}
+	for _, exp := range exps {
+		exp.FutureDated = s.isFuture(exp.ExpenseOccuredAt)
+	}
+}
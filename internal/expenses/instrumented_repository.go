@@ -0,0 +1,114 @@
+package expenses
+
+import (
+	"context"
+	"time"
+)
+
+// Observer records how an operation went, it is implemented by repometrics.Metrics
+type Observer interface {
+	Observe(op string, start time.Time, err error)
+}
+
+// InstrumentedRepository reports the duration and error of every call to the wrapped repository,
+// tagged with the operation name, i.e. "expenses.get_by_id"
+type InstrumentedRepository struct {
+	repo     Repository
+	observer Observer
+}
+
+func NewInstrumentedRepository(repo Repository, observer Observer) *InstrumentedRepository {
+	return &InstrumentedRepository{repo: repo, observer: observer}
+}
+
+func (r *InstrumentedRepository) GetByID(ctx context.Context, scope Scope, id int) (*Expense, error) {
+	start := time.Now()
+	record, err := r.repo.GetByID(ctx, scope, id)
+	r.observer.Observe("expenses.get_by_id", start, err)
+	return record, err
+}
+
+func (r *InstrumentedRepository) GetByIDs(ctx context.Context, scope Scope, ids []int) ([]*Expense, error) {
+	start := time.Now()
+	records, err := r.repo.GetByIDs(ctx, scope, ids)
+	r.observer.Observe("expenses.get_by_ids", start, err)
+	return records, err
+}
+
+func (r *InstrumentedRepository) GetAll(ctx context.Context, scope Scope) ([]*Expense, error) {
+	start := time.Now()
+	records, err := r.repo.GetAll(ctx, scope)
+	r.observer.Observe("expenses.get_all", start, err)
+	return records, err
+}
+
+// Each is observed as a whole, so its duration includes the time spent in fn
+func (r *InstrumentedRepository) Each(ctx context.Context, scope Scope, fn func(*Expense) error) error {
+	start := time.Now()
+	err := r.repo.Each(ctx, scope, fn)
+	r.observer.Observe("expenses.each", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) List(ctx context.Context, scope Scope, filter ListFilter) ([]*Expense, error) {
+	start := time.Now()
+	records, err := r.repo.List(ctx, scope, filter)
+	r.observer.Observe("expenses.list", start, err)
+	return records, err
+}
+
+func (r *InstrumentedRepository) Count(ctx context.Context, scope Scope, filter ListFilter) (int, error) {
+	start := time.Now()
+	count, err := r.repo.Count(ctx, scope, filter)
+	r.observer.Observe("expenses.count", start, err)
+	return count, err
+}
+
+func (r *InstrumentedRepository) Create(ctx context.Context, exp *Expense) (*Expense, error) {
+	start := time.Now()
+	record, err := r.repo.Create(ctx, exp)
+	r.observer.Observe("expenses.create", start, err)
+	return record, err
+}
+
+func (r *InstrumentedRepository) Update(ctx context.Context, scope Scope, exp *Expense) error {
+	start := time.Now()
+	err := r.repo.Update(ctx, scope, exp)
+	r.observer.Observe("expenses.update", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) Delete(ctx context.Context, scope Scope, id int) error {
+	start := time.Now()
+	err := r.repo.Delete(ctx, scope, id)
+	r.observer.Observe("expenses.delete", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) Apply(ctx context.Context, scope Scope, mutations []*Mutation) ([]*MutationResult, error) {
+	start := time.Now()
+	results, err := r.repo.Apply(ctx, scope, mutations)
+	r.observer.Observe("expenses.apply", start, err)
+	return results, err
+}
+
+func (r *InstrumentedRepository) Changes(ctx context.Context, scope Scope, after SyncCursor, until time.Time, limit int) ([]*Change, error) {
+	start := time.Now()
+	changes, err := r.repo.Changes(ctx, scope, after, until, limit)
+	r.observer.Observe("expenses.changes", start, err)
+	return changes, err
+}
+
+func (r *InstrumentedRepository) SumInRange(ctx context.Context, scope Scope, from, to time.Time, filter SummaryFilter) (*Total, error) {
+	start := time.Now()
+	total, err := r.repo.SumInRange(ctx, scope, from, to, filter)
+	r.observer.Observe("expenses.sum_in_range", start, err)
+	return total, err
+}
+
+func (r *InstrumentedRepository) GroupedSum(ctx context.Context, scope Scope, from, to time.Time, grouping Grouping, loc *time.Location, filter SummaryFilter) ([]*PeriodTotal, error) {
+	start := time.Now()
+	periods, err := r.repo.GroupedSum(ctx, scope, from, to, grouping, loc, filter)
+	r.observer.Observe("expenses.grouped_sum", start, err)
+	return periods, err
+}
@@ -0,0 +1,49 @@
+package expenses_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+)
+
+// recordingObserver keeps every observed operation and its error
+type recordingObserver struct {
+	ops  []string
+	errs []error
+}
+
+func (o *recordingObserver) Observe(op string, start time.Time, err error) {
+	o.ops = append(o.ops, op)
+	o.errs = append(o.errs, err)
+}
+
+func TestInstrumentedRepository(t *testing.T) {
+	observer := &recordingObserver{}
+	repo := expenses.NewInstrumentedRepository(setupTestRepo(t), observer)
+
+	if _, err := repo.GetByID(t.Context(), expenses.Unscoped, 1); err != nil {
+		t.Fatalf("GetByID() got error: '%v'", err)
+	}
+	if _, err := repo.GetByID(t.Context(), expenses.Unscoped, 999); err == nil {
+		t.Fatalf("GetByID() of a missing record got no error")
+	}
+	if err := repo.Delete(t.Context(), expenses.Unscoped, 1); err != nil {
+		t.Fatalf("Delete() got error: '%v'", err)
+	}
+
+	wantOps := []string{"expenses.get_by_id", "expenses.get_by_id", "expenses.delete"}
+	if len(observer.ops) != len(wantOps) {
+		t.Fatalf("got ops: %v, want: %v", observer.ops, wantOps)
+	}
+	for i, op := range wantOps {
+		if observer.ops[i] != op {
+			t.Errorf("got op %d: %q, want: %q", i, observer.ops[i], op)
+		}
+	}
+
+	// only the failed call passes its error on
+	if observer.errs[0] != nil || observer.errs[1] == nil || observer.errs[2] != nil {
+		t.Errorf("got errors: %v, want only the second", observer.errs)
+	}
+}
@@ -0,0 +1,123 @@
+package expenses
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned for a page cursor that was not made by Cursor.Encode
+var ErrInvalidCursor = invalid("cursor", "invalid page cursor")
+
+// ErrInvalidFilter is returned for a list filter that can never match, i.e. a minimum above the maximum
+var ErrInvalidFilter = invalid("", "invalid list filter")
+
+// Cursor marks the last expense of a page, the next page starts after it.
+// Lists are ordered newest first, by when the expense occured and then by id
+type Cursor struct {
+	OccuredAt time.Time
+	ID        int
+}
+
+// Encode is the opaque form of the cursor handed to clients
+func (c Cursor) Encode() string {
+	raw := strconv.FormatInt(c.OccuredAt.Unix(), 10) + ":" + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor reads a cursor made by Cursor.Encode
+func DecodeCursor(encoded string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	rawOccured, rawID, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	occured, err := strconv.ParseInt(rawOccured, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(rawID)
+	if err != nil || id <= 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &Cursor{OccuredAt: time.Unix(occured, 0), ID: id}, nil
+}
+
+// ListFilter narrows and pages a list of expenses, the zero value lists everything
+type ListFilter struct {
+	From      time.Time // occured at or after, zero for unbounded
+	To        time.Time // occured before, zero for unbounded
+	MinAmount int64     // cents, 0 for no minimum
+	MaxAmount int64     // cents, 0 for no maximum
+	Search    string    // found anywhere in the description, ignoring case and accents, empty for any
+
+	UpdatedSince time.Time // created or updated at or after, zero for any. For clients syncing changes since they last did
+
+	After  *Cursor // only expenses after this one, for keyset pagination
+	Limit  int     // 0 for no limit
+	Offset int
+}
+
+// checkFilter refuses filters that can never match, and folds the search like the descriptions it is matched against
+func checkFilter(filter ListFilter) (ListFilter, error) {
+	if filter.MinAmount < 0 || filter.MaxAmount < 0 || filter.Limit < 0 || filter.Offset < 0 {
+		return ListFilter{}, ErrInvalidFilter
+	}
+	if filter.MaxAmount != 0 && filter.MinAmount > filter.MaxAmount {
+		return ListFilter{}, ErrInvalidFilter
+	}
+
+	// matched against the folded description the repository keeps
+	filter.Search = FoldDescription(filter.Search)
+	return filter, nil
+}
+
+// ListExpenses lists the expenses matching filter newest first, filtered and paged by the database.
+// When a full page comes back, next is the cursor for the page after it, otherwise it is nil.
+func (s *ExpenseService) ListExpenses(ctx context.Context, filter ListFilter) ([]*Expense, *Cursor, error) {
+	filter, err := checkFilter(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scope, err := s.scope(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	exps, err := s.repo.List(ctx, scope, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.flagFuture(exps...)
+
+	var next *Cursor
+	if filter.Limit > 0 && len(exps) == filter.Limit {
+		last := exps[len(exps)-1]
+		next = &Cursor{OccuredAt: last.ExpenseOccuredAt, ID: last.ID}
+	}
+
+	return exps, next, nil
+}
+
+// CountExpenses counts every expense matching filter, whatever page of them its cursor, limit, and offset pick
+func (s *ExpenseService) CountExpenses(ctx context.Context, filter ListFilter) (int, error) {
+	filter, err := checkFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	scope, err := s.scope(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.repo.Count(ctx, scope, filter)
+}
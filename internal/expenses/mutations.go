@@ -0,0 +1,205 @@
+package expenses
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxMutations is how many mutations one batch can have
+const MaxMutations = 100
+
+// MutationOp is what a Mutation does to its expense
+type MutationOp string
+
+const (
+	MutationCreate MutationOp = "create"
+	MutationUpdate MutationOp = "update"
+	MutationDelete MutationOp = "delete"
+)
+
+// These errors are used in the validation step of ApplyMutations()
+var (
+	ErrInvalidMutationOp  = invalid("op", "mutation op needs to be create, update, or delete")
+	ErrInvalidClientID    = invalid("client_id", "client id needs to be a UUID")
+	ErrMissingTarget      = invalid("id", "an update or delete needs the id or the client id of the expense")
+	ErrInvalidBaseVersion = invalid("base_version", "an update or delete needs the version of the expense it was made to")
+	ErrMissingExpense     = invalid("expense", "a create or update needs the expense")
+	ErrInvalidBatch       = invalid("mutations", fmt.Sprintf("a batch needs between 1 and %d mutations", MaxMutations))
+)
+
+// ErrVersionConflict is reported for an update or delete made to a version of the expense that has changed since
+var ErrVersionConflict = NewError(KindConflict, errors.New("the expense changed since the version the edit was made to"))
+
+// ErrExpenseGone is reported for an update or delete of an expense that doesn't exist, nearly always as it was deleted since
+var ErrExpenseGone = NewError(KindConflict, errors.New("the expense does not exist, it may have been deleted"))
+
+// ErrClientIDInUse is reported for a create with the client id of an expense in another book
+var ErrClientIDInUse = NewError(KindConflict, errors.New("the client id is used by another expense"))
+
+// ErrNotApplied is reported for a mutation that would have applied, if another mutation of its batch had too
+var ErrNotApplied = NewError(KindConflict, errors.New("not applied, another mutation of the batch could not be"))
+
+// Mutation is one edit an offline client made to its copy of the book. Updates and deletes find their expense by ID,
+// or by ClientID for one the client created itself and doesn't know the id of yet
+type Mutation struct {
+	Op          MutationOp
+	ClientID    string   // the UUID the client created the expense with, needed to create one
+	ID          int      // the expense to update or delete, 0 to find it by ClientID
+	BaseVersion int      // the version the edit was made to, needed to update or delete
+	Expense     *Expense // what to create or update the expense to, nil for deletes
+}
+
+// MutationResult is how a mutation went, Err is nil once it is applied.
+// Expense is the stored expense after the mutation, or as it is for a conflict, nil once deleted
+type MutationResult struct {
+	ID       int // the expense the mutation was for, 0 when it doesn't exist
+	Expense  *Expense
+	Replayed bool // a create applied by an earlier batch, sent again
+	Err      error
+}
+
+// checkClientID accepts a UUID in its usual form, i.e. 0b6c1c6e-4a8e-4c3e-9d1a-2f4b5c6d7e8f, lower cased
+func checkClientID(id string) (string, error) {
+	if len(id) != 36 {
+		return "", ErrInvalidClientID
+	}
+	for i, r := range id {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return "", ErrInvalidClientID
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+				return "", ErrInvalidClientID
+			}
+		}
+	}
+	return strings.ToLower(id), nil
+}
+
+// checkMutation runs every check on m, returning the mutation to apply, with its expense checked like NewExpense's
+// and in the book of scope. Failing to look up the category is returned on its own, see checkExpense
+func (s *ExpenseService) checkMutation(ctx context.Context, scope Scope, m *Mutation) (*Mutation, error) {
+	checked := *m
+
+	var problems []error
+	if m.ClientID != "" || m.Op == MutationCreate {
+		var err error
+		if checked.ClientID, err = checkClientID(m.ClientID); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	switch m.Op {
+	case MutationCreate:
+		// the expense has no id until it is created
+		checked.ID = 0
+	case MutationUpdate, MutationDelete:
+		if m.ID < 0 || (m.ID == 0 && m.ClientID == "") {
+			problems = append(problems, ErrMissingTarget)
+		}
+		if m.BaseVersion <= 0 {
+			problems = append(problems, ErrInvalidBaseVersion)
+		}
+	default:
+		return nil, ErrInvalidMutationOp
+	}
+
+	if m.Op == MutationDelete {
+		checked.Expense = nil
+		return &checked, errors.Join(problems...)
+	}
+	if m.Expense == nil {
+		return nil, errors.Join(append(problems, ErrMissingExpense)...)
+	}
+
+	description, err := s.checkExpense(ctx, scope, m.Expense.ExpenseOccuredAt, m.Expense.Description, m.Expense.Amount, m.Expense.CategoryID)
+	if err != nil && KindOf(err) != KindInvalid {
+		return nil, err
+	}
+	if err := errors.Join(append(problems, err)...); err != nil {
+		return nil, err
+	}
+
+	checked.Expense = &Expense{
+		OwnerID:          scope.OwnerID,
+		HouseholdID:      scope.HouseholdID,
+		CategoryID:       m.Expense.CategoryID,
+		Amount:           m.Expense.Amount,
+		ExpenseOccuredAt: m.Expense.ExpenseOccuredAt,
+		Description:      description,
+		ClientID:         checked.ClientID,
+	}
+	return &checked, nil
+}
+
+// ApplyMutations applies a batch of offline edits to the user's book, all of them or none of them.
+// Updates and deletes conflict once the expense has changed since their base version, or is gone, and the result
+// has the expense as it is now for the client to merge its edit into. A create whose client id is already stored
+// was applied by an earlier batch, so sending a batch again is safe.
+// When any mutation can't be applied, the batch is not, and every result says why its mutation wasn't
+func (s *ExpenseService) ApplyMutations(ctx context.Context, mutations []*Mutation) ([]*MutationResult, error) {
+	if len(mutations) == 0 || len(mutations) > MaxMutations {
+		return nil, ErrInvalidBatch
+	}
+
+	scope, err := s.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	checked := make([]*Mutation, 0, len(mutations))
+	results := make([]*MutationResult, 0, len(mutations))
+	valid := true
+	for _, m := range mutations {
+		mutation, err := s.checkMutation(ctx, scope, m)
+		if err != nil && KindOf(err) != KindInvalid {
+			return nil, err
+		}
+		valid = valid && err == nil
+		checked = append(checked, mutation)
+		results = append(results, &MutationResult{ID: m.ID, Err: err})
+	}
+
+	if valid {
+		if results, err = s.repo.Apply(ctx, scope, checked); err != nil {
+			return nil, err
+		}
+	}
+
+	applied := true
+	for _, result := range results {
+		applied = applied && result.Err == nil
+	}
+	if !applied {
+		for _, result := range results {
+			if result.Err == nil {
+				result.Err = ErrNotApplied
+				result.Expense = nil
+			}
+		}
+		return results, nil
+	}
+
+	for i, result := range results {
+		if result.Expense != nil {
+			s.flagFuture(result.Expense)
+		}
+		if result.Replayed {
+			continue
+		}
+
+		switch checked[i].Op {
+		case MutationCreate:
+			s.publish(ctx, ExpenseCreated, scope, result.Expense)
+		case MutationUpdate:
+			s.publish(ctx, ExpenseUpdated, scope, result.Expense)
+		case MutationDelete:
+			s.publish(ctx, ExpenseDeleted, scope, &Expense{ID: result.ID})
+		}
+	}
+	return results, nil
+}
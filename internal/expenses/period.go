@@ -0,0 +1,139 @@
+package expenses
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// periodUnit is how long a period written in one of the notations parsePeriod reads is
+type periodUnit int
+
+const (
+	unitDay periodUnit = iota
+	unitWeek
+	unitMonth
+	unitQuarter
+	unitYear
+)
+
+func (u periodUnit) String() string {
+	return [...]string{"day", "week", "month", "quarter", "year"}[u]
+}
+
+// period is a span of whole days in UTC
+type period struct {
+	from time.Time // inclusive
+	to   time.Time // exclusive
+	unit periodUnit
+}
+
+// errPeriodNotation is the error for a period that isn't in any of the notations
+var errPeriodNotation = errors.New("period needs to be YYYY, YYYY-MM, YYYY-MM-DD, YYYY-Www, or YYYY-Qn")
+
+// parseYear reads a four digit year after 1970, the first year expenses can be in
+func parseYear(raw string) (int, error) {
+	if len(raw) != 4 {
+		return 0, fmt.Errorf("year %q needs to be four digits", raw)
+	}
+	year, err := strconv.Atoi(raw)
+	if err != nil || strings.ContainsAny(raw, "+-") {
+		return 0, fmt.Errorf("year %q needs to be a number", raw)
+	}
+	if year < 1970 {
+		return 0, fmt.Errorf("year %d needs to be 1970 or later", year)
+	}
+	return year, nil
+}
+
+// parseNumber reads a part of a period of exactly digits digits, between low and high
+func parseNumber(name, raw string, digits, low, high int) (int, error) {
+	n, err := strconv.Atoi(raw)
+	if err != nil || len(raw) != digits || strings.ContainsAny(raw, "+-") {
+		return 0, fmt.Errorf("%s %q needs to be %d digits", name, raw, digits)
+	}
+	if n < low || n > high {
+		return 0, fmt.Errorf("%s %s needs to be %0*d to %0*d", name, raw, digits, low, digits, high)
+	}
+	return n, nil
+}
+
+// isoWeekStart is the monday starting ISO week 1 of year, the week with the year's first thursday
+func isoWeekStart(year int) time.Time {
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	sinceMonday := (int(jan4.Weekday()) + 6) % 7
+	return jan4.AddDate(0, 0, -sinceMonday)
+}
+
+// isoWeeks is how many ISO weeks year has, 52 or 53
+func isoWeeks(year int) int {
+	_, week := time.Date(year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek()
+	return week
+}
+
+// parsePeriod reads a year "2025", month "2025-03", day "2025-03-14", ISO week "2025-W11",
+// or quarter "2025-Q1". Errors name the part that is wrong
+func parsePeriod(raw string) (period, error) {
+	parts := strings.Split(raw, "-")
+	if len(parts) > 3 || raw == "" {
+		return period{}, errPeriodNotation
+	}
+	year, err := parseYear(parts[0])
+	if err != nil {
+		return period{}, err
+	}
+
+	if len(parts) == 1 {
+		return period{from: monthStart(year, 1), to: monthStart(year+1, 1), unit: unitYear}, nil
+	}
+
+	second := parts[1]
+	switch {
+	case len(parts) == 2 && (strings.HasPrefix(second, "W") || strings.HasPrefix(second, "w")):
+		weeks := isoWeeks(year)
+		week, err := parseNumber("week", second[1:], 2, 1, weeks)
+		if err != nil {
+			return period{}, fmt.Errorf("%w, %d has %d ISO weeks", err, year, weeks)
+		}
+		from := isoWeekStart(year).AddDate(0, 0, 7*(week-1))
+		return period{from: from, to: from.AddDate(0, 0, 7), unit: unitWeek}, nil
+
+	case len(parts) == 2 && (strings.HasPrefix(second, "Q") || strings.HasPrefix(second, "q")):
+		quarter, err := parseNumber("quarter", second[1:], 1, 1, 4)
+		if err != nil {
+			return period{}, err
+		}
+		first := 3*(quarter-1) + 1
+		return period{from: monthStart(year, first), to: monthStart(year, first+3), unit: unitQuarter}, nil
+	}
+
+	month, err := parseNumber("month", second, 2, 1, 12)
+	if err != nil {
+		return period{}, err
+	}
+	if len(parts) == 2 {
+		return period{from: monthStart(year, month), to: monthStart(year, month+1), unit: unitMonth}, nil
+	}
+
+	days := monthStart(year, month+1).AddDate(0, 0, -1).Day()
+	day, err := parseNumber("day", parts[2], 2, 1, days)
+	if err != nil {
+		return period{}, fmt.Errorf("%w, %04d-%02d has %d days", err, year, month, days)
+	}
+	from := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	return period{from: from, to: from.AddDate(0, 0, 1), unit: unitDay}, nil
+}
+
+// parsePeriodOf reads a period that has to be a unit long, i.e. a month for CustomMonth
+func parsePeriodOf(raw string, unit periodUnit) (period, error) {
+	p, err := parsePeriod(raw)
+	if err != nil {
+		return period{}, err
+	}
+	if p.unit != unit {
+		return period{}, fmt.Errorf("%s needs to be a %s, not a %s", raw, unit, p.unit)
+	}
+	return p, nil
+}
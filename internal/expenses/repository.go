@@ -4,30 +4,62 @@ package expenses
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 // ErrNilPointer is returned when a nil pointer dereference is avoided
 var ErrNilPointer = errors.New("input pointer cannot be nil")
 
 // ErrNoRowsDeleted is returned when a delete query does not affect any rows
-var ErrNoRowsDeleted = errors.New("no rows were deleted")
+var ErrNoRowsDeleted = NewError(KindNotFound, errors.New("no rows were deleted"))
 
 // ErrNoRowsUpdated is returned when an update query does not affect any rows
-var ErrNoRowsUpdated = errors.New("no rows were updated")
+var ErrNoRowsUpdated = NewError(KindNotFound, errors.New("no rows were updated"))
 
+// Repository reads and writes are limited to scope, records outside of it act as if they do not exist.
+// Records that don't exist are reported with an Error of KindNotFound, whatever the backend's own error is
 type Repository interface {
 	// get one expense record by ID
-	GetByID(ctx context.Context, id int) (*Expense, error)
+	GetByID(ctx context.Context, scope Scope, id int) (*Expense, error)
+
+	// get every expense with an id in ids, in no particular order
+	GetByIDs(ctx context.Context, scope Scope, ids []int) ([]*Expense, error)
 
 	// get all expenses
-	GetAll(ctx context.Context) ([]*Expense, error)
+	GetAll(ctx context.Context, scope Scope) ([]*Expense, error)
+
+	// call fn with every expense, oldest first by occured at and then id, reading one row at a time.
+	// Stops at the first error from fn and returns it
+	Each(ctx context.Context, scope Scope, fn func(*Expense) error) error
+
+	// get the expenses matching filter, newest first by occured at and then id
+	List(ctx context.Context, scope Scope, filter ListFilter) ([]*Expense, error)
+
+	// count the expenses matching filter, ignoring its cursor, limit, and offset
+	Count(ctx context.Context, scope Scope, filter ListFilter) (int, error)
 
-	// create a new expense
+	// create a new expense, owned by exp.OwnerID
 	Create(ctx context.Context, exp *Expense) (*Expense, error)
 
 	// update an existing expense
-	Update(ctx context.Context, exp *Expense) error
+	Update(ctx context.Context, scope Scope, exp *Expense) error
+
+	// delete an exisiting expense, keeping a tombstone of it for Changes
+	Delete(ctx context.Context, scope Scope, id int) error
+
+	// apply every mutation in one transaction, committed only when they all apply. Updates and deletes whose
+	// base version isn't the stored one have ErrVersionConflict in their result, with the stored expense,
+	// and those of an expense that doesn't exist ErrExpenseGone. Creates with a stored client id are Replayed
+	Apply(ctx context.Context, scope Scope, mutations []*Mutation) ([]*MutationResult, error)
+
+	// get up to limit of the expenses created, updated, or deleted after the cursor and before the second until,
+	// oldest first by when they changed and then id. A limit of 0 is no limit
+	Changes(ctx context.Context, scope Scope, after SyncCursor, until time.Time, limit int) ([]*Change, error)
+
+	// sum and count the expenses occured in [from, to) that filter keeps. Zero times are unbounded
+	SumInRange(ctx context.Context, scope Scope, from, to time.Time, filter SummaryFilter) (*Total, error)
 
-	// delete an exisiting expense
-	Delete(ctx context.Context, id int) error
+	// sum and count the expenses occured in [from, to) that filter keeps per day, month, or year of loc, oldest first.
+	// A nil loc is UTC, zero times are unbounded, and periods without expenses are left out
+	GroupedSum(ctx context.Context, scope Scope, from, to time.Time, grouping Grouping, loc *time.Location, filter SummaryFilter) ([]*PeriodTotal, error)
 }
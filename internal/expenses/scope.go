@@ -0,0 +1,54 @@
+package expenses
+
+import (
+	"context"
+
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+)
+
+// Scope limits a repository call to the records of a single owner.
+// Every repository call takes one, so no query can forget to filter.
+type Scope struct {
+	OwnerID     int  // the user whose records are visible
+	HouseholdID int  // records shared with this household are visible too, 0 for none
+	AllOwners   bool // no filtering, for admin listing and when auth is disabled
+}
+
+// Unscoped sees every record, regardless of owner
+var Unscoped = Scope{AllOwners: true}
+
+// OwnerScope only sees records belonging to ownerID
+func OwnerScope(ownerID int) Scope {
+	return Scope{OwnerID: ownerID}
+}
+
+// HouseholdLookup finds the household a user shares their expenses with
+type HouseholdLookup interface {
+	// returns 0 when the user is not in a household
+	HouseholdIDForUser(ctx context.Context, userID int) (int, error)
+}
+
+// ScopeOf is for the authenticated user, including their household's shared book when households is set.
+// Anonymous requests only reach the services when auth is disabled, where there is a single tenant.
+func ScopeOf(ctx context.Context, households HouseholdLookup) (Scope, error) {
+	userID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return Unscoped, nil
+	}
+
+	scope := OwnerScope(userID)
+	if households != nil {
+		householdID, err := households.HouseholdIDForUser(ctx, userID)
+		if err != nil {
+			return Scope{}, err
+		}
+		scope.HouseholdID = householdID
+	}
+
+	return scope, nil
+}
+
+// scope is ScopeOf the households the service was given
+func (s *ExpenseService) scope(ctx context.Context) (Scope, error) {
+	return ScopeOf(ctx, s.households)
+}
@@ -3,19 +3,41 @@ package expenses
 import (
 	"context"
 	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/money"
 )
 
 // Service defines an interface for the business layer of the API.
 //
 // This is primarily implemented for easier mocking for testing.
+// Every method is scoped to the user on ctx, see auth.UserIDFromContext.
 type Service interface {
-	NewExpense(ctx context.Context, occuredAt time.Time, description string, amount int64) (*Expense, error)
+	// Currency is what expenses are recorded in, amounts given to NewExpense and UpdateExpense have to be in it
+	Currency() string
+
+	NewExpense(ctx context.Context, occuredAt time.Time, description string, amount money.Money, categoryID int) (*Expense, error)
 
 	GetAllExpenses(ctx context.Context) ([]*Expense, error)
 
+	ListExpenses(ctx context.Context, filter ListFilter) ([]*Expense, *Cursor, error)
+
+	CountExpenses(ctx context.Context, filter ListFilter) (int, error)
+
+	EachExpense(ctx context.Context, fn func(*Expense) error) error
+
 	GetExpenseByID(ctx context.Context, id int) (*Expense, error)
 
-	UpdateExpense(ctx context.Context, id int, occuredAt time.Time, description string, amount int64) error
+	GetExpensesByIDs(ctx context.Context, ids []int) ([]*Expense, []int, error)
+
+	UpdateExpense(ctx context.Context, id int, occuredAt time.Time, description string, amount money.Money, categoryID int) error
 
 	DeleteExpense(ctx context.Context, id int) error
+
+	Changes(ctx context.Context, since SyncCursor, limit int) (*ChangeSet, error)
+
+	ApplyMutations(ctx context.Context, mutations []*Mutation) ([]*MutationResult, error)
+
+	GetAllOwnersExpenses(ctx context.Context) ([]*Expense, error)
+
+	SummarizeExpenses(ctx context.Context, q SummaryQuery) (*Summary, error)
 }
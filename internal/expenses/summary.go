@@ -0,0 +1,383 @@
+package expenses
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/fxrates"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+)
+
+// Grouping is the size of the periods a summary is broken down into
+type Grouping int
+
+const (
+	GroupByRange Grouping = iota // whichever suits the range, see SummarizeExpenses
+	GroupByDay
+	GroupByMonth
+	GroupByYear
+)
+
+// Total is the sum and number of expenses
+type Total struct {
+	Amount money.Money
+	Count  int
+}
+
+// PeriodTotal is the total of one day, month, or year.
+// Periods without any expenses are left out
+type PeriodTotal struct {
+	Start time.Time // first instant of the period, in the summary's zone
+	Total
+}
+
+// Summary is the total of a time range, broken down into periods
+type Summary struct {
+	From     time.Time // inclusive, zero when unbounded
+	To       time.Time // exclusive, zero when unbounded
+	Grouping Grouping
+	Total
+	Periods    []*PeriodTotal
+	Conversion *fxrates.Conversion // how the amounts were converted, nil when they are in the recording currency
+}
+
+// SummaryQuery is what SummarizeExpenses totals, the zero value totals every expense by year
+type SummaryQuery struct {
+	Range    SummaryTimeRange
+	Period   string         // picks the period of the custom ranges, see SummarizeExpenses
+	GroupBy  Grouping       // breaks the range down by something other than what suits it
+	Location *time.Location // the days, months, and years are those of this zone, nil for UTC
+	Currency string         // converts the amounts to it, empty to leave them in the currency expenses are recorded in
+	Filter   SummaryFilter
+}
+
+// SummaryFilter narrows down the expenses a summary totals, the zero value totals all of them
+type SummaryFilter struct {
+	CategoryID int   // only the expenses in it, 0 for any category
+	MinAmount  int64 // cents, 0 for no minimum
+	MaxAmount  int64 // cents, 0 for no maximum
+}
+
+// IsZero reports whether the filter keeps every expense
+func (f SummaryFilter) IsZero() bool {
+	return f == SummaryFilter{}
+}
+
+// These errors are used for the currency of SummarizeExpenses()
+var (
+	ErrConversionDisabled = invalid("currency", "currency conversion is not enabled")
+	ErrUnknownCurrency    = invalid("currency", "must be a currency with an exchange rate, i.e. USD")
+)
+
+// ErrMissingModifier is wrapped by ErrInvalidTime when a custom range is given without its modifier
+var ErrMissingModifier = errors.New("range needs a modifier")
+
+// monthStart is the first instant of year and month in UTC, months past 12 roll into the next year
+func monthStart(year, month int) time.Time {
+	return time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// makeCustomMonth is the range of the month in modifier, i.e. "2025-03"
+func makeCustomMonth(modifier string) (time.Time, time.Time, error) {
+	p, err := parsePeriodOf(modifier, unitMonth)
+	if err != nil {
+		return time.Time{}, time.Time{}, &ErrInvalidTime{ProvidedTime: modifier, WrappedError: err}
+	}
+	return p.from, p.to, nil
+}
+
+// makeCustomYear is the range of the year in modifier, i.e. "2025"
+func makeCustomYear(modifier string) (time.Time, time.Time, error) {
+	p, err := parsePeriodOf(modifier, unitYear)
+	if err != nil {
+		return time.Time{}, time.Time{}, &ErrInvalidTime{ProvidedTime: modifier, WrappedError: err}
+	}
+	return p.from, p.to, nil
+}
+
+// makeCustomPeriod is the range of the period in modifier, in any notation parsePeriod reads.
+// Up to a month is broken down by day, quarters and years by month
+func makeCustomPeriod(modifier string) (time.Time, time.Time, Grouping, error) {
+	p, err := parsePeriod(modifier)
+	if err != nil {
+		return time.Time{}, time.Time{}, 0, &ErrInvalidTime{ProvidedTime: modifier, WrappedError: err}
+	}
+	if p.unit <= unitMonth {
+		return p.from, p.to, GroupByDay, nil
+	}
+	return p.from, p.to, GroupByMonth, nil
+}
+
+// makeCustomYearMonthRange is the range from the first to the last period in modifier, both included,
+// i.e. "2025-01:2025-06" for the first half of 2025. Either end can be in any notation parsePeriod reads,
+// like "2025-Q1:2025-05"
+func makeCustomYearMonthRange(modifier string) (time.Time, time.Time, error) {
+	rawFrom, rawTo, ok := strings.Cut(modifier, ":")
+	if !ok {
+		return time.Time{}, time.Time{}, &ErrInvalidTime{ProvidedTime: modifier, WrappedError: errors.New("range needs to be two periods, like YYYY-MM:YYYY-MM")}
+	}
+
+	first, err := parsePeriod(rawFrom)
+	if err != nil {
+		return time.Time{}, time.Time{}, &ErrInvalidTime{ProvidedTime: modifier, WrappedError: fmt.Errorf("start of range: %w", err)}
+	}
+	last, err := parsePeriod(rawTo)
+	if err != nil {
+		return time.Time{}, time.Time{}, &ErrInvalidTime{ProvidedTime: modifier, WrappedError: fmt.Errorf("end of range: %w", err)}
+	}
+
+	if !first.from.Before(last.to) {
+		return time.Time{}, time.Time{}, &ErrInvalidTime{ProvidedTime: modifier, WrappedError: fmt.Errorf("first %s needs to be before the last %s", first.unit, last.unit)}
+	}
+	return first.from, last.to, nil
+}
+
+// inZone is the start of t's day in UTC as the start of the same day in loc, the zero time stays zero
+func inZone(t time.Time, loc *time.Location) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// summaryRange is the time range and grouping for kind, relative to now for the current month and year.
+// The bounds start days in UTC, they are the days of now's zone, see inZone
+func summaryRange(kind SummaryTimeRange, modifier string, now time.Time) (time.Time, time.Time, Grouping, error) {
+	needsModifier := kind == CustomMonth || kind == CustomYear || kind == CustomYearMonthRange || kind == CustomPeriod
+	if needsModifier && modifier == "" {
+		return time.Time{}, time.Time{}, 0, &ErrInvalidTime{WrappedError: ErrMissingModifier}
+	}
+
+	switch kind {
+	case AllExpenses:
+		return time.Time{}, time.Time{}, GroupByYear, nil
+	case ThisMonth:
+		return monthStart(now.Year(), int(now.Month())), monthStart(now.Year(), int(now.Month())+1), GroupByDay, nil
+	case CustomMonth:
+		from, to, err := makeCustomMonth(modifier)
+		return from, to, GroupByDay, err
+	case ThisYear:
+		return monthStart(now.Year(), 1), monthStart(now.Year()+1, 1), GroupByMonth, nil
+	case CustomYear:
+		from, to, err := makeCustomYear(modifier)
+		return from, to, GroupByMonth, err
+	case CustomYearMonthRange:
+		from, to, err := makeCustomYearMonthRange(modifier)
+		return from, to, GroupByMonth, err
+	case CustomPeriod:
+		return makeCustomPeriod(modifier)
+	}
+
+	return time.Time{}, time.Time{}, 0, &ErrInvalidTime{ProvidedTime: modifier, WrappedError: errors.New("unknown summary range")}
+}
+
+// PeriodStart is the start of the day, month, or year t is in, in t's zone
+func PeriodStart(t time.Time, grouping Grouping) time.Time {
+	switch grouping {
+	case GroupByDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	case GroupByMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
+}
+
+// NextPeriod is the start of the period after the one starting at start
+func NextPeriod(start time.Time, grouping Grouping) time.Time {
+	switch grouping {
+	case GroupByDay:
+		return start.AddDate(0, 0, 1)
+	case GroupByMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(1, 0, 0)
+}
+
+// summaryShards splits [from, to) at period boundaries into at most n ranges with about as many periods each
+func summaryShards(from, to time.Time, grouping Grouping, n int) [][2]time.Time {
+	bounds := []time.Time{from}
+	for last := from; last.Before(to); {
+		last = NextPeriod(last, grouping)
+		if last.After(to) {
+			last = to
+		}
+		bounds = append(bounds, last)
+	}
+
+	periods := len(bounds) - 1
+	n = min(n, periods)
+	shards := make([][2]time.Time, 0, n)
+	for i := range n {
+		shards = append(shards, [2]time.Time{bounds[i*periods/n], bounds[(i+1)*periods/n]})
+	}
+	return shards
+}
+
+// shardedGroupedSum runs GroupedSum for every shard at once and puts the periods back in order.
+// The first failure cancels the shards still running
+func (s *ExpenseService) shardedGroupedSum(ctx context.Context, scope Scope, shards [][2]time.Time, grouping Grouping, loc *time.Location, filter SummaryFilter) ([]*PeriodTotal, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var mux sync.Mutex
+	var firstErr error
+	results := make([][]*PeriodTotal, len(shards))
+	for i, shard := range shards {
+		wg.Go(func() {
+			periods, err := s.repo.GroupedSum(ctx, scope, shard[0], shard[1], grouping, loc, filter)
+			if err != nil {
+				mux.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mux.Unlock()
+				return
+			}
+			results[i] = periods
+		})
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	periods := make([]*PeriodTotal, 0)
+	for _, shardPeriods := range results {
+		periods = append(periods, shardPeriods...)
+	}
+	return periods, nil
+}
+
+// checkSummaryFilter refuses filters that can never match, like checkFilter
+func checkSummaryFilter(filter SummaryFilter) error {
+	if filter.CategoryID < 0 || filter.MinAmount < 0 || filter.MaxAmount < 0 {
+		return ErrInvalidFilter
+	}
+	if filter.MaxAmount != 0 && filter.MinAmount > filter.MaxAmount {
+		return ErrInvalidFilter
+	}
+	return nil
+}
+
+// conversion looks up how to convert amounts to currency, nil when they are already in it
+func (s *ExpenseService) conversion(currency string) (*fxrates.Conversion, error) {
+	currency = strings.ToUpper(currency)
+	if currency == "" || currency == s.currency {
+		return nil, nil
+	}
+	if s.converter == nil {
+		return nil, ErrConversionDisabled
+	}
+
+	conversion, err := s.converter.Convert(currency)
+	if errors.Is(err, fxrates.ErrUnknownCurrency) {
+		return nil, ErrUnknownCurrency
+	}
+	return conversion, err
+}
+
+// convertSummary converts every amount of summary with conversion. Each is converted on its own,
+// so the periods can be off from the total by rounding
+func convertSummary(summary *Summary, conversion *fxrates.Conversion) {
+	convert := func(amount money.Money) money.Money {
+		return money.New(conversion.Apply(amount.Minor), conversion.To)
+	}
+
+	summary.Amount = convert(summary.Amount)
+	for _, period := range summary.Periods {
+		period.Amount = convert(period.Amount)
+	}
+	summary.Conversion = conversion
+}
+
+// SummarizeExpenses totals the expenses in the range of q, broken down by day for a month,
+// by month for a year or range of months, and by year for all expenses, unless q.GroupBy says otherwise.
+// The custom ranges take a period, "YYYY-MM" for CustomMonth, "YYYY" for CustomYear,
+// "YYYY-MM:YYYY-MM" for CustomYearMonthRange, and any of "YYYY", "YYYY-MM", "YYYY-MM-DD",
+// ISO week "YYYY-Www", or quarter "YYYY-Qn" for CustomPeriod, or either end of a range.
+// The ranges are the days of q.Location, and q.Filter narrows down the expenses they total.
+// Amounts are converted to q.Currency with the exchange rates, when the service has a converter.
+// The sums are done by the database.
+// With report workers, a bounded range is split into that many queries run side by side.
+func (s *ExpenseService) SummarizeExpenses(ctx context.Context, q SummaryQuery) (*Summary, error) {
+	loc := q.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	from, to, grouping, err := summaryRange(q.Range, q.Period, s.now().In(loc))
+	if err != nil {
+		return nil, err
+	}
+	from, to = inZone(from, loc), inZone(to, loc)
+	if q.GroupBy != GroupByRange {
+		grouping = q.GroupBy
+	}
+	if err := checkSummaryFilter(q.Filter); err != nil {
+		return nil, err
+	}
+
+	// looked up first, so a summary that can't be converted isn't summed for nothing
+	conversion, err := s.conversion(q.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	scope, err := s.scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.summarize(ctx, scope, from, to, grouping, loc, q.Filter)
+	if err != nil {
+		return nil, err
+	}
+	if conversion != nil {
+		convertSummary(summary, conversion)
+	}
+	return summary, nil
+}
+
+// summarize sums [from, to) per period of loc, in the currency expenses are recorded in
+func (s *ExpenseService) summarize(ctx context.Context, scope Scope, from, to time.Time, grouping Grouping, loc *time.Location, filter SummaryFilter) (*Summary, error) {
+	// the total of a split range is added up from its periods, which are never cut across shards
+	if s.reportWorkers > 1 && !from.IsZero() && !to.IsZero() {
+		periods, err := s.shardedGroupedSum(ctx, scope, summaryShards(from, to, grouping, s.reportWorkers), grouping, loc, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		summary := &Summary{From: from, To: to, Grouping: grouping, Total: Total{Amount: money.Zero(s.currency)}, Periods: periods}
+		for _, period := range periods {
+			if summary.Amount, err = summary.Amount.Add(period.Amount); err != nil {
+				return nil, err
+			}
+			summary.Count += period.Count
+		}
+		return summary, nil
+	}
+
+	total, err := s.repo.SumInRange(ctx, scope, from, to, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	periods, err := s.repo.GroupedSum(ctx, scope, from, to, grouping, loc, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Summary{
+		From:     from,
+		To:       to,
+		Grouping: grouping,
+		Total:    *total,
+		Periods:  periods,
+	}, nil
+}
@@ -0,0 +1,55 @@
+package expenses
+
+import (
+	"sync"
+	"time"
+)
+
+// ErrInvalidZone is returned by LoadZone for names that are neither an IANA zone nor an offset
+var ErrInvalidZone = invalid("timezone", "time zone needs to be an IANA name like Europe/Berlin, or an offset like +02:00")
+
+// offsetLayout is how zones without a name are written, i.e. "+02:00"
+const offsetLayout = "-07:00"
+
+// zones caches loaded IANA zones, since time.LoadLocation reads the zone database each time
+var zones sync.Map
+
+// ZoneName is the zone an expense occured in, as kept alongside ExpenseOccuredAt. It is the IANA name,
+// i.e. "Europe/Berlin", when the time has one, and otherwise the offset, i.e. "+02:00" for times parsed
+// from RFC3339, whose zone has no name. Times in time.Local are kept by offset, since "Local" means
+// something else on every server
+func ZoneName(t time.Time) string {
+	loc := t.Location()
+	if loc == time.UTC {
+		return "UTC"
+	}
+	if name := loc.String(); name != "Local" && name != "" {
+		if _, err := LoadZone(name); err == nil {
+			return name
+		}
+	}
+	return t.Format(offsetLayout)
+}
+
+// LoadZone reads a zone written by ZoneName
+func LoadZone(name string) (*time.Location, error) {
+	if cached, ok := zones.Load(name); ok {
+		return cached.(*time.Location), nil
+	}
+
+	if offset, err := time.Parse(offsetLayout, name); err == nil {
+		_, seconds := offset.Zone()
+		return time.FixedZone("", seconds), nil
+	}
+
+	// LoadLocation reads "" as UTC and "Local" as wherever the server is
+	if name == "" || name == "Local" {
+		return nil, ErrInvalidZone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidZone
+	}
+	zones.Store(name, loc)
+	return loc, nil
+}
@@ -0,0 +1,146 @@
+// Package fieldcrypt encrypts individual fields with AES-256-GCM before they are stored.
+// Values are tagged with the id of the key that sealed them, so keys can be rotated
+// while older values stay readable.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// prefix marks an encrypted value, anything without it is treated as plaintext
+const prefix = "enc:v1:"
+
+// KeySize is the length of every key, for AES-256
+const KeySize = 32
+
+var (
+	ErrInvalidKey = errors.New("encryption key needs an id and 32 bytes of base64")
+	ErrNoKeys     = errors.New("at least one encryption key is needed")
+	ErrUnknownKey = errors.New("value was encrypted with a key that is not configured")
+	ErrCorrupt    = errors.New("encrypted value is corrupt or was tampered with")
+)
+
+// Key is a named AES-256 key
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Keyring encrypts with its primary key, the first one, and decrypts with any of them
+type Keyring struct {
+	primary string
+	aeads   map[string]cipher.AEAD
+}
+
+// NewKeyring creates a keyring, keys[0] is used for everything encrypted from now on
+func NewKeyring(keys []Key) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	k := &Keyring{primary: keys[0].ID, aeads: make(map[string]cipher.AEAD, len(keys))}
+	for _, key := range keys {
+		if key.ID == "" || strings.Contains(key.ID, ":") || len(key.Secret) != KeySize {
+			return nil, ErrInvalidKey
+		}
+		if _, ok := k.aeads[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate encryption key id %q", key.ID)
+		}
+
+		block, err := aes.NewCipher(key.Secret)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.aeads[key.ID] = aead
+	}
+
+	return k, nil
+}
+
+// ParseKeys parses a comma separated list of id:base64 keys, i.e. "2025-06:...,2024-01:..."
+func ParseKeys(raw string) ([]Key, error) {
+	keys := make([]Key, 0)
+	for part := range strings.SplitSeq(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, ErrInvalidKey
+		}
+		secret, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, ErrInvalidKey
+		}
+		keys = append(keys, Key{ID: id, Secret: secret})
+	}
+
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	return keys, nil
+}
+
+// Encrypt seals plaintext with the primary key.
+// field is authenticated alongside it, so a value can't be moved into another field.
+func (k *Keyring) Encrypt(field, plaintext string) (string, error) {
+	aead := k.aeads[k.primary]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(field))
+	return prefix + k.primary + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value from Encrypt, values stored before encryption was enabled are returned as is
+func (k *Keyring) Decrypt(field, value string) (string, error) {
+	rest, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return value, nil
+	}
+
+	id, encoded, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", ErrCorrupt
+	}
+	aead, ok := k.aeads[id]
+	if !ok {
+		return "", ErrUnknownKey
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrCorrupt
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(field))
+	if err != nil {
+		return "", ErrCorrupt
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRotation reports whether value is plaintext or sealed with a key other than the primary
+func (k *Keyring) NeedsRotation(value string) bool {
+	rest, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return true
+	}
+	id, _, _ := strings.Cut(rest, ":")
+	return id != k.primary
+}
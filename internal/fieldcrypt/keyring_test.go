@@ -0,0 +1,196 @@
+package fieldcrypt_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nicholasss/expense-tracker-api/internal/fieldcrypt"
+)
+
+func TestKeyring(t *testing.T) {
+	oldKey := fieldcrypt.Key{ID: "old", Secret: bytes.Repeat([]byte{1}, fieldcrypt.KeySize)}
+	newKey := fieldcrypt.Key{ID: "new", Secret: bytes.Repeat([]byte{2}, fieldcrypt.KeySize)}
+
+	oldRing, err := fieldcrypt.NewKeyring([]fieldcrypt.Key{oldKey})
+	if err != nil {
+		t.Fatalf("NewKeyring() got error: '%v'", err)
+	}
+	newRing, err := fieldcrypt.NewKeyring([]fieldcrypt.Key{newKey, oldKey})
+	if err != nil {
+		t.Fatalf("NewKeyring() got error: '%v'", err)
+	}
+
+	sealedOld, err := oldRing.Encrypt("expenses.description", "rent")
+	if err != nil {
+		t.Fatalf("Encrypt() got error: '%v'", err)
+	}
+	sealedNew, err := newRing.Encrypt("expenses.description", "rent")
+	if err != nil {
+		t.Fatalf("Encrypt() got error: '%v'", err)
+	}
+	tampered := sealedNew[:len(sealedNew)-2] + "AA"
+
+	testTable := []struct {
+		name        string
+		keyring     *fieldcrypt.Keyring
+		inputField  string
+		inputValue  string
+		expectError bool
+		wantError   error
+		wantValue   string
+	}{
+		{
+			name:        "valid-same-key",
+			keyring:     newRing,
+			inputField:  "expenses.description",
+			inputValue:  sealedNew,
+			expectError: false,
+			wantError:   nil,
+			wantValue:   "rent",
+		},
+		{
+			name:        "valid-older-key",
+			keyring:     newRing,
+			inputField:  "expenses.description",
+			inputValue:  sealedOld,
+			expectError: false,
+			wantError:   nil,
+			wantValue:   "rent",
+		},
+		{
+			name:        "valid-plaintext-passes-through",
+			keyring:     newRing,
+			inputField:  "expenses.description",
+			inputValue:  "rent",
+			expectError: false,
+			wantError:   nil,
+			wantValue:   "rent",
+		},
+		{
+			name:        "invalid-unknown-key",
+			keyring:     oldRing,
+			inputField:  "expenses.description",
+			inputValue:  sealedNew,
+			expectError: true,
+			wantError:   fieldcrypt.ErrUnknownKey,
+		},
+		{
+			name:        "invalid-other-field",
+			keyring:     newRing,
+			inputField:  "bank_drafts.description",
+			inputValue:  sealedNew,
+			expectError: true,
+			wantError:   fieldcrypt.ErrCorrupt,
+		},
+		{
+			name:        "invalid-tampered",
+			keyring:     newRing,
+			inputField:  "expenses.description",
+			inputValue:  tampered,
+			expectError: true,
+			wantError:   fieldcrypt.ErrCorrupt,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			gotValue, gotErr := testCase.keyring.Decrypt(testCase.inputField, testCase.inputValue)
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("Decrypt() got error: '%v', expected error: '%v'", gotErr, testCase.wantError)
+			}
+
+			// checking error type if its not nil
+			if gotErr != nil {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+				return
+			}
+
+			if gotValue != testCase.wantValue {
+				t.Errorf("Decrypt() got: %q, want: %q", gotValue, testCase.wantValue)
+			}
+		})
+	}
+
+	if !newRing.NeedsRotation(sealedOld) || !newRing.NeedsRotation("rent") || newRing.NeedsRotation(sealedNew) {
+		t.Errorf("NeedsRotation() should only be false for values sealed with the primary key")
+	}
+	if sealedAgain, _ := newRing.Encrypt("expenses.description", "rent"); sealedAgain == sealedNew {
+		t.Errorf("Encrypt() should use a fresh nonce every time")
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	valid := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, fieldcrypt.KeySize))
+	short := base64.StdEncoding.EncodeToString([]byte("too short"))
+
+	testTable := []struct {
+		name        string
+		inputRaw    string
+		expectError bool
+		wantError   error
+		wantIDs     []string
+	}{
+		{
+			name:        "valid-two-keys",
+			inputRaw:    "2025:" + valid + ", 2024:" + valid,
+			expectError: false,
+			wantError:   nil,
+			wantIDs:     []string{"2025", "2024"},
+		},
+		{
+			name:        "invalid-empty",
+			inputRaw:    " , ",
+			expectError: true,
+			wantError:   fieldcrypt.ErrNoKeys,
+		},
+		{
+			name:        "invalid-missing-id",
+			inputRaw:    valid,
+			expectError: true,
+			wantError:   fieldcrypt.ErrInvalidKey,
+		},
+		{
+			name:        "invalid-short-key",
+			inputRaw:    "2025:" + short,
+			expectError: true,
+			wantError:   fieldcrypt.ErrInvalidKey,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			keys, gotErr := fieldcrypt.ParseKeys(testCase.inputRaw)
+			if gotErr == nil {
+				_, gotErr = fieldcrypt.NewKeyring(keys)
+			}
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("ParseKeys() got error: '%v', expected error: '%v'", gotErr, testCase.wantError)
+			}
+
+			// checking error type if its not nil
+			if gotErr != nil {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+				return
+			}
+
+			gotIDs := make([]string, 0, len(keys))
+			for _, key := range keys {
+				gotIDs = append(gotIDs, key.ID)
+			}
+			if strings.Join(gotIDs, ",") != strings.Join(testCase.wantIDs, ",") {
+				t.Errorf("ParseKeys() got ids: %v, want: %v", gotIDs, testCase.wantIDs)
+			}
+		})
+	}
+}
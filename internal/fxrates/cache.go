@@ -0,0 +1,118 @@
+package fxrates
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Cache serves conversions from the latest rates in memory, Run keeps them refreshed from the provider
+type Cache struct {
+	provider RateProvider
+	repo     Repository
+	base     string // the currency expenses are recorded in
+	maxAge   time.Duration
+	now      func() time.Time
+
+	mux   sync.RWMutex
+	rates *Rates
+}
+
+// Option configures optional parts of the Cache
+type Option func(*Cache)
+
+// WithClock replaces time.Now, for when rates were fetched and whether they are stale
+func WithClock(now func() time.Time) Option {
+	return func(c *Cache) { c.now = now }
+}
+
+// NewCache converts amounts recorded in base, i.e. "EUR".
+// Rates refreshed longer than maxAge ago are still used, but marked stale
+func NewCache(provider RateProvider, repo Repository, base string, maxAge time.Duration, opts ...Option) *Cache {
+	c := &Cache{provider: provider, repo: repo, base: base, maxAge: maxAge, now: time.Now}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Base is the currency amounts are converted from
+func (c *Cache) Base() string {
+	return c.base
+}
+
+// Load fills the cache with the persisted rates, it is a no-op when none were saved
+func (c *Cache) Load(ctx context.Context) error {
+	rates, err := c.repo.LoadRates(ctx)
+	if err != nil || rates == nil {
+		return err
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.rates = rates
+	return nil
+}
+
+// Refresh fetches the latest rates from the provider, persisting them before they are used.
+// The current rates are kept when either fails
+func (c *Cache) Refresh(ctx context.Context) error {
+	rates, err := c.provider.Latest(ctx)
+	if err != nil {
+		return err
+	}
+	rates.FetchedAt = c.now()
+
+	if err := c.repo.SaveRates(ctx, rates); err != nil {
+		return err
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.rates = rates
+	return nil
+}
+
+// Run calls Refresh every interval until ctx is done, logging rather than stopping on failures
+func (c *Cache) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.Refresh(ctx); err != nil {
+			log.Printf("exchange rate refresh from %s failed: %v", c.provider.Name(), err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Convert is the conversion from the base currency to currency, using the rates in memory
+func (c *Cache) Convert(currency string) (*Conversion, error) {
+	c.mux.RLock()
+	rates := c.rates
+	c.mux.RUnlock()
+
+	if rates == nil {
+		return nil, ErrNoRates
+	}
+
+	rate, err := rates.Rate(c.base, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Conversion{
+		From:      c.base,
+		To:        currency,
+		Rate:      rate,
+		AsOf:      rates.AsOf,
+		FetchedAt: rates.FetchedAt,
+		Stale:     c.now().Sub(rates.FetchedAt) > c.maxAge,
+	}, nil
+}
@@ -0,0 +1,409 @@
+package fxrates_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/nicholasss/expense-tracker-api/internal/fxrates"
+	"github.com/nicholasss/expense-tracker-api/internal/sqlite"
+)
+
+// fakeProvider returns rates until err is set
+type fakeProvider struct {
+	rates *fxrates.Rates
+	err   error
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) Latest(ctx context.Context) (*fxrates.Rates, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	// a fresh copy each time, as a real provider would decode
+	rates := *p.rates
+	return &rates, nil
+}
+
+// setupTestRepo creates a rate repository backed by an in-memory sqlite database
+func setupTestRepo(t *testing.T) *sqlite.FXRateRepository {
+	t.Helper()
+
+	repo, err := sqlite.NewSqliteRepository("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+
+	t.Cleanup(func() {
+		if err := repo.DB.Close(); err != nil {
+			t.Errorf("unable to close connection to in-memory sqlite database: %v", err)
+		}
+	})
+
+	createQuery := `
+  CREATE TABLE
+    fx_rates (
+      base TEXT NOT NULL,
+      currency TEXT NOT NULL,
+      rate REAL NOT NULL,
+      as_of INTEGER NOT NULL,
+      fetched_at INTEGER NOT NULL,
+      PRIMARY KEY (base, currency)
+    );`
+	if _, err := repo.DB.Exec(createQuery); err != nil {
+		t.Fatalf("unable to create tables: %v", err)
+	}
+
+	return sqlite.NewFXRateRepository(repo.DB, repo.Writer)
+}
+
+func TestCacheConvert(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputBase   string
+		inputTo     string
+		inputAge    time.Duration // since the rates were fetched
+		expectError bool
+		wantError   error
+		wantRate    float64
+		wantStale   bool
+	}{
+		{
+			name:      "valid-from-provider-base",
+			inputBase: "EUR",
+			inputTo:   "USD",
+			wantRate:  1.25,
+		},
+		{
+			name:      "valid-to-provider-base",
+			inputBase: "USD",
+			inputTo:   "EUR",
+			wantRate:  0.8,
+		},
+		{
+			name:      "valid-cross-rate",
+			inputBase: "USD",
+			inputTo:   "GBP",
+			wantRate:  0.68,
+		},
+		{
+			name:      "valid-same-currency",
+			inputBase: "EUR",
+			inputTo:   "EUR",
+			wantRate:  1,
+		},
+		{
+			name:      "valid-stale-rates-still-used",
+			inputBase: "EUR",
+			inputTo:   "USD",
+			inputAge:  49 * time.Hour,
+			wantRate:  1.25,
+			wantStale: true,
+		},
+		{
+			name:        "invalid-unknown-currency",
+			inputBase:   "EUR",
+			inputTo:     "XYZ",
+			expectError: true,
+			wantError:   fxrates.ErrUnknownCurrency,
+		},
+	}
+
+	provider := &fakeProvider{rates: &fxrates.Rates{
+		Base:  "EUR",
+		Rates: map[string]float64{"USD": 1.25, "GBP": 0.85},
+		AsOf:  time.Date(2025, 10, 14, 0, 0, 0, 0, time.UTC),
+	}}
+	fetchedAt := time.Date(2025, 10, 14, 16, 0, 0, 0, time.UTC)
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			now := fetchedAt
+			cache := fxrates.NewCache(provider, setupTestRepo(t), testCase.inputBase, 48*time.Hour,
+				fxrates.WithClock(func() time.Time { return now }))
+			if err := cache.Refresh(t.Context()); err != nil {
+				t.Fatalf("Refresh() got error: '%v'", err)
+			}
+			now = now.Add(testCase.inputAge)
+
+			got, gotErr := cache.Convert(testCase.inputTo)
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+			if testCase.expectError {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: '%v', want error: '%v'", gotErr, testCase.wantError)
+				}
+				return
+			}
+
+			if math.Abs(got.Rate-testCase.wantRate) > 1e-9 {
+				t.Errorf("got rate: %v, want rate: %v", got.Rate, testCase.wantRate)
+			}
+			if got.Stale != testCase.wantStale {
+				t.Errorf("got stale: %v, want stale: %v", got.Stale, testCase.wantStale)
+			}
+			if !got.FetchedAt.Equal(fetchedAt) || !got.AsOf.Equal(provider.rates.AsOf) {
+				t.Errorf("got fetched at: %v, as of: %v", got.FetchedAt, got.AsOf)
+			}
+		})
+	}
+}
+
+func TestCacheRefreshAndLoad(t *testing.T) {
+	repo := setupTestRepo(t)
+	provider := &fakeProvider{rates: &fxrates.Rates{
+		Base:  "EUR",
+		Rates: map[string]float64{"USD": 1.25},
+		AsOf:  time.Date(2025, 10, 14, 0, 0, 0, 0, time.UTC),
+	}}
+
+	// nothing is converted before the first refresh
+	cache := fxrates.NewCache(provider, repo, "EUR", time.Hour)
+	if _, err := cache.Convert("USD"); !errors.Is(err, fxrates.ErrNoRates) {
+		t.Fatalf("Convert() before refresh got error: '%v', want: '%v'", err, fxrates.ErrNoRates)
+	}
+	if err := cache.Load(t.Context()); err != nil {
+		t.Fatalf("Load() with nothing saved got error: '%v'", err)
+	}
+	if err := cache.Refresh(t.Context()); err != nil {
+		t.Fatalf("Refresh() got error: '%v'", err)
+	}
+
+	// a failed refresh keeps the rates already in memory
+	provider.err = errors.New("provider is down")
+	if err := cache.Refresh(t.Context()); err == nil {
+		t.Fatalf("Refresh() with the provider down got no error")
+	}
+	if got, err := cache.Convert("USD"); err != nil || got.Rate != 1.25 {
+		t.Fatalf("Convert() after failed refresh got: %+v, error: '%v'", got, err)
+	}
+
+	// a restart starts from the saved rates, without the provider
+	restarted := fxrates.NewCache(provider, repo, "EUR", time.Hour)
+	if err := restarted.Load(t.Context()); err != nil {
+		t.Fatalf("Load() got error: '%v'", err)
+	}
+	got, err := restarted.Convert("USD")
+	if err != nil {
+		t.Fatalf("Convert() after load got error: '%v'", err)
+	}
+	if got.Rate != 1.25 || !got.AsOf.Equal(provider.rates.AsOf) {
+		t.Errorf("got conversion after load: %+v", got)
+	}
+}
+
+func TestConversionApply(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputRate   float64
+		inputAmount int64
+		wantAmount  int64
+	}{
+		{name: "valid-rounds-down", inputRate: 1.16, inputAmount: 1001, wantAmount: 1161},
+		{name: "valid-rounds-half-up", inputRate: 0.5, inputAmount: 1001, wantAmount: 501},
+		{name: "valid-zero", inputRate: 1.16, inputAmount: 0, wantAmount: 0},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			conversion := &fxrates.Conversion{Rate: testCase.inputRate}
+			if got := conversion.Apply(testCase.inputAmount); got != testCase.wantAmount {
+				t.Errorf("got amount: %d, want amount: %d", got, testCase.wantAmount)
+			}
+		})
+	}
+}
+
+func TestJSONProviderLatest(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputStatus int
+		inputBody   string
+		expectError bool
+		wantBase    string
+		wantUSD     float64
+	}{
+		{
+			name:        "valid-rates",
+			inputStatus: http.StatusOK,
+			inputBody:   `{"amount":1.0,"base":"EUR","date":"2025-10-14","rates":{"GBP":0.8687,"USD":1.1604}}`,
+			wantBase:    "EUR",
+			wantUSD:     1.1604,
+		},
+		{
+			name:        "invalid-status",
+			inputStatus: http.StatusBadGateway,
+			inputBody:   `{}`,
+			expectError: true,
+		},
+		{
+			name:        "invalid-no-rates",
+			inputStatus: http.StatusOK,
+			inputBody:   `{"base":"EUR","date":"2025-10-14","rates":{}}`,
+			expectError: true,
+		},
+		{
+			name:        "invalid-date",
+			inputStatus: http.StatusOK,
+			inputBody:   `{"base":"EUR","date":"14/10/2025","rates":{"USD":1.1604}}`,
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testCase.inputStatus)
+				_, _ = w.Write([]byte(testCase.inputBody))
+			}))
+			defer srv.Close()
+
+			got, gotErr := fxrates.NewJSONProvider(srv.URL).Latest(t.Context())
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+			if testCase.expectError {
+				return
+			}
+
+			if got.Base != testCase.wantBase || got.Rates["USD"] != testCase.wantUSD {
+				t.Errorf("got rates: %+v", got)
+			}
+			if want := time.Date(2025, 10, 14, 0, 0, 0, 0, time.UTC); !got.AsOf.Equal(want) {
+				t.Errorf("got as of: %v, want: %v", got.AsOf, want)
+			}
+		})
+	}
+}
+
+func TestECBProviderLatest(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputStatus int
+		inputBody   string
+		expectError bool
+		wantUSD     float64
+	}{
+		{
+			name:        "valid-rates",
+			inputStatus: http.StatusOK,
+			inputBody: `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<gesmes:Sender><gesmes:name>European Central Bank</gesmes:name></gesmes:Sender>
+	<Cube>
+		<Cube time='2025-10-14'>
+			<Cube currency='USD' rate='1.1604'/>
+			<Cube currency='GBP' rate='0.8687'/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`,
+			wantUSD: 1.1604,
+		},
+		{
+			name:        "invalid-status",
+			inputStatus: http.StatusServiceUnavailable,
+			inputBody:   ``,
+			expectError: true,
+		},
+		{
+			name:        "invalid-no-rates",
+			inputStatus: http.StatusOK,
+			inputBody:   `<Envelope><Cube><Cube time="2025-10-14"></Cube></Cube></Envelope>`,
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testCase.inputStatus)
+				_, _ = w.Write([]byte(testCase.inputBody))
+			}))
+			defer srv.Close()
+
+			got, gotErr := fxrates.NewECBProvider(srv.URL).Latest(t.Context())
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+			if testCase.expectError {
+				return
+			}
+
+			if got.Base != "EUR" || got.Rates["USD"] != testCase.wantUSD || len(got.Rates) != 2 {
+				t.Errorf("got rates: %+v", got)
+			}
+			if want := time.Date(2025, 10, 14, 0, 0, 0, 0, time.UTC); !got.AsOf.Equal(want) {
+				t.Errorf("got as of: %v, want: %v", got.AsOf, want)
+			}
+		})
+	}
+}
+
+func TestFallbackProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	if err := os.WriteFile(path, []byte(`{"base":"EUR","date":"2025-10-01","rates":{"USD":1.17}}`), 0o600); err != nil {
+		t.Fatalf("unable to write rates file: %v", err)
+	}
+	primaryRates := &fxrates.Rates{Base: "EUR", Rates: map[string]float64{"USD": 1.16}}
+
+	testTable := []struct {
+		name        string
+		inputErr    error  // from the primary provider
+		inputPath   string // of the static fallback
+		expectError bool
+		wantUSD     float64
+	}{
+		{
+			name:      "valid-primary",
+			inputPath: path,
+			wantUSD:   1.16,
+		},
+		{
+			name:      "valid-primary-down-uses-static",
+			inputErr:  errors.New("ecb unreachable"),
+			inputPath: path,
+			wantUSD:   1.17,
+		},
+		{
+			name:        "invalid-both-failing",
+			inputErr:    errors.New("ecb unreachable"),
+			inputPath:   filepath.Join(t.TempDir(), "missing.json"),
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			provider := &fxrates.FallbackProvider{
+				Primary:  &fakeProvider{rates: primaryRates, err: testCase.inputErr},
+				Fallback: fxrates.NewStaticProvider(testCase.inputPath),
+			}
+
+			got, gotErr := provider.Latest(t.Context())
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+			if testCase.expectError {
+				if !errors.Is(gotErr, testCase.inputErr) {
+					t.Errorf("got error: '%v', want it to include: '%v'", gotErr, testCase.inputErr)
+				}
+				return
+			}
+			if got.Rates["USD"] != testCase.wantUSD {
+				t.Errorf("got rates: %+v, want USD: %v", got, testCase.wantUSD)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package fxrates
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// RateProvider is implemented by each source of exchange rates
+type RateProvider interface {
+	// Name is for logging, i.e. "json"
+	Name() string
+
+	// Latest returns the newest rates the provider has, FetchedAt is set by the cache
+	Latest(ctx context.Context) (*Rates, error)
+}
+
+// JSONProvider reads the {"base": "EUR", "date": "2025-10-14", "rates": {"USD": 1.16}} format
+// served by Frankfurter and similar ECB reference rate APIs
+type JSONProvider struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewJSONProvider creates a provider for url, i.e. https://api.frankfurter.app/latest
+func NewJSONProvider(url string) *JSONProvider {
+	return &JSONProvider{URL: url, Client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (p *JSONProvider) Name() string { return "json" }
+
+// Latest fetches and decodes the rates at URL
+func (p *JSONProvider) Latest(ctx context.Context) (*Rates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rates request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rates request failed: unexpected status %s", resp.Status)
+	}
+
+	return decodeRates(resp.Body)
+}
+
+// decodeRates reads rates in the JSONProvider format
+func decodeRates(r io.Reader) (*Rates, error) {
+	var ratesResp struct {
+		Base  string             `json:"base"`
+		Date  string             `json:"date"`
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(r).Decode(&ratesResp); err != nil {
+		return nil, fmt.Errorf("invalid rates response: %w", err)
+	}
+	if ratesResp.Base == "" || len(ratesResp.Rates) == 0 {
+		return nil, fmt.Errorf("invalid rates response: no base or rates")
+	}
+
+	asOf, err := time.Parse("2006-01-02", ratesResp.Date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rates date %q: %w", ratesResp.Date, err)
+	}
+
+	return &Rates{Base: ratesResp.Base, Rates: ratesResp.Rates, AsOf: asOf}, nil
+}
+
+// ECBDailyURL is the European Central Bank's daily reference rates, published around 16:00 CET on working days
+const ECBDailyURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// ECBProvider reads the European Central Bank's reference rates straight from its XML feed,
+// which are always based in EUR
+type ECBProvider struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewECBProvider creates a provider for url, ECBDailyURL when empty
+func NewECBProvider(url string) *ECBProvider {
+	if url == "" {
+		url = ECBDailyURL
+	}
+	return &ECBProvider{URL: url, Client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (p *ECBProvider) Name() string { return "ecb" }
+
+// Latest fetches and decodes the feed at URL
+func (p *ECBProvider) Latest(ctx context.Context) (*Rates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/xml")
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rates request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rates request failed: unexpected status %s", resp.Status)
+	}
+
+	// <gesmes:Envelope><Cube><Cube time="2025-10-14"><Cube currency="USD" rate="1.1609"/>...
+	var envelope struct {
+		Cube struct {
+			Day struct {
+				Time  string `xml:"time,attr"`
+				Rates []struct {
+					Currency string  `xml:"currency,attr"`
+					Rate     float64 `xml:"rate,attr"`
+				} `xml:"Cube"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("invalid rates response: %w", err)
+	}
+
+	day := envelope.Cube.Day
+	if len(day.Rates) == 0 {
+		return nil, fmt.Errorf("invalid rates response: no rates")
+	}
+	asOf, err := time.Parse("2006-01-02", day.Time)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rates date %q: %w", day.Time, err)
+	}
+
+	rates := make(map[string]float64, len(day.Rates))
+	for _, rate := range day.Rates {
+		rates[rate.Currency] = rate.Rate
+	}
+	return &Rates{Base: "EUR", Rates: rates, AsOf: asOf}, nil
+}
+
+// StaticProvider reads rates from a file in the JSONProvider format, kept up to date by hand.
+// The file is read on every refresh, so edits are picked up without a restart
+type StaticProvider struct {
+	Path string
+}
+
+func NewStaticProvider(path string) *StaticProvider {
+	return &StaticProvider{Path: path}
+}
+
+func (p *StaticProvider) Name() string { return "static" }
+
+// Latest reads the rates in the file
+func (p *StaticProvider) Latest(ctx context.Context) (*Rates, error) {
+	f, err := os.Open(p.Path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open rates file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return decodeRates(f)
+}
+
+// FallbackProvider uses Fallback's rates while Primary is failing, i.e. static rates while the ECB can't be reached
+type FallbackProvider struct {
+	Primary  RateProvider
+	Fallback RateProvider
+}
+
+func (p *FallbackProvider) Name() string {
+	return p.Primary.Name() + " (falling back to " + p.Fallback.Name() + ")"
+}
+
+// Latest is Primary's rates, or Fallback's when Primary fails
+func (p *FallbackProvider) Latest(ctx context.Context) (*Rates, error) {
+	rates, err := p.Primary.Latest(ctx)
+	if err == nil {
+		return rates, nil
+	}
+
+	fallback, fallbackErr := p.Fallback.Latest(ctx)
+	if fallbackErr != nil {
+		return nil, errors.Join(err, fallbackErr)
+	}
+	log.Printf("exchange rate refresh from %s failed, using %s rates: %v", p.Primary.Name(), p.Fallback.Name(), err)
+	return fallback, nil
+}
@@ -0,0 +1,64 @@
+// Package fxrates keeps exchange rates in memory, refreshed from a provider on a schedule and persisted
+// so a restart starts with the last rates rather than none. Requests never wait on the provider.
+package fxrates
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrNoRates is returned before the first refresh has succeeded, when nothing was persisted either
+var ErrNoRates = errors.New("no exchange rates have been fetched yet")
+
+// ErrUnknownCurrency is wrapped when a currency is not in the latest rates
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// Rates are the units of each currency worth one unit of Base, i.e. Base "EUR" and "USD": 1.16
+type Rates struct {
+	Base      string
+	Rates     map[string]float64
+	AsOf      time.Time // the day the provider published the rates
+	FetchedAt time.Time // when they were fetched from the provider
+}
+
+// rate is the units of currency per unit of Base, Base itself is always 1
+func (r *Rates) rate(currency string) (float64, error) {
+	if currency == r.Base {
+		return 1, nil
+	}
+	rate, ok := r.Rates[currency]
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("%w %q", ErrUnknownCurrency, currency)
+	}
+	return rate, nil
+}
+
+// Rate is the units of to worth one unit of from, crossing through Base when neither is Base
+func (r *Rates) Rate(from, to string) (float64, error) {
+	fromRate, err := r.rate(from)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := r.rate(to)
+	if err != nil {
+		return 0, err
+	}
+	return toRate / fromRate, nil
+}
+
+// Conversion is one rate from the cache along with how fresh it is, for responses to show
+type Conversion struct {
+	From      string
+	To        string
+	Rate      float64
+	AsOf      time.Time
+	FetchedAt time.Time
+	Stale     bool // the rates were last refreshed longer ago than the cache allows
+}
+
+// Apply converts an amount in minor units, i.e. cents, rounding half away from zero
+func (c *Conversion) Apply(amount int64) int64 {
+	return int64(math.Round(float64(amount) * c.Rate))
+}
@@ -0,0 +1,12 @@
+package fxrates
+
+import "context"
+
+// Repository persists the latest rates, so they survive a restart
+type Repository interface {
+	// load the saved rates, nil if none have been saved
+	LoadRates(ctx context.Context) (*Rates, error)
+
+	// replace the saved rates
+	SaveRates(ctx context.Context, rates *Rates) error
+}
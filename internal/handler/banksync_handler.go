@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/banksync"
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+)
+
+// === Handler Type
+
+type BankSyncHandler struct {
+	Service banksync.Service
+}
+
+func NewBankSyncHandler(service banksync.Service) *BankSyncHandler {
+	return &BankSyncHandler{Service: service}
+}
+
+// == Endpoint Types ==
+
+// DraftResponse is a pulled bank transaction that is waiting to be confirmed
+type DraftResponse struct {
+	ID          int         `json:"id"`
+	Provider    string      `json:"provider"`
+	OccuredAt   RFC3339Time `json:"occured_at"`
+	Description string      `json:"description"`
+	Amount      int64       `json:"amount"`
+	Currency    string      `json:"currency"`
+	Status      string      `json:"status"`
+}
+
+func draftToResponse(draft *banksync.Draft) *DraftResponse {
+	return &DraftResponse{
+		ID:          draft.ID,
+		Provider:    draft.Provider,
+		OccuredAt:   RFC3339Time{Time: draft.OccuredAt},
+		Description: draft.Description,
+		Amount:      draft.Amount.Minor,
+		Currency:    draft.Amount.Currency,
+		Status:      string(draft.Status),
+	}
+}
+
+// SyncResponse reports how many new drafts a manual sync created
+type SyncResponse struct {
+	Created int `json:"created"`
+}
+
+// === Endpoint Hanlders ===
+
+func (h *BankSyncHandler) GetPendingDrafts(c *gin.Context) {
+	drafts, err := h.Service.PendingDrafts(c.Request.Context())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	responseDrafts := make([]*DraftResponse, 0, len(drafts))
+	for _, draft := range drafts {
+		responseDrafts = append(responseDrafts, draftToResponse(draft))
+	}
+
+	c.JSON(http.StatusOK, responseDrafts)
+}
+
+func (h *BankSyncHandler) SyncNow(c *gin.Context) {
+	created, err := h.Service.Sync(c.Request.Context())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadGateway, gin.H{"error": "Bad Gateway: unable to sync with bank provider"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SyncResponse{Created: created})
+}
+
+func (h *BankSyncHandler) ConfirmDraft(c *gin.Context) {
+	idInt, err := ParseIDParam(c, "id")
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+
+	newRecord, err := h.Service.ConfirmDraft(c.Request.Context(), idInt)
+	if err != nil {
+		if errors.Is(err, banksync.ErrDraftNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not Found: " + err.Error()})
+			return
+		} else if errors.Is(err, banksync.ErrDraftNotPending) {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Conflict: " + err.Error()})
+			return
+		} else if expenses.KindOf(err) == expenses.KindInvalid {
+			// the draft came from the bank, so there is nothing wrong with the request itself
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Unprocessable Entity: " + err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	// return the new expense, pointing to where it lives
+	resp := expenseToResponse(newRecord)
+	c.Header("Location", resp.URL)
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *BankSyncHandler) DismissDraft(c *gin.Context) {
+	idInt, err := ParseIDParam(c, "id")
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+
+	err = h.Service.DismissDraft(c.Request.Context(), idInt)
+	if err != nil {
+		if errors.Is(err, banksync.ErrDraftNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not Found: " + err.Error()})
+			return
+		} else if errors.Is(err, banksync.ErrDraftNotPending) {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Conflict: " + err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/categories"
+)
+
+// === Handler Type
+
+// CategoryHandler manages the categories of the authenticated user's book
+type CategoryHandler struct {
+	Service categories.Service
+}
+
+func NewCategoryHandler(service categories.Service) *CategoryHandler {
+	return &CategoryHandler{Service: service}
+}
+
+// == Endpoint Types ==
+
+// CategoryRequest is utilized for the Create and Rename endpoints: POST /categories and PUT /categories/:id
+type CategoryRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// CategoryResponse is one of the book's categories, expenses refer to it by id
+type CategoryResponse struct {
+	ID        int         `json:"id"`
+	Name      string      `json:"name"`
+	CreatedAt RFC3339Time `json:"created_at"`
+}
+
+func categoryToResponse(category *categories.Category) *CategoryResponse {
+	return &CategoryResponse{
+		ID:        category.ID,
+		Name:      category.Name,
+		CreatedAt: RFC3339Time{Time: category.CreatedAt},
+	}
+}
+
+// abortWithCategoryError maps errors from categories.Service
+func abortWithCategoryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, categories.ErrEmptyName):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+	case errors.Is(err, categories.ErrNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not Found: " + err.Error()})
+	case errors.Is(err, categories.ErrNameTaken):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Conflict: " + err.Error()})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+	}
+}
+
+// === Endpoint Hanlders ===
+
+// List sends the book's categories by name, a new book starts with categories.Defaults
+func (h *CategoryHandler) List(c *gin.Context) {
+	found, err := h.Service.List(c.Request.Context())
+	if err != nil {
+		abortWithCategoryError(c, err)
+		return
+	}
+
+	resp := make([]*CategoryResponse, 0, len(found))
+	for _, category := range found {
+		resp = append(resp, categoryToResponse(category))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *CategoryHandler) Create(c *gin.Context) {
+	var reqBody CategoryRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	category, err := h.Service.Create(c.Request.Context(), reqBody.Name)
+	if err != nil {
+		abortWithCategoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, categoryToResponse(category))
+}
+
+// Rename renames a category, the expenses in it stay in it
+func (h *CategoryHandler) Rename(c *gin.Context) {
+	id, err := ParseIDParam(c, "id")
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+
+	var reqBody CategoryRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	category, err := h.Service.Rename(c.Request.Context(), id, reqBody.Name)
+	if err != nil {
+		abortWithCategoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, categoryToResponse(category))
+}
+
+// Archive takes a category out of use, the expenses already in it keep it
+func (h *CategoryHandler) Archive(c *gin.Context) {
+	id, err := ParseIDParam(c, "id")
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+
+	if err := h.Service.Archive(c.Request.Context(), id); err != nil {
+		abortWithCategoryError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
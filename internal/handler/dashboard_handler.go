@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/projections"
+)
+
+// === Handler Type
+
+// DashboardHandler serves the precomputed dashboard of the user's book
+type DashboardHandler struct {
+	Projector *projections.Projector
+}
+
+func NewDashboardHandler(projector *projections.Projector) *DashboardHandler {
+	return &DashboardHandler{Projector: projector}
+}
+
+// == Endpoint Types ==
+
+// CategoryTotalResponse is the total of one category, category_id is 0 for uncategorized
+type CategoryTotalResponse struct {
+	CategoryID int   `json:"category_id"`
+	Amount     int64 `json:"amount"`
+	Count      int   `json:"count"`
+}
+
+// MonthTotalResponse is the total of the current month, in UTC
+type MonthTotalResponse struct {
+	From   RFC3339Time `json:"from"`
+	To     RFC3339Time `json:"to"`
+	Amount int64       `json:"amount"`
+	Count  int         `json:"count"`
+}
+
+// DashboardResponse is utilized specifically for the GetDashboard endpoint: GET /expenses/dashboard
+type DashboardResponse struct {
+	Currency   string                   `json:"currency"`
+	Month      MonthTotalResponse       `json:"month"`
+	Categories []*CategoryTotalResponse `json:"categories"`
+	Latest     []*ExpenseResponse       `json:"latest"`
+	BuiltAt    RFC3339Time              `json:"built_at"`
+}
+
+// === Endpoint Hanlders ===
+
+// GetDashboard sends this month's total, every category's total, and the newest expenses.
+// They are kept up to date as expenses change, rather than summed for each request
+func (h *DashboardHandler) GetDashboard(c *gin.Context) {
+	dashboard, err := h.Projector.Dashboard(c.Request.Context())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	categories := make([]*CategoryTotalResponse, 0, len(dashboard.Categories))
+	for _, total := range dashboard.Categories {
+		categories = append(categories, &CategoryTotalResponse{
+			CategoryID: total.CategoryID,
+			Amount:     total.Amount.Minor,
+			Count:      total.Count,
+		})
+	}
+
+	latest := make([]*ExpenseResponse, 0, len(dashboard.Latest))
+	for _, exp := range dashboard.Latest {
+		latest = append(latest, expenseToResponse(exp))
+	}
+
+	c.JSON(http.StatusOK, &DashboardResponse{
+		Currency: dashboard.Month.Amount.Currency,
+		Month: MonthTotalResponse{
+			From:   RFC3339Time{Time: dashboard.From},
+			To:     RFC3339Time{Time: dashboard.To},
+			Amount: dashboard.Month.Amount.Minor,
+			Count:  dashboard.Month.Count,
+		},
+		Categories: categories,
+		Latest:     latest,
+		BuiltAt:    RFC3339Time{Time: dashboard.BuiltAt},
+	})
+}
@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/audit"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/households"
+	"github.com/nicholasss/expense-tracker-api/internal/i18n"
+	"github.com/nicholasss/expense-tracker-api/internal/users"
+)
+
+// === Handler Type
+
+// DataExportHandler gathers everything stored about a user into one archive.
+// Households and Audit are optional, their files are left out when they are nil.
+type DataExportHandler struct {
+	Users      users.Service
+	Expenses   expenses.Service
+	Households households.Service
+	Audit      audit.Service
+}
+
+func NewDataExportHandler(userService users.Service, expenseService expenses.Service,
+	householdService households.Service, auditService audit.Service,
+) *DataExportHandler {
+	return &DataExportHandler{Users: userService, Expenses: expenseService, Households: householdService, Audit: auditService}
+}
+
+// == Endpoint Types ==
+
+// dataExportManifest describes the archive, it is written as manifest.json
+type dataExportManifest struct {
+	UserID      int         `json:"user_id"`
+	GeneratedAt RFC3339Time `json:"generated_at"`
+	Files       []string    `json:"files"`
+	Attachments []string    `json:"attachments"` // always empty, no attachments are stored yet
+}
+
+type dataExportIdentity struct {
+	Issuer   string      `json:"issuer"`
+	Subject  string      `json:"subject"`
+	LinkedAt RFC3339Time `json:"linked_at"`
+}
+
+// dataExportProfile is the full account record, the password hash is left out on purpose
+type dataExportProfile struct {
+	ID          int                  `json:"id"`
+	Email       string               `json:"email"`
+	Name        string               `json:"name"`
+	IsAdmin     bool                 `json:"is_admin"`
+	HasPassword bool                 `json:"has_password"`
+	Locale      string               `json:"locale"`
+	CreatedAt   RFC3339Time          `json:"created_at"`
+	Identities  []dataExportIdentity `json:"identities"`
+}
+
+// dataExportHousehold only includes the user's own membership, not the other members
+type dataExportHousehold struct {
+	ID        int         `json:"id"`
+	Name      string      `json:"name"`
+	Role      string      `json:"role"`
+	JoinedAt  RFC3339Time `json:"joined_at"`
+	CreatedAt RFC3339Time `json:"created_at"`
+}
+
+// dataExportFile is one file in the archive
+type dataExportFile struct {
+	name string
+	data []byte
+}
+
+// === Endpoint Hanlders ===
+
+// ExportMe responds with a zip of everything stored about the authenticated user, it needs middleware.RequireAuth
+func (h *DataExportHandler) ExportMe(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	files, err := h.collect(ctx, userID)
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotFound) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: " + err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	archive, err := writeZip(files)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	filename := "user-" + strconv.Itoa(userID) + "-export.zip"
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// collect encodes every file of the export, with manifest.json first
+func (h *DataExportHandler) collect(ctx context.Context, userID int) ([]dataExportFile, error) {
+	files := make([]dataExportFile, 0)
+
+	addJSON := func(name string, v any) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		files = append(files, dataExportFile{name: name, data: data})
+		return nil
+	}
+
+	// profile
+	user, err := h.Users.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := h.Users.Identities(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := dataExportProfile{
+		ID:          user.ID,
+		Email:       user.Email,
+		Name:        user.Name,
+		IsAdmin:     user.IsAdmin,
+		HasPassword: user.PasswordHash != "",
+		Locale:      user.Locale,
+		CreatedAt:   RFC3339Time{Time: user.CreatedAt},
+		Identities:  make([]dataExportIdentity, 0, len(identities)),
+	}
+	for _, identity := range identities {
+		profile.Identities = append(profile.Identities, dataExportIdentity{
+			Issuer:   identity.Issuer,
+			Subject:  identity.Subject,
+			LinkedAt: RFC3339Time{Time: identity.CreatedAt},
+		})
+	}
+	if err := addJSON("profile.json", profile); err != nil {
+		return nil, err
+	}
+
+	// expenses, the household book also has other members' records so only the user's own are included.
+	// Streamed rather than read at once, the whole of a user's own data is never over the unpaged read cap
+	owned := make([]*expenses.Expense, 0)
+	err = h.Expenses.EachExpense(ctx, func(record *expenses.Expense) error {
+		if record.OwnerID == userID {
+			owned = append(owned, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	expensesJSON, err := encodeExpensesJSON(owned)
+	if err != nil {
+		return nil, err
+	}
+	// the csv is for people, so it is written the way the user saved, expenses.json keeps the plain formats
+	var localeFormat *i18n.Format
+	if format, ok := i18n.FormatFor(user.Locale); ok {
+		localeFormat = &format
+	}
+	expensesCSV, err := encodeExpensesCSV(owned, localeFormat)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files,
+		dataExportFile{name: "expenses.json", data: expensesJSON},
+		dataExportFile{name: "expenses.csv", data: expensesCSV},
+	)
+
+	// household membership
+	if h.Households != nil {
+		household, members, err := h.Households.Get(ctx, userID)
+		if err != nil && !errors.Is(err, households.ErrNotMember) {
+			return nil, err
+		}
+		if err == nil {
+			for _, member := range members {
+				if member.UserID != userID {
+					continue
+				}
+				err := addJSON("household.json", dataExportHousehold{
+					ID:        household.ID,
+					Name:      household.Name,
+					Role:      string(member.Role),
+					JoinedAt:  RFC3339Time{Time: member.JoinedAt},
+					CreatedAt: RFC3339Time{Time: household.CreatedAt},
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	// security events about the user
+	if h.Audit != nil {
+		events, err := h.Audit.List(ctx, audit.Filter{UserID: userID})
+		if err != nil {
+			return nil, err
+		}
+
+		resp := make([]AuditEventResponse, 0, len(events))
+		for _, event := range events {
+			resp = append(resp, auditEventToResponse(event))
+		}
+		if err := addJSON("audit_events.json", resp); err != nil {
+			return nil, err
+		}
+	}
+
+	manifest := dataExportManifest{
+		UserID:      userID,
+		GeneratedAt: RFC3339Time{Time: time.Now().UTC()},
+		Files:       make([]string, 0, len(files)),
+		Attachments: make([]string, 0),
+	}
+	for _, file := range files {
+		manifest.Files = append(manifest.Files, file.name)
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]dataExportFile{{name: "manifest.json", data: data}}, files...), nil
+}
+
+// writeZip writes files into a zip archive in order
+func writeZip(files []dataExportFile) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+
+	for _, file := range files {
+		f, err := w.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(file.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
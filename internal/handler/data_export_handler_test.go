@@ -0,0 +1,112 @@
+package handler_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/handler"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+	"github.com/nicholasss/expense-tracker-api/internal/users"
+)
+
+// exportUserService only implements what the data export reads, the rest of users.Service is left nil
+type exportUserService struct {
+	users.Service
+	user *users.User
+}
+
+func (s *exportUserService) GetProfile(ctx context.Context, id int) (*users.User, error) {
+	if id != s.user.ID {
+		return nil, users.ErrUserNotFound
+	}
+	return s.user, nil
+}
+
+func (s *exportUserService) Identities(ctx context.Context, id int) ([]*users.Identity, error) {
+	return []*users.Identity{{Issuer: "https://accounts.example.com", Subject: "ada-sub", CreatedAt: time.Now()}}, nil
+}
+
+func TestExportMe(t *testing.T) {
+	userService := &exportUserService{user: &users.User{ID: 1, Email: "ada@example.com", Name: "Ada", PasswordHash: "secret-hash"}}
+
+	// the second expense belongs to another household member
+	expenseService := &mockService{lastID: 2, db: map[int]*expenses.Expense{
+		1: {ID: 1, OwnerID: 1, Description: "groceries", Amount: money.New(1250, "EUR"), ExpenseOccuredAt: time.Now()},
+		2: {ID: 2, OwnerID: 2, Description: "not ada's", Amount: money.New(999, "EUR"), ExpenseOccuredAt: time.Now()},
+	}}
+
+	h := handler.NewDataExportHandler(userService, expenseService, nil, nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/me/export", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.WithUserID(c.Request.Context(), 1))
+		c.Next()
+	}, h.ExportMe)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/me/export", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status: %d, want: %d, body: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/zip" {
+		t.Errorf("got content type: %q, want: %q", got, "application/zip")
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("unable to read zip: %v", err)
+	}
+
+	files := make(map[string]string)
+	names := make([]string, 0)
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("unable to open %s: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatalf("unable to read %s: %v", f.Name, err)
+		}
+		files[f.Name] = string(data)
+		names = append(names, f.Name)
+	}
+
+	wantNames := []string{"manifest.json", "profile.json", "expenses.json", "expenses.csv"}
+	if !slices.Equal(names, wantNames) {
+		t.Errorf("got files: %v, want: %v", names, wantNames)
+	}
+
+	if strings.Contains(files["profile.json"], "secret-hash") {
+		t.Errorf("profile.json includes the password hash")
+	}
+	if !strings.Contains(files["profile.json"], "ada-sub") {
+		t.Errorf("profile.json is missing the linked identity: %s", files["profile.json"])
+	}
+
+	var exported []handler.ExpenseResponse
+	if err := json.Unmarshal([]byte(files["expenses.json"]), &exported); err != nil {
+		t.Fatalf("unable to decode expenses.json: %v", err)
+	}
+	if len(exported) != 1 || exported[0].ID != 1 {
+		t.Errorf("expenses.json got: %+v, want only expense 1", exported)
+	}
+	if strings.Contains(files["expenses.csv"], "not ada's") {
+		t.Errorf("expenses.csv includes another member's expense")
+	}
+}
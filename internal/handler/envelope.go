@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListResponse is how the /v1 lists are sent, an object rather than a bare top-level array,
+// with what a client needs to page through the rest
+type ListResponse struct {
+	Data []any    `json:"data"`
+	Meta ListMeta `json:"meta"`
+}
+
+// ListMeta describes the page in Data. Total counts every match, not just the ones on the page,
+// and NextCursor is null on the last page
+type ListMeta struct {
+	Total      int     `json:"total"`
+	Limit      int     `json:"limit"`
+	Offset     int     `json:"offset"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+// ListExpensesPage is GET /v1/expenses, which takes the same query parameters as GetAllExpenses,
+// other than ?ids=. The next page is in meta.next_cursor rather than the Link header
+func (h *GinHandler) ListExpensesPage(c *gin.Context) {
+	fields, err := ParseFieldsQuery(c, "fields", expenseFieldNames)
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+
+	filter, err := parseListFilter(c, h.MaxPageLimit)
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+	toResponse, ok := h.expenseResponder(c)
+	if !ok {
+		return
+	}
+
+	records, next, err := h.Service.ListExpenses(c.Request.Context(), filter)
+	if err != nil {
+		abortWithServiceError(c, err)
+		return
+	}
+	total, err := h.Service.CountExpenses(c.Request.Context(), filter)
+	if err != nil {
+		abortWithServiceError(c, err)
+		return
+	}
+
+	responseRecords := make([]*ExpenseResponse, 0, len(records))
+	for _, record := range records {
+		responseRecords = append(responseRecords, toResponse(record))
+	}
+	projected, err := projectEachField(responseRecords, fields)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	meta := ListMeta{Total: total, Limit: filter.Limit, Offset: filter.Offset}
+	if next != nil {
+		encoded := next.Encode()
+		meta.NextCursor = &encoded
+	}
+
+	c.Render(http.StatusOK, pooledJSON{Data: ListResponse{Data: projected, Meta: meta}})
+}
+
+// ListPage is GET /v1/categories, every category in one page
+func (h *CategoryHandler) ListPage(c *gin.Context) {
+	found, err := h.Service.List(c.Request.Context())
+	if err != nil {
+		abortWithCategoryError(c, err)
+		return
+	}
+
+	data := make([]any, 0, len(found))
+	for _, category := range found {
+		data = append(data, categoryToResponse(category))
+	}
+
+	c.JSON(http.StatusOK, ListResponse{Data: data, Meta: ListMeta{Total: len(data)}})
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+)
+
+// kindStatuses are the statuses service errors are answered with, by their kind
+var kindStatuses = map[expenses.Kind]int{
+	expenses.KindInvalid:  http.StatusBadRequest,
+	expenses.KindNotFound: http.StatusNotFound,
+	expenses.KindConflict: http.StatusConflict,
+	expenses.KindGone:     http.StatusGone,
+}
+
+// ProblemResponse is one of the problems with a request, for the field at fault when there is one
+type ProblemResponse struct {
+	Field string `json:"field,omitempty"`
+	Error string `json:"error"`
+}
+
+// abortWithServiceError answers err by its kind, naming the field at fault when there is one.
+// When err joins several problems, i.e. every check an expense failed, they are all listed under "errors".
+// Internal errors are answered with a generic 500, their details are not for clients
+func abortWithServiceError(c *gin.Context, err error) {
+	status, ok := kindStatuses[expenses.KindOf(err)]
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	problems := expenses.Problems(err)
+	messages := make([]string, 0, len(problems))
+	responses := make([]ProblemResponse, 0, len(problems))
+	for _, problem := range problems {
+		messages = append(messages, problem.Error())
+		responses = append(responses, ProblemResponse{Field: expenses.FieldOf(problem), Error: problem.Error()})
+	}
+
+	body := gin.H{"error": http.StatusText(status) + ": " + strings.Join(messages, "; ")}
+	if field := expenses.FieldOf(err); field != "" {
+		body["field"] = field
+	}
+	if len(responses) > 1 {
+		body["errors"] = responses
+	}
+	c.AbortWithStatusJSON(status, body)
+}
@@ -3,22 +3,35 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/categories"
 	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+	"github.com/nicholasss/expense-tracker-api/internal/quickadd"
 )
 
 // === Handler Type
 
 type GinHandler struct {
 	Service expenses.Service
+
+	// MaxPageLimit is the largest ?limit= a list accepts
+	MaxPageLimit int
+
+	// Parser reads the text sent to ParseExpense, rule based unless another one is plugged in
+	Parser quickadd.Parser
+
+	// Categories are embedded in expenses with ?include=category, which is refused while it is nil
+	Categories categories.Service
 }
 
 func NewGinHandler(service expenses.Service) *GinHandler {
-	return &GinHandler{Service: service}
+	return &GinHandler{Service: service, MaxPageLimit: MaxPageLimit, Parser: quickadd.RuleParser{}}
 }
 
 // == Helper Types ==
@@ -44,8 +57,13 @@ func (t *RFC3339Time) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// MarshalJSON formats straight into the returned slice, a quoted RFC3339 time never needs escaping
 func (t *RFC3339Time) MarshalJSON() ([]byte, error) {
-	return json.Marshal(t.Format(time.RFC3339))
+	// the layout is as long as any time it formats, i.e. "2025-10-23T15:00:00+02:00"
+	b := make([]byte, 0, len(time.RFC3339)+2)
+	b = append(b, '"')
+	b = t.AppendFormat(b, time.RFC3339)
+	return append(b, '"'), nil
 }
 
 // == Endpoint Types ==
@@ -56,9 +74,33 @@ type CreateExpenseRequest struct {
 	OccuredAt   RFC3339Time `json:"occured_at"`
 	Description string      `json:"description" binding:"required"`
 	Amount      int64       `json:"amount" binding:"required,gt=0"`
+	Currency    string      `json:"currency"`    // of amount, the currency expenses are recorded in when empty
+	TimeZone    string      `json:"timezone"`    // IANA zone occured_at is shown in, i.e. Europe/Berlin, its offset's when empty
+	CategoryID  int         `json:"category_id"` // one of GET /categories, uncategorized when empty
+}
+
+// occuredAt is when the expense occured, in the request's zone
+func (r *CreateExpenseRequest) occuredAt() (time.Time, error) {
+	if r.TimeZone == "" {
+		return r.OccuredAt.Time, nil
+	}
+	zone, err := expenses.LoadZone(r.TimeZone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return r.OccuredAt.In(zone), nil
+}
+
+// amount is the request's amount in its currency, defaulting to currency
+func (r *CreateExpenseRequest) amount(currency string) money.Money {
+	if r.Currency != "" {
+		currency = r.Currency
+	}
+	return money.New(r.Amount, currency)
 }
 
-// UpdateExpenseRequest is utilized specifically for the UpdateExpense endpoint: PUT /expense
+// UpdateExpenseRequest is utilized specifically for the deprecated UpdateExpenseByBody endpoint: PUT /expenses,
+// PUT /expenses/:id takes a CreateExpenseRequest
 type UpdateExpenseRequest struct {
 	ID int `json:"id" binding:"required"`
 	CreateExpenseRequest
@@ -66,23 +108,100 @@ type UpdateExpenseRequest struct {
 
 // ExpenseResponse is hopefully a general response that can be used across several endpoints
 type ExpenseResponse struct {
-	ID          int         `json:"id"`
-	CreatedAt   RFC3339Time `json:"created_at"`
-	OccuredAt   RFC3339Time `json:"occured_at"`
-	Description string      `json:"description"`
-	Amount      int64       `json:"amount"`
+	ID          int               `json:"id"`
+	OwnerID     int               `json:"owner_id,omitempty"`
+	CreatedAt   RFC3339Time       `json:"created_at"`
+	UpdatedAt   RFC3339Time       `json:"updated_at"`
+	OccuredAt   RFC3339Time       `json:"occured_at"`
+	Description string            `json:"description"`
+	Amount      int64             `json:"amount"`
+	Currency    string            `json:"currency"`
+	TimeZone    string            `json:"timezone"` // occured_at is in it, see expenses.ZoneName
+	CategoryID  int               `json:"category_id,omitempty"`
+	Category    *CategoryResponse `json:"category,omitempty"` // only with ?include=category
+	FutureDated bool              `json:"future_dated,omitempty"`
+	Version     int               `json:"version"`             // sent back as base_version with offline edits, see ApplyMutations
+	ClientID    string            `json:"client_id,omitempty"` // the UUID an offline client created it with
+	URL         string            `json:"url"`
+}
+
+// expenseURL is the canonical resource URL for an expense, used in responses and the Location header
+func expenseURL(id int) string {
+	return "/expenses/" + strconv.Itoa(id)
 }
 
 func expenseToResponse(exp *expenses.Expense) *ExpenseResponse {
 	return &ExpenseResponse{
 		ID:          exp.ID,
+		OwnerID:     exp.OwnerID,
 		CreatedAt:   RFC3339Time{Time: exp.RecordCreatedAt},
+		UpdatedAt:   RFC3339Time{Time: exp.RecordUpdatedAt},
 		OccuredAt:   RFC3339Time{Time: exp.ExpenseOccuredAt},
 		Description: exp.Description,
-		Amount:      exp.Amount,
+		Amount:      exp.Amount.Minor,
+		Currency:    exp.Amount.Currency,
+		TimeZone:    expenses.ZoneName(exp.ExpenseOccuredAt),
+		CategoryID:  exp.CategoryID,
+		FutureDated: exp.FutureDated,
+		Version:     exp.Version,
+		ClientID:    exp.ClientID,
+		URL:         expenseURL(exp.ID),
 	}
 }
 
+// BatchExpenseResponse is used for GET /expenses?ids=, listing the ids that had no record
+type BatchExpenseResponse struct {
+	Expenses []any `json:"expenses"`
+	Missing  []int `json:"missing"`
+}
+
+// ChangeResponse is one change in a ChangesResponse, deletes only have the id
+type ChangeResponse struct {
+	ID        int              `json:"id"`
+	Deleted   bool             `json:"deleted"`
+	ChangedAt RFC3339Time      `json:"changed_at"`
+	Expense   *ExpenseResponse `json:"expense,omitempty"`
+}
+
+// ChangesResponse is utilized specifically for the GetChanges endpoint: GET /expenses/changes
+type ChangesResponse struct {
+	Changes []*ChangeResponse `json:"changes"`
+	Cursor  string            `json:"cursor"` // ?since= of the next sync
+	HasMore bool              `json:"has_more"`
+}
+
+// MutationRequest is one offline edit of a MutationsRequest. Creates need client_id and expense, updates need
+// id or client_id, base_version, and expense, and deletes need id or client_id and base_version
+type MutationRequest struct {
+	Op          string                `json:"op" binding:"required"` // create, update, or delete
+	ClientID    string                `json:"client_id"`             // the UUID the client created the expense with
+	ID          int                   `json:"id"`
+	BaseVersion int                   `json:"base_version"` // the version of the expense the edit was made to
+	Expense     *CreateExpenseRequest `json:"expense"`
+}
+
+// MutationsRequest is utilized specifically for the ApplyMutations endpoint: POST /expenses/mutations
+type MutationsRequest struct {
+	Mutations []*MutationRequest `json:"mutations" binding:"required,dive"`
+}
+
+// MutationResultResponse is how one mutation of a MutationsRequest went, in the same order. Status is applied, conflict,
+// invalid, or not_applied when another mutation kept the batch from applying. Expense is the stored expense,
+// after the batch once applied or as it is for a conflict, and left out once deleted
+type MutationResultResponse struct {
+	Status  string           `json:"status"`
+	ID      int              `json:"id,omitempty"`
+	Error   string           `json:"error,omitempty"`
+	Field   string           `json:"field,omitempty"`
+	Expense *ExpenseResponse `json:"expense,omitempty"`
+}
+
+// MutationsResponse is utilized specifically for the ApplyMutations endpoint: POST /expenses/mutations
+type MutationsResponse struct {
+	Applied bool                      `json:"applied"`
+	Results []*MutationResultResponse `json:"results"`
+}
+
 // ErrorResponse is a payload type that is used for sending errors to the clients.
 type ErrorResponse struct {
 	HTTPCode int      `json:"code"`
@@ -91,47 +210,186 @@ type ErrorResponse struct {
 
 // === Endpoint Hanlders ===
 
+// GetAllExpenses lists expenses newest first, a page at a time.
+// Pages after the first are linked with a cursor in the Link header, and ?include=category embeds their categories
 func (h *GinHandler) GetAllExpenses(c *gin.Context) {
-	// get data
-	records, err := h.Service.GetAllExpenses(c.Request.Context())
+	// check for sparse fieldset
+	fields, err := ParseFieldsQuery(c, "fields", expenseFieldNames)
 	if err != nil {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		abortWithParamError(c, err)
+		return
+	}
+
+	// batch get when specific ids are requested
+	ids, ok, err := ParseIDsQuery(c, "ids")
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+	if ok {
+		h.getExpensesByIDs(c, ids, fields)
+		return
+	}
+
+	filter, err := parseListFilter(c, h.MaxPageLimit)
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+	toResponse, ok := h.expenseResponder(c)
+	if !ok {
+		return
+	}
+
+	// get one page of data, filtered by the database
+	records, next, err := h.Service.ListExpenses(c.Request.Context(), filter)
+	if err != nil {
+		abortWithServiceError(c, err)
+		return
+	}
+
+	// the next page is linked rather than numbered, so it stays right while expenses are added
+	if next != nil {
+		query := c.Request.URL.Query()
+		query.Del("offset")
+		query.Set("cursor", next.Encode())
+		c.Header("Link", "<"+c.Request.URL.Path+"?"+query.Encode()+">; rel=\"next\"")
+	}
+
+	// send data, each record is only turned into its response as it is encoded
+	c.Render(http.StatusOK, jsonArray[*expenses.Expense]{
+		Items: records,
+		Element: func(record *expenses.Expense) (any, error) {
+			return projectFields(toResponse(record), fields)
+		},
+	})
+}
+
+// parseListFilter reads the paging and filter query parameters of GET /expenses:
+// ?limit=, ?offset=, ?cursor=, ?from=, ?to=, ?min_amount=, ?max_amount=, ?q=, the text to search descriptions for,
+// and ?updated_since=, for clients fetching what changed since they last synced
+func parseListFilter(c *gin.Context, maxLimit int) (expenses.ListFilter, error) {
+	pagination, err := ParsePagination(c, maxLimit)
+	if err != nil {
+		return expenses.ListFilter{}, err
+	}
+	filter := expenses.ListFilter{Limit: pagination.Limit, Offset: pagination.Offset}
+
+	if raw, ok := c.GetQuery("cursor"); ok {
+		if filter.After, err = expenses.DecodeCursor(raw); err != nil {
+			return expenses.ListFilter{}, &ParamError{Param: "cursor", Reason: "must be the cursor of a previous page"}
+		}
+	}
+
+	if filter.From, _, err = ParseTimeQuery(c, "from"); err != nil {
+		return expenses.ListFilter{}, err
+	}
+	if filter.To, _, err = ParseTimeQuery(c, "to"); err != nil {
+		return expenses.ListFilter{}, err
+	}
+	if filter.UpdatedSince, _, err = ParseTimeQuery(c, "updated_since"); err != nil {
+		return expenses.ListFilter{}, err
+	}
+
+	minAmount, err := ParseIntQuery(c, "min_amount", 0, 1, math.MaxInt)
+	if err != nil {
+		return expenses.ListFilter{}, err
+	}
+	maxAmount, err := ParseIntQuery(c, "max_amount", 0, 1, math.MaxInt)
+	if err != nil {
+		return expenses.ListFilter{}, err
+	}
+	filter.MinAmount, filter.MaxAmount = int64(minAmount), int64(maxAmount)
+	filter.Search = c.Query("q")
+
+	return filter, nil
+}
+
+// GetAllOwnersExpenses lists every tenant's expenses, it needs middleware.RequireAdmin
+func (h *GinHandler) GetAllOwnersExpenses(c *gin.Context) {
+	records, err := h.Service.GetAllOwnersExpenses(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, expenses.ErrTooManyResults) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error() + ", the cap is set by MAX_RESULT_ROWS"})
+			return
+		}
+		abortWithServiceError(c, err)
+		return
+	}
+
+	// unpaged, so it is streamed rather than built up whole
+	c.Render(http.StatusOK, jsonArray[*expenses.Expense]{
+		Items: records,
+		Element: func(record *expenses.Expense) (any, error) {
+			return expenseToResponse(record), nil
+		},
+	})
+}
+
+// getExpensesByIDs responds with exactly the requested records, in request order
+func (h *GinHandler) getExpensesByIDs(c *gin.Context, ids []int, fields []string) {
+	toResponse, ok := h.expenseResponder(c)
+	if !ok {
 		return
 	}
 
-	responseRecords := make([]*ExpenseResponse, 0)
+	records, missing, err := h.Service.GetExpensesByIDs(c.Request.Context(), ids)
+	if err != nil {
+		abortWithServiceError(c, err)
+		return
+	}
+
+	responseRecords := make([]*ExpenseResponse, 0, len(records))
 	for _, record := range records {
-		responseRecords = append(responseRecords, expenseToResponse(record))
+		responseRecords = append(responseRecords, toResponse(record))
 	}
 
-	// send data
-	c.JSON(http.StatusOK, responseRecords)
+	projected, err := projectEachField(responseRecords, fields)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	c.Render(http.StatusOK, pooledJSON{Data: BatchExpenseResponse{Expenses: projected, Missing: missing}})
 }
 
+// GetExpenseByID sends one expense, ?include=category embeds its category
 func (h *GinHandler) GetExpenseByID(c *gin.Context) {
 	// check the ID for validity
-	idInt, err := strconv.Atoi(c.Param("id"))
+	idInt, err := ParseIDParam(c, "id")
 	if err != nil {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		abortWithParamError(c, err)
+		return
+	}
+
+	// check for sparse fieldset
+	fields, err := ParseFieldsQuery(c, "fields", expenseFieldNames)
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+
+	toResponse, ok := h.expenseResponder(c)
+	if !ok {
 		return
 	}
 
 	// get the record
 	record, err := h.Service.GetExpenseByID(c.Request.Context(), idInt)
 	if err != nil {
-		// specifically respond 404 if id is not a record
-		if errors.Is(err, expenses.ErrUnusedID) {
-			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not Found: " + err.Error()})
-			return
-		}
+		// 404 if id is not a record
+		abortWithServiceError(c, err)
+		return
+	}
 
-		// otherwise send generic error
-		c.AbortWithStatus(http.StatusInternalServerError)
+	projected, err := projectFields(toResponse(record), fields)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
 		return
 	}
 
 	// send reccord
-	c.JSON(http.StatusOK, expenseToResponse(record))
+	c.Render(http.StatusOK, pooledJSON{Data: projected})
 }
 
 func (h *GinHandler) CreateExpense(c *gin.Context) {
@@ -142,24 +400,55 @@ func (h *GinHandler) CreateExpense(c *gin.Context) {
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
 		return
 	}
+	occuredAt, err := reqBody.occuredAt()
+	if err != nil {
+		abortWithServiceError(c, err)
+		return
+	}
 
 	// send to service layer
-	newRecord, err := h.Service.NewExpense(c.Request.Context(), reqBody.OccuredAt.Time, reqBody.Description, reqBody.Amount)
+	newRecord, err := h.Service.NewExpense(c.Request.Context(), occuredAt, reqBody.Description, reqBody.amount(h.Service.Currency()), reqBody.CategoryID)
 	if err != nil {
-		// checking for service errors
-		if errors.Is(err, expenses.ErrInvalidAmount) || errors.Is(err, expenses.ErrInvalidOccuredAtTime) {
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
-			return
-		}
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		abortWithServiceError(c, err)
 		return
 	}
 
-	// return record
-	c.JSON(http.StatusCreated, expenseToResponse(newRecord))
+	// return record, pointing to where it lives
+	resp := expenseToResponse(newRecord)
+	c.Header("Location", resp.URL)
+	c.JSON(http.StatusCreated, resp)
 }
 
+// UpdateExpense replaces the expense with the id in the path: PUT /expenses/:id
 func (h *GinHandler) UpdateExpense(c *gin.Context) {
+	// check the ID for validity
+	idInt, err := ParseIDParam(c, "id")
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+
+	// bind and validation, an id in the body as well has to be the same one
+	var reqBody struct {
+		ID int `json:"id"`
+		CreateExpenseRequest
+	}
+	err = c.ShouldBindJSON(&reqBody)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+	if reqBody.ID != 0 && reqBody.ID != idInt {
+		abortWithParamError(c, &ParamError{Param: "id", Reason: "must match the id in the body, which can be left out"})
+		return
+	}
+
+	h.updateExpense(c, idInt, &reqBody.CreateExpenseRequest)
+}
+
+// UpdateExpenseByBody replaces the expense with the id in the body: PUT /expenses.
+// Deprecated: it predates PUT /expenses/:id, and is kept for existing clients
+func (h *GinHandler) UpdateExpenseByBody(c *gin.Context) {
 	// bind and validation
 	var reqBody UpdateExpenseRequest
 	err := c.ShouldBindJSON(&reqBody)
@@ -168,21 +457,21 @@ func (h *GinHandler) UpdateExpense(c *gin.Context) {
 		return
 	}
 
-	// send to service layer
-	err = h.Service.UpdateExpense(c.Request.Context(), reqBody.ID, reqBody.OccuredAt.Time, reqBody.Description, reqBody.Amount)
+	h.updateExpense(c, reqBody.ID, &reqBody.CreateExpenseRequest)
+}
+
+// updateExpense replaces the expense with id by reqBody
+func (h *GinHandler) updateExpense(c *gin.Context, id int, reqBody *CreateExpenseRequest) {
+	occuredAt, err := reqBody.occuredAt()
 	if err != nil {
-		if errors.Is(err, expenses.ErrInvalidAmount) || errors.Is(err, expenses.ErrInvalidOccuredAtTime) {
-			// service error
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
-			return
-		} else if errors.Is(err, expenses.ErrUnusedID) {
-			// repository error
-			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not Found"})
-			return
-		}
+		abortWithServiceError(c, err)
+		return
+	}
 
-		// generic error
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+	// send to service layer
+	err = h.Service.UpdateExpense(c.Request.Context(), id, occuredAt, reqBody.Description, reqBody.amount(h.Service.Currency()), reqBody.CategoryID)
+	if err != nil {
+		abortWithServiceError(c, err)
 		return
 	}
 
@@ -192,28 +481,134 @@ func (h *GinHandler) UpdateExpense(c *gin.Context) {
 
 func (h *GinHandler) DeleteExpense(c *gin.Context) {
 	// check the ID for validity
-	idInt, err := strconv.Atoi(c.Param("id"))
+	idInt, err := ParseIDParam(c, "id")
 	if err != nil {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		abortWithParamError(c, err)
 		return
 	}
 
 	// delete the record
 	err = h.Service.DeleteExpense(c.Request.Context(), idInt)
 	if err != nil {
-		// repository errors
-		if errors.Is(err, expenses.ErrInvalidID) {
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
-			return
-		} else if errors.Is(err, expenses.ErrUnusedID) {
-			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not Found"})
+		abortWithServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetChanges sends the expenses created, updated, or deleted since ?since=, the cursor of the last sync, oldest first.
+// Without ?since= every expense is sent, for a client's first sync. Up to ?limit= changes are sent at a time,
+// has_more says to sync again from the cursor straight away. Clients send their own edits with POST /expenses/mutations
+func (h *GinHandler) GetChanges(c *gin.Context) {
+	limit, err := ParseIntQuery(c, "limit", min(DefaultPageLimit, h.MaxPageLimit), 1, h.MaxPageLimit)
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+
+	var since expenses.SyncCursor
+	if raw, ok := c.GetQuery("since"); ok {
+		if since, err = expenses.DecodeSyncCursor(raw); err != nil {
+			abortWithParamError(c, &ParamError{Param: "since", Reason: "must be the cursor of a previous sync"})
 			return
 		}
+	}
 
-		// generic server error
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+	set, err := h.Service.Changes(c.Request.Context(), since, limit)
+	if err != nil {
+		abortWithServiceError(c, err)
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	changes := make([]*ChangeResponse, 0, len(set.Changes))
+	for _, change := range set.Changes {
+		response := &ChangeResponse{ID: change.ID, Deleted: change.Deleted(), ChangedAt: RFC3339Time{Time: change.ChangedAt}}
+		if !change.Deleted() {
+			response.Expense = expenseToResponse(change.Expense)
+		}
+		changes = append(changes, response)
+	}
+
+	c.JSON(http.StatusOK, &ChangesResponse{Changes: changes, Cursor: set.Next.Encode(), HasMore: set.More})
+}
+
+// mutationStatuses are the statuses of the results of a batch that didn't apply, by the kind of their error
+var mutationStatuses = map[expenses.Kind]string{
+	expenses.KindInvalid:  "invalid",
+	expenses.KindConflict: "conflict",
+}
+
+// mutationResultToResponse is how result is sent, see MutationResultResponse
+func mutationResultToResponse(result *expenses.MutationResult) *MutationResultResponse {
+	response := &MutationResultResponse{Status: "applied", ID: result.ID}
+	if result.Expense != nil {
+		response.Expense = expenseToResponse(result.Expense)
+	}
+	if result.Err == nil {
+		return response
+	}
+
+	response.Status = mutationStatuses[expenses.KindOf(result.Err)]
+	if errors.Is(result.Err, expenses.ErrNotApplied) {
+		response.Status = "not_applied"
+	}
+	response.Error = result.Err.Error()
+	response.Field = expenses.FieldOf(result.Err)
+	return response
+}
+
+// ApplyMutations applies a batch of edits an offline client made, all of them or none: POST /expenses/mutations.
+// It is 200 once applied, otherwise 409, or 400 when a mutation can never apply, with each mutation's
+// result in the order they were sent. Conflicts have the expense as it is now, to merge the edit into
+// and send again with its version as the base version
+func (h *GinHandler) ApplyMutations(c *gin.Context) {
+	var reqBody MutationsRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	mutations := make([]*expenses.Mutation, 0, len(reqBody.Mutations))
+	for _, m := range reqBody.Mutations {
+		mutation := &expenses.Mutation{Op: expenses.MutationOp(m.Op), ClientID: m.ClientID, ID: m.ID, BaseVersion: m.BaseVersion}
+		if m.Expense != nil {
+			occuredAt, err := m.Expense.occuredAt()
+			if err != nil {
+				abortWithServiceError(c, err)
+				return
+			}
+			mutation.Expense = &expenses.Expense{
+				ExpenseOccuredAt: occuredAt,
+				Description:      m.Expense.Description,
+				Amount:           m.Expense.amount(h.Service.Currency()),
+				CategoryID:       m.Expense.CategoryID,
+			}
+		}
+		mutations = append(mutations, mutation)
+	}
+
+	results, err := h.Service.ApplyMutations(c.Request.Context(), mutations)
+	if err != nil {
+		abortWithServiceError(c, err)
+		return
+	}
+
+	status := http.StatusOK
+	resp := &MutationsResponse{Applied: true, Results: make([]*MutationResultResponse, 0, len(results))}
+	for _, result := range results {
+		response := mutationResultToResponse(result)
+		switch response.Status {
+		case "invalid":
+			status = http.StatusBadRequest
+		case "conflict", "not_applied":
+			if status == http.StatusOK {
+				status = http.StatusConflict
+			}
+		}
+		resp.Results = append(resp.Results, response)
+	}
+	resp.Applied = status == http.StatusOK
+
+	c.JSON(status, resp)
 }
@@ -0,0 +1,1755 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/categories"
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/fxrates"
+	"github.com/nicholasss/expense-tracker-api/internal/handler"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+	"github.com/nicholasss/expense-tracker-api/internal/quickadd"
+)
+
+// mockService implements the expenses.Service interface to test the handler layer
+// it keeps records in a map, and only performs the minimum to act like the real service
+type mockService struct {
+	lastID int
+	db     map[int]*expenses.Expense
+
+	summaryQuery expenses.SummaryQuery // the last one summarized
+	summaryErr   error                 // returned by SummarizeExpenses when set
+}
+
+func (s *mockService) Currency() string {
+	return "EUR"
+}
+
+func (s *mockService) NewExpense(ctx context.Context, occuredAt time.Time, description string, amount money.Money, categoryID int) (*expenses.Expense, error) {
+	// every problem is reported, like the service does
+	var problems []error
+	if amount.Currency != s.Currency() {
+		problems = append(problems, expenses.ErrInvalidCurrency)
+	}
+	if !amount.IsPositive() {
+		problems = append(problems, expenses.ErrInvalidAmount)
+	}
+	if strings.TrimSpace(description) == "" {
+		problems = append(problems, expenses.ErrInvalidDescription)
+	}
+	// the book has ten categories
+	if categoryID > 10 {
+		problems = append(problems, expenses.ErrUnknownCategory)
+	}
+	if err := errors.Join(problems...); err != nil {
+		return nil, err
+	}
+
+	s.lastID += 1
+	exp := &expenses.Expense{
+		ID:               s.lastID,
+		CategoryID:       categoryID,
+		Amount:           amount,
+		ExpenseOccuredAt: occuredAt,
+		RecordCreatedAt:  time.Now(),
+		Description:      description,
+	}
+	s.db[exp.ID] = exp
+
+	return exp, nil
+}
+
+func (s *mockService) GetAllExpenses(ctx context.Context) ([]*expenses.Expense, error) {
+	records := make([]*expenses.Expense, 0)
+	for id := 1; id <= s.lastID; id++ {
+		if record, ok := s.db[id]; ok {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (s *mockService) ListExpenses(ctx context.Context, filter expenses.ListFilter) ([]*expenses.Expense, *expenses.Cursor, error) {
+	records, _ := s.GetAllExpenses(ctx)
+
+	// ids stand in for the cursor, records are listed newest id first
+	slices.Reverse(records)
+	if filter.After != nil {
+		records = slices.DeleteFunc(records, func(record *expenses.Expense) bool { return record.ID >= filter.After.ID })
+	}
+
+	var next *expenses.Cursor
+	if filter.Limit > 0 && len(records) > filter.Limit {
+		records = records[:filter.Limit]
+		next = &expenses.Cursor{OccuredAt: records[filter.Limit-1].ExpenseOccuredAt, ID: records[filter.Limit-1].ID}
+	}
+	return records, next, nil
+}
+
+func (s *mockService) CountExpenses(ctx context.Context, filter expenses.ListFilter) (int, error) {
+	records, _ := s.GetAllExpenses(ctx)
+	return len(records), nil
+}
+
+func (s *mockService) EachExpense(ctx context.Context, fn func(*expenses.Expense) error) error {
+	records, _ := s.GetAllExpenses(ctx)
+	for _, record := range records {
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *mockService) GetExpenseByID(ctx context.Context, id int) (*expenses.Expense, error) {
+	record, ok := s.db[id]
+	if !ok {
+		return nil, expenses.ErrUnusedID
+	}
+	return record, nil
+}
+
+func (s *mockService) GetExpensesByIDs(ctx context.Context, ids []int) ([]*expenses.Expense, []int, error) {
+	found := make([]*expenses.Expense, 0)
+	missing := make([]int, 0)
+	for _, id := range ids {
+		record, ok := s.db[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		found = append(found, record)
+	}
+	return found, missing, nil
+}
+
+func (s *mockService) UpdateExpense(ctx context.Context, id int, occuredAt time.Time, description string, amount money.Money, categoryID int) error {
+	if _, ok := s.db[id]; !ok {
+		return expenses.ErrUnusedID
+	}
+	s.db[id] = &expenses.Expense{ID: id, CategoryID: categoryID, Amount: amount, ExpenseOccuredAt: occuredAt, Description: description}
+	return nil
+}
+
+func (s *mockService) DeleteExpense(ctx context.Context, id int) error {
+	if _, ok := s.db[id]; !ok {
+		return expenses.ErrUnusedID
+	}
+	delete(s.db, id)
+	return nil
+}
+
+// ids stand in for the sync cursor, every id handed out is a change and the ones missing were deleted
+func (s *mockService) Changes(ctx context.Context, since expenses.SyncCursor, limit int) (*expenses.ChangeSet, error) {
+	// tombstones are kept from 2025 on, like a retention rule had pruned the ones before
+	if !since.ChangedAt.IsZero() && since.ChangedAt.Year() < 2025 {
+		return nil, expenses.ErrResyncRequired
+	}
+
+	set := &expenses.ChangeSet{Changes: make([]*expenses.Change, 0), Next: expenses.SyncCursor{ID: s.lastID}}
+	for id := since.ID + 1; id <= s.lastID; id++ {
+		if limit > 0 && len(set.Changes) == limit {
+			set.Next, set.More = expenses.SyncCursor{ID: id - 1}, true
+			break
+		}
+		set.Changes = append(set.Changes, &expenses.Change{ID: id, Expense: s.db[id]})
+	}
+	return set, nil
+}
+
+// every mutation applies, other than updates and deletes of ids without a record, which conflict
+// and keep the rest of the batch from applying like the service does
+func (s *mockService) ApplyMutations(ctx context.Context, mutations []*expenses.Mutation) ([]*expenses.MutationResult, error) {
+	results := make([]*expenses.MutationResult, 0, len(mutations))
+	applied, nextID := true, s.lastID
+	for _, m := range mutations {
+		result := &expenses.MutationResult{ID: m.ID}
+		switch _, ok := s.db[m.ID]; {
+		case m.Op == expenses.MutationCreate:
+			nextID += 1
+			result.ID = nextID
+			result.Expense = &expenses.Expense{ID: result.ID, Version: 1, ClientID: m.ClientID, Description: m.Expense.Description, Amount: m.Expense.Amount}
+		case !ok:
+			result.Err = expenses.ErrExpenseGone
+			applied = false
+		case m.Op == expenses.MutationUpdate:
+			result.Expense = &expenses.Expense{ID: m.ID, Version: m.BaseVersion + 1, Description: m.Expense.Description, Amount: m.Expense.Amount}
+		}
+		results = append(results, result)
+	}
+
+	for _, result := range results {
+		switch {
+		case !applied && result.Err == nil:
+			result.Err, result.Expense = expenses.ErrNotApplied, nil
+		case applied && result.Expense == nil:
+			delete(s.db, result.ID)
+		case applied:
+			s.db[result.ID] = result.Expense
+			s.lastID = max(s.lastID, result.ID)
+		}
+	}
+	return results, nil
+}
+
+func (s *mockService) GetAllOwnersExpenses(ctx context.Context) ([]*expenses.Expense, error) {
+	return s.GetAllExpenses(ctx)
+}
+
+func (s *mockService) SummarizeExpenses(ctx context.Context, q expenses.SummaryQuery) (*expenses.Summary, error) {
+	s.summaryQuery = q
+	if s.summaryErr != nil {
+		return nil, s.summaryErr
+	}
+	if q.Range == expenses.CustomMonth && q.Period != "2025-10" {
+		return nil, &expenses.ErrInvalidTime{ProvidedTime: q.Period}
+	}
+
+	summary := &expenses.Summary{Grouping: expenses.GroupByDay}
+	if q.GroupBy != expenses.GroupByRange {
+		summary.Grouping = q.GroupBy
+	}
+	summary.Amount = money.Zero(s.Currency())
+	for _, record := range s.db {
+		summary.Amount.Minor += record.Amount.Minor
+		summary.Count += 1
+	}
+
+	// converts to USD at 1.5, the service looks the rate up with its converter
+	if q.Currency == "USD" {
+		summary.Conversion = &fxrates.Conversion{From: "EUR", To: "USD", Rate: 1.5}
+		summary.Amount = money.New(summary.Conversion.Apply(summary.Amount.Minor), "USD")
+	}
+	return summary, nil
+}
+
+// setupTestRouter creates a gin engine backed by a mock service with two records loaded
+func setupTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+
+	serv := &mockService{db: make(map[int]*expenses.Expense)}
+	for _, description := range []string{"train ticket", "lunch with team"} {
+		_, err := serv.NewExpense(t.Context(), time.Unix(1761231600, 0), description, money.New(1250, "EUR"), 0)
+		if err != nil {
+			t.Fatalf("unable to setup mock service: %v", err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	h := handler.NewGinHandler(serv)
+	r := gin.New()
+	r.GET("/expenses", h.GetAllExpenses)
+	r.GET("/v1/expenses", h.ListExpensesPage)
+	r.GET("/expenses/summary", h.GetSummary)
+	r.GET("/expenses/changes", h.GetChanges)
+	r.GET("/expenses/:id", h.GetExpenseByID)
+	r.POST("/expenses", h.CreateExpense)
+	r.POST("/expenses/parse", h.ParseExpense)
+	r.POST("/expenses/mutations", h.ApplyMutations)
+	r.POST("/quick", h.QuickAdd)
+	r.PUT("/expenses/:id", h.UpdateExpense)
+	r.PUT("/expenses", h.UpdateExpenseByBody)
+	r.DELETE("/expenses/:id", h.DeleteExpense)
+
+	return r
+}
+
+// doRequest performs a request against the router and returns the recorded response
+func doRequest(t testing.TB, r http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCreateExpenseLocation(t *testing.T) {
+	r := setupTestRouter(t)
+
+	body := `{"occured_at": "2025-10-23T15:00:00Z", "description": "new altoids", "amount": 229}`
+	rec := doRequest(t, r, http.MethodPost, "/expenses", body)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("got status: %d, want status: %d", rec.Code, http.StatusCreated)
+	}
+	if got := rec.Header().Get("Location"); got != "/expenses/3" {
+		t.Errorf("got Location header: %q, want: %q", got, "/expenses/3")
+	}
+
+	var resp handler.ExpenseResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unable to decode response: %v", err)
+	}
+	if resp.URL != "/expenses/3" {
+		t.Errorf("got url: %q, want: %q", resp.URL, "/expenses/3")
+	}
+}
+
+func TestUpdateDeleteStatus(t *testing.T) {
+	testTable := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "valid-update",
+			method:     http.MethodPut,
+			target:     "/expenses/1",
+			body:       `{"occured_at": "2025-10-23T15:00:00Z", "description": "train ticket home", "amount": 1250}`,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "valid-update-same-id-in-body",
+			method:     http.MethodPut,
+			target:     "/expenses/1",
+			body:       `{"id": 1, "occured_at": "2025-10-23T15:00:00Z", "description": "train ticket home", "amount": 1250}`,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "valid-update-deprecated-id-in-body",
+			method:     http.MethodPut,
+			target:     "/expenses",
+			body:       `{"id": 1, "occured_at": "2025-10-23T15:00:00Z", "description": "train ticket home", "amount": 1250}`,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "invalid-update-other-id-in-body",
+			method:     http.MethodPut,
+			target:     "/expenses/1",
+			body:       `{"id": 2, "occured_at": "2025-10-23T15:00:00Z", "description": "train ticket home", "amount": 1250}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid-update-missing-id",
+			method:     http.MethodPut,
+			target:     "/expenses/99",
+			body:       `{"occured_at": "2025-10-23T15:00:00Z", "description": "train ticket home", "amount": 1250}`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "invalid-update-deprecated-missing-id",
+			method:     http.MethodPut,
+			target:     "/expenses",
+			body:       `{"id": 99, "occured_at": "2025-10-23T15:00:00Z", "description": "train ticket home", "amount": 1250}`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "valid-delete",
+			method:     http.MethodDelete,
+			target:     "/expenses/2",
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "invalid-delete-missing-id",
+			method:     http.MethodDelete,
+			target:     "/expenses/99",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	r := setupTestRouter(t)
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			rec := doRequest(t, r, testCase.method, testCase.target, testCase.body)
+			if rec.Code != testCase.wantStatus {
+				t.Errorf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateExpenseCurrency(t *testing.T) {
+	testTable := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantCurrency string
+	}{
+		{
+			name:         "valid-default-currency",
+			body:         `{"occured_at": "2025-10-23T15:00:00Z", "description": "new altoids", "amount": 229}`,
+			wantStatus:   http.StatusCreated,
+			wantCurrency: "EUR",
+		},
+		{
+			name:         "valid-recorded-currency",
+			body:         `{"occured_at": "2025-10-23T15:00:00Z", "description": "new altoids", "amount": 229, "currency": "EUR"}`,
+			wantStatus:   http.StatusCreated,
+			wantCurrency: "EUR",
+		},
+		{
+			name:       "invalid-other-currency",
+			body:       `{"occured_at": "2025-10-23T15:00:00Z", "description": "new altoids", "amount": 229, "currency": "USD"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := setupTestRouter(t)
+			rec := doRequest(t, r, http.MethodPost, "/expenses", testCase.body)
+
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+			if testCase.wantStatus != http.StatusCreated {
+				return
+			}
+
+			var resp handler.ExpenseResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unable to decode response: %v", err)
+			}
+			if resp.Currency != testCase.wantCurrency {
+				t.Errorf("got currency: %q, want: %q", resp.Currency, testCase.wantCurrency)
+			}
+		})
+	}
+}
+
+func TestCreateExpenseCategory(t *testing.T) {
+	testTable := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantCategory int
+	}{
+		{
+			name:       "valid-uncategorized",
+			body:       `{"occured_at": "2025-10-23T15:00:00Z", "description": "new altoids", "amount": 229}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:         "valid-category",
+			body:         `{"occured_at": "2025-10-23T15:00:00Z", "description": "new altoids", "amount": 229, "category_id": 3}`,
+			wantStatus:   http.StatusCreated,
+			wantCategory: 3,
+		},
+		{
+			name:       "invalid-unknown-category",
+			body:       `{"occured_at": "2025-10-23T15:00:00Z", "description": "new altoids", "amount": 229, "category_id": 42}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := setupTestRouter(t)
+			rec := doRequest(t, r, http.MethodPost, "/expenses", testCase.body)
+
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+			if testCase.wantStatus != http.StatusCreated {
+				var resp struct {
+					Field string `json:"field"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Field != "category_id" {
+					t.Errorf("got field: %q, error: '%v', want field: category_id", resp.Field, err)
+				}
+				return
+			}
+
+			var resp handler.ExpenseResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unable to decode response: %v", err)
+			}
+			if resp.CategoryID != testCase.wantCategory {
+				t.Errorf("got category: %d, want: %d", resp.CategoryID, testCase.wantCategory)
+			}
+		})
+	}
+}
+
+func TestCreateExpenseProblems(t *testing.T) {
+	r := setupTestRouter(t)
+
+	body := `{"occured_at": "2025-10-23T15:00:00Z", "description": "   ", "amount": 229, "currency": "USD"}`
+	rec := doRequest(t, r, http.MethodPost, "/expenses", body)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, http.StatusBadRequest, rec.Body.String())
+	}
+
+	var resp struct {
+		Field  string                    `json:"field"`
+		Errors []handler.ProblemResponse `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unable to decode response: %v", err)
+	}
+
+	// both problems are listed, the first is also the field at fault
+	gotFields := make([]string, 0, len(resp.Errors))
+	for _, problem := range resp.Errors {
+		gotFields = append(gotFields, problem.Field)
+	}
+	if wantFields := []string{"currency", "description"}; !slices.Equal(gotFields, wantFields) || resp.Field != "currency" {
+		t.Errorf("got field: %q and fields: %v, want field: %q and fields: %v", resp.Field, gotFields, "currency", wantFields)
+	}
+}
+
+func TestCreateExpenseTimeZone(t *testing.T) {
+	testTable := []struct {
+		name          string
+		body          string
+		wantStatus    int
+		wantOccuredAt string
+		wantTimeZone  string
+		wantField     string
+	}{
+		{
+			name:          "valid-offset",
+			body:          `{"occured_at": "2025-10-23T15:00:00+02:00", "description": "pretzel", "amount": 450}`,
+			wantStatus:    http.StatusCreated,
+			wantOccuredAt: "2025-10-23T15:00:00+02:00",
+			wantTimeZone:  "+02:00",
+		},
+		{
+			name:          "valid-iana-zone",
+			body:          `{"occured_at": "2025-10-23T13:00:00Z", "description": "pretzel", "amount": 450, "timezone": "Europe/Berlin"}`,
+			wantStatus:    http.StatusCreated,
+			wantOccuredAt: "2025-10-23T15:00:00+02:00",
+			wantTimeZone:  "Europe/Berlin",
+		},
+		{
+			name:       "invalid-zone",
+			body:       `{"occured_at": "2025-10-23T13:00:00Z", "description": "pretzel", "amount": 450, "timezone": "Berlin"}`,
+			wantStatus: http.StatusBadRequest,
+			wantField:  "timezone",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := setupTestRouter(t)
+			rec := doRequest(t, r, http.MethodPost, "/expenses", testCase.body)
+
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+			if testCase.wantStatus != http.StatusCreated {
+				// the field at fault is named for clients to point at
+				var errResp struct {
+					Field string `json:"field"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &errResp); err != nil || errResp.Field != testCase.wantField {
+					t.Errorf("got field: %q, error: '%v', want field: %q", errResp.Field, err, testCase.wantField)
+				}
+				return
+			}
+
+			var resp struct {
+				OccuredAt string `json:"occured_at"`
+				TimeZone  string `json:"timezone"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unable to decode response: %v", err)
+			}
+			if resp.OccuredAt != testCase.wantOccuredAt || resp.TimeZone != testCase.wantTimeZone {
+				t.Errorf("got occured_at: %q in %q, want: %q in %q", resp.OccuredAt, resp.TimeZone, testCase.wantOccuredAt, testCase.wantTimeZone)
+			}
+		})
+	}
+}
+
+func TestSparseFieldsets(t *testing.T) {
+	testTable := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantKeys   []string
+	}{
+		{
+			name:       "valid-get-by-id-two-fields",
+			target:     "/expenses/1?fields=id,amount",
+			wantStatus: http.StatusOK,
+			wantKeys:   []string{"amount", "id"},
+		},
+		{
+			name:       "valid-get-by-id-all-fields",
+			target:     "/expenses/1",
+			wantStatus: http.StatusOK,
+			wantKeys:   []string{"amount", "created_at", "currency", "description", "id", "occured_at", "timezone", "updated_at", "url", "version"},
+		},
+		{
+			name:       "invalid-unknown-field",
+			target:     "/expenses/1?fields=id,colour",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid-empty-fields",
+			target:     "/expenses/1?fields=",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := setupTestRouter(t)
+			rec := doRequest(t, r, http.MethodGet, testCase.target, "")
+
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+			if testCase.wantKeys == nil {
+				return
+			}
+
+			var resp map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unable to decode response: %v", err)
+			}
+			if len(resp) != len(testCase.wantKeys) {
+				t.Errorf("got %d keys: %v, want keys: %v", len(resp), resp, testCase.wantKeys)
+			}
+			for _, key := range testCase.wantKeys {
+				if _, ok := resp[key]; !ok {
+					t.Errorf("response missing key %q", key)
+				}
+			}
+		})
+	}
+}
+
+// stubCategories is a book with a single category, only List is used by ?include=
+type stubCategories struct {
+	categories.Service
+}
+
+func (stubCategories) List(ctx context.Context) ([]*categories.Category, error) {
+	return []*categories.Category{{ID: 3, Name: "Transport", CreatedAt: time.Unix(1761000000, 0)}}, nil
+}
+
+func TestIncludeCategory(t *testing.T) {
+	testTable := []struct {
+		name         string
+		target       string
+		inputNoBook  bool // categories are not enabled
+		wantStatus   int
+		wantCategory []string // the name embedded in each expense, empty for none
+	}{
+		{
+			name:         "valid-get-by-id",
+			target:       "/expenses/1?include=category",
+			wantStatus:   http.StatusOK,
+			wantCategory: []string{"Transport"},
+		},
+		{
+			name:         "valid-uncategorized",
+			target:       "/expenses/2?include=category",
+			wantStatus:   http.StatusOK,
+			wantCategory: []string{""},
+		},
+		{
+			name:         "valid-not-included",
+			target:       "/expenses/1",
+			wantStatus:   http.StatusOK,
+			wantCategory: []string{""},
+		},
+		{
+			name:         "valid-list",
+			target:       "/expenses?include=category",
+			wantStatus:   http.StatusOK,
+			wantCategory: []string{"", "Transport"},
+		},
+		{
+			name:       "invalid-unknown-include",
+			target:     "/expenses/1?include=receipts",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "invalid-without-categories",
+			target:      "/expenses/1?include=category",
+			inputNoBook: true,
+			wantStatus:  http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			serv := &mockService{db: make(map[int]*expenses.Expense)}
+			for _, categoryID := range []int{3, 0} {
+				if _, err := serv.NewExpense(t.Context(), time.Unix(1761231600, 0), "train ticket", money.New(1250, "EUR"), categoryID); err != nil {
+					t.Fatalf("unable to setup mock service: %v", err)
+				}
+			}
+
+			gin.SetMode(gin.TestMode)
+			h := handler.NewGinHandler(serv)
+			if !testCase.inputNoBook {
+				h.Categories = stubCategories{}
+			}
+			r := gin.New()
+			r.GET("/expenses", h.GetAllExpenses)
+			r.GET("/expenses/:id", h.GetExpenseByID)
+
+			rec := doRequest(t, r, http.MethodGet, testCase.target, "")
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+			if testCase.wantStatus != http.StatusOK {
+				return
+			}
+
+			var got []handler.ExpenseResponse
+			if strings.HasPrefix(rec.Body.String(), "[") {
+				if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+					t.Fatalf("unable to decode response: %v", err)
+				}
+			} else {
+				var one handler.ExpenseResponse
+				if err := json.Unmarshal(rec.Body.Bytes(), &one); err != nil {
+					t.Fatalf("unable to decode response: %v", err)
+				}
+				got = append(got, one)
+			}
+
+			gotCategory := make([]string, 0, len(got))
+			for _, exp := range got {
+				name := ""
+				if exp.Category != nil {
+					name = exp.Category.Name
+				}
+				gotCategory = append(gotCategory, name)
+			}
+			if !slices.Equal(gotCategory, testCase.wantCategory) {
+				t.Errorf("got categories: %q, want categories: %q", gotCategory, testCase.wantCategory)
+			}
+		})
+	}
+}
+
+func TestBatchGetByIDs(t *testing.T) {
+	testTable := []struct {
+		name        string
+		target      string
+		wantStatus  int
+		wantIDs     []int
+		wantMissing []int
+	}{
+		{
+			name:        "valid-ids-in-order",
+			target:      "/expenses?ids=2,1",
+			wantStatus:  http.StatusOK,
+			wantIDs:     []int{2, 1},
+			wantMissing: []int{},
+		},
+		{
+			name:        "valid-with-missing",
+			target:      "/expenses?ids=1,19",
+			wantStatus:  http.StatusOK,
+			wantIDs:     []int{1},
+			wantMissing: []int{19},
+		},
+		{
+			name:       "invalid-non-numeric-id",
+			target:     "/expenses?ids=1,two",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := setupTestRouter(t)
+			rec := doRequest(t, r, http.MethodGet, testCase.target, "")
+
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+			if testCase.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Expenses []handler.ExpenseResponse `json:"expenses"`
+				Missing  []int                     `json:"missing"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unable to decode response: %v", err)
+			}
+
+			gotIDs := make([]int, 0, len(resp.Expenses))
+			for _, exp := range resp.Expenses {
+				gotIDs = append(gotIDs, exp.ID)
+			}
+			if !slices.Equal(gotIDs, testCase.wantIDs) {
+				t.Errorf("got ids: %v, want ids: %v", gotIDs, testCase.wantIDs)
+			}
+			if !slices.Equal(resp.Missing, testCase.wantMissing) {
+				t.Errorf("got missing: %v, want missing: %v", resp.Missing, testCase.wantMissing)
+			}
+		})
+	}
+}
+
+func TestGetSummary(t *testing.T) {
+	testTable := []struct {
+		name        string
+		target      string
+		wantStatus  int
+		wantAmount  int64
+		wantGroupBy string
+	}{
+		{
+			name:        "valid-default-all",
+			target:      "/expenses/summary",
+			wantStatus:  http.StatusOK,
+			wantAmount:  2500,
+			wantGroupBy: "day",
+		},
+		{
+			name:        "valid-month",
+			target:      "/expenses/summary?range=month&period=2025-10",
+			wantStatus:  http.StatusOK,
+			wantAmount:  2500,
+			wantGroupBy: "day",
+		},
+		{
+			name:        "valid-quarter-period",
+			target:      "/expenses/summary?range=period&period=2025-Q4",
+			wantStatus:  http.StatusOK,
+			wantAmount:  2500,
+			wantGroupBy: "day",
+		},
+		{
+			name:        "valid-group-by-month",
+			target:      "/expenses/summary?range=this-year&group_by=month",
+			wantStatus:  http.StatusOK,
+			wantAmount:  2500,
+			wantGroupBy: "month",
+		},
+		{
+			name:       "invalid-group-by",
+			target:     "/expenses/summary?group_by=week",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid-period",
+			target:     "/expenses/summary?range=month&period=october",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid-range",
+			target:     "/expenses/summary?range=fortnight",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := setupTestRouter(t)
+			rec := doRequest(t, r, http.MethodGet, testCase.target, "")
+
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+			if testCase.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp handler.SummaryResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unable to decode response: %v", err)
+			}
+			if resp.Amount != testCase.wantAmount || resp.GroupBy != testCase.wantGroupBy || resp.Periods == nil {
+				t.Errorf("got: %+v, want amount: %d grouped by %s", resp, testCase.wantAmount, testCase.wantGroupBy)
+			}
+		})
+	}
+}
+
+func TestSummaryQuery(t *testing.T) {
+	berlin, err := expenses.LoadZone("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadZone() got error: '%v'", err)
+	}
+
+	testTable := []struct {
+		name           string
+		target         string
+		inputErr       error // returned by the service
+		wantStatus     int
+		wantQuery      expenses.SummaryQuery
+		wantAmount     int64
+		wantConversion bool
+	}{
+		{
+			name:       "valid-unconverted",
+			target:     "/expenses/summary",
+			wantStatus: http.StatusOK,
+			wantQuery:  expenses.SummaryQuery{Range: expenses.AllExpenses},
+			wantAmount: 2500,
+		},
+		{
+			name:           "valid-converted",
+			target:         "/expenses/summary?currency=USD",
+			wantStatus:     http.StatusOK,
+			wantQuery:      expenses.SummaryQuery{Range: expenses.AllExpenses, Currency: "USD"},
+			wantAmount:     3750,
+			wantConversion: true,
+		},
+		{
+			name:       "valid-zone-and-filter",
+			target:     "/expenses/summary?range=this-month&timezone=Europe/Berlin&category_id=2&min_amount=100&max_amount=5000",
+			wantStatus: http.StatusOK,
+			wantQuery: expenses.SummaryQuery{
+				Range:    expenses.ThisMonth,
+				Location: berlin,
+				Filter:   expenses.SummaryFilter{CategoryID: 2, MinAmount: 100, MaxAmount: 5000},
+			},
+			wantAmount: 2500,
+		},
+		{
+			name:       "invalid-timezone",
+			target:     "/expenses/summary?timezone=Mars/Olympus",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid-category-id",
+			target:     "/expenses/summary?category_id=abc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid-unknown-currency",
+			target:     "/expenses/summary?currency=XYZ",
+			inputErr:   expenses.ErrUnknownCurrency,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid-not-fetched-yet",
+			target:     "/expenses/summary?currency=USD",
+			inputErr:   fxrates.ErrNoRates,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "invalid-not-enabled",
+			target:     "/expenses/summary?currency=USD",
+			inputErr:   expenses.ErrConversionDisabled,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			serv := &mockService{db: make(map[int]*expenses.Expense), summaryErr: testCase.inputErr}
+			for range 2 {
+				if _, err := serv.NewExpense(t.Context(), time.Unix(1761231600, 0), "train ticket", money.New(1250, "EUR"), 0); err != nil {
+					t.Fatalf("unable to setup mock service: %v", err)
+				}
+			}
+
+			gin.SetMode(gin.TestMode)
+			h := handler.NewGinHandler(serv)
+			r := gin.New()
+			r.GET("/expenses/summary", h.GetSummary)
+
+			rec := doRequest(t, r, http.MethodGet, testCase.target, "")
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+			if testCase.wantStatus != http.StatusOK {
+				return
+			}
+
+			if !reflect.DeepEqual(serv.summaryQuery, testCase.wantQuery) {
+				t.Errorf("got query: %+v, want query: %+v", serv.summaryQuery, testCase.wantQuery)
+			}
+
+			var resp handler.SummaryResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unable to decode response: %v", err)
+			}
+			if resp.Amount != testCase.wantAmount {
+				t.Errorf("got amount: %d, want amount: %d", resp.Amount, testCase.wantAmount)
+			}
+			if (resp.Conversion != nil) != testCase.wantConversion {
+				t.Fatalf("got conversion: %+v, want one: %v", resp.Conversion, testCase.wantConversion)
+			}
+			if testCase.wantConversion && (resp.Currency != "USD" || resp.Conversion.To != "USD" || resp.Conversion.Rate != 1.5) {
+				t.Errorf("got currency: %s, conversion: %+v", resp.Currency, resp.Conversion)
+			}
+		})
+	}
+}
+
+func TestListPagination(t *testing.T) {
+	nextCursor := expenses.Cursor{OccuredAt: time.Unix(1761231600, 0), ID: 2}.Encode()
+
+	testTable := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantIDs    []int
+		wantLink   string
+	}{
+		{
+			name:       "valid-first-page",
+			target:     "/expenses?limit=1",
+			wantStatus: http.StatusOK,
+			wantIDs:    []int{2},
+			wantLink:   "</expenses?cursor=" + nextCursor + "&limit=1>; rel=\"next\"",
+		},
+		{
+			name:       "valid-last-page",
+			target:     "/expenses?limit=1&cursor=" + nextCursor,
+			wantStatus: http.StatusOK,
+			wantIDs:    []int{1},
+		},
+		{
+			name:       "invalid-cursor",
+			target:     "/expenses?cursor=abc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid-min-amount",
+			target:     "/expenses?min_amount=-1",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := setupTestRouter(t)
+			rec := doRequest(t, r, http.MethodGet, testCase.target, "")
+
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+			if testCase.wantIDs == nil {
+				return
+			}
+
+			if got := rec.Header().Get("Link"); got != testCase.wantLink {
+				t.Errorf("got link: %q, want link: %q", got, testCase.wantLink)
+			}
+
+			var resp []struct {
+				ID int `json:"id"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unable to decode response: %v", err)
+			}
+			gotIDs := make([]int, 0, len(resp))
+			for _, record := range resp {
+				gotIDs = append(gotIDs, record.ID)
+			}
+			if !slices.Equal(gotIDs, testCase.wantIDs) {
+				t.Errorf("got ids: %v, want ids: %v", gotIDs, testCase.wantIDs)
+			}
+		})
+	}
+}
+
+func TestListEnvelope(t *testing.T) {
+	nextCursor := expenses.Cursor{OccuredAt: time.Unix(1761231600, 0), ID: 2}.Encode()
+
+	testTable := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantIDs    []int
+		wantMeta   handler.ListMeta
+	}{
+		{
+			name:       "valid-first-page",
+			target:     "/v1/expenses?limit=1",
+			wantStatus: http.StatusOK,
+			wantIDs:    []int{2},
+			wantMeta:   handler.ListMeta{Total: 2, Limit: 1, NextCursor: &nextCursor},
+		},
+		{
+			name:       "valid-last-page",
+			target:     "/v1/expenses?limit=1&cursor=" + nextCursor,
+			wantStatus: http.StatusOK,
+			wantIDs:    []int{1},
+			wantMeta:   handler.ListMeta{Total: 2, Limit: 1},
+		},
+		{
+			name:       "valid-default-limit",
+			target:     "/v1/expenses",
+			wantStatus: http.StatusOK,
+			wantIDs:    []int{2, 1},
+			wantMeta:   handler.ListMeta{Total: 2, Limit: handler.DefaultPageLimit},
+		},
+		{
+			name:       "invalid-cursor",
+			target:     "/v1/expenses?cursor=abc",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := setupTestRouter(t)
+			rec := doRequest(t, r, http.MethodGet, testCase.target, "")
+
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+			if testCase.wantIDs == nil {
+				return
+			}
+
+			var resp struct {
+				Data []struct {
+					ID int `json:"id"`
+				} `json:"data"`
+				Meta handler.ListMeta `json:"meta"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unable to decode response: %v", err)
+			}
+			gotIDs := make([]int, 0, len(resp.Data))
+			for _, record := range resp.Data {
+				gotIDs = append(gotIDs, record.ID)
+			}
+			if !slices.Equal(gotIDs, testCase.wantIDs) {
+				t.Errorf("got ids: %v, want ids: %v", gotIDs, testCase.wantIDs)
+			}
+
+			got, want := resp.Meta, testCase.wantMeta
+			if got.Total != want.Total || got.Limit != want.Limit || got.Offset != want.Offset {
+				t.Errorf("got meta: %+v, want meta: %+v", got, want)
+			}
+			if (got.NextCursor == nil) != (want.NextCursor == nil) || (got.NextCursor != nil && *got.NextCursor != *want.NextCursor) {
+				t.Errorf("got next cursor: %v, want next cursor: %v", got.NextCursor, want.NextCursor)
+			}
+		})
+	}
+}
+
+func TestGetChanges(t *testing.T) {
+	afterFirst := expenses.SyncCursor{ID: 1}.Encode()
+
+	testTable := []struct {
+		name        string
+		target      string
+		wantStatus  int
+		wantIDs     []int
+		wantDeleted []int
+		wantCursor  string
+		wantMore    bool
+	}{
+		{
+			name:        "valid-first-sync",
+			target:      "/expenses/changes",
+			wantStatus:  http.StatusOK,
+			wantIDs:     []int{1, 2},
+			wantDeleted: []int{1},
+			wantCursor:  expenses.SyncCursor{ID: 2}.Encode(),
+		},
+		{
+			name:        "valid-limited",
+			target:      "/expenses/changes?limit=1",
+			wantStatus:  http.StatusOK,
+			wantIDs:     []int{1},
+			wantDeleted: []int{1},
+			wantCursor:  afterFirst,
+			wantMore:    true,
+		},
+		{
+			name:        "valid-since",
+			target:      "/expenses/changes?since=" + afterFirst,
+			wantStatus:  http.StatusOK,
+			wantIDs:     []int{2},
+			wantDeleted: []int{},
+			wantCursor:  expenses.SyncCursor{ID: 2}.Encode(),
+		},
+		{
+			name:       "invalid-since",
+			target:     "/expenses/changes?since=abc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid-since-pruned",
+			target:     "/expenses/changes?since=" + expenses.SyncCursor{ChangedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), ID: 1}.Encode(),
+			wantStatus: http.StatusGone,
+		},
+		{
+			name:       "invalid-limit",
+			target:     "/expenses/changes?limit=0",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := setupTestRouter(t)
+			if rec := doRequest(t, r, http.MethodDelete, "/expenses/1", ""); rec.Code != http.StatusNoContent {
+				t.Fatalf("unable to delete expense 1, got status: %d", rec.Code)
+			}
+
+			rec := doRequest(t, r, http.MethodGet, testCase.target, "")
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+			if testCase.wantIDs == nil {
+				return
+			}
+
+			var resp handler.ChangesResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unable to decode response: %v", err)
+			}
+
+			gotIDs, gotDeleted := make([]int, 0, len(resp.Changes)), make([]int, 0)
+			for _, change := range resp.Changes {
+				gotIDs = append(gotIDs, change.ID)
+				if change.Deleted {
+					gotDeleted = append(gotDeleted, change.ID)
+				}
+				if change.Deleted != (change.Expense == nil) {
+					t.Errorf("got change %d deleted: %v with expense: %v", change.ID, change.Deleted, change.Expense)
+				}
+			}
+			if !slices.Equal(gotIDs, testCase.wantIDs) || !slices.Equal(gotDeleted, testCase.wantDeleted) {
+				t.Errorf("got ids: %v, deleted: %v, want ids: %v, deleted: %v", gotIDs, gotDeleted, testCase.wantIDs, testCase.wantDeleted)
+			}
+			if resp.Cursor != testCase.wantCursor || resp.HasMore != testCase.wantMore {
+				t.Errorf("got cursor: %q, more: %v, want cursor: %q, more: %v", resp.Cursor, resp.HasMore, testCase.wantCursor, testCase.wantMore)
+			}
+		})
+	}
+}
+
+func TestApplyMutations(t *testing.T) {
+	const created = `{"op": "create", "client_id": "0b6c1c6e-4a8e-4c3e-9d1a-2f4b5c6d7e8f", "expense": {"occured_at": "2025-10-28T17:00:00Z", "description": "bagel", "amount": 450}}`
+
+	testTable := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantApplied  bool
+		wantStatuses []string
+		wantVersions []int // of each result's expense, 0 when it has none
+	}{
+		{
+			name:         "valid-batch",
+			body:         `{"mutations": [` + created + `, {"op": "update", "id": 1, "base_version": 1, "expense": {"occured_at": "2025-10-28T17:00:00Z", "description": "soda", "amount": 289}}, {"op": "delete", "id": 2, "base_version": 1}]}`,
+			wantStatus:   http.StatusOK,
+			wantApplied:  true,
+			wantStatuses: []string{"applied", "applied", "applied"},
+			wantVersions: []int{1, 2, 0},
+		},
+		{
+			name:         "conflict-deleted",
+			body:         `{"mutations": [` + created + `, {"op": "delete", "id": 9, "base_version": 1}]}`,
+			wantStatus:   http.StatusConflict,
+			wantStatuses: []string{"not_applied", "conflict"},
+			wantVersions: []int{0, 0},
+		},
+		{
+			name:       "invalid-missing-op",
+			body:       `{"mutations": [{"id": 1, "base_version": 1}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid-missing-mutations",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := setupTestRouter(t)
+
+			rec := doRequest(t, r, http.MethodPost, "/expenses/mutations", testCase.body)
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+			if testCase.wantStatuses == nil {
+				return
+			}
+
+			var resp handler.MutationsResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unable to decode response: %v", err)
+			}
+			if resp.Applied != testCase.wantApplied {
+				t.Errorf("got applied: %v, want applied: %v", resp.Applied, testCase.wantApplied)
+			}
+
+			gotStatuses, gotVersions := make([]string, 0, len(resp.Results)), make([]int, 0, len(resp.Results))
+			for _, result := range resp.Results {
+				gotStatuses = append(gotStatuses, result.Status)
+				version := 0
+				if result.Expense != nil {
+					version = result.Expense.Version
+				}
+				gotVersions = append(gotVersions, version)
+			}
+			if !slices.Equal(gotStatuses, testCase.wantStatuses) || !slices.Equal(gotVersions, testCase.wantVersions) {
+				t.Errorf("got statuses: %v, versions: %v, want statuses: %v, versions: %v", gotStatuses, gotVersions, testCase.wantStatuses, testCase.wantVersions)
+			}
+		})
+	}
+}
+
+// cappedService fails the unpaged reads, as the service does past its result cap
+type cappedService struct {
+	*mockService
+}
+
+func (s *cappedService) GetAllOwnersExpenses(ctx context.Context) ([]*expenses.Expense, error) {
+	return nil, expenses.ErrTooManyResults
+}
+
+func TestResultGuardrails(t *testing.T) {
+	testTable := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantError  string
+	}{
+		{
+			name:       "valid-limit-at-max",
+			target:     "/expenses?limit=1",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid-limit-over-max",
+			target:     "/expenses?limit=2",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "must be at most 1, follow the next link for the rest",
+		},
+		{
+			name:       "invalid-unpaged-read-over-cap",
+			target:     "/admin/expenses",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "MAX_RESULT_ROWS",
+		},
+	}
+
+	serv := &mockService{db: make(map[int]*expenses.Expense)}
+	for range 2 {
+		if _, err := serv.NewExpense(t.Context(), time.Unix(1761231600, 0), "train ticket", money.New(1250, "EUR"), 0); err != nil {
+			t.Fatalf("unable to setup mock service: %v", err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	h := handler.NewGinHandler(&cappedService{mockService: serv})
+	h.MaxPageLimit = 1
+	r := gin.New()
+	r.GET("/expenses", h.GetAllExpenses)
+	r.GET("/admin/expenses", h.GetAllOwnersExpenses)
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			rec := doRequest(t, r, http.MethodGet, testCase.target, "")
+
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), testCase.wantError) {
+				t.Errorf("got body: %s, want error containing: %q", rec.Body.String(), testCase.wantError)
+			}
+		})
+	}
+}
+
+func TestRFC3339TimeMarshalJSON(t *testing.T) {
+	testTable := []struct {
+		name  string
+		input time.Time
+	}{
+		{
+			name:  "valid-utc",
+			input: time.Date(2025, 10, 23, 15, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "valid-offset",
+			input: time.Date(2025, 10, 23, 15, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+		},
+		{
+			name:  "valid-sub-second-dropped",
+			input: time.Date(2025, 10, 23, 15, 0, 0, 123456789, time.UTC),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			rt := handler.RFC3339Time{Time: testCase.input}
+			got, err := rt.MarshalJSON()
+			if err != nil {
+				t.Fatalf("MarshalJSON() got error: '%v'", err)
+			}
+
+			want, _ := json.Marshal(testCase.input.Format(time.RFC3339))
+			if string(got) != string(want) {
+				t.Errorf("got: %s, want: %s", got, want)
+			}
+		})
+	}
+}
+
+func TestResponseEncoding(t *testing.T) {
+	r := setupTestRouter(t)
+
+	// the second round reuses the buffers of the first
+	for range 2 {
+		for _, target := range []string{"/expenses", "/expenses/1", "/expenses?ids=1,9"} {
+			rec := doRequest(t, r, http.MethodGet, target, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("got status: %d for %s, body: %s", rec.Code, target, rec.Body.String())
+			}
+
+			if got := rec.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+				t.Errorf("got content type: %q for %s", got, target)
+			}
+			if body := rec.Body.Bytes(); !json.Valid(body) || strings.HasSuffix(string(body), "\n") {
+				t.Errorf("got body: %q for %s, want json without a trailing newline", body, target)
+			}
+		}
+	}
+}
+
+// setupLoadedRouter serves the read routes from a mock service holding n records
+func setupLoadedRouter(t testing.TB, n int) *gin.Engine {
+	t.Helper()
+
+	serv := &mockService{db: make(map[int]*expenses.Expense, n)}
+	for range n {
+		_, err := serv.NewExpense(t.Context(), time.Unix(1761231600, 0), "train ticket", money.New(1250, "EUR"), 0)
+		if err != nil {
+			t.Fatalf("unable to setup mock service: %v", err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	h := handler.NewGinHandler(serv)
+	r := gin.New()
+	r.GET("/expenses", h.GetAllExpenses)
+	r.GET("/expenses/:id", h.GetExpenseByID)
+
+	return r
+}
+
+func BenchmarkGetAllExpenses(b *testing.B) {
+	benchTable := []struct {
+		name   string
+		target string
+	}{
+		{name: "page-of-50", target: "/expenses"},
+		{name: "page-of-50-sparse", target: "/expenses?fields=id,amount"},
+		{name: "one-by-id", target: "/expenses/1"},
+	}
+
+	r := setupLoadedRouter(b, handler.DefaultPageLimit)
+
+	for _, benchCase := range benchTable {
+		b.Run(benchCase.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				rec := doRequest(b, r, http.MethodGet, benchCase.target, "")
+				if rec.Code != http.StatusOK {
+					b.Fatalf("got status: %d", rec.Code)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRFC3339TimeMarshalJSON(b *testing.B) {
+	rt := handler.RFC3339Time{Time: time.Unix(1761231600, 0)}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := rt.MarshalJSON(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// allocation budgets of the hot read paths, with headroom over what they take today.
+// A change that needs more should raise the budget on purpose, alongside a benchmark comparison
+func TestAllocationBudgets(t *testing.T) {
+	testTable := []struct {
+		name      string
+		target    string
+		maxAllocs float64
+	}{
+		{name: "valid-page-of-50", target: "/expenses", maxAllocs: 360},
+		{name: "valid-one-by-id", target: "/expenses/1", maxAllocs: 40},
+	}
+
+	r := setupLoadedRouter(t, handler.DefaultPageLimit)
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got := testing.AllocsPerRun(50, func() {
+				doRequest(t, r, http.MethodGet, testCase.target, "")
+			})
+			if got > testCase.maxAllocs {
+				t.Errorf("got %.0f allocations per request, budget is %.0f", got, testCase.maxAllocs)
+			}
+			t.Logf("%.0f allocations per request, budget is %.0f", got, testCase.maxAllocs)
+		})
+	}
+}
+
+func TestStreamedList(t *testing.T) {
+	testTable := []struct {
+		name    string
+		records int
+		target  string
+		wantLen int
+	}{
+		{
+			name:    "valid-empty",
+			records: 0,
+			target:  "/expenses",
+			wantLen: 0,
+		},
+		{
+			name:    "valid-one",
+			records: 1,
+			target:  "/expenses",
+			wantLen: 1,
+		},
+		{
+			name:    "valid-written-in-several-parts",
+			records: handler.MaxPageLimit,
+			target:  "/expenses?limit=" + strconv.Itoa(handler.MaxPageLimit),
+			wantLen: handler.MaxPageLimit,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := setupLoadedRouter(t, testCase.records)
+			rec := doRequest(t, r, http.MethodGet, testCase.target, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("got status: %d, body: %s", rec.Code, rec.Body.String())
+			}
+
+			var got []handler.ExpenseResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("unable to decode response: %v", err)
+			}
+			if len(got) != testCase.wantLen {
+				t.Errorf("got %d records, want %d", len(got), testCase.wantLen)
+			}
+
+			// the same bytes as encoding the whole slice at once
+			want, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("unable to encode records: %v", err)
+			}
+			if rec.Body.String() != string(want) {
+				t.Errorf("got body: %.200s, want: %.200s", rec.Body.String(), want)
+			}
+		})
+	}
+}
+
+func TestQuickAdd(t *testing.T) {
+	testTable := []struct {
+		name            string
+		inputType       string
+		inputBody       string
+		wantStatus      int
+		wantAmount      int64
+		wantDescription string
+		wantOccuredAt   string // when not now
+	}{
+		{
+			name:            "valid-json-text",
+			inputType:       "application/json",
+			inputBody:       `{"text": "12.50 lunch with team"}`,
+			wantStatus:      http.StatusCreated,
+			wantAmount:      1250,
+			wantDescription: "lunch with team",
+		},
+		{
+			name:            "valid-json-number-amount-with-date",
+			inputType:       "application/json",
+			inputBody:       `{"amount": 3.5, "description": "coffee", "occured_at": "2025-10-23"}`,
+			wantStatus:      http.StatusCreated,
+			wantAmount:      350,
+			wantDescription: "coffee",
+			wantOccuredAt:   "2025-10-23T00:00:00Z",
+		},
+		{
+			name:            "valid-json-string-amount",
+			inputType:       "application/json",
+			inputBody:       `{"amount": "8", "description": "parking"}`,
+			wantStatus:      http.StatusCreated,
+			wantAmount:      800,
+			wantDescription: "parking",
+		},
+		{
+			name:            "valid-form",
+			inputType:       "application/x-www-form-urlencoded",
+			inputBody:       "amount=20&description=books&occured_at=2025-10-23T15:00:00Z",
+			wantStatus:      http.StatusCreated,
+			wantAmount:      2000,
+			wantDescription: "books",
+			wantOccuredAt:   "2025-10-23T15:00:00Z",
+		},
+		{
+			name:            "valid-plain-text",
+			inputType:       "text/plain",
+			inputBody:       "4.20 bus ticket\n",
+			wantStatus:      http.StatusCreated,
+			wantAmount:      420,
+			wantDescription: "bus ticket",
+		},
+		{
+			name:       "invalid-text-and-amount",
+			inputType:  "application/json",
+			inputBody:  `{"text": "12.50 lunch", "amount": 12.5}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid-missing-description",
+			inputType:  "application/x-www-form-urlencoded",
+			inputBody:  "amount=20",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid-zero-amount",
+			inputType:  "text/plain",
+			inputBody:  "0 nothing",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid-empty",
+			inputType:  "application/json",
+			inputBody:  `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid-date",
+			inputType:  "application/json",
+			inputBody:  `{"text": "12.50 lunch", "occured_at": "yesterday"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := setupTestRouter(t)
+
+			req := httptest.NewRequest(http.MethodPost, "/quick", strings.NewReader(testCase.inputBody))
+			req.Header.Set("Content-Type", testCase.inputType)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+			if rec.Code != http.StatusCreated {
+				return
+			}
+
+			var resp handler.ExpenseResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unable to decode response: %v", err)
+			}
+			if resp.Amount != testCase.wantAmount || resp.Description != testCase.wantDescription {
+				t.Errorf("got amount: %d, description: %q, want amount: %d, description: %q", resp.Amount, resp.Description, testCase.wantAmount, testCase.wantDescription)
+			}
+			if testCase.wantOccuredAt != "" {
+				if got := resp.OccuredAt.Format(time.RFC3339); got != testCase.wantOccuredAt {
+					t.Errorf("got occured_at: %q, want: %q", got, testCase.wantOccuredAt)
+				}
+			} else if time.Since(resp.OccuredAt.Time) > time.Minute {
+				t.Errorf("got occured_at: %v, want now", resp.OccuredAt.Time)
+			}
+		})
+	}
+}
+
+// failingParser stands in for a plugged in parser that can't be reached
+type failingParser struct{}
+
+func (failingParser) ParseDraft(ctx context.Context, text string, now time.Time) (*quickadd.Draft, error) {
+	return nil, errors.New("parser unavailable")
+}
+
+func TestParseExpense(t *testing.T) {
+	testTable := []struct {
+		name            string
+		inputBody       string
+		inputParser     quickadd.Parser // the rule parser when nil
+		wantStatus      int
+		wantAmount      int64
+		wantDescription string
+	}{
+		{
+			name:            "valid-text",
+			inputBody:       `{"text": "14.99 pizza last friday"}`,
+			wantStatus:      http.StatusOK,
+			wantAmount:      1499,
+			wantDescription: "pizza",
+		},
+		{
+			name:       "invalid-missing-text",
+			inputBody:  `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid-unreadable-text",
+			inputBody:  `{"text": "pizza last friday"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "invalid-parser-failure",
+			inputBody:   `{"text": "14.99 pizza"}`,
+			inputParser: failingParser{},
+			wantStatus:  http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			h := handler.NewGinHandler(&mockService{db: make(map[int]*expenses.Expense)})
+			if testCase.inputParser != nil {
+				h.Parser = testCase.inputParser
+			}
+			r := gin.New()
+			r.POST("/expenses/parse", h.ParseExpense)
+
+			rec := doRequest(t, r, http.MethodPost, "/expenses/parse", testCase.inputBody)
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var resp handler.DraftExpenseResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unable to decode response: %v", err)
+			}
+			if resp.Amount != testCase.wantAmount || resp.Description != testCase.wantDescription {
+				t.Errorf("got draft: %+v, want amount: %d, description: %q", resp, testCase.wantAmount, testCase.wantDescription)
+			}
+			if resp.OccuredAt.Weekday() != time.Friday {
+				t.Errorf("got occured_at: %v, want a friday", resp.OccuredAt.Time)
+			}
+		})
+	}
+}
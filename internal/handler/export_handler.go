@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/i18n"
+	"github.com/nicholasss/expense-tracker-api/internal/jobs"
+	"github.com/nicholasss/expense-tracker-api/internal/users"
+)
+
+// === Handler Type
+
+type ExportHandler struct {
+	Service expenses.Service
+	Jobs    *jobs.Manager
+
+	// Profiles has the locale users saved for their exports, only ?locale= is used when nil
+	Profiles ProfileLookup
+}
+
+// ProfileLookup finds a user's saved preferences
+type ProfileLookup interface {
+	GetProfile(ctx context.Context, id int) (*users.User, error)
+}
+
+func NewExportHandler(service expenses.Service, manager *jobs.Manager) *ExportHandler {
+	return &ExportHandler{Service: service, Jobs: manager}
+}
+
+// == Export Encoders ==
+
+// exportCSVHeader is the first row of every csv export
+var exportCSVHeader = []string{"id", "created_at", "occured_at", "description", "amount", "currency"}
+
+// expenseToCSVRecord formats one expense as a csv row matching exportCSVHeader. Without a locale format
+// times are RFC3339 and amounts in minor units, otherwise both are written the locale's way
+func expenseToCSVRecord(exp *expenses.Expense, format *i18n.Format) []string {
+	if format != nil {
+		return []string{
+			strconv.Itoa(exp.ID),
+			format.Time(exp.RecordCreatedAt),
+			format.Time(exp.ExpenseOccuredAt),
+			exp.Description,
+			format.Amount(exp.Amount),
+			exp.Amount.Currency,
+		}
+	}
+
+	return []string{
+		strconv.Itoa(exp.ID),
+		exp.RecordCreatedAt.Format(time.RFC3339),
+		exp.ExpenseOccuredAt.Format(time.RFC3339),
+		exp.Description,
+		strconv.FormatInt(exp.Amount.Minor, 10),
+		exp.Amount.Currency,
+	}
+}
+
+// newCSVWriter writes csv separated the way format's locale expects, or with commas without one
+func newCSVWriter(w io.Writer, format *i18n.Format) *csv.Writer {
+	csvWriter := csv.NewWriter(w)
+	if format != nil {
+		csvWriter.Comma = format.CSVComma
+	}
+	return csvWriter
+}
+
+func encodeExpensesCSV(records []*expenses.Expense, format *i18n.Format) ([]byte, error) {
+	var buf bytes.Buffer
+	w := newCSVWriter(&buf, format)
+
+	if err := w.Write(exportCSVHeader); err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if err := w.Write(expenseToCSVRecord(record, format)); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// exportFormat is the locale format csv exports are written in, from ?locale= or else the user's saved locale.
+// It is nil for the plain formats, false once the error has been sent
+func (h *ExportHandler) exportFormat(c *gin.Context) (*i18n.Format, bool) {
+	locale, ok := c.GetQuery("locale")
+	if !ok && h.Profiles != nil {
+		if userID, hasUser := auth.UserIDFromContext(c.Request.Context()); hasUser {
+			user, err := h.Profiles.GetProfile(c.Request.Context(), userID)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+				return nil, false
+			}
+			locale = user.Locale
+		}
+	}
+	if locale == "" {
+		return nil, true
+	}
+
+	format, ok := i18n.FormatFor(strings.ToLower(locale))
+	if !ok {
+		abortWithParamError(c, &ParamError{Param: "locale", Reason: "must be one of " + strings.Join(i18n.Locales(), ", ")})
+		return nil, false
+	}
+	return &format, true
+}
+
+func encodeExpensesJSON(records []*expenses.Expense) ([]byte, error) {
+	responseRecords := make([]*ExpenseResponse, 0, len(records))
+	for _, record := range records {
+		responseRecords = append(responseRecords, expenseToResponse(record))
+	}
+	return json.Marshal(responseRecords)
+}
+
+// csvFlushRows is how many rows a streamed export writes between flushes to the client
+const csvFlushRows = 500
+
+// === Endpoint Hanlders ===
+
+// StreamCSV writes every expense as csv straight to the response, reading and sending a row at a time,
+// so an export of years of expenses takes as little memory as one of a week. Unlike StartExport there is
+// no job to poll. A failure part way through aborts the connection, so a cut short export can't pass for a whole one.
+// ?locale=, or else the locale the user saved, writes the dates and amounts the way the locale does, i.e. de.
+func (h *ExportHandler) StreamCSV(c *gin.Context) {
+	format, ok := h.exportFormat(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `attachment; filename="expenses.csv"`)
+	c.Status(http.StatusOK)
+
+	w := newCSVWriter(c.Writer, format)
+	err := w.Write(exportCSVHeader)
+	if err == nil {
+		rows := 0
+		err = h.Service.EachExpense(c.Request.Context(), func(record *expenses.Expense) error {
+			if err := w.Write(expenseToCSVRecord(record, format)); err != nil {
+				return err
+			}
+
+			rows += 1
+			if rows%csvFlushRows == 0 {
+				w.Flush()
+				if err := w.Error(); err != nil {
+					return err
+				}
+				c.Writer.Flush()
+			}
+			return nil
+		})
+	}
+	if err == nil {
+		w.Flush()
+		err = w.Error()
+	}
+	if err == nil {
+		return
+	}
+
+	// nothing has reached the client yet, so it can still get a proper error
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	log.Printf("csv export aborted part way through: %v", err)
+	panic(http.ErrAbortHandler)
+}
+
+// StartExport queues an export of every expense, responding 202 with the job to poll.
+// csv exports are written for ?locale= or the user's saved locale, like StreamCSV
+func (h *ExportHandler) StartExport(c *gin.Context) {
+	format, err := ParseEnumQuery(c, "format", "json", "json", "csv")
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+	localeFormat, ok := h.exportFormat(c)
+	if !ok {
+		return
+	}
+
+	// the job outlives the request, so it carries the user over to keep the same scope
+	userID, hasUser := auth.UserIDFromContext(c.Request.Context())
+
+	job, err := h.Jobs.Submit("export", userID, func(ctx context.Context) (*jobs.Result, error) {
+		if hasUser {
+			ctx = auth.WithUserID(ctx, userID)
+		}
+
+		records, err := h.Service.GetAllExpenses(ctx)
+		if errors.Is(err, expenses.ErrTooManyResults) {
+			return nil, fmt.Errorf("%w, stream them with GET /exports/expenses.csv instead", err)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if format == "csv" {
+			data, err := encodeExpensesCSV(records, localeFormat)
+			if err != nil {
+				return nil, err
+			}
+			return &jobs.Result{Data: data, ContentType: "text/csv", Filename: "expenses.csv"}, nil
+		}
+
+		data, err := encodeExpensesJSON(records)
+		if err != nil {
+			return nil, err
+		}
+		return &jobs.Result{Data: data, ContentType: "application/json", Filename: "expenses.json"}, nil
+	})
+	if err != nil {
+		abortWithSubmitError(c, err)
+		return
+	}
+
+	respondJobAccepted(c, job)
+}
@@ -0,0 +1,248 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/handler"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+	"github.com/nicholasss/expense-tracker-api/internal/users"
+)
+
+// failingEachService fails EachExpense after failAfter expenses have been handed out
+type failingEachService struct {
+	*mockService
+	failAfter int
+}
+
+func (s *failingEachService) EachExpense(ctx context.Context, fn func(*expenses.Expense) error) error {
+	sent := 0
+	return s.mockService.EachExpense(ctx, func(record *expenses.Expense) error {
+		if sent == s.failAfter {
+			return errors.New("database went away")
+		}
+		sent += 1
+		return fn(record)
+	})
+}
+
+func TestStreamCSV(t *testing.T) {
+	testTable := []struct {
+		name           string
+		inputRecords   int
+		inputFailAt    int // fail the service after this many records, -1 to never fail
+		expectAbort    bool
+		wantStatus     int
+		wantCSVRows    int // not counting the header
+		wantAttachment bool
+	}{
+		{
+			name:           "valid-few-records",
+			inputRecords:   3,
+			inputFailAt:    -1,
+			wantStatus:     http.StatusOK,
+			wantCSVRows:    3,
+			wantAttachment: true,
+		},
+		{
+			name:           "valid-flushes-between-rows",
+			inputRecords:   1234,
+			inputFailAt:    -1,
+			wantStatus:     http.StatusOK,
+			wantCSVRows:    1234,
+			wantAttachment: true,
+		},
+		{
+			name:           "valid-no-records",
+			inputRecords:   0,
+			inputFailAt:    -1,
+			wantStatus:     http.StatusOK,
+			wantCSVRows:    0,
+			wantAttachment: true,
+		},
+		{
+			name:         "invalid-fails-before-first-flush",
+			inputRecords: 10,
+			inputFailAt:  3,
+			wantStatus:   http.StatusInternalServerError,
+		},
+		{
+			name:         "invalid-fails-after-first-flush",
+			inputRecords: 1000,
+			inputFailAt:  600,
+			expectAbort:  true,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			serv := &mockService{db: make(map[int]*expenses.Expense)}
+			for range testCase.inputRecords {
+				_, err := serv.NewExpense(t.Context(), time.Unix(1761231600, 0), "train ticket, return", money.New(1250, "EUR"), 0)
+				if err != nil {
+					t.Fatalf("unable to setup mock service: %v", err)
+				}
+			}
+
+			var service expenses.Service = serv
+			if testCase.inputFailAt >= 0 {
+				service = &failingEachService{mockService: serv, failAfter: testCase.inputFailAt}
+			}
+
+			h := handler.NewExportHandler(service, nil)
+			r := gin.New()
+			r.GET("/exports/expenses.csv", h.StreamCSV)
+
+			var aborted any
+			rec := func() *httptest.ResponseRecorder {
+				defer func() { aborted = recover() }()
+				return doRequest(t, r, http.MethodGet, "/exports/expenses.csv", "")
+			}()
+
+			if testCase.expectAbort {
+				if aborted != http.ErrAbortHandler {
+					t.Fatalf("got panic: %v, want http.ErrAbortHandler", aborted)
+				}
+				return
+			}
+			if aborted != nil {
+				t.Fatalf("got unexpected panic: %v", aborted)
+			}
+
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+
+			gotAttachment := strings.HasPrefix(rec.Header().Get("Content-Disposition"), "attachment")
+			if gotAttachment != testCase.wantAttachment {
+				t.Errorf("got Content-Disposition: %q", rec.Header().Get("Content-Disposition"))
+			}
+			if testCase.wantStatus != http.StatusOK {
+				return
+			}
+
+			rows, err := csv.NewReader(rec.Body).ReadAll()
+			if err != nil {
+				t.Fatalf("unable to read csv: %v", err)
+			}
+			if len(rows) == 0 || strings.Join(rows[0], ",") != "id,created_at,occured_at,description,amount,currency" {
+				t.Fatalf("got header: %v", rows)
+			}
+			if len(rows)-1 != testCase.wantCSVRows {
+				t.Errorf("got rows: %d, want rows: %d", len(rows)-1, testCase.wantCSVRows)
+			}
+			if testCase.wantCSVRows > 0 && rows[1][3] != "train ticket, return" {
+				t.Errorf("got description: %q", rows[1][3])
+			}
+		})
+	}
+}
+
+// savedLocales has the locale each user saved for their exports
+type savedLocales map[int]string
+
+func (p savedLocales) GetProfile(ctx context.Context, id int) (*users.User, error) {
+	return &users.User{ID: id, Locale: p[id]}, nil
+}
+
+func TestStreamCSVLocale(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputQuery  string
+		inputSaved  string // the user's saved locale
+		wantStatus  int
+		wantComma   rune
+		wantOccured string
+		wantAmount  string
+	}{
+		{
+			name:        "valid-plain-by-default",
+			wantStatus:  http.StatusOK,
+			wantComma:   ',',
+			wantOccured: "2025-10-23T15:00:00Z",
+			wantAmount:  "123456",
+		},
+		{
+			name:        "valid-requested-locale",
+			inputQuery:  "?locale=de",
+			wantStatus:  http.StatusOK,
+			wantComma:   ';',
+			wantOccured: "23.10.2025 15:00",
+			wantAmount:  "1.234,56\u00a0€",
+		},
+		{
+			name:        "valid-saved-locale",
+			inputSaved:  "fr",
+			wantStatus:  http.StatusOK,
+			wantComma:   ';',
+			wantOccured: "23/10/2025 15:00",
+			wantAmount:  "1\u00a0234,56\u00a0€",
+		},
+		{
+			name:        "valid-requested-over-saved",
+			inputQuery:  "?locale=en",
+			inputSaved:  "fr",
+			wantStatus:  http.StatusOK,
+			wantComma:   ',',
+			wantOccured: "Oct 23, 2025 15:00",
+			wantAmount:  "€1,234.56",
+		},
+		{
+			name:       "invalid-unsupported-locale",
+			inputQuery: "?locale=tlh",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			serv := &mockService{db: make(map[int]*expenses.Expense)}
+			_, err := serv.NewExpense(t.Context(), time.Unix(1761231600, 0).UTC(), "train ticket", money.New(123456, "EUR"), 0)
+			if err != nil {
+				t.Fatalf("unable to setup mock service: %v", err)
+			}
+
+			h := handler.NewExportHandler(serv, nil)
+			h.Profiles = savedLocales{1: testCase.inputSaved}
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Request = c.Request.WithContext(auth.WithUserID(c.Request.Context(), 1))
+			})
+			r.GET("/exports/expenses.csv", h.StreamCSV)
+
+			rec := doRequest(t, r, http.MethodGet, "/exports/expenses.csv"+testCase.inputQuery, "")
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+			if testCase.wantStatus != http.StatusOK {
+				return
+			}
+
+			reader := csv.NewReader(rec.Body)
+			reader.Comma = testCase.wantComma
+			rows, err := reader.ReadAll()
+			if err != nil {
+				t.Fatalf("unable to read csv: %v", err)
+			}
+			if len(rows) != 2 || len(rows[1]) != 6 {
+				t.Fatalf("got rows: %v", rows)
+			}
+			if rows[1][2] != testCase.wantOccured || rows[1][4] != testCase.wantAmount {
+				t.Errorf("got occured_at: %q, amount: %q, want occured_at: %q, amount: %q", rows[1][2], rows[1][4], testCase.wantOccured, testCase.wantAmount)
+			}
+		})
+	}
+}
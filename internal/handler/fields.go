@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jsonFieldNames lists the json names of a struct type's fields, used to validate ?fields=
+func jsonFieldNames(t reflect.Type) []string {
+	names := make([]string, 0, t.NumField())
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// expenseFieldNames are the fields of ExpenseResponse that can be requested
+var expenseFieldNames = jsonFieldNames(reflect.TypeFor[ExpenseResponse]())
+
+// ParseFieldsQuery parses the comma separated fields query parameter, i.e. ?fields=id,amount
+// Every requested field must be in allowed. A nil slice is returned when the parameter is absent.
+func ParseFieldsQuery(c *gin.Context, name string, allowed []string) ([]string, error) {
+	raw, ok := c.GetQuery(name)
+	if !ok {
+		return nil, nil
+	}
+
+	fields := make([]string, 0)
+	for field := range strings.SplitSeq(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !slices.Contains(allowed, field) {
+			return nil, &ParamError{Param: name, Reason: "unknown field '" + field + "', must be one of: " + strings.Join(allowed, ", ")}
+		}
+		fields = append(fields, field)
+	}
+
+	if len(fields) == 0 {
+		return nil, &ParamError{Param: name, Reason: "must list at least one field"}
+	}
+
+	return fields, nil
+}
+
+// projectFields reduces a response down to only the requested json fields.
+// When fields is nil the response is returned untouched.
+func projectFields(resp any, fields []string) (any, error) {
+	if fields == nil {
+		return resp, nil
+	}
+
+	encoded, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	all := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(encoded, &all); err != nil {
+		return nil, err
+	}
+
+	projected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if val, ok := all[field]; ok {
+			projected[field] = val
+		}
+	}
+
+	return projected, nil
+}
+
+// projectEachField applies projectFields over every response in a list
+func projectEachField[T any](resps []T, fields []string) ([]any, error) {
+	projected := make([]any, 0, len(resps))
+	for _, resp := range resps {
+		p, err := projectFields(resp, fields)
+		if err != nil {
+			return nil, err
+		}
+		projected = append(projected, p)
+	}
+	return projected, nil
+}
@@ -0,0 +1,365 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/households"
+)
+
+// === Handler Type
+
+// HouseholdHandler manages the authenticated user's household, it needs middleware.RequireAuth
+type HouseholdHandler struct {
+	Service households.Service
+}
+
+func NewHouseholdHandler(service households.Service) *HouseholdHandler {
+	return &HouseholdHandler{Service: service}
+}
+
+// == Endpoint Types ==
+
+// CreateHouseholdRequest is utilized specifically for the Create endpoint: POST /households
+type CreateHouseholdRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// AddMemberRequest is utilized specifically for the AddMember endpoint: POST /households/me/members
+type AddMemberRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// MemberResponse is a user in the household
+type MemberResponse struct {
+	UserID   int         `json:"user_id"`
+	Email    string      `json:"email"`
+	Name     string      `json:"name"`
+	Role     string      `json:"role"`
+	JoinedAt RFC3339Time `json:"joined_at"`
+}
+
+// HouseholdResponse is the household with its members
+type HouseholdResponse struct {
+	ID        int               `json:"id"`
+	Name      string            `json:"name"`
+	CreatedAt RFC3339Time       `json:"created_at"`
+	Members   []*MemberResponse `json:"members"`
+}
+
+// ContributionResponse is what one member spent into the shared book
+type ContributionResponse struct {
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"`
+	Total  int64  `json:"total"`
+	Count  int    `json:"count"`
+}
+
+// ContributionsResponse is the per-member report, biggest spender first
+type ContributionsResponse struct {
+	Total   int64                   `json:"total"`
+	Members []*ContributionResponse `json:"members"`
+}
+
+// BalanceResponse is where one member stands, positive balances are owed to them and negative ones they owe
+type BalanceResponse struct {
+	UserID  int    `json:"user_id"`
+	Name    string `json:"name"`
+	Paid    int64  `json:"paid"`
+	Share   int64  `json:"share"`
+	Settled int64  `json:"settled"`
+	Balance int64  `json:"balance"`
+}
+
+// TransferResponse is a payment that would settle up part of the balances
+type TransferResponse struct {
+	FromUserID int   `json:"from_user_id"`
+	ToUserID   int   `json:"to_user_id"`
+	Amount     int64 `json:"amount"`
+}
+
+// BalancesResponse is utilized specifically for the GetBalances endpoint: GET /households/me/balances
+type BalancesResponse struct {
+	Members   []*BalanceResponse  `json:"members"`
+	Transfers []*TransferResponse `json:"transfers"`
+}
+
+// SettleRequest is utilized specifically for the Settle endpoint: POST /households/me/settlements
+type SettleRequest struct {
+	ToUserID int   `json:"to_user_id" binding:"required"`
+	Amount   int64 `json:"amount" binding:"required"` // cents
+}
+
+// SettlementResponse is a payment recorded between two members
+type SettlementResponse struct {
+	ID         int         `json:"id"`
+	FromUserID int         `json:"from_user_id"`
+	ToUserID   int         `json:"to_user_id"`
+	Amount     int64       `json:"amount"`
+	CreatedAt  RFC3339Time `json:"created_at"`
+}
+
+func settlementToResponse(settlement *households.Settlement) *SettlementResponse {
+	return &SettlementResponse{
+		ID:         settlement.ID,
+		FromUserID: settlement.FromUserID,
+		ToUserID:   settlement.ToUserID,
+		Amount:     settlement.Amount,
+		CreatedAt:  RFC3339Time{Time: settlement.CreatedAt},
+	}
+}
+
+func memberToResponse(member *households.Member) *MemberResponse {
+	return &MemberResponse{
+		UserID:   member.UserID,
+		Email:    member.Email,
+		Name:     member.Name,
+		Role:     string(member.Role),
+		JoinedAt: RFC3339Time{Time: member.JoinedAt},
+	}
+}
+
+// abortWithHouseholdError maps errors from households.Service
+func abortWithHouseholdError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, households.ErrEmptyName), errors.Is(err, households.ErrSettleWithSelf),
+		errors.Is(err, households.ErrInvalidAmount):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+	case errors.Is(err, households.ErrNotMember), errors.Is(err, households.ErrMemberNotFound),
+		errors.Is(err, households.ErrUserNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not Found: " + err.Error()})
+	case errors.Is(err, households.ErrNotOwner):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: " + err.Error()})
+	case errors.Is(err, households.ErrAlreadyMember), errors.Is(err, households.ErrOwnerCannotLeave):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Conflict: " + err.Error()})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+	}
+}
+
+// actorID is the authenticated user, the request is aborted when there is none
+func actorID(c *gin.Context) (int, bool) {
+	userID, ok := auth.UserIDFromContext(c.Request.Context())
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	}
+	return userID, ok
+}
+
+// === Endpoint Hanlders ===
+
+func (h *HouseholdHandler) Create(c *gin.Context) {
+	userID, ok := actorID(c)
+	if !ok {
+		return
+	}
+
+	var reqBody CreateHouseholdRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	if _, err := h.Service.Create(c.Request.Context(), userID, reqBody.Name); err != nil {
+		abortWithHouseholdError(c, err)
+		return
+	}
+
+	c.Header("Location", "/households/me")
+	h.respondHousehold(c, userID, http.StatusCreated)
+}
+
+func (h *HouseholdHandler) Get(c *gin.Context) {
+	userID, ok := actorID(c)
+	if !ok {
+		return
+	}
+
+	h.respondHousehold(c, userID, http.StatusOK)
+}
+
+// respondHousehold sends the user's household with its members
+func (h *HouseholdHandler) respondHousehold(c *gin.Context, userID, status int) {
+	household, members, err := h.Service.Get(c.Request.Context(), userID)
+	if err != nil {
+		abortWithHouseholdError(c, err)
+		return
+	}
+
+	resp := HouseholdResponse{
+		ID:        household.ID,
+		Name:      household.Name,
+		CreatedAt: RFC3339Time{Time: household.CreatedAt},
+		Members:   make([]*MemberResponse, 0, len(members)),
+	}
+	for _, member := range members {
+		resp.Members = append(resp.Members, memberToResponse(member))
+	}
+
+	c.JSON(status, resp)
+}
+
+func (h *HouseholdHandler) AddMember(c *gin.Context) {
+	userID, ok := actorID(c)
+	if !ok {
+		return
+	}
+
+	var reqBody AddMemberRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	member, err := h.Service.AddMember(c.Request.Context(), userID, reqBody.Email)
+	if err != nil {
+		abortWithHouseholdError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, memberToResponse(member))
+}
+
+// RemoveMember removes a member, members can remove themselves to leave
+func (h *HouseholdHandler) RemoveMember(c *gin.Context) {
+	userID, ok := actorID(c)
+	if !ok {
+		return
+	}
+
+	memberID, err := ParseIDParam(c, "user_id")
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+
+	if err := h.Service.RemoveMember(c.Request.Context(), userID, memberID); err != nil {
+		abortWithHouseholdError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetContributions reports each member's spending, optionally between ?from= and ?to=
+func (h *HouseholdHandler) GetContributions(c *gin.Context) {
+	userID, ok := actorID(c)
+	if !ok {
+		return
+	}
+
+	// unset bounds are left as the zero time, which is unbounded
+	from, _, err := ParseTimeQuery(c, "from")
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+	to, _, err := ParseTimeQuery(c, "to")
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+
+	contributions, err := h.Service.Contributions(c.Request.Context(), userID, from, to)
+	if err != nil {
+		abortWithHouseholdError(c, err)
+		return
+	}
+
+	resp := ContributionsResponse{Members: make([]*ContributionResponse, 0, len(contributions))}
+	for _, contribution := range contributions {
+		resp.Total += contribution.Total
+		resp.Members = append(resp.Members, &ContributionResponse{
+			UserID: contribution.UserID,
+			Name:   contribution.Name,
+			Total:  contribution.Total,
+			Count:  contribution.Count,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetBalances reports where each member stands over every shared expense and settlement,
+// with the transfers that would settle everyone up
+func (h *HouseholdHandler) GetBalances(c *gin.Context) {
+	userID, ok := actorID(c)
+	if !ok {
+		return
+	}
+
+	balances, transfers, err := h.Service.Balances(c.Request.Context(), userID)
+	if err != nil {
+		abortWithHouseholdError(c, err)
+		return
+	}
+
+	resp := BalancesResponse{
+		Members:   make([]*BalanceResponse, 0, len(balances)),
+		Transfers: make([]*TransferResponse, 0, len(transfers)),
+	}
+	for _, balance := range balances {
+		resp.Members = append(resp.Members, &BalanceResponse{
+			UserID:  balance.UserID,
+			Name:    balance.Name,
+			Paid:    balance.Paid,
+			Share:   balance.Share,
+			Settled: balance.Settled,
+			Balance: balance.Balance,
+		})
+	}
+	for _, transfer := range transfers {
+		resp.Transfers = append(resp.Transfers, &TransferResponse{
+			FromUserID: transfer.FromUserID,
+			ToUserID:   transfer.ToUserID,
+			Amount:     transfer.Amount,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Settle records that the authenticated user paid another member back
+func (h *HouseholdHandler) Settle(c *gin.Context) {
+	userID, ok := actorID(c)
+	if !ok {
+		return
+	}
+
+	var reqBody SettleRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	settlement, err := h.Service.Settle(c.Request.Context(), userID, reqBody.ToUserID, reqBody.Amount)
+	if err != nil {
+		abortWithHouseholdError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, settlementToResponse(settlement))
+}
+
+// GetSettlements lists the payments recorded between members, newest first
+func (h *HouseholdHandler) GetSettlements(c *gin.Context) {
+	userID, ok := actorID(c)
+	if !ok {
+		return
+	}
+
+	settlements, err := h.Service.Settlements(c.Request.Context(), userID)
+	if err != nil {
+		abortWithHouseholdError(c, err)
+		return
+	}
+
+	resp := make([]*SettlementResponse, 0, len(settlements))
+	for _, settlement := range settlements {
+		resp = append(resp, settlementToResponse(settlement))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
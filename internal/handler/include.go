@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+)
+
+// expenseIncludes are the related resources ?include= can embed in expenses
+var expenseIncludes = []string{"category"}
+
+// expenseResponder turns expenses into responses, embedding what ?include= asks for, i.e. ?include=category.
+// The book's categories are read once for the whole response rather than once per expense, archived
+// ones aren't embedded. It responds with the error and returns false when the includes can't be read
+func (h *GinHandler) expenseResponder(c *gin.Context) (func(*expenses.Expense) *ExpenseResponse, bool) {
+	includes, err := ParseFieldsQuery(c, "include", expenseIncludes)
+	if err != nil {
+		abortWithParamError(c, err)
+		return nil, false
+	}
+	if includes == nil {
+		return expenseToResponse, true
+	}
+
+	// category is the only include so far
+	if h.Categories == nil {
+		abortWithParamError(c, &ParamError{Param: "include", Reason: "categories are not enabled"})
+		return nil, false
+	}
+	list, err := h.Categories.List(c.Request.Context())
+	if err != nil {
+		abortWithCategoryError(c, err)
+		return nil, false
+	}
+	byID := make(map[int]*CategoryResponse, len(list))
+	for _, category := range list {
+		byID[category.ID] = categoryToResponse(category)
+	}
+
+	return func(exp *expenses.Expense) *ExpenseResponse {
+		resp := expenseToResponse(exp)
+		resp.Category = byID[exp.CategoryID]
+		return resp
+	}, true
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/jobs"
+)
+
+// === Handler Type
+
+type JobHandler struct {
+	Jobs *jobs.Manager
+}
+
+func NewJobHandler(manager *jobs.Manager) *JobHandler {
+	return &JobHandler{Jobs: manager}
+}
+
+// == Endpoint Types ==
+
+// JobResponse reports the status of an async job, result_url is set once it has succeeded
+type JobResponse struct {
+	ID         string       `json:"id"`
+	Kind       string       `json:"kind"`
+	Status     string       `json:"status"`
+	CreatedAt  RFC3339Time  `json:"created_at"`
+	FinishedAt *RFC3339Time `json:"finished_at,omitempty"`
+	Error      string       `json:"error,omitempty"`
+	URL        string       `json:"url"`
+	ResultURL  string       `json:"result_url,omitempty"`
+}
+
+// jobURL is the canonical resource URL for a job, used in responses and the Location header
+func jobURL(id string) string {
+	return "/jobs/" + id
+}
+
+func jobToResponse(job *jobs.Job) *JobResponse {
+	resp := &JobResponse{
+		ID:        job.ID,
+		Kind:      job.Kind,
+		Status:    string(job.Status),
+		CreatedAt: RFC3339Time{Time: job.CreatedAt},
+		Error:     job.Err,
+		URL:       jobURL(job.ID),
+	}
+
+	if !job.FinishedAt.IsZero() {
+		resp.FinishedAt = &RFC3339Time{Time: job.FinishedAt}
+	}
+	if job.Status == jobs.StatusSucceeded {
+		resp.ResultURL = jobURL(job.ID) + "/result"
+	}
+
+	return resp
+}
+
+// respondJobAccepted sends 202 pointing the client at where to poll the job
+func respondJobAccepted(c *gin.Context, job *jobs.Job) {
+	resp := jobToResponse(job)
+	c.Header("Location", resp.URL)
+	c.JSON(http.StatusAccepted, resp)
+}
+
+// abortWithSubmitError maps errors from jobs.Manager.Submit
+func abortWithSubmitError(c *gin.Context, err error) {
+	if errors.Is(err, jobs.ErrQueueFull) || errors.Is(err, jobs.ErrManagerClosed) {
+		c.Header("Retry-After", "30")
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Service Unavailable: " + err.Error()})
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+}
+
+// getOwnJob finds the job from the id param, other users' jobs are reported as not found
+func (h *JobHandler) getOwnJob(c *gin.Context) (*jobs.Job, bool) {
+	job, err := h.Jobs.Get(c.Param("id"))
+	if err == nil {
+		if userID, ok := auth.UserIDFromContext(c.Request.Context()); ok && job.OwnerID != userID {
+			err = jobs.ErrJobNotFound
+		}
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not Found: " + err.Error()})
+		return nil, false
+	}
+	return job, true
+}
+
+// === Endpoint Hanlders ===
+
+func (h *JobHandler) GetJob(c *gin.Context) {
+	job, ok := h.getOwnJob(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, jobToResponse(job))
+}
+
+func (h *JobHandler) GetJobResult(c *gin.Context) {
+	job, ok := h.getOwnJob(c)
+	if !ok {
+		return
+	}
+
+	// only finished jobs have something to download
+	if job.Status != jobs.StatusSucceeded {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Conflict: job has not succeeded, status is " + string(job.Status)})
+		return
+	}
+
+	if job.Result.Filename != "" {
+		c.Header("Content-Disposition", `attachment; filename="`+job.Result.Filename+`"`)
+	}
+	c.Data(http.StatusOK, job.Result.ContentType, job.Result.Data)
+}
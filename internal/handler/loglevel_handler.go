@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// === Handler Type
+
+// LogLevelHandler lets admins change the log level of the running server, i.e. to debug in production.
+// A config reload that changes LOG_LEVEL replaces whatever was set here.
+type LogLevelHandler struct {
+	// SetLevel applies the level and returns the one before it, slog.SetLogLoggerLevel by default
+	SetLevel func(level slog.Level) slog.Level
+}
+
+func NewLogLevelHandler() *LogLevelHandler {
+	return &LogLevelHandler{SetLevel: slog.SetLogLoggerLevel}
+}
+
+// == Endpoint Types ==
+
+// LogLevelRequest is utilized specifically for the SetLogLevel endpoint: PUT /admin/loglevel
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required"`
+}
+
+// LogLevelResponse is the level in effect
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// currentLevel is the lowest standard level the default logger has enabled
+func currentLevel(ctx context.Context) slog.Level {
+	for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn} {
+		if slog.Default().Enabled(ctx, level) {
+			return level
+		}
+	}
+	return slog.LevelError
+}
+
+// === Endpoint Hanlders ===
+
+// GetLogLevel reports the level in effect
+func (h *LogLevelHandler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, LogLevelResponse{Level: currentLevel(c.Request.Context()).String()})
+}
+
+// SetLogLevel switches the level to one of debug, info, warn, or error until the next restart
+func (h *LogLevelHandler) SetLogLevel(c *gin.Context) {
+	var reqBody LogLevelRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(reqBody.Level)); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: level must be one of debug, info, warn, error"})
+		return
+	}
+
+	previous := h.SetLevel(level)
+	log.Printf("Log level changed from %s to %s", previous, level)
+
+	c.JSON(http.StatusOK, LogLevelResponse{Level: level.String()})
+}
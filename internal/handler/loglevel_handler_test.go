@@ -0,0 +1,74 @@
+package handler_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/handler"
+)
+
+func TestSetLogLevel(t *testing.T) {
+	testTable := []struct {
+		name       string
+		inputBody  string
+		wantStatus int
+		wantLevel  slog.Level
+		wantSet    bool
+	}{
+		{
+			name:       "valid-debug",
+			inputBody:  `{"level": "debug"}`,
+			wantStatus: http.StatusOK,
+			wantLevel:  slog.LevelDebug,
+			wantSet:    true,
+		},
+		{
+			name:       "valid-uppercase-warn",
+			inputBody:  `{"level": "WARN"}`,
+			wantStatus: http.StatusOK,
+			wantLevel:  slog.LevelWarn,
+			wantSet:    true,
+		},
+		{
+			name:       "invalid-level",
+			inputBody:  `{"level": "verbose"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid-missing-level",
+			inputBody:  `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			var gotLevel slog.Level
+			gotSet := false
+			h := &handler.LogLevelHandler{SetLevel: func(level slog.Level) slog.Level {
+				gotLevel, gotSet = level, true
+				return slog.LevelInfo
+			}}
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.PUT("/admin/loglevel", h.SetLogLevel)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/admin/loglevel", strings.NewReader(testCase.inputBody))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != testCase.wantStatus {
+				t.Errorf("got status: %d, want status: %d", rec.Code, testCase.wantStatus)
+			}
+			if gotSet != testCase.wantSet || gotLevel != testCase.wantLevel {
+				t.Errorf("got level set: %v to %s, want set: %v to %s", gotSet, gotLevel, testCase.wantSet, testCase.wantLevel)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/audit"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/oidc"
+	"github.com/nicholasss/expense-tracker-api/internal/users"
+)
+
+// cookies that carry the login attempt through the provider redirect
+const (
+	oidcStateCookie  = "oidc_state"
+	oidcNonceCookie  = "oidc_nonce"
+	oidcCookieMaxAge = 10 * 60 // seconds
+	oidcCallbackPath = "/auth/oidc/callback"
+)
+
+// === Handler Type
+
+// OIDCHandler logs users in through an external provider, then issues our own token
+type OIDCHandler struct {
+	Provider *oidc.Provider
+	Users    users.Service
+	Tokens   *auth.TokenIssuer
+	Audit    audit.Service
+}
+
+func NewOIDCHandler(provider *oidc.Provider, userService users.Service, tokens *auth.TokenIssuer, recorder audit.Service) *OIDCHandler {
+	return &OIDCHandler{Provider: provider, Users: userService, Tokens: tokens, Audit: recorder}
+}
+
+// randomString returns a url safe string with n bytes of randomness
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// === Endpoint Hanlders ===
+
+// Login redirects to the provider, remembering state and nonce in short lived cookies
+func (h *OIDCHandler) Login(c *gin.Context) {
+	state, err := randomString(32)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+	nonce, err := randomString(32)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	// lax so the cookies come back on the top level redirect from the provider
+	secure := c.Request.TLS != nil
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, oidcCookieMaxAge, oidcCallbackPath, "", secure, true)
+	c.SetCookie(oidcNonceCookie, nonce, oidcCookieMaxAge, oidcCallbackPath, "", secure, true)
+
+	c.Redirect(http.StatusFound, h.Provider.AuthCodeURL(state, nonce))
+}
+
+// Callback finishes the authorization code flow and returns the same body as POST /users/login
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	// the provider reports a denied login as an error param
+	if providerErr := c.Query("error"); providerErr != "" {
+		recordLogin(c, h.Audit, audit.TypeLoginFailed, 0, "", "oidc: provider returned "+providerErr, http.StatusUnauthorized)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: provider returned " + providerErr})
+		return
+	}
+
+	code := c.Query("code")
+	state := c.Query("state")
+	wantState, stateErr := c.Cookie(oidcStateCookie)
+	nonce, nonceErr := c.Cookie(oidcNonceCookie)
+	if code == "" || stateErr != nil || nonceErr != nil ||
+		subtle.ConstantTimeCompare([]byte(state), []byte(wantState)) != 1 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: invalid or expired login attempt"})
+		return
+	}
+
+	// the attempt is single use
+	secure := c.Request.TLS != nil
+	c.SetCookie(oidcStateCookie, "", -1, oidcCallbackPath, "", secure, true)
+	c.SetCookie(oidcNonceCookie, "", -1, oidcCallbackPath, "", secure, true)
+
+	claims, err := h.Provider.Exchange(c.Request.Context(), code, nonce)
+	if err != nil {
+		if errors.Is(err, oidc.ErrInvalidIDToken) {
+			recordLogin(c, h.Audit, audit.TypeLoginFailed, 0, "", "oidc: "+err.Error(), http.StatusUnauthorized)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: " + err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusBadGateway, gin.H{"error": "Bad Gateway: unable to complete login with provider"})
+		return
+	}
+
+	user, err := h.Users.LoginExternal(c.Request.Context(), users.ExternalIdentity{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	})
+	if err != nil {
+		if errors.Is(err, users.ErrUnverifiedEmail) || errors.Is(err, users.ErrInvalidEmail) {
+			recordLogin(c, h.Audit, audit.TypeLoginFailed, 0, claims.Email, "oidc: "+err.Error(), http.StatusForbidden)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: " + err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	token, expiresAt, err := h.Tokens.Issue(user.ID, user.TokenGeneration)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	recordLogin(c, h.Audit, audit.TypeLoginSucceeded, user.ID, user.Email, "oidc: "+claims.Issuer, http.StatusOK)
+	c.JSON(http.StatusOK, LoginResponse{
+		User:      userToResponse(user),
+		Token:     token,
+		ExpiresAt: &RFC3339Time{Time: expiresAt},
+	})
+}
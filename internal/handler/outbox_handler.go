@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/outbox"
+)
+
+// === Handler Type
+
+// OutboxHandler lets admins look at the webhook events given up on, and send them again
+type OutboxHandler struct {
+	Dispatcher *outbox.Dispatcher
+}
+
+func NewOutboxHandler(dispatcher *outbox.Dispatcher) *OutboxHandler {
+	return &OutboxHandler{Dispatcher: dispatcher}
+}
+
+// == Endpoint Types ==
+
+// DeadEventResponse is a webhook event that failed every attempt, with the body that was posted
+type DeadEventResponse struct {
+	ID        int             `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error"`
+	CreatedAt RFC3339Time     `json:"created_at"`
+	DeadAt    RFC3339Time     `json:"dead_at"`
+}
+
+func deadEventToResponse(msg *outbox.Message) DeadEventResponse {
+	return DeadEventResponse{
+		ID:        msg.ID,
+		Type:      string(msg.Type),
+		Payload:   msg.Payload,
+		Attempts:  msg.Attempts,
+		LastError: msg.LastError,
+		CreatedAt: RFC3339Time{Time: msg.CreatedAt},
+		DeadAt:    RFC3339Time{Time: msg.DeadAt},
+	}
+}
+
+// === Endpoint Hanlders ===
+
+// GetDead lists the events given up on, most recent first
+func (h *OutboxHandler) GetDead(c *gin.Context) {
+	pagination, err := ParsePagination(c, MaxPageLimit)
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+
+	dead, err := h.Dispatcher.ListDead(c.Request.Context(), pagination.Limit, pagination.Offset)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	resp := make([]DeadEventResponse, 0, len(dead))
+	for _, msg := range dead {
+		resp = append(resp, deadEventToResponse(msg))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Retry queues a dead event to be posted again
+func (h *OutboxHandler) Retry(c *gin.Context) {
+	id, err := ParseIDParam(c, "id")
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+
+	if err := h.Dispatcher.Retry(c.Request.Context(), id); err != nil {
+		if errors.Is(err, outbox.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not Found: " + err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
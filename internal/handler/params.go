@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dateOnlyLayout is accepted alongside RFC3339 for date query parameters
+const dateOnlyLayout = "2006-01-02"
+
+// Pagination defaults used by list endpoints, MaxPageLimit is only the default of GinHandler.MaxPageLimit
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+)
+
+// MaxBatchIDs is the most ids that can be requested at once with ?ids=
+const MaxBatchIDs = 100
+
+// ParamError is returned by the parameter helpers when a path or query parameter is malformed.
+// It names the offending parameter so every handler responds with the same 400 body.
+type ParamError struct {
+	Param  string
+	Reason string
+}
+
+// Error implements the error interface
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("invalid parameter '%s': %s", e.Param, e.Reason)
+}
+
+// Pagination holds the parsed limit and offset query parameters
+type Pagination struct {
+	Limit  int
+	Offset int
+}
+
+// abortWithParamError sends the consistent 400 response for a failed parameter helper
+func abortWithParamError(c *gin.Context, err error) {
+	c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+}
+
+// parseID parses raw as a positive integer id, naming param on failure
+func parseID(param, raw string) (int, error) {
+	id, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, &ParamError{Param: param, Reason: "must be an integer"}
+	}
+	if id <= 0 {
+		return 0, &ParamError{Param: param, Reason: "must be greater than 0"}
+	}
+	return id, nil
+}
+
+// ParseIDParam parses a positive integer id from the named path parameter, i.e. /expenses/:id
+func ParseIDParam(c *gin.Context, name string) (int, error) {
+	return parseID(name, c.Param(name))
+}
+
+// ParseIDsQuery parses a comma separated list of positive integer ids, i.e. ?ids=3,7,19
+// ok is false when the parameter is absent.
+func ParseIDsQuery(c *gin.Context, name string) (ids []int, ok bool, err error) {
+	raw, ok := c.GetQuery(name)
+	if !ok {
+		return nil, false, nil
+	}
+
+	ids = make([]int, 0)
+	for part := range strings.SplitSeq(raw, ",") {
+		id, err := parseID(name, strings.TrimSpace(part))
+		if err != nil {
+			return nil, true, err
+		}
+		ids = append(ids, id)
+	}
+
+	if len(ids) > MaxBatchIDs {
+		return nil, true, &ParamError{Param: name, Reason: fmt.Sprintf("must list at most %d ids", MaxBatchIDs)}
+	}
+
+	return ids, true, nil
+}
+
+// ParseIntQuery parses an optional integer query parameter that must fall within [minVal, maxVal].
+// def is returned when the parameter is absent.
+func ParseIntQuery(c *gin.Context, name string, def, minVal, maxVal int) (int, error) {
+	raw, ok := c.GetQuery(name)
+	if !ok {
+		return def, nil
+	}
+
+	val, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, &ParamError{Param: name, Reason: "must be an integer"}
+	}
+	if val < minVal || val > maxVal {
+		return 0, &ParamError{Param: name, Reason: fmt.Sprintf("must be between %d and %d", minVal, maxVal)}
+	}
+
+	return val, nil
+}
+
+// ParseTimeQuery parses an optional date query parameter given as RFC3339 or YYYY-MM-DD (UTC).
+// ok is false when the parameter is absent.
+func ParseTimeQuery(c *gin.Context, name string) (t time.Time, ok bool, err error) {
+	raw, ok := c.GetQuery(name)
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	if t, err = time.Parse(time.RFC3339, raw); err == nil {
+		return t, true, nil
+	}
+	if t, err = time.Parse(dateOnlyLayout, raw); err == nil {
+		return t, true, nil
+	}
+
+	return time.Time{}, true, &ParamError{Param: name, Reason: "must be an RFC3339 timestamp or YYYY-MM-DD date"}
+}
+
+// ParseEnumQuery parses an optional query parameter that must be one of allowed.
+// def is returned when the parameter is absent.
+func ParseEnumQuery(c *gin.Context, name, def string, allowed ...string) (string, error) {
+	raw, ok := c.GetQuery(name)
+	if !ok {
+		return def, nil
+	}
+
+	if !slices.Contains(allowed, raw) {
+		return "", &ParamError{Param: name, Reason: "must be one of: " + strings.Join(allowed, ", ")}
+	}
+
+	return raw, nil
+}
+
+// ParsePagination parses the limit and offset query parameters, applying DefaultPageLimit.
+// A limit above maxLimit is refused rather than cut down, so a short page is never mistaken for the last one
+func ParsePagination(c *gin.Context, maxLimit int) (Pagination, error) {
+	limit, err := ParseIntQuery(c, "limit", min(DefaultPageLimit, maxLimit), 1, maxLimit)
+	if err != nil {
+		if over, convErr := strconv.Atoi(c.Query("limit")); convErr == nil && over > maxLimit {
+			reason := fmt.Sprintf("must be at most %d, follow the next link for the rest", maxLimit)
+			return Pagination{}, &ParamError{Param: "limit", Reason: reason}
+		}
+		return Pagination{}, err
+	}
+
+	offset, err := ParseIntQuery(c, "offset", 0, 0, math.MaxInt)
+	if err != nil {
+		return Pagination{}, err
+	}
+
+	return Pagination{Limit: limit, Offset: offset}, nil
+}
@@ -0,0 +1,290 @@
+package handler_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/handler"
+)
+
+// newTestContext creates a gin context for the given request target and path params
+func newTestContext(t *testing.T, target string, params gin.Params) *gin.Context {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", target, nil)
+	c.Params = params
+
+	return c
+}
+
+// checkParamError makes sure that the error is a *handler.ParamError naming the wanted parameter
+func checkParamError(t *testing.T, gotErr error, wantParam string) {
+	t.Helper()
+
+	var paramErr *handler.ParamError
+	if !errors.As(gotErr, &paramErr) {
+		t.Fatalf("got error: '%v', want *handler.ParamError", gotErr)
+	}
+	if paramErr.Param != wantParam {
+		t.Errorf("ParamError.Param does not match. got: %q, want: %q", paramErr.Param, wantParam)
+	}
+}
+
+func TestParseIDParam(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputID     string
+		expectError bool
+		wantID      int
+	}{
+		{
+			name:        "valid-id",
+			inputID:     "12",
+			expectError: false,
+			wantID:      12,
+		},
+		{
+			name:        "invalid-not-a-number",
+			inputID:     "twelve",
+			expectError: true,
+		},
+		{
+			name:        "invalid-zero",
+			inputID:     "0",
+			expectError: true,
+		},
+		{
+			name:        "invalid-negative",
+			inputID:     "-4",
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := newTestContext(t, "/expenses/"+testCase.inputID, gin.Params{{Key: "id", Value: testCase.inputID}})
+
+			gotID, gotErr := handler.ParseIDParam(c, "id")
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("ParseIDParam(%q) got error: '%v', expected error: %v", testCase.inputID, gotErr, testCase.expectError)
+			}
+
+			if gotErr != nil {
+				checkParamError(t, gotErr, "id")
+				return
+			}
+
+			if gotID != testCase.wantID {
+				t.Errorf("got id: %d, want id: %d", gotID, testCase.wantID)
+			}
+		})
+	}
+}
+
+func TestParseTimeQuery(t *testing.T) {
+	testTable := []struct {
+		name        string
+		target      string
+		expectError bool
+		wantOK      bool
+		wantTime    time.Time
+	}{
+		{
+			name:        "valid-rfc3339",
+			target:      "/?from=2025-10-01T08:30:00Z",
+			expectError: false,
+			wantOK:      true,
+			wantTime:    time.Date(2025, 10, 1, 8, 30, 0, 0, time.UTC),
+		},
+		{
+			name:        "valid-date-only",
+			target:      "/?from=2025-10-01",
+			expectError: false,
+			wantOK:      true,
+			wantTime:    time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "valid-absent",
+			target:      "/",
+			expectError: false,
+			wantOK:      false,
+		},
+		{
+			name:        "invalid-format",
+			target:      "/?from=10/01/2025",
+			expectError: true,
+			wantOK:      true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := newTestContext(t, testCase.target, nil)
+
+			gotTime, gotOK, gotErr := handler.ParseTimeQuery(c, "from")
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("ParseTimeQuery() got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+
+			if gotErr != nil {
+				checkParamError(t, gotErr, "from")
+				return
+			}
+
+			if gotOK != testCase.wantOK {
+				t.Errorf("got ok: %v, want ok: %v", gotOK, testCase.wantOK)
+			}
+			if !gotTime.Equal(testCase.wantTime) {
+				t.Errorf("got time: %v, want time: %v", gotTime, testCase.wantTime)
+			}
+		})
+	}
+}
+
+func TestParseEnumQuery(t *testing.T) {
+	testTable := []struct {
+		name        string
+		target      string
+		expectError bool
+		wantValue   string
+	}{
+		{
+			name:        "valid-allowed-value",
+			target:      "/?sort=amount",
+			expectError: false,
+			wantValue:   "amount",
+		},
+		{
+			name:        "valid-default-when-absent",
+			target:      "/",
+			expectError: false,
+			wantValue:   "occured_at",
+		},
+		{
+			name:        "invalid-unknown-value",
+			target:      "/?sort=colour",
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := newTestContext(t, testCase.target, nil)
+
+			gotValue, gotErr := handler.ParseEnumQuery(c, "sort", "occured_at", "occured_at", "amount")
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("ParseEnumQuery() got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+
+			if gotErr != nil {
+				checkParamError(t, gotErr, "sort")
+				return
+			}
+
+			if gotValue != testCase.wantValue {
+				t.Errorf("got value: %q, want value: %q", gotValue, testCase.wantValue)
+			}
+		})
+	}
+}
+
+func TestParsePagination(t *testing.T) {
+	testTable := []struct {
+		name           string
+		target         string
+		inputMaxLimit  int
+		expectError    bool
+		wantParam      string
+		wantPagination handler.Pagination
+	}{
+		{
+			name:           "valid-defaults",
+			target:         "/",
+			inputMaxLimit:  handler.MaxPageLimit,
+			expectError:    false,
+			wantPagination: handler.Pagination{Limit: handler.DefaultPageLimit, Offset: 0},
+		},
+		{
+			name:           "valid-limit-and-offset",
+			target:         "/?limit=10&offset=30",
+			inputMaxLimit:  handler.MaxPageLimit,
+			expectError:    false,
+			wantPagination: handler.Pagination{Limit: 10, Offset: 30},
+		},
+		{
+			name:           "valid-default-within-smaller-max",
+			target:         "/",
+			inputMaxLimit:  20,
+			expectError:    false,
+			wantPagination: handler.Pagination{Limit: 20, Offset: 0},
+		},
+		{
+			name:           "valid-limit-at-max",
+			target:         "/?limit=20",
+			inputMaxLimit:  20,
+			expectError:    false,
+			wantPagination: handler.Pagination{Limit: 20, Offset: 0},
+		},
+		{
+			name:          "invalid-limit-too-large",
+			target:        "/?limit=100000",
+			inputMaxLimit: handler.MaxPageLimit,
+			expectError:   true,
+			wantParam:     "limit",
+		},
+		{
+			name:          "invalid-limit-over-configured-max",
+			target:        "/?limit=21",
+			inputMaxLimit: 20,
+			expectError:   true,
+			wantParam:     "limit",
+		},
+		{
+			name:          "invalid-zero-limit",
+			target:        "/?limit=0",
+			inputMaxLimit: handler.MaxPageLimit,
+			expectError:   true,
+			wantParam:     "limit",
+		},
+		{
+			name:          "invalid-negative-offset",
+			target:        "/?offset=-1",
+			inputMaxLimit: handler.MaxPageLimit,
+			expectError:   true,
+			wantParam:     "offset",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := newTestContext(t, testCase.target, nil)
+
+			gotPagination, gotErr := handler.ParsePagination(c, testCase.inputMaxLimit)
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("ParsePagination() got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+
+			if gotErr != nil {
+				checkParamError(t, gotErr, testCase.wantParam)
+				return
+			}
+
+			if gotPagination != testCase.wantPagination {
+				t.Errorf("got pagination: %+v, want pagination: %+v", gotPagination, testCase.wantPagination)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+	"github.com/nicholasss/expense-tracker-api/internal/quickadd"
+)
+
+// maxQuickBody is plenty for an amount and a description
+const maxQuickBody = 4 << 10
+
+// == Endpoint Types ==
+
+// QuickAddRequest is the body of QuickAdd, sent as JSON or a form. It holds either Text, i.e. "12.50 lunch",
+// or Amount and Description. Amount is in units rather than cents, i.e. 12.5 or "12.50"
+type QuickAddRequest struct {
+	Text        string      `json:"text" form:"text"`
+	Amount      json.Number `json:"amount" form:"amount"`
+	Description string      `json:"description" form:"description"`
+	OccuredAt   string      `json:"occured_at" form:"occured_at"` // RFC3339 or YYYY-MM-DD, now when empty
+}
+
+// === Endpoint Hanlders ===
+
+// QuickAdd records an expense from the smallest body that describes one: POST /quick
+// It is meant for automations like iOS Shortcuts or Tasker, which use a token scoped to expenses:create.
+// A text/plain body is read as the text on its own
+func (h *GinHandler) QuickAdd(c *gin.Context) {
+	var reqBody QuickAddRequest
+	if c.ContentType() == "text/plain" {
+		text, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxQuickBody))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: unable to read body"})
+			return
+		}
+		reqBody.Text = string(text)
+	} else if err := c.ShouldBind(&reqBody); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	entry, err := reqBody.entry()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	occuredAt := time.Now()
+	if reqBody.OccuredAt != "" {
+		if occuredAt, err = parseQuickTime(reqBody.OccuredAt); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+			return
+		}
+	}
+
+	newRecord, err := h.Service.NewExpense(c.Request.Context(), occuredAt, entry.Description, money.New(entry.Amount, h.Service.Currency()), 0)
+	if err != nil {
+		abortWithServiceError(c, err)
+		return
+	}
+
+	resp := expenseToResponse(newRecord)
+	c.Header("Location", resp.URL)
+	c.JSON(http.StatusCreated, resp)
+}
+
+// entry reads the expense out of whichever form the request used
+func (r *QuickAddRequest) entry() (*quickadd.Entry, error) {
+	if r.Text != "" {
+		if r.Amount != "" || r.Description != "" {
+			return nil, errors.New("send either text, or amount and description")
+		}
+		return quickadd.Parse(r.Text)
+	}
+
+	if r.Amount == "" {
+		return nil, quickadd.ErrNoEntry
+	}
+	amount, err := quickadd.ParseAmount(r.Amount.String())
+	if err != nil {
+		return nil, err
+	}
+	description := strings.TrimSpace(r.Description)
+	if description == "" {
+		return nil, quickadd.ErrNoDescription
+	}
+	return &quickadd.Entry{Amount: amount, Description: description}, nil
+}
+
+// parseQuickTime reads occured_at the way ParseTimeQuery reads query times
+func parseQuickTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(dateOnlyLayout, raw); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errors.New("occured_at must be an RFC3339 timestamp or YYYY-MM-DD date")
+}
+
+// ParseExpenseRequest is the body of ParseExpense
+type ParseExpenseRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// DraftExpenseResponse is an expense read from text, not yet recorded.
+// It is shaped like CreateExpenseRequest, so once confirmed it can be sent on to POST /expenses as is
+type DraftExpenseResponse struct {
+	OccuredAt   RFC3339Time `json:"occured_at"`
+	Description string      `json:"description"`
+	Amount      int64       `json:"amount"`
+}
+
+// ParseExpense reads free text, i.e. "14.99 pizza last friday", into a draft expense: POST /expenses/parse
+// Nothing is recorded, the draft is for the user to check first
+func (h *GinHandler) ParseExpense(c *gin.Context) {
+	var reqBody ParseExpenseRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	draft, err := h.Parser.ParseDraft(c.Request.Context(), reqBody.Text, time.Now())
+	if err != nil {
+		if errors.Is(err, quickadd.ErrUnreadable) {
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Unprocessable Entity: " + err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, DraftExpenseResponse{
+		OccuredAt:   RFC3339Time{Time: draft.OccuredAt},
+		Description: draft.Description,
+		Amount:      draft.Amount,
+	})
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// maxPooledBuffer keeps the odd huge response, i.e. an unpaged export, from pinning its buffer in the pool
+const maxPooledBuffer = 1 << 20
+
+// flushSize is how much of a streamed array is encoded before it is written out
+const flushSize = 32 << 10
+
+// jsonBuffers are reused across responses, so encoding a list doesn't grow a new buffer every request
+var jsonBuffers = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+func getBuffer() *bytes.Buffer {
+	return jsonBuffers.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuffer {
+		buf.Reset()
+		jsonBuffers.Put(buf)
+	}
+}
+
+// writeJSONContentType is the content type c.JSON sends
+func writeJSONContentType(w http.ResponseWriter) {
+	header := w.Header()
+	if val := header["Content-Type"]; len(val) == 0 {
+		header["Content-Type"] = []string{"application/json; charset=utf-8"}
+	}
+}
+
+// pooledJSON renders like gin's c.JSON, but encodes into a pooled buffer instead of allocating one per response
+type pooledJSON struct {
+	Data any
+}
+
+// Render implements gin's render.Render
+func (r pooledJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := json.NewEncoder(buf).Encode(r.Data); err != nil {
+		return err
+	}
+
+	// Encode ends with a newline, which c.JSON doesn't send
+	_, err := w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return err
+}
+
+// WriteContentType implements gin's render.Render
+func (r pooledJSON) WriteContentType(w http.ResponseWriter) {
+	writeJSONContentType(w)
+}
+
+// jsonArray renders Items as a JSON array, mapping and encoding one element at a time and writing them out
+// every flushSize bytes, so neither the responses nor their encoding are ever held in memory whole.
+// The bytes sent are the same as c.JSON sends for the mapped slice. An element failing to map or encode
+// after the first write cuts the response short, since the status has already been sent.
+type jsonArray[T any] struct {
+	Items   []T
+	Element func(T) (any, error)
+}
+
+// Render implements gin's render.Render
+func (r jsonArray[T]) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	enc := json.NewEncoder(buf)
+	buf.WriteByte('[')
+	for i, item := range r.Items {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		elem, err := r.Element(item)
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(elem); err != nil {
+			return err
+		}
+		// Encode ends every element with a newline
+		buf.Truncate(buf.Len() - 1)
+
+		if buf.Len() >= flushSize {
+			if _, err := w.Write(buf.Bytes()); err != nil {
+				return err
+			}
+			buf.Reset()
+		}
+	}
+	buf.WriteByte(']')
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteContentType implements gin's render.Render
+func (r jsonArray[T]) WriteContentType(w http.ResponseWriter) {
+	writeJSONContentType(w)
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/search"
+)
+
+// === Handler Type
+
+// SearchHandler serves fuzzy search over the expense descriptions, from the search index
+type SearchHandler struct {
+	Index        *search.Index
+	MaxPageLimit int
+}
+
+func NewSearchHandler(index *search.Index) *SearchHandler {
+	return &SearchHandler{Index: index, MaxPageLimit: MaxPageLimit}
+}
+
+// === Endpoint Hanlders ===
+
+// SearchExpenses finds the expenses whose descriptions have every word of ?q=, allowing for typos,
+// best matches first. It pages with ?limit= and ?offset= like GET /expenses
+func (h *SearchHandler) SearchExpenses(c *gin.Context) {
+	pagination, err := ParsePagination(c, h.MaxPageLimit)
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+
+	found, err := h.Index.Search(c.Request.Context(), c.Query("q"), pagination.Limit, pagination.Offset)
+	if err != nil {
+		if errors.Is(err, search.ErrEmptyQuery) {
+			abortWithParamError(c, &ParamError{Param: "q", Reason: "must have something to search for"})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	resp := make([]*ExpenseResponse, 0, len(found))
+	for _, exp := range found {
+		resp = append(resp, expenseToResponse(exp))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
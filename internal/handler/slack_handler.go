@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+	"github.com/nicholasss/expense-tracker-api/internal/slack"
+)
+
+// maxSlackBody is more than any slash command payload, which Slack caps well below it
+const maxSlackBody = 64 << 10
+
+// === Handler Type
+
+// SlackHandler answers the /expense slash command. Requests are checked against the app's signing secret,
+// since Slack can't send one of our tokens
+type SlackHandler struct {
+	Service       expenses.Service
+	SigningSecret []byte
+
+	// Users maps Slack user ids to ours, expenses from a mapped Slack user are theirs
+	Users map[string]int
+	// RequireUser refuses Slack users missing from Users instead of recording unowned expenses,
+	// it is set when auth is enabled
+	RequireUser bool
+
+	now func() time.Time
+}
+
+func NewSlackHandler(service expenses.Service, signingSecret string, users map[string]int, requireUser bool) *SlackHandler {
+	return &SlackHandler{
+		Service:       service,
+		SigningSecret: []byte(signingSecret),
+		Users:         users,
+		RequireUser:   requireUser,
+		now:           time.Now,
+	}
+}
+
+// == Endpoint Types ==
+
+// SlackResponse is the reply to a slash command, ephemeral replies are only shown to whoever sent it
+type SlackResponse struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+// reply answers the command with text only its sender sees. Slack shows anything but a 200 as a
+// failed command without the text, so problems with the command itself are 200s too
+func reply(c *gin.Context, text string) {
+	c.JSON(http.StatusOK, SlackResponse{ResponseType: "ephemeral", Text: text})
+}
+
+// === Endpoint Hanlders ===
+
+// Command records the expense in a signed slash command: POST /slack/commands
+func (h *SlackHandler) Command(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxSlackBody))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: unable to read body"})
+		return
+	}
+
+	timestamp, signature := c.GetHeader("X-Slack-Request-Timestamp"), c.GetHeader("X-Slack-Signature")
+	if err := slack.Verify(h.SigningSecret, timestamp, signature, body, h.now()); err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: " + err.Error()})
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: body must be form encoded"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	slackUser := form.Get("user_id")
+	if userID, ok := h.Users[slackUser]; ok {
+		ctx = auth.WithUserID(ctx, userID)
+	} else if h.RequireUser {
+		reply(c, fmt.Sprintf("Your Slack account isn't linked to an expense tracker user, ask an admin to add %s to SLACK_USERS", slackUser))
+		return
+	}
+
+	command, err := slack.ParseCommand(form.Get("text"))
+	if errors.Is(err, slack.ErrHelp) {
+		reply(c, slack.Usage)
+		return
+	}
+	if err != nil {
+		reply(c, err.Error()+". "+slack.Usage)
+		return
+	}
+
+	exp, err := h.Service.NewExpense(ctx, h.now(), command.Description, money.New(command.Amount, h.Service.Currency()), 0)
+	if errors.Is(err, expenses.ErrInvalidAmount) {
+		reply(c, "The amount needs to be more than 0")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to record Slack expense: %v", err)
+		reply(c, "Unable to record the expense right now, try again in a bit")
+		return
+	}
+
+	reply(c, fmt.Sprintf("Recorded %s for %s as expense %d", exp.Amount.Format(), slack.Escape(exp.Description), exp.ID))
+}
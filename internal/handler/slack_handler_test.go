@@ -0,0 +1,167 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/handler"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+	"github.com/nicholasss/expense-tracker-api/internal/slack"
+)
+
+// ownerRecordingService records who each expense was created for
+type ownerRecordingService struct {
+	expenses.Service
+	created []*expenses.Expense
+}
+
+func (s *ownerRecordingService) Currency() string {
+	return "EUR"
+}
+
+func (s *ownerRecordingService) NewExpense(ctx context.Context, occuredAt time.Time, description string, amount money.Money, categoryID int) (*expenses.Expense, error) {
+	if !amount.IsPositive() {
+		return nil, expenses.ErrInvalidAmount
+	}
+
+	ownerID, _ := auth.UserIDFromContext(ctx)
+	exp := &expenses.Expense{ID: len(s.created) + 1, OwnerID: ownerID, ExpenseOccuredAt: occuredAt, Description: description, Amount: amount}
+	s.created = append(s.created, exp)
+	return exp, nil
+}
+
+func TestSlackCommand(t *testing.T) {
+	const secret = "slack-signing-secret"
+
+	testTable := []struct {
+		name             string
+		inputUser        string
+		inputText        string
+		inputSecret      string // signs the request, the handler's secret when empty
+		inputRequireUser bool
+		wantStatus       int
+		wantText         string // contained in the reply
+		wantOwner        int    // of the created expense, -1 when none is created
+	}{
+		{
+			name:       "valid-mapped-user",
+			inputUser:  "U012AB3CD",
+			inputText:  "12.50 lunch with the team",
+			wantStatus: http.StatusOK,
+			wantText:   "Recorded €12.50 for lunch with the team as expense 1",
+			wantOwner:  7,
+		},
+		{
+			name:       "valid-unmapped-user-unowned",
+			inputUser:  "U999",
+			inputText:  "3 coffee",
+			wantStatus: http.StatusOK,
+			wantText:   "Recorded €3.00 for coffee",
+			wantOwner:  0,
+		},
+		{
+			name:             "invalid-unmapped-user-with-auth",
+			inputUser:        "U999",
+			inputText:        "3 coffee",
+			inputRequireUser: true,
+			wantStatus:       http.StatusOK,
+			wantText:         "ask an admin to add U999 to SLACK_USERS",
+			wantOwner:        -1,
+		},
+		{
+			name:       "valid-help",
+			inputUser:  "U012AB3CD",
+			inputText:  "help",
+			wantStatus: http.StatusOK,
+			wantText:   slack.Usage,
+			wantOwner:  -1,
+		},
+		{
+			name:       "invalid-amount-replies-with-usage",
+			inputUser:  "U012AB3CD",
+			inputText:  "lunch 12.50",
+			wantStatus: http.StatusOK,
+			wantText:   slack.Usage,
+			wantOwner:  -1,
+		},
+		{
+			name:       "invalid-zero-amount",
+			inputUser:  "U012AB3CD",
+			inputText:  "0 nothing",
+			wantStatus: http.StatusOK,
+			wantText:   "needs to be more than 0",
+			wantOwner:  -1,
+		},
+		{
+			name:        "invalid-signature",
+			inputUser:   "U012AB3CD",
+			inputText:   "12.50 lunch",
+			inputSecret: "not the secret",
+			wantStatus:  http.StatusUnauthorized,
+			wantOwner:   -1,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			service := &ownerRecordingService{}
+			h := handler.NewSlackHandler(service, secret, map[string]int{"U012AB3CD": 7}, testCase.inputRequireUser)
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.POST("/slack/commands", h.Command)
+
+			body := url.Values{
+				"command": {"/expense"},
+				"user_id": {testCase.inputUser},
+				"text":    {testCase.inputText},
+			}.Encode()
+			signingSecret := testCase.inputSecret
+			if signingSecret == "" {
+				signingSecret = secret
+			}
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+			req := httptest.NewRequest(http.MethodPost, "/slack/commands", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+			req.Header.Set("X-Slack-Signature", slack.Sign([]byte(signingSecret), timestamp, []byte(body)))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body.String())
+			}
+
+			if testCase.wantText != "" {
+				var got handler.SlackResponse
+				if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+					t.Fatalf("unable to decode reply: %v", err)
+				}
+				if got.ResponseType != "ephemeral" || !strings.Contains(got.Text, testCase.wantText) {
+					t.Errorf("got reply: %+v, want an ephemeral one containing: %q", got, testCase.wantText)
+				}
+			}
+
+			if testCase.wantOwner < 0 {
+				if len(service.created) != 0 {
+					t.Errorf("got %d expenses created, want none", len(service.created))
+				}
+				return
+			}
+			if len(service.created) != 1 || service.created[0].OwnerID != testCase.wantOwner {
+				t.Errorf("got created: %+v, want one expense owned by %d", service.created, testCase.wantOwner)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/opstats"
+)
+
+// === Handler Type
+
+// StatsHandler serves the admin dashboard's numbers
+type StatsHandler struct {
+	Stats *opstats.Service
+}
+
+func NewStatsHandler(stats *opstats.Service) *StatsHandler {
+	return &StatsHandler{Stats: stats}
+}
+
+// == Endpoint Types ==
+
+// RouteRateResponse is how often one route has been requested, recent counts the window only
+type RouteRateResponse struct {
+	Route        string  `json:"route"`
+	Requests     int64   `json:"requests"`
+	ServerErrors int64   `json:"server_errors"`
+	Recent       int64   `json:"recent"`
+	PerMinute    float64 `json:"per_minute"`
+}
+
+// OutboxStatsResponse counts the webhook events still to be delivered, and those given up on
+type OutboxStatsResponse struct {
+	Pending int64 `json:"pending"`
+	Dead    int64 `json:"dead"`
+}
+
+// StatsResponse is utilized specifically for the GetStats endpoint: GET /admin/stats
+type StatsResponse struct {
+	StartedAt     RFC3339Time          `json:"started_at"`
+	UptimeSeconds int64                `json:"uptime_seconds"`
+	Records       map[string]int64     `json:"records"`
+	DatabaseBytes int64                `json:"database_bytes"`
+	WindowSeconds int64                `json:"window_seconds"`
+	Routes        []*RouteRateResponse `json:"routes"`
+	Outbox        *OutboxStatsResponse `json:"outbox,omitempty"` // only with the webhook enabled
+}
+
+// === Endpoint Hanlders ===
+
+// GetStats reports record counts, the database size, and per route request rates since the server started
+func (h *StatsHandler) GetStats(c *gin.Context) {
+	stats, err := h.Stats.Stats(c.Request.Context())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	routes := make([]*RouteRateResponse, 0, len(stats.Routes))
+	for _, rate := range stats.Routes {
+		routes = append(routes, &RouteRateResponse{
+			Route:        rate.Route,
+			Requests:     rate.Requests,
+			ServerErrors: rate.ServerErrors,
+			Recent:       rate.Recent,
+			PerMinute:    rate.PerMinute,
+		})
+	}
+
+	var outbox *OutboxStatsResponse
+	if stats.Outbox != nil {
+		outbox = &OutboxStatsResponse{Pending: stats.Outbox.Pending, Dead: stats.Outbox.Dead}
+	}
+
+	c.JSON(http.StatusOK, StatsResponse{
+		StartedAt:     RFC3339Time{Time: stats.StartedAt},
+		UptimeSeconds: int64(stats.Uptime / time.Second),
+		Records:       stats.Records,
+		DatabaseBytes: stats.DatabaseBytes,
+		WindowSeconds: int64(stats.Window / time.Second),
+		Routes:        routes,
+		Outbox:        outbox,
+	})
+}
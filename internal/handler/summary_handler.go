@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/fxrates"
+	"github.com/nicholasss/expense-tracker-api/internal/i18n"
+)
+
+// summaryRanges maps the ?range= values to the service's time ranges
+var summaryRanges = map[string]expenses.SummaryTimeRange{
+	"all":        expenses.AllExpenses,
+	"this-month": expenses.ThisMonth,
+	"month":      expenses.CustomMonth,
+	"this-year":  expenses.ThisYear,
+	"year":       expenses.CustomYear,
+	"months":     expenses.CustomYearMonthRange,
+	"period":     expenses.CustomPeriod,
+}
+
+// summaryLabels name each range for display, they are translated to the request's locale
+var summaryLabels = map[string]string{
+	"all":        "All Time",
+	"this-month": "This Month",
+	"month":      "Selected Month",
+	"this-year":  "This Year",
+	"year":       "Selected Year",
+	"months":     "Selected Months",
+	"period":     "Selected Period",
+}
+
+// summaryGroupings maps the ?group_by= values to the service's groupings
+var summaryGroupings = map[string]expenses.Grouping{
+	"":      expenses.GroupByRange,
+	"day":   expenses.GroupByDay,
+	"month": expenses.GroupByMonth,
+	"year":  expenses.GroupByYear,
+}
+
+// groupingNames are how each grouping is named in responses
+var groupingNames = map[expenses.Grouping]string{
+	expenses.GroupByDay:   "day",
+	expenses.GroupByMonth: "month",
+	expenses.GroupByYear:  "year",
+}
+
+// == Endpoint Types ==
+
+// PeriodTotalResponse is the total of one day, month, or year of a summary
+type PeriodTotalResponse struct {
+	Start  RFC3339Time `json:"start"`
+	Amount int64       `json:"amount"`
+	Count  int         `json:"count"`
+}
+
+// ConversionResponse is how the amounts of a converted summary were converted.
+// Stale rates are still used, the client decides whether they are good enough
+type ConversionResponse struct {
+	From      string      `json:"from"`
+	To        string      `json:"to"`
+	Rate      float64     `json:"rate"`
+	RatesAsOf RFC3339Time `json:"rates_as_of"`
+	FetchedAt RFC3339Time `json:"fetched_at"`
+	Stale     bool        `json:"stale"`
+}
+
+// SummaryResponse is the response of GET /expenses/summary, the bounds are left out when unbounded
+type SummaryResponse struct {
+	Label      string                 `json:"label"` // in the Accept-Language locale
+	From       *RFC3339Time           `json:"from,omitempty"`
+	To         *RFC3339Time           `json:"to,omitempty"`
+	Amount     int64                  `json:"amount"`
+	Currency   string                 `json:"currency"` // of every amount, after conversion
+	Count      int                    `json:"count"`
+	GroupBy    string                 `json:"group_by"`
+	Periods    []*PeriodTotalResponse `json:"periods"`
+	Conversion *ConversionResponse    `json:"conversion,omitempty"`
+}
+
+// optionalTime is nil for the zero time
+func optionalTime(t time.Time) *RFC3339Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &RFC3339Time{Time: t}
+}
+
+// === Endpoint Hanlders ===
+
+// GetSummary totals the expenses in ?range=, one of all, this-month, month, this-year, year, months, or period.
+// month, year, and months take ?period=, i.e. 2025-03, 2025, or 2025-01:2025-06. period takes any of
+// 2025, 2025-03, 2025-03-14, ISO week 2025-W11, or quarter 2025-Q1, and so can either end of months.
+// ?group_by= is one of day, month, or year, when the range's own breakdown isn't wanted.
+// ?timezone= is the IANA zone or offset whose days, months, and years are totaled, UTC when left out.
+// ?category_id=, ?min_amount=, and ?max_amount= narrow down the expenses, like they do for the list.
+// ?currency= converts the amounts with the cached exchange rates, i.e. USD
+func (h *GinHandler) GetSummary(c *gin.Context) {
+	rangeName, err := ParseEnumQuery(c, "range", "all", "all", "this-month", "month", "this-year", "year", "months", "period")
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+	groupBy, err := ParseEnumQuery(c, "group_by", "", "day", "month", "year")
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+
+	query, err := parseSummaryQuery(c)
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+	query.Range, query.GroupBy = summaryRanges[rangeName], summaryGroupings[groupBy]
+
+	summary, err := h.Service.SummarizeExpenses(c.Request.Context(), query)
+	if err != nil {
+		var timeErr *expenses.ErrInvalidTime
+		switch {
+		case errors.As(err, &timeErr):
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: period: " + err.Error()})
+		case errors.Is(err, fxrates.ErrNoRates):
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Service Unavailable: " + err.Error()})
+		default:
+			abortWithServiceError(c, err)
+		}
+		return
+	}
+
+	resp := SummaryResponse{
+		Label:    i18n.T(c.Request.Context(), summaryLabels[rangeName]),
+		From:     optionalTime(summary.From),
+		To:       optionalTime(summary.To),
+		Amount:   summary.Amount.Minor,
+		Currency: summary.Amount.Currency,
+		Count:    summary.Count,
+		GroupBy:  groupingNames[summary.Grouping],
+		Periods:  make([]*PeriodTotalResponse, 0, len(summary.Periods)),
+	}
+	for _, period := range summary.Periods {
+		resp.Periods = append(resp.Periods, &PeriodTotalResponse{
+			Start:  RFC3339Time{Time: period.Start},
+			Amount: period.Amount.Minor,
+			Count:  period.Count,
+		})
+	}
+
+	if conversion := summary.Conversion; conversion != nil {
+		resp.Conversion = &ConversionResponse{
+			From:      conversion.From,
+			To:        conversion.To,
+			Rate:      conversion.Rate,
+			RatesAsOf: RFC3339Time{Time: conversion.AsOf},
+			FetchedAt: RFC3339Time{Time: conversion.FetchedAt},
+			Stale:     conversion.Stale,
+		}
+	}
+
+	c.Render(http.StatusOK, pooledJSON{Data: resp})
+}
+
+// parseSummaryQuery reads the zone, currency, and filter of a summary, the range is left to the caller
+func parseSummaryQuery(c *gin.Context) (expenses.SummaryQuery, error) {
+	query := expenses.SummaryQuery{Period: c.Query("period"), Currency: c.Query("currency")}
+
+	if name := c.Query("timezone"); name != "" {
+		zone, err := expenses.LoadZone(name)
+		if err != nil {
+			return expenses.SummaryQuery{}, &ParamError{Param: "timezone", Reason: "must be an IANA zone like Europe/Berlin, or an offset like +02:00"}
+		}
+		query.Location = zone
+	}
+
+	categoryID, err := ParseIntQuery(c, "category_id", 0, 1, math.MaxInt)
+	if err != nil {
+		return expenses.SummaryQuery{}, err
+	}
+	minAmount, err := ParseIntQuery(c, "min_amount", 0, 1, math.MaxInt)
+	if err != nil {
+		return expenses.SummaryQuery{}, err
+	}
+	maxAmount, err := ParseIntQuery(c, "max_amount", 0, 1, math.MaxInt)
+	if err != nil {
+		return expenses.SummaryQuery{}, err
+	}
+	query.Filter = expenses.SummaryFilter{CategoryID: categoryID, MinAmount: int64(minAmount), MaxAmount: int64(maxAmount)}
+
+	return query, nil
+}
@@ -0,0 +1,602 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/audit"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/users"
+)
+
+// maxScopedTokenTTL is the longest a scoped token can be issued for, they are revoked by logging out everywhere
+const maxScopedTokenTTL = 90 * 24 * time.Hour
+
+// impersonationTTL is how long an admin can act as another user before asking again
+const impersonationTTL = time.Hour
+
+// === Handler Type
+
+// UserHandler issues tokens on login when Tokens is set, and records logins when Audit is set
+type UserHandler struct {
+	Service users.Service
+	Tokens  *auth.TokenIssuer
+	Audit   audit.Service
+}
+
+func NewUserHandler(service users.Service, tokens *auth.TokenIssuer, recorder audit.Service) *UserHandler {
+	return &UserHandler{Service: service, Tokens: tokens, Audit: recorder}
+}
+
+// == Endpoint Types ==
+
+// RegisterRequest is utilized specifically for the Register endpoint: POST /users
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// LoginRequest is utilized specifically for the Login endpoint: POST /users/login.
+// Code is only needed once two-factor is enabled, it is an authenticator code or a recovery code
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code"`
+}
+
+// UpdateProfileRequest is utilized specifically for the UpdateMe endpoint: PATCH /users/me.
+// Fields left out are not changed, an empty locale goes back to the plain export formats
+type UpdateProfileRequest struct {
+	Name   *string `json:"name"`
+	Locale *string `json:"locale"`
+}
+
+// ChangePasswordRequest is utilized specifically for the ChangePassword endpoint: PUT /users/me/password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ChangeEmailRequest is utilized specifically for the RequestEmailChange endpoint: POST /users/me/email
+type ChangeEmailRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewEmail        string `json:"new_email" binding:"required"`
+}
+
+// ConfirmEmailRequest is utilized specifically for the ConfirmEmailChange endpoint: POST /users/email/confirm
+type ConfirmEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// SetupTOTPRequest is utilized specifically for the SetupTOTP endpoint: POST /users/me/2fa/setup
+type SetupTOTPRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+}
+
+// TOTPCodeRequest is utilized for the EnableTOTP and RegenerateRecoveryCodes endpoints:
+// POST /users/me/2fa/enable and POST /users/me/2fa/recovery-codes
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableTOTPRequest is utilized specifically for the DisableTOTP endpoint: POST /users/me/2fa/disable
+type DisableTOTPRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	Code            string `json:"code" binding:"required"`
+}
+
+// TOTPSetupResponse has what an authenticator app needs, URI is the otpauth:// payload for a QR code
+type TOTPSetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// RecoveryCodesResponse lists single use recovery codes, they can not be fetched again later
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// IssueTokenRequest is utilized specifically for the IssueToken endpoint: POST /users/me/tokens.
+// ExpiresIn is in seconds, the login token lifetime is used when it is 0
+type IssueTokenRequest struct {
+	Scopes    []string `json:"scopes" binding:"required"`
+	ExpiresIn int      `json:"expires_in"`
+}
+
+// TokenResponse is a scoped token, shown only once
+type TokenResponse struct {
+	Token     string       `json:"token"`
+	Scopes    []auth.Scope `json:"scopes"`
+	ExpiresAt RFC3339Time  `json:"expires_at"`
+}
+
+// ImpersonateRequest is utilized specifically for the Impersonate endpoint: POST /admin/users/:id/impersonate.
+// Reason is kept in the audit log, i.e. a support ticket number
+type ImpersonateRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ImpersonationResponse is a token for acting as User
+type ImpersonationResponse struct {
+	User *UserResponse `json:"user"`
+	TokenResponse
+}
+
+// UserResponse is the public profile of a user, it never includes the password hash
+type UserResponse struct {
+	ID        int         `json:"id"`
+	Email     string      `json:"email"`
+	Name      string      `json:"name"`
+	Locale    string      `json:"locale"` // empty for the plain export formats
+	CreatedAt RFC3339Time `json:"created_at"`
+}
+
+// LoginResponse includes a bearer token for the Authorization header when tokens are enabled
+type LoginResponse struct {
+	User      *UserResponse `json:"user"`
+	Token     string        `json:"token,omitempty"`
+	ExpiresAt *RFC3339Time  `json:"expires_at,omitempty"`
+}
+
+func userToResponse(user *users.User) *UserResponse {
+	return &UserResponse{
+		ID:        user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		Locale:    user.Locale,
+		CreatedAt: RFC3339Time{Time: user.CreatedAt},
+	}
+}
+
+// === Endpoint Hanlders ===
+
+func (h *UserHandler) Register(c *gin.Context) {
+	// request body bind
+	var reqBody RegisterRequest
+	err := c.ShouldBindJSON(&reqBody)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	// send to service layer
+	user, err := h.Service.Register(c.Request.Context(), reqBody.Email, reqBody.Name, reqBody.Password)
+	if err != nil {
+		if errors.Is(err, users.ErrInvalidEmail) || errors.Is(err, users.ErrWeakPassword) || errors.Is(err, users.ErrEmptyName) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+			return
+		} else if errors.Is(err, users.ErrEmailTaken) {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Conflict: " + err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, userToResponse(user))
+}
+
+func (h *UserHandler) Login(c *gin.Context) {
+	// request body bind
+	var reqBody LoginRequest
+	err := c.ShouldBindJSON(&reqBody)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	user, err := h.Service.Login(c.Request.Context(), reqBody.Email, reqBody.Password, reqBody.Code)
+	if err != nil {
+		if errors.Is(err, users.ErrInvalidCredentials) || errors.Is(err, users.ErrTOTPRequired) || errors.Is(err, users.ErrInvalidTOTP) {
+			recordLogin(c, h.Audit, audit.TypeLoginFailed, 0, reqBody.Email, err.Error(), http.StatusUnauthorized)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: " + err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	resp := LoginResponse{User: userToResponse(user)}
+	if h.Tokens != nil {
+		token, expiresAt, err := h.Tokens.Issue(user.ID, user.TokenGeneration)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+			return
+		}
+		resp.Token = token
+		resp.ExpiresAt = &RFC3339Time{Time: expiresAt}
+	}
+
+	recordLogin(c, h.Audit, audit.TypeLoginSucceeded, user.ID, user.Email, "password", http.StatusOK)
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetMe returns the profile of the authenticated user, it needs middleware.RequireAuth
+func (h *UserHandler) GetMe(c *gin.Context) {
+	userID, ok := auth.UserIDFromContext(c.Request.Context())
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, err := h.Service.GetProfile(c.Request.Context(), userID)
+	if err != nil {
+		// token for a user that has since been removed
+		if errors.Is(err, users.ErrUserNotFound) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: " + err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, userToResponse(user))
+}
+
+// abortWithAccountError maps the users errors from account changes to responses
+func abortWithAccountError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, users.ErrInvalidEmail) || errors.Is(err, users.ErrWeakPassword) ||
+		errors.Is(err, users.ErrEmptyName) || errors.Is(err, users.ErrInvalidEmailChange) || errors.Is(err, users.ErrInvalidLocale) ||
+		errors.Is(err, users.ErrEmailChangeDisabled):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+	case errors.Is(err, users.ErrWrongPassword) || errors.Is(err, users.ErrInvalidTOTP) || errors.Is(err, users.ErrTOTPRequired):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: " + err.Error()})
+	case errors.Is(err, users.ErrEmailTaken) || errors.Is(err, users.ErrTOTPNotEnrolled) || errors.Is(err, users.ErrTOTPAlreadyEnabled):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Conflict: " + err.Error()})
+	case errors.Is(err, users.ErrUserNotFound):
+		// token for a user that has since been removed
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: " + err.Error()})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+	}
+}
+
+// UpdateMe changes the authenticated user's profile, it needs middleware.RequireAuth
+func (h *UserHandler) UpdateMe(c *gin.Context) {
+	userID, ok := auth.UserIDFromContext(c.Request.Context())
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var reqBody UpdateProfileRequest
+	err := c.ShouldBindJSON(&reqBody)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	user, err := h.Service.UpdateProfile(c.Request.Context(), userID, users.ProfileUpdate{Name: reqBody.Name, Locale: reqBody.Locale})
+	if err != nil {
+		abortWithAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, userToResponse(user))
+}
+
+// ChangePassword needs the current password as well as the token, it needs middleware.RequireAuth.
+// Every token is revoked afterwards, including the one used here, so the client logs in again.
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	userID, ok := auth.UserIDFromContext(c.Request.Context())
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var reqBody ChangePasswordRequest
+	err := c.ShouldBindJSON(&reqBody)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	err = h.Service.ChangePassword(c.Request.Context(), userID, reqBody.CurrentPassword, reqBody.NewPassword)
+	if err != nil {
+		abortWithAccountError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RequestEmailChange sends a confirmation token to the new address, it needs middleware.RequireAuth.
+// The email is unchanged until the token is confirmed, so the response is 202.
+func (h *UserHandler) RequestEmailChange(c *gin.Context) {
+	userID, ok := auth.UserIDFromContext(c.Request.Context())
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var reqBody ChangeEmailRequest
+	err := c.ShouldBindJSON(&reqBody)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	err = h.Service.RequestEmailChange(c.Request.Context(), userID, reqBody.CurrentPassword, reqBody.NewEmail)
+	if err != nil {
+		abortWithAccountError(c, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// ConfirmEmailChange applies the change with the token sent to the new address.
+// It is public, since the token is only known to whoever can read the new inbox.
+func (h *UserHandler) ConfirmEmailChange(c *gin.Context) {
+	var reqBody ConfirmEmailRequest
+	err := c.ShouldBindJSON(&reqBody)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	user, err := h.Service.ConfirmEmailChange(c.Request.Context(), reqBody.Token)
+	if err != nil {
+		abortWithAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, userToResponse(user))
+}
+
+// LogoutEverywhere revokes every token the authenticated user holds, it needs middleware.RequireAuth
+func (h *UserHandler) LogoutEverywhere(c *gin.Context) {
+	userID, ok := auth.UserIDFromContext(c.Request.Context())
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	err := h.Service.RevokeTokens(c.Request.Context(), userID)
+	if err != nil {
+		abortWithAccountError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeUserTokens lets an admin log another user out everywhere, i.e. when their token was stolen
+func (h *UserHandler) RevokeUserTokens(c *gin.Context) {
+	userID, err := ParseIDParam(c, "id")
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+
+	err = h.Service.RevokeTokens(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not Found: " + err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SetupTOTP starts two-factor enrollment, it needs middleware.RequireAuth.
+// Login is unaffected until EnableTOTP confirms a code from the authenticator.
+func (h *UserHandler) SetupTOTP(c *gin.Context) {
+	userID, ok := auth.UserIDFromContext(c.Request.Context())
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var reqBody SetupTOTPRequest
+	err := c.ShouldBindJSON(&reqBody)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	setup, err := h.Service.SetupTOTP(c.Request.Context(), userID, reqBody.CurrentPassword)
+	if err != nil {
+		abortWithAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, TOTPSetupResponse{Secret: setup.Secret, URI: setup.URI})
+}
+
+// EnableTOTP turns on two-factor with a code from the authenticator, it needs middleware.RequireAuth.
+// The recovery codes are in the response, and only there.
+func (h *UserHandler) EnableTOTP(c *gin.Context) {
+	userID, ok := auth.UserIDFromContext(c.Request.Context())
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var reqBody TOTPCodeRequest
+	err := c.ShouldBindJSON(&reqBody)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	codes, err := h.Service.EnableTOTP(c.Request.Context(), userID, reqBody.Code)
+	if err != nil {
+		abortWithAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTP turns off two-factor, it needs middleware.RequireAuth
+func (h *UserHandler) DisableTOTP(c *gin.Context) {
+	userID, ok := auth.UserIDFromContext(c.Request.Context())
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var reqBody DisableTOTPRequest
+	err := c.ShouldBindJSON(&reqBody)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	err = h.Service.DisableTOTP(c.Request.Context(), userID, reqBody.CurrentPassword, reqBody.Code)
+	if err != nil {
+		abortWithAccountError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes, it needs middleware.RequireAuth
+func (h *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, ok := auth.UserIDFromContext(c.Request.Context())
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var reqBody TOTPCodeRequest
+	err := c.ShouldBindJSON(&reqBody)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	codes, err := h.Service.RegenerateRecoveryCodes(c.Request.Context(), userID, reqBody.Code)
+	if err != nil {
+		abortWithAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// IssueToken creates a token limited to some scopes, i.e. read-only for a dashboard.
+// It needs middleware.RequireAuth with an unscoped token, so scoped tokens can't mint more.
+func (h *UserHandler) IssueToken(c *gin.Context) {
+	userID, ok := auth.UserIDFromContext(c.Request.Context())
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var reqBody IssueTokenRequest
+	err := c.ShouldBindJSON(&reqBody)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	scopes, err := auth.ParseScopes(reqBody.Scopes)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	ttl := h.Tokens.TTL()
+	if reqBody.ExpiresIn != 0 {
+		ttl = time.Duration(reqBody.ExpiresIn) * time.Second
+		if ttl < 0 || ttl > maxScopedTokenTTL {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: expires_in needs to be between 1 and 7776000 seconds"})
+			return
+		}
+	}
+
+	// issued at the current generation, so logging out everywhere revokes it too
+	generation, err := h.Service.TokenGeneration(c.Request.Context(), userID)
+	if err != nil {
+		abortWithAccountError(c, err)
+		return
+	}
+
+	token, expiresAt, err := h.Tokens.IssueScoped(userID, generation, scopes, ttl)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, TokenResponse{Token: token, Scopes: scopes, ExpiresAt: RFC3339Time{Time: expiresAt}})
+}
+
+// Impersonate lets an admin act as another user for support, it needs middleware.RequireAdmin.
+// The token has every scope but account, so the admin can see and fix expenses but not take over
+// the account, and every request made with it is recorded in the audit log under the admin's id.
+func (h *UserHandler) Impersonate(c *gin.Context) {
+	actorID, ok := auth.UserIDFromContext(c.Request.Context())
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	userID, err := ParseIDParam(c, "id")
+	if err != nil {
+		abortWithParamError(c, err)
+		return
+	}
+
+	var reqBody ImpersonateRequest
+	err = c.ShouldBindJSON(&reqBody)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Bad Request: " + err.Error()})
+		return
+	}
+
+	user, err := h.Service.GetProfile(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not Found: " + err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	// admins can already see everything, acting as one would only hide who did what
+	if user.ID == actorID || user.IsAdmin {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: admins can not be impersonated"})
+		return
+	}
+
+	token, expiresAt, err := h.Tokens.IssueImpersonation(actorID, user.ID, user.TokenGeneration, auth.IssuableScopes, impersonationTTL)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	if h.Audit != nil {
+		h.Audit.Record(c.Request.Context(), audit.Event{
+			Type:      audit.TypeImpersonationStarted,
+			UserID:    user.ID,
+			ActorID:   actorID,
+			IP:        c.ClientIP(),
+			RemoteIP:  c.RemoteIP(),
+			UserAgent: c.Request.UserAgent(),
+			Method:    c.Request.Method,
+			Path:      c.FullPath(),
+			Status:    http.StatusCreated,
+			Detail:    reqBody.Reason,
+		})
+	}
+
+	c.JSON(http.StatusCreated, ImpersonationResponse{
+		User: userToResponse(user),
+		TokenResponse: TokenResponse{
+			Token:     token,
+			Scopes:    auth.IssuableScopes,
+			ExpiresAt: RFC3339Time{Time: expiresAt},
+		},
+	})
+}
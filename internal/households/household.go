@@ -0,0 +1,56 @@
+// Package households lets several users share one expense book, and reports what each member contributed
+package households
+
+import (
+	"errors"
+	"time"
+)
+
+// Role is what a member is allowed to do in their household
+type Role string
+
+const (
+	// RoleOwner created the household and manages its members
+	RoleOwner Role = "owner"
+	// RoleMember shares the expense book, and can leave
+	RoleMember Role = "member"
+)
+
+// Household is a group of users sharing an expense book.
+//
+// ID & CreatedAt is set in the repository layer
+type Household struct {
+	ID        int
+	Name      string
+	CreatedAt time.Time
+}
+
+// Member is a user in a household, a user can only be in one household at a time
+type Member struct {
+	UserID   int
+	Email    string
+	Name     string
+	Role     Role
+	JoinedAt time.Time
+}
+
+// Contribution is how much one member spent into the shared book
+type Contribution struct {
+	UserID int
+	Name   string
+	Total  int64 // cents total
+	Count  int   // number of expenses
+}
+
+// These errors are used in the validation step of the Service
+var (
+	ErrEmptyName        = errors.New("household name cannot be empty")
+	ErrAlreadyMember    = errors.New("user is already in a household")
+	ErrNotMember        = errors.New("user is not in a household")
+	ErrNotOwner         = errors.New("only the household owner can manage members")
+	ErrOwnerCannotLeave = errors.New("the household owner cannot be removed")
+	ErrUserNotFound     = errors.New("no user is registered with that email")
+	ErrMemberNotFound   = errors.New("user is not a member of this household")
+	ErrSettleWithSelf   = errors.New("cannot settle with yourself")
+	ErrInvalidAmount    = errors.New("settlement amount must be more than 0")
+)
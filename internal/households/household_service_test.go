@@ -0,0 +1,430 @@
+package households_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/households"
+	"github.com/nicholasss/expense-tracker-api/internal/users"
+)
+
+// mockRepository implements the Repository interface to test the service layer
+// it only tracks memberships, which is what the service makes decisions on,
+// along with fixed contributions and the settlements added for the balances
+type mockRepository struct {
+	lastID        int
+	members       map[int]*households.Member // by user id
+	homes         map[int]int                // user id to household id
+	contributions []*households.Contribution
+	settlements   []*households.Settlement
+}
+
+func (r *mockRepository) Create(ctx context.Context, name string, ownerID int) (*households.Household, error) {
+	if _, ok := r.homes[ownerID]; ok {
+		return nil, households.ErrAlreadyMember
+	}
+
+	r.lastID += 1
+	r.homes[ownerID] = r.lastID
+	r.members[ownerID] = &households.Member{UserID: ownerID, Role: households.RoleOwner}
+	return &households.Household{ID: r.lastID, Name: name}, nil
+}
+
+func (r *mockRepository) GetForUser(ctx context.Context, userID int) (*households.Household, households.Role, error) {
+	householdID, ok := r.homes[userID]
+	if !ok {
+		return nil, "", households.ErrNotMember
+	}
+	return &households.Household{ID: householdID}, r.members[userID].Role, nil
+}
+
+func (r *mockRepository) Members(ctx context.Context, householdID int) ([]*households.Member, error) {
+	members := make([]*households.Member, 0)
+	for userID, member := range r.members {
+		if r.homes[userID] == householdID {
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+func (r *mockRepository) AddMember(ctx context.Context, householdID, userID int, role households.Role) error {
+	if _, ok := r.homes[userID]; ok {
+		return households.ErrAlreadyMember
+	}
+	r.homes[userID] = householdID
+	r.members[userID] = &households.Member{UserID: userID, Role: role}
+	return nil
+}
+
+func (r *mockRepository) RemoveMember(ctx context.Context, householdID, userID int) error {
+	if r.homes[userID] != householdID {
+		return households.ErrMemberNotFound
+	}
+	delete(r.homes, userID)
+	delete(r.members, userID)
+	return nil
+}
+
+func (r *mockRepository) Contributions(ctx context.Context, householdID int, from, to time.Time) ([]*households.Contribution, error) {
+	return append(make([]*households.Contribution, 0), r.contributions...), nil
+}
+
+func (r *mockRepository) AddSettlement(ctx context.Context, householdID int, settlement *households.Settlement) (*households.Settlement, error) {
+	added := *settlement
+	added.ID = len(r.settlements) + 1
+	r.settlements = append(r.settlements, &added)
+	return &added, nil
+}
+
+func (r *mockRepository) Settlements(ctx context.Context, householdID int) ([]*households.Settlement, error) {
+	return append(make([]*households.Settlement, 0), r.settlements...), nil
+}
+
+// mockUsers finds users by email
+type mockUsers map[string]*users.User
+
+func (u mockUsers) GetByEmail(ctx context.Context, email string) (*users.User, error) {
+	user, ok := u[email]
+	if !ok {
+		return nil, users.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// setupTestService sets up a household owned by user 1 with user 2 as a member, user 3 is not in one.
+// contributions are what the household's book reports, whoever is in it
+func setupTestService(t *testing.T, contributions ...*households.Contribution) *households.HouseholdService {
+	t.Helper()
+
+	repo := &mockRepository{
+		members:       make(map[int]*households.Member),
+		homes:         make(map[int]int),
+		contributions: contributions,
+	}
+	lookup := mockUsers{
+		"ada@example.com":   {ID: 1, Email: "ada@example.com", Name: "Ada"},
+		"grace@example.com": {ID: 2, Email: "grace@example.com", Name: "Grace"},
+		"linus@example.com": {ID: 3, Email: "linus@example.com", Name: "Linus"},
+	}
+	serv := households.NewService(repo, lookup)
+
+	if _, err := serv.Create(t.Context(), 1, "flat 4b"); err != nil {
+		t.Fatalf("unable to setup household: %v", err)
+	}
+	if _, err := serv.AddMember(t.Context(), 1, "grace@example.com"); err != nil {
+		t.Fatalf("unable to setup household member: %v", err)
+	}
+
+	return serv
+}
+
+func TestAddMember(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputActor  int
+		inputEmail  string
+		expectError bool
+		wantError   error
+	}{
+		{
+			name:        "valid-owner-adds-by-email",
+			inputActor:  1,
+			inputEmail:  " Linus@Example.com ",
+			expectError: false,
+			wantError:   nil,
+		},
+		{
+			name:        "invalid-member-adds",
+			inputActor:  2,
+			inputEmail:  "linus@example.com",
+			expectError: true,
+			wantError:   households.ErrNotOwner,
+		},
+		{
+			name:        "invalid-not-in-household",
+			inputActor:  3,
+			inputEmail:  "ada@example.com",
+			expectError: true,
+			wantError:   households.ErrNotMember,
+		},
+		{
+			name:        "invalid-unknown-email",
+			inputActor:  1,
+			inputEmail:  "nobody@example.com",
+			expectError: true,
+			wantError:   households.ErrUserNotFound,
+		},
+		{
+			name:        "invalid-already-member",
+			inputActor:  1,
+			inputEmail:  "grace@example.com",
+			expectError: true,
+			wantError:   households.ErrAlreadyMember,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			serv := setupTestService(t)
+
+			gotMember, gotErr := serv.AddMember(t.Context(), testCase.inputActor, testCase.inputEmail)
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("AddMember() got error: '%v', expected error: '%v'", gotErr, testCase.wantError)
+			}
+
+			// checking error type if its not nil
+			if gotErr != nil {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+				return
+			}
+
+			if gotMember.UserID != 3 || gotMember.Role != households.RoleMember {
+				t.Errorf("got member: %+v, want user 3 as a member", gotMember)
+			}
+		})
+	}
+}
+
+func TestRemoveMember(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputActor  int
+		inputUser   int
+		expectError bool
+		wantError   error
+	}{
+		{
+			name:        "valid-owner-removes-member",
+			inputActor:  1,
+			inputUser:   2,
+			expectError: false,
+			wantError:   nil,
+		},
+		{
+			name:        "valid-member-leaves",
+			inputActor:  2,
+			inputUser:   2,
+			expectError: false,
+			wantError:   nil,
+		},
+		{
+			name:        "invalid-member-removes-owner",
+			inputActor:  2,
+			inputUser:   1,
+			expectError: true,
+			wantError:   households.ErrNotOwner,
+		},
+		{
+			name:        "invalid-owner-leaves",
+			inputActor:  1,
+			inputUser:   1,
+			expectError: true,
+			wantError:   households.ErrOwnerCannotLeave,
+		},
+		{
+			name:        "invalid-not-a-member",
+			inputActor:  1,
+			inputUser:   3,
+			expectError: true,
+			wantError:   households.ErrMemberNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			serv := setupTestService(t)
+
+			gotErr := serv.RemoveMember(t.Context(), testCase.inputActor, testCase.inputUser)
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("RemoveMember() got error: '%v', expected error: '%v'", gotErr, testCase.wantError)
+			}
+
+			// checking error type if its not nil
+			if gotErr != nil {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+				return
+			}
+
+			// the removed user no longer shares the book
+			householdID, err := serv.HouseholdIDForUser(t.Context(), testCase.inputUser)
+			if err != nil || householdID != 0 {
+				t.Errorf("HouseholdIDForUser() after removal got: %d, error: '%v', want: 0", householdID, err)
+			}
+		})
+	}
+}
+
+func TestBalances(t *testing.T) {
+	testTable := []struct {
+		name             string
+		inputPaid        map[int]int64 // by user id, in the book's order
+		inputSettlements []*households.Settlement
+		wantBalances     map[int]int64
+		wantTransfers    []households.Transfer
+	}{
+		{
+			name:          "valid-even-split",
+			inputPaid:     map[int]int64{1: 3000, 2: 1000},
+			wantBalances:  map[int]int64{1: 1000, 2: -1000},
+			wantTransfers: []households.Transfer{{FromUserID: 2, ToUserID: 1, Amount: 1000}},
+		},
+		{
+			name:          "valid-remainder-to-lowest-ids",
+			inputPaid:     map[int]int64{1: 0, 2: 100, 3: 0},
+			wantBalances:  map[int]int64{1: -34, 2: 67, 3: -33},
+			wantTransfers: []households.Transfer{{FromUserID: 1, ToUserID: 2, Amount: 34}, {FromUserID: 3, ToUserID: 2, Amount: 33}},
+		},
+		{
+			name:             "valid-partly-settled",
+			inputPaid:        map[int]int64{1: 3000, 2: 1000},
+			inputSettlements: []*households.Settlement{{FromUserID: 2, ToUserID: 1, Amount: 400}},
+			wantBalances:     map[int]int64{1: 600, 2: -600},
+			wantTransfers:    []households.Transfer{{FromUserID: 2, ToUserID: 1, Amount: 600}},
+		},
+		{
+			name:             "valid-settled-up",
+			inputPaid:        map[int]int64{1: 3000, 2: 1000},
+			inputSettlements: []*households.Settlement{{FromUserID: 2, ToUserID: 1, Amount: 1000}},
+			wantBalances:     map[int]int64{1: 0, 2: 0},
+			wantTransfers:    []households.Transfer{},
+		},
+		{
+			name:         "valid-fewest-transfers",
+			inputPaid:    map[int]int64{1: 9000, 2: 0, 3: 3000, 4: 0},
+			wantBalances: map[int]int64{1: 6000, 2: -3000, 3: 0, 4: -3000},
+			wantTransfers: []households.Transfer{
+				{FromUserID: 2, ToUserID: 1, Amount: 3000},
+				{FromUserID: 4, ToUserID: 1, Amount: 3000},
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			contributions := make([]*households.Contribution, 0, len(testCase.inputPaid))
+			for userID, paid := range testCase.inputPaid {
+				contributions = append(contributions, &households.Contribution{UserID: userID, Total: paid})
+			}
+			serv := setupTestService(t, contributions...)
+			for _, settlement := range testCase.inputSettlements {
+				if _, err := serv.Settle(t.Context(), settlement.FromUserID, settlement.ToUserID, settlement.Amount); err != nil {
+					t.Fatalf("Settle() got error: '%v'", err)
+				}
+			}
+
+			gotBalances, gotTransfers, err := serv.Balances(t.Context(), 1)
+			if err != nil {
+				t.Fatalf("Balances() got error: '%v'", err)
+			}
+
+			if len(gotBalances) != len(testCase.wantBalances) {
+				t.Fatalf("got %d balances, want %d", len(gotBalances), len(testCase.wantBalances))
+			}
+			for _, balance := range gotBalances {
+				if balance.Balance != testCase.wantBalances[balance.UserID] {
+					t.Errorf("user %d got balance: %d, want balance: %d", balance.UserID, balance.Balance, testCase.wantBalances[balance.UserID])
+				}
+			}
+
+			if len(gotTransfers) != len(testCase.wantTransfers) {
+				t.Fatalf("got transfers: %v, want %d transfers", gotTransfers, len(testCase.wantTransfers))
+			}
+			for i := range testCase.wantTransfers {
+				if *gotTransfers[i] != testCase.wantTransfers[i] {
+					t.Errorf("transfer %d does not match. got: %+v, want: %+v", i, *gotTransfers[i], testCase.wantTransfers[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSettle(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputActor  int
+		inputTo     int
+		inputAmount int64
+		expectError bool
+		wantError   error
+	}{
+		{
+			name:        "valid-member-pays-owner",
+			inputActor:  2,
+			inputTo:     1,
+			inputAmount: 1000,
+			expectError: false,
+			wantError:   nil,
+		},
+		{
+			name:        "invalid-zero-amount",
+			inputActor:  2,
+			inputTo:     1,
+			inputAmount: 0,
+			expectError: true,
+			wantError:   households.ErrInvalidAmount,
+		},
+		{
+			name:        "invalid-pays-self",
+			inputActor:  2,
+			inputTo:     2,
+			inputAmount: 1000,
+			expectError: true,
+			wantError:   households.ErrSettleWithSelf,
+		},
+		{
+			name:        "invalid-not-in-book",
+			inputActor:  2,
+			inputTo:     3,
+			inputAmount: 1000,
+			expectError: true,
+			wantError:   households.ErrMemberNotFound,
+		},
+		{
+			name:        "invalid-not-in-household",
+			inputActor:  3,
+			inputTo:     1,
+			inputAmount: 1000,
+			expectError: true,
+			wantError:   households.ErrNotMember,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			serv := setupTestService(t,
+				&households.Contribution{UserID: 1, Total: 3000},
+				&households.Contribution{UserID: 2, Total: 1000},
+			)
+
+			gotSettlement, gotErr := serv.Settle(t.Context(), testCase.inputActor, testCase.inputTo, testCase.inputAmount)
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("Settle() got error: '%v', expected error: '%v'", gotErr, testCase.wantError)
+			}
+
+			// checking error type if its not nil
+			if gotErr != nil {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+				return
+			}
+
+			if gotSettlement.FromUserID != testCase.inputActor || gotSettlement.ToUserID != testCase.inputTo || gotSettlement.Amount != testCase.inputAmount {
+				t.Errorf("got settlement: %+v", gotSettlement)
+			}
+		})
+	}
+}
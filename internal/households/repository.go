@@ -0,0 +1,32 @@
+package households
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	// create a household with ownerID as its first member
+	Create(ctx context.Context, name string, ownerID int) (*Household, error)
+
+	// get the household a user is in, ErrNotMember when they are in none
+	GetForUser(ctx context.Context, userID int) (*Household, Role, error)
+
+	// list members, oldest first
+	Members(ctx context.Context, householdID int) ([]*Member, error)
+
+	// add a user, ErrAlreadyMember when they are in any household
+	AddMember(ctx context.Context, householdID, userID int, role Role) error
+
+	// remove a user, ErrMemberNotFound when they are not in householdID
+	RemoveMember(ctx context.Context, householdID, userID int) error
+
+	// total the shared expenses per member, occured in [from, to). Zero times are unbounded
+	Contributions(ctx context.Context, householdID int, from, to time.Time) ([]*Contribution, error)
+
+	// record a payment between two users of the household
+	AddSettlement(ctx context.Context, householdID int, settlement *Settlement) (*Settlement, error)
+
+	// list every payment recorded in the household, newest first
+	Settlements(ctx context.Context, householdID int) ([]*Settlement, error)
+}
@@ -0,0 +1,172 @@
+package households
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/users"
+)
+
+// Service defines an interface for the households business layer.
+// Every method acts on behalf of the user with actorID.
+//
+// This is primarily implemented for easier mocking for testing.
+type Service interface {
+	Create(ctx context.Context, actorID int, name string) (*Household, error)
+
+	Get(ctx context.Context, actorID int) (*Household, []*Member, error)
+
+	AddMember(ctx context.Context, actorID int, email string) (*Member, error)
+
+	RemoveMember(ctx context.Context, actorID, userID int) error
+
+	Contributions(ctx context.Context, actorID int, from, to time.Time) ([]*Contribution, error)
+
+	Balances(ctx context.Context, actorID int) ([]*Balance, []*Transfer, error)
+
+	Settle(ctx context.Context, actorID, toUserID int, amount int64) (*Settlement, error)
+
+	Settlements(ctx context.Context, actorID int) ([]*Settlement, error)
+
+	HouseholdIDForUser(ctx context.Context, userID int) (int, error)
+}
+
+// UserLookup finds users to invite, it is implemented by users.Repository
+type UserLookup interface {
+	GetByEmail(ctx context.Context, email string) (*users.User, error)
+}
+
+// HouseholdService enforces who can manage a household
+type HouseholdService struct {
+	repo  Repository
+	users UserLookup
+	cache Invalidator
+}
+
+// Invalidator drops cached reads once members change, since members see each other's expenses.
+// It is implemented by respcache.Cache
+type Invalidator interface {
+	Invalidate()
+}
+
+// Option configures optional parts of the HouseholdService
+type Option func(*HouseholdService)
+
+// WithInvalidator invalidates cache whenever a member is added or removed
+func WithInvalidator(cache Invalidator) Option {
+	return func(s *HouseholdService) { s.cache = cache }
+}
+
+func NewService(repo Repository, userLookup UserLookup, opts ...Option) *HouseholdService {
+	s := &HouseholdService{repo: repo, users: userLookup}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// invalidate tells the cache, if there is one, that members changed
+func (s *HouseholdService) invalidate() {
+	if s.cache != nil {
+		s.cache.Invalidate()
+	}
+}
+
+// Create starts a household with the actor as its owner
+func (s *HouseholdService) Create(ctx context.Context, actorID int, name string) (*Household, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrEmptyName
+	}
+
+	return s.repo.Create(ctx, name, actorID)
+}
+
+// Get returns the actor's household and its members
+func (s *HouseholdService) Get(ctx context.Context, actorID int) (*Household, []*Member, error) {
+	household, _, err := s.repo.GetForUser(ctx, actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	members, err := s.repo.Members(ctx, household.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return household, members, nil
+}
+
+// AddMember adds a registered user by email, only the owner can add members
+func (s *HouseholdService) AddMember(ctx context.Context, actorID int, email string) (*Member, error) {
+	household, role, err := s.repo.GetForUser(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if role != RoleOwner {
+		return nil, ErrNotOwner
+	}
+
+	user, err := s.users.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.repo.AddMember(ctx, household.ID, user.ID, RoleMember); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	return &Member{UserID: user.ID, Email: user.Email, Name: user.Name, Role: RoleMember, JoinedAt: time.Now()}, nil
+}
+
+// RemoveMember removes userID from the actor's household.
+// The owner can remove anyone else, and members can only remove themselves.
+func (s *HouseholdService) RemoveMember(ctx context.Context, actorID, userID int) error {
+	household, role, err := s.repo.GetForUser(ctx, actorID)
+	if err != nil {
+		return err
+	}
+
+	if role == RoleOwner && userID == actorID {
+		return ErrOwnerCannotLeave
+	}
+	if role != RoleOwner && userID != actorID {
+		return ErrNotOwner
+	}
+
+	if err := s.repo.RemoveMember(ctx, household.ID, userID); err != nil {
+		return err
+	}
+	s.invalidate()
+
+	return nil
+}
+
+// Contributions totals the shared book per member, any member can see it
+func (s *HouseholdService) Contributions(ctx context.Context, actorID int, from, to time.Time) ([]*Contribution, error) {
+	household, _, err := s.repo.GetForUser(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.Contributions(ctx, household.ID, from, to)
+}
+
+// HouseholdIDForUser implements expenses.HouseholdLookup
+func (s *HouseholdService) HouseholdIDForUser(ctx context.Context, userID int) (int, error) {
+	household, _, err := s.repo.GetForUser(ctx, userID)
+	if errors.Is(err, ErrNotMember) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return household.ID, nil
+}
@@ -0,0 +1,180 @@
+package households
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+)
+
+// Settlement is a recorded payment from one member to another, paying back part of what they owe
+type Settlement struct {
+	ID         int
+	FromUserID int
+	ToUserID   int
+	Amount     int64 // cents
+	CreatedAt  time.Time
+}
+
+// Balance is where one member stands in the shared book. The book's total is split evenly between everyone
+// in Contributions, so Share is their part of it. Positive balances are owed to the member, negative ones they owe
+type Balance struct {
+	UserID  int
+	Name    string
+	Paid    int64 // cents spent into the shared book
+	Share   int64 // cents of the book's total that are theirs to pay
+	Settled int64 // cents paid to other members, less what other members paid them
+	Balance int64 // Paid + Settled - Share
+}
+
+// Transfer is a payment that would settle up part of the balances
+type Transfer struct {
+	FromUserID int
+	ToUserID   int
+	Amount     int64 // cents
+}
+
+// balances splits the book's total evenly between the contributors, the cents left over go to the
+// lowest user ids so the shares add up to the total. settlements are folded into what each has settled
+func balances(contributions []*Contribution, settlements []*Settlement) []*Balance {
+	if len(contributions) == 0 {
+		return make([]*Balance, 0)
+	}
+
+	var total int64
+	for _, contribution := range contributions {
+		total += contribution.Total
+	}
+
+	settled := make(map[int]int64)
+	for _, settlement := range settlements {
+		settled[settlement.FromUserID] += settlement.Amount
+		settled[settlement.ToUserID] -= settlement.Amount
+	}
+
+	// the remainder is handed out by user id, not by the order contributions are in
+	byID := slices.SortedFunc(slices.Values(contributions), func(a, b *Contribution) int {
+		return cmp.Compare(a.UserID, b.UserID)
+	})
+
+	share, remainder := total/int64(len(byID)), total%int64(len(byID))
+	found := make([]*Balance, 0, len(byID))
+	for i, contribution := range byID {
+		balance := &Balance{
+			UserID:  contribution.UserID,
+			Name:    contribution.Name,
+			Paid:    contribution.Total,
+			Share:   share,
+			Settled: settled[contribution.UserID],
+		}
+		if int64(i) < remainder {
+			balance.Share++
+		}
+		balance.Balance = balance.Paid + balance.Settled - balance.Share
+
+		found = append(found, balance)
+	}
+	return found
+}
+
+// transfers settles the balances by paying the biggest creditor from the biggest debtor until
+// everyone is even. It takes at most one transfer fewer than there are members with a balance
+func transfers(balances []*Balance) []*Transfer {
+	var debtors, creditors []*Balance
+	for _, balance := range balances {
+		// copied, since what is left of each is counted down below
+		left := *balance
+		switch {
+		case left.Balance < 0:
+			left.Balance = -left.Balance
+			debtors = append(debtors, &left)
+		case left.Balance > 0:
+			creditors = append(creditors, &left)
+		}
+	}
+
+	biggestFirst := func(a, b *Balance) int {
+		if c := cmp.Compare(b.Balance, a.Balance); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.UserID, b.UserID)
+	}
+	slices.SortFunc(debtors, biggestFirst)
+	slices.SortFunc(creditors, biggestFirst)
+
+	found := make([]*Transfer, 0)
+	for len(debtors) > 0 && len(creditors) > 0 {
+		debtor, creditor := debtors[0], creditors[0]
+		amount := min(debtor.Balance, creditor.Balance)
+		found = append(found, &Transfer{FromUserID: debtor.UserID, ToUserID: creditor.UserID, Amount: amount})
+
+		debtor.Balance -= amount
+		creditor.Balance -= amount
+		if debtor.Balance == 0 {
+			debtors = debtors[1:]
+		}
+		if creditor.Balance == 0 {
+			creditors = creditors[1:]
+		}
+		slices.SortFunc(debtors, biggestFirst)
+		slices.SortFunc(creditors, biggestFirst)
+	}
+	return found
+}
+
+// Balances is where everyone in the actor's shared book stands, with the transfers that would settle them up.
+// Every expense ever shared counts, along with every settlement recorded
+func (s *HouseholdService) Balances(ctx context.Context, actorID int) ([]*Balance, []*Transfer, error) {
+	household, _, err := s.repo.GetForUser(ctx, actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contributions, err := s.repo.Contributions(ctx, household.ID, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, nil, err
+	}
+	settlements, err := s.repo.Settlements(ctx, household.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	found := balances(contributions, settlements)
+	return found, transfers(found), nil
+}
+
+// Settle records that the actor paid amount cents to toUserID. Former members still in the book can be paid back
+func (s *HouseholdService) Settle(ctx context.Context, actorID, toUserID int, amount int64) (*Settlement, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	if toUserID == actorID {
+		return nil, ErrSettleWithSelf
+	}
+
+	household, _, err := s.repo.GetForUser(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	// everyone with a balance, which is who Contributions lists
+	contributions, err := s.repo.Contributions(ctx, household.ID, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(contributions, func(c *Contribution) bool { return c.UserID == toUserID }) {
+		return nil, ErrMemberNotFound
+	}
+
+	return s.repo.AddSettlement(ctx, household.ID, &Settlement{FromUserID: actorID, ToUserID: toUserID, Amount: amount})
+}
+
+// Settlements lists the payments recorded in the actor's household, newest first
+func (s *HouseholdService) Settlements(ctx context.Context, actorID int) ([]*Settlement, error) {
+	household, _, err := s.repo.GetForUser(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.Settlements(ctx, household.ID)
+}
@@ -0,0 +1,72 @@
+package i18n
+
+import (
+	"strings"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+)
+
+// Format is how a locale writes dates and amounts, for exports that people read rather than parse
+type Format struct {
+	DateTime    string // time layout
+	Decimal     string // between the whole and the fraction
+	Group       string // between each group of three digits
+	SymbolAfter bool   // "12,50 €" rather than "€12.50"
+	CSVComma    rune   // spreadsheets in decimal comma locales split csv on ';'
+}
+
+// formats are by locale, every locale with a catalog has one
+var formats = map[string]Format{
+	"en": {DateTime: "Jan 2, 2006 15:04", Decimal: ".", Group: ",", CSVComma: ','},
+	"de": {DateTime: "02.01.2006 15:04", Decimal: ",", Group: ".", SymbolAfter: true, CSVComma: ';'},
+	"es": {DateTime: "02/01/2006 15:04", Decimal: ",", Group: ".", SymbolAfter: true, CSVComma: ';'},
+	"fr": {DateTime: "02/01/2006 15:04", Decimal: ",", Group: "\u00a0", SymbolAfter: true, CSVComma: ';'},
+}
+
+// FormatFor is the Format of locale, ok is false for an unsupported one
+func FormatFor(locale string) (format Format, ok bool) {
+	format, ok = formats[locale]
+	return format, ok
+}
+
+// Time writes t in the locale's date and time layout, i.e. "14.03.2025 09:30"
+func (f Format) Time(t time.Time) string {
+	return t.Format(f.DateTime)
+}
+
+// Amount writes m with the locale's separators and the currency's symbol where the locale puts it,
+// i.e. "1.234,50 €" or "-€3.00", with a no-break space before a trailing symbol.
+// Currencies without a well known symbol are followed by their code
+func (f Format) Amount(m money.Money) string {
+	number, negative := strings.CutPrefix(m.Decimal(), "-")
+	whole, fraction, hasFraction := strings.Cut(number, ".")
+
+	var b strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(f.Group)
+		}
+		b.WriteRune(digit)
+	}
+	if hasFraction {
+		b.WriteString(f.Decimal)
+		b.WriteString(fraction)
+	}
+	number = b.String()
+
+	sign := ""
+	if negative {
+		sign = "-"
+	}
+
+	symbol, ok := money.Symbol(m.Currency)
+	switch {
+	case !ok:
+		return sign + number + " " + m.Currency
+	case f.SymbolAfter:
+		return sign + number + "\u00a0" + symbol
+	default:
+		return sign + symbol + number
+	}
+}
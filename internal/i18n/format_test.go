@@ -0,0 +1,75 @@
+package i18n_test
+
+import (
+	"testing"
+
+	"github.com/nicholasss/expense-tracker-api/internal/i18n"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+)
+
+func TestFormatAmount(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputLocale string
+		inputAmount money.Money
+		want        string
+	}{
+		{
+			name:        "valid-en-symbol-before",
+			inputLocale: "en",
+			inputAmount: money.New(123456789, "USD"),
+			want:        "$1,234,567.89",
+		},
+		{
+			name:        "valid-en-negative",
+			inputLocale: "en",
+			inputAmount: money.New(-300, "EUR"),
+			want:        "-€3.00",
+		},
+		{
+			name:        "valid-de-symbol-after",
+			inputLocale: "de",
+			inputAmount: money.New(123450, "EUR"),
+			want:        "1.234,50\u00a0€",
+		},
+		{
+			name:        "valid-fr-grouping",
+			inputLocale: "fr",
+			inputAmount: money.New(1234567, "JPY"),
+			want:        "1\u00a0234\u00a0567\u00a0¥",
+		},
+		{
+			name:        "valid-es-three-decimals",
+			inputLocale: "es",
+			inputAmount: money.New(1500, "KWD"),
+			want:        "1,500 KWD",
+		},
+		{
+			name:        "valid-no-symbol-uses-code",
+			inputLocale: "de",
+			inputAmount: money.New(99, "CHF"),
+			want:        "0,99 CHF",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			format, ok := i18n.FormatFor(testCase.inputLocale)
+			if !ok {
+				t.Fatalf("FormatFor(%q) found no format", testCase.inputLocale)
+			}
+			if got := format.Amount(testCase.inputAmount); got != testCase.want {
+				t.Errorf("got: %q, want: %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+// every supported locale needs a format for exports
+func TestFormatForEveryLocale(t *testing.T) {
+	for _, locale := range i18n.Locales() {
+		if _, ok := i18n.FormatFor(locale); !ok {
+			t.Errorf("FormatFor(%q) found no format", locale)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Package i18n translates the user facing text of responses, i.e. summary labels and error messages.
+// Catalogs are keyed by the English text, so English needs none and anything missing from a catalog
+// is sent in English. Messages with values in them, like the limits of a parameter, are only matched whole
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"path"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// English is the locale messages are written in
+const English = "en"
+
+// ErrUnsupportedLocale is returned for a locale without a catalog
+var ErrUnsupportedLocale = errors.New("locale has no message catalog")
+
+//go:embed catalogs/*.json
+var catalogFiles embed.FS
+
+// catalogs are the translations by locale and then by the English text
+var catalogs = mustLoadCatalogs()
+
+// mustLoadCatalogs reads every embedded catalog, they are named by their locale i.e. de.json
+func mustLoadCatalogs() map[string]map[string]string {
+	files, err := catalogFiles.ReadDir("catalogs")
+	if err != nil {
+		panic(err)
+	}
+
+	loaded := make(map[string]map[string]string, len(files))
+	for _, file := range files {
+		data, err := catalogFiles.ReadFile(path.Join("catalogs", file.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic("i18n: catalog " + file.Name() + ": " + err.Error())
+		}
+		loaded[strings.TrimSuffix(file.Name(), ".json")] = messages
+	}
+	return loaded
+}
+
+// Locales are the supported locales, English and every one with a catalog
+func Locales() []string {
+	locales := []string{English}
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	slices.Sort(locales[1:])
+	return locales
+}
+
+// Translate is message in locale, or message itself when locale's catalog doesn't have it
+func Translate(locale, message string) string {
+	if translated, ok := catalogs[locale][message]; ok {
+		return translated
+	}
+	return message
+}
+
+// TranslateError translates an error response's message, i.e. "Not Found: provided id does not have a record".
+// The status text and the detail after it are translated apart, as is each problem when the detail
+// joins several with "; "
+func TranslateError(locale, message string) string {
+	if _, ok := catalogs[locale]; !ok {
+		return message
+	}
+
+	status, detail, found := strings.Cut(message, ": ")
+	if !found {
+		return Translate(locale, message)
+	}
+
+	problems := strings.Split(detail, "; ")
+	for i, problem := range problems {
+		problems[i] = Translate(locale, problem)
+	}
+	return Translate(locale, status) + ": " + strings.Join(problems, "; ")
+}
+
+type localeKey struct{}
+
+// WithLocale sets the locale responses to the request are translated to
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// LocaleFromContext is the locale set by WithLocale, English when there is none
+func LocaleFromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok {
+		return locale
+	}
+	return English
+}
+
+// T is message in the request's locale
+func T(ctx context.Context, message string) string {
+	return Translate(LocaleFromContext(ctx), message)
+}
+
+// Matcher picks the supported locale closest to what a client accepts
+type Matcher struct {
+	locales []string // the fallback first, it is what the matcher picks when nothing is close
+	matcher language.Matcher
+}
+
+// NewMatcher matches against the supported locales, picking fallback when a client accepts none of them
+func NewMatcher(fallback string) (*Matcher, error) {
+	locales := Locales()
+	if !slices.Contains(locales, fallback) {
+		return nil, ErrUnsupportedLocale
+	}
+
+	locales = slices.DeleteFunc(locales, func(locale string) bool { return locale == fallback })
+	locales = append([]string{fallback}, locales...)
+
+	tags := make([]language.Tag, 0, len(locales))
+	for _, locale := range locales {
+		tags = append(tags, language.MustParse(locale))
+	}
+
+	return &Matcher{locales: locales, matcher: language.NewMatcher(tags)}, nil
+}
+
+// Match is the locale for an Accept-Language header, i.e. "de-AT,de;q=0.9,en;q=0.5" is de
+func (m *Matcher) Match(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return m.locales[0]
+	}
+
+	_, index, confidence := m.matcher.Match(tags...)
+	if confidence == language.No {
+		return m.locales[0]
+	}
+	return m.locales[index]
+}
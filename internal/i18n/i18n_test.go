@@ -0,0 +1,137 @@
+package i18n_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/nicholasss/expense-tracker-api/internal/i18n"
+)
+
+func TestLocales(t *testing.T) {
+	want := []string{"en", "de", "es", "fr"}
+	if got := i18n.Locales(); !slices.Equal(got, want) {
+		t.Errorf("got locales: %v, want locales: %v", got, want)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	testTable := []struct {
+		name          string
+		inputFallback string
+		inputHeader   string
+		expectError   bool
+		wantError     error
+		want          string
+	}{
+		{
+			name:          "valid-exact",
+			inputFallback: "en",
+			inputHeader:   "de",
+			want:          "de",
+		},
+		{
+			name:          "valid-region",
+			inputFallback: "en",
+			inputHeader:   "fr-CA,fr;q=0.9",
+			want:          "fr",
+		},
+		{
+			name:          "valid-by-quality",
+			inputFallback: "en",
+			inputHeader:   "it;q=0.9,es;q=0.8,en;q=0.1",
+			want:          "es",
+		},
+		{
+			name:          "valid-unsupported-is-fallback",
+			inputFallback: "de",
+			inputHeader:   "ja",
+			want:          "de",
+		},
+		{
+			name:          "valid-missing-is-fallback",
+			inputFallback: "es",
+			inputHeader:   "",
+			want:          "es",
+		},
+		{
+			name:          "valid-malformed-is-fallback",
+			inputFallback: "fr",
+			inputHeader:   "en;q=nope",
+			want:          "fr",
+		},
+		{
+			name:          "invalid-unsupported-fallback",
+			inputFallback: "tlh",
+			expectError:   true,
+			wantError:     i18n.ErrUnsupportedLocale,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			matcher, err := i18n.NewMatcher(testCase.inputFallback)
+			if testCase.expectError {
+				if !errors.Is(err, testCase.wantError) {
+					t.Fatalf("NewMatcher() got error: '%v', want error: '%v'", err, testCase.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewMatcher() got error: '%v'", err)
+			}
+
+			if got := matcher.Match(testCase.inputHeader); got != testCase.want {
+				t.Errorf("got locale: %q, want locale: %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestTranslateError(t *testing.T) {
+	testTable := []struct {
+		name         string
+		inputLocale  string
+		inputMessage string
+		want         string
+	}{
+		{
+			name:         "valid-status-only",
+			inputLocale:  "de",
+			inputMessage: "Internal Server Error",
+			want:         "Interner Serverfehler",
+		},
+		{
+			name:         "valid-status-and-detail",
+			inputLocale:  "fr",
+			inputMessage: "Not Found: provided id does not have a record",
+			want:         "Introuvable: aucun enregistrement pour cet identifiant",
+		},
+		{
+			name:         "valid-joined-problems",
+			inputLocale:  "es",
+			inputMessage: "Bad Request: expense amount needs to be greater than 0; expense date needs to be after 1970",
+			want:         "Solicitud incorrecta: el importe del gasto debe ser mayor que 0; la fecha del gasto debe ser posterior a 1970",
+		},
+		{
+			name:         "valid-unknown-detail-stays-english",
+			inputLocale:  "de",
+			inputMessage: "Bad Request: invalid parameter 'limit': must be an integer",
+			want:         "Ungültige Anfrage: invalid parameter 'limit': must be an integer",
+		},
+		{
+			name:         "valid-english-unchanged",
+			inputLocale:  "en",
+			inputMessage: "Not Found: provided id does not have a record",
+			want:         "Not Found: provided id does not have a record",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := i18n.TranslateError(testCase.inputLocale, testCase.inputMessage); got != testCase.want {
+				t.Errorf("got: %q, want: %q", got, testCase.want)
+			}
+		})
+	}
+}
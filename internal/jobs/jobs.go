@@ -0,0 +1,212 @@
+// Package jobs runs long running work, such as exports, on a pool of worker goroutines.
+// Callers get a job id straight away and poll for the status and result.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Status is where a job is in its lifecycle
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// ErrJobNotFound is returned for unknown or expired job ids
+var ErrJobNotFound = errors.New("provided id does not have a job")
+
+// ErrQueueFull is returned when too many jobs are already waiting
+var ErrQueueFull = errors.New("job queue is full, try again later")
+
+// ErrManagerClosed is returned when submitting after Close
+var ErrManagerClosed = errors.New("job manager is closed")
+
+// Result is the output of a successful job
+type Result struct {
+	Data        []byte
+	ContentType string
+	Filename    string
+}
+
+// Func is the work a job performs, ctx is cancelled when the manager is closed
+type Func func(ctx context.Context) (*Result, error)
+
+// Job is a snapshot of a submitted job
+type Job struct {
+	ID         string
+	Kind       string
+	OwnerID    int // user that submitted the job, 0 without auth
+	Status     Status
+	CreatedAt  time.Time
+	FinishedAt time.Time
+	Err        string
+	Result     *Result
+}
+
+// queuedJob pairs a job id with the work to run
+type queuedJob struct {
+	id string
+	fn Func
+}
+
+// Manager owns the worker pool and keeps finished jobs around for retention
+type Manager struct {
+	retention time.Duration
+
+	mux    sync.RWMutex
+	jobs   map[string]*Job
+	closed bool
+
+	queue  chan queuedJob
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewManager starts workers goroutines, finished jobs are forgotten after retention
+func NewManager(workers, queueSize int, retention time.Duration) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m := &Manager{
+		retention: retention,
+		jobs:      make(map[string]*Job),
+		queue:     make(chan queuedJob, queueSize),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+
+	for range workers {
+		m.wg.Add(1)
+		go m.work()
+	}
+
+	return m
+}
+
+// newJobID creates a random, unguessable id since ids are shared with clients
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Submit queues fn for ownerID and returns the job straight away
+func (m *Manager) Submit(kind string, ownerID int, fn Func) (*Job, error) {
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	job := &Job{ID: id, Kind: kind, OwnerID: ownerID, Status: StatusQueued, CreatedAt: time.Now()}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if m.closed {
+		return nil, ErrManagerClosed
+	}
+
+	m.sweep()
+
+	select {
+	case m.queue <- queuedJob{id: id, fn: fn}:
+	default:
+		return nil, ErrQueueFull
+	}
+
+	m.jobs[id] = job
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// Get returns a snapshot of the job with id
+func (m *Manager) Get(id string) (*Job, error) {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// Close stops accepting jobs, cancels running ones, and waits for the workers to exit
+func (m *Manager) Close() {
+	m.mux.Lock()
+	if m.closed {
+		m.mux.Unlock()
+		return
+	}
+	m.closed = true
+	close(m.queue)
+	m.mux.Unlock()
+
+	m.cancel()
+	m.wg.Wait()
+}
+
+// sweep forgets finished jobs past retention, m.mux must be held
+func (m *Manager) sweep() {
+	cutoff := time.Now().Add(-m.retention)
+	for id, job := range m.jobs {
+		if !job.FinishedAt.IsZero() && job.FinishedAt.Before(cutoff) {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// setStatus updates a job under the lock
+func (m *Manager) setStatus(id string, status Status, result *Result, err error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return
+	}
+
+	job.Status = status
+	job.Result = result
+	if err != nil {
+		job.Err = err.Error()
+	}
+	if status == StatusSucceeded || status == StatusFailed {
+		job.FinishedAt = time.Now()
+	}
+}
+
+// work runs queued jobs until the queue is closed
+func (m *Manager) work() {
+	defer m.wg.Done()
+
+	for qj := range m.queue {
+		// jobs left in the queue after Close are failed rather than run
+		if m.ctx.Err() != nil {
+			m.setStatus(qj.id, StatusFailed, nil, m.ctx.Err())
+			continue
+		}
+
+		m.setStatus(qj.id, StatusRunning, nil, nil)
+
+		result, err := qj.fn(m.ctx)
+		if err != nil {
+			m.setStatus(qj.id, StatusFailed, nil, err)
+			continue
+		}
+		m.setStatus(qj.id, StatusSucceeded, result, nil)
+	}
+}
@@ -0,0 +1,137 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/jobs"
+)
+
+// waitForJob polls until the job has finished, failing the test if it takes too long
+func waitForJob(t *testing.T, m *jobs.Manager, id string) *jobs.Job {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%q) got error: '%v'", id, err)
+		}
+		if job.Status == jobs.StatusSucceeded || job.Status == jobs.StatusFailed {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatalf("job %q did not finish in time", id)
+	return nil
+}
+
+func TestManagerSubmit(t *testing.T) {
+	errExport := errors.New("export went wrong")
+
+	testTable := []struct {
+		name       string
+		inputFunc  jobs.Func
+		wantStatus jobs.Status
+		wantErr    string
+		wantData   string
+	}{
+		{
+			name: "valid-job-succeeds",
+			inputFunc: func(ctx context.Context) (*jobs.Result, error) {
+				return &jobs.Result{Data: []byte("id,amount"), ContentType: "text/csv"}, nil
+			},
+			wantStatus: jobs.StatusSucceeded,
+			wantData:   "id,amount",
+		},
+		{
+			name: "invalid-job-fails",
+			inputFunc: func(ctx context.Context) (*jobs.Result, error) {
+				return nil, errExport
+			},
+			wantStatus: jobs.StatusFailed,
+			wantErr:    errExport.Error(),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			m := jobs.NewManager(1, 4, time.Hour)
+			defer m.Close()
+
+			submitted, err := m.Submit("export", 0, testCase.inputFunc)
+			if err != nil {
+				t.Fatalf("Submit() got error: '%v'", err)
+			}
+			if submitted.Status != jobs.StatusQueued {
+				t.Errorf("got submitted status: %v, want: %v", submitted.Status, jobs.StatusQueued)
+			}
+
+			job := waitForJob(t, m, submitted.ID)
+			if job.Status != testCase.wantStatus {
+				t.Errorf("got status: %v, want status: %v", job.Status, testCase.wantStatus)
+			}
+			if job.Err != testCase.wantErr {
+				t.Errorf("got error: %q, want error: %q", job.Err, testCase.wantErr)
+			}
+			if testCase.wantData != "" && string(job.Result.Data) != testCase.wantData {
+				t.Errorf("got data: %q, want data: %q", job.Result.Data, testCase.wantData)
+			}
+		})
+	}
+}
+
+func TestManagerGetUnknown(t *testing.T) {
+	m := jobs.NewManager(1, 1, time.Hour)
+	defer m.Close()
+
+	if _, err := m.Get("does-not-exist"); !errors.Is(err, jobs.ErrJobNotFound) {
+		t.Errorf("got error: '%v', want error: '%v'", err, jobs.ErrJobNotFound)
+	}
+}
+
+func TestManagerQueueFullAndClosed(t *testing.T) {
+	m := jobs.NewManager(1, 1, time.Hour)
+
+	// block the only worker so that the queue fills up
+	release := make(chan struct{})
+	blocking := func(ctx context.Context) (*jobs.Result, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return &jobs.Result{}, nil
+	}
+
+	first, err := m.Submit("export", 0, blocking)
+	if err != nil {
+		t.Fatalf("first Submit() got error: '%v'", err)
+	}
+
+	// wait for the worker to pick up the first job, so the queue is empty
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		job, _ := m.Get(first.ID)
+		if job.Status == jobs.StatusRunning || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, err := m.Submit("export", 0, blocking); err != nil {
+		t.Fatalf("second Submit() got error: '%v'", err)
+	}
+	if _, err := m.Submit("export", 0, blocking); !errors.Is(err, jobs.ErrQueueFull) {
+		t.Errorf("third Submit() got error: '%v', want error: '%v'", err, jobs.ErrQueueFull)
+	}
+
+	close(release)
+	m.Close()
+
+	if _, err := m.Submit("export", 0, blocking); !errors.Is(err, jobs.ErrManagerClosed) {
+		t.Errorf("Submit() after Close got error: '%v', want error: '%v'", err, jobs.ErrManagerClosed)
+	}
+}
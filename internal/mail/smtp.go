@@ -0,0 +1,73 @@
+// Package mail sends account emails through an SMTP server
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+)
+
+// SMTPMailer sends account emails through an SMTP server, see users.WithMailer.
+// It logs in with PLAIN auth when it has a username, which net/smtp only allows over TLS or to localhost
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer sends from the address from, through the server at host:port
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	m := &SMTPMailer{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: from,
+	}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+// SendEmailChange mails the confirmation token to the new address
+func (m *SMTPMailer) SendEmailChange(ctx context.Context, to, token string) error {
+	body := "Someone asked to change the email address of your expense tracker account to this one.\r\n" +
+		"Confirm it with this token, it expires in a day:\r\n\r\n" + token + "\r\n\r\n" +
+		"If it was not you, ignore this email and nothing will change.\r\n"
+
+	return m.send(ctx, to, "Confirm your new email address", body)
+}
+
+// send writes a plain text email to a single address
+func (m *SMTPMailer) send(ctx context.Context, to, subject, body string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// to has been validated, but a header must never be able to start another one
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("invalid recipient %q", to)
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(body)
+
+	if err := smtp.SendMail(m.addr, m.auth, envelopeAddress(m.from), []string{to}, []byte(msg.String())); err != nil {
+		return fmt.Errorf("sending email to %s: %w", to, err)
+	}
+	return nil
+}
+
+// envelopeAddress is the bare address in from, i.e. noreply@example.com from "Expenses <noreply@example.com>"
+func envelopeAddress(from string) string {
+	if start, end := strings.LastIndex(from, "<"), strings.LastIndex(from, ">"); start >= 0 && end > start {
+		return from[start+1 : end]
+	}
+	return from
+}
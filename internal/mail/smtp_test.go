@@ -0,0 +1,101 @@
+package mail_test
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/nicholasss/expense-tracker-api/internal/mail"
+)
+
+// fakeSMTP accepts one message on a local port, and sends what it was given on the returned channel
+func fakeSMTP(t *testing.T) (host string, port int, received chan string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received = make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		var session strings.Builder
+		reply("220 localhost ready")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			session.WriteString(line)
+
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					session.WriteString(line)
+				}
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				received <- session.String()
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, received
+}
+
+func TestSendEmailChange(t *testing.T) {
+	host, port, received := fakeSMTP(t)
+	mailer := mail.NewSMTPMailer(host, port, "", "", "Expenses <noreply@example.com>")
+
+	if err := mailer.SendEmailChange(t.Context(), "ada@example.org", "the-token"); err != nil {
+		t.Fatalf("SendEmailChange() got error: '%v'", err)
+	}
+
+	session := <-received
+	for _, want := range []string{
+		"MAIL FROM:<noreply@example.com>",
+		"RCPT TO:<ada@example.org>",
+		"To: ada@example.org",
+		"Subject: Confirm your new email address",
+		"the-token",
+	} {
+		if !strings.Contains(session, want) {
+			t.Errorf("session is missing %q:\n%s", want, session)
+		}
+	}
+}
+
+func TestSendEmailChangeRejectsHeaders(t *testing.T) {
+	mailer := mail.NewSMTPMailer("127.0.0.1", 1, "", "", "noreply@example.com")
+
+	err := mailer.SendEmailChange(t.Context(), "ada@example.org\r\nBcc: eve@example.com", "the-token")
+	if err == nil || !strings.Contains(err.Error(), "invalid recipient") {
+		t.Errorf("SendEmailChange() got error: '%v', want an invalid recipient", err)
+	}
+}
@@ -0,0 +1,91 @@
+package middleware_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/middleware"
+)
+
+func TestAccessLog(t *testing.T) {
+	testTable := []struct {
+		name       string
+		inputCfg   middleware.AccessLogConfig
+		inputPath  string
+		wantSubstr []string // empty for no log line
+		dontWant   []string
+	}{
+		{
+			name:       "valid-text-default-fields",
+			inputCfg:   middleware.AccessLogConfig{},
+			inputPath:  "/expenses",
+			wantSubstr: []string{"[GIN] ", "| 200 |", `GET     "/expenses"`},
+			dontWant:   []string{"ua=", "bytes=", "user="},
+		},
+		{
+			name: "valid-text-optional-fields",
+			inputCfg: middleware.AccessLogConfig{
+				Fields: []string{middleware.AccessLogUserAgent, middleware.AccessLogBytes, middleware.AccessLogUserID},
+			},
+			inputPath:  "/expenses",
+			wantSubstr: []string{`ua="test-agent"`, "bytes=2", "user=42"},
+		},
+		{
+			name:       "valid-json",
+			inputCfg:   middleware.AccessLogConfig{JSON: true, Fields: []string{middleware.AccessLogUserID}},
+			inputPath:  "/expenses",
+			wantSubstr: []string{`"status":200`, `"method":"GET"`, `"path":"/expenses"`, `"user_id":42`},
+			dontWant:   []string{`"user_agent"`, `"bytes"`},
+		},
+		{
+			name:      "valid-skipped-path",
+			inputCfg:  middleware.AccessLogConfig{SkipPaths: []string{"/healthz"}},
+			inputPath: "/healthz",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			var out bytes.Buffer
+			testCase.inputCfg.Output = &out
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(middleware.AccessLog(testCase.inputCfg))
+			// stands in for RequireAuth
+			setUser := func(c *gin.Context) {
+				c.Request = c.Request.WithContext(auth.WithUserID(c.Request.Context(), 42))
+			}
+			r.GET("/expenses", setUser, func(c *gin.Context) {
+				c.String(http.StatusOK, "[]")
+			})
+			r.GET("/healthz", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, testCase.inputPath, nil)
+			req.Header.Set("User-Agent", "test-agent")
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			got := out.String()
+			if len(testCase.wantSubstr) == 0 && got != "" {
+				t.Errorf("got log: %q, want none", got)
+			}
+			for _, want := range testCase.wantSubstr {
+				if !strings.Contains(got, want) {
+					t.Errorf("got log: %q, want it to contain: %q", got, want)
+				}
+			}
+			for _, dontWant := range testCase.dontWant {
+				if strings.Contains(got, dontWant) {
+					t.Errorf("got log: %q, do not want: %q", got, dontWant)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/audit"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+)
+
+// Audit records every request that ends in 401 or 403, so rejected tokens and
+// permission denials show up in the audit log whichever middleware or handler denied them.
+// Handlers that record a more specific event set audit.RecordedKey to skip this.
+//
+// Every request made while an admin impersonates a user is recorded too, whatever its status.
+func Audit(recorder audit.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		// RequireAuth replaces the request, so the user is known for requests after it
+		userID, _ := auth.UserIDFromContext(c.Request.Context())
+		actorID, impersonated := auth.ActorIDFromContext(c.Request.Context())
+
+		var eventType audit.Type
+		switch {
+		case impersonated:
+			eventType = audit.TypeImpersonatedRequest
+		case c.Writer.Status() == http.StatusUnauthorized:
+			eventType = audit.TypeUnauthorized
+		case c.Writer.Status() == http.StatusForbidden:
+			eventType = audit.TypeForbidden
+		default:
+			return
+		}
+		if c.GetBool(audit.RecordedKey) {
+			return
+		}
+
+		recorder.Record(c.Request.Context(), audit.Event{
+			Type:      eventType,
+			UserID:    userID,
+			ActorID:   actorID,
+			IP:        c.ClientIP(),
+			RemoteIP:  c.RemoteIP(),
+			UserAgent: c.Request.UserAgent(),
+			Method:    c.Request.Method,
+			Path:      c.FullPath(),
+			Status:    c.Writer.Status(),
+		})
+	}
+}
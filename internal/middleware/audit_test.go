@@ -0,0 +1,154 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/audit"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/middleware"
+)
+
+// recordingAudit keeps recorded events in memory
+type recordingAudit struct {
+	events []audit.Event
+}
+
+func (r *recordingAudit) Record(ctx context.Context, event audit.Event) {
+	r.events = append(r.events, event)
+}
+
+func (r *recordingAudit) List(ctx context.Context, filter audit.Filter) ([]*audit.Event, error) {
+	return nil, nil
+}
+
+func TestAudit(t *testing.T) {
+	tokens, err := auth.NewTokenIssuer([]byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	if err != nil {
+		t.Fatalf("unable to create issuer: %v", err)
+	}
+	validToken, _, err := tokens.Issue(42, 0)
+	if err != nil {
+		t.Fatalf("unable to issue token: %v", err)
+	}
+	// admin 7 acting as user 42
+	impersonationToken, _, err := tokens.IssueImpersonation(7, 42, 0, auth.IssuableScopes, time.Hour)
+	if err != nil {
+		t.Fatalf("unable to issue token: %v", err)
+	}
+
+	testTable := []struct {
+		name        string
+		inputPath   string
+		inputHeader string
+		wantStatus  int
+		wantEvent   audit.Type // empty for none
+		wantUserID  int
+		wantActorID int
+	}{
+		{
+			name:        "valid-allowed-not-recorded",
+			inputPath:   "/expenses",
+			inputHeader: "Bearer " + validToken,
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "valid-missing-token-recorded",
+			inputPath:   "/expenses",
+			inputHeader: "",
+			wantStatus:  http.StatusUnauthorized,
+			wantEvent:   audit.TypeUnauthorized,
+		},
+		{
+			name:        "valid-forbidden-recorded-with-user",
+			inputPath:   "/admin",
+			inputHeader: "Bearer " + validToken,
+			wantStatus:  http.StatusForbidden,
+			wantEvent:   audit.TypeForbidden,
+			wantUserID:  42,
+		},
+		{
+			name:        "valid-impersonated-allowed-recorded",
+			inputPath:   "/expenses",
+			inputHeader: "Bearer " + impersonationToken,
+			wantStatus:  http.StatusOK,
+			wantEvent:   audit.TypeImpersonatedRequest,
+			wantUserID:  42,
+			wantActorID: 7,
+		},
+		{
+			name:        "valid-impersonated-forbidden-recorded",
+			inputPath:   "/admin",
+			inputHeader: "Bearer " + impersonationToken,
+			wantStatus:  http.StatusForbidden,
+			wantEvent:   audit.TypeImpersonatedRequest,
+			wantUserID:  42,
+			wantActorID: 7,
+		},
+		{
+			name:        "valid-handler-recorded-skipped",
+			inputPath:   "/login",
+			inputHeader: "",
+			wantStatus:  http.StatusUnauthorized,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			recorder := &recordingAudit{}
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(middleware.Audit(recorder))
+			r.GET("/expenses", middleware.RequireAuth(tokens, nil), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			r.GET("/admin", middleware.RequireAuth(tokens, nil), func(c *gin.Context) {
+				c.AbortWithStatus(http.StatusForbidden)
+			})
+			r.GET("/login", func(c *gin.Context) {
+				c.Set(audit.RecordedKey, true)
+				c.AbortWithStatus(http.StatusUnauthorized)
+			})
+
+			// gin trusts every proxy by default, so the forwarded address is taken as the client's
+			req := httptest.NewRequest(http.MethodGet, testCase.inputPath, nil)
+			req.RemoteAddr = "192.168.0.2:5000"
+			req.Header.Set("X-Forwarded-For", "203.0.113.9")
+			if testCase.inputHeader != "" {
+				req.Header.Set("Authorization", testCase.inputHeader)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != testCase.wantStatus {
+				t.Errorf("got status: %d, want: %d", w.Code, testCase.wantStatus)
+			}
+
+			if testCase.wantEvent == "" {
+				if len(recorder.events) != 0 {
+					t.Errorf("got events: %+v, want none", recorder.events)
+				}
+				return
+			}
+
+			if len(recorder.events) != 1 {
+				t.Fatalf("got %d events, want 1", len(recorder.events))
+			}
+			got := recorder.events[0]
+			if got.Type != testCase.wantEvent || got.UserID != testCase.wantUserID || got.Path != testCase.inputPath {
+				t.Errorf("got event: %+v, want type: %q, user: %d, path: %q", got, testCase.wantEvent, testCase.wantUserID, testCase.inputPath)
+			}
+			if got.ActorID != testCase.wantActorID {
+				t.Errorf("got actor: %d, want actor: %d", got.ActorID, testCase.wantActorID)
+			}
+			if got.IP != "203.0.113.9" || got.RemoteIP != "192.168.0.2" {
+				t.Errorf("got ip: %q, remote ip: %q, want ip: %q, remote ip: %q", got.IP, got.RemoteIP, "203.0.113.9", "192.168.0.2")
+			}
+		})
+	}
+}
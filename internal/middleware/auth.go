@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/users"
+)
+
+// bearerToken pulls the token out of an "Authorization: Bearer <token>" header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// abortUnauthorized sends 401 with the WWW-Authenticate challenge
+func abortUnauthorized(c *gin.Context, reason string) {
+	c.Header("WWW-Authenticate", `Bearer realm="expense-tracker-api"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: " + reason})
+}
+
+// TokenGenerations looks up a user's current token generation, it is implemented by users.Service
+type TokenGenerations interface {
+	TokenGeneration(ctx context.Context, userID int) (int, error)
+}
+
+// RequireAuth rejects requests without a valid Bearer JWT.
+// The user id from the token is put on the request context, see auth.UserIDFromContext
+//
+// When generations is set, tokens issued before the user last logged out everywhere are rejected.
+// It is checked on every request so that a stolen token stops working straight away.
+func RequireAuth(tokens *auth.TokenIssuer, generations TokenGenerations) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c.Request)
+		if !ok {
+			abortUnauthorized(c, "missing bearer token")
+			return
+		}
+
+		claims, err := tokens.Verify(token)
+		if err != nil {
+			abortUnauthorized(c, err.Error())
+			return
+		}
+
+		userID, err := claims.UserID()
+		if err != nil {
+			abortUnauthorized(c, err.Error())
+			return
+		}
+		actorID, impersonated, err := claims.ActorID()
+		if err != nil {
+			abortUnauthorized(c, err.Error())
+			return
+		}
+
+		if generations != nil {
+			generation, err := generations.TokenGeneration(c.Request.Context(), userID)
+			if err != nil {
+				if errors.Is(err, users.ErrUserNotFound) {
+					abortUnauthorized(c, err.Error())
+					return
+				}
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+				return
+			}
+			if claims.Generation != generation {
+				abortUnauthorized(c, auth.ErrRevokedToken.Error())
+				return
+			}
+		}
+
+		ctx := auth.WithUserID(c.Request.Context(), userID)
+		if scopes := claims.Scopes(); scopes != nil {
+			ctx = auth.WithScopes(ctx, scopes)
+		}
+		if impersonated {
+			ctx = auth.WithActorID(ctx, actorID)
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequireStaticToken rejects requests whose bearer token is not exactly token.
+// It is for operator endpoints, such as profiling, that sit outside of user accounts.
+func RequireStaticToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got, ok := bearerToken(c.Request)
+		if !ok {
+			abortUnauthorized(c, "missing bearer token")
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			abortUnauthorized(c, auth.ErrInvalidToken.Error())
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireScope rejects scoped tokens that do not have scope, it needs RequireAuth to run first.
+// Unscoped tokens, and requests when auth is disabled, are always let through.
+func RequireScope(scope auth.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.HasScope(c.Request.Context(), scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: token is missing the " + string(scope) + " scope"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireAdmin rejects users that are not admins, it needs RequireAuth to run first.
+// The flag is looked up on every request so that revoking it takes effect straight away.
+func RequireAdmin(userService users.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := auth.UserIDFromContext(c.Request.Context())
+		if !ok {
+			abortUnauthorized(c, "missing bearer token")
+			return
+		}
+
+		user, err := userService.GetProfile(c.Request.Context(), userID)
+		if err != nil {
+			if errors.Is(err, users.ErrUserNotFound) {
+				abortUnauthorized(c, err.Error())
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+			return
+		}
+
+		if !user.IsAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: admin only"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,355 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/middleware"
+	"github.com/nicholasss/expense-tracker-api/internal/users"
+)
+
+func TestRequireAuth(t *testing.T) {
+	tokens, err := auth.NewTokenIssuer([]byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	if err != nil {
+		t.Fatalf("unable to create issuer: %v", err)
+	}
+	validToken, _, err := tokens.Issue(42, 0)
+	if err != nil {
+		t.Fatalf("unable to issue token: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/expenses", middleware.RequireAuth(tokens, nil), func(c *gin.Context) {
+		userID, _ := auth.UserIDFromContext(c.Request.Context())
+		c.String(http.StatusOK, strconv.Itoa(userID))
+	})
+
+	testTable := []struct {
+		name        string
+		inputHeader string
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name:        "valid-bearer-token",
+			inputHeader: "Bearer " + validToken,
+			wantStatus:  http.StatusOK,
+			wantBody:    "42",
+		},
+		{
+			name:        "invalid-missing-header",
+			inputHeader: "",
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			name:        "invalid-wrong-scheme",
+			inputHeader: "Basic " + validToken,
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			name:        "invalid-bad-token",
+			inputHeader: "Bearer not.a.token",
+			wantStatus:  http.StatusUnauthorized,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/expenses", nil)
+			if testCase.inputHeader != "" {
+				req.Header.Set("Authorization", testCase.inputHeader)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != testCase.wantStatus {
+				t.Errorf("got status: %d, want status: %d", rec.Code, testCase.wantStatus)
+			}
+			if testCase.wantStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("missing WWW-Authenticate header on 401")
+			}
+			if testCase.wantBody != "" && rec.Body.String() != testCase.wantBody {
+				t.Errorf("got body: %q, want body: %q", rec.Body.String(), testCase.wantBody)
+			}
+		})
+	}
+}
+
+// profileService only implements GetProfile, the rest of users.Service is left nil
+type profileService struct {
+	users.Service
+	db map[int]*users.User
+}
+
+func (s *profileService) GetProfile(ctx context.Context, id int) (*users.User, error) {
+	user, ok := s.db[id]
+	if !ok {
+		return nil, users.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func TestRequireAdmin(t *testing.T) {
+	tokens, err := auth.NewTokenIssuer([]byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	if err != nil {
+		t.Fatalf("unable to create issuer: %v", err)
+	}
+	userService := &profileService{db: map[int]*users.User{
+		1: {ID: 1, Email: "admin@example.com", IsAdmin: true},
+		2: {ID: 2, Email: "ada@example.com"},
+	}}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin/expenses", middleware.RequireAuth(tokens, nil), middleware.RequireAdmin(userService), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	testTable := []struct {
+		name       string
+		inputUser  int
+		wantStatus int
+	}{
+		{
+			name:       "valid-admin",
+			inputUser:  1,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid-not-admin",
+			inputUser:  2,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "invalid-removed-user",
+			inputUser:  3,
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			token, _, err := tokens.Issue(testCase.inputUser, 0)
+			if err != nil {
+				t.Fatalf("unable to issue token: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/expenses", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != testCase.wantStatus {
+				t.Errorf("got status: %d, want status: %d", rec.Code, testCase.wantStatus)
+			}
+		})
+	}
+}
+
+// generationService returns token generations from a map
+type generationService map[int]int
+
+func (g generationService) TokenGeneration(ctx context.Context, userID int) (int, error) {
+	generation, ok := g[userID]
+	if !ok {
+		return 0, users.ErrUserNotFound
+	}
+	return generation, nil
+}
+
+func TestRequireAuthRevoked(t *testing.T) {
+	tokens, err := auth.NewTokenIssuer([]byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	if err != nil {
+		t.Fatalf("unable to create issuer: %v", err)
+	}
+	// user 1 logged out everywhere once
+	generations := generationService{1: 1, 2: 0}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/expenses", middleware.RequireAuth(tokens, generations), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	testTable := []struct {
+		name            string
+		inputUser       int
+		inputGeneration int
+		wantStatus      int
+	}{
+		{
+			name:            "valid-current-generation",
+			inputUser:       1,
+			inputGeneration: 1,
+			wantStatus:      http.StatusOK,
+		},
+		{
+			name:            "valid-never-revoked",
+			inputUser:       2,
+			inputGeneration: 0,
+			wantStatus:      http.StatusOK,
+		},
+		{
+			name:            "invalid-revoked-generation",
+			inputUser:       1,
+			inputGeneration: 0,
+			wantStatus:      http.StatusUnauthorized,
+		},
+		{
+			name:            "invalid-removed-user",
+			inputUser:       3,
+			inputGeneration: 0,
+			wantStatus:      http.StatusUnauthorized,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			token, _, err := tokens.Issue(testCase.inputUser, testCase.inputGeneration)
+			if err != nil {
+				t.Fatalf("unable to issue token: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/expenses", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != testCase.wantStatus {
+				t.Errorf("got status: %d, want status: %d", rec.Code, testCase.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	tokens, err := auth.NewTokenIssuer([]byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	if err != nil {
+		t.Fatalf("unable to create issuer: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.RequireAuth(tokens, nil))
+	r.GET("/expenses", middleware.RequireScope(auth.ScopeExpensesRead), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	r.GET("/users/me", middleware.RequireScope(auth.ScopeAccount), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	testTable := []struct {
+		name        string
+		inputScopes []auth.Scope
+		inputPath   string
+		wantStatus  int
+	}{
+		{
+			name:        "valid-unscoped-token",
+			inputScopes: nil,
+			inputPath:   "/expenses",
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "valid-unscoped-token-account",
+			inputScopes: nil,
+			inputPath:   "/users/me",
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "valid-read-scope",
+			inputScopes: []auth.Scope{auth.ScopeSummariesRead, auth.ScopeExpensesRead},
+			inputPath:   "/expenses",
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "invalid-create-only",
+			inputScopes: []auth.Scope{auth.ScopeExpensesCreate},
+			inputPath:   "/expenses",
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			name:        "invalid-scoped-token-account",
+			inputScopes: []auth.Scope{auth.ScopeExpensesRead, auth.ScopeExpensesWrite},
+			inputPath:   "/users/me",
+			wantStatus:  http.StatusForbidden,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			token, _, err := tokens.IssueScoped(42, 0, testCase.inputScopes, time.Hour)
+			if err != nil {
+				t.Fatalf("unable to issue token: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, testCase.inputPath, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != testCase.wantStatus {
+				t.Errorf("got status: %d, want status: %d", rec.Code, testCase.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRequireStaticToken(t *testing.T) {
+	const token = "fedcba9876543210fedcba9876543210"
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/debug/pprof/", middleware.RequireStaticToken(token), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	testTable := []struct {
+		name        string
+		inputHeader string
+		wantStatus  int
+	}{
+		{
+			name:        "valid-token",
+			inputHeader: "Bearer " + token,
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "invalid-missing-token",
+			inputHeader: "",
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			name:        "invalid-wrong-token",
+			inputHeader: "Bearer 0123456789abcdef0123456789abcdef",
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			name:        "invalid-token-prefix",
+			inputHeader: "Bearer " + token[:16],
+			wantStatus:  http.StatusUnauthorized,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+			if testCase.inputHeader != "" {
+				req.Header.Set("Authorization", testCase.inputHeader)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != testCase.wantStatus {
+				t.Errorf("got status: %d, want status: %d", rec.Code, testCase.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/i18n"
+	"github.com/nicholasss/expense-tracker-api/internal/respcache"
+)
+
+// cachedHeaders are replayed from a cached response, anything else depends on the request,
+// i.e. CORS and rate limit headers
+var cachedHeaders = []string{"Content-Type", "Link"}
+
+// cacheWriter keeps a copy of the body as it is written
+type cacheWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *cacheWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// CacheResponses answers GET requests from cache while their entry is fresh, otherwise the 200 response
+// of the handler is kept for next time. Entries are per user, so it goes after the auth middleware.
+// Responses are marked with X-Cache: HIT or MISS.
+func CacheResponses(cache *respcache.Cache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		// anonymous requests only happen with auth disabled, where everyone sees the same expenses.
+		// responses can have translated labels, so each locale has its own entry
+		userID, _ := auth.UserIDFromContext(c.Request.Context())
+		key := strconv.Itoa(userID) + " " + i18n.LocaleFromContext(c.Request.Context()) + " " + c.Request.URL.RequestURI()
+
+		if entry, ok := cache.Get(key); ok {
+			for _, name := range cachedHeaders {
+				if value := entry.Header.Get(name); value != "" {
+					c.Header(name, value)
+				}
+			}
+			c.Header("X-Cache", "HIT")
+			c.Status(entry.Status)
+			c.Writer.Write(entry.Body)
+			c.Abort()
+			return
+		}
+
+		// read first, an invalidation while the handler runs means its response may already be stale
+		generation := cache.Generation()
+
+		original := c.Writer
+		writer := &cacheWriter{ResponseWriter: original}
+		c.Writer = writer
+		c.Header("X-Cache", "MISS")
+
+		c.Next()
+
+		c.Writer = original
+
+		// a timed out request may have had its error swallowed, see Timeout
+		if writer.Status() != http.StatusOK || c.IsAborted() || c.Request.Context().Err() != nil {
+			return
+		}
+
+		header := make(http.Header, len(cachedHeaders))
+		for _, name := range cachedHeaders {
+			if value := writer.Header().Get(name); value != "" {
+				header.Set(name, value)
+			}
+		}
+		cache.Set(key, generation, &respcache.Entry{Status: http.StatusOK, Header: header, Body: writer.body.Bytes()})
+	}
+}
@@ -0,0 +1,120 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/middleware"
+	"github.com/nicholasss/expense-tracker-api/internal/respcache"
+)
+
+func TestCacheResponses(t *testing.T) {
+	type request struct {
+		path   string
+		userID int
+	}
+
+	testTable := []struct {
+		name         string
+		inputFirst   request
+		inputSecond  request
+		invalidate   bool
+		wantXCache   string
+		wantHandlers int
+	}{
+		{
+			name:         "valid-repeat-is-hit",
+			inputFirst:   request{path: "/expenses", userID: 1},
+			inputSecond:  request{path: "/expenses", userID: 1},
+			wantXCache:   "HIT",
+			wantHandlers: 1,
+		},
+		{
+			name:         "valid-other-user-is-miss",
+			inputFirst:   request{path: "/expenses", userID: 1},
+			inputSecond:  request{path: "/expenses", userID: 2},
+			wantXCache:   "MISS",
+			wantHandlers: 2,
+		},
+		{
+			name:         "valid-other-query-is-miss",
+			inputFirst:   request{path: "/expenses", userID: 1},
+			inputSecond:  request{path: "/expenses?limit=5", userID: 1},
+			wantXCache:   "MISS",
+			wantHandlers: 2,
+		},
+		{
+			name:         "valid-invalidated-is-miss",
+			inputFirst:   request{path: "/expenses", userID: 1},
+			inputSecond:  request{path: "/expenses", userID: 1},
+			invalidate:   true,
+			wantXCache:   "MISS",
+			wantHandlers: 2,
+		},
+		{
+			name:         "valid-error-not-cached",
+			inputFirst:   request{path: "/fail", userID: 1},
+			inputSecond:  request{path: "/fail", userID: 1},
+			wantXCache:   "MISS",
+			wantHandlers: 2,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			cache := respcache.New("test", time.Minute, 10)
+			handlers := 0
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				userID, _ := strconv.Atoi(c.GetHeader("X-User"))
+				c.Request = c.Request.WithContext(auth.WithUserID(c.Request.Context(), userID))
+			})
+			r.Use(middleware.CacheResponses(cache))
+			r.GET("/expenses", func(c *gin.Context) {
+				handlers += 1
+				c.Header("Link", `</expenses?cursor=abc>; rel="next"`)
+				c.JSON(http.StatusOK, gin.H{"handled": handlers})
+			})
+			r.GET("/fail", func(c *gin.Context) {
+				handlers += 1
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+			})
+
+			var first *httptest.ResponseRecorder
+			for i, req := range []request{testCase.inputFirst, testCase.inputSecond} {
+				if i == 1 && testCase.invalidate {
+					cache.Invalidate()
+				}
+
+				httpReq := httptest.NewRequest(http.MethodGet, req.path, nil)
+				httpReq.Header.Set("X-User", strconv.Itoa(req.userID))
+				rec := httptest.NewRecorder()
+				r.ServeHTTP(rec, httpReq)
+
+				if i == 0 {
+					first = rec
+					continue
+				}
+				if got := rec.Header().Get("X-Cache"); got != testCase.wantXCache {
+					t.Errorf("got X-Cache: %q, want: %q", got, testCase.wantXCache)
+				}
+				if testCase.wantXCache == "HIT" {
+					if rec.Body.String() != first.Body.String() || rec.Header().Get("Link") != first.Header().Get("Link") {
+						t.Errorf("got cached response: %q, want: %q", rec.Body.String(), first.Body.String())
+					}
+				}
+			}
+
+			if handlers != testCase.wantHandlers {
+				t.Errorf("got %d handler calls, want %d", handlers, testCase.wantHandlers)
+			}
+		})
+	}
+}
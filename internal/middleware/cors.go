@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// corsExposedHeaders are the response headers browsers may read, beyond the CORS safelisted ones
+var corsExposedHeaders = []string{
+	"Location", "Link", "Retry-After", "Deprecation", "Sunset",
+	"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset",
+}
+
+// CORSConfig lists what cross-origin browsers are allowed to do.
+// An AllowedOrigins entry of "*" allows any origin.
+type CORSConfig struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	MaxAge         time.Duration
+}
+
+// corsRules is a CORSConfig prepared for answering requests
+type corsRules struct {
+	allowedOrigins []string
+	anyOrigin      bool
+	allowMethods   string
+	allowHeaders   string
+	maxAge         string
+}
+
+func newCORSRules(cfg CORSConfig) *corsRules {
+	return &corsRules{
+		allowedOrigins: cfg.AllowedOrigins,
+		anyOrigin:      slices.Contains(cfg.AllowedOrigins, "*"),
+		allowMethods:   strings.Join(cfg.AllowedMethods, ", "),
+		allowHeaders:   strings.Join(cfg.AllowedHeaders, ", "),
+		maxAge:         strconv.Itoa(int(cfg.MaxAge.Seconds())),
+	}
+}
+
+// CORSPolicy is a CORSConfig that can be replaced while requests are being served.
+// With no allowed origins the middleware does nothing.
+type CORSPolicy struct {
+	rules atomic.Pointer[corsRules]
+}
+
+// NewCORSPolicy starts a policy with cfg
+func NewCORSPolicy(cfg CORSConfig) *CORSPolicy {
+	p := &CORSPolicy{}
+	p.Set(cfg)
+	return p
+}
+
+// Set replaces the policy, taking effect from the next request
+func (p *CORSPolicy) Set(cfg CORSConfig) {
+	p.rules.Store(newCORSRules(cfg))
+}
+
+// CORS answers preflight requests and adds the Access-Control-* headers for allowed origins.
+// Requests from other origins are passed through without the headers, so browsers block them.
+// It needs to run before the routes so that OPTIONS requests never reach them.
+func CORS(cfg CORSConfig) gin.HandlerFunc {
+	return NewCORSPolicy(cfg).Middleware()
+}
+
+// Middleware applies the current policy, see CORS
+func (p *CORSPolicy) Middleware() gin.HandlerFunc {
+	exposeHeaders := strings.Join(corsExposedHeaders, ", ")
+
+	return func(c *gin.Context) {
+		rules := p.rules.Load()
+
+		origin := c.GetHeader("Origin")
+		if origin == "" || len(rules.allowedOrigins) == 0 {
+			c.Next()
+			return
+		}
+
+		// caches need to know the response depends on the origin
+		c.Writer.Header().Add("Vary", "Origin")
+
+		if !rules.anyOrigin && !slices.Contains(rules.allowedOrigins, origin) {
+			c.Next()
+			return
+		}
+
+		if rules.anyOrigin {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+
+		// a preflight asks before sending the real request
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			c.Header("Access-Control-Allow-Methods", rules.allowMethods)
+			c.Header("Access-Control-Allow-Headers", rules.allowHeaders)
+			c.Header("Access-Control-Max-Age", rules.maxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Header("Access-Control-Expose-Headers", exposeHeaders)
+		c.Next()
+	}
+}
@@ -0,0 +1,155 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/middleware"
+)
+
+func TestCORS(t *testing.T) {
+	testTable := []struct {
+		name           string
+		inputOrigins   []string
+		inputMethod    string
+		inputOrigin    string
+		inputPreflight bool
+		wantStatus     int
+		wantAllow      string
+		wantMethods    string
+	}{
+		{
+			name:         "valid-simple-request",
+			inputOrigins: []string{"https://app.example.com"},
+			inputMethod:  http.MethodGet,
+			inputOrigin:  "https://app.example.com",
+			wantStatus:   http.StatusOK,
+			wantAllow:    "https://app.example.com",
+		},
+		{
+			name:           "valid-preflight",
+			inputOrigins:   []string{"https://app.example.com"},
+			inputMethod:    http.MethodOptions,
+			inputOrigin:    "https://app.example.com",
+			inputPreflight: true,
+			wantStatus:     http.StatusNoContent,
+			wantAllow:      "https://app.example.com",
+			wantMethods:    "GET, POST",
+		},
+		{
+			name:         "valid-any-origin",
+			inputOrigins: []string{"*"},
+			inputMethod:  http.MethodGet,
+			inputOrigin:  "https://elsewhere.example.com",
+			wantStatus:   http.StatusOK,
+			wantAllow:    "*",
+		},
+		{
+			name:         "valid-same-origin-no-header",
+			inputOrigins: []string{"https://app.example.com"},
+			inputMethod:  http.MethodGet,
+			inputOrigin:  "",
+			wantStatus:   http.StatusOK,
+			wantAllow:    "",
+		},
+		{
+			name:           "invalid-unknown-origin-preflight",
+			inputOrigins:   []string{"https://app.example.com"},
+			inputMethod:    http.MethodOptions,
+			inputOrigin:    "https://evil.example.com",
+			inputPreflight: true,
+			wantStatus:     http.StatusNotFound,
+			wantAllow:      "",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(middleware.CORS(middleware.CORSConfig{
+				AllowedOrigins: testCase.inputOrigins,
+				AllowedMethods: []string{"GET", "POST"},
+				AllowedHeaders: []string{"Authorization", "Content-Type"},
+				MaxAge:         10 * time.Minute,
+			}))
+			r.GET("/expenses", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(testCase.inputMethod, "/expenses", nil)
+			if testCase.inputOrigin != "" {
+				req.Header.Set("Origin", testCase.inputOrigin)
+			}
+			if testCase.inputPreflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != testCase.wantStatus {
+				t.Errorf("got status: %d, want status: %d", rec.Code, testCase.wantStatus)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != testCase.wantAllow {
+				t.Errorf("got Access-Control-Allow-Origin: %q, want: %q", got, testCase.wantAllow)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Methods"); got != testCase.wantMethods {
+				t.Errorf("got Access-Control-Allow-Methods: %q, want: %q", got, testCase.wantMethods)
+			}
+		})
+	}
+}
+
+func TestCORSPolicySet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	policy := middleware.NewCORSPolicy(middleware.CORSConfig{})
+	r := gin.New()
+	r.Use(policy.Middleware())
+	r.GET("/expenses", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	testTable := []struct {
+		name         string
+		inputOrigins []string
+		wantAllow    string
+		wantVary     string
+	}{
+		{
+			name:         "valid-disabled",
+			inputOrigins: nil,
+			wantAllow:    "",
+			wantVary:     "",
+		},
+		{
+			name:         "valid-origin-added",
+			inputOrigins: []string{"https://app.example.com"},
+			wantAllow:    "https://app.example.com",
+			wantVary:     "Origin",
+		},
+		{
+			name:         "invalid-origin-removed",
+			inputOrigins: []string{"https://admin.example.com"},
+			wantAllow:    "",
+			wantVary:     "Origin",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			policy.Set(middleware.CORSConfig{AllowedOrigins: testCase.inputOrigins})
+
+			req := httptest.NewRequest(http.MethodGet, "/expenses", nil)
+			req.Header.Set("Origin", "https://app.example.com")
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != testCase.wantAllow {
+				t.Errorf("got Access-Control-Allow-Origin: %q, want: %q", got, testCase.wantAllow)
+			}
+			if got := rec.Header().Get("Vary"); got != testCase.wantVary {
+				t.Errorf("got Vary: %q, want: %q", got, testCase.wantVary)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RequestCounter is told about every answered request, i.e. *opstats.Service
+type RequestCounter interface {
+	ObserveRequest(route string, status int)
+}
+
+// CountRequests counts each request under its method and route pattern, i.e. "GET /expenses/:id",
+// so ids in paths don't split a route up. Paths matching no route are counted together per method.
+// It needs to run before Recovery to count panics as the 500s they are answered with.
+func CountRequests(counter RequestCounter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "(unmatched)"
+		}
+		counter.ObserveRequest(c.Request.Method+" "+route, c.Writer.Status())
+	}
+}
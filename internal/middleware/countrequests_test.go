@@ -0,0 +1,66 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/middleware"
+)
+
+// recordingCounter keeps every observed request as "route status"
+type recordingCounter struct {
+	observed []string
+}
+
+func (rc *recordingCounter) ObserveRequest(route string, status int) {
+	rc.observed = append(rc.observed, route+" "+http.StatusText(status))
+}
+
+func TestCountRequests(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputMethod string
+		inputPath   string
+		want        string
+	}{
+		{
+			name:        "valid-route-pattern",
+			inputMethod: http.MethodGet,
+			inputPath:   "/expenses/42",
+			want:        "GET /expenses/:id OK",
+		},
+		{
+			name:        "valid-panic-counted-as-500",
+			inputMethod: http.MethodDelete,
+			inputPath:   "/expenses/42",
+			want:        "DELETE /expenses/:id Internal Server Error",
+		},
+		{
+			name:        "valid-unmatched-path",
+			inputMethod: http.MethodGet,
+			inputPath:   "/wp-login.php",
+			want:        "GET (unmatched) Not Found",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			counter := &recordingCounter{}
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(middleware.CountRequests(counter), middleware.Recovery())
+			r.GET("/expenses/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+			r.DELETE("/expenses/:id", func(c *gin.Context) { panic("boom") })
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(testCase.inputMethod, testCase.inputPath, nil))
+
+			if !slices.Equal(counter.observed, []string{testCase.want}) {
+				t.Errorf("got observed: %q, want: %q", counter.observed, testCase.want)
+			}
+		})
+	}
+}
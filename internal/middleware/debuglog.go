@@ -0,0 +1,204 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// redacted replaces sensitive values in debug logs
+const redacted = "[REDACTED]"
+
+// defaultMaxDebugBody is how much of each body is logged when DebugLogConfig.MaxBodyBytes is 0
+const defaultMaxDebugBody = 4096
+
+// debugRedactedHeaders are never logged, whatever the config
+var debugRedactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// DebugRedactedFields are the JSON fields and query parameters always redacted from debug logs, at any depth.
+// They cover the passwords, tokens, and two factor secrets the account routes send and receive,
+// and the code and state of an OIDC callback.
+var DebugRedactedFields = []string{
+	"password", "current_password", "new_password",
+	"token", "code", "state", "secret", "uri", "recovery_codes",
+}
+
+// DebugLogConfig picks what DebugLog leaves out
+type DebugLogConfig struct {
+	// RedactFields are JSON fields and query parameters redacted on top of DebugRedactedFields, matched without case
+	RedactFields []string
+	// MaxBodyBytes is how much of a body is logged, bodies are cut off after it
+	MaxBodyBytes int
+	// Logger defaults to slog.Default()
+	Logger *slog.Logger
+}
+
+// bodyRecorder keeps a copy of the start of the response body as it is written
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body  bytes.Buffer
+	limit int
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.keep(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.keep([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyRecorder) keep(b []byte) {
+	if room := w.limit - w.body.Len(); room > 0 {
+		w.body.Write(b[:min(len(b), room)])
+	}
+}
+
+// DebugLog logs the headers and bodies of every request and response at debug level, for troubleshooting.
+// Auth headers and the configured JSON fields are redacted, and bodies that are not JSON only have
+// their size logged. It costs nothing unless the logger has debug enabled, so it can stay installed
+// while the log level is changed.
+func DebugLog(cfg DebugLogConfig) gin.HandlerFunc {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	limit := cfg.MaxBodyBytes
+	if limit <= 0 {
+		limit = defaultMaxDebugBody
+	}
+
+	fields := make(map[string]bool)
+	for _, field := range slices.Concat(DebugRedactedFields, cfg.RedactFields) {
+		fields[strings.ToLower(field)] = true
+	}
+
+	return func(c *gin.Context) {
+		if !logger.Enabled(c.Request.Context(), slog.LevelDebug) {
+			c.Next()
+			return
+		}
+
+		// read the start of the body and put it back in front of the rest
+		var reqBody []byte
+		if c.Request.Body != nil {
+			var err error
+			reqBody, err = io.ReadAll(io.LimitReader(c.Request.Body, int64(limit)))
+			if err != nil {
+				logger.Debug("debug log: unable to read request body", "error", err)
+			}
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(reqBody), c.Request.Body), c.Request.Body}
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer, limit: limit}
+		c.Writer = recorder
+
+		logger.Debug("request",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"query", redactQuery(c.Request.URL.RawQuery, fields),
+			"headers", redactHeaders(c.Request.Header),
+			"body", redactBody(c.ContentType(), reqBody, fields),
+		)
+
+		c.Next()
+
+		logger.Debug("response",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", recorder.Status(),
+			"headers", redactHeaders(recorder.Header()),
+			"body", redactBody(recorder.Header().Get("Content-Type"), recorder.body.Bytes(), fields),
+		)
+	}
+}
+
+// redactHeaders flattens header, replacing the auth headers
+func redactHeaders(header http.Header) map[string]string {
+	flat := make(map[string]string, len(header))
+	for key, values := range header {
+		flat[key] = strings.Join(values, ", ")
+	}
+	for _, key := range debugRedactedHeaders {
+		if _, ok := flat[key]; ok {
+			flat[key] = redacted
+		}
+	}
+	return flat
+}
+
+// redactQuery replaces the values of fields in a raw query, keeping the rest as it was sent
+func redactQuery(rawQuery string, fields map[string]bool) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		if fields[strings.ToLower(key)] {
+			params[i] = key + "=" + redacted
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// redactBody is the body as logged, JSON with fields redacted or just the size of anything else.
+// A cut off JSON body can't be parsed, so it is treated like anything else.
+func redactBody(contentType string, body []byte, fields map[string]bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	if strings.HasPrefix(contentType, "application/json") {
+		var value any
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err == nil {
+			if out, err := json.Marshal(redactValue(value, fields)); err == nil {
+				return string(out)
+			}
+		}
+	}
+
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	if mediaType == "" {
+		mediaType = "unknown type"
+	}
+	return "<" + strings.TrimSpace(mediaType) + ", " + strconv.Itoa(len(body)) + " bytes>"
+}
+
+// redactValue replaces the values of fields in every object within value
+func redactValue(value any, fields map[string]bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, inner := range v {
+			if fields[strings.ToLower(key)] {
+				v[key] = redacted
+			} else {
+				v[key] = redactValue(inner, fields)
+			}
+		}
+	case []any:
+		for i, inner := range v {
+			v[i] = redactValue(inner, fields)
+		}
+	}
+	return value
+}
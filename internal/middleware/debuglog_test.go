@@ -0,0 +1,127 @@
+package middleware_test
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/middleware"
+)
+
+func TestDebugLog(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputLevel  slog.Level
+		inputFields []string
+		inputQuery  string
+		inputType   string
+		inputBody   string
+		inputHeader string
+		wantSubstr  []string // empty for no log lines
+		dontWant    []string
+	}{
+		{
+			name:        "valid-login-redacted",
+			inputLevel:  slog.LevelDebug,
+			inputType:   "application/json",
+			inputBody:   `{"email":"ada@example.com","password":"hunter2hunter2"}`,
+			inputHeader: "Bearer secret-token",
+			wantSubstr:  []string{"ada@example.com", `\"password\":\"[REDACTED]\"`, `"Authorization":"[REDACTED]"`, `\"token\":\"[REDACTED]\"`, `"status":200`},
+			dontWant:    []string{"hunter2hunter2", "secret-token", "issued-token"},
+		},
+		{
+			name:        "valid-configured-field-redacted",
+			inputLevel:  slog.LevelDebug,
+			inputFields: []string{"Email"},
+			inputType:   "application/json",
+			inputBody:   `{"email":"ada@example.com","password":"hunter2hunter2"}`,
+			wantSubstr:  []string{`\"email\":\"[REDACTED]\"`},
+			dontWant:    []string{"ada@example.com", "hunter2hunter2"},
+		},
+		{
+			name:        "valid-query-redacted",
+			inputLevel:  slog.LevelDebug,
+			inputFields: []string{"Email"},
+			inputQuery:  "code=oidc-code&STATE=oidc-state&next=%2Fexpenses&e%6Dail=ada%40example.com",
+			inputType:   "application/json",
+			inputBody:   `{}`,
+			wantSubstr:  []string{`"query":"code=[REDACTED]&STATE=[REDACTED]&next=%2Fexpenses&email=[REDACTED]"`},
+			dontWant:    []string{"oidc-code", "oidc-state", "ada%40example.com"},
+		},
+		{
+			name:       "valid-non-json-size-only",
+			inputLevel: slog.LevelDebug,
+			inputType:  "text/csv",
+			inputBody:  "description,amount\npassword,100",
+			wantSubstr: []string{"<text/csv, 31 bytes>"},
+			dontWant:   []string{"password,100"},
+		},
+		{
+			name:       "valid-info-level-silent",
+			inputLevel: slog.LevelInfo,
+			inputType:  "application/json",
+			inputBody:  `{"email":"ada@example.com","password":"hunter2hunter2"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: testCase.inputLevel}))
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(middleware.DebugLog(middleware.DebugLogConfig{RedactFields: testCase.inputFields, Logger: logger}))
+
+			var gotBody string
+			r.POST("/users/login", func(c *gin.Context) {
+				b, err := io.ReadAll(c.Request.Body)
+				if err != nil {
+					t.Errorf("unable to read body: %v", err)
+				}
+				gotBody = string(b)
+				c.JSON(http.StatusOK, gin.H{"token": "issued-token"})
+			})
+
+			target := "/users/login"
+			if testCase.inputQuery != "" {
+				target += "?" + testCase.inputQuery
+			}
+			req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(testCase.inputBody))
+			req.Header.Set("Content-Type", testCase.inputType)
+			if testCase.inputHeader != "" {
+				req.Header.Set("Authorization", testCase.inputHeader)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			// the handler and client are unaffected by the logging
+			if gotBody != testCase.inputBody {
+				t.Errorf("handler got body: %q, want: %q", gotBody, testCase.inputBody)
+			}
+			if !strings.Contains(rec.Body.String(), "issued-token") {
+				t.Errorf("client got body: %q, want the token", rec.Body.String())
+			}
+
+			got := out.String()
+			if len(testCase.wantSubstr) == 0 && got != "" {
+				t.Errorf("got log: %q, want none", got)
+			}
+			for _, want := range testCase.wantSubstr {
+				if !strings.Contains(got, want) {
+					t.Errorf("got log: %q, want it to contain: %q", got, want)
+				}
+			}
+			for _, dontWant := range testCase.dontWant {
+				if strings.Contains(got, dontWant) {
+					t.Errorf("got log: %q, do not want: %q", got, dontWant)
+				}
+			}
+		})
+	}
+}
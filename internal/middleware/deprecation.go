@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecation describes a route that is being phased out
+type Deprecation struct {
+	Since     time.Time // when the route was deprecated, sent as the Deprecation header (RFC 9745)
+	Sunset    time.Time // when the route will be removed, optional (RFC 8594)
+	Successor string    // path of the route replacing it, optional
+	Info      string    // link to migration notes, optional
+}
+
+// Deprecated marks a route as deprecated, i.e. r.PUT("/expenses", middleware.Deprecated(d), h.UpdateExpenseByBody)
+// Responses carry the Deprecation and Sunset headers, with Link headers pointing at the replacement.
+func Deprecated(d Deprecation) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(d.Since.Unix(), 10)
+
+	links := make([]string, 0, 2)
+	if d.Successor != "" {
+		links = append(links, "<"+d.Successor+`>; rel="successor-version"`)
+	}
+	if d.Info != "" {
+		links = append(links, "<"+d.Info+`>; rel="deprecation"; type="text/html"`)
+	}
+	link := strings.Join(links, ", ")
+
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		if !d.Sunset.IsZero() {
+			c.Header("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if link != "" {
+			c.Header("Link", link)
+		}
+
+		c.Next()
+	}
+}
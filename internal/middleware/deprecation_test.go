@@ -0,0 +1,64 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/middleware"
+)
+
+func TestDeprecated(t *testing.T) {
+	testTable := []struct {
+		name            string
+		inputDeprecated middleware.Deprecation
+		wantDeprecation string
+		wantSunset      string
+		wantLink        string
+	}{
+		{
+			name: "valid-all-fields",
+			inputDeprecated: middleware.Deprecation{
+				Since:     time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC),
+				Sunset:    time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC),
+				Successor: "/v2/expenses",
+				Info:      "https://example.com/migrating",
+			},
+			wantDeprecation: "@1761955200",
+			wantSunset:      "Fri, 01 May 2026 00:00:00 GMT",
+			wantLink:        `</v2/expenses>; rel="successor-version", <https://example.com/migrating>; rel="deprecation"; type="text/html"`,
+		},
+		{
+			name: "valid-only-since",
+			inputDeprecated: middleware.Deprecation{
+				Since: time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC),
+			},
+			wantDeprecation: "@1761955200",
+			wantSunset:      "",
+			wantLink:        "",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.GET("/expenses", middleware.Deprecated(testCase.inputDeprecated), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/expenses", nil))
+
+			if got := rec.Header().Get("Deprecation"); got != testCase.wantDeprecation {
+				t.Errorf("got Deprecation: %q, want: %q", got, testCase.wantDeprecation)
+			}
+			if got := rec.Header().Get("Sunset"); got != testCase.wantSunset {
+				t.Errorf("got Sunset: %q, want: %q", got, testCase.wantSunset)
+			}
+			if got := rec.Header().Get("Link"); got != testCase.wantLink {
+				t.Errorf("got Link: %q, want: %q", got, testCase.wantLink)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/errreport"
+)
+
+// ErrorReporter takes the events ReportErrors captures, i.e. *errreport.Reporter
+type ErrorReporter interface {
+	Capture(event errreport.Event)
+}
+
+// ReportErrors sends the panics Recovery catches, and every other 5xx response, to reporter
+// with the request they happened in. It needs to run before Recovery to see the panics.
+func ReportErrors(reporter ErrorReporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		status := c.Writer.Status()
+		event := errreport.Event{
+			Request:  c.Request,
+			Route:    c.FullPath(),
+			Status:   status,
+			ClientIP: c.ClientIP(),
+		}
+		if userID, ok := auth.UserIDFromContext(c.Request.Context()); ok {
+			event.UserID = userID
+		}
+
+		if value, ok := c.Get(PanicKey); ok {
+			recovered := value.(*RecoveredPanic)
+			event.Type = "panic"
+			event.Message = fmt.Sprint(recovered.Value)
+			event.Stack = recovered.Stack
+			reporter.Capture(event)
+			return
+		}
+
+		if status < 500 {
+			return
+		}
+
+		// handlers that attach their error with c.Error give the real cause
+		event.Type = "http " + strconv.Itoa(status)
+		event.Message = fmt.Sprintf("%d response to %s %s", status, c.Request.Method, c.FullPath())
+		if len(c.Errors) > 0 {
+			event.Message = c.Errors.String()
+		}
+		reporter.Capture(event)
+	}
+}
@@ -0,0 +1,103 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/errreport"
+	"github.com/nicholasss/expense-tracker-api/internal/middleware"
+)
+
+// recordingReporter keeps captured events in memory
+type recordingReporter struct {
+	events []errreport.Event
+}
+
+func (r *recordingReporter) Capture(event errreport.Event) {
+	r.events = append(r.events, event)
+}
+
+func TestReportErrors(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputPath   string
+		wantStatus  int
+		wantType    string // empty for no event
+		wantMessage string
+		wantStack   bool
+	}{
+		{
+			name:       "valid-ok-not-reported",
+			inputPath:  "/ok",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "valid-client-error-not-reported",
+			inputPath:  "/missing",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:        "valid-panic-reported-with-stack",
+			inputPath:   "/panic",
+			wantStatus:  http.StatusInternalServerError,
+			wantType:    "panic",
+			wantMessage: "boom",
+			wantStack:   true,
+		},
+		{
+			name:        "valid-server-error-reported",
+			inputPath:   "/fail",
+			wantStatus:  http.StatusInternalServerError,
+			wantType:    "http 500",
+			wantMessage: "database is locked",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			reporter := &recordingReporter{}
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(middleware.ReportErrors(reporter), middleware.Recovery())
+			r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+			r.GET("/panic", func(c *gin.Context) { panic("boom") })
+			r.GET("/fail", func(c *gin.Context) {
+				_ = c.Error(errors.New("database is locked"))
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+			})
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, testCase.inputPath, nil))
+
+			if rec.Code != testCase.wantStatus {
+				t.Errorf("got status: %d, want status: %d", rec.Code, testCase.wantStatus)
+			}
+
+			if testCase.wantType == "" {
+				if len(reporter.events) != 0 {
+					t.Errorf("got events: %+v, want none", reporter.events)
+				}
+				return
+			}
+
+			if len(reporter.events) != 1 {
+				t.Fatalf("got %d events, want 1", len(reporter.events))
+			}
+			got := reporter.events[0]
+			if got.Type != testCase.wantType || !strings.Contains(got.Message, testCase.wantMessage) {
+				t.Errorf("got event type: %q, message: %q, want type: %q, message: %q", got.Type, got.Message, testCase.wantType, testCase.wantMessage)
+			}
+			if (len(got.Stack) > 0) != testCase.wantStack {
+				t.Errorf("got %d stack frames, want stack: %v", len(got.Stack), testCase.wantStack)
+			}
+			if got.Route != testCase.inputPath || got.Status != testCase.wantStatus {
+				t.Errorf("got route: %q, status: %d", got.Route, got.Status)
+			}
+		})
+	}
+}
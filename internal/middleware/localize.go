@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/i18n"
+)
+
+// localizeWriter holds back error responses, so their messages can be translated before they are sent
+type localizeWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// holding is whether writes are held back, only error responses are
+func (w *localizeWriter) holding() bool {
+	return w.ResponseWriter.Status() >= http.StatusBadRequest && !w.ResponseWriter.Written()
+}
+
+func (w *localizeWriter) Write(b []byte) (int, error) {
+	if w.holding() {
+		return w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *localizeWriter) WriteString(s string) (int, error) {
+	if w.holding() {
+		return w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Written counts the held back body, so nothing after the handler answers a second time
+func (w *localizeWriter) Written() bool {
+	return w.body.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// translateBody translates the "error" of a JSON error body, and of each problem under "errors".
+// Anything it can't read is sent as it is
+func translateBody(locale string, body []byte) []byte {
+	var decoded map[string]any
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return body
+	}
+
+	if message, ok := decoded["error"].(string); ok {
+		decoded["error"] = i18n.TranslateError(locale, message)
+	}
+	if problems, ok := decoded["errors"].([]any); ok {
+		for _, problem := range problems {
+			if problem, ok := problem.(map[string]any); ok {
+				if message, ok := problem["error"].(string); ok {
+					problem["error"] = i18n.Translate(locale, message)
+				}
+			}
+		}
+	}
+
+	translated, err := json.Marshal(decoded)
+	if err != nil {
+		return body
+	}
+	return translated
+}
+
+// Localize picks the locale of each request from its Accept-Language header, sets it on the request context
+// for handlers to translate with, and translates the messages of JSON error responses to it.
+// Responses say which locale they are in with Content-Language
+func Localize(matcher *i18n.Matcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := matcher.Match(c.GetHeader("Accept-Language"))
+		c.Request = c.Request.WithContext(i18n.WithLocale(c.Request.Context(), locale))
+		c.Header("Content-Language", locale)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+
+		// messages are written in English already
+		if locale == i18n.English {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		writer := &localizeWriter{ResponseWriter: original}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = original
+		if writer.body.Len() == 0 {
+			return
+		}
+
+		body := writer.body.Bytes()
+		if strings.HasPrefix(original.Header().Get("Content-Type"), "application/json") {
+			body = translateBody(locale, body)
+		}
+		original.Write(body)
+	}
+}
@@ -0,0 +1,121 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/i18n"
+	"github.com/nicholasss/expense-tracker-api/internal/middleware"
+)
+
+func TestLocalize(t *testing.T) {
+	matcher, err := i18n.NewMatcher(i18n.English)
+	if err != nil {
+		t.Fatalf("NewMatcher() got error: '%v'", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Localize(matcher))
+	r.Use(middleware.Recovery())
+	r.GET("/label", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"label": i18n.T(c.Request.Context(), "This Month")})
+	})
+	r.GET("/missing", func(c *gin.Context) {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not Found: provided id does not have a record", "field": "id"})
+	})
+	r.GET("/problems", func(c *gin.Context) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": "Bad Request: id needs to be greater than 0; invalid list filter",
+			"errors": []gin.H{
+				{"field": "id", "error": "id needs to be greater than 0"},
+				{"error": "invalid list filter"},
+			},
+		})
+	})
+	r.GET("/panic", func(c *gin.Context) {
+		panic("something went wrong")
+	})
+	r.GET("/text", func(c *gin.Context) {
+		c.String(http.StatusBadRequest, "Bad Request")
+	})
+
+	testTable := []struct {
+		name        string
+		inputPath   string
+		inputHeader string
+		wantStatus  int
+		wantBody    string
+		wantLocale  string
+	}{
+		{
+			name:        "valid-label-translated",
+			inputPath:   "/label",
+			inputHeader: "de-DE,de;q=0.9",
+			wantStatus:  http.StatusOK,
+			wantBody:    `{"label":"Dieser Monat"}`,
+			wantLocale:  "de",
+		},
+		{
+			name:        "valid-error-translated",
+			inputPath:   "/missing",
+			inputHeader: "fr",
+			wantStatus:  http.StatusNotFound,
+			wantBody:    `{"error":"Introuvable: aucun enregistrement pour cet identifiant","field":"id"}`,
+			wantLocale:  "fr",
+		},
+		{
+			name:        "valid-problems-translated",
+			inputPath:   "/problems",
+			inputHeader: "es",
+			wantStatus:  http.StatusBadRequest,
+			wantBody:    `{"error":"Solicitud incorrecta: el id debe ser mayor que 0; filtro de lista no válido","errors":[{"error":"el id debe ser mayor que 0","field":"id"},{"error":"filtro de lista no válido"}]}`,
+			wantLocale:  "es",
+		},
+		{
+			name:        "valid-panic-translated",
+			inputPath:   "/panic",
+			inputHeader: "de",
+			wantStatus:  http.StatusInternalServerError,
+			wantBody:    `{"error":"Interner Serverfehler"}`,
+			wantLocale:  "de",
+		},
+		{
+			name:        "valid-not-json-untouched",
+			inputPath:   "/text",
+			inputHeader: "de",
+			wantStatus:  http.StatusBadRequest,
+			wantBody:    "Bad Request",
+			wantLocale:  "de",
+		},
+		{
+			name:        "valid-unsupported-is-english",
+			inputPath:   "/missing",
+			inputHeader: "ja",
+			wantStatus:  http.StatusNotFound,
+			wantBody:    `{"error":"Not Found: provided id does not have a record","field":"id"}`,
+			wantLocale:  "en",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, testCase.inputPath, nil)
+			req.Header.Set("Accept-Language", testCase.inputHeader)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != testCase.wantStatus {
+				t.Errorf("got status: %d, want status: %d", rec.Code, testCase.wantStatus)
+			}
+			if rec.Body.String() != testCase.wantBody {
+				t.Errorf("got body: %q, want body: %q", rec.Body.String(), testCase.wantBody)
+			}
+			if got := rec.Header().Get("Content-Language"); got != testCase.wantLocale {
+				t.Errorf("got Content-Language: %q, want: %q", got, testCase.wantLocale)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// Package middleware holds gin middleware shared across all routes
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+)
+
+// clientWindow counts the requests a client has made in its current window
+type clientWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimiter is a fixed window limiter keyed on the client IP, or on what MiddlewareBy is given.
+// Every response carries the RateLimit-* headers so well behaved clients can self-throttle.
+type RateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mux       sync.Mutex
+	clients   map[string]*clientWindow
+	lastSweep time.Time
+}
+
+// NewRateLimiter allows limit requests per client in every window
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:     limit,
+		window:    window,
+		clients:   make(map[string]*clientWindow),
+		lastSweep: time.Now(),
+	}
+}
+
+// SetLimit changes the limit and window for the next requests, a limit of 0 turns limiting off.
+// Clients keep their current window and count.
+func (l *RateLimiter) SetLimit(limit int, window time.Duration) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	l.limit = limit
+	l.window = window
+}
+
+// take counts a request for key, returning the limit, what is left, and when the window resets
+func (l *RateLimiter) take(key string, now time.Time) (limit, remaining int, reset time.Duration, allowed bool) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if l.limit <= 0 {
+		return 0, 0, 0, true
+	}
+
+	// drop clients whose window has passed so the map does not grow forever
+	if now.Sub(l.lastSweep) > l.window {
+		for k, cw := range l.clients {
+			if now.Sub(cw.start) >= l.window {
+				delete(l.clients, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	cw, ok := l.clients[key]
+	if !ok || now.Sub(cw.start) >= l.window {
+		cw = &clientWindow{start: now}
+		l.clients[key] = cw
+	}
+
+	reset = cw.start.Add(l.window).Sub(now)
+	if cw.count >= l.limit {
+		return l.limit, 0, reset, false
+	}
+
+	cw.count += 1
+	return l.limit, l.limit - cw.count, reset, true
+}
+
+// KeyByClientIP counts requests against the client IP, as gin resolves it through the trusted proxies
+func KeyByClientIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUser counts requests against the authenticated user, whichever IP they come from.
+// Requests without a user fall back to the client IP
+func KeyByUser(c *gin.Context) string {
+	if userID, ok := auth.UserIDFromContext(c.Request.Context()); ok {
+		return "user:" + strconv.Itoa(userID)
+	}
+	return KeyByClientIP(c)
+}
+
+// Middleware limits requests per client IP, see MiddlewareBy
+func (l *RateLimiter) Middleware() gin.HandlerFunc {
+	return l.MiddlewareBy(KeyByClientIP)
+}
+
+// MiddlewareBy sets the RateLimit-Limit, RateLimit-Remaining, and RateLimit-Reset headers,
+// and rejects requests over the limit for their key with 429 and Retry-After. It does nothing while the limit is 0
+func (l *RateLimiter) MiddlewareBy(key func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, remaining, reset, allowed := l.take(key(c), time.Now())
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		// headers are in whole seconds, rounded up so clients never retry early
+		resetSeconds := strconv.Itoa(int(math.Ceil(reset.Seconds())))
+
+		c.Header("RateLimit-Limit", strconv.Itoa(limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Header("RateLimit-Reset", resetSeconds)
+
+		if !allowed {
+			c.Header("Retry-After", resetSeconds)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too Many Requests"})
+			return
+		}
+
+		c.Next()
+	}
+}
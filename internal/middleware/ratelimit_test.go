@@ -0,0 +1,207 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/middleware"
+)
+
+func TestRateLimiter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(middleware.NewRateLimiter(2, time.Hour).Middleware())
+	r.GET("/expenses", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	testTable := []struct {
+		name           string
+		remoteAddr     string
+		wantStatus     int
+		wantRemaining  int
+		wantRetryAfter bool
+	}{
+		{
+			name:          "valid-first-request",
+			remoteAddr:    "10.0.0.1:5000",
+			wantStatus:    http.StatusOK,
+			wantRemaining: 1,
+		},
+		{
+			name:          "valid-second-request",
+			remoteAddr:    "10.0.0.1:5000",
+			wantStatus:    http.StatusOK,
+			wantRemaining: 0,
+		},
+		{
+			name:           "invalid-over-limit",
+			remoteAddr:     "10.0.0.1:5000",
+			wantStatus:     http.StatusTooManyRequests,
+			wantRemaining:  0,
+			wantRetryAfter: true,
+		},
+		{
+			name:          "valid-other-client",
+			remoteAddr:    "10.0.0.2:5000",
+			wantStatus:    http.StatusOK,
+			wantRemaining: 1,
+		},
+	}
+
+	// runs in order, each request counts against the limiter
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/expenses", nil)
+			req.RemoteAddr = testCase.remoteAddr
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != testCase.wantStatus {
+				t.Errorf("got status: %d, want status: %d", rec.Code, testCase.wantStatus)
+			}
+			if got := rec.Header().Get("RateLimit-Limit"); got != "2" {
+				t.Errorf("got RateLimit-Limit: %q, want: %q", got, "2")
+			}
+			if got := rec.Header().Get("RateLimit-Remaining"); got != strconv.Itoa(testCase.wantRemaining) {
+				t.Errorf("got RateLimit-Remaining: %q, want: %d", got, testCase.wantRemaining)
+			}
+			if got := rec.Header().Get("Retry-After"); (got != "") != testCase.wantRetryAfter {
+				t.Errorf("got Retry-After: %q, want present: %v", got, testCase.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestRateLimiterSetLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := middleware.NewRateLimiter(0, time.Hour)
+	r := gin.New()
+	r.Use(limiter.Middleware())
+	r.GET("/expenses", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	testTable := []struct {
+		name        string
+		inputLimit  int
+		wantStatus  int
+		wantLimit   string
+		wantLimited bool
+	}{
+		{
+			name:       "valid-disabled-no-headers",
+			inputLimit: 0,
+			wantStatus: http.StatusOK,
+			wantLimit:  "",
+		},
+		{
+			name:       "valid-enabled",
+			inputLimit: 1,
+			wantStatus: http.StatusOK,
+			wantLimit:  "1",
+		},
+		{
+			name:       "invalid-enabled-over-limit",
+			inputLimit: 1,
+			wantStatus: http.StatusTooManyRequests,
+			wantLimit:  "1",
+		},
+		{
+			name:       "valid-raised-keeps-count",
+			inputLimit: 3,
+			wantStatus: http.StatusOK,
+			wantLimit:  "3",
+		},
+		{
+			name:       "valid-disabled-again",
+			inputLimit: 0,
+			wantStatus: http.StatusOK,
+			wantLimit:  "",
+		},
+	}
+
+	// runs in order, each request counts against the limiter
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			limiter.SetLimit(testCase.inputLimit, time.Hour)
+
+			req := httptest.NewRequest(http.MethodGet, "/expenses", nil)
+			req.RemoteAddr = "10.0.0.1:5000"
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != testCase.wantStatus {
+				t.Errorf("got status: %d, want status: %d", rec.Code, testCase.wantStatus)
+			}
+			if got := rec.Header().Get("RateLimit-Limit"); got != testCase.wantLimit {
+				t.Errorf("got RateLimit-Limit: %q, want: %q", got, testCase.wantLimit)
+			}
+		})
+	}
+}
+
+func TestRateLimiterByUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if id, err := strconv.Atoi(c.GetHeader("X-User-ID")); err == nil {
+			c.Request = c.Request.WithContext(auth.WithUserID(c.Request.Context(), id))
+		}
+		c.Next()
+	})
+	r.Use(middleware.NewRateLimiter(1, time.Hour).MiddlewareBy(middleware.KeyByUser))
+	r.GET("/users/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	testTable := []struct {
+		name       string
+		inputUser  string
+		remoteAddr string
+		wantStatus int
+	}{
+		{
+			name:       "valid-first-request",
+			inputUser:  "1",
+			remoteAddr: "10.0.0.1:5000",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid-same-user-other-ip",
+			inputUser:  "1",
+			remoteAddr: "10.0.0.2:5000",
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:       "valid-other-user-same-ip",
+			inputUser:  "2",
+			remoteAddr: "10.0.0.1:5000",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "valid-anonymous-falls-back-to-ip",
+			remoteAddr: "10.0.0.1:5000",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	// runs in order, each request counts against the limiter
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users/me", nil)
+			req.RemoteAddr = testCase.remoteAddr
+			if testCase.inputUser != "" {
+				req.Header.Set("X-User-ID", testCase.inputUser)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != testCase.wantStatus {
+				t.Errorf("got status: %d, want status: %d", rec.Code, testCase.wantStatus)
+			}
+		})
+	}
+}
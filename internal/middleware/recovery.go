@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"errors"
+	"expvar"
+	"log"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+)
+
+// panicsRecovered counts handler panics, published by expvar as http_panics_recovered
+var panicsRecovered = expvar.NewInt("http_panics_recovered")
+
+// PanicKey is set on the gin context to a *RecoveredPanic when Recovery catches one
+const PanicKey = "middleware.panic"
+
+// RecoveredPanic is a handler panic, with the stack from runtime.Callers as it was when it happened
+type RecoveredPanic struct {
+	Value any
+	Stack []uintptr
+}
+
+// Recovery turns a panicking handler into a 500 with the usual JSON error body,
+// logging the route and stack once instead of gin's full request dump.
+// Panics from clients hanging up are not errors, so they are logged without the stack.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			// net/http uses this to abort a response on purpose, it has to keep unwinding
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			if err, ok := recovered.(error); ok && (errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)) {
+				log.Printf("client went away during %s %s: %v", c.Request.Method, c.FullPath(), err)
+				c.Abort()
+				return
+			}
+
+			panicsRecovered.Add(1)
+			log.Printf("panic recovered in %s %s: %v\n%s", c.Request.Method, c.FullPath(), recovered, debug.Stack())
+
+			// skipping runtime.Callers, this func, and runtime.gopanic
+			stack := make([]uintptr, 64)
+			stack = stack[:runtime.Callers(3, stack)]
+			c.Set(PanicKey, &RecoveredPanic{Value: recovered, Stack: stack})
+
+			// headers may already be out if the handler panicked mid-response
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		}()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,68 @@
+package middleware_test
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/middleware"
+)
+
+func TestRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Recovery())
+	r.GET("/panic", func(c *gin.Context) {
+		panic("something went wrong")
+	})
+	r.GET("/ok", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	testTable := []struct {
+		name       string
+		inputPath  string
+		wantStatus int
+		wantBody   string
+		wantCount  int64 // how much the panic counter goes up
+	}{
+		{
+			name:       "valid-panic-recovered",
+			inputPath:  "/panic",
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":"Internal Server Error"}`,
+			wantCount:  1,
+		},
+		{
+			name:       "valid-no-panic",
+			inputPath:  "/ok",
+			wantStatus: http.StatusOK,
+			wantCount:  0,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			before, _ := strconv.ParseInt(expvar.Get("http_panics_recovered").String(), 10, 64)
+
+			req := httptest.NewRequest(http.MethodGet, testCase.inputPath, nil)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != testCase.wantStatus {
+				t.Errorf("got status: %d, want status: %d", rec.Code, testCase.wantStatus)
+			}
+			if testCase.wantBody != "" && rec.Body.String() != testCase.wantBody {
+				t.Errorf("got body: %q, want body: %q", rec.Body.String(), testCase.wantBody)
+			}
+
+			after, _ := strconv.ParseInt(expvar.Get("http_panics_recovered").String(), 10, 64)
+			if after-before != testCase.wantCount {
+				t.Errorf("panic counter went up by %d, want %d", after-before, testCase.wantCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimeoutConfig picks how long each route gets
+type TimeoutConfig struct {
+	// Default applies to every route without its own timeout, 0 for none
+	Default time.Duration
+	// Routes are keyed by method and route, i.e. "GET /expenses/:id", a 0 turns the timeout off
+	Routes map[string]time.Duration
+}
+
+// timeoutWriter holds back the response once the deadline has passed,
+// so a handler failing on the cancelled context doesn't answer before the 504 does
+type timeoutWriter struct {
+	gin.ResponseWriter
+	ctx      context.Context
+	timedOut bool
+}
+
+// expired checks the deadline before anything is written
+func (w *timeoutWriter) expired() bool {
+	if !w.timedOut && !w.ResponseWriter.Written() && errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		w.timedOut = true
+	}
+	return w.timedOut
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if !w.expired() {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	if !w.expired() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	if w.expired() {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	if w.expired() {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Timeout gives each request a deadline, cancelling its context once it passes so that repository
+// queries stop and free their connections. Requests that run out answer 504 Gateway Timeout,
+// unless the handler had already started its response.
+func Timeout(cfg TimeoutConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := cfg.Default
+		if routeTimeout, ok := cfg.Routes[c.Request.Method+" "+c.FullPath()]; ok {
+			timeout = routeTimeout
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		original := c.Writer
+		writer := &timeoutWriter{ResponseWriter: original, ctx: ctx}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = original
+		if writer.expired() {
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"error": "Gateway Timeout: request took longer than " + timeout.String()})
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicholasss/expense-tracker-api/internal/middleware"
+)
+
+// waitFor acts like a slow query, returning early with a 500 if the request is cancelled
+func waitFor(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		select {
+		case <-time.After(d):
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		case <-c.Request.Context().Done():
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	testTable := []struct {
+		name       string
+		inputPath  string
+		wantStatus int
+	}{
+		{
+			name:       "valid-fast-route",
+			inputPath:  "/fast",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid-slow-route-times-out",
+			inputPath:  "/slow",
+			wantStatus: http.StatusGatewayTimeout,
+		},
+		{
+			name:       "valid-slow-route-with-longer-timeout",
+			inputPath:  "/report",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "valid-slow-route-with-timeout-off",
+			inputPath:  "/stream/:id",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Timeout(middleware.TimeoutConfig{
+		Default: 20 * time.Millisecond,
+		Routes: map[string]time.Duration{
+			"GET /report":     time.Second,
+			"GET /stream/:id": 0,
+		},
+	}))
+	r.GET("/fast", waitFor(0))
+	r.GET("/slow", waitFor(time.Second))
+	r.GET("/report", waitFor(50*time.Millisecond))
+	r.GET("/stream/:id", waitFor(50*time.Millisecond))
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, testCase.inputPath, nil))
+
+			if rec.Code != testCase.wantStatus {
+				t.Errorf("got status: %d, want status: %d, body: %s", rec.Code, testCase.wantStatus, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Package money keeps amounts together with their currency, so amounts in different currencies
+// can't be added up by mistake
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultCurrency is what expenses are recorded in unless FX_BASE_CURRENCY says otherwise.
+// It is the currency amounts were in before they were kept with one, so existing books keep their meaning
+const DefaultCurrency = "USD"
+
+// ErrCurrencyMismatch is returned by arithmetic on amounts in different currencies
+var ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+
+// Money is an amount in minor units of Currency, i.e. cents for "EUR" and yen for "JPY"
+type Money struct {
+	Minor    int64
+	Currency string // ISO 4217 code, i.e. "EUR"
+}
+
+// New is minor units of currency
+func New(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+// Zero is nothing in currency, for sums to start from
+func Zero(currency string) Money {
+	return Money{Currency: currency}
+}
+
+// IsPositive reports whether m is more than nothing
+func (m Money) IsPositive() bool {
+	return m.Minor > 0
+}
+
+// Add is m plus other, refusing amounts in another currency
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return Money{Minor: m.Minor + other.Minor, Currency: m.Currency}, nil
+}
+
+// Sub is m minus other, refusing amounts in another currency
+func (m Money) Sub(other Money) (Money, error) {
+	return m.Add(Money{Minor: -other.Minor, Currency: other.Currency})
+}
+
+// Sum adds up amounts, which all have to be in currency
+func Sum(currency string, amounts ...Money) (Money, error) {
+	total := Zero(currency)
+	for _, amount := range amounts {
+		var err error
+		if total, err = total.Add(amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// exponents are the currencies without two decimals, by how many they have
+var exponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// Exponent is how many decimals currency has, 2 for most
+func Exponent(currency string) int {
+	if exponent, ok := exponents[currency]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// Decimal writes the amount without its currency, with as many decimals as the currency has, i.e. "12.50"
+func (m Money) Decimal() string {
+	minor, sign := m.Minor, ""
+	if minor < 0 {
+		minor, sign = -minor, "-"
+	}
+
+	exponent := Exponent(m.Currency)
+	if exponent == 0 {
+		return fmt.Sprintf("%s%d", sign, minor)
+	}
+	unit := int64(1)
+	for range exponent {
+		unit *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, minor/unit, exponent, minor%unit)
+}
+
+// String writes the amount followed by its currency code, i.e. "12.50 EUR"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// symbols are the currencies written with a symbol in front by Format
+var symbols = map[string]string{
+	"EUR": "€", "GBP": "£", "JPY": "¥", "USD": "$", "INR": "₹", "KRW": "₩", "ILS": "₪", "NGN": "₦",
+}
+
+// Symbol is currency's well known symbol, i.e. "€" for "EUR", ok is false when it has none
+func Symbol(currency string) (symbol string, ok bool) {
+	symbol, ok = symbols[currency]
+	return symbol, ok
+}
+
+// Format writes the amount for people to read, with the currency's symbol when it has a well known one,
+// i.e. "€12.50" or "-$3.00", and otherwise like String
+func (m Money) Format() string {
+	symbol, ok := Symbol(m.Currency)
+	if !ok {
+		return m.String()
+	}
+	decimal := m.Decimal()
+	if rest, negative := strings.CutPrefix(decimal, "-"); negative {
+		return "-" + symbol + rest
+	}
+	return symbol + decimal
+}
@@ -0,0 +1,128 @@
+package money_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+)
+
+func TestAdd(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputA      money.Money
+		inputB      money.Money
+		want        money.Money
+		expectError bool
+		wantError   error
+	}{
+		{
+			name:   "valid-same-currency",
+			inputA: money.New(1250, "EUR"),
+			inputB: money.New(750, "EUR"),
+			want:   money.New(2000, "EUR"),
+		},
+		{
+			name:   "valid-to-zero",
+			inputA: money.Zero("JPY"),
+			inputB: money.New(500, "JPY"),
+			want:   money.New(500, "JPY"),
+		},
+		{
+			name:        "invalid-currency-mismatch",
+			inputA:      money.New(1250, "EUR"),
+			inputB:      money.New(1250, "USD"),
+			expectError: true,
+			wantError:   money.ErrCurrencyMismatch,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := testCase.inputA.Add(testCase.inputB)
+			if testCase.expectError {
+				if !errors.Is(err, testCase.wantError) {
+					t.Errorf("Add() got error: '%v', want error: '%v'", err, testCase.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Add() got error: '%v'", err)
+			}
+			if got != testCase.want {
+				t.Errorf("Add() got: %v, want: %v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestSum(t *testing.T) {
+	got, err := money.Sum("EUR", money.New(100, "EUR"), money.New(250, "EUR"))
+	if err != nil || got != money.New(350, "EUR") {
+		t.Errorf("Sum() got: %v, error: '%v', want: 3.50 EUR", got, err)
+	}
+
+	if _, err := money.Sum("EUR", money.New(100, "EUR"), money.New(250, "GBP")); !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Errorf("Sum() got error: '%v', want error: '%v'", err, money.ErrCurrencyMismatch)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	testTable := []struct {
+		name        string
+		input       money.Money
+		wantDecimal string
+		wantString  string
+		wantFormat  string
+	}{
+		{
+			name:        "euro-cents",
+			input:       money.New(1250, "EUR"),
+			wantDecimal: "12.50",
+			wantString:  "12.50 EUR",
+			wantFormat:  "€12.50",
+		},
+		{
+			name:        "negative-dollars",
+			input:       money.New(-300, "USD"),
+			wantDecimal: "-3.00",
+			wantString:  "-3.00 USD",
+			wantFormat:  "-$3.00",
+		},
+		{
+			name:        "under-one-unit",
+			input:       money.New(5, "GBP"),
+			wantDecimal: "0.05",
+			wantString:  "0.05 GBP",
+			wantFormat:  "£0.05",
+		},
+		{
+			name:        "yen-without-decimals",
+			input:       money.New(1250, "JPY"),
+			wantDecimal: "1250",
+			wantString:  "1250 JPY",
+			wantFormat:  "¥1250",
+		},
+		{
+			name:        "dinar-three-decimals",
+			input:       money.New(1250, "BHD"),
+			wantDecimal: "1.250",
+			wantString:  "1.250 BHD",
+			wantFormat:  "1.250 BHD",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := testCase.input.Decimal(); got != testCase.wantDecimal {
+				t.Errorf("Decimal() got: %q, want: %q", got, testCase.wantDecimal)
+			}
+			if got := testCase.input.String(); got != testCase.wantString {
+				t.Errorf("String() got: %q, want: %q", got, testCase.wantString)
+			}
+			if got := testCase.input.Format(); got != testCase.wantFormat {
+				t.Errorf("Format() got: %q, want: %q", got, testCase.wantFormat)
+			}
+		})
+	}
+}
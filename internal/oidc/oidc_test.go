@@ -0,0 +1,259 @@
+package oidc_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/oidc"
+)
+
+// fakeIssuer is a minimal OpenID provider that hands out whatever id token is set
+type fakeIssuer struct {
+	server  *httptest.Server
+	key     *rsa.PrivateKey
+	idToken string
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate rsa key: %v", err)
+	}
+	f := &fakeIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 f.server.URL,
+			"authorization_endpoint": f.server.URL + "/authorize",
+			"token_endpoint":         f.server.URL + "/token",
+			"jwks_uri":               f.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test-key",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, ok := r.BasicAuth(); !ok || id != "client-id" || secret != "client-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.FormValue("code") != "good-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": f.idToken})
+	})
+
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// sign creates an RS256 id token with claims
+func (f *fakeIssuer) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test-key", "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("unable to marshal claims: %v", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("unable to sign token: %v", err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	f := newFakeIssuer(t)
+
+	provider, err := oidc.NewProvider(t.Context(), f.server.URL, "client-id", "client-secret", "http://localhost/callback")
+	if err != nil {
+		t.Fatalf("NewProvider() got error: '%v'", err)
+	}
+
+	got, err := url.Parse(provider.AuthCodeURL("the-state", "the-nonce"))
+	if err != nil {
+		t.Fatalf("unable to parse auth code url: %v", err)
+	}
+	if got.Path != "/authorize" {
+		t.Errorf("got path: %q, want: %q", got.Path, "/authorize")
+	}
+
+	query := got.Query()
+	for key, want := range map[string]string{
+		"response_type": "code",
+		"client_id":     "client-id",
+		"redirect_uri":  "http://localhost/callback",
+		"state":         "the-state",
+		"nonce":         "the-nonce",
+	} {
+		if query.Get(key) != want {
+			t.Errorf("got %s: %q, want: %q", key, query.Get(key), want)
+		}
+	}
+}
+
+func TestExchange(t *testing.T) {
+	f := newFakeIssuer(t)
+
+	validClaims := func() map[string]any {
+		return map[string]any{
+			"iss":            f.server.URL,
+			"sub":            "user-123",
+			"aud":            "client-id",
+			"exp":            time.Now().Add(time.Hour).Unix(),
+			"nonce":          "the-nonce",
+			"email":          "ada@example.com",
+			"email_verified": true,
+			"name":           "Ada",
+		}
+	}
+
+	testTable := []struct {
+		name        string
+		claims      func() map[string]any
+		code        string
+		expectError bool
+		wantError   error
+	}{
+		{
+			name:        "valid-id-token",
+			claims:      validClaims,
+			code:        "good-code",
+			expectError: false,
+			wantError:   nil,
+		},
+		{
+			name: "valid-audience-list",
+			claims: func() map[string]any {
+				c := validClaims()
+				c["aud"] = []string{"another-client", "client-id"}
+				return c
+			},
+			code:        "good-code",
+			expectError: false,
+			wantError:   nil,
+		},
+		{
+			name: "invalid-audience",
+			claims: func() map[string]any {
+				c := validClaims()
+				c["aud"] = "another-client"
+				return c
+			},
+			code:        "good-code",
+			expectError: true,
+			wantError:   oidc.ErrInvalidIDToken,
+		},
+		{
+			name: "invalid-nonce",
+			claims: func() map[string]any {
+				c := validClaims()
+				c["nonce"] = "replayed-nonce"
+				return c
+			},
+			code:        "good-code",
+			expectError: true,
+			wantError:   oidc.ErrInvalidIDToken,
+		},
+		{
+			name: "invalid-expired",
+			claims: func() map[string]any {
+				c := validClaims()
+				c["exp"] = time.Now().Add(-time.Minute).Unix()
+				return c
+			},
+			code:        "good-code",
+			expectError: true,
+			wantError:   oidc.ErrInvalidIDToken,
+		},
+		{
+			name: "invalid-issuer",
+			claims: func() map[string]any {
+				c := validClaims()
+				c["iss"] = "https://evil.example.com"
+				return c
+			},
+			code:        "good-code",
+			expectError: true,
+			wantError:   oidc.ErrInvalidIDToken,
+		},
+		{
+			name:        "invalid-code",
+			claims:      validClaims,
+			code:        "bad-code",
+			expectError: true,
+			wantError:   nil,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			provider, err := oidc.NewProvider(t.Context(), f.server.URL, "client-id", "client-secret", "http://localhost/callback")
+			if err != nil {
+				t.Fatalf("NewProvider() got error: '%v'", err)
+			}
+			f.idToken = f.sign(t, testCase.claims())
+
+			gotClaims, gotErr := provider.Exchange(t.Context(), testCase.code, "the-nonce")
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("Exchange() got error: '%v', expected error: '%v'", gotErr, testCase.wantError)
+			}
+
+			// checking error type if its not nil
+			if gotErr != nil {
+				if testCase.wantError != nil && !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+				return
+			}
+
+			if gotClaims.Subject != "user-123" || gotClaims.Email != "ada@example.com" || !gotClaims.EmailVerified {
+				t.Errorf("claims do not match. got: %+v", gotClaims)
+			}
+		})
+	}
+}
+
+func TestNewProviderDiscovery(t *testing.T) {
+	f := newFakeIssuer(t)
+
+	// a trailing slash on the configured issuer is still the same issuer
+	_, err := oidc.NewProvider(t.Context(), f.server.URL+"/", "client-id", "client-secret", "http://localhost/callback")
+	if err != nil {
+		t.Errorf("NewProvider() with trailing slash got error: '%v'", err)
+	}
+
+	_, err = oidc.NewProvider(t.Context(), "http://127.0.0.1:1", "client-id", "client-secret", "http://localhost/callback")
+	if err == nil {
+		t.Errorf("NewProvider() with unreachable issuer got no error")
+	}
+}
@@ -0,0 +1,109 @@
+// Package opstats gathers the operational numbers on the admin dashboard: how many records there are,
+// how big the database has grown, and how often each route is being requested
+package opstats
+
+import (
+	"context"
+	"time"
+)
+
+// Repository reads the database side of the stats, it is implemented by sqlite.StatsRepository
+type Repository interface {
+	// RecordCounts is the number of rows per table, keyed by table name
+	RecordCounts(ctx context.Context) (map[string]int64, error)
+
+	// SizeBytes is how much disk the database takes up
+	SizeBytes(ctx context.Context) (int64, error)
+}
+
+// OutboxCounter counts the webhook events in the outbox, it is implemented by sqlite.OutboxRepository
+type OutboxCounter interface {
+	Counts(ctx context.Context) (pending, dead int64, err error)
+}
+
+// OutboxStats is how the webhook deliveries are doing
+type OutboxStats struct {
+	Pending int64 // waiting to be delivered, including failed ones that will be tried again
+	Dead    int64 // given up on, waiting for an admin to retry them
+}
+
+// Stats is a snapshot of the running server
+type Stats struct {
+	StartedAt     time.Time
+	Uptime        time.Duration
+	Records       map[string]int64
+	DatabaseBytes int64
+	Window        time.Duration // how far back RouteRate.Recent reaches
+	Routes        []*RouteRate
+	Outbox        *OutboxStats // nil without WithOutbox
+}
+
+// Service counts requests as they are answered, and puts them together with the database stats on request
+type Service struct {
+	repo    Repository
+	outbox  OutboxCounter
+	routes  *routeCounter
+	started time.Time
+	now     func() time.Time
+}
+
+// Option configures optional parts of the Service
+type Option func(*Service)
+
+// WithClock replaces time.Now, for the uptime and which minute requests are counted in
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) { s.now = now }
+}
+
+// WithOutbox adds the webhook outbox's pending and dead events to the stats
+func WithOutbox(counter OutboxCounter) Option {
+	return func(s *Service) { s.outbox = counter }
+}
+
+// NewService reports request rates over the last window, rounded up to whole minutes
+func NewService(repo Repository, window time.Duration, opts ...Option) *Service {
+	s := &Service{repo: repo, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.started = s.now()
+	s.routes = newRouteCounter(window)
+	return s
+}
+
+// ObserveRequest counts one answered request to route, i.e. "GET /expenses/:id"
+func (s *Service) ObserveRequest(route string, status int) {
+	s.routes.observe(route, status, s.now())
+}
+
+// Stats reads the database stats and the request rates so far
+func (s *Service) Stats(ctx context.Context) (*Stats, error) {
+	records, err := s.repo.RecordCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	size, err := s.repo.SizeBytes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var outbox *OutboxStats
+	if s.outbox != nil {
+		pending, dead, err := s.outbox.Counts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		outbox = &OutboxStats{Pending: pending, Dead: dead}
+	}
+
+	now := s.now()
+	return &Stats{
+		StartedAt:     s.started,
+		Uptime:        now.Sub(s.started),
+		Records:       records,
+		DatabaseBytes: size,
+		Window:        s.routes.window(),
+		Routes:        s.routes.rates(now),
+		Outbox:        outbox,
+	}, nil
+}
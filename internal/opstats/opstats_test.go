@@ -0,0 +1,92 @@
+package opstats_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/opstats"
+)
+
+// fakeRepository has a fixed set of stats, or fails with err
+type fakeRepository struct {
+	err error
+}
+
+func (f *fakeRepository) RecordCounts(ctx context.Context) (map[string]int64, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return map[string]int64{"expenses": 12, "users": 2}, nil
+}
+
+func (f *fakeRepository) SizeBytes(ctx context.Context) (int64, error) {
+	return 4096, f.err
+}
+
+// fakeOutbox has a fixed number of pending and dead events
+type fakeOutbox struct{}
+
+func (fakeOutbox) Counts(ctx context.Context) (int64, int64, error) {
+	return 3, 1, nil
+}
+
+func TestStats(t *testing.T) {
+	start := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	service := opstats.NewService(&fakeRepository{}, 5*time.Minute, opstats.WithClock(func() time.Time { return now }))
+
+	// two minutes in, then ten minutes in, when the first ones have left the window
+	now = start.Add(2 * time.Minute)
+	service.ObserveRequest("GET /expenses", http.StatusOK)
+	service.ObserveRequest("GET /expenses", http.StatusOK)
+	service.ObserveRequest("POST /expenses", http.StatusInternalServerError)
+	now = start.Add(10 * time.Minute)
+	service.ObserveRequest("GET /expenses", http.StatusServiceUnavailable)
+
+	got, err := service.Stats(t.Context())
+	if err != nil {
+		t.Fatalf("Stats() got error: %v", err)
+	}
+
+	if got.Uptime != 10*time.Minute || got.Window != 5*time.Minute {
+		t.Errorf("got uptime %v and window %v, want 10m and 5m", got.Uptime, got.Window)
+	}
+	if got.Records["expenses"] != 12 || got.DatabaseBytes != 4096 {
+		t.Errorf("got records %v and %d bytes", got.Records, got.DatabaseBytes)
+	}
+
+	want := []opstats.RouteRate{
+		{Route: "GET /expenses", Requests: 3, ServerErrors: 1, Recent: 1, PerMinute: 0.2},
+		{Route: "POST /expenses", Requests: 1, ServerErrors: 1, Recent: 0, PerMinute: 0},
+	}
+	if len(got.Routes) != len(want) {
+		t.Fatalf("got %d routes, want %d", len(got.Routes), len(want))
+	}
+	for i := range want {
+		if *got.Routes[i] != want[i] {
+			t.Errorf("got route %d: %+v, want: %+v", i, *got.Routes[i], want[i])
+		}
+	}
+
+	// the outbox is only counted when it is there
+	if got.Outbox != nil {
+		t.Errorf("got outbox stats %+v without an outbox", got.Outbox)
+	}
+	withOutbox := opstats.NewService(&fakeRepository{}, time.Minute, opstats.WithOutbox(fakeOutbox{}))
+	got, err = withOutbox.Stats(t.Context())
+	if err != nil {
+		t.Fatalf("Stats() with an outbox got error: %v", err)
+	}
+	if got.Outbox == nil || *got.Outbox != (opstats.OutboxStats{Pending: 3, Dead: 1}) {
+		t.Errorf("got outbox stats %+v, want 3 pending and 1 dead", got.Outbox)
+	}
+
+	// a failing repository fails the whole snapshot
+	failing := opstats.NewService(&fakeRepository{err: errors.New("disk gone")}, time.Minute)
+	if _, err := failing.Stats(t.Context()); err == nil {
+		t.Errorf("Stats() got no error from a failing repository")
+	}
+}
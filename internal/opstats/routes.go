@@ -0,0 +1,93 @@
+package opstats
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RouteRate is how often one route has been requested
+type RouteRate struct {
+	Route        string
+	Requests     int64 // since the server started
+	ServerErrors int64 // 5xx responses since the server started
+	Recent       int64 // requests within the window
+	PerMinute    float64
+}
+
+// routeCounter counts requests per route in one minute buckets, a ring of them spanning the window
+type routeCounter struct {
+	mux     sync.Mutex
+	minutes int
+	routes  map[string]*routeCounts
+}
+
+type routeCounts struct {
+	requests     int64
+	serverErrors int64
+	buckets      []int64
+	bucketMinute []int64 // the unix minute each bucket is counting, older ones are stale
+}
+
+func newRouteCounter(window time.Duration) *routeCounter {
+	minutes := max(int((window+time.Minute-1)/time.Minute), 1)
+	return &routeCounter{minutes: minutes, routes: make(map[string]*routeCounts)}
+}
+
+func (rc *routeCounter) window() time.Duration {
+	return time.Duration(rc.minutes) * time.Minute
+}
+
+func (rc *routeCounter) observe(route string, status int, at time.Time) {
+	minute := at.Unix() / 60
+
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+
+	counts, ok := rc.routes[route]
+	if !ok {
+		counts = &routeCounts{buckets: make([]int64, rc.minutes), bucketMinute: make([]int64, rc.minutes)}
+		rc.routes[route] = counts
+	}
+
+	counts.requests += 1
+	if status >= http.StatusInternalServerError {
+		counts.serverErrors += 1
+	}
+
+	i := int(minute % int64(rc.minutes))
+	if counts.bucketMinute[i] != minute {
+		counts.bucketMinute[i], counts.buckets[i] = minute, 0
+	}
+	counts.buckets[i] += 1
+}
+
+// rates are every route requested so far, sorted by route
+func (rc *routeCounter) rates(now time.Time) []*RouteRate {
+	oldest := now.Unix()/60 - int64(rc.minutes) + 1
+
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+
+	rates := make([]*RouteRate, 0, len(rc.routes))
+	for route, counts := range rc.routes {
+		var recent int64
+		for i, n := range counts.buckets {
+			if counts.bucketMinute[i] >= oldest {
+				recent += n
+			}
+		}
+		rates = append(rates, &RouteRate{
+			Route:        route,
+			Requests:     counts.requests,
+			ServerErrors: counts.serverErrors,
+			Recent:       recent,
+			PerMinute:    float64(recent) / float64(rc.minutes),
+		})
+	}
+
+	slices.SortFunc(rates, func(a, b *RouteRate) int { return strings.Compare(a.Route, b.Route) })
+	return rates
+}
@@ -0,0 +1,166 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// batchSize is how many due events are posted per round, the rest wait for the next
+	batchSize = 50
+
+	// postTimeout bounds each post to the webhook
+	postTimeout = 10 * time.Second
+
+	// a failed post is tried again after baseDelay, doubling with every attempt up to maxDelay
+	baseDelay = 10 * time.Second
+	maxDelay  = time.Hour
+)
+
+// Sender delivers an event, an error means it is tried again later
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Webhook posts events to URL, signed with Secret. An event can be posted more than once, i.e. when the server
+// stops between posting it and marking it delivered, so receivers should skip the X-Event-ID values they have seen
+type Webhook struct {
+	URL    string
+	Secret []byte
+	Client *http.Client
+	now    func() time.Time
+}
+
+func NewWebhook(url string, secret []byte) *Webhook {
+	return &Webhook{URL: url, Secret: secret, Client: &http.Client{Timeout: postTimeout}, now: time.Now}
+}
+
+// Sign is the X-Signature of body posted at timestamp, the unix time in X-Signature-Timestamp. Receivers compute
+// it with the shared secret and compare, rejecting old timestamps so a captured post can't be replayed later
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send posts the event's payload, anything but a 2xx answer is a failure
+func (w *Webhook) Send(ctx context.Context, msg *Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(msg.Payload))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(w.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", strconv.Itoa(msg.ID))
+	req.Header.Set("X-Event-Type", string(msg.Type))
+	req.Header.Set("X-Signature-Timestamp", timestamp)
+	req.Header.Set("X-Signature", Sign(w.Secret, timestamp, msg.Payload))
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// Dispatcher posts the events in the outbox, oldest first. An event failing maxAttempts times is dead,
+// it stays in the outbox for ListDead until it is retried
+type Dispatcher struct {
+	repo        Repository
+	sender      Sender
+	maxAttempts int
+	now         func() time.Time
+}
+
+func NewDispatcher(repo Repository, sender Sender, maxAttempts int) *Dispatcher {
+	return &Dispatcher{repo: repo, sender: sender, maxAttempts: maxAttempts, now: time.Now}
+}
+
+// backoff is how long to wait after a post failed for the attempts-th time
+func backoff(attempts int) time.Duration {
+	delay := baseDelay
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+// DispatchDue posts a batch of the events that are due, returning how many were delivered.
+// Failed posts are rescheduled rather than returned, errors are from the outbox itself
+func (d *Dispatcher) DispatchDue(ctx context.Context) (int, error) {
+	due, err := d.repo.Due(ctx, d.now(), batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, msg := range due {
+		sendErr := d.sender.Send(ctx, msg)
+		if sendErr == nil {
+			if err := d.repo.Delivered(ctx, msg.ID, d.now()); err != nil {
+				return delivered, err
+			}
+			delivered++
+			continue
+		}
+
+		// stopping mid-post isn't the webhook's fault
+		if ctx.Err() != nil {
+			return delivered, ctx.Err()
+		}
+
+		attempts := msg.Attempts + 1
+		if attempts >= d.maxAttempts {
+			log.Printf("Giving up on outbox event %d after %d attempts: %v", msg.ID, attempts, sendErr)
+			err = d.repo.Dead(ctx, msg.ID, attempts, sendErr.Error(), d.now())
+		} else {
+			err = d.repo.Failed(ctx, msg.ID, attempts, sendErr.Error(), d.now().Add(backoff(attempts)))
+		}
+		if err != nil {
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+// Run calls DispatchDue every interval until ctx is done, logging rather than stopping on failures
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DispatchDue(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to dispatch outbox events: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ListDead lists the events given up on, most recent first
+func (d *Dispatcher) ListDead(ctx context.Context, limit, offset int) ([]*Message, error) {
+	return d.repo.ListDead(ctx, limit, offset)
+}
+
+// Retry queues a dead event to be posted again right away, with its attempts starting over
+func (d *Dispatcher) Retry(ctx context.Context, id int) error {
+	return d.repo.Retry(ctx, id, d.now())
+}
@@ -0,0 +1,185 @@
+package outbox_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/outbox"
+)
+
+// mockRepository keeps a single event, recording what the dispatcher does with it
+type mockRepository struct {
+	msg       *outbox.Message
+	delivered bool
+	dead      bool
+	next      time.Time
+}
+
+func (r *mockRepository) Due(ctx context.Context, now time.Time, limit int) ([]*outbox.Message, error) {
+	if r.delivered || r.dead {
+		return nil, nil
+	}
+	return []*outbox.Message{r.msg}, nil
+}
+
+func (r *mockRepository) Delivered(ctx context.Context, id int, at time.Time) error {
+	r.delivered = true
+	return nil
+}
+
+func (r *mockRepository) Failed(ctx context.Context, id int, attempts int, lastError string, next time.Time) error {
+	r.msg.Attempts, r.msg.LastError, r.next = attempts, lastError, next
+	return nil
+}
+
+func (r *mockRepository) Dead(ctx context.Context, id int, attempts int, lastError string, at time.Time) error {
+	r.msg.Attempts, r.msg.LastError, r.dead = attempts, lastError, true
+	return nil
+}
+
+func (r *mockRepository) ListDead(ctx context.Context, limit, offset int) ([]*outbox.Message, error) {
+	return nil, nil
+}
+
+func (r *mockRepository) Retry(ctx context.Context, id int, now time.Time) error {
+	return nil
+}
+
+// senderFunc sends with a function
+type senderFunc func(ctx context.Context, msg *outbox.Message) error
+
+func (f senderFunc) Send(ctx context.Context, msg *outbox.Message) error { return f(ctx, msg) }
+
+func TestDispatchDue(t *testing.T) {
+	testTable := []struct {
+		name          string
+		inputAttempts int // already made
+		inputSendErr  error
+		wantDelivered bool
+		wantDead      bool
+		wantDelay     time.Duration // until the next attempt
+	}{
+		{
+			name:          "valid-delivered",
+			wantDelivered: true,
+		},
+		{
+			name:         "invalid-first-failure",
+			inputSendErr: errors.New("webhook answered 500"),
+			wantDelay:    10 * time.Second,
+		},
+		{
+			name:          "invalid-backoff-doubles",
+			inputAttempts: 2,
+			inputSendErr:  errors.New("webhook answered 500"),
+			wantDelay:     40 * time.Second,
+		},
+		{
+			name:          "invalid-backoff-capped",
+			inputAttempts: 9,
+			inputSendErr:  errors.New("webhook answered 500"),
+			wantDelay:     time.Hour,
+		},
+		{
+			name:          "invalid-last-attempt",
+			inputAttempts: 11,
+			inputSendErr:  errors.New("webhook answered 500"),
+			wantDead:      true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := &mockRepository{msg: &outbox.Message{ID: 1, Type: expenses.ExpenseCreated, Attempts: testCase.inputAttempts}}
+			send := senderFunc(func(ctx context.Context, msg *outbox.Message) error { return testCase.inputSendErr })
+			dispatcher := outbox.NewDispatcher(repo, send, 12)
+
+			start := time.Now()
+			delivered, err := dispatcher.DispatchDue(t.Context())
+			if err != nil {
+				t.Fatalf("DispatchDue() got error: '%v'", err)
+			}
+
+			if repo.delivered != testCase.wantDelivered || (delivered == 1) != testCase.wantDelivered {
+				t.Errorf("got delivered: %v (%d), want delivered: %v", repo.delivered, delivered, testCase.wantDelivered)
+			}
+			if repo.dead != testCase.wantDead {
+				t.Errorf("got dead: %v, want dead: %v", repo.dead, testCase.wantDead)
+			}
+			if testCase.inputSendErr != nil && repo.msg.Attempts != testCase.inputAttempts+1 {
+				t.Errorf("got attempts: %d, want attempts: %d", repo.msg.Attempts, testCase.inputAttempts+1)
+			}
+			if testCase.wantDelay == 0 {
+				return
+			}
+			if delay := repo.next.Sub(start); delay < testCase.wantDelay || delay > testCase.wantDelay+time.Second {
+				t.Errorf("got next attempt in: %v, want: %v", delay, testCase.wantDelay)
+			}
+		})
+	}
+}
+
+func TestWebhookSend(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputStatus int
+		expectError bool
+	}{
+		{
+			name:        "valid-ok",
+			inputStatus: http.StatusOK,
+		},
+		{
+			name:        "valid-no-content",
+			inputStatus: http.StatusNoContent,
+		},
+		{
+			name:        "invalid-server-error",
+			inputStatus: http.StatusInternalServerError,
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			var gotID, gotType, gotTimestamp, gotSignature string
+			var gotBody []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotID, gotType = r.Header.Get("X-Event-ID"), r.Header.Get("X-Event-Type")
+				gotTimestamp, gotSignature = r.Header.Get("X-Signature-Timestamp"), r.Header.Get("X-Signature")
+				gotBody, _ = io.ReadAll(r.Body)
+				w.WriteHeader(testCase.inputStatus)
+			}))
+			defer server.Close()
+
+			msg := &outbox.Message{ID: 7, Type: expenses.ExpenseDeleted, Payload: []byte(`{"id":3}`)}
+			err := outbox.NewWebhook(server.URL, []byte("webhook-secret")).Send(t.Context(), msg)
+			if testCase.expectError != (err != nil) {
+				t.Fatalf("Send() got error: '%v', expected error: %v", err, testCase.expectError)
+			}
+			if gotID != "7" || gotType != "expense.deleted" {
+				t.Errorf("got headers id: %q, type: %q, want id: \"7\", type: \"expense.deleted\"", gotID, gotType)
+			}
+
+			// checked the way a receiver would, from the shared secret and what arrived
+			mac := hmac.New(sha256.New, []byte("webhook-secret"))
+			mac.Write([]byte(gotTimestamp + "." + string(gotBody)))
+			wantSignature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+			if gotTimestamp == "" || gotSignature != wantSignature {
+				t.Errorf("got signature: %q at %q, want: %q", gotSignature, gotTimestamp, wantSignature)
+			}
+			if gotSignature != outbox.Sign([]byte("webhook-secret"), gotTimestamp, gotBody) {
+				t.Errorf("got signature: %q, not the one Sign() makes", gotSignature)
+			}
+		})
+	}
+}
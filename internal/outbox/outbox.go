@@ -0,0 +1,88 @@
+// Package outbox delivers expense events to a webhook. Events are written to the outbox in the same
+// transaction as the change they are about, and posted from there by the Dispatcher, which retries
+// failed posts with backoff until it gives up and leaves the event dead for an admin to look at.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+)
+
+// ErrNotFound is returned for an event that isn't in the outbox, or isn't dead when it is retried
+var ErrNotFound = errors.New("outbox event not found")
+
+// Message is an event waiting in the outbox, or one delivered or given up on
+type Message struct {
+	ID            int
+	Type          expenses.EventType
+	Payload       []byte // the JSON body posted, an ExpenseEvent
+	Attempts      int
+	LastError     string
+	CreatedAt     time.Time
+	NextAttemptAt time.Time
+	DeadAt        time.Time // zero unless given up on
+}
+
+// ExpenseEvent is the body posted for every event. Descriptions are sent as they are stored,
+// so they are sealed when field encryption is on
+type ExpenseEvent struct {
+	Type    expenses.EventType `json:"type"`
+	At      time.Time          `json:"at"`
+	Expense EventExpense       `json:"expense"`
+}
+
+// EventExpense is the expense an event is about, as it was written. For deletes it is as it was before
+type EventExpense struct {
+	ID          int       `json:"id"`
+	OwnerID     int       `json:"owner_id,omitempty"`
+	HouseholdID int       `json:"household_id,omitempty"`
+	OccuredAt   time.Time `json:"occured_at"`
+	TimeZone    string    `json:"timezone"`
+	Description string    `json:"description"`
+	Amount      int64     `json:"amount"`
+	Currency    string    `json:"currency"`
+	CategoryID  int       `json:"category_id,omitempty"`
+}
+
+// NewExpenseEvent is the event for exp having changed
+func NewExpenseEvent(eventType expenses.EventType, exp *expenses.Expense, at time.Time) ExpenseEvent {
+	return ExpenseEvent{
+		Type: eventType,
+		At:   at.UTC(),
+		Expense: EventExpense{
+			ID:          exp.ID,
+			OwnerID:     exp.OwnerID,
+			HouseholdID: exp.HouseholdID,
+			OccuredAt:   exp.ExpenseOccuredAt,
+			TimeZone:    expenses.ZoneName(exp.ExpenseOccuredAt),
+			Description: exp.Description,
+			Amount:      exp.Amount.Minor,
+			Currency:    exp.Amount.Currency,
+			CategoryID:  exp.CategoryID,
+		},
+	}
+}
+
+// Repository is where the outbox is kept. Events are added to it by the expenses repository, see sqlite.SqliteRepository
+type Repository interface {
+	// get up to limit events waiting to be delivered whose next attempt is due at now, oldest first
+	Due(ctx context.Context, now time.Time, limit int) ([]*Message, error)
+
+	// mark an event delivered
+	Delivered(ctx context.Context, id int, at time.Time) error
+
+	// record a failed attempt, trying again at next
+	Failed(ctx context.Context, id int, attempts int, lastError string, next time.Time) error
+
+	// give up on an event after its last failed attempt
+	Dead(ctx context.Context, id int, attempts int, lastError string, at time.Time) error
+
+	// list the events given up on, most recent first
+	ListDead(ctx context.Context, limit, offset int) ([]*Message, error)
+
+	// queue a dead event again, as if it was new
+	Retry(ctx context.Context, id int, now time.Time) error
+}
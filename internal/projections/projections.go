@@ -0,0 +1,222 @@
+// Package projections keeps read models of the expenses, documents precomputed from the expense events so
+// the dashboard reads them instead of querying the expenses table on every request
+package projections
+
+import (
+	"cmp"
+	"context"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+)
+
+// LatestSize is how many of the newest expenses a dashboard keeps
+const LatestSize = 50
+
+// CategoryTotal is the total of every expense in one category, 0 for uncategorized
+type CategoryTotal struct {
+	CategoryID int
+	expenses.Total
+}
+
+// Dashboard is the read model of one book: this month's total, every category's total,
+// and the newest expenses by occured at and then id
+type Dashboard struct {
+	From       time.Time // first instant of the current month, in UTC
+	To         time.Time // first instant of the next month
+	Month      expenses.Total
+	Categories []*CategoryTotal // by category id
+	Latest     []*expenses.Expense
+	BuiltAt    time.Time // when it was last read from the repository, events are applied since
+}
+
+// clone copies d, so callers can hold on to it while events keep changing the original
+func (d *Dashboard) clone() *Dashboard {
+	c := *d
+	c.Categories = make([]*CategoryTotal, 0, len(d.Categories))
+	for _, total := range d.Categories {
+		copied := *total
+		c.Categories = append(c.Categories, &copied)
+	}
+	c.Latest = slices.Clone(d.Latest)
+	return &c
+}
+
+// Projector keeps a Dashboard for every scope that has asked for one. Joining or leaving a household
+// changes a user's scope rather than what a scope sees, so membership changes need no invalidating.
+// A book's dashboard is read from the repository the first time, after which created expenses are applied
+// to it as they are published. Updates and deletes don't say what the expense was before, so they drop the
+// dashboards that could see it, which are read again on their next request
+type Projector struct {
+	repo       expenses.Repository
+	households expenses.HouseholdLookup
+	now        func() time.Time
+
+	mux        sync.Mutex
+	dashboards map[expenses.Scope]*Dashboard
+	generation int // counts the events, so a read racing one isn't kept
+}
+
+func NewProjector(repo expenses.Repository, households expenses.HouseholdLookup) *Projector {
+	return &Projector{
+		repo:       repo,
+		households: households,
+		now:        time.Now,
+		dashboards: make(map[expenses.Scope]*Dashboard),
+	}
+}
+
+// Subscribe keeps the dashboards up to date with the events published on bus
+func (p *Projector) Subscribe(bus *expenses.Bus) {
+	bus.Subscribe(p.Apply)
+}
+
+// sees reports whether scope's book includes exp, like the repository's scope filter
+func sees(scope expenses.Scope, exp *expenses.Expense) bool {
+	return scope.AllOwners || exp.OwnerID == scope.OwnerID || (scope.HouseholdID != 0 && exp.HouseholdID == scope.HouseholdID)
+}
+
+// overlaps reports whether a and b can see some of the same expenses
+func overlaps(a, b expenses.Scope) bool {
+	return a.AllOwners || b.AllOwners || a.OwnerID == b.OwnerID || (a.HouseholdID != 0 && a.HouseholdID == b.HouseholdID)
+}
+
+// Apply updates the dashboards affected by event, it is the Subscriber Subscribe adds
+func (p *Projector) Apply(ctx context.Context, event expenses.Event) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	p.generation++
+	for scope, dashboard := range p.dashboards {
+		if event.Type != expenses.ExpenseCreated {
+			if overlaps(scope, event.Scope) {
+				delete(p.dashboards, scope)
+			}
+			continue
+		}
+
+		if !sees(scope, event.Expense) {
+			continue
+		}
+		if err := dashboard.add(event.Expense); err != nil {
+			log.Printf("Failed to project expense %d, the dashboard is read again: %v", event.Expense.ID, err)
+			delete(p.dashboards, scope)
+		}
+	}
+}
+
+// Dashboard is the read model of the authenticated user's book
+func (p *Projector) Dashboard(ctx context.Context) (*Dashboard, error) {
+	scope, err := expenses.ScopeOf(ctx, p.households)
+	if err != nil {
+		return nil, err
+	}
+	from, to := monthOf(p.now())
+
+	p.mux.Lock()
+	dashboard, ok := p.dashboards[scope]
+	generation := p.generation
+	if ok && dashboard.From.Equal(from) {
+		defer p.mux.Unlock()
+		return dashboard.clone(), nil
+	}
+	p.mux.Unlock()
+
+	// read without holding the lock, so events aren't held up by it
+	dashboard, err = p.build(ctx, scope, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	// an event in the meantime may or may not be in what was read
+	if p.generation == generation {
+		p.dashboards[scope] = dashboard
+	}
+	return dashboard.clone(), nil
+}
+
+// monthOf is the UTC month now is in
+func monthOf(now time.Time) (from, to time.Time) {
+	now = now.UTC()
+	from = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return from, from.AddDate(0, 1, 0)
+}
+
+// build reads scope's dashboard from the repository
+func (p *Projector) build(ctx context.Context, scope expenses.Scope, from, to time.Time) (*Dashboard, error) {
+	month, err := p.repo.SumInRange(ctx, scope, from, to, expenses.SummaryFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard := &Dashboard{From: from, To: to, Month: *month, BuiltAt: p.now()}
+
+	// the month is already summed, only the categories are left to add up
+	err = p.repo.Each(ctx, scope, func(exp *expenses.Expense) error {
+		return dashboard.addToCategory(exp)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard.Latest, err = p.repo.List(ctx, scope, expenses.ListFilter{Limit: LatestSize})
+	if err != nil {
+		return nil, err
+	}
+	return dashboard, nil
+}
+
+// add applies a created expense to the dashboard
+func (d *Dashboard) add(exp *expenses.Expense) error {
+	if !exp.ExpenseOccuredAt.Before(d.From) && exp.ExpenseOccuredAt.Before(d.To) {
+		amount, err := d.Month.Amount.Add(exp.Amount)
+		if err != nil {
+			return err
+		}
+		d.Month = expenses.Total{Amount: amount, Count: d.Month.Count + 1}
+	}
+
+	if err := d.addToCategory(exp); err != nil {
+		return err
+	}
+
+	// newest first, like the repository lists them
+	i, _ := slices.BinarySearchFunc(d.Latest, exp, func(listed, target *expenses.Expense) int {
+		if c := target.ExpenseOccuredAt.Compare(listed.ExpenseOccuredAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(target.ID, listed.ID)
+	})
+	if i < LatestSize {
+		copied := *exp
+		d.Latest = slices.Insert(d.Latest, i, &copied)
+		d.Latest = d.Latest[:min(len(d.Latest), LatestSize)]
+	}
+	return nil
+}
+
+// addToCategory adds exp to its category's total, keeping the totals by category id
+func (d *Dashboard) addToCategory(exp *expenses.Expense) error {
+	i, found := slices.BinarySearchFunc(d.Categories, exp.CategoryID, func(total *CategoryTotal, id int) int {
+		return cmp.Compare(total.CategoryID, id)
+	})
+	if !found {
+		total := &CategoryTotal{CategoryID: exp.CategoryID, Total: expenses.Total{Amount: money.Zero(exp.Amount.Currency)}}
+		d.Categories = slices.Insert(d.Categories, i, total)
+	}
+
+	total := d.Categories[i]
+	amount, err := total.Amount.Add(exp.Amount)
+	if err != nil {
+		return err
+	}
+	total.Total = expenses.Total{Amount: amount, Count: total.Count + 1}
+	return nil
+}
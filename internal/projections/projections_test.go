@@ -0,0 +1,220 @@
+package projections_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nicholasss/expense-tracker-api/internal/categories"
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+	"github.com/nicholasss/expense-tracker-api/internal/projections"
+	"github.com/nicholasss/expense-tracker-api/internal/sqlite"
+)
+
+// setupTestService creates an expense service backed by an in-memory sqlite database, with the default categories,
+// and a projector subscribed to its events
+func setupTestService(t *testing.T) (*expenses.ExpenseService, *projections.Projector) {
+	t.Helper()
+
+	repo, err := sqlite.NewSqliteRepository("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+	t.Cleanup(func() { repo.Close() })
+
+	if _, err := sqlite.Migrate(t.Context(), repo.DB); err != nil {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+	repo.Currency = money.DefaultCurrency
+
+	// seeds the default categories, 1 and 2 are the first of them
+	categoryService := categories.NewService(sqlite.NewCategoryRepository(repo.DB, repo.Writer))
+	if _, err := categoryService.List(t.Context()); err != nil {
+		t.Fatalf("unable to seed categories: %v", err)
+	}
+
+	bus := expenses.NewBus()
+	projector := projections.NewProjector(repo, nil)
+	projector.Subscribe(bus)
+
+	return expenses.NewService(repo, expenses.WithEvents(bus), expenses.WithCategories(categoryService)), projector
+}
+
+func TestDashboard(t *testing.T) {
+	// a few hours into the month rather than now, which could be too early in it
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	thisMonth := monthStart.Add(3 * time.Hour)
+	lastMonth := monthStart.Add(-time.Hour)
+
+	// created before the dashboard is first read
+	seed := []struct {
+		occuredAt  time.Time
+		amount     int64
+		categoryID int
+	}{
+		{lastMonth, 90000, 2},
+		{thisMonth.Add(-time.Hour), 1250, 1},
+		{thisMonth.Add(-2 * time.Hour), 700, 0},
+	}
+
+	testTable := []struct {
+		name            string
+		inputChange     func(ctx context.Context, service *expenses.ExpenseService) error // after the first read
+		wantMonthAmount int64
+		wantMonthCount  int
+		wantCategories  map[int]int64
+		wantLatest      []int // ids
+		wantRebuilt     bool
+	}{
+		{
+			name:            "valid-read",
+			inputChange:     func(ctx context.Context, service *expenses.ExpenseService) error { return nil },
+			wantMonthAmount: 1950,
+			wantMonthCount:  2,
+			wantCategories:  map[int]int64{0: 700, 1: 1250, 2: 90000},
+			wantLatest:      []int{2, 3, 1},
+		},
+		{
+			name: "valid-created-applied",
+			inputChange: func(ctx context.Context, service *expenses.ExpenseService) error {
+				_, err := service.NewExpense(ctx, thisMonth, "bread", money.New(300, money.DefaultCurrency), 1)
+				return err
+			},
+			wantMonthAmount: 2250,
+			wantMonthCount:  3,
+			wantCategories:  map[int]int64{0: 700, 1: 1550, 2: 90000},
+			wantLatest:      []int{4, 2, 3, 1},
+		},
+		{
+			name: "valid-created-last-month",
+			inputChange: func(ctx context.Context, service *expenses.ExpenseService) error {
+				_, err := service.NewExpense(ctx, lastMonth.Add(-time.Hour), "rent", money.New(90000, money.DefaultCurrency), 2)
+				return err
+			},
+			wantMonthAmount: 1950,
+			wantMonthCount:  2,
+			wantCategories:  map[int]int64{0: 700, 1: 1250, 2: 180000},
+			wantLatest:      []int{2, 3, 1, 4},
+		},
+		{
+			name: "valid-updated-rebuilt",
+			inputChange: func(ctx context.Context, service *expenses.ExpenseService) error {
+				return service.UpdateExpense(ctx, 3, thisMonth.Add(-2*time.Hour), "milk", money.New(500, money.DefaultCurrency), 1)
+			},
+			wantMonthAmount: 1750,
+			wantMonthCount:  2,
+			wantCategories:  map[int]int64{1: 1750, 2: 90000},
+			wantLatest:      []int{2, 3, 1},
+			wantRebuilt:     true,
+		},
+		{
+			name: "valid-deleted-rebuilt",
+			inputChange: func(ctx context.Context, service *expenses.ExpenseService) error {
+				return service.DeleteExpense(ctx, 1)
+			},
+			wantMonthAmount: 1950,
+			wantMonthCount:  2,
+			wantCategories:  map[int]int64{0: 700, 1: 1250},
+			wantLatest:      []int{2, 3},
+			wantRebuilt:     true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			service, projector := setupTestService(t)
+			for _, exp := range seed {
+				_, err := service.NewExpense(t.Context(), exp.occuredAt, "seeded", money.New(exp.amount, money.DefaultCurrency), exp.categoryID)
+				if err != nil {
+					t.Fatalf("NewExpense() got error: '%v'", err)
+				}
+			}
+
+			first, err := projector.Dashboard(t.Context())
+			if err != nil {
+				t.Fatalf("Dashboard() got error: '%v'", err)
+			}
+			if err := testCase.inputChange(t.Context(), service); err != nil {
+				t.Fatalf("change got error: '%v'", err)
+			}
+
+			got, err := projector.Dashboard(t.Context())
+			if err != nil {
+				t.Fatalf("Dashboard() got error: '%v'", err)
+			}
+
+			if got.Month.Amount.Minor != testCase.wantMonthAmount || got.Month.Count != testCase.wantMonthCount {
+				t.Errorf("got month: %d (%d expenses), want month: %d (%d expenses)", got.Month.Amount.Minor, got.Month.Count, testCase.wantMonthAmount, testCase.wantMonthCount)
+			}
+
+			gotCategories := make(map[int]int64, len(got.Categories))
+			for _, total := range got.Categories {
+				gotCategories[total.CategoryID] = total.Amount.Minor
+			}
+			if len(gotCategories) != len(testCase.wantCategories) {
+				t.Errorf("got categories: %v, want categories: %v", gotCategories, testCase.wantCategories)
+			}
+			for id, amount := range testCase.wantCategories {
+				if gotCategories[id] != amount {
+					t.Errorf("got categories: %v, want categories: %v", gotCategories, testCase.wantCategories)
+					break
+				}
+			}
+
+			gotLatest := make([]int, 0, len(got.Latest))
+			for _, exp := range got.Latest {
+				gotLatest = append(gotLatest, exp.ID)
+			}
+			if !slices.Equal(gotLatest, testCase.wantLatest) {
+				t.Errorf("got latest: %v, want latest: %v", gotLatest, testCase.wantLatest)
+			}
+
+			// created expenses are applied to the dashboard as it is, the rest read it again
+			if rebuilt := !got.BuiltAt.Equal(first.BuiltAt); rebuilt != testCase.wantRebuilt {
+				t.Errorf("got rebuilt: %v, want rebuilt: %v", rebuilt, testCase.wantRebuilt)
+			}
+		})
+	}
+}
+
+func TestDashboardLatestSize(t *testing.T) {
+	service, projector := setupTestService(t)
+	start := time.Now().UTC().Add(-time.Hour)
+
+	for i := range projections.LatestSize {
+		_, err := service.NewExpense(t.Context(), start.Add(time.Duration(i)*time.Second), "coffee", money.New(350, money.DefaultCurrency), 0)
+		if err != nil {
+			t.Fatalf("NewExpense() got error: '%v'", err)
+		}
+	}
+	if _, err := projector.Dashboard(t.Context()); err != nil {
+		t.Fatalf("Dashboard() got error: '%v'", err)
+	}
+
+	// older than every listed expense, so only counted
+	if _, err := service.NewExpense(t.Context(), start.Add(-time.Minute), "tea", money.New(300, money.DefaultCurrency), 0); err != nil {
+		t.Fatalf("NewExpense() got error: '%v'", err)
+	}
+	newest, err := service.NewExpense(t.Context(), start.Add(time.Minute), "cake", money.New(450, money.DefaultCurrency), 0)
+	if err != nil {
+		t.Fatalf("NewExpense() got error: '%v'", err)
+	}
+
+	got, err := projector.Dashboard(t.Context())
+	if err != nil {
+		t.Fatalf("Dashboard() got error: '%v'", err)
+	}
+	if len(got.Latest) != projections.LatestSize || got.Latest[0].ID != newest.ID {
+		t.Errorf("got %d latest, newest: %d, want %d latest, newest: %d", len(got.Latest), got.Latest[0].ID, projections.LatestSize, newest.ID)
+	}
+	if got.Categories[0].Count != projections.LatestSize+2 {
+		t.Errorf("got count: %d, want count: %d", got.Categories[0].Count, projections.LatestSize+2)
+	}
+}
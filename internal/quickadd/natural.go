@@ -0,0 +1,153 @@
+package quickadd
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoAmount is returned when no word of the text is an amount
+var ErrNoAmount = errors.New("add an amount, like 12.50")
+
+// ErrUnreadable is wrapped by parse errors the user can fix by rewording the text
+var ErrUnreadable = errors.New("unable to read an expense from the text")
+
+// unreadableError keeps the message of the error it wraps, which says what to fix
+type unreadableError struct{ err error }
+
+func (e *unreadableError) Error() string   { return e.err.Error() }
+func (e *unreadableError) Unwrap() []error { return []error{ErrUnreadable, e.err} }
+
+// Draft is an expense read from free text, for the user to confirm before it is recorded
+type Draft struct {
+	Amount      int64 // cents
+	Description string
+	OccuredAt   time.Time
+}
+
+// Parser turns free text into a draft expense, relative dates are taken from now.
+// Errors wrap ErrUnreadable when the text is at fault, anything else is the parser failing.
+// RuleParser is the built in one, another can be plugged in, i.e. one asking a language model
+type Parser interface {
+	ParseDraft(ctx context.Context, text string, now time.Time) (*Draft, error)
+}
+
+// RuleParser reads text like "14.99 pizza last friday" with a few fixed rules.
+// The first word that is an amount is the amount, and a date anywhere in the text is when it occured:
+// today, yesterday, "3 days ago", a weekday optionally after "last" or "on", or YYYY-MM-DD.
+// Whatever is left is the description, without a leading "for"
+type RuleParser struct{}
+
+func (p RuleParser) ParseDraft(ctx context.Context, text string, now time.Time) (*Draft, error) {
+	draft, err := p.parse(text, now)
+	if err != nil {
+		return nil, &unreadableError{err: err}
+	}
+	return draft, nil
+}
+
+func (RuleParser) parse(text string, now time.Time) (*Draft, error) {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return nil, ErrNoEntry
+	}
+
+	draft := &Draft{OccuredAt: now}
+	foundAmount, foundDate := false, false
+	rest := make([]string, 0, len(words))
+	for i := 0; i < len(words); i++ {
+		// dates first, so the 3 in "3 days ago" isn't taken for the amount
+		if !foundDate {
+			if occured, n, ok := parseDate(words[i:], now); ok {
+				draft.OccuredAt, foundDate = occured, true
+				i += n - 1
+				continue
+			}
+		}
+		if !foundAmount {
+			if amount, err := ParseAmount(words[i]); err == nil {
+				draft.Amount, foundAmount = amount, true
+				continue
+			}
+		}
+		rest = append(rest, words[i])
+	}
+
+	if !foundAmount {
+		return nil, ErrNoAmount
+	}
+	if len(rest) > 0 && strings.EqualFold(rest[0], "for") {
+		rest = rest[1:]
+	}
+	if len(rest) == 0 {
+		return nil, ErrNoDescription
+	}
+
+	draft.Description = strings.Join(rest, " ")
+	return draft, nil
+}
+
+// parseDate reads a date phrase at the start of words, returning how many words it took
+func parseDate(words []string, now time.Time) (time.Time, int, bool) {
+	word := strings.ToLower(words[0])
+	if word == "on" && len(words) > 1 {
+		if occured, n, ok := parseDate(words[1:], now); ok {
+			return occured, n + 1, true
+		}
+		return time.Time{}, 0, false
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch word {
+	case "today":
+		return now, 1, true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), 1, true
+	case "last":
+		if len(words) > 1 {
+			if weekday, ok := parseWeekday(words[1]); ok {
+				return lastWeekday(today, weekday, false), 2, true
+			}
+		}
+		return time.Time{}, 0, false
+	}
+
+	if weekday, ok := parseWeekday(word); ok {
+		return lastWeekday(today, weekday, true), 1, true
+	}
+
+	if date, err := time.ParseInLocation(time.DateOnly, word, now.Location()); err == nil {
+		return date, 1, true
+	}
+
+	// "3 days ago"
+	if len(words) >= 3 && strings.ToLower(words[2]) == "ago" {
+		unit := strings.ToLower(words[1])
+		if n, err := strconv.Atoi(word); err == nil && n >= 0 && (unit == "days" || unit == "day") {
+			return today.AddDate(0, 0, -n), 3, true
+		}
+	}
+
+	return time.Time{}, 0, false
+}
+
+// parseWeekday reads a weekday's full name, short ones like "sat" or "sun" are too often part of a description
+func parseWeekday(word string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(word, day.String()) {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// lastWeekday is the latest weekday before today, or on it when includeToday is set
+func lastWeekday(today time.Time, weekday time.Weekday, includeToday bool) time.Time {
+	back := (int(today.Weekday()) - int(weekday) + 7) % 7
+	if back == 0 && !includeToday {
+		back = 7
+	}
+	return today.AddDate(0, 0, -back)
+}
@@ -0,0 +1,61 @@
+// Package quickadd reads expenses typed out as text, i.e. "12.50 lunch with the team",
+// for the entry points where filling in a full request body is too much
+package quickadd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrNoEntry is returned by Parse for text with nothing in it
+var ErrNoEntry = errors.New("enter an amount followed by what the expense was for, like 12.50 lunch")
+
+// ErrNoDescription is returned by Parse for an amount on its own
+var ErrNoDescription = errors.New("add what the expense was for after the amount")
+
+// Entry is an expense read from text, it has no date of its own
+type Entry struct {
+	Amount      int64 // cents
+	Description string
+}
+
+// Parse reads an amount followed by a description, see ParseAmount for the amount
+func Parse(text string) (*Entry, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return nil, ErrNoEntry
+	}
+	if len(fields) == 1 {
+		return nil, ErrNoDescription
+	}
+
+	amount, err := ParseAmount(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{Amount: amount, Description: strings.Join(fields[1:], " ")}, nil
+}
+
+// ParseAmount reads "12", "12.5", or "12.50" into cents, after a leading currency symbol
+func ParseAmount(raw string) (int64, error) {
+	trimmed := strings.TrimLeft(raw, "$€£¥")
+	whole, frac, hasFrac := strings.Cut(trimmed, ".")
+	if whole == "" || len(frac) > 2 || (hasFrac && frac == "") || strings.ContainsAny(trimmed, "+-") {
+		return 0, fmt.Errorf("amount %q needs to be a number with up to two decimals, like 12.50", raw)
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("amount %q needs to be a number with up to two decimals, like 12.50", raw)
+	}
+	var cents int64
+	if frac != "" {
+		cents, err = strconv.ParseInt(frac+strings.Repeat("0", 2-len(frac)), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("amount %q needs to be a number with up to two decimals, like 12.50", raw)
+		}
+	}
+	return units*100 + cents, nil
+}
@@ -0,0 +1,176 @@
+package quickadd_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/quickadd"
+)
+
+func TestParse(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputText   string
+		expectError bool
+		wantError   error
+		want        quickadd.Entry
+	}{
+		{
+			name:      "valid-decimal-amount",
+			inputText: "12.50 lunch with the team",
+			want:      quickadd.Entry{Amount: 1250, Description: "lunch with the team"},
+		},
+		{
+			name:      "valid-symbol-and-spacing",
+			inputText: "  £8   coffee  ",
+			want:      quickadd.Entry{Amount: 800, Description: "coffee"},
+		},
+		{
+			name:        "invalid-empty",
+			inputText:   "   ",
+			expectError: true,
+			wantError:   quickadd.ErrNoEntry,
+		},
+		{
+			name:        "invalid-amount-only",
+			inputText:   "12.50",
+			expectError: true,
+			wantError:   quickadd.ErrNoDescription,
+		},
+		{
+			name:        "invalid-amount-not-first",
+			inputText:   "lunch 12.50",
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, gotErr := quickadd.Parse(testCase.inputText)
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("Parse(%q) got error: '%v', expected error: %v", testCase.inputText, gotErr, testCase.expectError)
+			}
+			if gotErr != nil {
+				if testCase.wantError != nil && !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: '%v', want error: '%v'", gotErr, testCase.wantError)
+				}
+				return
+			}
+			if *got != testCase.want {
+				t.Errorf("Parse(%q) got: %+v, want: %+v", testCase.inputText, *got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputRaw    string
+		expectError bool
+		want        int64
+	}{
+		{name: "valid-whole", inputRaw: "12", want: 1200},
+		{name: "valid-one-decimal", inputRaw: "€3.5", want: 350},
+		{name: "valid-two-decimals", inputRaw: "$0.99", want: 99},
+		{name: "invalid-three-decimals", inputRaw: "12.505", expectError: true},
+		{name: "invalid-trailing-point", inputRaw: "12.", expectError: true},
+		{name: "invalid-negative", inputRaw: "-12", expectError: true},
+		{name: "invalid-word", inputRaw: "twelve", expectError: true},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, gotErr := quickadd.ParseAmount(testCase.inputRaw)
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("ParseAmount(%q) got error: '%v', expected error: %v", testCase.inputRaw, gotErr, testCase.expectError)
+			}
+			if got != testCase.want {
+				t.Errorf("ParseAmount(%q) got: %d, want: %d", testCase.inputRaw, got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestRuleParser(t *testing.T) {
+	// a wednesday afternoon
+	now := time.Date(2025, 10, 22, 15, 30, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name        string
+		inputText   string
+		expectError bool
+		wantError   error
+		want        quickadd.Draft
+	}{
+		{
+			name:      "valid-last-weekday",
+			inputText: "14.99 pizza last friday",
+			want:      quickadd.Draft{Amount: 1499, Description: "pizza", OccuredAt: time.Date(2025, 10, 17, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:      "valid-last-same-weekday-is-a-week-ago",
+			inputText: "pizza 14.99 last Wednesday",
+			want:      quickadd.Draft{Amount: 1499, Description: "pizza", OccuredAt: time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:      "valid-weekday-includes-today",
+			inputText: "$3 coffee on wednesday",
+			want:      quickadd.Draft{Amount: 300, Description: "coffee", OccuredAt: time.Date(2025, 10, 22, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:      "valid-no-date-is-now",
+			inputText: "20 for books",
+			want:      quickadd.Draft{Amount: 2000, Description: "books", OccuredAt: now},
+		},
+		{
+			name:      "valid-yesterday-first",
+			inputText: "yesterday taxi home 32.50",
+			want:      quickadd.Draft{Amount: 3250, Description: "taxi home", OccuredAt: time.Date(2025, 10, 21, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:      "valid-days-ago-before-amount",
+			inputText: "3 days ago 8 cinema",
+			want:      quickadd.Draft{Amount: 800, Description: "cinema", OccuredAt: time.Date(2025, 10, 19, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:      "valid-iso-date",
+			inputText: "groceries 45.10 2025-09-30",
+			want:      quickadd.Draft{Amount: 4510, Description: "groceries", OccuredAt: time.Date(2025, 9, 30, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:        "invalid-no-amount",
+			inputText:   "pizza last friday",
+			expectError: true,
+			wantError:   quickadd.ErrNoAmount,
+		},
+		{
+			name:        "invalid-no-description",
+			inputText:   "14.99 yesterday",
+			expectError: true,
+			wantError:   quickadd.ErrNoDescription,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, gotErr := quickadd.RuleParser{}.ParseDraft(t.Context(), testCase.inputText, now)
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("ParseDraft(%q) got error: '%v', expected error: %v", testCase.inputText, gotErr, testCase.expectError)
+			}
+			if gotErr != nil {
+				if !errors.Is(gotErr, quickadd.ErrUnreadable) {
+					t.Errorf("got error: '%v', want it to wrap: '%v'", gotErr, quickadd.ErrUnreadable)
+				}
+				if testCase.wantError != nil && !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: '%v', want error: '%v'", gotErr, testCase.wantError)
+				}
+				return
+			}
+			if got.Amount != testCase.want.Amount || got.Description != testCase.want.Description || !got.OccuredAt.Equal(testCase.want.OccuredAt) {
+				t.Errorf("ParseDraft(%q) got: %+v, want: %+v", testCase.inputText, *got, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Package repometrics records how long repository operations take and how they fail, per database backend.
+// Everything is published with expvar under "repository", keyed by backend and then by operation.
+package repometrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expvar"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bucketBounds are the upper bounds of the duration histogram buckets, in milliseconds
+var bucketBounds = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500}
+
+// backends holds every Metrics, published by expvar as repository
+var backends = expvar.NewMap("repository")
+
+// Metrics records the operations of one backend
+type Metrics struct {
+	db *sql.DB
+
+	mux sync.Mutex
+	ops map[string]*opStats
+}
+
+// opStats are the totals for one operation
+type opStats struct {
+	count   int64
+	errors  map[string]int64
+	sum     float64
+	buckets []int64 // one per bucketBounds, then one for anything slower
+}
+
+// New publishes metrics for backend, i.e. "sqlite".
+// Open connections are read from db when it is not nil. Calling New twice for a backend replaces the first.
+func New(backend string, db *sql.DB) *Metrics {
+	m := &Metrics{db: db, ops: make(map[string]*opStats)}
+	backends.Set(backend, expvar.Func(m.snapshot))
+	return m
+}
+
+// Observe records one run of op that started at start and returned err
+func (m *Metrics) Observe(op string, start time.Time, err error) {
+	ms := float64(time.Since(start)) / float64(time.Millisecond)
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	stats, ok := m.ops[op]
+	if !ok {
+		stats = &opStats{errors: make(map[string]int64), buckets: make([]int64, len(bucketBounds)+1)}
+		m.ops[op] = stats
+	}
+
+	stats.count += 1
+	stats.sum += ms
+
+	bucket := len(bucketBounds)
+	for i, bound := range bucketBounds {
+		if ms <= bound {
+			bucket = i
+			break
+		}
+	}
+	stats.buckets[bucket] += 1
+
+	if err != nil {
+		stats.errors[ErrorType(err)] += 1
+	}
+}
+
+// ErrorType names the kind of failure err is, for counting errors by type.
+// Known errors get a short name, anything else is named by the Go type of the innermost error.
+func ErrorType(err error) string {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return "not_found"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+
+	for {
+		inner := errors.Unwrap(err)
+		if inner == nil {
+			break
+		}
+		err = inner
+	}
+
+	name := strings.TrimPrefix(reflect.TypeOf(err).String(), "*")
+	if name == "errors.errorString" || name == "fmt.wrapError" {
+		return "other"
+	}
+	return name
+}
+
+// snapshot is the expvar value, the histogram buckets are cumulative and keyed by their upper bound
+func (m *Metrics) snapshot() any {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	ops := make(map[string]any, len(m.ops))
+	for op, stats := range m.ops {
+		buckets := make(map[string]int64, len(stats.buckets))
+		var total int64
+		for i, n := range stats.buckets {
+			total += n
+			le := "+Inf"
+			if i < len(bucketBounds) {
+				le = strconv.FormatFloat(bucketBounds[i], 'f', -1, 64)
+			}
+			buckets[le] = total
+		}
+
+		errs := make(map[string]int64, len(stats.errors))
+		for errType, n := range stats.errors {
+			errs[errType] = n
+		}
+
+		ops[op] = map[string]any{
+			"count":  stats.count,
+			"errors": errs,
+			"duration_ms": map[string]any{
+				"sum":     stats.sum,
+				"buckets": buckets,
+			},
+		}
+	}
+
+	snapshot := map[string]any{"operations": ops}
+	if m.db != nil {
+		dbStats := m.db.Stats()
+		snapshot["open_connections"] = dbStats.OpenConnections
+		snapshot["in_use_connections"] = dbStats.InUse
+		snapshot["idle_connections"] = dbStats.Idle
+	}
+	return snapshot
+}
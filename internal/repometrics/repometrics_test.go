@@ -0,0 +1,108 @@
+package repometrics_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/repometrics"
+)
+
+// driverError stands in for a driver's own error type
+type driverError struct{}
+
+func (driverError) Error() string { return "database is locked" }
+
+func TestErrorType(t *testing.T) {
+	testTable := []struct {
+		name     string
+		inputErr error
+		want     string
+	}{
+		{
+			name:     "valid-not-found",
+			inputErr: fmt.Errorf("query: %w", sql.ErrNoRows),
+			want:     "not_found",
+		},
+		{
+			name:     "valid-canceled",
+			inputErr: context.Canceled,
+			want:     "canceled",
+		},
+		{
+			name:     "valid-timeout",
+			inputErr: fmt.Errorf("query: %w", context.DeadlineExceeded),
+			want:     "timeout",
+		},
+		{
+			name:     "valid-driver-error-type",
+			inputErr: fmt.Errorf("query: %w", driverError{}),
+			want:     "repometrics_test.driverError",
+		},
+		{
+			name:     "valid-plain-error",
+			inputErr: errors.New("no rows were deleted"),
+			want:     "other",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got := repometrics.ErrorType(testCase.inputErr)
+			if got != testCase.want {
+				t.Errorf("got: %q, want: %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+// published is the expvar value of one backend
+type published struct {
+	Operations map[string]struct {
+		Count      int64            `json:"count"`
+		Errors     map[string]int64 `json:"errors"`
+		DurationMS struct {
+			Buckets map[string]int64 `json:"buckets"`
+		} `json:"duration_ms"`
+	} `json:"operations"`
+	OpenConnections *int `json:"open_connections"`
+}
+
+func TestMetricsObserve(t *testing.T) {
+	m := repometrics.New("test-backend", nil)
+
+	m.Observe("expenses.get_by_id", time.Now(), nil)
+	m.Observe("expenses.get_by_id", time.Now().Add(-2*time.Second), sql.ErrNoRows)
+	m.Observe("expenses.delete", time.Now().Add(-time.Minute), context.DeadlineExceeded)
+
+	var got map[string]published
+	if err := json.Unmarshal([]byte(expvar.Get("repository").String()), &got); err != nil {
+		t.Fatalf("unable to decode expvar: %v", err)
+	}
+
+	backend, ok := got["test-backend"]
+	if !ok {
+		t.Fatalf("got backends: %v, want test-backend", got)
+	}
+	if backend.OpenConnections != nil {
+		t.Errorf("got open connections: %d, want none without a db", *backend.OpenConnections)
+	}
+
+	getByID := backend.Operations["expenses.get_by_id"]
+	if getByID.Count != 2 || getByID.Errors["not_found"] != 1 {
+		t.Errorf("got get_by_id: %+v, want 2 calls and 1 not_found", getByID)
+	}
+	if getByID.DurationMS.Buckets["1000"] != 1 || getByID.DurationMS.Buckets["2500"] != 2 || getByID.DurationMS.Buckets["+Inf"] != 2 {
+		t.Errorf("got get_by_id buckets: %v, want one fast and one 2s call", getByID.DurationMS.Buckets)
+	}
+
+	deleted := backend.Operations["expenses.delete"]
+	if deleted.Errors["timeout"] != 1 || deleted.DurationMS.Buckets["2500"] != 0 || deleted.DurationMS.Buckets["+Inf"] != 1 {
+		t.Errorf("got delete: %+v, want one slow timeout", deleted)
+	}
+}
@@ -0,0 +1,127 @@
+// Package respcache keeps recent responses of hot read endpoints in memory, so repeated reads skip the database.
+// Entries expire after a TTL and are all dropped whenever the data behind them changes.
+// Hits, misses, and invalidations are published with expvar under "response_cache".
+package respcache
+
+import (
+	"expvar"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// caches holds every Cache, published by expvar as response_cache
+var caches = expvar.NewMap("response_cache")
+
+// Entry is one cached response
+type Entry struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// item is an entry and when it stops being served
+type item struct {
+	entry   *Entry
+	expires time.Time
+}
+
+// Cache is safe for concurrent use
+type Cache struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mux        sync.Mutex
+	generation uint64
+	items      map[string]*item
+
+	hits          int64
+	misses        int64
+	invalidations int64
+}
+
+// New creates a cache published as name, i.e. "expenses", holding up to maxEntries responses for ttl each.
+// Calling New twice with a name replaces the first in the published metrics.
+func New(name string, ttl time.Duration, maxEntries int) *Cache {
+	c := &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		items:      make(map[string]*item),
+	}
+	caches.Set(name, expvar.Func(c.snapshot))
+	return c
+}
+
+// Get returns the unexpired entry for key, counting a hit or a miss
+func (c *Cache) Get(key string) (*Entry, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	cached, ok := c.items[key]
+	if !ok || !c.now().Before(cached.expires) {
+		c.misses += 1
+		return nil, false
+	}
+
+	c.hits += 1
+	return cached.entry, true
+}
+
+// Generation changes on every Invalidate, read it before building a response that is going to be cached
+func (c *Cache) Generation() uint64 {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.generation
+}
+
+// Set caches entry under key, unless the cache was invalidated since generation was read,
+// since the entry might have been built from data that has changed since.
+// Once the cache is full, new entries are only kept when expired ones can make room.
+func (c *Cache) Set(key string, generation uint64, entry *Entry) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	now := c.now()
+	if _, ok := c.items[key]; !ok && len(c.items) >= c.maxEntries {
+		for k, cached := range c.items {
+			if !now.Before(cached.expires) {
+				delete(c.items, k)
+			}
+		}
+		if len(c.items) >= c.maxEntries {
+			return
+		}
+	}
+
+	c.items[key] = &item{entry: entry, expires: now.Add(c.ttl)}
+}
+
+// Invalidate drops every entry, it is called by the services once data changes
+func (c *Cache) Invalidate() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.generation += 1
+	c.invalidations += 1
+	clear(c.items)
+}
+
+// snapshot is the expvar value
+func (c *Cache) snapshot() any {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return map[string]any{
+		"hits":          c.hits,
+		"misses":        c.misses,
+		"invalidations": c.invalidations,
+		"entries":       len(c.items),
+	}
+}
@@ -0,0 +1,94 @@
+package respcache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/respcache"
+)
+
+func TestCache(t *testing.T) {
+	testTable := []struct {
+		name       string
+		inputSetup func(cache *respcache.Cache)
+		inputKey   string
+		wantHit    bool
+	}{
+		{
+			name: "valid-hit",
+			inputSetup: func(cache *respcache.Cache) {
+				cache.Set("a", cache.Generation(), &respcache.Entry{Status: 200})
+			},
+			inputKey: "a",
+			wantHit:  true,
+		},
+		{
+			name:     "valid-miss",
+			inputKey: "a",
+			wantHit:  false,
+		},
+		{
+			name: "valid-expired",
+			inputSetup: func(cache *respcache.Cache) {
+				cache.Set("a", cache.Generation(), &respcache.Entry{Status: 200})
+				time.Sleep(30 * time.Millisecond)
+			},
+			inputKey: "a",
+			wantHit:  false,
+		},
+		{
+			name: "valid-invalidated",
+			inputSetup: func(cache *respcache.Cache) {
+				cache.Set("a", cache.Generation(), &respcache.Entry{Status: 200})
+				cache.Invalidate()
+			},
+			inputKey: "a",
+			wantHit:  false,
+		},
+		{
+			name: "valid-stale-generation-not-kept",
+			inputSetup: func(cache *respcache.Cache) {
+				generation := cache.Generation()
+				cache.Invalidate()
+				cache.Set("a", generation, &respcache.Entry{Status: 200})
+			},
+			inputKey: "a",
+			wantHit:  false,
+		},
+		{
+			name: "valid-full-cache-not-kept",
+			inputSetup: func(cache *respcache.Cache) {
+				cache.Set("a", cache.Generation(), &respcache.Entry{Status: 200})
+				cache.Set("b", cache.Generation(), &respcache.Entry{Status: 200})
+				cache.Set("c", cache.Generation(), &respcache.Entry{Status: 200})
+			},
+			inputKey: "c",
+			wantHit:  false,
+		},
+		{
+			name: "valid-full-cache-makes-room",
+			inputSetup: func(cache *respcache.Cache) {
+				cache.Set("a", cache.Generation(), &respcache.Entry{Status: 200})
+				cache.Set("b", cache.Generation(), &respcache.Entry{Status: 200})
+				time.Sleep(30 * time.Millisecond)
+				cache.Set("c", cache.Generation(), &respcache.Entry{Status: 200})
+			},
+			inputKey: "c",
+			wantHit:  true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			cache := respcache.New("test", 20*time.Millisecond, 2)
+			if testCase.inputSetup != nil {
+				testCase.inputSetup(cache)
+			}
+
+			_, gotHit := cache.Get(testCase.inputKey)
+			if gotHit != testCase.wantHit {
+				t.Errorf("got hit: %v, want hit: %v", gotHit, testCase.wantHit)
+			}
+		})
+	}
+}
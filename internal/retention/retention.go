@@ -0,0 +1,107 @@
+// Package retention removes records once they are older than the rules configured for them allow.
+// Every removal is recorded in the audit log, so what was removed, and when, can be looked up later.
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/audit"
+)
+
+// The kinds of records rules can be set for, by the names RETENTION_RULES takes
+const (
+	TargetAuditEvents = "audit_events"       // the audit log, including the reports of earlier removals
+	TargetOutbox      = "outbox"             // webhook events delivered or given up on, waiting ones are always kept
+	TargetTombstones  = "expense_tombstones" // the deletions GET /expenses/changes syncs, older cursors have to resync
+)
+
+// Purger removes the records of one kind created, delivered, or given up on before a cutoff
+type Purger interface {
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
+
+// Report is what enforcing one rule removed
+type Report struct {
+	Target  string
+	MaxAge  time.Duration
+	Before  time.Time
+	Removed int
+	Err     error
+}
+
+// Service enforces a maximum age for each target with a rule, targets without one are kept forever
+type Service struct {
+	purgers  map[string]Purger
+	rules    map[string]time.Duration
+	recorder audit.Service
+	now      func() time.Time
+}
+
+// NewService enforces rules, keyed by target, with the purger of each target. Removals are recorded
+// with recorder, which may be nil
+func NewService(purgers map[string]Purger, rules map[string]time.Duration, recorder audit.Service) *Service {
+	return &Service{purgers: purgers, rules: rules, recorder: recorder, now: time.Now}
+}
+
+// Enforce removes what every rule no longer allows to be kept, in target order.
+// A rule failing doesn't stop the others, its report has the error
+func (s *Service) Enforce(ctx context.Context) []Report {
+	targets := make([]string, 0, len(s.rules))
+	for target := range s.rules {
+		targets = append(targets, target)
+	}
+	slices.Sort(targets)
+
+	reports := make([]Report, 0, len(targets))
+	for _, target := range targets {
+		report := Report{Target: target, MaxAge: s.rules[target], Before: s.now().Add(-s.rules[target])}
+
+		purger, ok := s.purgers[target]
+		if !ok {
+			report.Err = fmt.Errorf("no records are kept as %q", target)
+		} else {
+			report.Removed, report.Err = purger.Purge(ctx, report.Before)
+		}
+
+		s.record(ctx, report)
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// record puts a removal in the audit log, rules that removed nothing aren't worth an entry
+func (s *Service) record(ctx context.Context, report Report) {
+	if report.Err != nil {
+		log.Printf("Failed to enforce the %s retention rule: %v", report.Target, report.Err)
+		return
+	}
+	if report.Removed == 0 || s.recorder == nil {
+		return
+	}
+
+	s.recorder.Record(ctx, audit.Event{
+		Type: audit.TypeRetentionPurged,
+		Detail: fmt.Sprintf("removed %d %s older than %s, from before %s",
+			report.Removed, report.Target, report.MaxAge, report.Before.UTC().Format(time.RFC3339)),
+	})
+}
+
+// Run calls Enforce every interval until ctx is done
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.Enforce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package retention_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/audit"
+	"github.com/nicholasss/expense-tracker-api/internal/retention"
+)
+
+// mockPurger removes a fixed number of records, remembering the cutoff it was given
+type mockPurger struct {
+	removed int
+	err     error
+	before  time.Time
+}
+
+func (p *mockPurger) Purge(ctx context.Context, before time.Time) (int, error) {
+	p.before = before
+	return p.removed, p.err
+}
+
+// mockRecorder keeps the audit events recorded
+type mockRecorder struct {
+	events []audit.Event
+}
+
+func (r *mockRecorder) Record(ctx context.Context, event audit.Event) {
+	r.events = append(r.events, event)
+}
+
+func (r *mockRecorder) List(ctx context.Context, filter audit.Filter) ([]*audit.Event, error) {
+	return nil, nil
+}
+
+func TestEnforce(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputPurger *mockPurger
+		inputRules  map[string]time.Duration
+		expectError bool
+		wantRemoved int
+		wantEvents  int
+	}{
+		{
+			name:        "valid-removed",
+			inputPurger: &mockPurger{removed: 3},
+			inputRules:  map[string]time.Duration{retention.TargetOutbox: 720 * time.Hour},
+			wantRemoved: 3,
+			wantEvents:  1,
+		},
+		{
+			name:        "valid-nothing-to-remove",
+			inputPurger: &mockPurger{},
+			inputRules:  map[string]time.Duration{retention.TargetOutbox: 720 * time.Hour},
+		},
+		{
+			name:        "invalid-purge-failed",
+			inputPurger: &mockPurger{err: errors.New("database is locked")},
+			inputRules:  map[string]time.Duration{retention.TargetOutbox: 720 * time.Hour},
+			expectError: true,
+		},
+		{
+			name:        "invalid-unknown-target",
+			inputPurger: &mockPurger{removed: 3},
+			inputRules:  map[string]time.Duration{"attachments": 720 * time.Hour},
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			recorder := &mockRecorder{}
+			purgers := map[string]retention.Purger{retention.TargetOutbox: testCase.inputPurger}
+			service := retention.NewService(purgers, testCase.inputRules, recorder)
+
+			start := time.Now()
+			reports := service.Enforce(t.Context())
+			if len(reports) != 1 {
+				t.Fatalf("got %d reports, want 1", len(reports))
+			}
+			report := reports[0]
+
+			if testCase.expectError != (report.Err != nil) {
+				t.Fatalf("got error: '%v', expected error: %v", report.Err, testCase.expectError)
+			}
+			if report.Removed != testCase.wantRemoved {
+				t.Errorf("got removed: %d, want removed: %d", report.Removed, testCase.wantRemoved)
+			}
+			if len(recorder.events) != testCase.wantEvents {
+				t.Fatalf("got %d audit events, want %d", len(recorder.events), testCase.wantEvents)
+			}
+			if testCase.expectError {
+				return
+			}
+
+			// the cutoff is the rule's age before now
+			if before := testCase.inputPurger.before; before.Before(start.Add(-720*time.Hour)) || before.After(time.Now().Add(-720*time.Hour)) {
+				t.Errorf("got cutoff %v, want 720h before now", before)
+			}
+			for _, event := range recorder.events {
+				if event.Type != audit.TypeRetentionPurged || !strings.Contains(event.Detail, "removed 3 outbox") {
+					t.Errorf("got audit event: %+v", event)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+// Package search keeps a bleve index of the expense descriptions, for the fuzzy matching the sqlite backend
+// has no full-text search for. The index follows the expense events, the expenses table stays the source of truth
+package search
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strconv"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+)
+
+// ErrEmptyQuery is returned when there is nothing to search for
+var ErrEmptyQuery = errors.New("search query is empty")
+
+// batchSize is how many expenses are indexed at a time when building the index
+const batchSize = 500
+
+// document is what is indexed for an expense, its id is the document id
+type document struct {
+	Description string   `json:"description"` // folded, see expenses.FoldDescription
+	Books       []string `json:"books"`       // bookKeys, so results can be limited to a scope
+}
+
+// bookKeys are the terms of the books exp belongs to, its owner's and its household's
+func bookKeys(exp *expenses.Expense) []string {
+	keys := []string{"owner:" + strconv.Itoa(exp.OwnerID)}
+	if exp.HouseholdID != 0 {
+		keys = append(keys, "household:"+strconv.Itoa(exp.HouseholdID))
+	}
+	return keys
+}
+
+// newMapping indexes descriptions as text and books as exact terms, storing neither
+func newMapping() mapping.IndexMapping {
+	description := bleve.NewTextFieldMapping()
+	description.Analyzer = "standard"
+	description.Store = false
+	description.IncludeInAll = false
+
+	books := bleve.NewKeywordFieldMapping()
+	books.Store = false
+	books.IncludeInAll = false
+
+	doc := bleve.NewDocumentMapping()
+	doc.AddFieldMappingsAt("description", description)
+	doc.AddFieldMappingsAt("books", books)
+
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.DefaultMapping = doc
+	return indexMapping
+}
+
+// Index searches the expense descriptions. Matches are read back from the repository, so an index
+// that fell behind can miss an expense but never shows one the user can't see
+type Index struct {
+	index      bleve.Index
+	repo       expenses.Repository
+	households expenses.HouseholdLookup
+}
+
+// Open opens the index at path, creating it and indexing every expense when there is none yet.
+// An empty path keeps the index in memory, so it is built on every start.
+// Removing the directory while the server is stopped builds it again on the next start
+func Open(ctx context.Context, path string, repo expenses.Repository, households expenses.HouseholdLookup) (*Index, error) {
+	var index bleve.Index
+	var err error
+	created := true
+
+	if path == "" {
+		index, err = bleve.NewMemOnly(newMapping())
+	} else if index, err = bleve.Open(path); errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		index, err = bleve.New(path, newMapping())
+	} else {
+		created = false
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &Index{index: index, repo: repo, households: households}
+	if created {
+		indexed, err := idx.build(ctx)
+		if err != nil {
+			index.Close()
+			return nil, err
+		}
+		log.Printf("Indexed %d expenses for search", indexed)
+	}
+	return idx, nil
+}
+
+// Close closes the index, writing out what is left on disk
+func (idx *Index) Close() error {
+	return idx.index.Close()
+}
+
+// build indexes every expense, returning how many were
+func (idx *Index) build(ctx context.Context) (int, error) {
+	batch := idx.index.NewBatch()
+	indexed := 0
+
+	err := idx.repo.Each(ctx, expenses.Unscoped, func(exp *expenses.Expense) error {
+		if err := batch.Index(strconv.Itoa(exp.ID), documentOf(exp)); err != nil {
+			return err
+		}
+		indexed++
+
+		if batch.Size() < batchSize {
+			return nil
+		}
+		if err := idx.index.Batch(batch); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := idx.index.Batch(batch); err != nil {
+		return 0, err
+	}
+	return indexed, nil
+}
+
+// documentOf is what is indexed for exp
+func documentOf(exp *expenses.Expense) document {
+	return document{Description: expenses.FoldDescription(exp.Description), Books: bookKeys(exp)}
+}
+
+// Subscribe keeps the index up to date with the events published on bus
+func (idx *Index) Subscribe(bus *expenses.Bus) {
+	bus.Subscribe(idx.Apply)
+}
+
+// Apply indexes the expense event is about, or removes it once deleted. Failures are logged, the
+// expense is only missing from search until it changes again or the index is built again
+func (idx *Index) Apply(ctx context.Context, event expenses.Event) {
+	id := strconv.Itoa(event.Expense.ID)
+
+	var err error
+	switch event.Type {
+	case expenses.ExpenseCreated:
+		err = idx.index.Index(id, documentOf(event.Expense))
+	case expenses.ExpenseUpdated:
+		// updates don't carry the owner or household, so the expense is read back
+		var exp *expenses.Expense
+		if exp, err = idx.repo.GetByID(ctx, expenses.Unscoped, event.Expense.ID); err == nil {
+			err = idx.index.Index(id, documentOf(exp))
+		}
+	case expenses.ExpenseDeleted:
+		err = idx.index.Delete(id)
+	}
+
+	if err != nil {
+		log.Printf("Failed to index expense %s for search: %v", id, err)
+	}
+}
+
+// scopeQuery limits a search to the books scope can see, nil when it sees every book
+func scopeQuery(scope expenses.Scope) query.Query {
+	if scope.AllOwners {
+		return nil
+	}
+
+	keys := bookKeys(&expenses.Expense{OwnerID: scope.OwnerID, HouseholdID: scope.HouseholdID})
+	books := make([]query.Query, 0, len(keys))
+	for _, key := range keys {
+		term := bleve.NewTermQuery(key)
+		term.SetField("books")
+		books = append(books, term)
+	}
+	return bleve.NewDisjunctionQuery(books...)
+}
+
+// Search finds the authenticated user's expenses whose descriptions have every word of text,
+// allowing for a typo or two depending on each word's length. The best matches come first
+func (idx *Index) Search(ctx context.Context, text string, limit, offset int) ([]*expenses.Expense, error) {
+	text = expenses.FoldDescription(text)
+	if text == "" {
+		return nil, ErrEmptyQuery
+	}
+
+	scope, err := expenses.ScopeOf(ctx, idx.households)
+	if err != nil {
+		return nil, err
+	}
+
+	match := bleve.NewMatchQuery(text)
+	match.SetField("description")
+	match.SetAutoFuzziness(true)
+	match.SetOperator(query.MatchQueryOperatorAnd)
+
+	var q query.Query = match
+	if books := scopeQuery(scope); books != nil {
+		q = bleve.NewConjunctionQuery(match, books)
+	}
+
+	result, err := idx.index.SearchInContext(ctx, bleve.NewSearchRequestOptions(q, limit, offset, false))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		id, err := strconv.Atoi(hit.ID)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	found, err := idx.repo.GetByIDs(ctx, scope, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// in the order of the hits, leaving out any the index kept after they were gone
+	slices.SortFunc(found, func(a, b *expenses.Expense) int {
+		return slices.Index(ids, a.ID) - slices.Index(ids, b.ID)
+	})
+	return found, nil
+}
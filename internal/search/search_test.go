@@ -0,0 +1,160 @@
+package search_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+	"github.com/nicholasss/expense-tracker-api/internal/search"
+	"github.com/nicholasss/expense-tracker-api/internal/sqlite"
+)
+
+// setupTestIndex creates an expense service backed by an in-memory sqlite database, with expenses for users 1 and 2
+// created before the index is opened and after, and an in-memory index following its events
+func setupTestIndex(t *testing.T) (*expenses.ExpenseService, *search.Index) {
+	t.Helper()
+
+	repo, err := sqlite.NewSqliteRepository("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+
+	if _, err := sqlite.Migrate(t.Context(), repo.DB); err != nil {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+
+	bus := expenses.NewBus()
+	service := expenses.NewService(repo, expenses.WithEvents(bus))
+	occuredAt := time.Unix(1761670800, 0)
+
+	// ids 1 and 2 are indexed when the index is built, 3 and 4 from their events
+	create := func(userID int, description string) {
+		t.Helper()
+		ctx := auth.WithUserID(t.Context(), userID)
+		if _, err := service.NewExpense(ctx, occuredAt, description, money.New(350, money.DefaultCurrency), 0); err != nil {
+			t.Fatalf("NewExpense() got error: '%v'", err)
+		}
+	}
+	create(1, "Coffee at Café Müller")
+	create(2, "coffee beans")
+
+	index, err := search.Open(t.Context(), "", repo, nil)
+	if err != nil {
+		t.Fatalf("Open() got error: '%v'", err)
+	}
+	t.Cleanup(func() { index.Close() })
+	index.Subscribe(bus)
+
+	create(1, "train ticket to Lyon")
+	create(1, "coffee and croissant")
+
+	return service, index
+}
+
+func TestSearch(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputUserID int
+		inputQuery  string
+		expectError bool
+		wantError   error
+		wantIDs     []int
+	}{
+		{
+			name:        "valid-exact",
+			inputUserID: 1,
+			inputQuery:  "ticket",
+			wantIDs:     []int{3},
+		},
+		{
+			name:        "valid-typo",
+			inputUserID: 1,
+			inputQuery:  "trein tiket",
+			wantIDs:     []int{3},
+		},
+		{
+			name:        "valid-accents-folded",
+			inputUserID: 1,
+			inputQuery:  "cafe muller",
+			wantIDs:     []int{1},
+		},
+		{
+			name:        "valid-only-own-expenses",
+			inputUserID: 2,
+			inputQuery:  "cofee",
+			wantIDs:     []int{2},
+		},
+		{
+			name:        "valid-every-word",
+			inputUserID: 1,
+			inputQuery:  "coffee croissant",
+			wantIDs:     []int{4},
+		},
+		{
+			name:        "valid-no-matches",
+			inputUserID: 2,
+			inputQuery:  "ticket",
+			wantIDs:     []int{},
+		},
+		{
+			name:        "invalid-empty-query",
+			inputUserID: 1,
+			inputQuery:  "  ",
+			expectError: true,
+			wantError:   search.ErrEmptyQuery,
+		},
+	}
+
+	_, index := setupTestIndex(t)
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := auth.WithUserID(t.Context(), testCase.inputUserID)
+			found, err := index.Search(ctx, testCase.inputQuery, 10, 0)
+			if testCase.expectError {
+				if !errors.Is(err, testCase.wantError) {
+					t.Fatalf("Search() got error: '%v', want error: '%v'", err, testCase.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Search() got error: '%v'", err)
+			}
+
+			gotIDs := make([]int, 0, len(found))
+			for _, exp := range found {
+				gotIDs = append(gotIDs, exp.ID)
+			}
+			if !slices.Equal(gotIDs, testCase.wantIDs) {
+				t.Errorf("got ids: %v, want ids: %v", gotIDs, testCase.wantIDs)
+			}
+		})
+	}
+}
+
+func TestSearchFollowsChanges(t *testing.T) {
+	service, index := setupTestIndex(t)
+	ctx := auth.WithUserID(t.Context(), 1)
+
+	err := service.UpdateExpense(ctx, 3, time.Unix(1761670800, 0), "bus ticket to Lyon", money.New(2900, money.DefaultCurrency), 0)
+	if err != nil {
+		t.Fatalf("UpdateExpense() got error: '%v'", err)
+	}
+	if found, err := index.Search(ctx, "bus", 10, 0); err != nil || len(found) != 1 || found[0].ID != 3 {
+		t.Fatalf("Search() after update got: %v, error: '%v', want expense 3", found, err)
+	}
+
+	if err := service.DeleteExpense(ctx, 3); err != nil {
+		t.Fatalf("DeleteExpense() got error: '%v'", err)
+	}
+	if found, err := index.Search(ctx, "ticket", 10, 0); err != nil || len(found) != 0 {
+		t.Fatalf("Search() after delete got: %v, error: '%v', want nothing", found, err)
+	}
+}
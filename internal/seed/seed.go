@@ -0,0 +1,132 @@
+// Package seed makes up demo expenses, months of believable spending for trying out reports and UIs
+package seed
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+)
+
+// Expense is one made up expense, amounts are in minor units of the creator's currency
+type Expense struct {
+	OccuredAt   time.Time
+	Category    string
+	Description string
+	Amount      int64
+}
+
+// recurring is paid once a month on the same day, for roughly the same amount
+type recurring struct {
+	category    string
+	description string
+	day         int
+	min, max    int64
+}
+
+// occasional happens perWeek times a week on average, during the hours given
+type occasional struct {
+	category     string
+	descriptions []string
+	perWeek      float64
+	min, max     int64
+	from, until  int // hours of the day
+}
+
+var recurringExpenses = []recurring{
+	{"housing", "rent", 1, 145000, 145000},
+	{"utilities", "electricity bill", 6, 5800, 11200},
+	{"utilities", "internet", 12, 5999, 5999},
+	{"utilities", "phone plan", 20, 3500, 3500},
+	{"entertainment", "music streaming", 15, 1099, 1099},
+	{"health", "gym membership", 3, 4500, 4500},
+}
+
+var occasionalExpenses = []occasional{
+	{"groceries", []string{"weekly shop at the supermarket", "groceries", "farmers market", "corner shop top-up"}, 2, 1800, 14500, 9, 21},
+	{"coffee", []string{"flat white", "coffee and a croissant", "iced latte"}, 4, 350, 850, 7, 11},
+	{"dining", []string{"lunch with the team", "takeaway pizza", "ramen", "dinner with friends", "burrito"}, 3, 1100, 7800, 12, 22},
+	{"transport", []string{"train ticket", "bus fare", "cab home", "bike share"}, 3, 250, 3200, 7, 23},
+	{"transport", []string{"fuel"}, 0.5, 4200, 7600, 8, 20},
+	{"shopping", []string{"new running shoes", "books", "household supplies", "birthday present", "phone charger"}, 1, 900, 12000, 10, 20},
+	{"entertainment", []string{"cinema tickets", "concert", "board game night", "museum entry"}, 0.75, 1200, 8500, 14, 23},
+	{"health", []string{"pharmacy", "dentist copay", "vitamins"}, 0.4, 600, 9000, 9, 18},
+}
+
+// amountBetween is a random amount in [min, max]
+func amountBetween(rng *rand.Rand, min, max int64) int64 {
+	if max <= min {
+		return min
+	}
+	return min + rng.Int64N(max-min+1)
+}
+
+// timeOnDay is a random minute of day between the hours from and until
+func timeOnDay(rng *rand.Rand, day time.Time, from, until int) time.Time {
+	minutes := from*60 + rng.IntN((until-from)*60)
+	return day.Add(time.Duration(minutes) * time.Minute)
+}
+
+// Generate makes up the expenses of the given number of months up to now, the current month included.
+// The same rng seed gives the same expenses, in the order they occured
+func Generate(rng *rand.Rand, now time.Time, months int) []Expense {
+	year, month, _ := now.Date()
+	start := time.Date(year, month-time.Month(months-1), 1, 0, 0, 0, 0, now.Location())
+
+	generated := make([]Expense, 0)
+	for day := start; !day.After(now); day = day.AddDate(0, 0, 1) {
+		for _, r := range recurringExpenses {
+			if day.Day() == r.day {
+				generated = append(generated, Expense{
+					OccuredAt:   day.Add(9 * time.Hour),
+					Category:    r.category,
+					Description: r.description,
+					Amount:      amountBetween(rng, r.min, r.max),
+				})
+			}
+		}
+
+		for _, o := range occasionalExpenses {
+			// a day has a perWeek/7 chance of each, and rarely two of them
+			for chance := o.perWeek / 7; rng.Float64() < chance; chance /= 4 {
+				generated = append(generated, Expense{
+					OccuredAt:   timeOnDay(rng, day, o.from, o.until),
+					Category:    o.category,
+					Description: o.descriptions[rng.IntN(len(o.descriptions))],
+					Amount:      amountBetween(rng, o.min, o.max),
+				})
+			}
+		}
+	}
+
+	// nothing in the future, and in order, as if they were recorded as they happened
+	kept := generated[:0]
+	for _, exp := range generated {
+		if !exp.OccuredAt.After(now) {
+			kept = append(kept, exp)
+		}
+	}
+	slices.SortStableFunc(kept, func(a, b Expense) int { return a.OccuredAt.Compare(b.OccuredAt) })
+	return kept
+}
+
+// Creator records an expense, *expenses.ExpenseService in the server. It is called with the
+// seeding user in ctx, so the expenses are theirs and land in their household like any they add
+type Creator interface {
+	Currency() string
+	NewExpense(ctx context.Context, occuredAt time.Time, description string, amount money.Money, categoryID int) (*expenses.Expense, error)
+}
+
+// Seed records every generated expense through creator, stopping at the first that fails
+func Seed(ctx context.Context, creator Creator, generated []Expense) (int, error) {
+	for i, exp := range generated {
+		if _, err := creator.NewExpense(ctx, exp.OccuredAt, exp.Description, money.New(exp.Amount, creator.Currency()), 0); err != nil {
+			return i, fmt.Errorf("seeding %q on %s: %w", exp.Description, exp.OccuredAt.Format(time.DateOnly), err)
+		}
+	}
+	return len(generated), nil
+}
@@ -0,0 +1,111 @@
+package seed_test
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+	"github.com/nicholasss/expense-tracker-api/internal/seed"
+)
+
+func TestGenerate(t *testing.T) {
+	testTable := []struct {
+		name       string
+		inputNow   time.Time
+		inputMonth int
+		wantRent   int // one on the first of each month
+		wantStart  time.Time
+	}{
+		{
+			name:       "valid-six-months",
+			inputNow:   time.Date(2025, 10, 14, 18, 0, 0, 0, time.UTC),
+			inputMonth: 6,
+			wantRent:   6,
+			wantStart:  time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "valid-across-new-year",
+			inputNow:   time.Date(2026, 2, 1, 8, 0, 0, 0, time.UTC),
+			inputMonth: 3,
+			wantRent:   2, // february's is paid at 9:00, after now
+			wantStart:  time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got := seed.Generate(rand.New(rand.NewPCG(1, 2)), testCase.inputNow, testCase.inputMonth)
+			if len(got) == 0 {
+				t.Fatalf("got no expenses")
+			}
+
+			rent := 0
+			categories := make(map[string]bool)
+			for i, exp := range got {
+				if exp.OccuredAt.Before(testCase.wantStart) || exp.OccuredAt.After(testCase.inputNow) {
+					t.Errorf("got expense %d at %v, outside of %v to %v", i, exp.OccuredAt, testCase.wantStart, testCase.inputNow)
+				}
+				if i > 0 && exp.OccuredAt.Before(got[i-1].OccuredAt) {
+					t.Errorf("got expense %d before the one ahead of it", i)
+				}
+				if exp.Amount <= 0 || exp.Description == "" {
+					t.Errorf("got expense %d: %+v", i, exp)
+				}
+				if exp.Description == "rent" {
+					rent++
+				}
+				categories[exp.Category] = true
+			}
+
+			if rent != testCase.wantRent {
+				t.Errorf("got %d rent payments, want %d", rent, testCase.wantRent)
+			}
+			if len(categories) < 6 {
+				t.Errorf("got %d categories, want at least 6: %v", len(categories), categories)
+			}
+
+			// the same seed makes the same expenses
+			again := seed.Generate(rand.New(rand.NewPCG(1, 2)), testCase.inputNow, testCase.inputMonth)
+			if !reflect.DeepEqual(got, again) {
+				t.Errorf("got different expenses from the same seed")
+			}
+		})
+	}
+}
+
+// failingCreator fails once it has created failAfter expenses
+type failingCreator struct {
+	created   int
+	failAfter int
+}
+
+func (c *failingCreator) Currency() string {
+	return "EUR"
+}
+
+func (c *failingCreator) NewExpense(ctx context.Context, occuredAt time.Time, description string, amount money.Money, categoryID int) (*expenses.Expense, error) {
+	if c.created == c.failAfter {
+		return nil, expenses.ErrInvalidAmount
+	}
+	c.created++
+	return &expenses.Expense{ID: c.created, ExpenseOccuredAt: occuredAt, Description: description, Amount: amount}, nil
+}
+
+func TestSeed(t *testing.T) {
+	generated := seed.Generate(rand.New(rand.NewPCG(1, 2)), time.Date(2025, 10, 14, 18, 0, 0, 0, time.UTC), 1)
+
+	seeded, err := seed.Seed(t.Context(), &failingCreator{failAfter: len(generated)}, generated)
+	if err != nil || seeded != len(generated) {
+		t.Fatalf("Seed() got %d seeded and error: '%v', want %d", seeded, err, len(generated))
+	}
+
+	seeded, err = seed.Seed(t.Context(), &failingCreator{failAfter: 3}, generated)
+	if !errors.Is(err, expenses.ErrInvalidAmount) || seeded != 3 {
+		t.Errorf("Seed() got %d seeded and error: '%v', want 3 and '%v'", seeded, err, expenses.ErrInvalidAmount)
+	}
+}
@@ -0,0 +1,132 @@
+// Package selfcheck verifies the environment at startup, before the server takes any requests
+package selfcheck
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"time"
+)
+
+// checkTimeout bounds each check, so a hung database fails startup instead of blocking it
+const checkTimeout = 10 * time.Second
+
+// Check is one step of the startup sequence.
+// Hint is printed when it fails, saying what usually fixes it.
+type Check struct {
+	Name string
+	Hint string
+	Run  func(ctx context.Context) error
+}
+
+// FailedError is returned for the first check that fails
+type FailedError struct {
+	Check string
+	Hint  string
+	Err   error
+}
+
+func (e *FailedError) Error() string {
+	if e.Hint == "" {
+		return fmt.Sprintf("self-check %q failed: %v", e.Check, e.Err)
+	}
+	return fmt.Sprintf("self-check %q failed: %v (%s)", e.Check, e.Err, e.Hint)
+}
+
+// Unwrap implementing for errors.Is()
+func (e *FailedError) Unwrap() error { return e.Err }
+
+// Run runs the checks in order and stops at the first failure, since later checks
+// usually depend on earlier ones. report is called with the name of each check that passes
+func Run(ctx context.Context, checks []Check, report func(name string)) error {
+	for _, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		err := check.Run(checkCtx)
+		cancel()
+
+		if err != nil {
+			return &FailedError{Check: check.Name, Hint: check.Hint, Err: err}
+		}
+		if report != nil {
+			report(check.Name)
+		}
+	}
+	return nil
+}
+
+// Database checks that db accepts connections
+func Database(db *sql.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// SchemaVersion checks that the applied migrations match the ones this build expects.
+// A database that is behind needs migrating, and one that is ahead belongs to a newer build.
+func SchemaVersion(applied func(ctx context.Context) (int64, error), want int64) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		got, err := applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case got < want:
+			return fmt.Errorf("database schema is at version %d, this build needs %d", got, want)
+		case got > want:
+			return fmt.Errorf("database schema is at version %d, newer than %d that this build knows", got, want)
+		}
+		return nil
+	}
+}
+
+// Writable checks that files can be created in dir, creating it when it does not exist
+func Writable(dir string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+
+		f, err := os.CreateTemp(dir, ".selfcheck-*")
+		if err != nil {
+			return err
+		}
+		return errors.Join(f.Close(), os.Remove(f.Name()))
+	}
+}
+
+// ErrClockBehind is returned when the clock is set before this build was made
+var ErrClockBehind = errors.New("system clock is behind")
+
+// Clock checks that now is not before floor, since tokens, TLS certificates, and
+// the recorded times of expenses all depend on the clock being roughly right
+func Clock(now func() time.Time, floor time.Time) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if got := now(); got.Before(floor) {
+			return fmt.Errorf("%w: it reads %s, which is before %s", ErrClockBehind,
+				got.UTC().Format(time.RFC3339), floor.UTC().Format(time.RFC3339))
+		}
+		return nil
+	}
+}
+
+// BuildTime is the commit time stamped into the binary, or def when it was built without vcs info
+func BuildTime(def time.Time) time.Time {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return def
+	}
+
+	for _, setting := range info.Settings {
+		if setting.Key != "vcs.time" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, setting.Value); err == nil {
+			return t
+		}
+	}
+	return def
+}
@@ -0,0 +1,145 @@
+package selfcheck_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/selfcheck"
+)
+
+var errBroken = errors.New("broken")
+
+func pass(ctx context.Context) error { return nil }
+func fail(ctx context.Context) error { return errBroken }
+
+func TestRun(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputChecks []selfcheck.Check
+		expectError bool
+		wantCheck   string
+		wantPassed  []string
+	}{
+		{
+			name: "valid-all-pass",
+			inputChecks: []selfcheck.Check{
+				{Name: "clock", Run: pass},
+				{Name: "database", Run: pass},
+			},
+			expectError: false,
+			wantPassed:  []string{"clock", "database"},
+		},
+		{
+			name: "invalid-stops-at-first-failure",
+			inputChecks: []selfcheck.Check{
+				{Name: "clock", Run: pass},
+				{Name: "database", Hint: "check DB_PATH", Run: fail},
+				{Name: "schema", Run: pass},
+			},
+			expectError: true,
+			wantCheck:   "database",
+			wantPassed:  []string{"clock"},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			passed := make([]string, 0)
+			gotErr := selfcheck.Run(t.Context(), testCase.inputChecks, func(name string) {
+				passed = append(passed, name)
+			})
+
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("Run() got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+			if !slices.Equal(passed, testCase.wantPassed) {
+				t.Errorf("got passed: %v, want passed: %v", passed, testCase.wantPassed)
+			}
+
+			if gotErr != nil {
+				var failed *selfcheck.FailedError
+				if !errors.As(gotErr, &failed) || failed.Check != testCase.wantCheck {
+					t.Errorf("got error: '%v', want failed check: %q", gotErr, testCase.wantCheck)
+				}
+				if !errors.Is(gotErr, errBroken) {
+					t.Errorf("got error: '%v', want it to wrap: '%v'", gotErr, errBroken)
+				}
+			}
+		})
+	}
+}
+
+func TestChecks(t *testing.T) {
+	floor := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	// a file where a directory is needed can never be written into
+	blocked := filepath.Join(t.TempDir(), "blocked")
+	if err := os.WriteFile(blocked, nil, 0o600); err != nil {
+		t.Fatalf("unable to create file: %v", err)
+	}
+
+	applied := func(version int64) func(ctx context.Context) (int64, error) {
+		return func(ctx context.Context) (int64, error) { return version, nil }
+	}
+
+	testTable := []struct {
+		name        string
+		inputCheck  func(ctx context.Context) error
+		expectError bool
+		wantError   error
+	}{
+		{
+			name:        "valid-clock",
+			inputCheck:  selfcheck.Clock(func() time.Time { return floor.Add(time.Hour) }, floor),
+			expectError: false,
+		},
+		{
+			name:        "invalid-clock-behind",
+			inputCheck:  selfcheck.Clock(func() time.Time { return time.Unix(0, 0) }, floor),
+			expectError: true,
+			wantError:   selfcheck.ErrClockBehind,
+		},
+		{
+			name:        "valid-schema-current",
+			inputCheck:  selfcheck.SchemaVersion(applied(11), 11),
+			expectError: false,
+		},
+		{
+			name:        "invalid-schema-behind",
+			inputCheck:  selfcheck.SchemaVersion(applied(9), 11),
+			expectError: true,
+		},
+		{
+			name:        "invalid-schema-ahead",
+			inputCheck:  selfcheck.SchemaVersion(applied(12), 11),
+			expectError: true,
+		},
+		{
+			name:        "valid-writable-new-dir",
+			inputCheck:  selfcheck.Writable(filepath.Join(t.TempDir(), "attachments")),
+			expectError: false,
+		},
+		{
+			name:        "invalid-writable-not-a-dir",
+			inputCheck:  selfcheck.Writable(filepath.Join(blocked, "attachments")),
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			gotErr := testCase.inputCheck(t.Context())
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("check got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+			if testCase.wantError != nil && !errors.Is(gotErr, testCase.wantError) {
+				t.Errorf("got error: '%v', want error: '%v'", gotErr, testCase.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package slack
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/nicholasss/expense-tracker-api/internal/quickadd"
+)
+
+// Usage is the reply to "/expense help" and to anything that can't be read
+const Usage = "Record an expense with `/expense 12.50 lunch with the team`, the amount first and then what it was for"
+
+// ErrHelp is returned by ParseCommand when the text asks for Usage
+var ErrHelp = errors.New("slack command asked for help")
+
+// Command is an expense entered with the slash command, it occured when the command was sent
+type Command struct {
+	Amount      int64 // cents
+	Description string
+}
+
+// ParseCommand reads the text after /expense, an amount followed by a description.
+// The amount can have a currency symbol and up to two decimals, i.e. "$12.50"
+func ParseCommand(text string) (*Command, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || strings.EqualFold(fields[0], "help") {
+		return nil, ErrHelp
+	}
+
+	entry, err := quickadd.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Command{Amount: entry.Amount, Description: entry.Description}, nil
+}
+
+// Escape makes text safe to put in a message, where &, <, and > would otherwise be read as markup
+func Escape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
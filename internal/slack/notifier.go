@@ -0,0 +1,142 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+)
+
+// queueSize is how many notifications can wait to be posted before new ones are dropped
+const queueSize = 32
+
+// postTimeout bounds each post to the webhook
+const postTimeout = 10 * time.Second
+
+// notificationsDropped counts notifications lost to a full queue, published by expvar as slack_notifications_dropped
+var notificationsDropped = expvar.NewInt("slack_notifications_dropped")
+
+// Message is the body of an incoming webhook post
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Notifier posts to a channel's incoming webhook when an expense of at least MinAmount is created
+// by one of its owners. Posts are queued and sent in the background, so a slow webhook never slows down a request
+type Notifier struct {
+	webhookURL string
+	minAmount  int64
+	owners     map[int]bool
+	client     *http.Client
+
+	mux    sync.Mutex
+	closed bool
+	queue  chan Message
+	done   chan struct{}
+}
+
+// NewNotifier starts posting to webhookURL about expenses of minAmount minor units or more,
+// owned by one of ownerIDs. Expenses created without auth are owned by 0
+func NewNotifier(webhookURL string, minAmount int64, ownerIDs []int) *Notifier {
+	owners := make(map[int]bool, len(ownerIDs))
+	for _, id := range ownerIDs {
+		owners[id] = true
+	}
+
+	n := &Notifier{
+		webhookURL: webhookURL,
+		minAmount:  minAmount,
+		owners:     owners,
+		client:     &http.Client{Timeout: postTimeout},
+		queue:      make(chan Message, queueSize),
+		done:       make(chan struct{}),
+	}
+
+	go n.run()
+	return n
+}
+
+// ExpenseCreated queues a notification when e is large enough, see expenses.WithNotifier
+func (n *Notifier) ExpenseCreated(e *expenses.Expense) {
+	// the channel is the operator's, other users' expenses are none of its business
+	if e.Amount.Minor < n.minAmount || !n.owners[e.OwnerID] {
+		return
+	}
+	n.Notify(Message{Text: fmt.Sprintf("Large expense of *%s* for %s on %s (expense %d)",
+		e.Amount.Format(), Escape(e.Description), e.ExpenseOccuredAt.UTC().Format(time.DateOnly), e.ID)})
+}
+
+// Notify queues msg, dropping it when the queue is full or the notifier is closed
+func (n *Notifier) Notify(msg Message) {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	if n.closed {
+		return
+	}
+
+	select {
+	case n.queue <- msg:
+	default:
+		notificationsDropped.Add(1)
+	}
+}
+
+// Close stops taking notifications and waits for the queued ones to be posted
+func (n *Notifier) Close() {
+	n.mux.Lock()
+	if n.closed {
+		n.mux.Unlock()
+		return
+	}
+	n.closed = true
+	close(n.queue)
+	n.mux.Unlock()
+
+	<-n.done
+}
+
+// run posts queued notifications until the queue is closed
+func (n *Notifier) run() {
+	defer close(n.done)
+
+	for msg := range n.queue {
+		if err := n.post(msg); err != nil {
+			log.Printf("Failed to post Slack notification: %v", err)
+		}
+	}
+}
+
+func (n *Notifier) post(msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), postTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack webhook answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package slack_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+	"github.com/nicholasss/expense-tracker-api/internal/slack"
+)
+
+func TestVerify(t *testing.T) {
+	secret := []byte("8f742231b10e8888abcd99yyyzzz85a5")
+	body := []byte("token=xyzz0WbapA4vBCDEFasx0q6G&command=%2Fexpense&text=12.50+lunch")
+	now := time.Unix(1760000000, 0)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	testTable := []struct {
+		name           string
+		inputTimestamp string
+		inputSignature string
+		inputBody      []byte
+		inputNow       time.Time
+		expectError    bool
+		wantError      error
+	}{
+		{
+			name:           "valid-signature",
+			inputTimestamp: timestamp,
+			inputSignature: slack.Sign(secret, timestamp, body),
+			inputBody:      body,
+			inputNow:       now,
+		},
+		{
+			name:           "valid-within-clock-skew",
+			inputTimestamp: timestamp,
+			inputSignature: slack.Sign(secret, timestamp, body),
+			inputBody:      body,
+			inputNow:       now.Add(4 * time.Minute),
+		},
+		{
+			name:           "invalid-tampered-body",
+			inputTimestamp: timestamp,
+			inputSignature: slack.Sign(secret, timestamp, body),
+			inputBody:      []byte("token=xyzz0WbapA4vBCDEFasx0q6G&command=%2Fexpense&text=99999+lunch"),
+			inputNow:       now,
+			expectError:    true,
+			wantError:      slack.ErrBadSignature,
+		},
+		{
+			name:           "invalid-other-secret",
+			inputTimestamp: timestamp,
+			inputSignature: slack.Sign([]byte("another secret"), timestamp, body),
+			inputBody:      body,
+			inputNow:       now,
+			expectError:    true,
+			wantError:      slack.ErrBadSignature,
+		},
+		{
+			name:           "invalid-replayed-later",
+			inputTimestamp: timestamp,
+			inputSignature: slack.Sign(secret, timestamp, body),
+			inputBody:      body,
+			inputNow:       now.Add(10 * time.Minute),
+			expectError:    true,
+			wantError:      slack.ErrStaleRequest,
+		},
+		{
+			name:           "invalid-missing-timestamp",
+			inputSignature: slack.Sign(secret, "", body),
+			inputBody:      body,
+			inputNow:       now,
+			expectError:    true,
+			wantError:      slack.ErrStaleRequest,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			gotErr := slack.Verify(secret, testCase.inputTimestamp, testCase.inputSignature, testCase.inputBody, testCase.inputNow)
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("Verify() got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+			if testCase.wantError != nil && !errors.Is(gotErr, testCase.wantError) {
+				t.Errorf("got error: '%v', want error: '%v'", gotErr, testCase.wantError)
+			}
+		})
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputText   string
+		expectError bool
+		wantError   error
+		want        slack.Command
+	}{
+		{
+			name:      "valid-decimal-amount",
+			inputText: "12.50 lunch with the team",
+			want:      slack.Command{Amount: 1250, Description: "lunch with the team"},
+		},
+		{
+			name:      "valid-whole-amount-with-symbol",
+			inputText: "  $8   coffee  ",
+			want:      slack.Command{Amount: 800, Description: "coffee"},
+		},
+		{
+			name:      "valid-one-decimal",
+			inputText: "€3.5 bus",
+			want:      slack.Command{Amount: 350, Description: "bus"},
+		},
+		{
+			name:        "invalid-empty-asks-for-help",
+			inputText:   "",
+			expectError: true,
+			wantError:   slack.ErrHelp,
+		},
+		{
+			name:        "invalid-help",
+			inputText:   "HELP",
+			expectError: true,
+			wantError:   slack.ErrHelp,
+		},
+		{
+			name:        "invalid-missing-description",
+			inputText:   "12.50",
+			expectError: true,
+		},
+		{
+			name:        "invalid-description-first",
+			inputText:   "lunch 12.50",
+			expectError: true,
+		},
+		{
+			name:        "invalid-three-decimals",
+			inputText:   "12.505 lunch",
+			expectError: true,
+		},
+		{
+			name:        "invalid-negative",
+			inputText:   "-12 refund",
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, gotErr := slack.ParseCommand(testCase.inputText)
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("ParseCommand(%q) got error: '%v', expected error: %v", testCase.inputText, gotErr, testCase.expectError)
+			}
+			if gotErr != nil {
+				if testCase.wantError != nil && !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: '%v', want error: '%v'", gotErr, testCase.wantError)
+				}
+				return
+			}
+			if *got != testCase.want {
+				t.Errorf("ParseCommand(%q) got: %+v, want: %+v", testCase.inputText, *got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestNotifier(t *testing.T) {
+	var mux sync.Mutex
+	var got []slack.Message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slack.Message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("unable to decode webhook post: %v", err)
+		}
+		mux.Lock()
+		got = append(got, msg)
+		mux.Unlock()
+	}))
+	defer srv.Close()
+
+	notifier := slack.NewNotifier(srv.URL, 10000, []int{1})
+	occured := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	notifier.ExpenseCreated(&expenses.Expense{ID: 1, OwnerID: 1, Amount: money.New(9999, "EUR"), Description: "groceries", ExpenseOccuredAt: occured})
+	notifier.ExpenseCreated(&expenses.Expense{ID: 2, OwnerID: 1, Amount: money.New(125000, "EUR"), Description: "rent <march> & fees", ExpenseOccuredAt: occured})
+
+	// another user's expenses stay out of the channel, however large
+	notifier.ExpenseCreated(&expenses.Expense{ID: 4, OwnerID: 2, Amount: money.New(990000, "EUR"), Description: "car", ExpenseOccuredAt: occured})
+	notifier.Close()
+
+	// closing waits for the queue, and later notifications are dropped
+	notifier.ExpenseCreated(&expenses.Expense{ID: 3, OwnerID: 1, Amount: money.New(125000, "EUR"), Description: "rent", ExpenseOccuredAt: occured})
+
+	mux.Lock()
+	defer mux.Unlock()
+	if len(got) != 1 {
+		t.Fatalf("got %d posts, want 1: %+v", len(got), got)
+	}
+	want := "Large expense of *€1250.00* for rent &lt;march&gt; &amp; fees on 2025-03-14 (expense 2)"
+	if got[0].Text != want {
+		t.Errorf("got text: %q, want: %q", got[0].Text, want)
+	}
+}
@@ -0,0 +1,50 @@
+// Package slack takes expenses from a Slack slash command, and posts notifications about them to a channel
+// through an incoming webhook.
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// maxRequestAge is how old a signed request can be, so a captured one can't be replayed later
+const maxRequestAge = 5 * time.Minute
+
+// signatureVersion prefixes the signed string and the signature, Slack has only ever used v0
+const signatureVersion = "v0"
+
+// Errors from Verify, neither says which part of the signature was wrong
+var (
+	ErrStaleRequest = errors.New("slack request timestamp is missing or too old")
+	ErrBadSignature = errors.New("slack request signature does not match")
+)
+
+// Sign is the X-Slack-Signature of body sent at timestamp, the unix time in X-Slack-Request-Timestamp
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signatureVersion + ":" + timestamp + ":"))
+	mac.Write(body)
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a request came from Slack, signed with the app's signing secret within maxRequestAge of now.
+// body is the raw request body, before any form parsing
+func Verify(secret []byte, timestamp, signature string, body []byte, now time.Time) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrStaleRequest
+	}
+	age := now.Sub(time.Unix(unix, 0))
+	if age > maxRequestAge || age < -maxRequestAge {
+		return ErrStaleRequest
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return ErrBadSignature
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/audit"
+)
+
+// AuditRepository implements audit.Repository, sharing the expenses database
+type AuditRepository struct {
+	DB     *sql.DB
+	Writer *sql.DB // takes every write, see NewSqliteRepository
+}
+
+func NewAuditRepository(db, writer *sql.DB) *AuditRepository {
+	return &AuditRepository{DB: db, Writer: writer}
+}
+
+// Insert stores the event, the time is set by the database
+func (r *AuditRepository) Insert(ctx context.Context, event *audit.Event) error {
+	query := `
+  INSERT INTO
+    audit_events
+      (
+        type,
+        user_id,
+        actor_id,
+        email,
+        ip,
+        remote_ip,
+        user_agent,
+        method,
+        path,
+        status,
+        detail,
+        created_at
+      )
+  VALUES
+    (
+      ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+      unixepoch()
+    )
+  RETURNING
+    id, created_at;`
+
+	var createdAt int64
+	err := r.Writer.QueryRowContext(ctx, query,
+		string(event.Type), nullableID(event.UserID), nullableID(event.ActorID), event.Email, event.IP, event.RemoteIP,
+		event.UserAgent, event.Method, event.Path, event.Status, event.Detail,
+	).Scan(&event.ID, &createdAt)
+	if err != nil {
+		return NewQueryError(query, err)
+	}
+	event.CreatedAt = time.Unix(createdAt, 0)
+
+	return nil
+}
+
+// List returns events matching the filter, newest first
+func (r *AuditRepository) List(ctx context.Context, filter audit.Filter) ([]*audit.Event, error) {
+	query := `
+  SELECT
+    id, type, user_id, actor_id, email, ip, remote_ip, user_agent, method, path, status, detail, created_at
+  FROM
+    audit_events
+  WHERE
+    (? = '' OR type = ?)
+    AND (? = 0 OR user_id = ?)
+    AND (? = 0 OR actor_id = ?)
+    AND (? IS NULL OR created_at >= ?)
+    AND (? IS NULL OR created_at < ?)
+  ORDER BY
+    created_at DESC, id DESC
+  LIMIT ? OFFSET ?;`
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1 // no limit in sqlite
+	}
+
+	fromArg, toArg := nullableTime(filter.From), nullableTime(filter.To)
+	rows, err := r.DB.QueryContext(ctx, query,
+		string(filter.Type), string(filter.Type), filter.UserID, filter.UserID, filter.ActorID, filter.ActorID,
+		fromArg, fromArg, toArg, toArg, limit, filter.Offset,
+	)
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+
+	// deferred but still checking error
+	defer func() {
+		closeErr := rows.Close()
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close query rows: %w", closeErr)
+		}
+	}()
+
+	events := make([]*audit.Event, 0)
+	for rows.Next() {
+		var event audit.Event
+		var eventType string
+		var userID, actorID sql.NullInt64
+		var createdAt int64
+		err = rows.Scan(&event.ID, &eventType, &userID, &actorID, &event.Email, &event.IP, &event.RemoteIP,
+			&event.UserAgent, &event.Method, &event.Path, &event.Status, &event.Detail, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+		event.Type = audit.Type(eventType)
+		event.UserID = int(userID.Int64)
+		event.ActorID = int(actorID.Int64)
+		event.CreatedAt = time.Unix(createdAt, 0)
+
+		events = append(events, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Purge removes the events recorded before before, implementing retention.Purger
+func (r *AuditRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	query := `
+  DELETE FROM
+    audit_events
+  WHERE
+    created_at < ?;`
+
+	res, err := r.Writer.ExecContext(ctx, query, before.Unix())
+	if err != nil {
+		return 0, NewQueryError(query, err)
+	}
+
+	removed, err := res.RowsAffected()
+	return int(removed), err
+}
@@ -0,0 +1,133 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/audit"
+	"github.com/nicholasss/expense-tracker-api/internal/sqlite"
+)
+
+// setupAuditTestRepo creates the audit_events table
+func setupAuditTestRepo(t *testing.T) *sqlite.AuditRepository {
+	t.Helper()
+
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+
+	t.Cleanup(func() {
+		if err := repo.DB.Close(); err != nil {
+			t.Errorf("unable to close connection to in-memory sqlite database: %v", err)
+		}
+	})
+
+	createQuery := `
+  CREATE TABLE
+    audit_events (
+      id INTEGER PRIMARY KEY,
+      type TEXT NOT NULL,
+      user_id INTEGER,
+      actor_id INTEGER,
+      email TEXT,
+      ip TEXT,
+      remote_ip TEXT NOT NULL DEFAULT '',
+      user_agent TEXT,
+      method TEXT,
+      path TEXT,
+      status INTEGER,
+      detail TEXT,
+      created_at INTEGER NOT NULL
+    );`
+	if _, err := repo.DB.Exec(createQuery); err != nil {
+		t.Fatalf("unable to create tables: %v", err)
+	}
+
+	return sqlite.NewAuditRepository(repo.DB, repo.Writer)
+}
+
+func TestAuditEvents(t *testing.T) {
+	repo := setupAuditTestRepo(t)
+
+	inserts := []audit.Event{
+		{Type: audit.TypeLoginFailed, Email: "ada@example.com", IP: "10.0.0.1", RemoteIP: "192.168.0.2", Status: 401},
+		{Type: audit.TypeLoginSucceeded, UserID: 1, IP: "10.0.0.1", Status: 200},
+		{Type: audit.TypeForbidden, UserID: 2, Method: "GET", Path: "/admin/expenses", Status: 403},
+		{Type: audit.TypeImpersonatedRequest, UserID: 2, ActorID: 1, Method: "GET", Path: "/expenses", Status: 200},
+	}
+	for _, event := range inserts {
+		if err := repo.Insert(t.Context(), &event); err != nil {
+			t.Fatalf("Insert(%q) got error: '%v'", event.Type, err)
+		}
+		if event.ID == 0 || event.CreatedAt.IsZero() {
+			t.Errorf("Insert(%q) did not set id and created time: %+v", event.Type, event)
+		}
+	}
+
+	testTable := []struct {
+		name      string
+		filter    audit.Filter
+		wantTypes []audit.Type
+	}{
+		{
+			name:      "valid-all-newest-first",
+			filter:    audit.Filter{},
+			wantTypes: []audit.Type{audit.TypeImpersonatedRequest, audit.TypeForbidden, audit.TypeLoginSucceeded, audit.TypeLoginFailed},
+		},
+		{
+			name:      "valid-by-type",
+			filter:    audit.Filter{Type: audit.TypeLoginFailed},
+			wantTypes: []audit.Type{audit.TypeLoginFailed},
+		},
+		{
+			name:      "valid-by-user",
+			filter:    audit.Filter{UserID: 2},
+			wantTypes: []audit.Type{audit.TypeImpersonatedRequest, audit.TypeForbidden},
+		},
+		{
+			name:      "valid-by-actor",
+			filter:    audit.Filter{ActorID: 1},
+			wantTypes: []audit.Type{audit.TypeImpersonatedRequest},
+		},
+		{
+			name:      "valid-limit",
+			filter:    audit.Filter{Limit: 1, Offset: 2},
+			wantTypes: []audit.Type{audit.TypeLoginSucceeded},
+		},
+		{
+			name:      "valid-before-range",
+			filter:    audit.Filter{To: time.Now().Add(-time.Hour)},
+			wantTypes: []audit.Type{},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := repo.List(t.Context(), testCase.filter)
+			if err != nil {
+				t.Fatalf("List() got error: '%v'", err)
+			}
+
+			if len(got) != len(testCase.wantTypes) {
+				t.Fatalf("List() got %d events, want %d", len(got), len(testCase.wantTypes))
+			}
+			for i, event := range got {
+				if event.Type != testCase.wantTypes[i] {
+					t.Errorf("event %d got type: %q, want: %q", i, event.Type, testCase.wantTypes[i])
+				}
+			}
+		})
+	}
+
+	// the forwarded and connection addresses are kept apart
+	got, err := repo.List(t.Context(), audit.Filter{Type: audit.TypeLoginFailed})
+	if err != nil {
+		t.Fatalf("List() got error: '%v'", err)
+	}
+	if len(got) != 1 || got[0].IP != "10.0.0.1" || got[0].RemoteIP != "192.168.0.2" {
+		t.Errorf("List() got: %+v, want ip: %q, remote ip: %q", got, "10.0.0.1", "192.168.0.2")
+	}
+}
@@ -0,0 +1,214 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/categories"
+)
+
+// CategoryRepository implements categories.Repository, sharing the expenses database
+type CategoryRepository struct {
+	DB     *sql.DB
+	Writer *sql.DB // takes every write, see NewSqliteRepository
+}
+
+func NewCategoryRepository(db, writer *sql.DB) *CategoryRepository {
+	return &CategoryRepository{DB: db, Writer: writer}
+}
+
+// List returns the book's categories in use, by name
+func (r *CategoryRepository) List(ctx context.Context, book categories.Book) ([]*categories.Category, error) {
+	query := `
+  SELECT
+    id, name, created_at
+  FROM
+    categories
+  WHERE
+    owner_id = ? AND household_id = ? AND archived = 0
+  ORDER BY
+    name COLLATE NOCASE, id;`
+
+	rows, err := r.DB.QueryContext(ctx, query, book.OwnerID, book.HouseholdID)
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+
+	// deferred but still checking error
+	defer func() {
+		closeErr := rows.Close()
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close query rows: %w", closeErr)
+		}
+	}()
+
+	found := make([]*categories.Category, 0)
+	for rows.Next() {
+		var category categories.Category
+		var createdAt int64
+		if err = rows.Scan(&category.ID, &category.Name, &createdAt); err != nil {
+			return nil, err
+		}
+		category.CreatedAt = time.Unix(createdAt, 0)
+
+		found = append(found, &category)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// Seed inserts names in one transaction, and only when the book has never had a category.
+// The writer takes its lock as the transaction begins, so two first requests can't both seed
+func (r *CategoryRepository) Seed(ctx context.Context, book categories.Book, names []string) error {
+	existsQuery := `
+  SELECT
+    EXISTS (SELECT 1 FROM categories WHERE owner_id = ? AND household_id = ?);`
+
+	insertQuery := `
+  INSERT INTO
+    categories
+      (
+        owner_id,
+        household_id,
+        name,
+        created_at
+      )
+  VALUES
+    (
+      ?,
+      ?,
+      ?,
+      unixepoch()
+    );`
+
+	tx, err := r.Writer.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, existsQuery, book.OwnerID, book.HouseholdID).Scan(&exists); err != nil {
+		return NewQueryError(existsQuery, err)
+	}
+	if exists {
+		return nil
+	}
+
+	for _, name := range names {
+		if _, err := tx.ExecContext(ctx, insertQuery, book.OwnerID, book.HouseholdID, name); err != nil {
+			return NewQueryError(insertQuery, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Create inserts a category, mapping the unique name to categories.ErrNameTaken
+func (r *CategoryRepository) Create(ctx context.Context, book categories.Book, name string) (*categories.Category, error) {
+	query := `
+  INSERT INTO
+    categories
+      (
+        owner_id,
+        household_id,
+        name,
+        created_at
+      )
+  VALUES
+    (
+      ?,
+      ?,
+      ?,
+      unixepoch()
+    )
+  RETURNING
+    id, name, created_at;`
+
+	var category categories.Category
+	var createdAt int64
+	err := r.Writer.QueryRowContext(ctx, query, book.OwnerID, book.HouseholdID, name).Scan(&category.ID, &category.Name, &createdAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, categories.ErrNameTaken
+		}
+		return nil, NewQueryError(query, err)
+	}
+	category.CreatedAt = time.Unix(createdAt, 0)
+
+	return &category, nil
+}
+
+// Rename renames a category in use, mapping the unique name to categories.ErrNameTaken
+func (r *CategoryRepository) Rename(ctx context.Context, book categories.Book, id int, name string) (*categories.Category, error) {
+	query := `
+  UPDATE
+    categories
+  SET
+    name = ?
+  WHERE
+    id = ? AND owner_id = ? AND household_id = ? AND archived = 0
+  RETURNING
+    id, name, created_at;`
+
+	var category categories.Category
+	var createdAt int64
+	err := r.Writer.QueryRowContext(ctx, query, name, id, book.OwnerID, book.HouseholdID).Scan(&category.ID, &category.Name, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, categories.ErrNotFound
+	}
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, categories.ErrNameTaken
+		}
+		return nil, NewQueryError(query, err)
+	}
+	category.CreatedAt = time.Unix(createdAt, 0)
+
+	return &category, nil
+}
+
+// Archive takes a category out of use, it is kept for the expenses already in it
+func (r *CategoryRepository) Archive(ctx context.Context, book categories.Book, id int) error {
+	query := `
+  UPDATE
+    categories
+  SET
+    archived = 1
+  WHERE
+    id = ? AND owner_id = ? AND household_id = ? AND archived = 0;`
+
+	res, err := r.Writer.ExecContext(ctx, query, id, book.OwnerID, book.HouseholdID)
+	if err != nil {
+		return NewQueryError(query, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return categories.ErrNotFound
+	}
+	return nil
+}
+
+// Exists reports whether the book has a category in use with id
+func (r *CategoryRepository) Exists(ctx context.Context, book categories.Book, id int) (bool, error) {
+	query := `
+  SELECT
+    EXISTS (SELECT 1 FROM categories WHERE id = ? AND owner_id = ? AND household_id = ? AND archived = 0);`
+
+	var exists bool
+	if err := r.DB.QueryRowContext(ctx, query, id, book.OwnerID, book.HouseholdID).Scan(&exists); err != nil {
+		return false, NewQueryError(query, err)
+	}
+	return exists, nil
+}
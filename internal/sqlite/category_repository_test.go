@@ -0,0 +1,149 @@
+package sqlite_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nicholasss/expense-tracker-api/internal/categories"
+	"github.com/nicholasss/expense-tracker-api/internal/sqlite"
+)
+
+// setupCategoryTestRepo migrates a database rather than building the tables, since the index
+// keeping names unique is part of what is tested
+func setupCategoryTestRepo(t *testing.T) *sqlite.CategoryRepository {
+	t.Helper()
+
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+	t.Cleanup(func() { repo.Close() })
+
+	if _, err := sqlite.Migrate(t.Context(), repo.DB); err != nil {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+
+	return sqlite.NewCategoryRepository(repo.DB, repo.Writer)
+}
+
+func TestCategorySeed(t *testing.T) {
+	repo := setupCategoryTestRepo(t)
+	book := categories.Book{OwnerID: 1}
+
+	if err := repo.Seed(t.Context(), book, []string{"Food", "Rent"}); err != nil {
+		t.Fatalf("Seed() got error: '%v'", err)
+	}
+	// only a book without any categories is seeded
+	if err := repo.Seed(t.Context(), book, []string{"Pets"}); err != nil {
+		t.Fatalf("Seed() again got error: '%v'", err)
+	}
+
+	got, err := repo.List(t.Context(), book)
+	if err != nil {
+		t.Fatalf("List() got error: '%v'", err)
+	}
+	if len(got) != 2 || got[0].Name != "Food" || got[1].Name != "Rent" {
+		t.Errorf("List() got: %v, want Food and Rent", got)
+	}
+
+	// books don't see each other's
+	other, err := repo.List(t.Context(), categories.Book{HouseholdID: 1})
+	if err != nil || len(other) != 0 {
+		t.Errorf("List() of another book got: %v, error: '%v', want none", other, err)
+	}
+
+	// archiving every category doesn't bring the seeds back
+	for _, category := range got {
+		if err := repo.Archive(t.Context(), book, category.ID); err != nil {
+			t.Fatalf("Archive() got error: '%v'", err)
+		}
+	}
+	if err := repo.Seed(t.Context(), book, []string{"Food"}); err != nil {
+		t.Fatalf("Seed() after archiving got error: '%v'", err)
+	}
+	if got, err := repo.List(t.Context(), book); err != nil || len(got) != 0 {
+		t.Errorf("List() after archiving got: %v, error: '%v', want none", got, err)
+	}
+}
+
+func TestCategoryNames(t *testing.T) {
+	repo := setupCategoryTestRepo(t)
+	book := categories.Book{HouseholdID: 4}
+
+	food, err := repo.Create(t.Context(), book, "Food")
+	if err != nil {
+		t.Fatalf("Create() got error: '%v'", err)
+	}
+	pets, err := repo.Create(t.Context(), book, "Pets")
+	if err != nil {
+		t.Fatalf("Create() got error: '%v'", err)
+	}
+
+	// names are unique within a book regardless of case, but not across books
+	if _, err := repo.Create(t.Context(), book, "FOOD"); !errors.Is(err, categories.ErrNameTaken) {
+		t.Errorf("Create() of a taken name got error: '%v', want error: '%v'", err, categories.ErrNameTaken)
+	}
+	if _, err := repo.Rename(t.Context(), book, pets.ID, "food"); !errors.Is(err, categories.ErrNameTaken) {
+		t.Errorf("Rename() to a taken name got error: '%v', want error: '%v'", err, categories.ErrNameTaken)
+	}
+	if _, err := repo.Create(t.Context(), categories.Book{OwnerID: 4}, "Food"); err != nil {
+		t.Errorf("Create() in another book got error: '%v'", err)
+	}
+
+	renamed, err := repo.Rename(t.Context(), book, food.ID, "Eating out")
+	if err != nil || renamed.Name != "Eating out" {
+		t.Errorf("Rename() got: %v, error: '%v'", renamed, err)
+	}
+
+	// an archived category frees its name, and can't be found any more
+	if err := repo.Archive(t.Context(), book, pets.ID); err != nil {
+		t.Fatalf("Archive() got error: '%v'", err)
+	}
+	if _, err := repo.Create(t.Context(), book, "Pets"); err != nil {
+		t.Errorf("Create() of an archived name got error: '%v'", err)
+	}
+	if err := repo.Archive(t.Context(), book, pets.ID); !errors.Is(err, categories.ErrNotFound) {
+		t.Errorf("Archive() again got error: '%v', want error: '%v'", err, categories.ErrNotFound)
+	}
+	if _, err := repo.Rename(t.Context(), book, pets.ID, "Dogs"); !errors.Is(err, categories.ErrNotFound) {
+		t.Errorf("Rename() of an archived category got error: '%v', want error: '%v'", err, categories.ErrNotFound)
+	}
+
+	testTable := []struct {
+		name      string
+		inputBook categories.Book
+		inputID   int
+		want      bool
+	}{
+		{
+			name:      "in-use",
+			inputBook: book,
+			inputID:   food.ID,
+			want:      true,
+		},
+		{
+			name:      "archived",
+			inputBook: book,
+			inputID:   pets.ID,
+		},
+		{
+			name:      "other-book",
+			inputBook: categories.Book{OwnerID: 4},
+			inputID:   food.ID,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := repo.Exists(t.Context(), testCase.inputBook, testCase.inputID)
+			if err != nil {
+				t.Fatalf("Exists() got error: '%v'", err)
+			}
+			if got != testCase.want {
+				t.Errorf("Exists() got: %v, want: %v", got, testCase.want)
+			}
+		})
+	}
+}
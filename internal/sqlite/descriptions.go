@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+)
+
+// FoldDescriptions fills in the search key of expenses stored before descriptions were folded,
+// see expenses.FoldDescription, and returns how many it folded. Expenses stored since have
+// their key written with them, so once every row is folded this finds nothing to do
+func (r *SqliteRepository) FoldDescriptions(ctx context.Context) (int, error) {
+	selectQuery := `
+  SELECT
+    id, description
+  FROM
+    expenses
+  WHERE
+    description_key = '' AND description != '';`
+
+	rows, err := r.DB.QueryContext(ctx, selectQuery)
+	if err != nil {
+		return 0, NewQueryError(selectQuery, err)
+	}
+
+	// read them all before writing, an in-memory database reads and writes over a single connection
+	type unfolded struct {
+		id          int
+		description string
+	}
+	pending := make([]unfolded, 0)
+	for rows.Next() {
+		var row unfolded
+		if err := rows.Scan(&row.id, &row.description); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		pending = append(pending, row)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, err
+	}
+	if err := rows.Close(); err != nil {
+		return 0, fmt.Errorf("failed to close query rows: %w", err)
+	}
+
+	updateQuery := `
+  UPDATE
+    expenses
+  SET
+    description_key = ?
+  WHERE
+    id = ?;`
+
+	folded := 0
+	for _, row := range pending {
+		if _, err := r.Writer.ExecContext(ctx, updateQuery, expenses.FoldDescription(row.description), row.id); err != nil {
+			return folded, NewQueryError(updateQuery, err)
+		}
+		folded++
+	}
+
+	return folded, nil
+}
@@ -0,0 +1,236 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/banksync"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+)
+
+// sqliteDraft has time stored as unix seconds, and a nullable expense id
+type sqliteDraft struct {
+	ID          int
+	Provider    string
+	ExternalID  string
+	CreatedAt   int64
+	OccuredAt   int64
+	Description string
+	Amount      int64
+	Currency    string
+	Status      string
+	ExpenseID   sql.NullInt64
+}
+
+// toServiceDraft reads drafts without a currency, pulled before it was kept, as in currency
+func toServiceDraft(db sqliteDraft, currency string) *banksync.Draft {
+	if db.Currency != "" {
+		currency = db.Currency
+	}
+	return &banksync.Draft{
+		ID:          db.ID,
+		Provider:    db.Provider,
+		ExternalID:  db.ExternalID,
+		CreatedAt:   time.Unix(db.CreatedAt, 0),
+		OccuredAt:   time.Unix(db.OccuredAt, 0),
+		Description: db.Description,
+		Amount:      money.New(db.Amount, currency),
+		Status:      banksync.DraftStatus(db.Status),
+		ExpenseID:   int(db.ExpenseID.Int64),
+	}
+}
+
+// DraftRepository implements banksync.Repository, sharing the expenses database
+type DraftRepository struct {
+	DB       *sql.DB
+	Writer   *sql.DB // takes every write, see NewSqliteRepository
+	Currency string  // of drafts pulled before their currency was kept, money.DefaultCurrency by default
+}
+
+func NewDraftRepository(db, writer *sql.DB) *DraftRepository {
+	return &DraftRepository{DB: db, Writer: writer, Currency: money.DefaultCurrency}
+}
+
+// SaveDrafts inserts transactions as pending drafts, ignoring ones that were already pulled
+func (r *DraftRepository) SaveDrafts(ctx context.Context, provider string, txns []banksync.Transaction) (int, error) {
+	query := `
+  INSERT OR IGNORE INTO
+    bank_drafts
+      (
+        provider,
+        external_id,
+        created_at,
+        occured_at,
+        description,
+        amount,
+        currency,
+        status
+      )
+  VALUES
+    (
+      ?,
+      ?,
+      unixepoch(),
+      ?,
+      ?,
+      ?,
+      ?,
+      'pending'
+    );`
+
+	tx, err := r.Writer.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	created := 0
+	for _, txn := range txns {
+		res, err := tx.ExecContext(ctx, query,
+			provider, txn.ExternalID, txn.BookedAt.Unix(), txn.Description, txn.Amount.Minor, txn.Amount.Currency,
+		)
+		if err != nil {
+			return 0, NewQueryError(query, err)
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		created += int(rowsAffected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return created, nil
+}
+
+// LatestOccuredAt returns the newest draft time for the provider, or the zero time if there are none
+func (r *DraftRepository) LatestOccuredAt(ctx context.Context, provider string) (time.Time, error) {
+	query := `
+  SELECT
+    max(occured_at)
+  FROM
+    bank_drafts
+  WHERE
+    provider = ?;`
+
+	var latest sql.NullInt64
+	err := r.DB.QueryRowContext(ctx, query, provider).Scan(&latest)
+	if err != nil {
+		return time.Time{}, NewQueryError(query, err)
+	}
+
+	if !latest.Valid {
+		return time.Time{}, nil
+	}
+	return time.Unix(latest.Int64, 0), nil
+}
+
+// GetDraftByID finds a particular draft with an id
+func (r *DraftRepository) GetDraftByID(ctx context.Context, id int) (*banksync.Draft, error) {
+	var dbD sqliteDraft
+
+	query := `
+  SELECT
+    id, provider, external_id, created_at, occured_at, description, amount, currency, status, expense_id
+  FROM
+    bank_drafts
+  WHERE
+    id = ?;`
+
+	row := r.DB.QueryRowContext(ctx, query, id)
+	err := row.Scan(&dbD.ID, &dbD.Provider, &dbD.ExternalID, &dbD.CreatedAt, &dbD.OccuredAt,
+		&dbD.Description, &dbD.Amount, &dbD.Currency, &dbD.Status, &dbD.ExpenseID,
+	)
+	if err == sql.ErrNoRows {
+		return nil, banksync.ErrDraftNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toServiceDraft(dbD, r.Currency), nil
+}
+
+// GetDraftsByStatus returns every draft with status, oldest first
+func (r *DraftRepository) GetDraftsByStatus(ctx context.Context, status banksync.DraftStatus) ([]*banksync.Draft, error) {
+	query := `
+  SELECT
+    id, provider, external_id, created_at, occured_at, description, amount, currency, status, expense_id
+  FROM
+    bank_drafts
+  WHERE
+    status = ?
+  ORDER BY
+    occured_at;`
+
+	rows, err := r.DB.QueryContext(ctx, query, string(status))
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+
+	// deferred but still checking error
+	defer func() {
+		closeErr := rows.Close()
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close query rows: %w", closeErr)
+		}
+	}()
+
+	drafts := make([]*banksync.Draft, 0)
+	for rows.Next() {
+		var dbD sqliteDraft
+		err = rows.Scan(&dbD.ID, &dbD.Provider, &dbD.ExternalID, &dbD.CreatedAt, &dbD.OccuredAt,
+			&dbD.Description, &dbD.Amount, &dbD.Currency, &dbD.Status, &dbD.ExpenseID,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		drafts = append(drafts, toServiceDraft(dbD, r.Currency))
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return drafts, nil
+}
+
+// ResolveDraft moves a pending draft to status, only pending drafts are changed
+func (r *DraftRepository) ResolveDraft(ctx context.Context, id int, status banksync.DraftStatus, expenseID int) error {
+	query := `
+  UPDATE
+    bank_drafts
+  SET
+    status = ?,
+    expense_id = ?
+  WHERE
+    id = ? AND status = 'pending';`
+
+	// keep expense_id null for dismissed drafts
+	var dbExpenseID sql.NullInt64
+	if expenseID != 0 {
+		dbExpenseID = sql.NullInt64{Int64: int64(expenseID), Valid: true}
+	}
+
+	res, err := r.Writer.ExecContext(ctx, query, string(status), dbExpenseID, id)
+	if err != nil {
+		return NewQueryError(query, err)
+	}
+
+	rowsUpdated, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsUpdated == 0 {
+		return banksync.ErrDraftNotPending
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/fxrates"
+)
+
+// FXRateRepository implements fxrates.Repository, sharing the expenses database
+type FXRateRepository struct {
+	DB     *sql.DB
+	Writer *sql.DB // takes every write, see NewSqliteRepository
+}
+
+func NewFXRateRepository(db, writer *sql.DB) *FXRateRepository {
+	return &FXRateRepository{DB: db, Writer: writer}
+}
+
+// LoadRates reads the saved rates back, nil when none have been saved
+func (r *FXRateRepository) LoadRates(ctx context.Context) (rates *fxrates.Rates, err error) {
+	query := `
+  SELECT
+    base, currency, rate, as_of, fetched_at
+  FROM
+    fx_rates;`
+
+	rows, err := r.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+
+	// deferred but still checking error
+	defer func() {
+		closeErr := rows.Close()
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close query rows: %w", closeErr)
+		}
+	}()
+
+	for rows.Next() {
+		var base, currency string
+		var rate float64
+		var asOf, fetchedAt int64
+		if err = rows.Scan(&base, &currency, &rate, &asOf, &fetchedAt); err != nil {
+			return nil, err
+		}
+
+		// every row of a save shares the base and times
+		if rates == nil {
+			rates = &fxrates.Rates{
+				Base:      base,
+				Rates:     make(map[string]float64),
+				AsOf:      time.Unix(asOf, 0).UTC(),
+				FetchedAt: time.Unix(fetchedAt, 0),
+			}
+		}
+		rates.Rates[currency] = rate
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// SaveRates replaces the saved rates in one transaction, so a load never sees half of a save
+func (r *FXRateRepository) SaveRates(ctx context.Context, rates *fxrates.Rates) error {
+	deleteQuery := `
+  DELETE FROM
+    fx_rates;`
+
+	insertQuery := `
+  INSERT INTO
+    fx_rates
+      (
+        base,
+        currency,
+        rate,
+        as_of,
+        fetched_at
+      )
+  VALUES
+    (
+      ?,
+      ?,
+      ?,
+      ?,
+      ?
+    );`
+
+	tx, err := r.Writer.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, deleteQuery); err != nil {
+		return NewQueryError(deleteQuery, err)
+	}
+
+	for currency, rate := range rates.Rates {
+		_, err := tx.ExecContext(ctx, insertQuery,
+			rates.Base, currency, rate, rates.AsOf.Unix(), rates.FetchedAt.Unix(),
+		)
+		if err != nil {
+			return NewQueryError(insertQuery, err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,343 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/households"
+)
+
+// nullableTime stores the zero time as null
+func nullableTime(t time.Time) sql.NullInt64 {
+	return sql.NullInt64{Int64: t.Unix(), Valid: !t.IsZero()}
+}
+
+// HouseholdRepository implements households.Repository, sharing the expenses database
+type HouseholdRepository struct {
+	DB     *sql.DB
+	Writer *sql.DB // takes every write, see NewSqliteRepository
+}
+
+func NewHouseholdRepository(db, writer *sql.DB) *HouseholdRepository {
+	return &HouseholdRepository{DB: db, Writer: writer}
+}
+
+// Create inserts the household and its owner together
+func (r *HouseholdRepository) Create(ctx context.Context, name string, ownerID int) (*households.Household, error) {
+	householdQuery := `
+  INSERT INTO
+    households
+      (
+        name,
+        created_at
+      )
+  VALUES
+    (
+      ?,
+      unixepoch()
+    )
+  RETURNING
+    id, name, created_at;`
+
+	tx, err := r.Writer.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var household households.Household
+	var createdAt int64
+	err = tx.QueryRowContext(ctx, householdQuery, name).Scan(&household.ID, &household.Name, &createdAt)
+	if err != nil {
+		return nil, NewQueryError(householdQuery, err)
+	}
+	household.CreatedAt = time.Unix(createdAt, 0)
+
+	if err := addMember(ctx, tx, household.ID, ownerID, households.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &household, nil
+}
+
+// GetForUser finds the household a user is in, along with their role
+func (r *HouseholdRepository) GetForUser(ctx context.Context, userID int) (*households.Household, households.Role, error) {
+	query := `
+  SELECT
+    h.id, h.name, h.created_at, m.role
+  FROM
+    households h
+  JOIN
+    household_members m ON m.household_id = h.id
+  WHERE
+    m.user_id = ?;`
+
+	var household households.Household
+	var createdAt int64
+	var role string
+
+	err := r.DB.QueryRowContext(ctx, query, userID).Scan(&household.ID, &household.Name, &createdAt, &role)
+	if err == sql.ErrNoRows {
+		return nil, "", households.ErrNotMember
+	}
+	if err != nil {
+		return nil, "", NewQueryError(query, err)
+	}
+	household.CreatedAt = time.Unix(createdAt, 0)
+
+	return &household, households.Role(role), nil
+}
+
+// Members lists the household's members, oldest first
+func (r *HouseholdRepository) Members(ctx context.Context, householdID int) ([]*households.Member, error) {
+	query := `
+  SELECT
+    u.id, u.email, u.name, m.role, m.joined_at
+  FROM
+    household_members m
+  JOIN
+    users u ON u.id = m.user_id
+  WHERE
+    m.household_id = ?
+  ORDER BY
+    m.joined_at, u.id;`
+
+	rows, err := r.DB.QueryContext(ctx, query, householdID)
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+
+	// deferred but still checking error
+	defer func() {
+		closeErr := rows.Close()
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close query rows: %w", closeErr)
+		}
+	}()
+
+	members := make([]*households.Member, 0)
+	for rows.Next() {
+		var member households.Member
+		var role string
+		var joinedAt int64
+		err = rows.Scan(&member.UserID, &member.Email, &member.Name, &role, &joinedAt)
+		if err != nil {
+			return nil, err
+		}
+		member.Role = households.Role(role)
+		member.JoinedAt = time.Unix(joinedAt, 0)
+
+		members = append(members, &member)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// addMember inserts a membership, mapping the unique user_id to households.ErrAlreadyMember
+func addMember(ctx context.Context, db execer, householdID, userID int, role households.Role) error {
+	query := `
+  INSERT INTO
+    household_members
+      (
+        household_id,
+        user_id,
+        role,
+        joined_at
+      )
+  VALUES
+    (
+      ?,
+      ?,
+      ?,
+      unixepoch()
+    );`
+
+	_, err := db.ExecContext(ctx, query, householdID, userID, string(role))
+	if err != nil {
+		if isUniqueViolation(err) || isPrimaryKeyViolation(err) {
+			return households.ErrAlreadyMember
+		}
+		return NewQueryError(query, err)
+	}
+	return nil
+}
+
+// AddMember adds a user to the household
+func (r *HouseholdRepository) AddMember(ctx context.Context, householdID, userID int, role households.Role) error {
+	return addMember(ctx, r.Writer, householdID, userID, role)
+}
+
+// RemoveMember removes a user from the household, their shared expenses stay in the book
+func (r *HouseholdRepository) RemoveMember(ctx context.Context, householdID, userID int) error {
+	query := `
+  DELETE FROM
+    household_members
+  WHERE
+    household_id = ? AND user_id = ?;`
+
+	res, err := r.Writer.ExecContext(ctx, query, householdID, userID)
+	if err != nil {
+		return NewQueryError(query, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return households.ErrMemberNotFound
+	}
+	return nil
+}
+
+// Contributions totals the shared book per user, current members are included even without expenses.
+// Former members are kept while they still have expenses in the book.
+func (r *HouseholdRepository) Contributions(ctx context.Context, householdID int, from, to time.Time) ([]*households.Contribution, error) {
+	query := `
+  SELECT
+    u.id, u.name, coalesce(sum(e.amount), 0) AS total, count(e.id)
+  FROM
+    users u
+  LEFT JOIN
+    expenses e ON e.owner_id = u.id
+      AND e.household_id = ?
+      AND (? IS NULL OR e.occured_at >= ?)
+      AND (? IS NULL OR e.occured_at < ?)
+  WHERE
+    u.id IN (SELECT user_id FROM household_members WHERE household_id = ?)
+    OR u.id IN (SELECT owner_id FROM expenses WHERE household_id = ?)
+  GROUP BY
+    u.id
+  ORDER BY
+    total DESC, u.id;`
+
+	fromArg, toArg := nullableTime(from), nullableTime(to)
+	rows, err := r.DB.QueryContext(ctx, query,
+		householdID, fromArg, fromArg, toArg, toArg, householdID, householdID,
+	)
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+
+	// deferred but still checking error
+	defer func() {
+		closeErr := rows.Close()
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close query rows: %w", closeErr)
+		}
+	}()
+
+	contributions := make([]*households.Contribution, 0)
+	for rows.Next() {
+		var contribution households.Contribution
+		err = rows.Scan(&contribution.UserID, &contribution.Name, &contribution.Total, &contribution.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		contributions = append(contributions, &contribution)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return contributions, nil
+}
+
+// AddSettlement records a payment between two users of the household
+func (r *HouseholdRepository) AddSettlement(ctx context.Context, householdID int, settlement *households.Settlement) (*households.Settlement, error) {
+	query := `
+  INSERT INTO
+    settlements
+      (
+        household_id,
+        from_user_id,
+        to_user_id,
+        amount,
+        created_at
+      )
+  VALUES
+    (
+      ?,
+      ?,
+      ?,
+      ?,
+      unixepoch()
+    )
+  RETURNING
+    id, from_user_id, to_user_id, amount, created_at;`
+
+	var added households.Settlement
+	var createdAt int64
+	err := r.Writer.QueryRowContext(ctx, query,
+		householdID, settlement.FromUserID, settlement.ToUserID, settlement.Amount,
+	).Scan(&added.ID, &added.FromUserID, &added.ToUserID, &added.Amount, &createdAt)
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+	added.CreatedAt = time.Unix(createdAt, 0)
+
+	return &added, nil
+}
+
+// Settlements lists every payment recorded in the household, newest first
+func (r *HouseholdRepository) Settlements(ctx context.Context, householdID int) (found []*households.Settlement, err error) {
+	query := `
+  SELECT
+    id, from_user_id, to_user_id, amount, created_at
+  FROM
+    settlements
+  WHERE
+    household_id = ?
+  ORDER BY
+    created_at DESC, id DESC;`
+
+	rows, err := r.DB.QueryContext(ctx, query, householdID)
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+
+	// deferred but still checking error
+	defer func() {
+		closeErr := rows.Close()
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close query rows: %w", closeErr)
+		}
+	}()
+
+	found = make([]*households.Settlement, 0)
+	for rows.Next() {
+		var settlement households.Settlement
+		var createdAt int64
+		err = rows.Scan(&settlement.ID, &settlement.FromUserID, &settlement.ToUserID, &settlement.Amount, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+		settlement.CreatedAt = time.Unix(createdAt, 0)
+
+		found = append(found, &settlement)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
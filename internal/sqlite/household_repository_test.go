@@ -0,0 +1,207 @@
+package sqlite_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/households"
+	"github.com/nicholasss/expense-tracker-api/internal/sqlite"
+)
+
+// setupHouseholdTestRepo creates the users, households, and expenses tables with three users loaded
+func setupHouseholdTestRepo(t *testing.T) *sqlite.HouseholdRepository {
+	t.Helper()
+
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+
+	t.Cleanup(func() {
+		if err := repo.DB.Close(); err != nil {
+			t.Errorf("unable to close connection to in-memory sqlite database: %v", err)
+		}
+	})
+
+	setupUserTestDB(t, repo.DB)
+	setupTestDB(t, repo.DB)
+
+	createQuery := `
+  CREATE TABLE
+    households (
+      id INTEGER PRIMARY KEY,
+      name TEXT NOT NULL,
+      created_at INTEGER
+    );
+  CREATE TABLE
+    household_members (
+      household_id INTEGER NOT NULL,
+      user_id INTEGER NOT NULL UNIQUE,
+      role TEXT NOT NULL DEFAULT 'member',
+      joined_at INTEGER,
+      PRIMARY KEY (household_id, user_id)
+    );
+  CREATE TABLE
+    settlements (
+      id INTEGER PRIMARY KEY,
+      household_id INTEGER NOT NULL,
+      from_user_id INTEGER NOT NULL,
+      to_user_id INTEGER NOT NULL,
+      amount INTEGER NOT NULL,
+      created_at INTEGER NOT NULL
+    );
+  INSERT INTO
+    users (email, name, password_hash, created_at)
+  VALUES
+    ("grace@example.com", "Grace", "not-a-real-hash", unixepoch()),
+    ("linus@example.com", "Linus", "not-a-real-hash", unixepoch());`
+	if _, err := repo.DB.Exec(createQuery); err != nil {
+		t.Fatalf("unable to create tables: %v", err)
+	}
+
+	return sqlite.NewHouseholdRepository(repo.DB, repo.Writer)
+}
+
+func TestHouseholdMembership(t *testing.T) {
+	repo := setupHouseholdTestRepo(t)
+
+	household, err := repo.Create(t.Context(), "flat 4b", 1)
+	if err != nil {
+		t.Fatalf("Create() got error: '%v'", err)
+	}
+
+	if err := repo.AddMember(t.Context(), household.ID, 2, households.RoleMember); err != nil {
+		t.Fatalf("AddMember(2) got error: '%v'", err)
+	}
+
+	// a user can only be in one household
+	if err := repo.AddMember(t.Context(), household.ID, 2, households.RoleMember); !errors.Is(err, households.ErrAlreadyMember) {
+		t.Errorf("AddMember(2) again got error: '%v', want error: '%v'", err, households.ErrAlreadyMember)
+	}
+	if _, err := repo.Create(t.Context(), "second home", 1); !errors.Is(err, households.ErrAlreadyMember) {
+		t.Errorf("Create() by a member got error: '%v', want error: '%v'", err, households.ErrAlreadyMember)
+	}
+
+	got, role, err := repo.GetForUser(t.Context(), 2)
+	if err != nil || got.ID != household.ID || role != households.RoleMember {
+		t.Errorf("GetForUser(2) got: %+v, role: %q, error: '%v'", got, role, err)
+	}
+	if _, _, err := repo.GetForUser(t.Context(), 3); !errors.Is(err, households.ErrNotMember) {
+		t.Errorf("GetForUser(3) got error: '%v', want error: '%v'", err, households.ErrNotMember)
+	}
+
+	members, err := repo.Members(t.Context(), household.ID)
+	if err != nil || len(members) != 2 || members[0].Role != households.RoleOwner {
+		t.Errorf("Members() got: %v, error: '%v'", members, err)
+	}
+
+	if err := repo.RemoveMember(t.Context(), household.ID, 2); err != nil {
+		t.Errorf("RemoveMember(2) got error: '%v'", err)
+	}
+	if err := repo.RemoveMember(t.Context(), household.ID, 2); !errors.Is(err, households.ErrMemberNotFound) {
+		t.Errorf("RemoveMember(2) again got error: '%v', want error: '%v'", err, households.ErrMemberNotFound)
+	}
+}
+
+func TestHouseholdContributions(t *testing.T) {
+	repo := setupHouseholdTestRepo(t)
+
+	household, err := repo.Create(t.Context(), "flat 4b", 1)
+	if err != nil {
+		t.Fatalf("Create() got error: '%v'", err)
+	}
+	if err := repo.AddMember(t.Context(), household.ID, 2, households.RoleMember); err != nil {
+		t.Fatalf("AddMember(2) got error: '%v'", err)
+	}
+
+	// user 1 shared the first two expenses, user 2 the third, the rest are not shared
+	_, err = repo.DB.Exec(`
+  UPDATE expenses SET owner_id = 1, household_id = ? WHERE id IN (1, 2);
+  UPDATE expenses SET owner_id = 2, household_id = ? WHERE id = 3;
+  UPDATE expenses SET owner_id = 2 WHERE id > 3;`, household.ID, household.ID)
+	if err != nil {
+		t.Fatalf("unable to share expenses: %v", err)
+	}
+
+	testTable := []struct {
+		name      string
+		inputFrom time.Time
+		inputTo   time.Time
+		want      []households.Contribution
+	}{
+		{
+			name: "valid-all-time",
+			want: []households.Contribution{
+				{UserID: 1, Name: "Ada", Total: 11999 + 1399, Count: 2},
+				{UserID: 2, Name: "Grace", Total: 2700, Count: 1},
+			},
+		},
+		{
+			name:      "valid-from-bound",
+			inputFrom: time.Unix(1761148800, 0),
+			want: []households.Contribution{
+				{UserID: 1, Name: "Ada", Total: 11999 + 1399, Count: 2},
+				{UserID: 2, Name: "Grace", Total: 0, Count: 0},
+			},
+		},
+		{
+			name:    "valid-to-bound",
+			inputTo: time.Unix(1761148800, 0),
+			want: []households.Contribution{
+				{UserID: 2, Name: "Grace", Total: 2700, Count: 1},
+				{UserID: 1, Name: "Ada", Total: 0, Count: 0},
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := repo.Contributions(t.Context(), household.ID, testCase.inputFrom, testCase.inputTo)
+			if err != nil {
+				t.Fatalf("Contributions() got error: '%v'", err)
+			}
+
+			if len(got) != len(testCase.want) {
+				t.Fatalf("got %d contributions, want %d", len(got), len(testCase.want))
+			}
+			for i := range testCase.want {
+				if *got[i] != testCase.want[i] {
+					t.Errorf("contribution %d does not match. got: %+v, want: %+v", i, *got[i], testCase.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHouseholdSettlements(t *testing.T) {
+	repo := setupHouseholdTestRepo(t)
+
+	household, err := repo.Create(t.Context(), "flat 4b", 1)
+	if err != nil {
+		t.Fatalf("Create() got error: '%v'", err)
+	}
+
+	first, err := repo.AddSettlement(t.Context(), household.ID, &households.Settlement{FromUserID: 2, ToUserID: 1, Amount: 1000})
+	if err != nil {
+		t.Fatalf("AddSettlement() got error: '%v'", err)
+	}
+	if first.ID == 0 || first.FromUserID != 2 || first.ToUserID != 1 || first.Amount != 1000 || first.CreatedAt.IsZero() {
+		t.Errorf("AddSettlement() got: %+v", first)
+	}
+	second, err := repo.AddSettlement(t.Context(), household.ID, &households.Settlement{FromUserID: 1, ToUserID: 2, Amount: 250})
+	if err != nil {
+		t.Fatalf("AddSettlement() got error: '%v'", err)
+	}
+
+	// newest first, and only the household's own
+	got, err := repo.Settlements(t.Context(), household.ID)
+	if err != nil || len(got) != 2 || got[0].ID != second.ID || got[1].ID != first.ID {
+		t.Errorf("Settlements() got: %v, error: '%v'", got, err)
+	}
+	if got, err := repo.Settlements(t.Context(), household.ID+1); err != nil || len(got) != 0 {
+		t.Errorf("Settlements() of another household got: %v, error: '%v'", got, err)
+	}
+}
@@ -0,0 +1,57 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+)
+
+// index is one the expense queries rely on, created by the migrations
+type index struct {
+	name    string
+	table   string
+	columns string
+}
+
+// expenseIndexes keep range, owner and sync queries off full table scans,
+// they have to match the migrations in sql/schema
+var expenseIndexes = []index{
+	{name: "expenses_owner_id", table: "expenses", columns: "owner_id"},
+	{name: "expenses_household_id", table: "expenses", columns: "household_id"},
+	{name: "expenses_occured_at", table: "expenses", columns: "occured_at, id"},
+	{name: "expenses_updated_at", table: "expenses", columns: "updated_at"},
+}
+
+// EnsureIndexes creates any index the expense queries rely on that is missing from the database,
+// i.e. one dropped by hand, and returns the names of the ones it had to create
+func (r *SqliteRepository) EnsureIndexes(ctx context.Context) ([]string, error) {
+	existsQuery := `
+  SELECT
+    1
+  FROM
+    sqlite_master
+  WHERE
+    type = 'index' AND name = ?;`
+
+	created := make([]string, 0)
+	for _, idx := range expenseIndexes {
+		var exists int
+		err := r.DB.QueryRowContext(ctx, existsQuery, idx.name).Scan(&exists)
+		if err == nil {
+			continue
+		}
+		if err != sql.ErrNoRows {
+			return created, NewQueryError(existsQuery, err)
+		}
+
+		createQuery := `
+  CREATE INDEX IF NOT EXISTS
+    ` + idx.name + ` ON ` + idx.table + ` (` + idx.columns + `);`
+
+		if _, err := r.Writer.ExecContext(ctx, createQuery); err != nil {
+			return created, NewQueryError(createQuery, err)
+		}
+		created = append(created, idx.name)
+	}
+
+	return created, nil
+}
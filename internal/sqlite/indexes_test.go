@@ -0,0 +1,56 @@
+package sqlite_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nicholasss/expense-tracker-api/internal/sqlite"
+)
+
+func TestEnsureIndexes(t *testing.T) {
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+	defer repo.DB.Close()
+
+	setupTestDB(t, repo.DB)
+
+	// one index is already there, as if the migrations only partly ran
+	_, err = repo.DB.Exec(`CREATE INDEX expenses_owner_id ON expenses (owner_id);`)
+	if err != nil {
+		t.Fatalf("unable to create index: %v", err)
+	}
+
+	got, err := repo.EnsureIndexes(t.Context())
+	if err != nil {
+		t.Fatalf("EnsureIndexes() got error: '%v'", err)
+	}
+	want := []string{"expenses_household_id", "expenses_occured_at", "expenses_updated_at"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got created: %v, want created: %v", got, want)
+	}
+
+	// a range query uses the new index instead of scanning the table
+	var id, parent, notUsed int
+	var detail string
+	err = repo.DB.QueryRow(`EXPLAIN QUERY PLAN SELECT id FROM expenses WHERE occured_at >= 1761001200;`).Scan(&id, &parent, &notUsed, &detail)
+	if err != nil {
+		t.Fatalf("unable to explain query: %v", err)
+	}
+	if !strings.Contains(detail, "expenses_occured_at") {
+		t.Errorf("got query plan: %q, want it to use expenses_occured_at", detail)
+	}
+
+	// nothing is left to create the second time
+	got, err = repo.EnsureIndexes(t.Context())
+	if err != nil {
+		t.Fatalf("EnsureIndexes() got error: '%v'", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got created: %v, want none", got)
+	}
+}
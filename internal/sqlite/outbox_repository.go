@@ -0,0 +1,229 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/outbox"
+)
+
+// OutboxRepository implements outbox.Repository, sharing the expenses database
+type OutboxRepository struct {
+	DB     *sql.DB
+	Writer *sql.DB // takes every write, see NewSqliteRepository
+}
+
+func NewOutboxRepository(db, writer *sql.DB) *OutboxRepository {
+	return &OutboxRepository{DB: db, Writer: writer}
+}
+
+// enqueue adds the event for exp to the outbox within tx, so it is committed along with the change
+func enqueue(ctx context.Context, tx *sql.Tx, eventType expenses.EventType, exp *expenses.Expense) error {
+	query := `
+  INSERT INTO
+    outbox
+      (
+        event_type,
+        payload,
+        created_at,
+        next_attempt_at
+      )
+  VALUES
+    (
+      ?,
+      ?,
+      unixepoch(),
+      unixepoch()
+    );`
+
+	payload, err := json.Marshal(outbox.NewExpenseEvent(eventType, exp, time.Now()))
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, query, string(eventType), string(payload)); err != nil {
+		return NewQueryError(query, err)
+	}
+	return nil
+}
+
+// scanMessages reads every row of an outbox query
+func scanMessages(rows *sql.Rows) (found []*outbox.Message, err error) {
+	// deferred but still checking error
+	defer func() {
+		closeErr := rows.Close()
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close query rows: %w", closeErr)
+		}
+	}()
+
+	found = make([]*outbox.Message, 0)
+	for rows.Next() {
+		var msg outbox.Message
+		var eventType, payload string
+		var createdAt, nextAttemptAt int64
+		var deadAt sql.NullInt64
+		err = rows.Scan(&msg.ID, &eventType, &payload, &msg.Attempts, &msg.LastError, &createdAt, &nextAttemptAt, &deadAt)
+		if err != nil {
+			return nil, err
+		}
+		msg.Type = expenses.EventType(eventType)
+		msg.Payload = []byte(payload)
+		msg.CreatedAt = time.Unix(createdAt, 0)
+		msg.NextAttemptAt = time.Unix(nextAttemptAt, 0)
+		if deadAt.Valid {
+			msg.DeadAt = time.Unix(deadAt.Int64, 0)
+		}
+
+		found = append(found, &msg)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// Due lists the events waiting to be delivered whose next attempt is at or before now, oldest first
+func (r *OutboxRepository) Due(ctx context.Context, now time.Time, limit int) ([]*outbox.Message, error) {
+	query := `
+  SELECT
+    id, event_type, payload, attempts, last_error, created_at, next_attempt_at, dead_at
+  FROM
+    outbox
+  WHERE
+    delivered_at IS NULL AND dead_at IS NULL AND next_attempt_at <= ?
+  ORDER BY
+    id
+  LIMIT ?;`
+
+	rows, err := r.DB.QueryContext(ctx, query, now.Unix(), limit)
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+	return scanMessages(rows)
+}
+
+// update runs an update of a single event, mapping no event to outbox.ErrNotFound
+func (r *OutboxRepository) update(ctx context.Context, query string, args ...any) error {
+	res, err := r.Writer.ExecContext(ctx, query, args...)
+	if err != nil {
+		return NewQueryError(query, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return outbox.ErrNotFound
+	}
+	return nil
+}
+
+func (r *OutboxRepository) Delivered(ctx context.Context, id int, at time.Time) error {
+	query := `
+  UPDATE
+    outbox
+  SET
+    delivered_at = ?
+  WHERE
+    id = ?;`
+
+	return r.update(ctx, query, at.Unix(), id)
+}
+
+func (r *OutboxRepository) Failed(ctx context.Context, id int, attempts int, lastError string, next time.Time) error {
+	query := `
+  UPDATE
+    outbox
+  SET
+    attempts = ?, last_error = ?, next_attempt_at = ?
+  WHERE
+    id = ?;`
+
+	return r.update(ctx, query, attempts, lastError, next.Unix(), id)
+}
+
+func (r *OutboxRepository) Dead(ctx context.Context, id int, attempts int, lastError string, at time.Time) error {
+	query := `
+  UPDATE
+    outbox
+  SET
+    attempts = ?, last_error = ?, dead_at = ?
+  WHERE
+    id = ?;`
+
+	return r.update(ctx, query, attempts, lastError, at.Unix(), id)
+}
+
+// ListDead lists the events given up on, most recently given up on first
+func (r *OutboxRepository) ListDead(ctx context.Context, limit, offset int) ([]*outbox.Message, error) {
+	query := `
+  SELECT
+    id, event_type, payload, attempts, last_error, created_at, next_attempt_at, dead_at
+  FROM
+    outbox
+  WHERE
+    dead_at IS NOT NULL
+  ORDER BY
+    dead_at DESC, id DESC
+  LIMIT ? OFFSET ?;`
+
+	rows, err := r.DB.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+	return scanMessages(rows)
+}
+
+// Counts is how many events are waiting to be delivered, and how many were given up on, implementing opstats.OutboxCounter
+func (r *OutboxRepository) Counts(ctx context.Context) (pending, dead int64, err error) {
+	query := `
+  SELECT
+    count(*) FILTER (WHERE delivered_at IS NULL AND dead_at IS NULL),
+    count(*) FILTER (WHERE dead_at IS NOT NULL)
+  FROM
+    outbox;`
+
+	if err := r.DB.QueryRowContext(ctx, query).Scan(&pending, &dead); err != nil {
+		return 0, 0, NewQueryError(query, err)
+	}
+	return pending, dead, nil
+}
+
+// Retry queues a dead event again, only dead events can be retried
+func (r *OutboxRepository) Retry(ctx context.Context, id int, now time.Time) error {
+	query := `
+  UPDATE
+    outbox
+  SET
+    attempts = 0, dead_at = NULL, next_attempt_at = ?
+  WHERE
+    id = ? AND dead_at IS NOT NULL;`
+
+	return r.update(ctx, query, now.Unix(), id)
+}
+
+// Purge removes the events delivered or given up on before before, implementing retention.Purger.
+// Events still waiting to be delivered are kept however old they are
+func (r *OutboxRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	query := `
+  DELETE FROM
+    outbox
+  WHERE
+    delivered_at < ? OR dead_at < ?;`
+
+	res, err := r.Writer.ExecContext(ctx, query, before.Unix(), before.Unix())
+	if err != nil {
+		return 0, NewQueryError(query, err)
+	}
+
+	removed, err := res.RowsAffected()
+	return int(removed), err
+}
@@ -0,0 +1,182 @@
+package sqlite_test
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
+	"github.com/nicholasss/expense-tracker-api/internal/outbox"
+	"github.com/nicholasss/expense-tracker-api/internal/sqlite"
+)
+
+// setupOutboxTestRepo migrates a database with the outbox on
+func setupOutboxTestRepo(t *testing.T) (*sqlite.SqliteRepository, *sqlite.OutboxRepository) {
+	t.Helper()
+
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+	t.Cleanup(func() { repo.Close() })
+
+	if _, err := sqlite.Migrate(t.Context(), repo.DB); err != nil {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+	repo.Currency = money.DefaultCurrency
+	repo.Outbox = true
+
+	return repo, sqlite.NewOutboxRepository(repo.DB, repo.Writer)
+}
+
+func TestOutboxWrites(t *testing.T) {
+	repo, outboxRepo := setupOutboxTestRepo(t)
+
+	created, err := repo.Create(t.Context(), &expenses.Expense{
+		ExpenseOccuredAt: time.Unix(1761670800, 0).UTC(),
+		Description:      "soda",
+		Amount:           money.New(289, money.DefaultCurrency),
+	})
+	if err != nil {
+		t.Fatalf("Create() got error: '%v'", err)
+	}
+	created.Description = "soda and chips"
+	if err := repo.Update(t.Context(), expenses.Unscoped, created); err != nil {
+		t.Fatalf("Update() got error: '%v'", err)
+	}
+	if err := repo.Delete(t.Context(), expenses.Unscoped, created.ID); err != nil {
+		t.Fatalf("Delete() got error: '%v'", err)
+	}
+
+	// nothing changed, so nothing is written
+	if err := repo.Delete(t.Context(), expenses.Unscoped, created.ID); !errors.Is(err, expenses.ErrNoRowsDeleted) {
+		t.Fatalf("Delete() again got error: '%v', want: '%v'", err, expenses.ErrNoRowsDeleted)
+	}
+
+	due, err := outboxRepo.Due(t.Context(), time.Now(), 10)
+	if err != nil {
+		t.Fatalf("Due() got error: '%v'", err)
+	}
+
+	gotTypes := make([]expenses.EventType, 0, len(due))
+	for _, msg := range due {
+		gotTypes = append(gotTypes, msg.Type)
+	}
+	wantTypes := []expenses.EventType{expenses.ExpenseCreated, expenses.ExpenseUpdated, expenses.ExpenseDeleted}
+	if !slices.Equal(gotTypes, wantTypes) {
+		t.Fatalf("got types: %v, want types: %v", gotTypes, wantTypes)
+	}
+
+	// deletes carry the expense as it was
+	var deleted outbox.ExpenseEvent
+	if err := json.Unmarshal(due[2].Payload, &deleted); err != nil {
+		t.Fatalf("unable to decode payload: %v", err)
+	}
+	if deleted.Expense.ID != created.ID || deleted.Expense.Description != "soda and chips" || deleted.Expense.Amount != 289 {
+		t.Errorf("got deleted expense: %+v", deleted.Expense)
+	}
+}
+
+func TestOutboxDelivery(t *testing.T) {
+	repo, outboxRepo := setupOutboxTestRepo(t)
+	now := time.Now()
+
+	for _, description := range []string{"soda", "chips"} {
+		_, err := repo.Create(t.Context(), &expenses.Expense{
+			ExpenseOccuredAt: time.Unix(1761670800, 0),
+			Description:      description,
+			Amount:           money.New(289, money.DefaultCurrency),
+		})
+		if err != nil {
+			t.Fatalf("Create() got error: '%v'", err)
+		}
+	}
+
+	due, err := outboxRepo.Due(t.Context(), now, 10)
+	if err != nil || len(due) != 2 {
+		t.Fatalf("Due() got: %d events, error: '%v', want 2", len(due), err)
+	}
+	if err := outboxRepo.Delivered(t.Context(), due[0].ID, now); err != nil {
+		t.Fatalf("Delivered() got error: '%v'", err)
+	}
+	if err := outboxRepo.Failed(t.Context(), due[1].ID, 1, "webhook answered 500", now.Add(time.Minute)); err != nil {
+		t.Fatalf("Failed() got error: '%v'", err)
+	}
+
+	// delivered events are done with, failed ones wait for their next attempt
+	if due, err = outboxRepo.Due(t.Context(), now, 10); err != nil || len(due) != 0 {
+		t.Fatalf("Due() got: %d events, error: '%v', want none", len(due), err)
+	}
+	if due, err = outboxRepo.Due(t.Context(), now.Add(time.Minute), 10); err != nil || len(due) != 1 || due[0].Attempts != 1 {
+		t.Fatalf("Due() a minute later got: %v, error: '%v', want the failed event", due, err)
+	}
+
+	if err := outboxRepo.Dead(t.Context(), due[0].ID, 2, "webhook answered 500", now); err != nil {
+		t.Fatalf("Dead() got error: '%v'", err)
+	}
+	dead, err := outboxRepo.ListDead(t.Context(), 10, 0)
+	if err != nil || len(dead) != 1 || dead[0].ID != due[0].ID || dead[0].LastError != "webhook answered 500" {
+		t.Fatalf("ListDead() got: %v, error: '%v', want the dead event", dead, err)
+	}
+	if due, err := outboxRepo.Due(t.Context(), now.Add(time.Hour), 10); err != nil || len(due) != 0 {
+		t.Fatalf("Due() got: %d events, error: '%v', want none once dead", len(due), err)
+	}
+	if pending, dead, err := outboxRepo.Counts(t.Context()); err != nil || pending != 0 || dead != 1 {
+		t.Fatalf("Counts() got: %d pending, %d dead, error: '%v', want 0 pending, 1 dead", pending, dead, err)
+	}
+
+	// only dead events are retried
+	if err := outboxRepo.Retry(t.Context(), dead[0].ID, now); err != nil {
+		t.Fatalf("Retry() got error: '%v'", err)
+	}
+	if err := outboxRepo.Retry(t.Context(), dead[0].ID, now); !errors.Is(err, outbox.ErrNotFound) {
+		t.Fatalf("Retry() again got error: '%v', want: '%v'", err, outbox.ErrNotFound)
+	}
+	if due, err = outboxRepo.Due(t.Context(), now, 10); err != nil || len(due) != 1 || due[0].Attempts != 0 {
+		t.Fatalf("Due() after retry got: %v, error: '%v', want the event again", due, err)
+	}
+	if pending, dead, err := outboxRepo.Counts(t.Context()); err != nil || pending != 1 || dead != 0 {
+		t.Fatalf("Counts() after retry got: %d pending, %d dead, error: '%v', want 1 pending, 0 dead", pending, dead, err)
+	}
+}
+
+func TestOutboxPurge(t *testing.T) {
+	repo, outboxRepo := setupOutboxTestRepo(t)
+	now := time.Now()
+
+	for _, description := range []string{"soda", "chips", "dip"} {
+		_, err := repo.Create(t.Context(), &expenses.Expense{
+			ExpenseOccuredAt: time.Unix(1761670800, 0),
+			Description:      description,
+			Amount:           money.New(289, money.DefaultCurrency),
+		})
+		if err != nil {
+			t.Fatalf("Create() got error: '%v'", err)
+		}
+	}
+
+	due, err := outboxRepo.Due(t.Context(), now, 10)
+	if err != nil || len(due) != 3 {
+		t.Fatalf("Due() got: %d events, error: '%v', want 3", len(due), err)
+	}
+	if err := outboxRepo.Delivered(t.Context(), due[0].ID, now.Add(-48*time.Hour)); err != nil {
+		t.Fatalf("Delivered() got error: '%v'", err)
+	}
+	if err := outboxRepo.Dead(t.Context(), due[1].ID, 10, "webhook answered 500", now.Add(-48*time.Hour)); err != nil {
+		t.Fatalf("Dead() got error: '%v'", err)
+	}
+
+	// the waiting event is kept however old it is
+	removed, err := outboxRepo.Purge(t.Context(), now.Add(-24*time.Hour))
+	if err != nil || removed != 2 {
+		t.Fatalf("Purge() got: %d, error: '%v', want: 2", removed, err)
+	}
+	if due, err = outboxRepo.Due(t.Context(), now, 10); err != nil || len(due) != 1 {
+		t.Fatalf("Due() got: %d events, error: '%v', want 1", len(due), err)
+	}
+}
@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	migrations "github.com/nicholasss/expense-tracker-api/sql"
+)
+
+// SchemaVersion is the newest goose migration applied to db, 0 when none have been
+func SchemaVersion(ctx context.Context, db *sql.DB) (int64, error) {
+	query := `
+  SELECT
+    COALESCE(MAX(version_id), 0)
+  FROM
+    goose_db_version
+  WHERE
+    is_applied = 1;`
+
+	var version int64
+	if err := db.QueryRowContext(ctx, query).Scan(&version); err != nil {
+		return 0, NewQueryError(query, err)
+	}
+	return version, nil
+}
+
+// Migrate applies the embedded migrations newer than the schema of db, each in its own transaction,
+// and records them in goose's own table so goose can take over from there.
+// It is for databases nobody runs goose against, like the in-memory one of dev mode
+func Migrate(ctx context.Context, db *sql.DB) ([]migrations.Migration, error) {
+	createQuery := `
+  CREATE TABLE IF NOT EXISTS
+    goose_db_version (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      version_id INTEGER NOT NULL,
+      is_applied INTEGER NOT NULL,
+      tstamp TIMESTAMP DEFAULT (datetime('now'))
+    );`
+	if _, err := db.ExecContext(ctx, createQuery); err != nil {
+		return nil, NewQueryError(createQuery, err)
+	}
+
+	current, err := SchemaVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	all, err := migrations.All()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]migrations.Migration, 0)
+	for _, migration := range all {
+		if migration.Version <= current {
+			continue
+		}
+		if err := applyMigration(ctx, db, migration); err != nil {
+			return applied, fmt.Errorf("migration %s: %w", migration.Name, err)
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// applyMigration runs the up section of migration and records its version, or neither
+func applyMigration(ctx context.Context, db *sql.DB, migration migrations.Migration) error {
+	up, err := migrations.Up(migration.Name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, up); err != nil {
+		return err
+	}
+
+	insertQuery := `
+  INSERT INTO
+    goose_db_version (version_id, is_applied)
+  VALUES
+    (?, 1);`
+	if _, err := tx.ExecContext(ctx, insertQuery, migration.Version); err != nil {
+		return NewQueryError(insertQuery, err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,150 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/nicholasss/expense-tracker-api/internal/sqlite"
+	migrations "github.com/nicholasss/expense-tracker-api/sql"
+)
+
+func TestSchemaVersion(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputSetup  string
+		expectError bool
+		wantVersion int64
+	}{
+		{
+			name:        "invalid-never-migrated",
+			inputSetup:  ``,
+			expectError: true,
+		},
+		{
+			name: "valid-no-migrations",
+			inputSetup: `
+  CREATE TABLE
+    goose_db_version (id INTEGER PRIMARY KEY, version_id INTEGER NOT NULL, is_applied INTEGER NOT NULL);`,
+			expectError: false,
+			wantVersion: 0,
+		},
+		{
+			name: "valid-latest-applied",
+			inputSetup: `
+  CREATE TABLE
+    goose_db_version (id INTEGER PRIMARY KEY, version_id INTEGER NOT NULL, is_applied INTEGER NOT NULL);
+  INSERT INTO
+    goose_db_version (version_id, is_applied)
+  VALUES
+    (0, 1), (1, 1), (2, 1), (3, 0);`,
+			expectError: false,
+			wantVersion: 2,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo, err := sqlite.NewSqliteRepository(database, dbString)
+			if err != nil {
+				t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+			}
+			// every connection to :memory: is a new database
+			repo.DB.SetMaxOpenConns(1)
+			t.Cleanup(func() {
+				if err := repo.DB.Close(); err != nil {
+					t.Errorf("unable to close connection to in-memory sqlite database: %v", err)
+				}
+			})
+
+			if testCase.inputSetup != "" {
+				if _, err := repo.DB.Exec(testCase.inputSetup); err != nil {
+					t.Fatalf("unable to setup goose table: %v", err)
+				}
+			}
+
+			got, gotErr := sqlite.SchemaVersion(t.Context(), repo.DB)
+			if (gotErr != nil) != testCase.expectError {
+				t.Fatalf("SchemaVersion() got error: '%v', expected error: %v", gotErr, testCase.expectError)
+			}
+			if got != testCase.wantVersion {
+				t.Errorf("SchemaVersion() got: %d, want: %d", got, testCase.wantVersion)
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	testTable := []struct {
+		name         string
+		inputVersion int64 // migrated up to before, 0 for an empty database
+	}{
+		{
+			name: "valid-empty-database",
+		},
+		{
+			name:         "valid-partly-migrated",
+			inputVersion: 12,
+		},
+	}
+
+	all, err := migrations.All()
+	if err != nil {
+		t.Fatalf("unable to read migrations: %v", err)
+	}
+	wantVersion := all[len(all)-1].Version
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo, err := sqlite.NewSqliteRepository(database, dbString)
+			if err != nil {
+				t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+			}
+			t.Cleanup(func() { repo.Close() })
+
+			wantApplied := len(all)
+			if testCase.inputVersion > 0 {
+				for _, migration := range all[:testCase.inputVersion] {
+					up, err := migrations.Up(migration.Name)
+					if err != nil {
+						t.Fatalf("unable to read migration: %v", err)
+					}
+					if _, err := repo.DB.Exec(up); err != nil {
+						t.Fatalf("unable to apply migration %s: %v", migration.Name, err)
+					}
+				}
+				setup := `
+  CREATE TABLE
+    goose_db_version (id INTEGER PRIMARY KEY, version_id INTEGER NOT NULL, is_applied INTEGER NOT NULL);
+  INSERT INTO
+    goose_db_version (version_id, is_applied)
+  VALUES
+    (0, 1), (?, 1);`
+				if _, err := repo.DB.Exec(setup, testCase.inputVersion); err != nil {
+					t.Fatalf("unable to setup goose table: %v", err)
+				}
+				wantApplied -= int(testCase.inputVersion)
+			}
+
+			applied, err := sqlite.Migrate(t.Context(), repo.DB)
+			if err != nil {
+				t.Fatalf("Migrate() got error: '%v'", err)
+			}
+			if len(applied) != wantApplied {
+				t.Errorf("Migrate() applied %d migrations, want %d", len(applied), wantApplied)
+			}
+
+			got, err := sqlite.SchemaVersion(t.Context(), repo.DB)
+			if err != nil {
+				t.Fatalf("SchemaVersion() got error: '%v'", err)
+			}
+			if got != wantVersion {
+				t.Errorf("SchemaVersion() got: %d, want: %d", got, wantVersion)
+			}
+
+			// a second run has nothing left to do
+			again, err := sqlite.Migrate(t.Context(), repo.DB)
+			if err != nil || len(again) != 0 {
+				t.Errorf("second Migrate() got %d migrations and error: '%v', want none", len(again), err)
+			}
+		})
+	}
+}
@@ -4,10 +4,14 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
 )
 
 // QueryError for wrapping sql query errors
@@ -29,83 +33,257 @@ func NewQueryError(query string, err error) *QueryError {
 	return &QueryError{Query: query, Err: err}
 }
 
-// sqliteExpense has time stored as unix seconds (not milli-)
+// sqliteExpense has time stored as unix seconds (not milli-), and a nullable owner, household, category, and client id.
+// The zone occured_at happened in is kept apart, see expenses.ZoneName, and so is the folded description
+// searches match, see expenses.FoldDescription
 type sqliteExpense struct {
-	ID          int
-	CreatedAt   int64
-	OccuredAt   int64
-	Description string
-	Amount      int64
+	ID             int
+	OwnerID        sql.NullInt64
+	HouseholdID    sql.NullInt64
+	CreatedAt      int64
+	UpdatedAt      int64
+	OccuredAt      int64
+	OccuredZone    string
+	Description    string
+	DescriptionKey string
+	Amount         int64
+	CategoryID     sql.NullInt64
+	Version        int64
+	ClientID       sql.NullString
 }
 
 func toSqliteExpense(e *expenses.Expense) sqliteExpense {
 	// convert times to int
 	return sqliteExpense{
-		ID:          e.ID,
-		Description: e.Description,
-		Amount:      e.Amount,
-		// CreatedAt will occur within the database
-		OccuredAt: e.ExpenseOccuredAt.Unix(),
+		ID:             e.ID,
+		OwnerID:        nullableID(e.OwnerID),
+		HouseholdID:    nullableID(e.HouseholdID),
+		Description:    e.Description,
+		DescriptionKey: expenses.FoldDescription(e.Description),
+		Amount:         e.Amount.Minor,
+		CategoryID:     nullableID(e.CategoryID),
+		ClientID:       sql.NullString{String: e.ClientID, Valid: e.ClientID != ""},
+		// CreatedAt and UpdatedAt will occur within the database
+		OccuredAt:   e.ExpenseOccuredAt.Unix(),
+		OccuredZone: expenses.ZoneName(e.ExpenseOccuredAt),
 	}
 }
 
-func toServiceExpense(db sqliteExpense) *expenses.Expense {
+// toServiceExpense reads the amount in currency, the only one expenses are stored in.
+// Expenses stored before their zone was kept, or in a zone this server doesn't know, are in time.Local
+func toServiceExpense(db sqliteExpense, currency string) *expenses.Expense {
+	occuredAt := time.Unix(db.OccuredAt, 0)
+	if zone, err := expenses.LoadZone(db.OccuredZone); err == nil {
+		occuredAt = occuredAt.In(zone)
+	}
+
 	return &expenses.Expense{
 		ID:               db.ID,
+		OwnerID:          int(db.OwnerID.Int64),
+		HouseholdID:      int(db.HouseholdID.Int64),
 		Description:      db.Description,
-		Amount:           db.Amount,
+		Amount:           money.New(db.Amount, currency),
+		CategoryID:       int(db.CategoryID.Int64),
 		RecordCreatedAt:  time.Unix(db.CreatedAt, 0),
-		ExpenseOccuredAt: time.Unix(db.OccuredAt, 0),
+		RecordUpdatedAt:  time.Unix(db.UpdatedAt, 0),
+		ExpenseOccuredAt: occuredAt,
+		Version:          int(db.Version),
+		ClientID:         db.ClientID.String,
 	}
 }
 
+// nullableID stores the zero id as null
+func nullableID(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
+
+// scopeArgs are the arguments for the "(? OR owner_id = ? OR household_id = ?)" filter used in every scoped query.
+// A zero household is passed as null, which never matches.
+func scopeArgs(scope expenses.Scope) []any {
+	return []any{scope.AllOwners, scope.OwnerID, nullableID(scope.HouseholdID)}
+}
+
+// summaryFilterArgs are the arguments for the
+// "(? = 0 OR category_id = ?) AND (? = 0 OR amount >= ?) AND (? = 0 OR amount <= ?)" filter of the summary queries
+func summaryFilterArgs(filter expenses.SummaryFilter) []any {
+	return []any{filter.CategoryID, filter.CategoryID, filter.MinAmount, filter.MinAmount, filter.MaxAmount, filter.MaxAmount}
+}
+
+// SqliteRepository reads through DB, a pool of connections, and writes through Writer, a single connection.
+// sqlite only allows one writer at a time, so writes wait their turn for the connection instead of failing with SQLITE_BUSY
 type SqliteRepository struct {
-	DB *sql.DB
+	DB     *sql.DB
+	Writer *sql.DB
+
+	// Currency is what the stored amounts are in, money.DefaultCurrency unless FX_BASE_CURRENCY is set
+	Currency string
+
+	// Outbox adds an event to the outbox for every expense created, updated, or deleted, in the same
+	// transaction as the change, see OutboxRepository. Left off, nothing is written to the outbox
+	Outbox bool
+}
+
+// enqueue adds the event for exp to the outbox within tx, when the outbox is on
+func (r *SqliteRepository) enqueue(ctx context.Context, tx *sql.Tx, eventType expenses.EventType, exp *expenses.Expense) error {
+	if !r.Outbox {
+		return nil
+	}
+	return enqueue(ctx, tx, eventType, exp)
 }
 
+// connParams are added to the database string of both pools. In WAL mode reads don't wait on the writer,
+// and the busy timeout covers the locks taken by other processes, i.e. goose or the sqlite3 shell
+var connParams = []string{"_journal_mode=WAL", "_busy_timeout=5000"}
+
+// writerParams take the write lock when a transaction begins, rather than failing at its first write
+var writerParams = []string{"_txlock=immediate"}
+
+// isMemory reports whether dbString is an in-memory database, which only exists within one pool
+func isMemory(dbString string) bool {
+	return dbString == ":memory:" || strings.HasPrefix(dbString, "file::memory:") || strings.Contains(dbString, "mode=memory")
+}
+
+// withParams adds params to dbString, leaving out any it already sets
+func withParams(dbString string, params ...string) string {
+	for _, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if strings.Contains(dbString, key+"=") {
+			continue
+		}
+
+		if strings.Contains(dbString, "?") {
+			dbString += "&" + param
+		} else {
+			dbString += "?" + param
+		}
+	}
+	return dbString
+}
+
+// NewSqliteRepository opens the read pool and the writer.
+// An in-memory database is read and written through the same pool, since a second pool would get a database of its own,
+// and over a single connection, since each connection to :memory: is a new database too.
 func NewSqliteRepository(dbDriver, dbString string) (*SqliteRepository, error) {
-	db, err := sql.Open(dbDriver, dbString)
+	if isMemory(dbString) {
+		db, err := sql.Open(dbDriver, dbString)
+		if err != nil {
+			return nil, err
+		}
+		db.SetMaxOpenConns(1)
+		return &SqliteRepository{DB: db, Writer: db, Currency: money.DefaultCurrency}, nil
+	}
+
+	db, err := sql.Open(dbDriver, withParams(dbString, connParams...))
 	if err != nil {
 		return nil, err
 	}
 
-	return &SqliteRepository{DB: db}, nil
+	writer, err := sql.Open(dbDriver, withParams(dbString, append(connParams, writerParams...)...))
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	writer.SetMaxOpenConns(1)
+
+	return &SqliteRepository{DB: db, Writer: writer, Currency: money.DefaultCurrency}, nil
+}
+
+// Close closes the read pool and the writer
+func (r *SqliteRepository) Close() error {
+	if r.Writer == r.DB {
+		return r.DB.Close()
+	}
+	return errors.Join(r.DB.Close(), r.Writer.Close())
 }
 
 // GetByID find a particular expense with an id
-func (r *SqliteRepository) GetByID(ctx context.Context, id int) (*expenses.Expense, error) {
+func (r *SqliteRepository) GetByID(ctx context.Context, scope expenses.Scope, id int) (*expenses.Expense, error) {
 	var dbE sqliteExpense
 
 	query := `
   SELECT
-    id, created_at, occured_at, description, amount
+    id, owner_id, household_id, created_at, updated_at, occured_at, occured_zone, description, amount, category_id, version, client_id
   FROM
     expenses
   WHERE
-    id = ?;`
+    id = ? AND (? OR owner_id = ? OR household_id = ?);`
 
-	row := r.DB.QueryRowContext(ctx, query, id)
-	err := row.Scan(&dbE.ID, &dbE.CreatedAt, &dbE.OccuredAt, &dbE.Description, &dbE.Amount)
+	row := r.DB.QueryRowContext(ctx, query, append([]any{id}, scopeArgs(scope)...)...)
+	err := row.Scan(&dbE.ID, &dbE.OwnerID, &dbE.HouseholdID, &dbE.CreatedAt, &dbE.UpdatedAt, &dbE.OccuredAt, &dbE.OccuredZone, &dbE.Description, &dbE.Amount, &dbE.CategoryID, &dbE.Version, &dbE.ClientID)
 	if err == sql.ErrNoRows {
-		return nil, NewQueryError(query, err)
+		return nil, expenses.NewError(expenses.KindNotFound, NewQueryError(query, err))
 	}
 	if err != nil {
 		return nil, err
 	}
 
 	// perform conversion to domain expense as last step
-	return toServiceExpense(dbE), nil
+	return toServiceExpense(dbE, r.Currency), nil
+}
+
+// GetByIDs finds every expense with an id in ids, using a single IN (...) query
+func (r *SqliteRepository) GetByIDs(ctx context.Context, scope expenses.Scope, ids []int) ([]*expenses.Expense, error) {
+	if len(ids) == 0 {
+		return make([]*expenses.Expense, 0), nil
+	}
+
+	// one placeholder per id
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	args := make([]any, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	query := `
+  SELECT
+    id, owner_id, household_id, created_at, updated_at, occured_at, occured_zone, description, amount, category_id, version, client_id
+  FROM
+    expenses
+  WHERE
+    id IN (` + placeholders + `) AND (? OR owner_id = ? OR household_id = ?);`
+
+	rows, err := r.DB.QueryContext(ctx, query, append(args, scopeArgs(scope)...)...)
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+
+	// deferred but still checking error
+	defer func() {
+		closeErr := rows.Close()
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close query rows: %w", closeErr)
+		}
+	}()
+
+	exps := make([]*expenses.Expense, 0, len(ids))
+	for rows.Next() {
+		var dbE sqliteExpense
+		err = rows.Scan(&dbE.ID, &dbE.OwnerID, &dbE.HouseholdID, &dbE.CreatedAt, &dbE.UpdatedAt, &dbE.OccuredAt, &dbE.OccuredZone, &dbE.Description, &dbE.Amount, &dbE.CategoryID, &dbE.Version, &dbE.ClientID)
+		if err != nil {
+			return nil, err
+		}
+
+		exps = append(exps, toServiceExpense(dbE, r.Currency))
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return exps, nil
 }
 
 // GetAll returns a list of all expenses in the database
-func (r *SqliteRepository) GetAll(ctx context.Context) ([]*expenses.Expense, error) {
+func (r *SqliteRepository) GetAll(ctx context.Context, scope expenses.Scope) ([]*expenses.Expense, error) {
 	query := `
   SELECT
-    id, created_at, occured_at, description, amount
+    id, owner_id, household_id, created_at, updated_at, occured_at, occured_zone, description, amount, category_id, version, client_id
   FROM
-    expenses;`
+    expenses
+  WHERE
+    (? OR owner_id = ? OR household_id = ?);`
 
-	rows, err := r.DB.QueryContext(ctx, query)
+	rows, err := r.DB.QueryContext(ctx, query, scopeArgs(scope)...)
 	if err != nil {
 		return nil, err
 	}
@@ -122,7 +300,7 @@ func (r *SqliteRepository) GetAll(ctx context.Context) ([]*expenses.Expense, err
 	dbExpenses := make([]sqliteExpense, 0)
 	for rows.Next() {
 		var dbE sqliteExpense
-		err = rows.Scan(&dbE.ID, &dbE.CreatedAt, &dbE.OccuredAt, &dbE.Description, &dbE.Amount)
+		err = rows.Scan(&dbE.ID, &dbE.OwnerID, &dbE.HouseholdID, &dbE.CreatedAt, &dbE.UpdatedAt, &dbE.OccuredAt, &dbE.OccuredZone, &dbE.Description, &dbE.Amount, &dbE.CategoryID, &dbE.Version, &dbE.ClientID)
 		if err != nil {
 			return nil, err
 		}
@@ -139,112 +317,807 @@ func (r *SqliteRepository) GetAll(ctx context.Context) ([]*expenses.Expense, err
 
 	expenses := make([]*expenses.Expense, 0)
 	for _, dbE := range dbExpenses {
-		expenses = append(expenses, toServiceExpense(dbE))
+		expenses = append(expenses, toServiceExpense(dbE, r.Currency))
 	}
 
 	return expenses, nil
 }
 
+// Each reads expenses a row at a time, so a caller streaming them out holds one expense in memory at a time
+func (r *SqliteRepository) Each(ctx context.Context, scope expenses.Scope, fn func(*expenses.Expense) error) (err error) {
+	query := `
+  SELECT
+    id, owner_id, household_id, created_at, updated_at, occured_at, occured_zone, description, amount, category_id, version, client_id
+  FROM
+    expenses
+  WHERE
+    (? OR owner_id = ? OR household_id = ?)
+  ORDER BY
+    occured_at, id;`
+
+	rows, err := r.DB.QueryContext(ctx, query, scopeArgs(scope)...)
+	if err != nil {
+		return NewQueryError(query, err)
+	}
+
+	// deferred but still checking error
+	defer func() {
+		closeErr := rows.Close()
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close query rows: %w", closeErr)
+		}
+	}()
+
+	for rows.Next() {
+		var dbE sqliteExpense
+		err = rows.Scan(&dbE.ID, &dbE.OwnerID, &dbE.HouseholdID, &dbE.CreatedAt, &dbE.UpdatedAt, &dbE.OccuredAt, &dbE.OccuredZone, &dbE.Description, &dbE.Amount, &dbE.CategoryID, &dbE.Version, &dbE.ClientID)
+		if err != nil {
+			return err
+		}
+
+		if err = fn(toServiceExpense(dbE, r.Currency)); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// List filters and pages in the query itself, so only the requested page is ever read.
+// The cursor is a keyset on (occured_at, id), which stays fast however deep the page is
+func (r *SqliteRepository) List(ctx context.Context, scope expenses.Scope, filter expenses.ListFilter) ([]*expenses.Expense, error) {
+	query := `
+  SELECT
+    id, owner_id, household_id, created_at, updated_at, occured_at, occured_zone, description, amount, category_id, version, client_id
+  FROM
+    expenses
+  WHERE
+    (? OR owner_id = ? OR household_id = ?)
+    AND (? IS NULL OR occured_at >= ?)
+    AND (? IS NULL OR occured_at < ?)
+    AND (? = 0 OR amount >= ?)
+    AND (? = 0 OR amount <= ?)
+    AND (? = '' OR instr(description_key, ?) > 0)
+    AND (? IS NULL OR updated_at >= ?)
+    AND (? IS NULL OR occured_at < ? OR (occured_at = ? AND id < ?))
+  ORDER BY
+    occured_at DESC, id DESC
+  LIMIT ? OFFSET ?;`
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1 // no limit in sqlite
+	}
+
+	var afterOccured sql.NullInt64
+	var afterID int
+	if filter.After != nil {
+		afterOccured = sql.NullInt64{Int64: filter.After.OccuredAt.Unix(), Valid: true}
+		afterID = filter.After.ID
+	}
+
+	fromArg, toArg, updatedArg := nullableTime(filter.From), nullableTime(filter.To), nullableTime(filter.UpdatedSince)
+	args := append(scopeArgs(scope),
+		fromArg, fromArg, toArg, toArg,
+		filter.MinAmount, filter.MinAmount, filter.MaxAmount, filter.MaxAmount,
+		filter.Search, filter.Search,
+		updatedArg, updatedArg,
+		afterOccured, afterOccured, afterOccured, afterID,
+		limit, filter.Offset,
+	)
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+
+	// deferred but still checking error
+	defer func() {
+		closeErr := rows.Close()
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close query rows: %w", closeErr)
+		}
+	}()
+
+	records := make([]*expenses.Expense, 0)
+	for rows.Next() {
+		var dbE sqliteExpense
+		err = rows.Scan(&dbE.ID, &dbE.OwnerID, &dbE.HouseholdID, &dbE.CreatedAt, &dbE.UpdatedAt, &dbE.OccuredAt, &dbE.OccuredZone, &dbE.Description, &dbE.Amount, &dbE.CategoryID, &dbE.Version, &dbE.ClientID)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, toServiceExpense(dbE, r.Currency))
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// Count counts the expenses List would find on every page of filter
+func (r *SqliteRepository) Count(ctx context.Context, scope expenses.Scope, filter expenses.ListFilter) (int, error) {
+	query := `
+  SELECT
+    COUNT(*)
+  FROM
+    expenses
+  WHERE
+    (? OR owner_id = ? OR household_id = ?)
+    AND (? IS NULL OR occured_at >= ?)
+    AND (? IS NULL OR occured_at < ?)
+    AND (? = 0 OR amount >= ?)
+    AND (? = 0 OR amount <= ?)
+    AND (? = '' OR instr(description_key, ?) > 0)
+    AND (? IS NULL OR updated_at >= ?);`
+
+	fromArg, toArg, updatedArg := nullableTime(filter.From), nullableTime(filter.To), nullableTime(filter.UpdatedSince)
+	args := append(scopeArgs(scope),
+		fromArg, fromArg, toArg, toArg,
+		filter.MinAmount, filter.MinAmount, filter.MaxAmount, filter.MaxAmount,
+		filter.Search, filter.Search,
+		updatedArg, updatedArg,
+	)
+
+	var count int
+	if err := r.DB.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, NewQueryError(query, err)
+	}
+	return count, nil
+}
+
 // Create creates a new expense and returns it with id and createdAt
 func (r *SqliteRepository) Create(ctx context.Context, exp *expenses.Expense) (*expenses.Expense, error) {
 	if exp == nil {
 		return nil, expenses.ErrNilPointer
 	}
 
+	tx, err := r.Writer.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	created, err := r.create(ctx, tx, exp)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// create inserts exp within tx, for Create and Apply
+func (r *SqliteRepository) create(ctx context.Context, tx *sql.Tx, exp *expenses.Expense) (*expenses.Expense, error) {
+	if exp.Amount.Currency != r.Currency {
+		return nil, fmt.Errorf("%w: storing %s in %s", money.ErrCurrencyMismatch, exp.Amount.Currency, r.Currency)
+	}
+
 	insertDBE := toSqliteExpense(exp)
 
 	query := `
   INSERT INTO
     expenses
       (
+        owner_id,
+        household_id,
         created_at,
+        updated_at,
         occured_at,
+        occured_zone,
         description,
-        amount
+        description_key,
+        amount,
+        category_id,
+        client_id
       )
   VALUES
     (
+      ?,
+      ?,
+      unixepoch(),
       unixepoch(),
       ?,
       ?,
+      ?,
+      ?,
+      ?,
+      ?,
       ?
     )
-  RETURNING *;`
+  RETURNING
+    id, owner_id, household_id, created_at, updated_at, occured_at, occured_zone, description, amount, category_id, version, client_id;`
 
 	// ID is generated by the db so we ignore it when inserting
-	row := r.DB.QueryRowContext(ctx, query,
-		insertDBE.OccuredAt, insertDBE.Description, insertDBE.Amount,
+	row := tx.QueryRowContext(ctx, query,
+		insertDBE.OwnerID, insertDBE.HouseholdID, insertDBE.OccuredAt, insertDBE.OccuredZone, insertDBE.Description, insertDBE.DescriptionKey, insertDBE.Amount,
+		insertDBE.CategoryID, insertDBE.ClientID,
 	)
 
 	var returnDBE sqliteExpense
 	err := row.Scan(
-		&returnDBE.ID, &returnDBE.CreatedAt, &returnDBE.OccuredAt,
-		&returnDBE.Description, &returnDBE.Amount,
+		&returnDBE.ID, &returnDBE.OwnerID, &returnDBE.HouseholdID, &returnDBE.CreatedAt, &returnDBE.UpdatedAt, &returnDBE.OccuredAt,
+		&returnDBE.OccuredZone, &returnDBE.Description, &returnDBE.Amount, &returnDBE.CategoryID,
+		&returnDBE.Version, &returnDBE.ClientID,
 	)
 	if err != nil {
 		return nil, err
 	}
+	created := toServiceExpense(returnDBE, r.Currency)
 
-	return toServiceExpense(returnDBE), nil
+	// sqlite can hand out the id of the newest expense again once it is deleted, the client syncing
+	// it learns of the expense created rather than the one deleted before it
+	if _, err := tx.ExecContext(ctx, "DELETE FROM expense_tombstones WHERE expense_id = ?;", created.ID); err != nil {
+		return nil, err
+	}
+
+	if err := r.enqueue(ctx, tx, expenses.ExpenseCreated, created); err != nil {
+		return nil, err
+	}
+	return created, nil
 }
 
-// Update performs a full update for occuredAt, description, and amount
+// Update performs a full update for occuredAt, description, amount, and category
 // It does not return the updated expense struct since id and createdAt do not change
-func (r *SqliteRepository) Update(ctx context.Context, exp *expenses.Expense) error {
+func (r *SqliteRepository) Update(ctx context.Context, scope expenses.Scope, exp *expenses.Expense) error {
 	if exp == nil {
 		return expenses.ErrNilPointer
 	}
 
+	tx, err := r.Writer.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := r.update(ctx, tx, scope, exp); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// update writes exp over the stored expense within tx, counting up its version, for Update and Apply
+func (r *SqliteRepository) update(ctx context.Context, tx *sql.Tx, scope expenses.Scope, exp *expenses.Expense) (*expenses.Expense, error) {
+	if exp.Amount.Currency != r.Currency {
+		return nil, fmt.Errorf("%w: storing %s in %s", money.ErrCurrencyMismatch, exp.Amount.Currency, r.Currency)
+	}
+
 	insertDBE := toSqliteExpense(exp)
 
 	query := `
   UPDATE
     expenses
   SET
+    updated_at = unixepoch(),
+    version = version + 1,
     occured_at = ?,
+    occured_zone = ?,
     description = ?,
-    amount = ?
+    description_key = ?,
+    amount = ?,
+    category_id = ?
   WHERE
-    id = ?;`
+    id = ? AND (? OR owner_id = ? OR household_id = ?)
+  RETURNING
+    id, owner_id, household_id, created_at, updated_at, occured_at, occured_zone, description, amount, category_id, version, client_id;`
 
-	res, err := r.DB.ExecContext(ctx, query,
-		insertDBE.OccuredAt, insertDBE.Description, insertDBE.Amount, insertDBE.ID,
+	args := []any{insertDBE.OccuredAt, insertDBE.OccuredZone, insertDBE.Description, insertDBE.DescriptionKey, insertDBE.Amount, insertDBE.CategoryID, insertDBE.ID}
+	updated, err := scanWritten(tx.QueryRowContext(ctx, query, append(args, scopeArgs(scope)...)...), r.Currency)
+	if err == sql.ErrNoRows {
+		return nil, expenses.ErrNoRowsUpdated
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.enqueue(ctx, tx, expenses.ExpenseUpdated, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// scanWritten reads the expense an update or delete returns
+func scanWritten(row *sql.Row, currency string) (*expenses.Expense, error) {
+	var dbE sqliteExpense
+	err := row.Scan(
+		&dbE.ID, &dbE.OwnerID, &dbE.HouseholdID, &dbE.CreatedAt, &dbE.UpdatedAt, &dbE.OccuredAt,
+		&dbE.OccuredZone, &dbE.Description, &dbE.Amount, &dbE.CategoryID, &dbE.Version, &dbE.ClientID,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return toServiceExpense(dbE, currency), nil
+}
 
-	rowsUpdated, err := res.RowsAffected()
+func (r *SqliteRepository) Delete(ctx context.Context, scope expenses.Scope, id int) error {
+	tx, err := r.Writer.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer func() { _ = tx.Rollback() }()
 
-	if rowsUpdated == 0 {
-		return expenses.ErrNoRowsUpdated
+	if err := r.remove(ctx, tx, scope, id); err != nil {
+		return err
 	}
-	return nil
+	return tx.Commit()
 }
 
-func (r *SqliteRepository) Delete(ctx context.Context, id int) error {
+// remove deletes the expense with id within tx, leaving its tombstone, for Delete and Apply
+func (r *SqliteRepository) remove(ctx context.Context, tx *sql.Tx, scope expenses.Scope, id int) error {
 	query := `
   DELETE FROM
     expenses
   WHERE
-    id = ?;`
+    id = ? AND (? OR owner_id = ? OR household_id = ?)
+  RETURNING
+    id, owner_id, household_id, created_at, updated_at, occured_at, occured_zone, description, amount, category_id, version, client_id;`
 
-	res, err := r.DB.ExecContext(ctx, query, id)
+	deleted, err := scanWritten(tx.QueryRowContext(ctx, query, append([]any{id}, scopeArgs(scope)...)...), r.Currency)
+	if err == sql.ErrNoRows {
+		return expenses.ErrNoRowsDeleted
+	}
 	if err != nil {
 		return err
 	}
 
-	rowsAffected, err := res.RowsAffected()
+	tombstone := `
+  INSERT OR REPLACE INTO
+    expense_tombstones (expense_id, owner_id, household_id, deleted_at)
+  VALUES
+    (?, ?, ?, unixepoch());`
+
+	_, err = tx.ExecContext(ctx, tombstone, deleted.ID, nullableID(deleted.OwnerID), nullableID(deleted.HouseholdID))
 	if err != nil {
-		return err
+		return NewQueryError(tombstone, err)
 	}
 
-	if rowsAffected == 0 {
-		return expenses.ErrNoRowsDeleted
+	return r.enqueue(ctx, tx, expenses.ExpenseDeleted, deleted)
+}
+
+// Apply runs the whole batch in one transaction on the writer, so nothing is written between a mutation's
+// version check and its write. Anything short of every mutation applying is rolled back
+func (r *SqliteRepository) Apply(ctx context.Context, scope expenses.Scope, mutations []*expenses.Mutation) ([]*expenses.MutationResult, error) {
+	tx, err := r.Writer.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	results := make([]*expenses.MutationResult, 0, len(mutations))
+	applied := true
+	for _, m := range mutations {
+		result, err := r.apply(ctx, tx, scope, m)
+		if err != nil {
+			return nil, err
+		}
+		applied = applied && result.Err == nil
+		results = append(results, result)
+	}
+
+	if !applied {
+		return results, nil
+	}
+	return results, tx.Commit()
+}
+
+// apply applies one mutation of a batch within tx
+func (r *SqliteRepository) apply(ctx context.Context, tx *sql.Tx, scope expenses.Scope, m *expenses.Mutation) (*expenses.MutationResult, error) {
+	stored, err := r.mutated(ctx, tx, scope, m)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Op == expenses.MutationCreate {
+		if stored != nil {
+			return &expenses.MutationResult{ID: stored.ID, Expense: stored, Replayed: true}, nil
+		}
+
+		created, err := r.create(ctx, tx, m.Expense)
+		if isUniqueViolation(err) {
+			return &expenses.MutationResult{Err: expenses.ErrClientIDInUse}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &expenses.MutationResult{ID: created.ID, Expense: created}, nil
+	}
+
+	// updates and deletes only apply to the version they were made to
+	if stored == nil {
+		return &expenses.MutationResult{ID: m.ID, Err: expenses.ErrExpenseGone}, nil
+	}
+	if stored.Version != m.BaseVersion {
+		return &expenses.MutationResult{ID: stored.ID, Expense: stored, Err: expenses.ErrVersionConflict}, nil
+	}
+
+	if m.Op == expenses.MutationDelete {
+		if err := r.remove(ctx, tx, scope, stored.ID); err != nil {
+			return nil, err
+		}
+		return &expenses.MutationResult{ID: stored.ID}, nil
+	}
+
+	exp := *m.Expense
+	exp.ID = stored.ID
+	updated, err := r.update(ctx, tx, scope, &exp)
+	if err != nil {
+		return nil, err
+	}
+	return &expenses.MutationResult{ID: updated.ID, Expense: updated}, nil
+}
+
+// mutated reads the expense m is for within tx, by its id or else its client id, nil when there is none
+func (r *SqliteRepository) mutated(ctx context.Context, tx *sql.Tx, scope expenses.Scope, m *expenses.Mutation) (*expenses.Expense, error) {
+	query := `
+  SELECT
+    id, owner_id, household_id, created_at, updated_at, occured_at, occured_zone, description, amount, category_id, version, client_id
+  FROM
+    expenses
+  WHERE
+    (CASE WHEN ? > 0 THEN id = ? ELSE client_id = ? END)
+    AND (? OR owner_id = ? OR household_id = ?);`
+
+	args := append([]any{m.ID, m.ID, m.ClientID}, scopeArgs(scope)...)
+	stored, err := scanWritten(tx.QueryRowContext(ctx, query, args...), r.Currency)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+	return stored, nil
+}
+
+// Changes reads the expenses and the tombstones of the deleted ones as one list, keyset paged on (changed_at, id)
+// like List is on (occured_at, id). An id is never in both, Create removes the tombstone of an id it hands out again
+func (r *SqliteRepository) Changes(ctx context.Context, scope expenses.Scope, after expenses.SyncCursor, until time.Time, limit int) (changes []*expenses.Change, err error) {
+	query := `
+  SELECT
+    id, updated_at AS changed_at, 0 AS deleted,
+    owner_id, household_id, created_at, updated_at, occured_at, occured_zone, description, amount, category_id, version, client_id
+  FROM
+    expenses
+  WHERE
+    (? OR owner_id = ? OR household_id = ?)
+    AND (updated_at > ? OR (updated_at = ? AND id > ?))
+    AND updated_at < ?
+  UNION ALL
+  SELECT
+    expense_id, deleted_at, 1,
+    owner_id, household_id, 0, 0, 0, '', '', 0, NULL, 0, NULL
+  FROM
+    expense_tombstones
+  WHERE
+    (? OR owner_id = ? OR household_id = ?)
+    AND (deleted_at > ? OR (deleted_at = ? AND expense_id > ?))
+    AND deleted_at < ?
+  ORDER BY
+    changed_at, id
+  LIMIT ?;`
+
+	if limit <= 0 {
+		limit = -1 // no limit in sqlite
+	}
+
+	afterChanged, untilArg := after.ChangedAt.Unix(), until.Unix()
+	args := append(scopeArgs(scope), afterChanged, afterChanged, after.ID, untilArg)
+	args = append(args, scopeArgs(scope)...)
+	args = append(args, afterChanged, afterChanged, after.ID, untilArg, limit)
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+
+	// deferred but still checking error
+	defer func() {
+		closeErr := rows.Close()
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close query rows: %w", closeErr)
+		}
+	}()
+
+	changes = make([]*expenses.Change, 0)
+	for rows.Next() {
+		var dbE sqliteExpense
+		var changedAt int64
+		var deleted bool
+		err = rows.Scan(
+			&dbE.ID, &changedAt, &deleted,
+			&dbE.OwnerID, &dbE.HouseholdID, &dbE.CreatedAt, &dbE.UpdatedAt, &dbE.OccuredAt, &dbE.OccuredZone, &dbE.Description, &dbE.Amount, &dbE.CategoryID, &dbE.Version, &dbE.ClientID,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		change := &expenses.Change{ID: dbE.ID, ChangedAt: time.Unix(changedAt, 0)}
+		if !deleted {
+			change.Expense = toServiceExpense(dbE, r.Currency)
+		}
+		changes = append(changes, change)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// groupingFormats are the strftime format and matching time layout of each period
+var groupingFormats = map[expenses.Grouping]struct {
+	strftime string
+	layout   string
+}{
+	expenses.GroupByDay:   {"%Y-%m-%d", "2006-01-02"},
+	expenses.GroupByMonth: {"%Y-%m", "2006-01"},
+	expenses.GroupByYear:  {"%Y", "2006"},
+}
+
+// rollupGrain picks the expense_rollups rows that add up to [from, to) grouped by grouping.
+// Months are used where both bounds start a UTC month, days where both start a UTC day,
+// anything else is summed from the expenses themselves, as are filtered sums the rollups can't narrow down
+func rollupGrain(from, to time.Time, grouping expenses.Grouping, filter expenses.SummaryFilter) (string, bool) {
+	if !filter.IsZero() {
+		return "", false
+	}
+
+	startsDay := func(t time.Time) bool { return t.IsZero() || t.Equal(t.Truncate(24*time.Hour)) }
+	startsMonth := func(t time.Time) bool { return t.IsZero() || (startsDay(t) && t.UTC().Day() == 1) }
+
+	switch {
+	case grouping != expenses.GroupByDay && startsMonth(from) && startsMonth(to):
+		return "month", true
+	case startsDay(from) && startsDay(to):
+		return "day", true
+	default:
+		return "", false
+	}
+}
+
+// SumInRange sums the expenses occured in [from, to) in the database, rather than loading them.
+// Aligned ranges are read from the rollups, see rollupGrain
+func (r *SqliteRepository) SumInRange(ctx context.Context, scope expenses.Scope, from, to time.Time, filter expenses.SummaryFilter) (*expenses.Total, error) {
+	query := `
+  SELECT
+    coalesce(sum(amount), 0), count(id)
+  FROM
+    expenses
+  WHERE
+    (? IS NULL OR occured_at >= ?)
+    AND (? IS NULL OR occured_at < ?)
+    AND (? OR owner_id = ? OR household_id = ?)
+    AND (? = 0 OR category_id = ?) AND (? = 0 OR amount >= ?) AND (? = 0 OR amount <= ?);`
+
+	fromArg, toArg := nullableTime(from), nullableTime(to)
+	args := append([]any{fromArg, fromArg, toArg, toArg}, scopeArgs(scope)...)
+
+	if grain, ok := rollupGrain(from, to, expenses.GroupByYear, filter); ok {
+		query = `
+  SELECT
+    coalesce(sum(amount), 0), coalesce(sum(count), 0)
+  FROM
+    expense_rollups
+  WHERE
+    grain = ?
+    AND (? IS NULL OR period >= ?)
+    AND (? IS NULL OR period < ?)
+    AND (? OR owner_id = nullif(?, 0) OR household_id = ?);`
+		args = append([]any{grain}, args...)
+	} else {
+		args = append(args, summaryFilterArgs(filter)...)
+	}
+
+	var total expenses.Total
+	var amount int64
+	err := r.DB.QueryRowContext(ctx, query, args...).Scan(&amount, &total.Count)
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+
+	total.Amount = money.New(amount, r.Currency)
+	return &total, nil
+}
+
+// GroupedSum sums the expenses occured in [from, to) per period of loc with GROUP BY.
+// UTC periods are formatted by SQLite, and aligned ranges read from the rollups, see rollupGrain.
+// Other zones are summed with groupedSumInZone
+func (r *SqliteRepository) GroupedSum(ctx context.Context, scope expenses.Scope, from, to time.Time, grouping expenses.Grouping, loc *time.Location, filter expenses.SummaryFilter) ([]*expenses.PeriodTotal, error) {
+	format, ok := groupingFormats[grouping]
+	if !ok {
+		return nil, fmt.Errorf("unknown grouping %d", grouping)
+	}
+	if loc != nil && loc != time.UTC {
+		return r.groupedSumInZone(ctx, scope, from, to, grouping, loc, filter)
+	}
+
+	query := `
+  SELECT
+    strftime(?, occured_at, 'unixepoch') AS period, sum(amount), count(id)
+  FROM
+    expenses
+  WHERE
+    (? IS NULL OR occured_at >= ?)
+    AND (? IS NULL OR occured_at < ?)
+    AND (? OR owner_id = ? OR household_id = ?)
+    AND (? = 0 OR category_id = ?) AND (? = 0 OR amount >= ?) AND (? = 0 OR amount <= ?)
+  GROUP BY
+    period
+  ORDER BY
+    period;`
+
+	fromArg, toArg := nullableTime(from), nullableTime(to)
+	args := append([]any{format.strftime, fromArg, fromArg, toArg, toArg}, scopeArgs(scope)...)
+
+	if grain, ok := rollupGrain(from, to, grouping, filter); ok {
+		// the rollup period is a start time, so it is formatted just like occured_at
+		query = `
+  SELECT
+    strftime(?, period, 'unixepoch') AS start, sum(amount), sum(count)
+  FROM
+    expense_rollups
+  WHERE
+    grain = ?
+    AND (? IS NULL OR period >= ?)
+    AND (? IS NULL OR period < ?)
+    AND (? OR owner_id = nullif(?, 0) OR household_id = ?)
+  GROUP BY
+    start
+  ORDER BY
+    start;`
+		args = append([]any{format.strftime, grain}, args[1:]...)
+	} else {
+		args = append(args, summaryFilterArgs(filter)...)
+	}
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+
+	// deferred but still checking error
+	defer func() {
+		closeErr := rows.Close()
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close query rows: %w", closeErr)
+		}
+	}()
+
+	periods := make([]*expenses.PeriodTotal, 0)
+	for rows.Next() {
+		var period string
+		var amount int64
+		var total expenses.PeriodTotal
+		if err = rows.Scan(&period, &amount, &total.Count); err != nil {
+			return nil, err
+		}
+		total.Amount = money.New(amount, r.Currency)
+
+		total.Start, err = time.Parse(format.layout, period)
+		if err != nil {
+			return nil, err
+		}
+		periods = append(periods, &total)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return periods, nil
+}
+
+// occuredBounds are the first and last occured_at of the expenses in [from, to) that filter keeps,
+// false when there are none
+func (r *SqliteRepository) occuredBounds(ctx context.Context, scope expenses.Scope, from, to time.Time, filter expenses.SummaryFilter) (time.Time, time.Time, bool, error) {
+	query := `
+  SELECT
+    min(occured_at), max(occured_at)
+  FROM
+    expenses
+  WHERE
+    (? IS NULL OR occured_at >= ?)
+    AND (? IS NULL OR occured_at < ?)
+    AND (? OR owner_id = ? OR household_id = ?)
+    AND (? = 0 OR category_id = ?) AND (? = 0 OR amount >= ?) AND (? = 0 OR amount <= ?);`
+
+	fromArg, toArg := nullableTime(from), nullableTime(to)
+	args := append([]any{fromArg, fromArg, toArg, toArg}, scopeArgs(scope)...)
+	args = append(args, summaryFilterArgs(filter)...)
+
+	var first, last sql.NullInt64
+	if err := r.DB.QueryRowContext(ctx, query, args...).Scan(&first, &last); err != nil {
+		return time.Time{}, time.Time{}, false, NewQueryError(query, err)
+	}
+	if !first.Valid {
+		return time.Time{}, time.Time{}, false, nil
+	}
+	return time.Unix(first.Int64, 0), time.Unix(last.Int64, 0), true, nil
+}
+
+// groupedSumInZone is GroupedSum for the periods of a zone other than UTC. SQLite only knows UTC and
+// the server's zone, so the start and end of every period in [from, to) are worked out here, and passed in as JSON
+func (r *SqliteRepository) groupedSumInZone(ctx context.Context, scope expenses.Scope, from, to time.Time, grouping expenses.Grouping, loc *time.Location, filter expenses.SummaryFilter) ([]*expenses.PeriodTotal, error) {
+	// unbounded ends are cut to the expenses there are
+	first, last := from, to
+	if from.IsZero() || to.IsZero() {
+		occuredFirst, occuredLast, ok, err := r.occuredBounds(ctx, scope, from, to, filter)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return make([]*expenses.PeriodTotal, 0), nil
+		}
+		if from.IsZero() {
+			first = occuredFirst
+		}
+		if to.IsZero() {
+			last = occuredLast.Add(time.Second)
+		}
+	}
+
+	var bounds [][2]int64
+	for start := expenses.PeriodStart(first.In(loc), grouping); start.Before(last); {
+		next := expenses.NextPeriod(start, grouping)
+		bounds = append(bounds, [2]int64{start.Unix(), next.Unix()})
+		start = next
+	}
+	periodsArg, err := json.Marshal(bounds)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+  SELECT
+    json_extract(p.value, '$[0]') AS start, sum(e.amount), count(e.id)
+  FROM
+    json_each(?) AS p
+    JOIN expenses AS e
+      ON e.occured_at >= json_extract(p.value, '$[0]') AND e.occured_at < json_extract(p.value, '$[1]')
+  WHERE
+    (? IS NULL OR e.occured_at >= ?)
+    AND (? IS NULL OR e.occured_at < ?)
+    AND (? OR e.owner_id = ? OR e.household_id = ?)
+    AND (? = 0 OR e.category_id = ?) AND (? = 0 OR e.amount >= ?) AND (? = 0 OR e.amount <= ?)
+  GROUP BY
+    start
+  ORDER BY
+    start;`
+
+	fromArg, toArg := nullableTime(from), nullableTime(to)
+	args := append([]any{string(periodsArg), fromArg, fromArg, toArg, toArg}, scopeArgs(scope)...)
+	args = append(args, summaryFilterArgs(filter)...)
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+
+	// deferred but still checking error
+	defer func() {
+		closeErr := rows.Close()
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close query rows: %w", closeErr)
+		}
+	}()
+
+	periods := make([]*expenses.PeriodTotal, 0)
+	for rows.Next() {
+		var start, amount int64
+		var total expenses.PeriodTotal
+		if err = rows.Scan(&start, &amount, &total.Count); err != nil {
+			return nil, err
+		}
+		total.Amount = money.New(amount, r.Currency)
+		total.Start = time.Unix(start, 0).In(loc)
+		periods = append(periods, &total)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return periods, nil
 }
@@ -3,11 +3,17 @@ package sqlite_test
 import (
 	"database/sql"
 	"errors"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/money"
 	"github.com/nicholasss/expense-tracker-api/internal/sqlite"
+	migrations "github.com/nicholasss/expense-tracker-api/sql"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -23,7 +29,8 @@ func checkExpenseEquality(t *testing.T, got, want *expenses.Expense) {
 	if got.ID != want.ID {
 		t.Errorf("expenses.ID does not match. got: %v, want: %v", got.ID, want.ID)
 	}
-	if got.ExpenseOccuredAt != want.ExpenseOccuredAt {
+	// the same instant in the same zone, the location itself is loaded again on every read
+	if !got.ExpenseOccuredAt.Equal(want.ExpenseOccuredAt) || expenses.ZoneName(got.ExpenseOccuredAt) != expenses.ZoneName(want.ExpenseOccuredAt) {
 		t.Errorf("expenses.ExpenseOccuredAt does not match. got: %v, want: %v", got.ExpenseOccuredAt, want.ExpenseOccuredAt)
 	}
 	if got.Description != want.Description {
@@ -36,7 +43,33 @@ func checkExpenseEquality(t *testing.T, got, want *expenses.Expense) {
 	// not checking created at for now...
 }
 
-func setupTestDB(t *testing.T, db *sql.DB) {
+// createRollups adds the expense_rollups table and the triggers keeping it up to date, from the migration itself
+func createRollups(t testing.TB, db *sql.DB) {
+	t.Helper()
+
+	up, err := migrations.Up("00014_expense_rollups.sql")
+	if err != nil {
+		t.Fatalf("unable to read rollups migration: %v", err)
+	}
+	if _, err := db.Exec(up); err != nil {
+		t.Fatalf("unable to create rollups: %v", err)
+	}
+}
+
+// createTombstones adds the expense_tombstones table deletes write to, from the migration itself
+func createTombstones(t testing.TB, db *sql.DB) {
+	t.Helper()
+
+	up, err := migrations.Up("00023_expense_tombstones.sql")
+	if err != nil {
+		t.Fatalf("unable to read tombstones migration: %v", err)
+	}
+	if _, err := db.Exec(up); err != nil {
+		t.Fatalf("unable to create tombstones: %v", err)
+	}
+}
+
+func setupTestDB(t testing.TB, db *sql.DB) {
 	t.Helper()
 
 	// create the in memory
@@ -50,15 +83,25 @@ func setupTestDB(t *testing.T, db *sql.DB) {
   CREATE TABLE
     expenses (
       id INTEGER PRIMARY KEY,
+      owner_id INTEGER,
+      household_id INTEGER,
       created_at INTEGER,
+      updated_at INTEGER NOT NULL DEFAULT 0,
       occured_at INTEGER,
+      occured_zone TEXT NOT NULL DEFAULT '',
       description TEXT,
-      amount INTEGER
+      description_key TEXT NOT NULL DEFAULT '',
+      amount INTEGER,
+      category_id INTEGER,
+      version INTEGER NOT NULL DEFAULT 1,
+      client_id TEXT
     );`
 	_, err := db.Exec(createQuery)
 	if err != nil {
 		t.Fatalf("unable to create table: %v", err)
 	}
+	createRollups(t, db)
+	createTombstones(t, db)
 
 	// insert data for testing
 	insertQuery := `
@@ -131,7 +174,7 @@ func TestGetByID(t *testing.T) {
 			wantError:   nil,
 			wantRecord: &expenses.Expense{
 				ID:               1,
-				Amount:           11999,
+				Amount:           money.New(11999, "USD"),
 				ExpenseOccuredAt: time.Unix(1761231600, 0),
 				Description:      "new hairdryer",
 			},
@@ -143,7 +186,7 @@ func TestGetByID(t *testing.T) {
 			wantError:   nil,
 			wantRecord: &expenses.Expense{
 				ID:               2,
-				Amount:           1399,
+				Amount:           money.New(1399, "USD"),
 				ExpenseOccuredAt: time.Unix(1761148800, 0),
 				Description:      "oat breakfast",
 			},
@@ -182,7 +225,7 @@ func TestGetByID(t *testing.T) {
 			}()
 
 			// calling the function
-			gotRecord, gotErr := repo.GetByID(t.Context(), testCase.inputID)
+			gotRecord, gotErr := repo.GetByID(t.Context(), expenses.Unscoped, testCase.inputID)
 
 			// checking if we expect an error
 			if (gotErr != nil) != testCase.expectError {
@@ -204,6 +247,75 @@ func TestGetByID(t *testing.T) {
 	}
 }
 
+func TestGetByIDs(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputIDs    []int
+		expectError bool
+		wantError   error
+		wantIDs     []int
+	}{
+		{
+			name:        "valid-several-ids",
+			inputIDs:    []int{5, 2, 3},
+			expectError: false,
+			wantError:   nil,
+			wantIDs:     []int{2, 3, 5},
+		},
+		{
+			name:        "valid-some-missing",
+			inputIDs:    []int{1, 42},
+			expectError: false,
+			wantError:   nil,
+			wantIDs:     []int{1},
+		},
+		{
+			name:        "valid-empty-input",
+			inputIDs:    []int{},
+			expectError: false,
+			wantError:   nil,
+			wantIDs:     []int{},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo, err := sqlite.NewSqliteRepository(database, dbString)
+			if err != nil {
+				t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+			}
+
+			setupTestDB(t, repo.DB)
+
+			// defer teardown
+			defer func() {
+				err := repo.DB.Close()
+				if err != nil {
+					t.Errorf("unable to close connection to in-memory sqlite database: %v", err)
+				}
+			}()
+
+			// calling the function
+			gotRecords, gotErr := repo.GetByIDs(t.Context(), expenses.Unscoped, testCase.inputIDs)
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("GetByIDs(%v) got error: '%v', expected error: '%v'", testCase.inputIDs, gotErr, testCase.wantError)
+			}
+
+			// checking result, sorted since IN (...) does not guarantee order
+			gotIDs := make([]int, 0, len(gotRecords))
+			for _, record := range gotRecords {
+				gotIDs = append(gotIDs, record.ID)
+			}
+			slices.Sort(gotIDs)
+			if !slices.Equal(gotIDs, testCase.wantIDs) {
+				t.Errorf("got ids: %v, want ids: %v", gotIDs, testCase.wantIDs)
+			}
+		})
+	}
+}
+
 // TestGetAll does not test whether the database is empty
 func TestGetAll(t *testing.T) {
 	testTable := []struct {
@@ -219,37 +331,37 @@ func TestGetAll(t *testing.T) {
 			wantRecords: []*expenses.Expense{
 				{
 					ID:               1,
-					Amount:           11999,
+					Amount:           money.New(11999, "USD"),
 					ExpenseOccuredAt: time.Unix(1761231600, 0),
 					Description:      "new hairdryer",
 				},
 				{
 					ID:               2,
-					Amount:           1399,
+					Amount:           money.New(1399, "USD"),
 					ExpenseOccuredAt: time.Unix(1761148800, 0),
 					Description:      "oat breakfast",
 				},
 				{
 					ID:               3,
-					Amount:           2700,
+					Amount:           money.New(2700, "USD"),
 					ExpenseOccuredAt: time.Unix(1761073200, 0),
 					Description:      "cab to train station",
 				},
 				{
 					ID:               4,
-					Amount:           6289,
+					Amount:           money.New(6289, "USD"),
 					ExpenseOccuredAt: time.Unix(1761001200, 0),
 					Description:      "late dinner with client",
 				},
 				{
 					ID:               5,
-					Amount:           2560,
+					Amount:           money.New(2560, "USD"),
 					ExpenseOccuredAt: time.Unix(1760882400, 0),
 					Description:      "cab to lunch",
 				},
 				{
 					ID:               6,
-					Amount:           18988,
+					Amount:           money.New(18988, "USD"),
 					ExpenseOccuredAt: time.Unix(1760810400, 0),
 					Description:      "new coffee machine for headquarters",
 				},
@@ -275,7 +387,7 @@ func TestGetAll(t *testing.T) {
 			}()
 
 			// calling the function
-			gotRecords, gotErr := repo.GetAll(t.Context())
+			gotRecords, gotErr := repo.GetAll(t.Context(), expenses.Unscoped)
 
 			// checking if we expect an error
 			if (gotErr != nil) != testCase.expectError {
@@ -302,6 +414,11 @@ func TestGetAll(t *testing.T) {
 func TestCreate(t *testing.T) {
 	// the in memory database is setup for each individual test case,
 	// so the newly created record will always be ID = 7
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("unable to load zone: %v", err)
+	}
+	newYork := time.FixedZone("", -4*60*60)
 
 	testTable := []struct {
 		name        string
@@ -313,7 +430,7 @@ func TestCreate(t *testing.T) {
 		{
 			name: "valid-first-full-record",
 			inputRecord: &expenses.Expense{
-				Amount:           229,
+				Amount:           money.New(229, "USD"),
 				ExpenseOccuredAt: time.Unix(1761249149, 0),
 				Description:      "new altoids",
 			},
@@ -321,7 +438,7 @@ func TestCreate(t *testing.T) {
 			wantError:   nil,
 			wantRecord: &expenses.Expense{
 				ID:               7,
-				Amount:           229,
+				Amount:           money.New(229, "USD"),
 				ExpenseOccuredAt: time.Unix(1761249149, 0),
 				Description:      "new altoids",
 			},
@@ -329,7 +446,7 @@ func TestCreate(t *testing.T) {
 		{
 			name: "valid-second-full-record",
 			inputRecord: &expenses.Expense{
-				Amount:           3278900,
+				Amount:           money.New(3278900, "USD"),
 				ExpenseOccuredAt: time.Unix(1761242999, 0),
 				Description:      "a brand new car",
 			},
@@ -337,11 +454,39 @@ func TestCreate(t *testing.T) {
 			wantError:   nil,
 			wantRecord: &expenses.Expense{
 				ID:               7,
-				Amount:           3278900,
+				Amount:           money.New(3278900, "USD"),
 				ExpenseOccuredAt: time.Unix(1761242999, 0),
 				Description:      "a brand new car",
 			},
 		},
+		{
+			name: "valid-iana-zone",
+			inputRecord: &expenses.Expense{
+				Amount:           money.New(450, "USD"),
+				ExpenseOccuredAt: time.Unix(1761249149, 0).In(berlin),
+				Description:      "pretzel at the station",
+			},
+			wantRecord: &expenses.Expense{
+				ID:               7,
+				Amount:           money.New(450, "USD"),
+				ExpenseOccuredAt: time.Unix(1761249149, 0).In(berlin),
+				Description:      "pretzel at the station",
+			},
+		},
+		{
+			name: "valid-offset-zone",
+			inputRecord: &expenses.Expense{
+				Amount:           money.New(1200, "USD"),
+				ExpenseOccuredAt: time.Unix(1761249149, 0).In(newYork),
+				Description:      "bagels",
+			},
+			wantRecord: &expenses.Expense{
+				ID:               7,
+				Amount:           money.New(1200, "USD"),
+				ExpenseOccuredAt: time.Unix(1761249149, 0).In(newYork),
+				Description:      "bagels",
+			},
+		},
 		{
 			name:        "invalid-nil-record",
 			inputRecord: nil,
@@ -402,7 +547,7 @@ func TestUpdate(t *testing.T) {
 			name: "valid-update-description",
 			inputRecord: &expenses.Expense{
 				ID:               2,
-				Amount:           1399,
+				Amount:           money.New(1399, "USD"),
 				ExpenseOccuredAt: time.Unix(1761148800, 0),
 				Description:      "oat breakfast and a coffee",
 			},
@@ -413,7 +558,7 @@ func TestUpdate(t *testing.T) {
 			name: "valid-update-amount",
 			inputRecord: &expenses.Expense{
 				ID:               2,
-				Amount:           1849,
+				Amount:           money.New(1849, "USD"),
 				ExpenseOccuredAt: time.Unix(1761148800, 0),
 				Description:      "oat breakfast",
 			},
@@ -424,7 +569,7 @@ func TestUpdate(t *testing.T) {
 			name: "valid-update-occured-at-time",
 			inputRecord: &expenses.Expense{
 				ID:               2,
-				Amount:           1399,
+				Amount:           money.New(1399, "USD"),
 				ExpenseOccuredAt: time.Unix(1761148600, 0),
 				Description:      "oat breakfast",
 			},
@@ -435,7 +580,7 @@ func TestUpdate(t *testing.T) {
 			name: "invalid-nonexistent-id",
 			inputRecord: &expenses.Expense{
 				ID:               13,
-				Amount:           1399,
+				Amount:           money.New(1399, "USD"),
 				ExpenseOccuredAt: time.Unix(1761148600, 0),
 				Description:      "oat breakfast",
 			},
@@ -467,8 +612,9 @@ func TestUpdate(t *testing.T) {
 				}
 			}()
 
-			// call the function here
-			gotErr := repo.Update(t.Context(), testCase.inputRecord)
+			// call the function here, updated_at is in whole seconds
+			before := time.Now().Truncate(time.Second)
+			gotErr := repo.Update(t.Context(), expenses.Unscoped, testCase.inputRecord)
 
 			// checking if we expect an error
 			if (gotErr != nil) != testCase.expectError {
@@ -480,6 +626,16 @@ func TestUpdate(t *testing.T) {
 				if !errors.Is(gotErr, testCase.wantError) {
 					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
 				}
+				return
+			}
+
+			// the update is stamped for clients syncing changes
+			updated, err := repo.GetByID(t.Context(), expenses.Unscoped, testCase.inputRecord.ID)
+			if err != nil {
+				t.Fatalf("GetByID() got error: '%v'", err)
+			}
+			if updated.RecordUpdatedAt.Before(before) {
+				t.Errorf("got updated at: %v, want at or after: %v", updated.RecordUpdatedAt, before)
 			}
 		})
 	}
@@ -530,7 +686,7 @@ func TestDelete(t *testing.T) {
 			}()
 
 			// call the function here
-			gotErr := repo.Delete(t.Context(), testCase.inputID)
+			gotErr := repo.Delete(t.Context(), expenses.Unscoped, testCase.inputID)
 
 			// checking if we expect an error
 			if (gotErr != nil) != testCase.expectError {
@@ -546,3 +702,876 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestChanges(t *testing.T) {
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+	t.Cleanup(func() { repo.Close() })
+
+	setupTestDB(t, repo.DB)
+
+	// records 1 to 3 changed at 1000 and the rest at 2000, the sixth belongs to user 2
+	_, err = repo.DB.Exec(`UPDATE expenses SET updated_at = CASE WHEN id <= 3 THEN 1000 ELSE 2000 END, owner_id = CASE WHEN id = 6 THEN 2 ELSE 1 END;`)
+	if err != nil {
+		t.Fatalf("unable to set changes: %v", err)
+	}
+	if err := repo.Delete(t.Context(), expenses.OwnerScope(1), 2); err != nil {
+		t.Fatalf("Delete() got error: '%v'", err)
+	}
+	if _, err := repo.DB.Exec(`UPDATE expense_tombstones SET deleted_at = 1500;`); err != nil {
+		t.Fatalf("unable to set deleted at: %v", err)
+	}
+
+	testTable := []struct {
+		name        string
+		inputScope  expenses.Scope
+		inputAfter  expenses.SyncCursor
+		inputUntil  int64
+		inputLimit  int
+		wantIDs     []int
+		wantDeleted []int
+	}{
+		{
+			name:        "valid-every-change",
+			inputScope:  expenses.Unscoped,
+			inputUntil:  3000,
+			wantIDs:     []int{1, 3, 2, 4, 5, 6},
+			wantDeleted: []int{2},
+		},
+		{
+			name:        "valid-after-cursor",
+			inputScope:  expenses.Unscoped,
+			inputAfter:  expenses.SyncCursor{ChangedAt: time.Unix(1000, 0), ID: 1},
+			inputUntil:  3000,
+			wantIDs:     []int{3, 2, 4, 5, 6},
+			wantDeleted: []int{2},
+		},
+		{
+			name:        "valid-limited",
+			inputScope:  expenses.Unscoped,
+			inputUntil:  3000,
+			inputLimit:  2,
+			wantIDs:     []int{1, 3},
+			wantDeleted: []int{},
+		},
+		{
+			name:        "valid-before-until",
+			inputScope:  expenses.Unscoped,
+			inputUntil:  2000,
+			wantIDs:     []int{1, 3, 2},
+			wantDeleted: []int{2},
+		},
+		{
+			name:        "valid-owner-scope",
+			inputScope:  expenses.OwnerScope(2),
+			inputUntil:  3000,
+			wantIDs:     []int{6},
+			wantDeleted: []int{},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := repo.Changes(t.Context(), testCase.inputScope, testCase.inputAfter, time.Unix(testCase.inputUntil, 0), testCase.inputLimit)
+			if err != nil {
+				t.Fatalf("Changes() got error: '%v'", err)
+			}
+
+			gotIDs, gotDeleted := make([]int, 0, len(got)), make([]int, 0)
+			for _, change := range got {
+				gotIDs = append(gotIDs, change.ID)
+				if change.Deleted() {
+					gotDeleted = append(gotDeleted, change.ID)
+				}
+			}
+			if !slices.Equal(gotIDs, testCase.wantIDs) || !slices.Equal(gotDeleted, testCase.wantDeleted) {
+				t.Errorf("got ids: %v, deleted: %v, want ids: %v, deleted: %v", gotIDs, gotDeleted, testCase.wantIDs, testCase.wantDeleted)
+			}
+		})
+	}
+
+	// the newest id is handed out again once deleted, which is a create rather than a delete
+	if err := repo.Delete(t.Context(), expenses.Unscoped, 6); err != nil {
+		t.Fatalf("Delete() got error: '%v'", err)
+	}
+	created, err := repo.Create(t.Context(), &expenses.Expense{OwnerID: 2, ExpenseOccuredAt: time.Unix(1761231600, 0), Amount: money.New(500, "USD")})
+	if err != nil || created.ID != 6 {
+		t.Fatalf("Create() got: %+v, error: '%v', want id 6", created, err)
+	}
+	got, err := repo.Changes(t.Context(), expenses.OwnerScope(2), expenses.SyncCursor{}, time.Now().Add(time.Minute), 0)
+	if err != nil || len(got) != 1 || got[0].Deleted() {
+		t.Errorf("Changes() after create got: %v, error: '%v', want expense 6 created", got, err)
+	}
+}
+
+func TestTombstonePurge(t *testing.T) {
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+	t.Cleanup(func() { repo.Close() })
+
+	setupTestDB(t, repo.DB)
+
+	for _, id := range []int{1, 2} {
+		if err := repo.Delete(t.Context(), expenses.Unscoped, id); err != nil {
+			t.Fatalf("Delete() got error: '%v'", err)
+		}
+	}
+	if _, err := repo.DB.Exec(`UPDATE expense_tombstones SET deleted_at = 1000 WHERE expense_id = 1;`); err != nil {
+		t.Fatalf("unable to set deleted at: %v", err)
+	}
+
+	tombstones := sqlite.NewTombstoneRepository(repo.DB, repo.Writer)
+	removed, err := tombstones.Purge(t.Context(), time.Unix(2000, 0))
+	if err != nil || removed != 1 {
+		t.Fatalf("Purge() got: %d, error: '%v', want: 1", removed, err)
+	}
+
+	// the tombstone deleted just now is still synced
+	got, err := repo.Changes(t.Context(), expenses.Unscoped, expenses.SyncCursor{}, time.Now().Add(time.Hour), 0)
+	if err != nil {
+		t.Fatalf("Changes() got error: '%v'", err)
+	}
+	var deleted []int
+	for _, change := range got {
+		if change.Deleted() {
+			deleted = append(deleted, change.ID)
+		}
+	}
+	if !slices.Equal(deleted, []int{2}) {
+		t.Errorf("got deleted: %v, want: [2]", deleted)
+	}
+}
+
+func TestOwnerScope(t *testing.T) {
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+
+	setupTestDB(t, repo.DB)
+
+	// defer teardown
+	defer func() {
+		err := repo.DB.Close()
+		if err != nil {
+			t.Errorf("unable to close connection to in-memory sqlite database: %v", err)
+		}
+	}()
+
+	// first three records belong to user 1, the rest to user 2
+	_, err = repo.DB.Exec(`UPDATE expenses SET owner_id = CASE WHEN id <= 3 THEN 1 ELSE 2 END;`)
+	if err != nil {
+		t.Fatalf("unable to set owners: %v", err)
+	}
+
+	owner1, owner2 := expenses.OwnerScope(1), expenses.OwnerScope(2)
+
+	records, err := repo.GetAll(t.Context(), owner1)
+	if err != nil || len(records) != 3 {
+		t.Errorf("GetAll(owner 1) got %d records, error: '%v', want 3 records", len(records), err)
+	}
+	for _, record := range records {
+		if record.OwnerID != 1 {
+			t.Errorf("GetAll(owner 1) returned record %d owned by %d", record.ID, record.OwnerID)
+		}
+	}
+
+	records, err = repo.GetByIDs(t.Context(), owner2, []int{1, 4, 5})
+	if err != nil || len(records) != 2 {
+		t.Errorf("GetByIDs(owner 2) got %d records, error: '%v', want 2 records", len(records), err)
+	}
+
+	// other owners' records act as if they do not exist
+	if _, err := repo.GetByID(t.Context(), owner2, 1); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetByID(owner 2, 1) got error: '%v', want error: '%v'", err, sql.ErrNoRows)
+	}
+	err = repo.Update(t.Context(), owner2, &expenses.Expense{ID: 1, ExpenseOccuredAt: time.Unix(1761231600, 0), Amount: money.New(1, "USD")})
+	if !errors.Is(err, expenses.ErrNoRowsUpdated) {
+		t.Errorf("Update(owner 2, 1) got error: '%v', want error: '%v'", err, expenses.ErrNoRowsUpdated)
+	}
+	if err := repo.Delete(t.Context(), owner2, 1); !errors.Is(err, expenses.ErrNoRowsDeleted) {
+		t.Errorf("Delete(owner 2, 1) got error: '%v', want error: '%v'", err, expenses.ErrNoRowsDeleted)
+	}
+
+	// new records keep their owner
+	created, err := repo.Create(t.Context(), &expenses.Expense{OwnerID: 2, ExpenseOccuredAt: time.Unix(1761231600, 0), Amount: money.New(500, "USD")})
+	if err != nil || created.OwnerID != 2 {
+		t.Errorf("Create() got: %+v, error: '%v', want owner 2", created, err)
+	}
+
+	// amounts are only stored in the repository's currency
+	_, err = repo.Create(t.Context(), &expenses.Expense{OwnerID: 2, ExpenseOccuredAt: time.Unix(1761231600, 0), Amount: money.New(500, "EUR")})
+	if !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Errorf("Create(USD) got error: '%v', want error: '%v'", err, money.ErrCurrencyMismatch)
+	}
+
+	records, err = repo.GetAll(t.Context(), expenses.Unscoped)
+	if err != nil || len(records) != 7 {
+		t.Errorf("GetAll(unscoped) got %d records, error: '%v', want 7 records", len(records), err)
+	}
+}
+
+func TestSumInRange(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputScope  expenses.Scope
+		inputFrom   time.Time
+		inputTo     time.Time
+		inputFilter expenses.SummaryFilter
+		want        expenses.Total
+	}{
+		{
+			name:       "valid-unbounded",
+			inputScope: expenses.Unscoped,
+			want:       expenses.Total{Amount: money.New(43935, "USD"), Count: 6},
+		},
+		{
+			name:       "valid-two-days",
+			inputScope: expenses.Unscoped,
+			inputFrom:  time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC),
+			inputTo:    time.Date(2025, 10, 22, 0, 0, 0, 0, time.UTC),
+			want:       expenses.Total{Amount: money.New(8989, "USD"), Count: 2},
+		},
+		{
+			name:       "valid-owner-scope",
+			inputScope: expenses.OwnerScope(1),
+			want:       expenses.Total{Amount: money.New(16098, "USD"), Count: 3},
+		},
+		{
+			name:       "valid-part-of-a-day",
+			inputScope: expenses.Unscoped,
+			inputFrom:  time.Date(2025, 10, 21, 12, 0, 0, 0, time.UTC),
+			inputTo:    time.Date(2025, 10, 22, 12, 0, 0, 0, time.UTC),
+			want:       expenses.Total{Amount: money.New(2700, "USD"), Count: 1},
+		},
+		{
+			name:        "valid-amount-filter",
+			inputScope:  expenses.Unscoped,
+			inputFilter: expenses.SummaryFilter{MinAmount: 2600, MaxAmount: 12000},
+			want:        expenses.Total{Amount: money.New(20988, "USD"), Count: 3},
+		},
+		{
+			name:        "valid-category-filter",
+			inputScope:  expenses.Unscoped,
+			inputFrom:   time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC),
+			inputTo:     time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC),
+			inputFilter: expenses.SummaryFilter{CategoryID: 5},
+			want:        expenses.Total{Amount: money.New(13398, "USD"), Count: 2},
+		},
+		{
+			name:       "valid-empty-range",
+			inputScope: expenses.Unscoped,
+			inputFrom:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			inputTo:    time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:       expenses.Total{Amount: money.Zero("USD")},
+		},
+	}
+
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+	defer repo.DB.Close()
+
+	setupTestDB(t, repo.DB)
+
+	// first three records belong to user 1, the rest to user 2
+	_, err = repo.DB.Exec(`UPDATE expenses SET owner_id = CASE WHEN id <= 3 THEN 1 ELSE 2 END;`)
+	if err != nil {
+		t.Fatalf("unable to set owners: %v", err)
+	}
+	_, err = repo.DB.Exec(`UPDATE expenses SET category_id = 5 WHERE id <= 2;`)
+	if err != nil {
+		t.Fatalf("unable to set categories: %v", err)
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := repo.SumInRange(t.Context(), testCase.inputScope, testCase.inputFrom, testCase.inputTo, testCase.inputFilter)
+			if err != nil {
+				t.Fatalf("SumInRange() got error: '%v'", err)
+			}
+			if *got != testCase.want {
+				t.Errorf("got: %+v, want: %+v", *got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestGroupedSum(t *testing.T) {
+	testTable := []struct {
+		name          string
+		inputGrouping expenses.Grouping
+		inputFrom     time.Time
+		wantStarts    []time.Time
+		wantAmounts   []int64
+	}{
+		{
+			name:          "valid-by-month",
+			inputGrouping: expenses.GroupByMonth,
+			wantStarts:    []time.Time{time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)},
+			wantAmounts:   []int64{43935},
+		},
+		{
+			name:          "valid-by-day-from-the-21st",
+			inputGrouping: expenses.GroupByDay,
+			inputFrom:     time.Date(2025, 10, 21, 0, 0, 0, 0, time.UTC),
+			wantStarts: []time.Time{
+				time.Date(2025, 10, 21, 0, 0, 0, 0, time.UTC),
+				time.Date(2025, 10, 22, 0, 0, 0, 0, time.UTC),
+				time.Date(2025, 10, 23, 0, 0, 0, 0, time.UTC),
+			},
+			wantAmounts: []int64{2700, 1399, 11999},
+		},
+		{
+			name:          "valid-by-day-from-the-evening-of-the-21st",
+			inputGrouping: expenses.GroupByDay,
+			inputFrom:     time.Date(2025, 10, 21, 20, 0, 0, 0, time.UTC),
+			wantStarts: []time.Time{
+				time.Date(2025, 10, 22, 0, 0, 0, 0, time.UTC),
+				time.Date(2025, 10, 23, 0, 0, 0, 0, time.UTC),
+			},
+			wantAmounts: []int64{1399, 11999},
+		},
+		{
+			name:          "valid-by-year",
+			inputGrouping: expenses.GroupByYear,
+			wantStarts:    []time.Time{time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+			wantAmounts:   []int64{43935},
+		},
+	}
+
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+	defer repo.DB.Close()
+
+	setupTestDB(t, repo.DB)
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := repo.GroupedSum(t.Context(), expenses.Unscoped, testCase.inputFrom, time.Time{}, testCase.inputGrouping, time.UTC, expenses.SummaryFilter{})
+			if err != nil {
+				t.Fatalf("GroupedSum() got error: '%v'", err)
+			}
+			if len(got) != len(testCase.wantStarts) {
+				t.Fatalf("got %d periods, want %d", len(got), len(testCase.wantStarts))
+			}
+			for i, period := range got {
+				if !period.Start.Equal(testCase.wantStarts[i]) || period.Amount.Minor != testCase.wantAmounts[i] {
+					t.Errorf("got period %d: %v %d, want: %v %d", i, period.Start, period.Amount.Minor, testCase.wantStarts[i], testCase.wantAmounts[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGroupedSumInZone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("unable to load zone: %v", err)
+	}
+
+	testTable := []struct {
+		name          string
+		inputFrom     time.Time
+		inputTo       time.Time
+		inputGrouping expenses.Grouping
+		inputLoc      *time.Location
+		wantStarts    []time.Time
+		wantAmounts   []int64
+	}{
+		{
+			name:          "valid-utc-month",
+			inputGrouping: expenses.GroupByMonth,
+			inputLoc:      time.UTC,
+			wantStarts:    []time.Time{time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)},
+			wantAmounts:   []int64{48935},
+		},
+		{
+			name:          "valid-berlin-month",
+			inputGrouping: expenses.GroupByMonth,
+			inputLoc:      berlin,
+			wantStarts: []time.Time{
+				time.Date(2025, 10, 1, 0, 0, 0, 0, berlin),
+				time.Date(2025, 11, 1, 0, 0, 0, 0, berlin),
+			},
+			wantAmounts: []int64{43935, 5000},
+		},
+		{
+			name:          "valid-berlin-days-of-november",
+			inputFrom:     time.Date(2025, 11, 1, 0, 0, 0, 0, berlin),
+			inputTo:       time.Date(2025, 12, 1, 0, 0, 0, 0, berlin),
+			inputGrouping: expenses.GroupByDay,
+			inputLoc:      berlin,
+			wantStarts:    []time.Time{time.Date(2025, 11, 1, 0, 0, 0, 0, berlin)},
+			wantAmounts:   []int64{5000},
+		},
+		{
+			name:          "valid-berlin-year",
+			inputGrouping: expenses.GroupByYear,
+			inputLoc:      berlin,
+			wantStarts:    []time.Time{time.Date(2025, 1, 1, 0, 0, 0, 0, berlin)},
+			wantAmounts:   []int64{48935},
+		},
+	}
+
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+	defer repo.DB.Close()
+
+	setupTestDB(t, repo.DB)
+
+	// 2025-11-01 00:30 in Berlin, still October in UTC
+	_, err = repo.DB.Exec(`INSERT INTO expenses (created_at, occured_at, occured_zone, description, amount)
+  VALUES (unixepoch(), 1761953400, 'Europe/Berlin', 'midnight snack', 5000);`)
+	if err != nil {
+		t.Fatalf("unable to insert expense: %v", err)
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := repo.GroupedSum(t.Context(), expenses.Unscoped, testCase.inputFrom, testCase.inputTo, testCase.inputGrouping, testCase.inputLoc, expenses.SummaryFilter{})
+			if err != nil {
+				t.Fatalf("GroupedSum() got error: '%v'", err)
+			}
+			if len(got) != len(testCase.wantStarts) {
+				t.Fatalf("got %d periods, want %d", len(got), len(testCase.wantStarts))
+			}
+			for i, period := range got {
+				if !period.Start.Equal(testCase.wantStarts[i]) || period.Amount.Minor != testCase.wantAmounts[i] {
+					t.Errorf("got period %d: %v %d, want: %v %d", i, period.Start, period.Amount.Minor, testCase.wantStarts[i], testCase.wantAmounts[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRollupsFollowWrites(t *testing.T) {
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+	defer repo.DB.Close()
+
+	setupTestDB(t, repo.DB)
+
+	created, err := repo.Create(t.Context(), &expenses.Expense{
+		OwnerID:          1,
+		ExpenseOccuredAt: time.Date(2025, 9, 30, 23, 30, 0, 0, time.UTC),
+		Description:      "late train home",
+		Amount:           money.New(450, "USD"),
+	})
+	if err != nil {
+		t.Fatalf("Create() got error: '%v'", err)
+	}
+
+	// moved into the next day and month, to another owner
+	created.OwnerID = 2
+	created.ExpenseOccuredAt = time.Date(2025, 10, 1, 0, 30, 0, 0, time.UTC)
+	created.Amount = money.New(550, "USD")
+	if err := repo.Update(t.Context(), expenses.Unscoped, created); err != nil {
+		t.Fatalf("Update() got error: '%v'", err)
+	}
+	if err := repo.Delete(t.Context(), expenses.Unscoped, 1); err != nil {
+		t.Fatalf("Delete() got error: '%v'", err)
+	}
+
+	// a from of one second past the epoch covers every expense, but is never aligned, so it is summed from the expenses
+	unaligned := time.Unix(1, 0)
+	scopes := []expenses.Scope{expenses.Unscoped, expenses.OwnerScope(1), expenses.OwnerScope(2)}
+	groupings := []expenses.Grouping{expenses.GroupByDay, expenses.GroupByMonth, expenses.GroupByYear}
+
+	for _, scope := range scopes {
+		want, err := repo.SumInRange(t.Context(), scope, unaligned, time.Time{}, expenses.SummaryFilter{})
+		if err != nil {
+			t.Fatalf("SumInRange() got error: '%v'", err)
+		}
+		got, err := repo.SumInRange(t.Context(), scope, time.Time{}, time.Time{}, expenses.SummaryFilter{})
+		if err != nil {
+			t.Fatalf("SumInRange() got error: '%v'", err)
+		}
+		if *got != *want {
+			t.Errorf("scope %+v got rollup total: %+v, want: %+v", scope, *got, *want)
+		}
+
+		for _, grouping := range groupings {
+			want, err := repo.GroupedSum(t.Context(), scope, unaligned, time.Time{}, grouping, time.UTC, expenses.SummaryFilter{})
+			if err != nil {
+				t.Fatalf("GroupedSum() got error: '%v'", err)
+			}
+			got, err := repo.GroupedSum(t.Context(), scope, time.Time{}, time.Time{}, grouping, time.UTC, expenses.SummaryFilter{})
+			if err != nil {
+				t.Fatalf("GroupedSum() got error: '%v'", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("scope %+v grouping %d got rollup periods: %+v, want: %+v", scope, grouping, got, want)
+			}
+		}
+	}
+
+	// the rollups drop a period once its last expense is gone
+	var periods int
+	err = repo.DB.QueryRow(`SELECT count(*) FROM expense_rollups WHERE period = ?;`,
+		time.Date(2025, 9, 30, 0, 0, 0, 0, time.UTC).Unix()).Scan(&periods)
+	if err != nil {
+		t.Fatalf("unable to count rollups: %v", err)
+	}
+	if periods != 0 {
+		t.Errorf("got %d rollups for the day the expense moved from, want 0", periods)
+	}
+}
+
+func TestList(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputScope  expenses.Scope
+		inputFilter expenses.ListFilter
+		wantIDs     []int
+		wantCount   int // on every page
+	}{
+		{
+			name:       "valid-unfiltered",
+			inputScope: expenses.Unscoped,
+			wantIDs:    []int{1, 2, 3, 4, 5, 6},
+			wantCount:  6,
+		},
+		{
+			name:        "valid-limit",
+			inputScope:  expenses.Unscoped,
+			inputFilter: expenses.ListFilter{Limit: 2},
+			wantIDs:     []int{1, 2},
+			wantCount:   6,
+		},
+		{
+			name:        "valid-limit-offset",
+			inputScope:  expenses.Unscoped,
+			inputFilter: expenses.ListFilter{Limit: 2, Offset: 2},
+			wantIDs:     []int{3, 4},
+			wantCount:   6,
+		},
+		{
+			name:       "valid-after-cursor",
+			inputScope: expenses.Unscoped,
+			inputFilter: expenses.ListFilter{
+				Limit: 2,
+				After: &expenses.Cursor{OccuredAt: time.Unix(1761148800, 0), ID: 2},
+			},
+			wantIDs:   []int{3, 4},
+			wantCount: 6,
+		},
+		{
+			name:       "valid-time-range",
+			inputScope: expenses.Unscoped,
+			inputFilter: expenses.ListFilter{
+				From: time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2025, 10, 22, 0, 0, 0, 0, time.UTC),
+			},
+			wantIDs:   []int{3, 4},
+			wantCount: 2,
+		},
+		{
+			name:        "valid-amount-range",
+			inputScope:  expenses.Unscoped,
+			inputFilter: expenses.ListFilter{MinAmount: 2600, MaxAmount: 12000},
+			wantIDs:     []int{1, 3, 4},
+			wantCount:   3,
+		},
+		{
+			name:       "valid-owner-scope",
+			inputScope: expenses.OwnerScope(1),
+			wantIDs:    []int{1, 2, 3},
+			wantCount:  3,
+		},
+		{
+			name:        "valid-search",
+			inputScope:  expenses.Unscoped,
+			inputFilter: expenses.ListFilter{Search: "cab to"},
+			wantIDs:     []int{3, 5},
+			wantCount:   2,
+		},
+		{
+			name:        "valid-updated-since",
+			inputScope:  expenses.Unscoped,
+			inputFilter: expenses.ListFilter{UpdatedSince: time.Unix(1761300000, 0)},
+			wantIDs:     []int{2, 4},
+			wantCount:   2,
+		},
+	}
+
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+	defer repo.DB.Close()
+
+	setupTestDB(t, repo.DB)
+
+	// first three records belong to user 1, the rest to user 2
+	_, err = repo.DB.Exec(`UPDATE expenses SET owner_id = CASE WHEN id <= 3 THEN 1 ELSE 2 END;`)
+	if err != nil {
+		t.Fatalf("unable to set owners: %v", err)
+	}
+
+	// two records were changed after the others were created
+	_, err = repo.DB.Exec(`UPDATE expenses SET updated_at = CASE WHEN id IN (2, 4) THEN 1761300000 ELSE 1761200000 END;`)
+	if err != nil {
+		t.Fatalf("unable to set updated at: %v", err)
+	}
+
+	// the test data is inserted without its search key, like expenses stored before it was kept
+	folded, err := repo.FoldDescriptions(t.Context())
+	if err != nil || folded != 6 {
+		t.Fatalf("FoldDescriptions() got: %d, error: '%v', want: 6", folded, err)
+	}
+	if folded, err = repo.FoldDescriptions(t.Context()); err != nil || folded != 0 {
+		t.Fatalf("FoldDescriptions() again got: %d, error: '%v', want: 0", folded, err)
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := repo.List(t.Context(), testCase.inputScope, testCase.inputFilter)
+			if err != nil {
+				t.Fatalf("List() got error: '%v'", err)
+			}
+
+			gotIDs := make([]int, 0, len(got))
+			for _, record := range got {
+				gotIDs = append(gotIDs, record.ID)
+			}
+			if !slices.Equal(gotIDs, testCase.wantIDs) {
+				t.Errorf("got ids: %v, want ids: %v", gotIDs, testCase.wantIDs)
+			}
+
+			gotCount, err := repo.Count(t.Context(), testCase.inputScope, testCase.inputFilter)
+			if err != nil {
+				t.Fatalf("Count() got error: '%v'", err)
+			}
+			if gotCount != testCase.wantCount {
+				t.Errorf("Count() got: %d, want: %d", gotCount, testCase.wantCount)
+			}
+		})
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	// a file, since an in-memory database is read and written through one pool
+	repo, err := sqlite.NewSqliteRepository(database, filepath.Join(t.TempDir(), "expenses.db"))
+	if err != nil {
+		t.Fatalf("failed to setup sqlite3 db due to: %v", err)
+	}
+	defer repo.Close()
+
+	var journalMode string
+	if err := repo.DB.QueryRow(`PRAGMA journal_mode;`).Scan(&journalMode); err != nil {
+		t.Fatalf("unable to read journal mode: %v", err)
+	}
+	if journalMode != "wal" {
+		t.Errorf("got journal mode: %q, want: %q", journalMode, "wal")
+	}
+
+	setupTestDB(t, repo.Writer)
+
+	// bursts of writes queue for the writer while reads carry on
+	const writers = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*2)
+	for i := range writers {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := repo.Create(t.Context(), &expenses.Expense{ExpenseOccuredAt: time.Unix(1761231600, 0), Description: "burst", Amount: money.New(int64(100+i), "USD")})
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := repo.List(t.Context(), expenses.Unscoped, expenses.ListFilter{Limit: 10})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("got error under concurrent writes: '%v'", err)
+		}
+	}
+
+	got, err := repo.SumInRange(t.Context(), expenses.Unscoped, time.Time{}, time.Time{}, expenses.SummaryFilter{})
+	if err != nil {
+		t.Fatalf("SumInRange() got error: '%v'", err)
+	}
+	if got.Count != 6+writers {
+		t.Errorf("got %d expenses, want %d", got.Count, 6+writers)
+	}
+}
+
+// setupBenchRepo is an in-memory repository holding n expenses, one an hour apart
+func setupBenchRepo(b *testing.B, n int) *sqlite.SqliteRepository {
+	b.Helper()
+
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		b.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+	b.Cleanup(func() { repo.Close() })
+
+	setupTestDB(b, repo.DB)
+	if _, err := repo.EnsureIndexes(b.Context()); err != nil {
+		b.Fatalf("unable to create indexes: %v", err)
+	}
+
+	start := time.Unix(1761231600, 0)
+	for i := range n {
+		exp := &expenses.Expense{ExpenseOccuredAt: start.Add(-time.Duration(i) * time.Hour), Description: "bench", Amount: money.New(int64(100+i), "USD")}
+		if _, err := repo.Create(b.Context(), exp); err != nil {
+			b.Fatalf("unable to insert expense: %v", err)
+		}
+	}
+
+	return repo
+}
+
+func BenchmarkList(b *testing.B) {
+	repo := setupBenchRepo(b, 10000)
+
+	benchTable := []struct {
+		name        string
+		inputFilter expenses.ListFilter
+	}{
+		{
+			name:        "first-page",
+			inputFilter: expenses.ListFilter{Limit: 50},
+		},
+		{
+			name: "page-after-cursor",
+			inputFilter: expenses.ListFilter{
+				Limit: 50,
+				After: &expenses.Cursor{OccuredAt: time.Unix(1761231600, 0).Add(-5000 * time.Hour), ID: 5007},
+			},
+		},
+		{
+			name: "time-range",
+			inputFilter: expenses.ListFilter{
+				Limit: 50,
+				From:  time.Unix(1761231600, 0).Add(-100 * time.Hour),
+				To:    time.Unix(1761231600, 0).Add(-50 * time.Hour),
+			},
+		},
+	}
+
+	for _, benchCase := range benchTable {
+		b.Run(benchCase.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := repo.List(b.Context(), expenses.Unscoped, benchCase.inputFilter); err != nil {
+					b.Fatalf("List() got error: '%v'", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCreate(b *testing.B) {
+	repo := setupBenchRepo(b, 0)
+	exp := &expenses.Expense{ExpenseOccuredAt: time.Unix(1761231600, 0), Description: "bench", Amount: money.New(1250, "USD")}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := repo.Create(b.Context(), exp); err != nil {
+			b.Fatalf("Create() got error: '%v'", err)
+		}
+	}
+}
+
+func TestEach(t *testing.T) {
+	errStop := errors.New("stop")
+
+	testTable := []struct {
+		name       string
+		inputScope expenses.Scope
+		inputStop  int // stop with errStop after this many expenses, 0 to never stop
+		wantIDs    []int
+		wantErr    error
+	}{
+		{
+			name:       "valid-oldest-first",
+			inputScope: expenses.Unscoped,
+			wantIDs:    []int{6, 5, 4, 3, 2, 1},
+		},
+		{
+			name:       "valid-owner-scope",
+			inputScope: expenses.OwnerScope(1),
+			wantIDs:    []int{3, 2, 1},
+		},
+		{
+			name:       "invalid-stops-at-first-error",
+			inputScope: expenses.Unscoped,
+			inputStop:  2,
+			wantIDs:    []int{6, 5},
+			wantErr:    errStop,
+		},
+	}
+
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+	defer repo.DB.Close()
+
+	setupTestDB(t, repo.DB)
+
+	// first three records belong to user 1, the rest to user 2
+	_, err = repo.DB.Exec(`UPDATE expenses SET owner_id = CASE WHEN id <= 3 THEN 1 ELSE 2 END;`)
+	if err != nil {
+		t.Fatalf("unable to set owners: %v", err)
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			gotIDs := make([]int, 0)
+			gotErr := repo.Each(t.Context(), testCase.inputScope, func(record *expenses.Expense) error {
+				gotIDs = append(gotIDs, record.ID)
+				if len(gotIDs) == testCase.inputStop {
+					return errStop
+				}
+				return nil
+			})
+
+			if !errors.Is(gotErr, testCase.wantErr) {
+				t.Errorf("got error: '%v', want error: '%v'", gotErr, testCase.wantErr)
+			}
+			if !slices.Equal(gotIDs, testCase.wantIDs) {
+				t.Errorf("got ids: %v, want ids: %v", gotIDs, testCase.wantIDs)
+			}
+		})
+	}
+}
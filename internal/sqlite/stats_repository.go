@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+)
+
+// countedTables are the tables on the admin dashboard, the ones that grow with use
+var countedTables = []string{"expenses", "users", "households", "bank_drafts", "audit_events"}
+
+// StatsRepository reads the dashboard numbers, see opstats.Repository
+type StatsRepository struct {
+	DB *sql.DB
+}
+
+func NewStatsRepository(db *sql.DB) *StatsRepository {
+	return &StatsRepository{DB: db}
+}
+
+// RecordCounts counts the rows of each counted table, leaving out any the migrations haven't created
+func (r *StatsRepository) RecordCounts(ctx context.Context) (map[string]int64, error) {
+	existsQuery := `
+  SELECT
+    1
+  FROM
+    sqlite_master
+  WHERE
+    type = 'table' AND name = ?;`
+
+	counts := make(map[string]int64, len(countedTables))
+	for _, table := range countedTables {
+		var exists int
+		err := r.DB.QueryRowContext(ctx, existsQuery, table).Scan(&exists)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, NewQueryError(existsQuery, err)
+		}
+
+		// the table names are constants, never input
+		countQuery := `
+  SELECT
+    COUNT(*)
+  FROM
+    ` + table + `;`
+
+		var count int64
+		if err := r.DB.QueryRowContext(ctx, countQuery).Scan(&count); err != nil {
+			return nil, NewQueryError(countQuery, err)
+		}
+		counts[table] = count
+	}
+
+	return counts, nil
+}
+
+// SizeBytes is the size of the database pages, free ones included, which is the main file without its WAL
+func (r *StatsRepository) SizeBytes(ctx context.Context) (int64, error) {
+	query := `
+  SELECT
+    page_count * page_size
+  FROM
+    pragma_page_count(), pragma_page_size();`
+
+	var size int64
+	if err := r.DB.QueryRowContext(ctx, query).Scan(&size); err != nil {
+		return 0, NewQueryError(query, err)
+	}
+	return size, nil
+}
@@ -0,0 +1,54 @@
+package sqlite_test
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/nicholasss/expense-tracker-api/internal/sqlite"
+)
+
+func TestStatsRepository(t *testing.T) {
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	// every connection to :memory: is a new database
+	repo.DB.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		if err := repo.DB.Close(); err != nil {
+			t.Errorf("unable to close connection to in-memory sqlite database: %v", err)
+		}
+	})
+
+	// only some of the counted tables, as if partly migrated
+	setup := `
+  CREATE TABLE
+    expenses (id INTEGER PRIMARY KEY);
+  CREATE TABLE
+    users (id INTEGER PRIMARY KEY);
+  INSERT INTO
+    expenses (id)
+  VALUES
+    (1), (2), (3);`
+	if _, err := repo.DB.Exec(setup); err != nil {
+		t.Fatalf("unable to create tables: %v", err)
+	}
+
+	stats := sqlite.NewStatsRepository(repo.DB)
+
+	gotCounts, err := stats.RecordCounts(t.Context())
+	if err != nil {
+		t.Fatalf("RecordCounts() got error: %v", err)
+	}
+	if want := map[string]int64{"expenses": 3, "users": 0}; !maps.Equal(gotCounts, want) {
+		t.Errorf("RecordCounts() got: %v, want: %v", gotCounts, want)
+	}
+
+	gotSize, err := stats.SizeBytes(t.Context())
+	if err != nil {
+		t.Fatalf("SizeBytes() got error: %v", err)
+	}
+	if gotSize <= 0 {
+		t.Errorf("SizeBytes() got: %d, want more than 0", gotSize)
+	}
+}
@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// TombstoneRepository prunes the tombstones deletes leave for Changes, sharing the expenses database
+type TombstoneRepository struct {
+	DB     *sql.DB
+	Writer *sql.DB // takes every write, see NewSqliteRepository
+}
+
+func NewTombstoneRepository(db, writer *sql.DB) *TombstoneRepository {
+	return &TombstoneRepository{DB: db, Writer: writer}
+}
+
+// Purge removes the tombstones of expenses deleted before before, implementing retention.Purger.
+// Clients syncing from before then are told to resync, see expenses.WithTombstoneRetention
+func (r *TombstoneRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	query := `
+  DELETE FROM
+    expense_tombstones
+  WHERE
+    deleted_at < ?;`
+
+	res, err := r.Writer.ExecContext(ctx, query, before.Unix())
+	if err != nil {
+		return 0, NewQueryError(query, err)
+	}
+
+	removed, err := res.RowsAffected()
+	return int(removed), err
+}
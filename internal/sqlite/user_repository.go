@@ -0,0 +1,510 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/nicholasss/expense-tracker-api/internal/users"
+)
+
+// sqliteUser has time stored as unix seconds
+type sqliteUser struct {
+	ID              int
+	Email           string
+	Name            string
+	PasswordHash    string
+	IsAdmin         bool
+	TokenGeneration int
+	Locale          string
+	CreatedAt       int64
+}
+
+func toServiceUser(db sqliteUser) *users.User {
+	return &users.User{
+		ID:              db.ID,
+		Email:           db.Email,
+		Name:            db.Name,
+		PasswordHash:    db.PasswordHash,
+		IsAdmin:         db.IsAdmin,
+		TokenGeneration: db.TokenGeneration,
+		Locale:          db.Locale,
+		CreatedAt:       time.Unix(db.CreatedAt, 0),
+	}
+}
+
+// isUniqueViolation reports whether err is from a unique constraint failing
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// isPrimaryKeyViolation reports whether err is from a primary key constraint failing
+func isPrimaryKeyViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+}
+
+// UserRepository implements users.Repository, sharing the expenses database
+type UserRepository struct {
+	DB     *sql.DB
+	Writer *sql.DB // takes every write, see NewSqliteRepository
+}
+
+func NewUserRepository(db, writer *sql.DB) *UserRepository {
+	return &UserRepository{DB: db, Writer: writer}
+}
+
+// Create creates a new user and returns it with id and createdAt
+func (r *UserRepository) Create(ctx context.Context, user *users.User) (*users.User, error) {
+	if user == nil {
+		return nil, users.ErrNilPointer
+	}
+
+	query := `
+  INSERT INTO
+    users
+      (
+        email,
+        name,
+        password_hash,
+        created_at
+      )
+  VALUES
+    (
+      ?,
+      ?,
+      ?,
+      unixepoch()
+    )
+  RETURNING
+    id, email, name, password_hash, is_admin, token_generation, locale, created_at;`
+
+	row := r.Writer.QueryRowContext(ctx, query, user.Email, user.Name, user.PasswordHash)
+
+	var dbU sqliteUser
+	err := row.Scan(&dbU.ID, &dbU.Email, &dbU.Name, &dbU.PasswordHash, &dbU.IsAdmin, &dbU.TokenGeneration, &dbU.Locale, &dbU.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, users.ErrEmailTaken
+		}
+		return nil, NewQueryError(query, err)
+	}
+
+	return toServiceUser(dbU), nil
+}
+
+// GetByID finds a particular user with an id
+func (r *UserRepository) GetByID(ctx context.Context, id int) (*users.User, error) {
+	query := `
+  SELECT
+    id, email, name, password_hash, is_admin, token_generation, locale, created_at
+  FROM
+    users
+  WHERE
+    id = ?;`
+
+	return r.getOne(ctx, query, id)
+}
+
+// GetByEmail finds a particular user with a lowercase email
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*users.User, error) {
+	query := `
+  SELECT
+    id, email, name, password_hash, is_admin, token_generation, locale, created_at
+  FROM
+    users
+  WHERE
+    email = ?;`
+
+	return r.getOne(ctx, query, email)
+}
+
+// getOne scans a single user row, mapping no rows to users.ErrUserNotFound
+func (r *UserRepository) getOne(ctx context.Context, query string, arg any) (*users.User, error) {
+	var dbU sqliteUser
+
+	row := r.DB.QueryRowContext(ctx, query, arg)
+	err := row.Scan(&dbU.ID, &dbU.Email, &dbU.Name, &dbU.PasswordHash, &dbU.IsAdmin, &dbU.TokenGeneration, &dbU.Locale, &dbU.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, users.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+
+	return toServiceUser(dbU), nil
+}
+
+// GetByIdentity finds the user linked to an external issuer and subject
+func (r *UserRepository) GetByIdentity(ctx context.Context, issuer, subject string) (*users.User, error) {
+	var dbU sqliteUser
+
+	query := `
+  SELECT
+    u.id, u.email, u.name, u.password_hash, u.is_admin, u.token_generation, u.locale, u.created_at
+  FROM
+    users u
+  JOIN
+    user_identities i ON i.user_id = u.id
+  WHERE
+    i.issuer = ? AND i.subject = ?;`
+
+	row := r.DB.QueryRowContext(ctx, query, issuer, subject)
+	err := row.Scan(&dbU.ID, &dbU.Email, &dbU.Name, &dbU.PasswordHash, &dbU.IsAdmin, &dbU.TokenGeneration, &dbU.Locale, &dbU.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, users.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+
+	return toServiceUser(dbU), nil
+}
+
+// LinkIdentity links an external issuer and subject to a user
+func (r *UserRepository) LinkIdentity(ctx context.Context, userID int, issuer, subject string) error {
+	query := `
+  INSERT INTO
+    user_identities
+      (
+        issuer,
+        subject,
+        user_id,
+        created_at
+      )
+  VALUES
+    (
+      ?,
+      ?,
+      ?,
+      unixepoch()
+    );`
+
+	_, err := r.Writer.ExecContext(ctx, query, issuer, subject, userID)
+	if err != nil {
+		return NewQueryError(query, err)
+	}
+	return nil
+}
+
+// ListIdentities lists the external identities linked to a user, oldest first
+func (r *UserRepository) ListIdentities(ctx context.Context, userID int) ([]*users.Identity, error) {
+	query := `
+  SELECT
+    issuer, subject, created_at
+  FROM
+    user_identities
+  WHERE
+    user_id = ?
+  ORDER BY
+    created_at, issuer, subject;`
+
+	rows, err := r.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+
+	// deferred but still checking error
+	defer func() {
+		closeErr := rows.Close()
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close query rows: %w", closeErr)
+		}
+	}()
+
+	identities := make([]*users.Identity, 0)
+	for rows.Next() {
+		var identity users.Identity
+		var createdAt sql.NullInt64
+		err = rows.Scan(&identity.Issuer, &identity.Subject, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+		identity.CreatedAt = time.Unix(createdAt.Int64, 0)
+
+		identities = append(identities, &identity)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+// Update saves the email, name, password hash, and locale of an existing user
+func (r *UserRepository) Update(ctx context.Context, user *users.User) (*users.User, error) {
+	if user == nil {
+		return nil, users.ErrNilPointer
+	}
+
+	query := `
+  UPDATE
+    users
+  SET
+    email = ?,
+    name = ?,
+    password_hash = ?,
+    locale = ?
+  WHERE
+    id = ?
+  RETURNING
+    id, email, name, password_hash, is_admin, token_generation, locale, created_at;`
+
+	row := r.Writer.QueryRowContext(ctx, query, user.Email, user.Name, user.PasswordHash, user.Locale, user.ID)
+
+	var dbU sqliteUser
+	err := row.Scan(&dbU.ID, &dbU.Email, &dbU.Name, &dbU.PasswordHash, &dbU.IsAdmin, &dbU.TokenGeneration, &dbU.Locale, &dbU.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, users.ErrUserNotFound
+	}
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, users.ErrEmailTaken
+		}
+		return nil, NewQueryError(query, err)
+	}
+
+	return toServiceUser(dbU), nil
+}
+
+// RevokeTokens bumps the token generation in place, so concurrent revocations are never lost
+func (r *UserRepository) RevokeTokens(ctx context.Context, id int) (int, error) {
+	query := `
+  UPDATE
+    users
+  SET
+    token_generation = token_generation + 1
+  WHERE
+    id = ?
+  RETURNING
+    token_generation;`
+
+	var generation int
+	err := r.Writer.QueryRowContext(ctx, query, id).Scan(&generation)
+	if err == sql.ErrNoRows {
+		return 0, users.ErrUserNotFound
+	}
+	if err != nil {
+		return 0, NewQueryError(query, err)
+	}
+
+	return generation, nil
+}
+
+// CreateEmailChange stores a pending change, a user only has one at a time
+func (r *UserRepository) CreateEmailChange(ctx context.Context, change *users.EmailChange) error {
+	if change == nil {
+		return users.ErrNilPointer
+	}
+
+	query := `
+  INSERT OR REPLACE INTO
+    email_changes
+      (
+        user_id,
+        token_hash,
+        new_email,
+        expires_at
+      )
+  VALUES
+    (
+      ?,
+      ?,
+      ?,
+      ?
+    );`
+
+	_, err := r.Writer.ExecContext(ctx, query, change.UserID, change.TokenHash, change.NewEmail, change.ExpiresAt.Unix())
+	if err != nil {
+		return NewQueryError(query, err)
+	}
+	return nil
+}
+
+// TakeEmailChange deletes and returns the change for a token hash, so a token can only be used once
+func (r *UserRepository) TakeEmailChange(ctx context.Context, tokenHash string) (*users.EmailChange, error) {
+	query := `
+  DELETE FROM
+    email_changes
+  WHERE
+    token_hash = ?
+  RETURNING
+    user_id, token_hash, new_email, expires_at;`
+
+	var change users.EmailChange
+	var expiresAt int64
+	err := r.Writer.QueryRowContext(ctx, query, tokenHash).Scan(&change.UserID, &change.TokenHash, &change.NewEmail, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, users.ErrInvalidEmailChange
+	}
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+	change.ExpiresAt = time.Unix(expiresAt, 0)
+
+	return &change, nil
+}
+
+// GetTOTP finds the user's authenticator, mapping no rows to users.ErrTOTPNotEnrolled
+func (r *UserRepository) GetTOTP(ctx context.Context, userID int) (*users.TOTP, error) {
+	query := `
+  SELECT
+    user_id, secret, enabled, last_step
+  FROM
+    user_totp
+  WHERE
+    user_id = ?;`
+
+	var enrollment users.TOTP
+	err := r.DB.QueryRowContext(ctx, query, userID).Scan(&enrollment.UserID, &enrollment.Secret, &enrollment.Enabled, &enrollment.LastStep)
+	if err == sql.ErrNoRows {
+		return nil, users.ErrTOTPNotEnrolled
+	}
+	if err != nil {
+		return nil, NewQueryError(query, err)
+	}
+
+	return &enrollment, nil
+}
+
+// SaveTOTP stores the user's authenticator, a user only has one at a time
+func (r *UserRepository) SaveTOTP(ctx context.Context, enrollment *users.TOTP) error {
+	if enrollment == nil {
+		return users.ErrNilPointer
+	}
+
+	query := `
+  INSERT OR REPLACE INTO
+    user_totp
+      (
+        user_id,
+        secret,
+        enabled,
+        last_step,
+        created_at
+      )
+  VALUES
+    (
+      ?,
+      ?,
+      ?,
+      ?,
+      unixepoch()
+    );`
+
+	_, err := r.Writer.ExecContext(ctx, query, enrollment.UserID, enrollment.Secret, enrollment.Enabled, enrollment.LastStep)
+	if err != nil {
+		return NewQueryError(query, err)
+	}
+	return nil
+}
+
+// UseTOTPStep moves the last step forward in place, so two requests can't both use the same code
+func (r *UserRepository) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	query := `
+  UPDATE
+    user_totp
+  SET
+    last_step = ?
+  WHERE
+    user_id = ? AND last_step < ?;`
+
+	result, err := r.Writer.ExecContext(ctx, query, step, userID, step)
+	if err != nil {
+		return false, NewQueryError(query, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// DeleteTOTP removes the authenticator and recovery codes together
+func (r *UserRepository) DeleteTOTP(ctx context.Context, userID int) error {
+	tx, err := r.Writer.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, query := range []string{`
+  DELETE FROM
+    user_recovery_codes
+  WHERE
+    user_id = ?;`, `
+  DELETE FROM
+    user_totp
+  WHERE
+    user_id = ?;`} {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return NewQueryError(query, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ReplaceRecoveryCodes swaps out every recovery code in one transaction, so old codes never linger
+func (r *UserRepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	tx, err := r.Writer.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	deleteQuery := `
+  DELETE FROM
+    user_recovery_codes
+  WHERE
+    user_id = ?;`
+	if _, err := tx.ExecContext(ctx, deleteQuery, userID); err != nil {
+		return NewQueryError(deleteQuery, err)
+	}
+
+	insertQuery := `
+  INSERT INTO
+    user_recovery_codes
+      (
+        user_id,
+        code_hash
+      )
+  VALUES
+    (
+      ?,
+      ?
+    );`
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx, insertQuery, userID, hash); err != nil {
+			return NewQueryError(insertQuery, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UseRecoveryCode deletes a recovery code, so it only works once
+func (r *UserRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	query := `
+  DELETE FROM
+    user_recovery_codes
+  WHERE
+    user_id = ? AND code_hash = ?;`
+
+	result, err := r.Writer.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, NewQueryError(query, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
@@ -0,0 +1,451 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/sqlite"
+	"github.com/nicholasss/expense-tracker-api/internal/users"
+)
+
+func setupUserTestDB(t *testing.T, db *sql.DB) {
+	t.Helper()
+
+	createQuery := `
+  CREATE TABLE
+    users (
+      id INTEGER PRIMARY KEY,
+      email TEXT NOT NULL UNIQUE,
+      name TEXT NOT NULL,
+      password_hash TEXT NOT NULL,
+      is_admin INTEGER NOT NULL DEFAULT 0,
+      token_generation INTEGER NOT NULL DEFAULT 0,
+      locale TEXT NOT NULL DEFAULT '',
+      created_at INTEGER
+    );
+  CREATE TABLE
+    user_identities (
+      issuer TEXT NOT NULL,
+      subject TEXT NOT NULL,
+      user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+      created_at INTEGER,
+      PRIMARY KEY (issuer, subject)
+    );
+  CREATE TABLE
+    email_changes (
+      user_id INTEGER PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+      token_hash TEXT NOT NULL UNIQUE,
+      new_email TEXT NOT NULL,
+      expires_at INTEGER NOT NULL
+    );
+  CREATE TABLE
+    user_totp (
+      user_id INTEGER PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+      secret TEXT NOT NULL,
+      enabled INTEGER NOT NULL DEFAULT 0,
+      last_step INTEGER NOT NULL DEFAULT 0,
+      created_at INTEGER NOT NULL
+    );
+  CREATE TABLE
+    user_recovery_codes (
+      user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+      code_hash TEXT NOT NULL,
+      PRIMARY KEY (user_id, code_hash)
+    );`
+	_, err := db.Exec(createQuery)
+	if err != nil {
+		t.Fatalf("unable to create table: %v", err)
+	}
+
+	insertQuery := `
+  INSERT INTO
+    users
+      (
+        email,
+        name,
+        password_hash,
+        created_at
+      )
+  VALUES
+    (
+      "ada@example.com",
+      "Ada",
+      "not-a-real-hash",
+      unixepoch()
+    );`
+	_, err = db.Exec(insertQuery)
+	if err != nil {
+		t.Fatalf("unable to insert test data: %v", err)
+	}
+}
+
+func TestUserCreate(t *testing.T) {
+	testTable := []struct {
+		name        string
+		inputUser   *users.User
+		expectError bool
+		wantError   error
+		wantID      int
+	}{
+		{
+			name:        "valid-new-user",
+			inputUser:   &users.User{Email: "grace@example.com", Name: "Grace", PasswordHash: "hash"},
+			expectError: false,
+			wantError:   nil,
+			wantID:      2,
+		},
+		{
+			name:        "invalid-duplicate-email",
+			inputUser:   &users.User{Email: "ada@example.com", Name: "Ada Again", PasswordHash: "hash"},
+			expectError: true,
+			wantError:   users.ErrEmailTaken,
+		},
+		{
+			name:        "invalid-nil-user",
+			inputUser:   nil,
+			expectError: true,
+			wantError:   users.ErrNilPointer,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo, err := sqlite.NewSqliteRepository(database, dbString)
+			if err != nil {
+				t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+			}
+			userRepo := sqlite.NewUserRepository(repo.DB, repo.Writer)
+
+			setupUserTestDB(t, repo.DB)
+
+			// defer teardown
+			defer func() {
+				err := repo.DB.Close()
+				if err != nil {
+					t.Errorf("unable to close connection to in-memory sqlite database: %v", err)
+				}
+			}()
+
+			// call the function
+			gotUser, gotErr := userRepo.Create(t.Context(), testCase.inputUser)
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("Create() got error: '%v', expected error: '%v'", gotErr, testCase.wantError)
+			}
+
+			// checking error type if its not nil
+			if gotErr != nil {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+				return
+			}
+
+			if gotUser.ID != testCase.wantID {
+				t.Errorf("got id: %d, want id: %d", gotUser.ID, testCase.wantID)
+			}
+
+			// reading it back by email
+			byEmail, err := userRepo.GetByEmail(t.Context(), testCase.inputUser.Email)
+			if err != nil || byEmail.ID != testCase.wantID {
+				t.Errorf("GetByEmail() got: %+v, error: '%v'", byEmail, err)
+			}
+		})
+	}
+}
+
+func TestUserGetByID(t *testing.T) {
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	userRepo := sqlite.NewUserRepository(repo.DB, repo.Writer)
+
+	setupUserTestDB(t, repo.DB)
+
+	// defer teardown
+	defer func() {
+		err := repo.DB.Close()
+		if err != nil {
+			t.Errorf("unable to close connection to in-memory sqlite database: %v", err)
+		}
+	}()
+
+	user, err := userRepo.GetByID(t.Context(), 1)
+	if err != nil || user.Email != "ada@example.com" {
+		t.Errorf("GetByID(1) got: %+v, error: '%v'", user, err)
+	}
+
+	_, err = userRepo.GetByID(t.Context(), 12)
+	if !errors.Is(err, users.ErrUserNotFound) {
+		t.Errorf("GetByID(12) got error: '%v', want error: '%v'", err, users.ErrUserNotFound)
+	}
+}
+
+func TestUserIdentity(t *testing.T) {
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	userRepo := sqlite.NewUserRepository(repo.DB, repo.Writer)
+
+	setupUserTestDB(t, repo.DB)
+
+	// defer teardown
+	defer func() {
+		err := repo.DB.Close()
+		if err != nil {
+			t.Errorf("unable to close connection to in-memory sqlite database: %v", err)
+		}
+	}()
+
+	issuer := "https://accounts.example.com"
+
+	_, err = userRepo.GetByIdentity(t.Context(), issuer, "ada-sub")
+	if !errors.Is(err, users.ErrUserNotFound) {
+		t.Errorf("GetByIdentity() before linking got error: '%v', want error: '%v'", err, users.ErrUserNotFound)
+	}
+
+	if err := userRepo.LinkIdentity(t.Context(), 1, issuer, "ada-sub"); err != nil {
+		t.Fatalf("LinkIdentity() got error: '%v'", err)
+	}
+
+	user, err := userRepo.GetByIdentity(t.Context(), issuer, "ada-sub")
+	if err != nil || user.ID != 1 {
+		t.Errorf("GetByIdentity() got: %+v, error: '%v'", user, err)
+	}
+
+	identities, err := userRepo.ListIdentities(t.Context(), 1)
+	if err != nil || len(identities) != 1 || identities[0].Issuer != issuer || identities[0].Subject != "ada-sub" {
+		t.Errorf("ListIdentities() got: %+v, error: '%v'", identities, err)
+	}
+
+	// the same subject from another issuer is a different identity
+	_, err = userRepo.GetByIdentity(t.Context(), "https://other.example.com", "ada-sub")
+	if !errors.Is(err, users.ErrUserNotFound) {
+		t.Errorf("GetByIdentity() other issuer got error: '%v', want error: '%v'", err, users.ErrUserNotFound)
+	}
+}
+
+func TestUserUpdate(t *testing.T) {
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	userRepo := sqlite.NewUserRepository(repo.DB, repo.Writer)
+
+	setupUserTestDB(t, repo.DB)
+
+	// defer teardown
+	defer func() {
+		err := repo.DB.Close()
+		if err != nil {
+			t.Errorf("unable to close connection to in-memory sqlite database: %v", err)
+		}
+	}()
+
+	other, err := userRepo.Create(t.Context(), &users.User{Email: "grace@example.com", Name: "Grace", PasswordHash: "not-a-real-hash"})
+	if err != nil {
+		t.Fatalf("Create() got error: '%v'", err)
+	}
+
+	testTable := []struct {
+		name        string
+		inputUser   *users.User
+		expectError bool
+		wantError   error
+	}{
+		{
+			name:        "valid-update",
+			inputUser:   &users.User{ID: 1, Email: "ada@example.org", Name: "Ada L", PasswordHash: "another-hash", Locale: "de"},
+			expectError: false,
+			wantError:   nil,
+		},
+		{
+			name:        "invalid-email-taken",
+			inputUser:   &users.User{ID: 1, Email: other.Email, Name: "Ada", PasswordHash: "another-hash"},
+			expectError: true,
+			wantError:   users.ErrEmailTaken,
+		},
+		{
+			name:        "invalid-missing-user",
+			inputUser:   &users.User{ID: 99, Email: "nobody@example.com", Name: "Nobody"},
+			expectError: true,
+			wantError:   users.ErrUserNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			gotUser, gotErr := userRepo.Update(t.Context(), testCase.inputUser)
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("Update() got error: '%v', expected error: '%v'", gotErr, testCase.wantError)
+			}
+
+			// checking error type if its not nil
+			if gotErr != nil {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+				return
+			}
+
+			if gotUser.Email != testCase.inputUser.Email || gotUser.Name != testCase.inputUser.Name ||
+				gotUser.PasswordHash != testCase.inputUser.PasswordHash || gotUser.Locale != testCase.inputUser.Locale {
+				t.Errorf("Update() got: %+v, want: %+v", gotUser, testCase.inputUser)
+			}
+		})
+	}
+}
+
+func TestUserEmailChange(t *testing.T) {
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	userRepo := sqlite.NewUserRepository(repo.DB, repo.Writer)
+
+	setupUserTestDB(t, repo.DB)
+
+	// defer teardown
+	defer func() {
+		err := repo.DB.Close()
+		if err != nil {
+			t.Errorf("unable to close connection to in-memory sqlite database: %v", err)
+		}
+	}()
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	for _, hash := range []string{"first-hash", "second-hash"} {
+		err := userRepo.CreateEmailChange(t.Context(), &users.EmailChange{
+			TokenHash: hash, UserID: 1, NewEmail: "ada@example.org", ExpiresAt: expiresAt,
+		})
+		if err != nil {
+			t.Fatalf("CreateEmailChange(%q) got error: '%v'", hash, err)
+		}
+	}
+
+	// a new request replaces the earlier one
+	if _, err := userRepo.TakeEmailChange(t.Context(), "first-hash"); !errors.Is(err, users.ErrInvalidEmailChange) {
+		t.Errorf("TakeEmailChange(first) got error: '%v', want error: '%v'", err, users.ErrInvalidEmailChange)
+	}
+
+	change, err := userRepo.TakeEmailChange(t.Context(), "second-hash")
+	if err != nil {
+		t.Fatalf("TakeEmailChange(second) got error: '%v'", err)
+	}
+	if change.UserID != 1 || change.NewEmail != "ada@example.org" || !change.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("TakeEmailChange(second) got: %+v", change)
+	}
+
+	// tokens are single use
+	if _, err := userRepo.TakeEmailChange(t.Context(), "second-hash"); !errors.Is(err, users.ErrInvalidEmailChange) {
+		t.Errorf("TakeEmailChange(second) again got error: '%v', want error: '%v'", err, users.ErrInvalidEmailChange)
+	}
+}
+
+func TestUserRevokeTokens(t *testing.T) {
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	userRepo := sqlite.NewUserRepository(repo.DB, repo.Writer)
+
+	setupUserTestDB(t, repo.DB)
+
+	// defer teardown
+	defer func() {
+		err := repo.DB.Close()
+		if err != nil {
+			t.Errorf("unable to close connection to in-memory sqlite database: %v", err)
+		}
+	}()
+
+	for want := 1; want <= 2; want++ {
+		got, err := userRepo.RevokeTokens(t.Context(), 1)
+		if err != nil || got != want {
+			t.Errorf("RevokeTokens() got: %d, error: '%v', want: %d", got, err, want)
+		}
+	}
+
+	user, err := userRepo.GetByID(t.Context(), 1)
+	if err != nil || user.TokenGeneration != 2 {
+		t.Errorf("GetByID() got: %+v, error: '%v', want generation 2", user, err)
+	}
+
+	if _, err := userRepo.RevokeTokens(t.Context(), 99); !errors.Is(err, users.ErrUserNotFound) {
+		t.Errorf("RevokeTokens(99) got error: '%v', want error: '%v'", err, users.ErrUserNotFound)
+	}
+}
+
+func TestUserTOTP(t *testing.T) {
+	repo, err := sqlite.NewSqliteRepository(database, dbString)
+	if err != nil {
+		t.Fatalf("failed to setup in-memory sqlite3 db due to: %v", err)
+	}
+	userRepo := sqlite.NewUserRepository(repo.DB, repo.Writer)
+
+	setupUserTestDB(t, repo.DB)
+
+	// defer teardown
+	defer func() {
+		err := repo.DB.Close()
+		if err != nil {
+			t.Errorf("unable to close connection to in-memory sqlite database: %v", err)
+		}
+	}()
+
+	if _, err := userRepo.GetTOTP(t.Context(), 1); !errors.Is(err, users.ErrTOTPNotEnrolled) {
+		t.Errorf("GetTOTP() before setup got error: '%v', want error: '%v'", err, users.ErrTOTPNotEnrolled)
+	}
+
+	err = userRepo.SaveTOTP(t.Context(), &users.TOTP{UserID: 1, Secret: "SECRET", Enabled: true, LastStep: 10})
+	if err != nil {
+		t.Fatalf("SaveTOTP() got error: '%v'", err)
+	}
+	enrollment, err := userRepo.GetTOTP(t.Context(), 1)
+	if err != nil || enrollment.Secret != "SECRET" || !enrollment.Enabled || enrollment.LastStep != 10 {
+		t.Errorf("GetTOTP() got: %+v, error: '%v'", enrollment, err)
+	}
+
+	// steps only move forward
+	for _, step := range []struct {
+		step int64
+		want bool
+	}{{10, false}, {9, false}, {11, true}, {11, false}} {
+		if got, err := userRepo.UseTOTPStep(t.Context(), 1, step.step); err != nil || got != step.want {
+			t.Errorf("UseTOTPStep(%d) got: %v, error: '%v', want: %v", step.step, got, err, step.want)
+		}
+	}
+
+	// replacing drops the earlier codes
+	if err := userRepo.ReplaceRecoveryCodes(t.Context(), 1, []string{"old-a", "old-b"}); err != nil {
+		t.Fatalf("ReplaceRecoveryCodes() got error: '%v'", err)
+	}
+	if err := userRepo.ReplaceRecoveryCodes(t.Context(), 1, []string{"new-a", "new-b"}); err != nil {
+		t.Fatalf("ReplaceRecoveryCodes() got error: '%v'", err)
+	}
+	for _, code := range []struct {
+		hash string
+		want bool
+	}{{"old-a", false}, {"new-a", true}, {"new-a", false}} {
+		if got, err := userRepo.UseRecoveryCode(t.Context(), 1, code.hash); err != nil || got != code.want {
+			t.Errorf("UseRecoveryCode(%q) got: %v, error: '%v', want: %v", code.hash, got, err, code.want)
+		}
+	}
+
+	if err := userRepo.DeleteTOTP(t.Context(), 1); err != nil {
+		t.Fatalf("DeleteTOTP() got error: '%v'", err)
+	}
+	if _, err := userRepo.GetTOTP(t.Context(), 1); !errors.Is(err, users.ErrTOTPNotEnrolled) {
+		t.Errorf("GetTOTP() after delete got error: '%v', want error: '%v'", err, users.ErrTOTPNotEnrolled)
+	}
+	if got, err := userRepo.UseRecoveryCode(t.Context(), 1, "new-b"); err != nil || got {
+		t.Errorf("UseRecoveryCode() after delete got: %v, error: '%v', want: false", got, err)
+	}
+}
@@ -0,0 +1,89 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/totp"
+)
+
+// rfcSecret is the SHA1 seed from RFC 6238 appendix B, "12345678901234567890"
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// RFC 6238 appendix B, the last 6 of the 8 digit codes
+	testTable := []struct {
+		name      string
+		inputTime int64
+		wantCode  string
+	}{
+		{name: "valid-rfc-59", inputTime: 59, wantCode: "287082"},
+		{name: "valid-rfc-1111111109", inputTime: 1111111109, wantCode: "081804"},
+		{name: "valid-rfc-1234567890", inputTime: 1234567890, wantCode: "005924"},
+		{name: "valid-rfc-2000000000", inputTime: 2000000000, wantCode: "279037"},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := totp.Code(rfcSecret, totp.Step(time.Unix(testCase.inputTime, 0)))
+			if err != nil {
+				t.Fatalf("Code() got error: '%v'", err)
+			}
+			if got != testCase.wantCode {
+				t.Errorf("Code() got: %q, want: %q", got, testCase.wantCode)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current, _ := totp.Code(rfcSecret, totp.Step(now))
+	previous, _ := totp.Code(rfcSecret, totp.Step(now)-1)
+	stale, _ := totp.Code(rfcSecret, totp.Step(now)-5)
+
+	testTable := []struct {
+		name      string
+		inputCode string
+		wantOK    bool
+		wantStep  int64
+	}{
+		{name: "valid-current", inputCode: current, wantOK: true, wantStep: totp.Step(now)},
+		{name: "valid-previous-step", inputCode: previous, wantOK: true, wantStep: totp.Step(now) - 1},
+		{name: "valid-with-spaces", inputCode: current[:3] + " " + current[3:], wantOK: true, wantStep: totp.Step(now)},
+		{name: "invalid-stale", inputCode: stale, wantOK: false},
+		{name: "invalid-length", inputCode: "12345", wantOK: false},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			step, ok := totp.Validate(rfcSecret, testCase.inputCode, now)
+			if ok != testCase.wantOK {
+				t.Fatalf("Validate() got ok: %v, want: %v", ok, testCase.wantOK)
+			}
+			if ok && step != testCase.wantStep {
+				t.Errorf("Validate() got step: %d, want: %d", step, testCase.wantStep)
+			}
+		})
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() got error: '%v'", err)
+	}
+
+	got, err := url.Parse(totp.ProvisioningURI("expense-tracker-api", "ada@example.com", secret))
+	if err != nil {
+		t.Fatalf("unable to parse uri: %v", err)
+	}
+	if got.Scheme != "otpauth" || got.Host != "totp" || got.Path != "/expense-tracker-api:ada@example.com" {
+		t.Errorf("unexpected uri: %s", got)
+	}
+	if got.Query().Get("secret") != secret || got.Query().Get("issuer") != "expense-tracker-api" {
+		t.Errorf("unexpected query: %s", got.RawQuery)
+	}
+}
@@ -0,0 +1,11 @@
+package users
+
+import (
+	"context"
+)
+
+// Mailer delivers account emails
+type Mailer interface {
+	// send the token that confirms changing to the address to
+	SendEmailChange(ctx context.Context, to, token string) error
+}
@@ -0,0 +1,53 @@
+package users
+
+import "context"
+
+type Repository interface {
+	// create a new user, returning ErrEmailTaken if the email is in use
+	Create(ctx context.Context, user *User) (*User, error)
+
+	// get one user by ID
+	GetByID(ctx context.Context, id int) (*User, error)
+
+	// get one user by their lowercase email
+	GetByEmail(ctx context.Context, email string) (*User, error)
+
+	// get the user linked to an external identity
+	GetByIdentity(ctx context.Context, issuer, subject string) (*User, error)
+
+	// link an external identity to a user
+	LinkIdentity(ctx context.Context, userID int, issuer, subject string) error
+
+	// list the external identities linked to a user, oldest first
+	ListIdentities(ctx context.Context, userID int) ([]*Identity, error)
+
+	// update the email, name, and password hash. ErrEmailTaken if the email is in use
+	Update(ctx context.Context, user *User) (*User, error)
+
+	// bump the user's token generation, revoking every token issued so far. Returns the new generation
+	RevokeTokens(ctx context.Context, id int) (int, error)
+
+	// store a pending email change, replacing any earlier one for the same user
+	CreateEmailChange(ctx context.Context, change *EmailChange) error
+
+	// remove and return the pending change for a token hash, ErrInvalidEmailChange when there is none
+	TakeEmailChange(ctx context.Context, tokenHash string) (*EmailChange, error)
+
+	// get the user's authenticator, ErrTOTPNotEnrolled when there is none
+	GetTOTP(ctx context.Context, userID int) (*TOTP, error)
+
+	// store the user's authenticator, replacing any earlier one
+	SaveTOTP(ctx context.Context, totp *TOTP) error
+
+	// record a code's time step as used, false when it is not after the last step used
+	UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error)
+
+	// remove the user's authenticator and recovery codes
+	DeleteTOTP(ctx context.Context, userID int) error
+
+	// replace every recovery code the user has with these hashes
+	ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error
+
+	// remove a recovery code, false when the user does not have it
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+}
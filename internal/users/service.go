@@ -0,0 +1,366 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/i18n"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// minPasswordLength is the shortest password Register() accepts
+const minPasswordLength = 8
+
+// emailChangeTTL is how long the new address has to confirm an email change
+const emailChangeTTL = 24 * time.Hour
+
+// Service defines an interface for the users business layer.
+//
+// This is primarily implemented for easier mocking for testing.
+type Service interface {
+	Register(ctx context.Context, email, name, password string) (*User, error)
+
+	Login(ctx context.Context, email, password, code string) (*User, error)
+
+	LoginExternal(ctx context.Context, identity ExternalIdentity) (*User, error)
+
+	GetProfile(ctx context.Context, id int) (*User, error)
+
+	UpdateProfile(ctx context.Context, id int, update ProfileUpdate) (*User, error)
+
+	ChangePassword(ctx context.Context, id int, currentPassword, newPassword string) error
+
+	RequestEmailChange(ctx context.Context, id int, currentPassword, newEmail string) error
+
+	ConfirmEmailChange(ctx context.Context, token string) (*User, error)
+
+	RevokeTokens(ctx context.Context, id int) error
+
+	TokenGeneration(ctx context.Context, id int) (int, error)
+
+	Identities(ctx context.Context, id int) ([]*Identity, error)
+
+	SetupTOTP(ctx context.Context, id int, currentPassword string) (*TOTPSetup, error)
+
+	EnableTOTP(ctx context.Context, id int, code string) ([]string, error)
+
+	DisableTOTP(ctx context.Context, id int, currentPassword, code string) error
+
+	RegenerateRecoveryCodes(ctx context.Context, id int, code string) ([]string, error)
+}
+
+// ExternalIdentity is who an external provider, such as OIDC, says the user is
+type ExternalIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// UserService handles password hashing and account validation
+type UserService struct {
+	repo   Repository
+	mailer Mailer
+}
+
+// Option configures optional parts of the UserService
+type Option func(*UserService)
+
+// WithMailer sends account emails with m, without one users can't change their email address
+func WithMailer(m Mailer) Option {
+	return func(s *UserService) {
+		s.mailer = m
+	}
+}
+
+func NewService(repo Repository, opts ...Option) *UserService {
+	s := &UserService{repo: repo}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// normalizeEmail lowercases and validates an email address
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", ErrInvalidEmail
+	}
+	return email, nil
+}
+
+// checkPassword is to ensure that a new password is long enough
+func checkPassword(password string) error {
+	if len(password) < minPasswordLength {
+		return ErrWeakPassword
+	}
+	return nil
+}
+
+func (s *UserService) Register(ctx context.Context, email, name, password string) (*User, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrEmptyName
+	}
+
+	if err := checkPassword(password); err != nil {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &User{
+		Email:        email,
+		Name:         name,
+		PasswordHash: string(hash),
+	}
+
+	return s.repo.Create(ctx, user)
+}
+
+// Login checks the password against the stored hash, returning the user on success.
+// Users with two-factor enabled also need a code, either from their authenticator or a recovery code.
+// The code is only checked after the password, so it doesn't reveal who has two-factor.
+func (s *UserService) Login(ctx context.Context, email, password, code string) (*User, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := s.checkSecondFactor(ctx, user.ID, code); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// LoginExternal maps an external identity to a local user.
+// Known identities log straight in, otherwise a verified email is linked to the matching user,
+// or a new user is created without a password.
+// Two-factor is left to the external provider, so it is not checked here.
+func (s *UserService) LoginExternal(ctx context.Context, identity ExternalIdentity) (*User, error) {
+	user, err := s.repo.GetByIdentity(ctx, identity.Issuer, identity.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	// first time seeing this identity
+	if !identity.EmailVerified {
+		return nil, ErrUnverifiedEmail
+	}
+	email, err := normalizeEmail(identity.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err = s.repo.GetByEmail(ctx, email)
+	if errors.Is(err, ErrUserNotFound) {
+		name := strings.TrimSpace(identity.Name)
+		if name == "" {
+			name, _, _ = strings.Cut(email, "@")
+		}
+		user, err = s.repo.Create(ctx, &User{Email: email, Name: name})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.LinkIdentity(ctx, user.ID, identity.Issuer, identity.Subject); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *UserService) GetProfile(ctx context.Context, id int) (*User, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// UpdateProfile changes the display name and the locale exports are formatted for
+func (s *UserService) UpdateProfile(ctx context.Context, id int, update ProfileUpdate) (*User, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" {
+			return nil, ErrEmptyName
+		}
+		user.Name = name
+	}
+	if update.Locale != nil {
+		locale := strings.ToLower(strings.TrimSpace(*update.Locale))
+		if locale != "" && !slices.Contains(i18n.Locales(), locale) {
+			return nil, ErrInvalidLocale
+		}
+		user.Locale = locale
+	}
+
+	return s.repo.Update(ctx, user)
+}
+
+// verifyPassword loads the user and checks their current password
+func (s *UserService) verifyPassword(ctx context.Context, id int, password string) (*User, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+	return user, nil
+}
+
+// ChangePassword replaces the password once the current one is verified.
+// Every existing token is revoked, in case the old password was how someone got one.
+func (s *UserService) ChangePassword(ctx context.Context, id int, currentPassword, newPassword string) error {
+	if err := checkPassword(newPassword); err != nil {
+		return err
+	}
+
+	user, err := s.verifyPassword(ctx, id, currentPassword)
+	if err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = string(hash)
+
+	if _, err := s.repo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	_, err = s.repo.RevokeTokens(ctx, id)
+	return err
+}
+
+// hashToken is how email change tokens are stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// RequestEmailChange sends a confirmation token to the new address.
+// The email only changes once ConfirmEmailChange() is called with that token.
+func (s *UserService) RequestEmailChange(ctx context.Context, id int, currentPassword, newEmail string) error {
+	if s.mailer == nil {
+		return ErrEmailChangeDisabled
+	}
+
+	newEmail, err := normalizeEmail(newEmail)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.verifyPassword(ctx, id, currentPassword)
+	if err != nil {
+		return err
+	}
+
+	// checked again on confirm, this just fails early
+	if _, err := s.repo.GetByEmail(ctx, newEmail); err == nil {
+		return ErrEmailTaken
+	} else if !errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	err = s.repo.CreateEmailChange(ctx, &EmailChange{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		NewEmail:  newEmail,
+		ExpiresAt: time.Now().Add(emailChangeTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.SendEmailChange(ctx, newEmail, token)
+}
+
+// ConfirmEmailChange applies a pending email change, each token works once
+func (s *UserService) ConfirmEmailChange(ctx context.Context, token string) (*User, error) {
+	change, err := s.repo.TakeEmailChange(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(change.ExpiresAt) {
+		return nil, ErrInvalidEmailChange
+	}
+
+	user, err := s.repo.GetByID(ctx, change.UserID)
+	if err != nil {
+		return nil, err
+	}
+	user.Email = change.NewEmail
+
+	return s.repo.Update(ctx, user)
+}
+
+// RevokeTokens logs the user out everywhere, tokens issued before now stop working
+func (s *UserService) RevokeTokens(ctx context.Context, id int) error {
+	_, err := s.repo.RevokeTokens(ctx, id)
+	return err
+}
+
+// TokenGeneration is the generation new tokens are issued at, older tokens are revoked
+func (s *UserService) TokenGeneration(ctx context.Context, id int) (int, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	return user.TokenGeneration, nil
+}
+
+// Identities lists the external logins linked to the user
+func (s *UserService) Identities(ctx context.Context, id int) ([]*Identity, error) {
+	return s.repo.ListIdentities(ctx, id)
+}
@@ -0,0 +1,194 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/totp"
+)
+
+// totpIssuer is the account name shown in authenticator apps
+const totpIssuer = "expense-tracker-api"
+
+// recoveryCodeCount is how many single use codes are handed out when two-factor is enabled
+const recoveryCodeCount = 10
+
+// TOTPSetup is what an authenticator app needs, URI is usually shown as a QR code
+type TOTPSetup struct {
+	Secret string
+	URI    string
+}
+
+// SetupTOTP starts two-factor enrollment with a new secret, once the current password is verified.
+// It has no effect on login until EnableTOTP() confirms a code from the authenticator.
+func (s *UserService) SetupTOTP(ctx context.Context, id int, currentPassword string) (*TOTPSetup, error) {
+	user, err := s.verifyPassword(ctx, id, currentPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetTOTP(ctx, id)
+	if err != nil && !errors.Is(err, ErrTOTPNotEnrolled) {
+		return nil, err
+	}
+	if existing != nil && existing.Enabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.SaveTOTP(ctx, &TOTP{UserID: id, Secret: secret})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TOTPSetup{
+		Secret: secret,
+		URI:    totp.ProvisioningURI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// EnableTOTP turns on two-factor once a code from the new authenticator checks out.
+// It returns the recovery codes, which are only ever shown this once.
+func (s *UserService) EnableTOTP(ctx context.Context, id int, code string) ([]string, error) {
+	enrollment, err := s.repo.GetTOTP(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.Enabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	step, ok := totp.Validate(enrollment.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTOTP
+	}
+
+	enrollment.Enabled = true
+	enrollment.LastStep = step
+	if err := s.repo.SaveTOTP(ctx, enrollment); err != nil {
+		return nil, err
+	}
+
+	return s.newRecoveryCodes(ctx, id)
+}
+
+// DisableTOTP turns off two-factor, it needs the current password and a code or recovery code
+func (s *UserService) DisableTOTP(ctx context.Context, id int, currentPassword, code string) error {
+	if _, err := s.verifyPassword(ctx, id, currentPassword); err != nil {
+		return err
+	}
+
+	if err := s.checkEnabledTOTP(ctx, id, code); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteTOTP(ctx, id)
+}
+
+// RegenerateRecoveryCodes replaces every recovery code, i.e. when they have run out or leaked
+func (s *UserService) RegenerateRecoveryCodes(ctx context.Context, id int, code string) ([]string, error) {
+	if err := s.checkEnabledTOTP(ctx, id, code); err != nil {
+		return nil, err
+	}
+
+	return s.newRecoveryCodes(ctx, id)
+}
+
+// checkSecondFactor is the second step of Login(), users without two-factor enabled always pass
+func (s *UserService) checkSecondFactor(ctx context.Context, id int, code string) error {
+	enrollment, err := s.repo.GetTOTP(ctx, id)
+	if errors.Is(err, ErrTOTPNotEnrolled) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !enrollment.Enabled {
+		return nil
+	}
+
+	return s.checkCode(ctx, enrollment, code)
+}
+
+// checkEnabledTOTP checks a code for account changes, which need two-factor to be on already
+func (s *UserService) checkEnabledTOTP(ctx context.Context, id int, code string) error {
+	enrollment, err := s.repo.GetTOTP(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !enrollment.Enabled {
+		return ErrTOTPNotEnrolled
+	}
+
+	return s.checkCode(ctx, enrollment, code)
+}
+
+// checkCode accepts either a current authenticator code or an unused recovery code.
+// Both are consumed, so the same code can't be used twice.
+func (s *UserService) checkCode(ctx context.Context, enrollment *TOTP, code string) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ErrTOTPRequired
+	}
+
+	if step, ok := totp.Validate(enrollment.Secret, code, time.Now()); ok {
+		used, err := s.repo.UseTOTPStep(ctx, enrollment.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidTOTP
+		}
+		return nil
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, enrollment.UserID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTOTP
+	}
+	return nil
+}
+
+// recoveryEncoding keeps codes easy to read out and type
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCodes generates and stores a fresh set of recovery codes, formatted as "abcd-efgh"
+func (s *UserService) newRecoveryCodes(ctx context.Context, id int) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for range recoveryCodeCount {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(b))
+		code := raw[:4] + "-" + raw[4:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, id, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode is how recovery codes are stored, ignoring case, dashes, and spaces
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return hashToken(code)
+}
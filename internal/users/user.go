@@ -0,0 +1,93 @@
+// Package users implements registration, login, and profiles for the people using the API
+package users
+
+import (
+	"errors"
+	"time"
+)
+
+// User is an account that can log in.
+//
+// ID & CreatedAt is set in the repository layer
+type User struct {
+	ID              int       // id of the user for db
+	Email           string    // lowercase, unique
+	Name            string    // display name
+	PasswordHash    string    // bcrypt hash, never the password itself. Empty for external only accounts
+	IsAdmin         bool      // can see every tenant's records, only granted in the database
+	TokenGeneration int       // tokens issued at an older generation are revoked
+	Locale          string    // exports are formatted for it, i.e. "de". Empty for the plain formats
+	CreatedAt       time.Time // when the account was registered
+}
+
+// Identity is an external login linked to a user
+type Identity struct {
+	Issuer    string
+	Subject   string
+	CreatedAt time.Time
+}
+
+// These errors are used in the validation step of Register()
+var (
+	ErrInvalidEmail = errors.New("email address is not valid")
+	ErrWeakPassword = errors.New("password needs to be at least 8 characters")
+	ErrEmptyName    = errors.New("name cannot be empty")
+	ErrEmailTaken   = errors.New("email address is already registered")
+)
+
+// ErrInvalidCredentials is returned by Login() for an unknown email or wrong password.
+// They are deliberately not told apart so that registered emails can't be discovered.
+var ErrInvalidCredentials = errors.New("email or password is incorrect")
+
+// ErrUnverifiedEmail is returned by LoginExternal() when the provider has not verified the email,
+// since linking on an unverified email would let anyone claim an existing account
+var ErrUnverifiedEmail = errors.New("external identity does not have a verified email address")
+
+// ErrWrongPassword is returned when an account change is not confirmed with the current password.
+// Accounts created through an external provider have no password, so they always get this error
+var ErrWrongPassword = errors.New("current password is incorrect")
+
+// ErrInvalidLocale is returned by UpdateProfile() for a locale without a catalog, see i18n.Locales
+var ErrInvalidLocale = errors.New("locale is not supported, use one of en, de, es, fr")
+
+// ProfileUpdate is what UpdateProfile() changes, nil fields are left as they are
+type ProfileUpdate struct {
+	Name   *string
+	Locale *string // empty goes back to the plain formats
+}
+
+// ErrInvalidEmailChange is returned by ConfirmEmailChange() for an unknown, used, or expired token
+var ErrInvalidEmailChange = errors.New("email change token is invalid or expired")
+
+// ErrEmailChangeDisabled is returned by RequestEmailChange() when no Mailer is configured to send the token
+var ErrEmailChangeDisabled = errors.New("email changes are not enabled")
+
+// EmailChange is a pending change of email, waiting for the new address to be confirmed
+type EmailChange struct {
+	TokenHash string    // sha256 of the token sent to the new address, the token itself is never stored
+	UserID    int       // whose email changes
+	NewEmail  string    // lowercase
+	ExpiresAt time.Time // the token is rejected after this
+}
+
+// ErrNilPointer is returned when a nil pointer dereference is avoided
+var ErrNilPointer = errors.New("input pointer cannot be nil")
+
+// ErrUserNotFound is returned when an id does not have a user
+var ErrUserNotFound = errors.New("provided id does not have a user")
+
+// TOTP is a user's authenticator app, for two-factor login
+type TOTP struct {
+	UserID   int    // whose authenticator this is
+	Secret   string // base32 shared secret
+	Enabled  bool   // false until the first code is confirmed, login ignores it until then
+	LastStep int64  // the last time step a code was accepted for, codes at or before it are replays
+}
+
+// These errors are used by the two-factor methods and Login()
+var (
+	ErrTOTPRequired       = errors.New("two-factor code is required")
+	ErrInvalidTOTP        = errors.New("two-factor code is incorrect")
+	ErrTOTPNotEnrolled    = errors.New("two-factor authentication is not set up")
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+)
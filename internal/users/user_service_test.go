@@ -0,0 +1,827 @@
+package users_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nicholasss/expense-tracker-api/internal/totp"
+	"github.com/nicholasss/expense-tracker-api/internal/users"
+)
+
+// mockRepository implements the Respository interface to test the service layer
+type mockRepository struct {
+	lastID     int
+	db         map[int]*users.User
+	identities map[string]int
+	changes    map[string]*users.EmailChange
+	totps      map[int]*users.TOTP
+	recovery   map[int]map[string]bool
+
+	// mutex for safety
+	mux *sync.RWMutex
+}
+
+func (r *mockRepository) Create(ctx context.Context, user *users.User) (*users.User, error) {
+	if user == nil {
+		return nil, users.ErrNilPointer
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for _, existing := range r.db {
+		if existing.Email == user.Email {
+			return nil, users.ErrEmailTaken
+		}
+	}
+
+	r.lastID += 1
+	user.ID = r.lastID
+	user.CreatedAt = time.Now()
+	r.db[user.ID] = user
+
+	return user, nil
+}
+
+func (r *mockRepository) GetByID(ctx context.Context, id int) (*users.User, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	user, ok := r.db[id]
+	if !ok {
+		return nil, users.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (r *mockRepository) GetByEmail(ctx context.Context, email string) (*users.User, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	for _, user := range r.db {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, users.ErrUserNotFound
+}
+
+func (r *mockRepository) GetByIdentity(ctx context.Context, issuer, subject string) (*users.User, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	userID, ok := r.identities[issuer+"|"+subject]
+	if !ok {
+		return nil, users.ErrUserNotFound
+	}
+	return r.db[userID], nil
+}
+
+func (r *mockRepository) LinkIdentity(ctx context.Context, userID int, issuer, subject string) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.identities[issuer+"|"+subject] = userID
+	return nil
+}
+
+func (r *mockRepository) ListIdentities(ctx context.Context, userID int) ([]*users.Identity, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	identities := make([]*users.Identity, 0)
+	for key, id := range r.identities {
+		if id == userID {
+			issuer, subject, _ := strings.Cut(key, "|")
+			identities = append(identities, &users.Identity{Issuer: issuer, Subject: subject})
+		}
+	}
+	return identities, nil
+}
+
+func (r *mockRepository) Update(ctx context.Context, user *users.User) (*users.User, error) {
+	if user == nil {
+		return nil, users.ErrNilPointer
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if _, ok := r.db[user.ID]; !ok {
+		return nil, users.ErrUserNotFound
+	}
+	for _, existing := range r.db {
+		if existing.ID != user.ID && existing.Email == user.Email {
+			return nil, users.ErrEmailTaken
+		}
+	}
+
+	updated := *user
+	r.db[user.ID] = &updated
+	return &updated, nil
+}
+
+func (r *mockRepository) RevokeTokens(ctx context.Context, id int) (int, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	user, ok := r.db[id]
+	if !ok {
+		return 0, users.ErrUserNotFound
+	}
+	user.TokenGeneration += 1
+	return user.TokenGeneration, nil
+}
+
+func (r *mockRepository) CreateEmailChange(ctx context.Context, change *users.EmailChange) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for hash, existing := range r.changes {
+		if existing.UserID == change.UserID {
+			delete(r.changes, hash)
+		}
+	}
+	r.changes[change.TokenHash] = change
+	return nil
+}
+
+func (r *mockRepository) TakeEmailChange(ctx context.Context, tokenHash string) (*users.EmailChange, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	change, ok := r.changes[tokenHash]
+	if !ok {
+		return nil, users.ErrInvalidEmailChange
+	}
+	delete(r.changes, tokenHash)
+	return change, nil
+}
+
+func (r *mockRepository) GetTOTP(ctx context.Context, userID int) (*users.TOTP, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	enrollment, ok := r.totps[userID]
+	if !ok {
+		return nil, users.ErrTOTPNotEnrolled
+	}
+	copied := *enrollment
+	return &copied, nil
+}
+
+func (r *mockRepository) SaveTOTP(ctx context.Context, enrollment *users.TOTP) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	copied := *enrollment
+	r.totps[enrollment.UserID] = &copied
+	return nil
+}
+
+func (r *mockRepository) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	enrollment, ok := r.totps[userID]
+	if !ok || enrollment.LastStep >= step {
+		return false, nil
+	}
+	enrollment.LastStep = step
+	return true, nil
+}
+
+func (r *mockRepository) DeleteTOTP(ctx context.Context, userID int) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	delete(r.totps, userID)
+	delete(r.recovery, userID)
+	return nil
+}
+
+func (r *mockRepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.recovery[userID] = make(map[string]bool)
+	for _, hash := range codeHashes {
+		r.recovery[userID][hash] = true
+	}
+	return nil
+}
+
+func (r *mockRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if !r.recovery[userID][codeHash] {
+		return false, nil
+	}
+	delete(r.recovery[userID], codeHash)
+	return true, nil
+}
+
+// sentMailer keeps the last token sent to each address
+type sentMailer struct {
+	tokens map[string]string
+}
+
+func (m *sentMailer) SendEmailChange(ctx context.Context, to, token string) error {
+	m.tokens[to] = token
+	return nil
+}
+
+// setupTestService sets up a user service with one registered user: ada@example.com
+func setupTestService(t *testing.T) *users.UserService {
+	t.Helper()
+
+	return setupTestServiceWithMailer(t, &sentMailer{tokens: make(map[string]string)})
+}
+
+// setupTestServiceWithMailer is setupTestService sending account emails to mailer
+func setupTestServiceWithMailer(t *testing.T, mailer users.Mailer) *users.UserService {
+	t.Helper()
+
+	repo := &mockRepository{
+		db:         make(map[int]*users.User),
+		identities: make(map[string]int),
+		changes:    make(map[string]*users.EmailChange),
+		totps:      make(map[int]*users.TOTP),
+		recovery:   make(map[int]map[string]bool),
+		mux:        &sync.RWMutex{},
+	}
+	serv := users.NewService(repo, users.WithMailer(mailer))
+
+	_, err := serv.Register(t.Context(), "ada@example.com", "Ada", "correct horse battery")
+	if err != nil {
+		t.Fatalf("Unable to setup test service due to: %v", err)
+	}
+
+	return serv
+}
+
+func TestRegister(t *testing.T) {
+	testTable := []struct {
+		name          string
+		inputEmail    string
+		inputName     string
+		inputPassword string
+		expectError   bool
+		wantError     error
+		wantEmail     string
+	}{
+		{
+			name:          "valid-registration",
+			inputEmail:    "grace@example.com",
+			inputName:     "Grace",
+			inputPassword: "hopper1906",
+			expectError:   false,
+			wantError:     nil,
+			wantEmail:     "grace@example.com",
+		},
+		{
+			name:          "valid-email-is-lowercased",
+			inputEmail:    "  Grace@Example.COM ",
+			inputName:     "Grace",
+			inputPassword: "hopper1906",
+			expectError:   false,
+			wantError:     nil,
+			wantEmail:     "grace@example.com",
+		},
+		{
+			name:          "invalid-email",
+			inputEmail:    "grace-at-example",
+			inputName:     "Grace",
+			inputPassword: "hopper1906",
+			expectError:   true,
+			wantError:     users.ErrInvalidEmail,
+		},
+		{
+			name:          "invalid-short-password",
+			inputEmail:    "grace@example.com",
+			inputName:     "Grace",
+			inputPassword: "short",
+			expectError:   true,
+			wantError:     users.ErrWeakPassword,
+		},
+		{
+			name:          "invalid-empty-name",
+			inputEmail:    "grace@example.com",
+			inputName:     "   ",
+			inputPassword: "hopper1906",
+			expectError:   true,
+			wantError:     users.ErrEmptyName,
+		},
+		{
+			name:          "invalid-email-taken",
+			inputEmail:    "ADA@example.com",
+			inputName:     "Another Ada",
+			inputPassword: "hopper1906",
+			expectError:   true,
+			wantError:     users.ErrEmailTaken,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			serv := setupTestService(t)
+
+			gotUser, gotErr := serv.Register(t.Context(), testCase.inputEmail, testCase.inputName, testCase.inputPassword)
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("Register() got error: '%v', expected error: '%v'", gotErr, testCase.wantError)
+			}
+
+			// checking error type if its not nil
+			if gotErr != nil {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+				return
+			}
+
+			if gotUser.Email != testCase.wantEmail {
+				t.Errorf("got email: %q, want email: %q", gotUser.Email, testCase.wantEmail)
+			}
+			if gotUser.PasswordHash == testCase.inputPassword {
+				t.Errorf("password was stored without hashing")
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	testTable := []struct {
+		name          string
+		inputEmail    string
+		inputPassword string
+		expectError   bool
+		wantError     error
+	}{
+		{
+			name:          "valid-login",
+			inputEmail:    "ada@example.com",
+			inputPassword: "correct horse battery",
+			expectError:   false,
+			wantError:     nil,
+		},
+		{
+			name:          "valid-login-mixed-case-email",
+			inputEmail:    "Ada@Example.com",
+			inputPassword: "correct horse battery",
+			expectError:   false,
+			wantError:     nil,
+		},
+		{
+			name:          "invalid-wrong-password",
+			inputEmail:    "ada@example.com",
+			inputPassword: "incorrect horse battery",
+			expectError:   true,
+			wantError:     users.ErrInvalidCredentials,
+		},
+		{
+			name:          "invalid-unknown-email",
+			inputEmail:    "nobody@example.com",
+			inputPassword: "correct horse battery",
+			expectError:   true,
+			wantError:     users.ErrInvalidCredentials,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			serv := setupTestService(t)
+
+			gotUser, gotErr := serv.Login(t.Context(), testCase.inputEmail, testCase.inputPassword, "")
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("Login() got error: '%v', expected error: '%v'", gotErr, testCase.wantError)
+			}
+
+			// checking error type if its not nil
+			if gotErr != nil {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+				return
+			}
+
+			if gotUser.ID != 1 {
+				t.Errorf("got user id: %d, want user id: 1", gotUser.ID)
+			}
+		})
+	}
+}
+
+func TestLoginExternal(t *testing.T) {
+	testTable := []struct {
+		name          string
+		inputIdentity users.ExternalIdentity
+		expectError   bool
+		wantError     error
+		wantUserID    int
+	}{
+		{
+			name: "valid-links-existing-verified-email",
+			inputIdentity: users.ExternalIdentity{
+				Issuer: "https://accounts.example.com", Subject: "ada-sub", Email: "Ada@example.com", EmailVerified: true,
+			},
+			expectError: false,
+			wantError:   nil,
+			wantUserID:  1,
+		},
+		{
+			name: "valid-creates-new-user",
+			inputIdentity: users.ExternalIdentity{
+				Issuer: "https://accounts.example.com", Subject: "grace-sub", Email: "grace@example.com", EmailVerified: true, Name: "Grace",
+			},
+			expectError: false,
+			wantError:   nil,
+			wantUserID:  2,
+		},
+		{
+			name: "invalid-unverified-email",
+			inputIdentity: users.ExternalIdentity{
+				Issuer: "https://accounts.example.com", Subject: "mallory-sub", Email: "ada@example.com", EmailVerified: false,
+			},
+			expectError: true,
+			wantError:   users.ErrUnverifiedEmail,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			serv := setupTestService(t)
+
+			gotUser, gotErr := serv.LoginExternal(t.Context(), testCase.inputIdentity)
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("LoginExternal() got error: '%v', expected error: '%v'", gotErr, testCase.wantError)
+			}
+
+			// checking error type if its not nil
+			if gotErr != nil {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+				return
+			}
+
+			if gotUser.ID != testCase.wantUserID {
+				t.Errorf("got user id: %d, want user id: %d", gotUser.ID, testCase.wantUserID)
+			}
+
+			// the second login goes through the linked identity
+			again, err := serv.LoginExternal(t.Context(), testCase.inputIdentity)
+			if err != nil || again.ID != testCase.wantUserID {
+				t.Errorf("second LoginExternal() got: %+v, error: '%v'", again, err)
+			}
+
+			// external only accounts can't log in with a password
+			if testCase.wantUserID != 1 {
+				if _, err := serv.Login(t.Context(), gotUser.Email, "", ""); !errors.Is(err, users.ErrInvalidCredentials) {
+					t.Errorf("password Login() for external account got error: '%v'", err)
+				}
+			}
+		})
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	testTable := []struct {
+		name         string
+		inputCurrent string
+		inputNew     string
+		expectError  bool
+		wantError    error
+	}{
+		{
+			name:         "valid-change",
+			inputCurrent: "correct horse battery",
+			inputNew:     "battery staple horse",
+			expectError:  false,
+			wantError:    nil,
+		},
+		{
+			name:         "invalid-wrong-current-password",
+			inputCurrent: "not my password",
+			inputNew:     "battery staple horse",
+			expectError:  true,
+			wantError:    users.ErrWrongPassword,
+		},
+		{
+			name:         "invalid-weak-new-password",
+			inputCurrent: "correct horse battery",
+			inputNew:     "short",
+			expectError:  true,
+			wantError:    users.ErrWeakPassword,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			serv := setupTestService(t)
+
+			gotErr := serv.ChangePassword(t.Context(), 1, testCase.inputCurrent, testCase.inputNew)
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("ChangePassword() got error: '%v', expected error: '%v'", gotErr, testCase.wantError)
+			}
+
+			// checking error type if its not nil
+			if gotErr != nil {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+				return
+			}
+
+			// changing the password logs out everywhere
+			if generation, err := serv.TokenGeneration(t.Context(), 1); err != nil || generation != 1 {
+				t.Errorf("TokenGeneration() got: %d, error: '%v', want: 1", generation, err)
+			}
+
+			// only the new password logs in
+			if _, err := serv.Login(t.Context(), "ada@example.com", testCase.inputNew, ""); err != nil {
+				t.Errorf("Login() with new password got error: '%v'", err)
+			}
+			if _, err := serv.Login(t.Context(), "ada@example.com", testCase.inputCurrent, ""); !errors.Is(err, users.ErrInvalidCredentials) {
+				t.Errorf("Login() with old password got error: '%v', want error: '%v'", err, users.ErrInvalidCredentials)
+			}
+		})
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	serv := setupTestService(t)
+	name := func(s string) *string { return &s }
+
+	user, err := serv.UpdateProfile(t.Context(), 1, users.ProfileUpdate{Name: name("  Ada Lovelace ")})
+	if err != nil || user.Name != "Ada Lovelace" || user.Locale != "" {
+		t.Errorf("UpdateProfile() got: %+v, error: '%v'", user, err)
+	}
+
+	// the name is left as it is when only the locale changes
+	user, err = serv.UpdateProfile(t.Context(), 1, users.ProfileUpdate{Locale: name(" DE ")})
+	if err != nil || user.Name != "Ada Lovelace" || user.Locale != "de" {
+		t.Errorf("UpdateProfile() of the locale got: %+v, error: '%v'", user, err)
+	}
+
+	if _, err := serv.UpdateProfile(t.Context(), 1, users.ProfileUpdate{Name: name(" ")}); !errors.Is(err, users.ErrEmptyName) {
+		t.Errorf("UpdateProfile() with empty name got error: '%v', want error: '%v'", err, users.ErrEmptyName)
+	}
+	if _, err := serv.UpdateProfile(t.Context(), 1, users.ProfileUpdate{Locale: name("tlh")}); !errors.Is(err, users.ErrInvalidLocale) {
+		t.Errorf("UpdateProfile() with unsupported locale got error: '%v', want error: '%v'", err, users.ErrInvalidLocale)
+	}
+}
+
+func TestEmailChange(t *testing.T) {
+	mailer := &sentMailer{tokens: make(map[string]string)}
+	serv := setupTestServiceWithMailer(t, mailer)
+
+	if _, err := serv.Register(t.Context(), "grace@example.com", "Grace", "hopper1906"); err != nil {
+		t.Fatalf("Register() got error: '%v'", err)
+	}
+
+	testTable := []struct {
+		name          string
+		inputPassword string
+		inputEmail    string
+		expectError   bool
+		wantError     error
+	}{
+		{
+			name:          "invalid-wrong-password",
+			inputPassword: "not my password",
+			inputEmail:    "ada@example.org",
+			expectError:   true,
+			wantError:     users.ErrWrongPassword,
+		},
+		{
+			name:          "invalid-email",
+			inputPassword: "correct horse battery",
+			inputEmail:    "ada-at-example",
+			expectError:   true,
+			wantError:     users.ErrInvalidEmail,
+		},
+		{
+			name:          "invalid-email-taken",
+			inputPassword: "correct horse battery",
+			inputEmail:    "Grace@example.com",
+			expectError:   true,
+			wantError:     users.ErrEmailTaken,
+		},
+		{
+			name:          "valid-request",
+			inputPassword: "correct horse battery",
+			inputEmail:    "Ada@Example.org",
+			expectError:   false,
+			wantError:     nil,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			gotErr := serv.RequestEmailChange(t.Context(), 1, testCase.inputPassword, testCase.inputEmail)
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("RequestEmailChange() got error: '%v', expected error: '%v'", gotErr, testCase.wantError)
+			}
+
+			// checking error type if its not nil
+			if gotErr != nil {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+			}
+		})
+	}
+
+	// the email does not change until the token is confirmed
+	if user, _ := serv.GetProfile(t.Context(), 1); user.Email != "ada@example.com" {
+		t.Errorf("email changed before confirming: %q", user.Email)
+	}
+
+	token, ok := mailer.tokens["ada@example.org"]
+	if !ok {
+		t.Fatalf("no token was sent to the new address")
+	}
+
+	if _, err := serv.ConfirmEmailChange(t.Context(), "not-the-token"); !errors.Is(err, users.ErrInvalidEmailChange) {
+		t.Errorf("ConfirmEmailChange() with wrong token got error: '%v', want error: '%v'", err, users.ErrInvalidEmailChange)
+	}
+
+	user, err := serv.ConfirmEmailChange(t.Context(), token)
+	if err != nil || user.Email != "ada@example.org" {
+		t.Errorf("ConfirmEmailChange() got: %+v, error: '%v'", user, err)
+	}
+
+	if _, err := serv.ConfirmEmailChange(t.Context(), token); !errors.Is(err, users.ErrInvalidEmailChange) {
+		t.Errorf("ConfirmEmailChange() twice got error: '%v', want error: '%v'", err, users.ErrInvalidEmailChange)
+	}
+}
+
+func TestEmailChangeWithoutMailer(t *testing.T) {
+	serv := setupTestServiceWithMailer(t, nil)
+
+	err := serv.RequestEmailChange(t.Context(), 1, "correct horse battery", "ada@example.org")
+	if !errors.Is(err, users.ErrEmailChangeDisabled) {
+		t.Errorf("RequestEmailChange() without a mailer got error: '%v', want error: '%v'", err, users.ErrEmailChangeDisabled)
+	}
+}
+
+func TestRevokeTokens(t *testing.T) {
+	serv := setupTestService(t)
+
+	for want := 1; want <= 2; want++ {
+		if err := serv.RevokeTokens(t.Context(), 1); err != nil {
+			t.Fatalf("RevokeTokens() got error: '%v'", err)
+		}
+		if got, err := serv.TokenGeneration(t.Context(), 1); err != nil || got != want {
+			t.Errorf("TokenGeneration() got: %d, error: '%v', want: %d", got, err, want)
+		}
+	}
+
+	if err := serv.RevokeTokens(t.Context(), 99); !errors.Is(err, users.ErrUserNotFound) {
+		t.Errorf("RevokeTokens(99) got error: '%v', want error: '%v'", err, users.ErrUserNotFound)
+	}
+}
+
+// codeAt is the authenticator code offset steps from now
+func codeAt(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+
+	code, err := totp.Code(secret, totp.Step(time.Now())+offset)
+	if err != nil {
+		t.Fatalf("Code() got error: '%v'", err)
+	}
+	return code
+}
+
+func TestTwoFactor(t *testing.T) {
+	serv := setupTestService(t)
+
+	if _, err := serv.SetupTOTP(t.Context(), 1, "not my password"); !errors.Is(err, users.ErrWrongPassword) {
+		t.Errorf("SetupTOTP() with wrong password got error: '%v', want error: '%v'", err, users.ErrWrongPassword)
+	}
+
+	setup, err := serv.SetupTOTP(t.Context(), 1, "correct horse battery")
+	if err != nil {
+		t.Fatalf("SetupTOTP() got error: '%v'", err)
+	}
+	if !strings.HasPrefix(setup.URI, "otpauth://totp/") || !strings.Contains(setup.URI, setup.Secret) {
+		t.Errorf("SetupTOTP() got uri: %q", setup.URI)
+	}
+
+	// login ignores two-factor until a code is confirmed
+	if _, err := serv.Login(t.Context(), "ada@example.com", "correct horse battery", ""); err != nil {
+		t.Errorf("Login() before enabling got error: '%v'", err)
+	}
+
+	if _, err := serv.EnableTOTP(t.Context(), 1, "000000"); !errors.Is(err, users.ErrInvalidTOTP) {
+		t.Errorf("EnableTOTP() with wrong code got error: '%v', want error: '%v'", err, users.ErrInvalidTOTP)
+	}
+	recoveryCodes, err := serv.EnableTOTP(t.Context(), 1, codeAt(t, setup.Secret, 0))
+	if err != nil || len(recoveryCodes) != 10 {
+		t.Fatalf("EnableTOTP() got: %v, error: '%v'", recoveryCodes, err)
+	}
+
+	testTable := []struct {
+		name          string
+		inputPassword string
+		inputCode     string
+		expectError   bool
+		wantError     error
+	}{
+		{
+			name:          "invalid-missing-code",
+			inputPassword: "correct horse battery",
+			inputCode:     "",
+			expectError:   true,
+			wantError:     users.ErrTOTPRequired,
+		},
+		{
+			name:          "invalid-password-checked-first",
+			inputPassword: "not my password",
+			inputCode:     "",
+			expectError:   true,
+			wantError:     users.ErrInvalidCredentials,
+		},
+		{
+			name:          "invalid-replayed-code",
+			inputPassword: "correct horse battery",
+			inputCode:     codeAt(t, setup.Secret, 0),
+			expectError:   true,
+			wantError:     users.ErrInvalidTOTP,
+		},
+		{
+			name:          "valid-next-code",
+			inputPassword: "correct horse battery",
+			inputCode:     codeAt(t, setup.Secret, 1),
+			expectError:   false,
+			wantError:     nil,
+		},
+		{
+			name:          "valid-recovery-code",
+			inputPassword: "correct horse battery",
+			inputCode:     strings.ToUpper(recoveryCodes[0]),
+			expectError:   false,
+			wantError:     nil,
+		},
+		{
+			name:          "invalid-used-recovery-code",
+			inputPassword: "correct horse battery",
+			inputCode:     recoveryCodes[0],
+			expectError:   true,
+			wantError:     users.ErrInvalidTOTP,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, gotErr := serv.Login(t.Context(), "ada@example.com", testCase.inputPassword, testCase.inputCode)
+
+			// checking if we expect an error
+			if (gotErr != nil) != testCase.expectError {
+				t.Errorf("Login() got error: '%v', expected error: '%v'", gotErr, testCase.wantError)
+			}
+
+			// checking error type if its not nil
+			if gotErr != nil {
+				if !errors.Is(gotErr, testCase.wantError) {
+					t.Errorf("got error: %v, want error: %v", gotErr, testCase.wantError)
+				}
+			}
+		})
+	}
+
+	// regenerating replaces every earlier code
+	newCodes, err := serv.RegenerateRecoveryCodes(t.Context(), 1, recoveryCodes[1])
+	if err != nil {
+		t.Fatalf("RegenerateRecoveryCodes() got error: '%v'", err)
+	}
+	if _, err := serv.Login(t.Context(), "ada@example.com", "correct horse battery", recoveryCodes[2]); !errors.Is(err, users.ErrInvalidTOTP) {
+		t.Errorf("Login() with replaced recovery code got error: '%v', want error: '%v'", err, users.ErrInvalidTOTP)
+	}
+
+	if _, err := serv.SetupTOTP(t.Context(), 1, "correct horse battery"); !errors.Is(err, users.ErrTOTPAlreadyEnabled) {
+		t.Errorf("SetupTOTP() while enabled got error: '%v', want error: '%v'", err, users.ErrTOTPAlreadyEnabled)
+	}
+
+	if err := serv.DisableTOTP(t.Context(), 1, "correct horse battery", newCodes[0]); err != nil {
+		t.Fatalf("DisableTOTP() got error: '%v'", err)
+	}
+	if _, err := serv.Login(t.Context(), "ada@example.com", "correct horse battery", ""); err != nil {
+		t.Errorf("Login() after disabling got error: '%v'", err)
+	}
+}
@@ -0,0 +1,419 @@
+// The expense tracker web UI. It only calls the public API, with the token from signing in kept in local storage.
+// Everything user supplied is set with textContent, never as html.
+"use strict";
+
+const tokenKey = "expense-tracker-token";
+const pageSize = 50;
+
+// the API is served next to /ui, possibly under a reverse proxy's prefix
+const apiBase = location.pathname.replace(/\/ui(\/.*)?$/, "");
+
+const state = {
+  next: null, // path of the next page of expenses
+  editing: null, // expense being edited, null while adding
+};
+
+const $ = (id) => document.getElementById(id);
+
+function setStatus(message, isError) {
+  $("status").textContent = message || "";
+  $("status").classList.toggle("error", Boolean(isError));
+}
+
+// == API ==
+
+class APIError extends Error {
+  constructor(status, message) {
+    super(message);
+    this.status = status;
+  }
+}
+
+async function errorMessage(resp) {
+  try {
+    const body = await resp.json();
+    if (body.issues) {
+      return body.issues.join(", ");
+    }
+    if (body.error) {
+      return body.error;
+    }
+  } catch {
+    // not json, fall through to the status
+  }
+  return `${resp.status} ${resp.statusText}`;
+}
+
+async function api(method, path, body) {
+  const headers = {};
+  const token = localStorage.getItem(tokenKey);
+  if (token) {
+    headers.Authorization = `Bearer ${token}`;
+  }
+  if (body !== undefined) {
+    headers["Content-Type"] = "application/json";
+  }
+
+  const resp = await fetch(apiBase + path, {
+    method,
+    headers,
+    body: body === undefined ? undefined : JSON.stringify(body),
+  });
+  if (!resp.ok) {
+    throw new APIError(resp.status, await errorMessage(resp));
+  }
+  return resp;
+}
+
+// nextLink is the path in a Link: <...>; rel="next" header, or null on the last page
+function nextLink(resp) {
+  const match = /<([^>]+)>;\s*rel="next"/.exec(resp.headers.get("Link") || "");
+  return match ? match[1] : null;
+}
+
+// handleError sends the user to sign in when the API wants a token, and shows anything else
+function handleError(err) {
+  if (err instanceof APIError && err.status === 401) {
+    localStorage.removeItem(tokenKey);
+    show("login");
+    setStatus("Sign in to continue", true);
+    return;
+  }
+  setStatus(err.message, true);
+}
+
+// == Amounts and times ==
+
+// formatAmount shows cents as a decimal, i.e. 1250 as 12.50
+function formatAmount(cents) {
+  const sign = cents < 0 ? "-" : "";
+  const abs = Math.abs(cents);
+  return `${sign}${Math.floor(abs / 100)}.${String(abs % 100).padStart(2, "0")}`;
+}
+
+// parseAmount reads a decimal with up to two decimals into cents, null when it isn't one
+function parseAmount(value) {
+  const match = /^(\d+)(?:\.(\d{1,2}))?$/.exec(value.trim());
+  if (!match) {
+    return null;
+  }
+  return Number(match[1]) * 100 + Number((match[2] || "").padEnd(2, "0"));
+}
+
+// toLocalInput formats a time for a datetime-local input, which has no time zone
+function toLocalInput(iso) {
+  const date = new Date(iso);
+  const offset = date.getTimezoneOffset() * 60000;
+  return new Date(date.getTime() - offset).toISOString().slice(0, 16);
+}
+
+function formatTime(iso) {
+  return new Date(iso).toLocaleString(undefined, { dateStyle: "medium", timeStyle: "short" });
+}
+
+// == Expenses ==
+
+function expenseRow(expense) {
+  const row = document.createElement("tr");
+
+  const occured = document.createElement("td");
+  occured.textContent = formatTime(expense.occured_at);
+  const amount = document.createElement("td");
+  amount.className = "amount";
+  amount.textContent = formatAmount(expense.amount);
+  const description = document.createElement("td");
+  description.textContent = expense.description;
+
+  const actions = document.createElement("td");
+  const edit = document.createElement("button");
+  edit.type = "button";
+  edit.textContent = "Edit";
+  edit.addEventListener("click", () => startEditing(expense));
+  const remove = document.createElement("button");
+  remove.type = "button";
+  remove.textContent = "Delete";
+  remove.addEventListener("click", () => deleteExpense(expense));
+  actions.append(edit, remove);
+
+  row.append(occured, amount, description, actions);
+  return row;
+}
+
+// loadExpenses loads the first page, replacing the list, or the next page onto its end
+async function loadExpenses(more) {
+  const path = more ? state.next : `/expenses?limit=${pageSize}`;
+  try {
+    const resp = await api("GET", path);
+    const expenses = await resp.json();
+
+    if (!more) {
+      $("expense-rows").replaceChildren();
+    }
+    $("expense-rows").append(...expenses.map(expenseRow));
+    state.next = nextLink(resp);
+    $("load-more").hidden = !state.next;
+    if (!more && expenses.length === 0) {
+      setStatus("No expenses yet, add one above");
+    }
+  } catch (err) {
+    handleError(err);
+  }
+}
+
+function resetForm() {
+  state.editing = null;
+  $("expense-form").reset();
+  $("expense-form-title").textContent = "Add an expense";
+  $("cancel-edit").hidden = true;
+}
+
+function startEditing(expense) {
+  state.editing = expense;
+  const form = $("expense-form");
+  form.elements.amount.value = formatAmount(expense.amount);
+  form.elements.description.value = expense.description;
+  form.elements.occured_at.value = toLocalInput(expense.occured_at);
+  $("expense-form-title").textContent = `Edit expense ${expense.id}`;
+  $("cancel-edit").hidden = false;
+  form.elements.amount.focus();
+}
+
+async function saveExpense(event) {
+  event.preventDefault();
+  const form = event.target;
+
+  const amount = parseAmount(form.elements.amount.value);
+  if (amount === null || amount <= 0) {
+    setStatus("Amount must be a number like 12.50", true);
+    return;
+  }
+  const occuredAt = form.elements.occured_at.value ? new Date(form.elements.occured_at.value) : new Date();
+  const body = {
+    occured_at: occuredAt.toISOString(),
+    description: form.elements.description.value.trim(),
+    amount,
+  };
+
+  try {
+    if (state.editing) {
+      await api("PUT", `/expenses/${state.editing.id}`, body);
+      setStatus(`Updated expense ${state.editing.id}`);
+    } else {
+      const created = await (await api("POST", "/expenses", body)).json();
+      setStatus(`Added expense ${created.id}`);
+    }
+    resetForm();
+    await loadExpenses(false);
+  } catch (err) {
+    handleError(err);
+  }
+}
+
+async function deleteExpense(expense) {
+  if (!confirm(`Delete "${expense.description}" (${formatAmount(expense.amount)})?`)) {
+    return;
+  }
+  try {
+    await api("DELETE", `/expenses/${expense.id}`);
+    setStatus(`Deleted expense ${expense.id}`);
+    if (state.editing && state.editing.id === expense.id) {
+      resetForm();
+    }
+    await loadExpenses(false);
+  } catch (err) {
+    handleError(err);
+  }
+}
+
+// == Summary ==
+
+// periodLayouts trim each period's start to what the grouping is by
+const periodLayouts = { day: 10, month: 7, year: 4 };
+
+async function loadSummary(event) {
+  if (event) {
+    event.preventDefault();
+  }
+  const form = $("summary-form");
+  const query = new URLSearchParams({ range: form.elements.range.value });
+  if (form.elements.period.value.trim()) {
+    query.set("period", form.elements.period.value.trim());
+  }
+  if (form.elements.currency.value.trim()) {
+    query.set("currency", form.elements.currency.value.trim());
+  }
+
+  try {
+    const summary = await (await api("GET", `/expenses/summary?${query}`)).json();
+
+    const currency = summary.conversion ? ` ${summary.conversion.to}` : "";
+    let total = `${formatAmount(summary.amount)}${currency} over ${summary.count} expenses`;
+    if (summary.conversion && summary.conversion.stale) {
+      total += ", converted with stale rates";
+    }
+    $("summary-total").textContent = total;
+    $("summary-group").textContent = summary.group_by || "Period";
+
+    const rows = (summary.periods || []).map((period) => {
+      const row = document.createElement("tr");
+      const start = document.createElement("td");
+      start.textContent = period.start.slice(0, periodLayouts[summary.group_by] || 10);
+      const count = document.createElement("td");
+      count.className = "amount";
+      count.textContent = period.count;
+      const amount = document.createElement("td");
+      amount.className = "amount";
+      amount.textContent = formatAmount(period.amount);
+      row.append(start, count, amount);
+      return row;
+    });
+    $("summary-rows").replaceChildren(...rows);
+    setStatus("");
+  } catch (err) {
+    handleError(err);
+  }
+}
+
+// == Admin ==
+
+// cells builds a table row, the columns from numeric on are right aligned numbers
+function cells(values, numeric) {
+  const row = document.createElement("tr");
+  values.forEach((value, i) => {
+    const cell = document.createElement("td");
+    if (i >= numeric) {
+      cell.className = "amount";
+    }
+    cell.textContent = value;
+    row.append(cell);
+  });
+  return row;
+}
+
+function formatBytes(bytes) {
+  const units = ["B", "KiB", "MiB", "GiB"];
+  let unit = 0;
+  while (bytes >= 1024 && unit < units.length - 1) {
+    bytes /= 1024;
+    unit++;
+  }
+  return `${bytes.toFixed(unit === 0 ? 0 : 1)} ${units[unit]}`;
+}
+
+function formatDuration(seconds) {
+  const days = Math.floor(seconds / 86400);
+  const hours = Math.floor((seconds % 86400) / 3600);
+  const minutes = Math.floor((seconds % 3600) / 60);
+  return days > 0 ? `${days}d ${hours}h` : `${hours}h ${minutes}m`;
+}
+
+async function loadStats() {
+  try {
+    const stats = await (await api("GET", "/admin/stats")).json();
+
+    $("admin-overview").textContent =
+      `Up ${formatDuration(stats.uptime_seconds)} since ${formatTime(stats.started_at)}, ` +
+      `database is ${formatBytes(stats.database_bytes)}`;
+    // only reported with the webhook enabled
+    $("admin-outbox").hidden = !stats.outbox;
+    if (stats.outbox) {
+      $("admin-outbox").textContent =
+        `Webhook outbox has ${stats.outbox.pending} pending and ${stats.outbox.dead} dead events`;
+    }
+    $("admin-records").replaceChildren(
+      ...Object.entries(stats.records).map(([table, count]) => cells([table, count], 1)),
+    );
+    $("admin-recent").textContent = `Last ${stats.window_seconds / 60}m`;
+    $("admin-routes").replaceChildren(
+      ...stats.routes.map((route) =>
+        cells([route.route, route.requests, route.server_errors, route.recent, route.per_minute.toFixed(2)], 1),
+      ),
+    );
+    setStatus("");
+  } catch (err) {
+    if (err instanceof APIError && (err.status === 403 || err.status === 404)) {
+      setStatus("Server stats are only shown to admins, with auth enabled", true);
+      return;
+    }
+    handleError(err);
+  }
+}
+
+// == Signing in ==
+
+async function signIn(event) {
+  event.preventDefault();
+  const form = event.target;
+  const body = { email: form.elements.email.value, password: form.elements.password.value };
+  if (form.elements.code.value) {
+    body.code = form.elements.code.value;
+  }
+
+  try {
+    const login = await (await api("POST", "/users/login", body)).json();
+    if (!login.token) {
+      setStatus("This server doesn't issue tokens", true);
+      return;
+    }
+    localStorage.setItem(tokenKey, login.token);
+    form.reset();
+    setStatus(`Signed in as ${login.user.email}`);
+    route();
+  } catch (err) {
+    setStatus(err.message, true);
+  }
+}
+
+function useToken(event) {
+  event.preventDefault();
+  localStorage.setItem(tokenKey, event.target.elements.token.value.trim());
+  event.target.reset();
+  setStatus("");
+  route();
+}
+
+function signOut() {
+  localStorage.removeItem(tokenKey);
+  $("expense-rows").replaceChildren();
+  $("summary-rows").replaceChildren();
+  show("login");
+  setStatus("Signed out");
+}
+
+// == Navigation ==
+
+function show(section) {
+  for (const id of ["login", "expenses", "summary", "admin"]) {
+    $(id).hidden = id !== section;
+  }
+  $("sign-out").hidden = !localStorage.getItem(tokenKey);
+}
+
+// route shows the section in the url's hash, loading what it lists
+function route() {
+  if (location.hash === "#summary") {
+    show("summary");
+    loadSummary();
+    return;
+  }
+  if (location.hash === "#admin") {
+    show("admin");
+    loadStats();
+    return;
+  }
+  show("expenses");
+  loadExpenses(false);
+}
+
+document.addEventListener("DOMContentLoaded", () => {
+  $("login-form").addEventListener("submit", signIn);
+  $("token-form").addEventListener("submit", useToken);
+  $("sign-out").addEventListener("click", signOut);
+  $("expense-form").addEventListener("submit", saveExpense);
+  $("cancel-edit").addEventListener("click", resetForm);
+  $("load-more").addEventListener("click", () => loadExpenses(true));
+  $("summary-form").addEventListener("submit", loadSummary);
+  $("admin-refresh").addEventListener("click", loadStats);
+  window.addEventListener("hashchange", route);
+  route();
+});
@@ -0,0 +1,30 @@
+// Package webui embeds the single page app served at /ui, for listing, adding, and editing expenses
+// and viewing summaries without deploying a frontend. It is plain html, css, and js with no build step,
+// and only talks to the API, signing in like any other client
+package webui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the app, mounted with its /ui prefix stripped
+func Handler() http.Handler {
+	// only fails for a path that isn't embedded, which go:embed already checked
+	files, _ := fs.Sub(static, "static")
+	fileServer := http.FileServerFS(files)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		// the token is kept in local storage, so nothing but the app's own scripts may run
+		header.Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+		header.Set("X-Content-Type-Options", "nosniff")
+		// embedded files have no modification time, so browsers are told to check on every load
+		header.Set("Cache-Control", "no-cache")
+		fileServer.ServeHTTP(w, r)
+	})
+}
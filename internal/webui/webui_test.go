@@ -0,0 +1,69 @@
+package webui_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nicholasss/expense-tracker-api/internal/webui"
+)
+
+func TestHandler(t *testing.T) {
+	testTable := []struct {
+		name            string
+		inputPath       string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "valid-index",
+			inputPath:       "/ui/",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/html",
+			wantBody:        `<script src="app.js" defer></script>`,
+		},
+		{
+			name:            "valid-script",
+			inputPath:       "/ui/app.js",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/javascript",
+			wantBody:        "textContent",
+		},
+		{
+			name:            "valid-stylesheet",
+			inputPath:       "/ui/app.css",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/css",
+		},
+		{
+			name:       "invalid-missing-file",
+			inputPath:  "/ui/secrets.txt",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	// mounted the way the routes mount it
+	handler := http.StripPrefix("/ui", webui.Handler())
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, testCase.inputPath, nil))
+
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("got status: %d, want: %d", rec.Code, testCase.wantStatus)
+			}
+			if got := rec.Header().Get("Content-Security-Policy"); !strings.Contains(got, "default-src 'self'") {
+				t.Errorf("got content security policy: %q", got)
+			}
+			if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, testCase.wantContentType) {
+				t.Errorf("got content type: %q, want: %q", got, testCase.wantContentType)
+			}
+			if !strings.Contains(rec.Body.String(), testCase.wantBody) {
+				t.Errorf("body is missing %q", testCase.wantBody)
+			}
+		})
+	}
+}
@@ -1,21 +1,381 @@
 package routes
 
 import (
+	"expvar"
+	"maps"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/nicholasss/expense-tracker-api/config"
+	"github.com/nicholasss/expense-tracker-api/internal/audit"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/banksync"
+	"github.com/nicholasss/expense-tracker-api/internal/categories"
+	"github.com/nicholasss/expense-tracker-api/internal/errreport"
 	"github.com/nicholasss/expense-tracker-api/internal/expenses"
 	"github.com/nicholasss/expense-tracker-api/internal/handler"
+	"github.com/nicholasss/expense-tracker-api/internal/households"
+	"github.com/nicholasss/expense-tracker-api/internal/i18n"
+	"github.com/nicholasss/expense-tracker-api/internal/jobs"
+	"github.com/nicholasss/expense-tracker-api/internal/middleware"
+	"github.com/nicholasss/expense-tracker-api/internal/oidc"
+	"github.com/nicholasss/expense-tracker-api/internal/opstats"
+	"github.com/nicholasss/expense-tracker-api/internal/outbox"
+	"github.com/nicholasss/expense-tracker-api/internal/projections"
+	"github.com/nicholasss/expense-tracker-api/internal/respcache"
+	"github.com/nicholasss/expense-tracker-api/internal/search"
+	"github.com/nicholasss/expense-tracker-api/internal/users"
+	"github.com/nicholasss/expense-tracker-api/internal/webui"
 )
 
-func SetupRoutes(service expenses.Service) *gin.Engine {
-	h := handler.NewGinHandler(service)
+// Services holds the business layers that routes are registered for.
+// Optional subsystems are left nil when they are disabled in the config.
+type Services struct {
+	Expenses expenses.Service
+	Users    users.Service
+	Tokens   *auth.TokenIssuer
+	BankSync banksync.Service
+	Jobs     *jobs.Manager
+	OIDC     *oidc.Provider
+
+	Households households.Service
+	Categories categories.Service
+	Audit      audit.Service
+	Errors     *errreport.Reporter
+	Cache      *respcache.Cache
+	Stats      *opstats.Service
+	Outbox     *outbox.Dispatcher
+	Dashboards *projections.Projector
+	Search     *search.Index
+	Locales    *i18n.Matcher
+}
+
+// limit for the account routes that check a password or token, per client IP and per signed in user
+const (
+	accountRateLimitRequests = 10
+	accountRateLimitWindow   = 15 * time.Minute
+)
+
+// updateByBody deprecates PUT /expenses, with the id in the body, for PUT /expenses/:id
+var updateByBody = middleware.Deprecation{
+	Since:     time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC),
+	Successor: "/expenses/:id",
+}
+
+// Reloadable is the middleware that takes config reloads without a restart
+type Reloadable struct {
+	rateLimiter *middleware.RateLimiter
+	cors        *middleware.CORSPolicy
+}
+
+// Apply switches the rate limit and CORS policy over to the settings in cfg
+func (rl *Reloadable) Apply(cfg *config.Config) {
+	rl.rateLimiter.SetLimit(cfg.RateLimitRequests, cfg.RateLimitWindow)
+	rl.cors.Set(corsConfig(cfg))
+}
+
+// corsConfig picks the CORS settings out of cfg
+func corsConfig(cfg *config.Config) middleware.CORSConfig {
+	return middleware.CORSConfig{
+		AllowedOrigins: cfg.CORSAllowedOrigins,
+		AllowedMethods: cfg.CORSAllowedMethods,
+		AllowedHeaders: cfg.CORSAllowedHeaders,
+		MaxAge:         cfg.CORSMaxAge,
+	}
+}
+
+func SetupRoutes(cfg *config.Config, services Services) (*gin.Engine, *Reloadable) {
+	h := handler.NewGinHandler(services.Expenses)
+	h.MaxPageLimit = cfg.MaxPageSize
+	h.Categories = services.Categories
+
+	// gin decodes every JSON body with the same settings, unknown fields fail as `json: unknown field "name"`
+	binding.EnableDecoderDisallowUnknownFields = cfg.StrictJSON
+
+	// gin.Default() without its recovery, which dumps the whole request and sends no body
+	r := gin.New()
+
+	// gin believes X-Forwarded-For from anyone by default, which lets clients pick the IP they are rate limited on.
+	// config has already checked every entry, so an error here only falls back to trusting no one
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		_ = r.SetTrustedProxies(nil)
+	}
+	r.Use(middleware.AccessLog(middleware.AccessLogConfig{
+		JSON:      cfg.AccessLogFormat == "json",
+		Fields:    cfg.AccessLogFields,
+		SkipPaths: cfg.AccessLogSkipPaths,
+	}))
+
+	// wraps everything else, so every error response is translated, recovery's and the timeout's too
+	if services.Locales != nil {
+		r.Use(middleware.Localize(services.Locales))
+	}
+
+	// also wraps recovery, so panics are counted as the 500s they end up as
+	if services.Stats != nil {
+		r.Use(middleware.CountRequests(services.Stats))
+	}
+
+	// wraps recovery, so panics are reported along with every other 5xx
+	if services.Errors != nil {
+		r.Use(middleware.ReportErrors(services.Errors))
+	}
+	r.Use(middleware.Recovery())
+
+	// opt-in, and silent unless the log level is debug
+	if cfg.DebugLogBodies {
+		r.Use(middleware.DebugLog(middleware.DebugLogConfig{RedactFields: cfg.DebugLogRedactFields}))
+	}
+
+	// cancels the request context, so slow queries give their connections back
+	r.Use(middleware.Timeout(middleware.TimeoutConfig{Default: cfg.RequestTimeout, Routes: routeTimeouts(cfg)}))
+
+	// both are always installed so a reload can turn them on, they do nothing while unset
+	reloadable := &Reloadable{
+		rateLimiter: middleware.NewRateLimiter(cfg.RateLimitRequests, cfg.RateLimitWindow),
+		cors:        middleware.NewCORSPolicy(corsConfig(cfg)),
+	}
+
+	// before rate limiting, so preflight requests are answered without counting against clients
+	r.Use(reloadable.cors.Middleware())
+	r.Use(reloadable.rateLimiter.Middleware())
+
+	if services.Audit != nil {
+		r.Use(middleware.Audit(services.Audit))
+	}
+
+	// account routes are always public, login only issues tokens when they are configured
+	uh := handler.NewUserHandler(services.Users, services.Tokens, services.Audit)
+
+	// password checks and confirmation tokens can be guessed at, so they get a much tighter limit
+	accountLimiter := middleware.NewRateLimiter(accountRateLimitRequests, accountRateLimitWindow).Middleware()
+
+	// signed in guesses are also counted per user, so spreading them over many addresses does not help
+	userLimiter := middleware.NewRateLimiter(accountRateLimitRequests, accountRateLimitWindow).MiddlewareBy(middleware.KeyByUser)
+
+	r.POST("/users", uh.Register)
+	r.POST("/users/login", accountLimiter, uh.Login)
+
+	r.POST("/users/email/confirm", accountLimiter, uh.ConfirmEmailChange)
+
+	// scoped tokens are for scripts and dashboards, they never reach the account routes
+	requireAccount := middleware.RequireScope(auth.ScopeAccount)
+
+	if services.Tokens != nil {
+		requireAuth := middleware.RequireAuth(services.Tokens, services.Users)
+		account := r.Group("", requireAuth, requireAccount)
+
+		account.GET("/users/me", uh.GetMe)
+		account.PATCH("/users/me", uh.UpdateMe)
+		account.PUT("/users/me/password", accountLimiter, userLimiter, uh.ChangePassword)
+		account.POST("/users/me/email", accountLimiter, userLimiter, uh.RequestEmailChange)
+		account.POST("/users/me/logout-all", uh.LogoutEverywhere)
+		account.POST("/users/me/tokens", uh.IssueToken)
+
+		account.POST("/users/me/2fa/setup", accountLimiter, userLimiter, uh.SetupTOTP)
+		account.POST("/users/me/2fa/enable", accountLimiter, userLimiter, uh.EnableTOTP)
+		account.POST("/users/me/2fa/disable", accountLimiter, userLimiter, uh.DisableTOTP)
+		account.POST("/users/me/2fa/recovery-codes", accountLimiter, userLimiter, uh.RegenerateRecoveryCodes)
+
+		dh := handler.NewDataExportHandler(services.Users, services.Expenses, services.Households, services.Audit)
+		account.GET("/me/export", dh.ExportMe)
+	}
+
+	// external login always ends with one of our tokens
+	if services.OIDC != nil && services.Tokens != nil {
+		oh := handler.NewOIDCHandler(services.OIDC, services.Users, services.Tokens, services.Audit)
+
+		r.GET("/auth/oidc/login", oh.Login)
+		r.GET("/auth/oidc/callback", oh.Callback)
+	}
+
+	// everything touching expenses needs a user once auth is enabled
+	protected := r.Group("")
+	if cfg.AuthEnabled {
+		protected.Use(middleware.RequireAuth(services.Tokens, services.Users))
+	}
+
+	// scopes only restrict scoped tokens, so these are no-ops with auth disabled
+	requireRead := middleware.RequireScope(auth.ScopeExpensesRead)
+	requireCreate := middleware.RequireScope(auth.ScopeExpensesCreate)
+	requireWrite := middleware.RequireScope(auth.ScopeExpensesWrite)
+	requireSummaries := middleware.RequireScope(auth.ScopeSummariesRead)
+
+	// hot reads are answered from memory while cached, it does nothing with the cache off
+	cacheResponses := func(c *gin.Context) {}
+	if services.Cache != nil {
+		cacheResponses = middleware.CacheResponses(services.Cache)
+	}
+
+	protected.GET("/expenses", requireRead, cacheResponses, h.GetAllExpenses)
+	protected.GET("/expenses/summary", requireSummaries, cacheResponses, h.GetSummary)
+	protected.GET("/expenses/changes", requireRead, h.GetChanges)
+	protected.GET("/expenses/:id", requireRead, h.GetExpenseByID)
+	if services.Dashboards != nil {
+		protected.GET("/expenses/dashboard", requireSummaries, handler.NewDashboardHandler(services.Dashboards).GetDashboard)
+	}
+	if services.Search != nil {
+		sch := handler.NewSearchHandler(services.Search)
+		sch.MaxPageLimit = cfg.MaxPageSize
+
+		protected.GET("/expenses/search", requireRead, sch.SearchExpenses)
+	}
+	protected.POST("/expenses", requireCreate, h.CreateExpense)
+	protected.POST("/expenses/parse", requireCreate, h.ParseExpense)
+	// a batch of offline edits can create expenses as well as change and delete them
+	protected.POST("/expenses/mutations", requireCreate, requireWrite, h.ApplyMutations)
+	protected.POST("/quick", requireCreate, h.QuickAdd)
+	protected.PUT("/expenses/:id", requireWrite, h.UpdateExpense)
+	protected.PUT("/expenses", requireWrite, middleware.Deprecated(updateByBody), h.UpdateExpenseByBody)
+	protected.DELETE("/expenses/:id", requireWrite, h.DeleteExpense)
+
+	// every book has its own, they are shared by a household like its expenses
+	if services.Categories != nil {
+		ch := handler.NewCategoryHandler(services.Categories)
+
+		protected.GET("/categories", requireRead, ch.List)
+		protected.POST("/categories", requireWrite, ch.Create)
+		protected.PUT("/categories/:id", requireWrite, ch.Rename)
+		protected.DELETE("/categories/:id", requireWrite, ch.Archive)
+	}
+
+	// the same lists in an envelope with their paging, rather than as bare arrays
+	v1 := protected.Group("/v1")
+	v1.GET("/expenses", requireRead, cacheResponses, h.ListExpensesPage)
+	if services.Categories != nil {
+		v1.GET("/categories", requireRead, handler.NewCategoryHandler(services.Categories).ListPage)
+	}
+
+	// cross-tenant listing and households only exist once there are tenants
+	if cfg.AuthEnabled {
+		protected.GET("/admin/expenses", requireAccount, middleware.RequireAdmin(services.Users), h.GetAllOwnersExpenses)
+		protected.POST("/admin/users/:id/revoke-tokens", requireAccount, middleware.RequireAdmin(services.Users), uh.RevokeUserTokens)
+
+		// changed levels last until a restart or a config reload
+		lh := handler.NewLogLevelHandler()
+		protected.GET("/admin/loglevel", requireAccount, middleware.RequireAdmin(services.Users), lh.GetLogLevel)
+		protected.PUT("/admin/loglevel", requireAccount, middleware.RequireAdmin(services.Users), lh.SetLogLevel)
+
+		if services.Stats != nil {
+			sh := handler.NewStatsHandler(services.Stats)
+
+			protected.GET("/admin/stats", requireAccount, middleware.RequireAdmin(services.Users), sh.GetStats)
+		}
+
+		if services.Outbox != nil {
+			obh := handler.NewOutboxHandler(services.Outbox)
+
+			protected.GET("/admin/outbox/dead", requireAccount, middleware.RequireAdmin(services.Users), obh.GetDead)
+			protected.POST("/admin/outbox/:id/retry", requireAccount, middleware.RequireAdmin(services.Users), obh.Retry)
+		}
+
+		if services.Audit != nil {
+			ah := handler.NewAuditHandler(services.Audit)
+
+			protected.GET("/admin/audit", requireAccount, middleware.RequireAdmin(services.Users), ah.GetEvents)
+
+			// only offered with auditing on, since every impersonated request has to be on record
+			protected.POST("/admin/users/:id/impersonate", requireAccount, middleware.RequireAdmin(services.Users), uh.Impersonate)
+		}
+
+		if services.Households != nil {
+			hh := handler.NewHouseholdHandler(services.Households)
+
+			protected.POST("/households", requireAccount, hh.Create)
+			protected.GET("/households/me", requireAccount, hh.Get)
+			protected.POST("/households/me/members", requireAccount, hh.AddMember)
+			protected.DELETE("/households/me/members/:user_id", requireAccount, hh.RemoveMember)
+			protected.GET("/households/me/contributions", requireSummaries, hh.GetContributions)
+			protected.GET("/households/me/balances", requireSummaries, hh.GetBalances)
+			protected.GET("/households/me/settlements", requireRead, hh.GetSettlements)
+			protected.POST("/households/me/settlements", requireWrite, hh.Settle)
+		}
+	}
+
+	// streamed straight to the client, so unlike the other exports it needs no job worker
+	eh := handler.NewExportHandler(services.Expenses, services.Jobs)
+	eh.Profiles = services.Users
+	protected.GET("/exports/expenses.csv", requireRead, eh.StreamCSV)
+
+	if services.Jobs != nil {
+		jh := handler.NewJobHandler(services.Jobs)
+
+		protected.POST("/exports", requireRead, eh.StartExport)
+		protected.GET("/jobs/:id", requireRead, jh.GetJob)
+		protected.GET("/jobs/:id/result", requireRead, jh.GetJobResult)
+	}
+
+	if services.BankSync != nil {
+		bh := handler.NewBankSyncHandler(services.BankSync)
+
+		// the bank account is the operator's, so once there are tenants only admins see and book its transactions
+		bank := protected.Group("/bank")
+		if cfg.AuthEnabled {
+			bank.Use(requireAccount, middleware.RequireAdmin(services.Users))
+		}
+
+		bank.POST("/sync", requireWrite, bh.SyncNow)
+		bank.GET("/drafts", requireRead, bh.GetPendingDrafts)
+		bank.POST("/drafts/:id/confirm", requireWrite, bh.ConfirmDraft)
+		bank.DELETE("/drafts/:id", requireWrite, bh.DismissDraft)
+	}
+
+	// signed by Slack rather than carrying one of our tokens, Slack users act as the user SLACK_USERS maps them to
+	if cfg.SlackSigningSecret != "" {
+		slh := handler.NewSlackHandler(services.Expenses, cfg.SlackSigningSecret, cfg.SlackUsers, cfg.AuthEnabled)
+
+		r.POST("/slack/commands", slh.Command)
+	}
+
+	// the app itself is public, it asks for a token and sends it along with every API call
+	if cfg.UIEnabled {
+		r.GET("/ui/*filepath", gin.WrapH(http.StripPrefix("/ui", webui.Handler())))
+	}
+
+	if cfg.PprofToken != "" {
+		registerPprof(r, cfg.PprofToken)
+	}
+
+	return r, reloadable
+}
+
+// streamingRoutes run for as long as the client asks, so they have no timeout unless one is configured
+var streamingRoutes = []string{"GET /debug/pprof/profile", "GET /debug/pprof/trace", "GET /exports/expenses.csv"}
+
+// routeTimeouts are the configured per route timeouts, with the streaming routes turned off
+func routeTimeouts(cfg *config.Config) map[string]time.Duration {
+	timeouts := maps.Clone(cfg.RouteTimeouts)
+	if timeouts == nil {
+		timeouts = make(map[string]time.Duration)
+	}
+	for _, route := range streamingRoutes {
+		if _, ok := timeouts[route]; !ok {
+			timeouts[route] = 0
+		}
+	}
+	return timeouts
+}
+
+// registerPprof serves net/http/pprof under /debug/pprof, for profiling a live server, and the expvar
+// counters under /debug/vars. Profiles expose memory contents, so the routes sit behind their own token
+// rather than a user login.
+func registerPprof(r *gin.Engine, token string) {
+	r.GET("/debug/vars", middleware.RequireStaticToken(token), gin.WrapH(expvar.Handler()))
 
-	r := gin.Default()
+	debug := r.Group("/debug/pprof", middleware.RequireStaticToken(token))
 
-	r.GET("/expenses", h.GetAllExpenses)
-	r.GET("/expenses/:id", h.GetExpenseByID)
-	r.POST("/expenses", h.CreateExpense)
-	r.PUT("/expenses", h.UpdateExpense)
-	r.DELETE("/expenses/:id", h.DeleteExpense)
+	debug.GET("/", gin.WrapF(pprof.Index))
+	debug.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+	debug.GET("/profile", gin.WrapF(pprof.Profile))
+	debug.GET("/symbol", gin.WrapF(pprof.Symbol))
+	debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+	debug.GET("/trace", gin.WrapF(pprof.Trace))
 
-	return r
+	// heap, goroutine, allocs, block, mutex, and threadcreate
+	debug.GET("/:profile", func(c *gin.Context) {
+		pprof.Handler(c.Param("profile")).ServeHTTP(c.Writer, c.Request)
+	})
 }
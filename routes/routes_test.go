@@ -0,0 +1,328 @@
+package routes_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/nicholasss/expense-tracker-api/config"
+	"github.com/nicholasss/expense-tracker-api/internal/auth"
+	"github.com/nicholasss/expense-tracker-api/internal/banksync"
+	"github.com/nicholasss/expense-tracker-api/internal/categories"
+	"github.com/nicholasss/expense-tracker-api/internal/expenses"
+	"github.com/nicholasss/expense-tracker-api/internal/users"
+	"github.com/nicholasss/expense-tracker-api/routes"
+)
+
+// stubCategories creates whatever it is asked to, for tests about the request rather than the service
+type stubCategories struct{}
+
+func (stubCategories) List(ctx context.Context) ([]*categories.Category, error) {
+	return []*categories.Category{}, nil
+}
+
+func (stubCategories) Create(ctx context.Context, name string) (*categories.Category, error) {
+	return &categories.Category{ID: 1, Name: name, CreatedAt: time.Unix(0, 0)}, nil
+}
+
+func (stubCategories) Rename(ctx context.Context, id int, name string) (*categories.Category, error) {
+	return &categories.Category{ID: id, Name: name, CreatedAt: time.Unix(0, 0)}, nil
+}
+
+func (stubCategories) Archive(ctx context.Context, id int) error {
+	return nil
+}
+
+// stubBankSync has no drafts, for tests about who may reach the bank routes
+type stubBankSync struct{}
+
+func (stubBankSync) Sync(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (stubBankSync) PendingDrafts(ctx context.Context) ([]*banksync.Draft, error) {
+	return []*banksync.Draft{}, nil
+}
+
+func (stubBankSync) ConfirmDraft(ctx context.Context, id int) (*expenses.Expense, error) {
+	return nil, banksync.ErrDraftNotFound
+}
+
+func (stubBankSync) DismissDraft(ctx context.Context, id int) error {
+	return banksync.ErrDraftNotFound
+}
+
+// stubUsers only implements the lookups the auth middleware makes, the rest of users.Service is left nil
+type stubUsers struct {
+	users.Service
+	db map[int]*users.User
+}
+
+func (s stubUsers) GetProfile(ctx context.Context, id int) (*users.User, error) {
+	user, ok := s.db[id]
+	if !ok {
+		return nil, users.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s stubUsers) TokenGeneration(ctx context.Context, id int) (int, error) {
+	if _, ok := s.db[id]; !ok {
+		return 0, users.ErrUserNotFound
+	}
+	return 0, nil
+}
+
+// TestExpenseRoutes guards against an expense route going missing from the engine, every one
+// of them is needed whatever else is configured
+func TestExpenseRoutes(t *testing.T) {
+	testTable := []struct {
+		name  string
+		input *config.Config
+	}{
+		{
+			name:  "auth-disabled",
+			input: &config.Config{},
+		},
+		{
+			name:  "auth-enabled",
+			input: &config.Config{AuthEnabled: true},
+		},
+	}
+
+	wantRoutes := []string{
+		"GET /expenses",
+		"GET /expenses/summary",
+		"GET /expenses/:id",
+		"POST /expenses",
+		"POST /expenses/parse",
+		"POST /quick",
+		"PUT /expenses/:id",
+		"PUT /expenses",
+		"DELETE /expenses/:id",
+		"GET /exports/expenses.csv",
+		"GET /v1/expenses",
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r, _ := routes.SetupRoutes(testCase.input, routes.Services{})
+
+			registered := make(map[string]bool)
+			for _, route := range r.Routes() {
+				registered[route.Method+" "+route.Path] = true
+			}
+			for _, want := range wantRoutes {
+				if !registered[want] {
+					t.Errorf("route %s is not registered", want)
+				}
+			}
+		})
+	}
+}
+
+func TestStrictJSON(t *testing.T) {
+	testTable := []struct {
+		name           string
+		inputStrict    bool
+		inputBody      string
+		wantStatusCode int
+		wantError      string
+	}{
+		{
+			name:           "lenient-unknown-field",
+			inputStrict:    false,
+			inputBody:      `{"name":"Pets","colour":"red"}`,
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name:           "strict-known-fields",
+			inputStrict:    true,
+			inputBody:      `{"name":"Pets"}`,
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name:           "strict-unknown-field",
+			inputStrict:    true,
+			inputBody:      `{"name":"Pets","colour":"red"}`,
+			wantStatusCode: http.StatusBadRequest,
+			wantError:      `unknown field \"colour\"`,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	t.Cleanup(func() { binding.EnableDecoderDisallowUnknownFields = false })
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			cfg := &config.Config{StrictJSON: testCase.inputStrict}
+			r, _ := routes.SetupRoutes(cfg, routes.Services{Categories: stubCategories{}})
+
+			req := httptest.NewRequest(http.MethodPost, "/categories", strings.NewReader(testCase.inputBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != testCase.wantStatusCode {
+				t.Fatalf("expected status code %d, got %d: %s", testCase.wantStatusCode, w.Code, w.Body.String())
+			}
+			if testCase.wantError != "" && !strings.Contains(w.Body.String(), testCase.wantError) {
+				t.Errorf("expected error naming %q, got: %s", testCase.wantError, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestUpdateByBodyDeprecated points clients of PUT /expenses at the route replacing it
+func TestUpdateByBodyDeprecated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, _ := routes.SetupRoutes(&config.Config{}, routes.Services{})
+
+	// the headers are set before the body is read, so a bad request still carries them
+	req := httptest.NewRequest(http.MethodPut, "/expenses", strings.NewReader(`{`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Deprecation"); got == "" {
+		t.Errorf("expected a Deprecation header, got none")
+	}
+	if got, want := w.Header().Get("Link"), `</expenses/:id>; rel="successor-version"`; got != want {
+		t.Errorf("got Link header: %q, want: %q", got, want)
+	}
+}
+
+// TestForwardedForRateLimit guards the rate limit against clients that forge X-Forwarded-For
+func TestForwardedForRateLimit(t *testing.T) {
+	testTable := []struct {
+		name         string
+		inputTrusted []string
+		wantStatus   int
+	}{
+		{
+			name:       "invalid-forged-without-trusted-proxies",
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:         "invalid-forged-from-untrusted-proxy",
+			inputTrusted: []string{"10.0.0.1"},
+			wantStatus:   http.StatusTooManyRequests,
+		},
+		{
+			name:         "valid-forwarded-by-trusted-proxy",
+			inputTrusted: []string{"203.0.113.0/24"},
+			wantStatus:   http.StatusOK,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			cfg := &config.Config{RateLimitRequests: 2, RateLimitWindow: time.Hour, TrustedProxies: testCase.inputTrusted}
+			r, _ := routes.SetupRoutes(cfg, routes.Services{Categories: stubCategories{}})
+
+			// the same connection claims to be a new client every time
+			var w *httptest.ResponseRecorder
+			for _, forwarded := range []string{"198.51.100.1", "198.51.100.2", "198.51.100.3"} {
+				req := httptest.NewRequest(http.MethodGet, "/categories", nil)
+				req.RemoteAddr = "203.0.113.7:5000"
+				req.Header.Set("X-Forwarded-For", forwarded)
+				w = httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+			}
+
+			if w.Code != testCase.wantStatus {
+				t.Errorf("expected status code %d on the third request, got %d: %s", testCase.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestBankRoutesAdminOnly guards the operator's bank transactions from the other users once auth is enabled
+func TestBankRoutesAdminOnly(t *testing.T) {
+	tokens, err := auth.NewTokenIssuer([]byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	if err != nil {
+		t.Fatalf("unable to create issuer: %v", err)
+	}
+	services := routes.Services{
+		BankSync: stubBankSync{},
+		Tokens:   tokens,
+		Users: stubUsers{db: map[int]*users.User{
+			1: {ID: 1, Email: "admin@example.com", IsAdmin: true},
+			2: {ID: 2, Email: "ada@example.com"},
+		}},
+	}
+
+	testTable := []struct {
+		name       string
+		inputUser  int
+		method     string
+		target     string
+		wantStatus int
+	}{
+		{
+			name:       "valid-admin-lists-drafts",
+			inputUser:  1,
+			method:     http.MethodGet,
+			target:     "/bank/drafts",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid-user-lists-drafts",
+			inputUser:  2,
+			method:     http.MethodGet,
+			target:     "/bank/drafts",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "invalid-user-confirms-draft",
+			inputUser:  2,
+			method:     http.MethodPost,
+			target:     "/bank/drafts/1/confirm",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "invalid-user-dismisses-draft",
+			inputUser:  2,
+			method:     http.MethodDelete,
+			target:     "/bank/drafts/1",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "invalid-user-syncs",
+			inputUser:  2,
+			method:     http.MethodPost,
+			target:     "/bank/sync",
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	r, _ := routes.SetupRoutes(&config.Config{AuthEnabled: true}, services)
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			token, _, err := tokens.Issue(testCase.inputUser, 0)
+			if err != nil {
+				t.Fatalf("unable to issue token: %v", err)
+			}
+
+			req := httptest.NewRequest(testCase.method, testCase.target, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != testCase.wantStatus {
+				t.Errorf("expected status code %d, got %d: %s", testCase.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
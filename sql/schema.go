@@ -0,0 +1,73 @@
+// Package sql embeds the goose migrations in sql/schema, so the server knows which schema version it expects.
+// Go files can't live in sql/schema itself, since goose would take them for migrations
+package sql
+
+import (
+	"cmp"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//go:embed schema/*.sql
+var Migrations embed.FS
+
+// Migration is one file in sql/schema, named by its version, i.e. 14 for 00014_expense_rollups.sql
+type Migration struct {
+	Version int64
+	Name    string
+}
+
+// All are the migrations in the order goose applies them, oldest first
+func All() ([]Migration, error) {
+	names, err := fs.Glob(Migrations, "schema/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	all := make([]Migration, 0, len(names))
+	for _, name := range names {
+		name = path.Base(name)
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s is not named version_name.sql", name)
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s is not named version_name.sql", name)
+		}
+		all = append(all, Migration{Version: version, Name: name})
+	}
+
+	slices.SortFunc(all, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return all, nil
+}
+
+// LatestVersion is the version of the newest migration, from its 00012_name.sql file name
+func LatestVersion() (int64, error) {
+	all, err := All()
+	if err != nil || len(all) == 0 {
+		return 0, err
+	}
+	return all[len(all)-1].Version, nil
+}
+
+// Up is the up section of the named migration, i.e. "00014_expense_rollups.sql", for tests that build their tables by hand
+func Up(name string) (string, error) {
+	contents, err := Migrations.ReadFile(path.Join("schema", name))
+	if err != nil {
+		return "", err
+	}
+
+	_, up, ok := strings.Cut(string(contents), "-- +goose Up")
+	if !ok {
+		return "", fmt.Errorf("migration %s has no up section", name)
+	}
+	up, _, _ = strings.Cut(up, "-- +goose Down")
+
+	return up, nil
+}
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+create table bank_drafts (
+    id integer primary key,
+
+    -- which provider the transaction came from, and its id there
+    provider text not null,
+    external_id text not null,
+
+    -- time is stored as unix time with **only** second precision
+    created_at integer,
+    occured_at integer,
+
+    description text,
+
+    -- stored as cents, not dollars
+    amount integer,
+
+    -- pending, confirmed, or dismissed
+    status text not null default 'pending',
+
+    -- the expense created when the draft was confirmed
+    expense_id integer references expenses(id),
+
+    unique (provider, external_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table bank_drafts;
+-- +goose StatementEnd
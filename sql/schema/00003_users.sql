@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+create table users (
+    id integer primary key,
+
+    -- stored lowercase
+    email text not null unique,
+    name text not null,
+
+    -- bcrypt hash
+    password_hash text not null,
+
+    -- time is stored as unix time with **only** second precision
+    created_at integer
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table users;
+-- +goose StatementEnd
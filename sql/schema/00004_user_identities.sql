@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+create table user_identities (
+    -- external oidc identity, the issuer and subject are unique together
+    issuer text not null,
+    subject text not null,
+
+    user_id integer not null references users(id) on delete cascade,
+
+    -- time is stored as unix time with **only** second precision
+    created_at integer,
+
+    primary key (issuer, subject)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table user_identities;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- null for expenses created before auth was enabled, only visible without auth or to admins
+alter table expenses add column owner_id integer references users(id) on delete cascade;
+
+create index expenses_owner_id on expenses(owner_id);
+
+-- admins can list every tenant's expenses, granted by hand:
+--   update users set is_admin = 1 where email = '...';
+alter table users add column is_admin integer not null default 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table users drop column is_admin;
+
+drop index expenses_owner_id;
+
+alter table expenses drop column owner_id;
+-- +goose StatementEnd
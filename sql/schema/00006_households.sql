@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+create table households (
+    id integer primary key,
+
+    name text not null,
+
+    -- time is stored as unix time with **only** second precision
+    created_at integer
+);
+
+create table household_members (
+    household_id integer not null references households(id) on delete cascade,
+
+    -- a user can only be in one household at a time
+    user_id integer not null unique references users(id) on delete cascade,
+
+    -- owner or member
+    role text not null default 'member',
+
+    -- time is stored as unix time with **only** second precision
+    joined_at integer,
+
+    primary key (household_id, user_id)
+);
+
+-- the shared book an expense was created in, it stays there if the owner leaves
+alter table expenses add column household_id integer references households(id) on delete set null;
+
+create index expenses_household_id on expenses(household_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index expenses_household_id;
+
+alter table expenses drop column household_id;
+
+drop table household_members;
+
+drop table households;
+-- +goose StatementEnd
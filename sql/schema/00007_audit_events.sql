@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+create table audit_events (
+    id integer primary key,
+    type text not null,
+
+    -- no foreign key, events outlive the users they mention
+    user_id integer,
+    email text,
+
+    ip text,
+    user_agent text,
+    method text,
+    path text,
+    status integer,
+    detail text,
+
+    -- time is stored as unix time with **only** second precision
+    created_at integer not null
+);
+
+create index audit_events_created_at_idx on audit_events (created_at);
+create index audit_events_user_id_idx on audit_events (user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table audit_events;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+create table email_changes (
+    -- a user has at most one pending change, a new request replaces it
+    user_id integer primary key references users(id) on delete cascade,
+
+    -- sha256 of the emailed token, hex encoded
+    token_hash text not null unique,
+
+    -- stored lowercase
+    new_email text not null,
+
+    -- time is stored as unix time with **only** second precision
+    expires_at integer not null
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table email_changes;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- bumped to revoke every token the user holds, tokens carry the generation they were issued at
+alter table users add column token_generation integer not null default 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table users drop column token_generation;
+-- +goose StatementEnd
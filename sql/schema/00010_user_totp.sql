@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+create table user_totp (
+    -- a user has at most one authenticator, setting up again replaces it until it is enabled
+    user_id integer primary key references users(id) on delete cascade,
+
+    -- base32 shared secret, authenticator apps need it in the clear
+    secret text not null,
+
+    -- 0 until the first code is confirmed, login only asks for a code once it is 1
+    enabled integer not null default 0,
+
+    -- the last 30 second step a code was accepted for, so a code can't be replayed
+    last_step integer not null default 0,
+
+    -- time is stored as unix time with **only** second precision
+    created_at integer not null
+);
+
+create table user_recovery_codes (
+    user_id integer not null references users(id) on delete cascade,
+
+    -- sha256 of the normalized code, hex encoded. Each code is deleted once used
+    code_hash text not null,
+
+    primary key (user_id, code_hash)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table user_recovery_codes;
+drop table user_totp;
+-- +goose StatementEnd
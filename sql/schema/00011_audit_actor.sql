@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the admin acting on behalf of user_id, null when users act for themselves
+alter table audit_events add column actor_id integer;
+
+create index audit_events_actor_id_idx on audit_events (actor_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index audit_events_actor_id_idx;
+alter table audit_events drop column actor_id;
+-- +goose StatementEnd
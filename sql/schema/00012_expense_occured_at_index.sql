@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- lists and summaries filter and order by when expenses occured, id breaks ties between pages
+create index expenses_occured_at on expenses(occured_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index expenses_occured_at;
+-- +goose StatementEnd
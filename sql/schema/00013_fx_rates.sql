@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- only the latest rates are kept, each refresh replaces every row
+create table fx_rates (
+    -- units of currency worth one unit of base
+    base text not null,
+    currency text not null,
+    rate real not null,
+
+    -- time is stored as unix time with **only** second precision
+    as_of integer not null,
+    fetched_at integer not null,
+
+    primary key (base, currency)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table fx_rates;
+-- +goose StatementEnd
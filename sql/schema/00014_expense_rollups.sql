@@ -0,0 +1,90 @@
+-- +goose Up
+-- +goose StatementBegin
+-- totals per UTC day and month, kept up to date by the triggers below in the same transaction as every write,
+-- so summaries add up a row per period instead of every expense in it
+create table expense_rollups (
+    -- 'day' or 'month', and the unix time the period starts at
+    grain text not null,
+    period integer not null,
+
+    -- 0 rather than null, so each owner and household has a single row per period
+    owner_id integer not null,
+    household_id integer not null,
+
+    -- stored as cents, not dollars
+    amount integer not null,
+    count integer not null,
+
+    primary key (grain, period, owner_id, household_id)
+);
+
+insert into expense_rollups (grain, period, owner_id, household_id, amount, count)
+select
+    'day', unixepoch(occured_at, 'unixepoch', 'start of day'), ifnull(owner_id, 0), ifnull(household_id, 0),
+    sum(ifnull(amount, 0)), count(*)
+from expenses
+group by 2, 3, 4;
+
+insert into expense_rollups (grain, period, owner_id, household_id, amount, count)
+select
+    'month', unixepoch(occured_at, 'unixepoch', 'start of month'), ifnull(owner_id, 0), ifnull(household_id, 0),
+    sum(ifnull(amount, 0)), count(*)
+from expenses
+group by 2, 3, 4;
+
+create trigger expense_rollups_insert after insert on expenses
+begin
+    insert into expense_rollups (grain, period, owner_id, household_id, amount, count)
+    values
+        ('day', unixepoch(new.occured_at, 'unixepoch', 'start of day'), ifnull(new.owner_id, 0), ifnull(new.household_id, 0), ifnull(new.amount, 0), 1),
+        ('month', unixepoch(new.occured_at, 'unixepoch', 'start of month'), ifnull(new.owner_id, 0), ifnull(new.household_id, 0), ifnull(new.amount, 0), 1)
+    on conflict (grain, period, owner_id, household_id) do update set
+        amount = amount + excluded.amount,
+        count = count + excluded.count;
+end;
+
+-- also fires for the expenses deleted along with their owner
+create trigger expense_rollups_delete after delete on expenses
+begin
+    update expense_rollups set
+        amount = amount - ifnull(old.amount, 0),
+        count = count - 1
+    where
+        owner_id = ifnull(old.owner_id, 0) AND household_id = ifnull(old.household_id, 0)
+        AND ((grain = 'day' AND period = unixepoch(old.occured_at, 'unixepoch', 'start of day'))
+          OR (grain = 'month' AND period = unixepoch(old.occured_at, 'unixepoch', 'start of month')));
+
+    delete from expense_rollups where count = 0
+        AND owner_id = ifnull(old.owner_id, 0) AND household_id = ifnull(old.household_id, 0);
+end;
+
+create trigger expense_rollups_update after update of occured_at, amount, owner_id, household_id on expenses
+begin
+    update expense_rollups set
+        amount = amount - ifnull(old.amount, 0),
+        count = count - 1
+    where
+        owner_id = ifnull(old.owner_id, 0) AND household_id = ifnull(old.household_id, 0)
+        AND ((grain = 'day' AND period = unixepoch(old.occured_at, 'unixepoch', 'start of day'))
+          OR (grain = 'month' AND period = unixepoch(old.occured_at, 'unixepoch', 'start of month')));
+
+    delete from expense_rollups where count = 0
+        AND owner_id = ifnull(old.owner_id, 0) AND household_id = ifnull(old.household_id, 0);
+
+    insert into expense_rollups (grain, period, owner_id, household_id, amount, count)
+    values
+        ('day', unixepoch(new.occured_at, 'unixepoch', 'start of day'), ifnull(new.owner_id, 0), ifnull(new.household_id, 0), ifnull(new.amount, 0), 1),
+        ('month', unixepoch(new.occured_at, 'unixepoch', 'start of month'), ifnull(new.owner_id, 0), ifnull(new.household_id, 0), ifnull(new.amount, 0), 1)
+    on conflict (grain, period, owner_id, household_id) do update set
+        amount = amount + excluded.amount,
+        count = count + excluded.count;
+end;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop trigger expense_rollups_update;
+drop trigger expense_rollups_delete;
+drop trigger expense_rollups_insert;
+drop table expense_rollups;
+-- +goose StatementEnd
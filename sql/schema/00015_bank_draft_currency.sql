@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the currency the bank reported the transaction in, empty for drafts pulled before it was kept
+alter table bank_drafts add column currency text not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table bank_drafts drop column currency;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the zone occured_at happened in, an IANA name or an offset like +02:00, empty for expenses added before it was kept
+alter table expenses add column occured_zone text not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table expenses drop column occured_zone;
+-- +goose StatementEnd
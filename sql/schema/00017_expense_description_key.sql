@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the description folded for search, see expenses.FoldDescription. Existing rows are folded by the server on startup
+alter table expenses add column description_key text not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table expenses drop column description_key;
+-- +goose StatementEnd
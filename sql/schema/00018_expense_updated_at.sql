@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- when the expense was last created or updated, in unix seconds, for clients syncing the changes since they last did
+alter table expenses add column updated_at integer not null default 0;
+update expenses set updated_at = created_at;
+create index expenses_updated_at on expenses(updated_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index expenses_updated_at;
+alter table expenses drop column updated_at;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the categories of a book: a household's shared book, a user's own, or with neither the single book kept without auth
+create table categories (
+    id integer primary key,
+
+    -- 0 rather than null, so the unique index below treats the books without one as the same book
+    owner_id integer not null default 0,
+    household_id integer not null default 0,
+
+    name text not null,
+
+    -- archived categories stay on the expenses already in them, but can't be picked for new ones
+    archived integer not null default 0,
+
+    -- time is stored as unix time with **only** second precision
+    created_at integer
+);
+
+-- a book can't have two categories in use by the same name, in any case
+create unique index categories_book_name on categories(household_id, owner_id, name collate nocase) where archived = 0;
+
+-- uncategorized when null
+alter table expenses add column category_id integer references categories(id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table expenses drop column category_id;
+
+drop index categories_book_name;
+
+drop table categories;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+-- webhook events, written in the same transaction as the expense change they are about,
+-- so a change is never committed without its event or the other way around
+create table outbox (
+    id integer primary key,
+    event_type text not null,
+
+    -- the JSON body posted to the webhook
+    payload text not null,
+
+    attempts integer not null default 0,
+    last_error text not null default '',
+
+    -- time is stored as unix time with **only** second precision
+    created_at integer not null,
+    next_attempt_at integer not null,
+
+    -- null until delivered, or until given up on after the last attempt
+    delivered_at integer,
+    dead_at integer
+);
+
+-- the dispatcher only ever looks for events still waiting to be delivered
+create index outbox_pending_idx on outbox (next_attempt_at) where delivered_at is null and dead_at is null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index outbox_pending_idx;
+
+drop table outbox;
+-- +goose StatementEnd
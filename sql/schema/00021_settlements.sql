@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- payments between household members, paying back their share of the shared book
+create table settlements (
+    id integer primary key,
+    household_id integer not null references households(id) on delete cascade,
+
+    -- from_user_id paid to_user_id
+    from_user_id integer not null references users(id),
+    to_user_id integer not null references users(id),
+
+    -- in cents, always positive
+    amount integer not null,
+
+    -- time is stored as unix time with **only** second precision
+    created_at integer not null
+);
+
+create index settlements_household_id on settlements(household_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index settlements_household_id;
+
+drop table settlements;
+-- +goose StatementEnd
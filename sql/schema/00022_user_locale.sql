@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the locale exports are formatted for, i.e. 'de'. Empty keeps the plain RFC3339 dates and minor unit amounts
+alter table users add column locale text not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table users drop column locale;
+-- +goose StatementEnd
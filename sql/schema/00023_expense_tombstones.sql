@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the expenses that were deleted, so clients syncing changes learn about deletes as well as creates and updates.
+-- Only the book the expense was in is kept, for the same scope filter as the expenses
+create table expense_tombstones (
+    expense_id integer primary key,
+    owner_id integer,
+    household_id integer,
+
+    -- time is stored as unix time with **only** second precision
+    deleted_at integer not null
+);
+
+create index expense_tombstones_deleted_at on expense_tombstones(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index expense_tombstones_deleted_at;
+
+drop table expense_tombstones;
+-- +goose StatementEnd
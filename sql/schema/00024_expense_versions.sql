@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- counts the writes to an expense, offline clients send the version their edit was made to so edits made since aren't lost
+alter table expenses add column version integer not null default 1;
+
+-- the UUID a client gave an expense it created offline, so a batch sent twice only creates it once
+alter table expenses add column client_id text;
+create unique index expenses_client_id on expenses(client_id) where client_id is not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index expenses_client_id;
+alter table expenses drop column client_id;
+alter table expenses drop column version;
+-- +goose StatementEnd
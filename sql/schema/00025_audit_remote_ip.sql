@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the address the connection came from, ip is what X-Forwarded-For claims when it comes through a trusted proxy
+alter table audit_events add column remote_ip text not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table audit_events drop column remote_ip;
+-- +goose StatementEnd
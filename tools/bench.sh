@@ -0,0 +1,22 @@
+#!/usr/bin/env bash
+
+# run the benchmarks on this tree and on a base ref (main by default), then compare them with benchstat
+# usage: tools/bench.sh [base-ref]
+# needs benchstat: go install golang.org/x/perf/cmd/benchstat@latest
+
+set -euo pipefail
+
+base="${1:-main}"
+count="${BENCH_COUNT:-6}"
+out="$(mktemp -d)"
+trap 'git worktree remove --force "$out/base" 2>/dev/null || true; rm -rf "$out"' EXIT
+
+bench() {
+	go test ./... -run '^$' -bench . -benchmem -count "$count"
+}
+
+git worktree add --quiet --detach "$out/base" "$base"
+(cd "$out/base" && bench) > "$out/base.txt"
+bench > "$out/head.txt"
+
+benchstat "$out/base.txt" "$out/head.txt"